@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v50/github"
+)
+
+// ------------------------
+// Section: Action Definitions
+// ------------------------
+
+// inHouseActionsDir is the directory of a repository that holds its in-house actions, one per subdirectory.
+const inHouseActionsDir = ".github/actions"
+
+// actionDefinitionNames are the file names of an action definition, in order of preference.
+var actionDefinitionNames = []string{"action.yml", "action.yaml"}
+
+// ActionDefinitionFile is an action.yml file found in a repository.
+type ActionDefinitionFile struct {
+	RepoName string
+	Name     string // Directory under .github/actions, or the repository name for an action at its root
+	FilePath string
+	Content  string
+	Hash     string
+	BlobSHA  string
+}
+
+// actionDefinitionPath returns the path of an action's definition in a repository when it is named action.yml.
+// Definitions at other paths, such as the repository root, are recorded in the index's filenames section.
+func actionDefinitionPath(name string) string {
+	return path.Join(inHouseActionsDir, name, "action.yml")
+}
+
+// findActionDefinition returns the action definition among the entries of a directory listing, or nil.
+func findActionDefinition(entries []*github.RepositoryContent) *github.RepositoryContent {
+	for _, name := range actionDefinitionNames {
+		for _, entry := range entries {
+			if entry.GetType() == "file" && entry.GetName() == name {
+				return entry
+			}
+		}
+	}
+	return nil
+}
+
+// fetchActionDefinitions retrieves the action definitions of a repository: one at its root, which is
+// indexed under the repository name, and one in each subdirectory of .github/actions.
+func fetchActionDefinitions(client *github.Client, repo *github.Repository) ([]ActionDefinitionFile, error) {
+	ctx := context.Background()
+	owner := repo.GetOwner().GetLogin()
+	opts := &github.RepositoryContentGetOptions{Ref: getDefaultBranch(repo)}
+
+	listDir := func(dirPath string) ([]*github.RepositoryContent, error) {
+		_, entries, _, err := client.Repositories.GetContents(ctx, owner, repo.GetName(), dirPath, opts)
+		if err != nil && isNotFoundError(err) {
+			return nil, nil
+		}
+		return entries, err
+	}
+
+	type candidate struct {
+		name  string
+		entry *github.RepositoryContent
+	}
+	var candidates []candidate
+
+	rootEntries, err := listDir("")
+	if err != nil {
+		logErrorf("Error listing the root of repository '%s': %v", repo.GetName(), err)
+		return nil, err
+	}
+	if entry := findActionDefinition(rootEntries); entry != nil {
+		candidates = append(candidates, candidate{repo.GetName(), entry})
+	}
+
+	actionDirs, err := listDir(inHouseActionsDir)
+	if err != nil {
+		logErrorf("Error accessing %s in repository '%s': %v", inHouseActionsDir, repo.GetName(), err)
+		return nil, err
+	}
+	for _, dir := range actionDirs {
+		if dir.GetType() != "dir" {
+			continue
+		}
+		entries, err := listDir(dir.GetPath())
+		if err != nil {
+			logErrorf("Error accessing %s in repository '%s': %v", dir.GetPath(), repo.GetName(), err)
+			return nil, err
+		}
+		if entry := findActionDefinition(entries); entry != nil {
+			candidates = append(candidates, candidate{dir.GetName(), entry})
+		}
+	}
+
+	// As with workflows, files that fail verification are all collected before the repository fails
+	var definitions []ActionDefinitionFile
+	var integrityErrs []error
+	for _, c := range candidates {
+		logDebugf("Found action definition: %s in repository '%s'", c.entry.GetPath(), repo.GetName())
+		content, err := fetchBlobContent(client, owner, repo.GetName(), c.entry.GetSHA())
+		if err != nil {
+			logErrorf("Error fetching content for file '%s' in repository '%s': %v", c.entry.GetPath(), repo.GetName(), err)
+			err = withBlobPath(err, c.entry.GetPath())
+			if len(blobIntegrityFailures(err)) > 0 {
+				integrityErrs = append(integrityErrs, err)
+				continue
+			}
+			return nil, err
+		}
+		if content == "" {
+			logDebugf("Empty content for file '%s' in repository '%s'", c.entry.GetPath(), repo.GetName())
+			continue
+		}
+		definitions = append(definitions, ActionDefinitionFile{
+			RepoName: repo.GetName(),
+			Name:     c.name,
+			FilePath: c.entry.GetPath(),
+			Content:  content,
+			Hash:     computeHash([]byte(content)),
+			BlobSHA:  c.entry.GetSHA(),
+		})
+	}
+	if len(integrityErrs) > 0 {
+		return nil, errors.Join(integrityErrs...)
+	}
+
+	return definitions, nil
+}
+
+// updateActionDefinitionIndex maps a repository to an action definition hash in db/actions/<name>/index.yaml.
+func updateActionDefinitionIndex(dbPath string, definition ActionDefinitionFile) error {
+	definitionPath := filepath.Join(dbPath, "actions", definition.Name)
+	if err := os.MkdirAll(definitionPath, os.ModePerm); err != nil {
+		return err
+	}
+
+	indexPath := filepath.Join(definitionPath, "index.yaml")
+	index, err := readActionIndex(indexPath)
+	if err != nil {
+		return err
+	}
+
+	index.Repositories[definition.RepoName] = definition.Hash
+	recordBlobSHA(index, definition.Hash, definition.BlobSHA)
+	recordFilename(index, definition.RepoName, actionDefinitionPath(definition.Name), definition.FilePath)
+
+	if err := writeActionIndex(indexPath, index); err != nil {
+		return err
+	}
+
+	logDebugf("Updated action definition index for '%s' with repository '%s'", definition.Name, definition.RepoName)
+	return nil
+}
+
+// storeActionDefinitionVersion saves the action definition content under its hash.
+func storeActionDefinitionVersion(dbPath string, definition ActionDefinitionFile) error {
+	definitionPath := filepath.Join(dbPath, "actions", definition.Name)
+	if err := os.MkdirAll(versionsDir(definitionPath), os.ModePerm); err != nil {
+		return err
+	}
+	filePath := versionPath(definitionPath, definition.Hash)
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		logDebugf("Storing action definition '%s' under hash '%s'", definition.Name, definition.Hash)
+		return writeVersionFile(filePath, []byte(definition.Content))
+	}
+
+	logDebugf("Action definition with hash '%s' already exists. Skipping write.", definition.Hash)
+	return nil
+}
+
+// garbageCollectActionDefinitions removes unused action definition versions from the database.
+func garbageCollectActionDefinitions(dbPath string) error {
+	return garbageCollectIndexedFolders(filepath.Join(dbPath, "actions"), "action definition")
+}
+
+// garbageCollectIndexedFolders removes the stored versions that no repository maps to from each indexed
+// folder of a database directory such as actions, including nested ones. label names the kind of file in messages.
+func garbageCollectIndexedFolders(kindPath, label string) error {
+	names, err := walkIndexedFolders(kindPath)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		index, err := readActionIndex(filepath.Join(kindPath, filepath.FromSlash(name), "index.yaml"))
+		if err != nil {
+			logErrorf("Error reading index for %s '%s': %v", label, name, err)
+			continue
+		}
+		hashesInUse := make(map[string]bool)
+		for _, hash := range index.Repositories {
+			hashesInUse[hash] = true
+		}
+
+		storedPath := versionsDir(filepath.Join(kindPath, filepath.FromSlash(name)))
+		files, err := os.ReadDir(storedPath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			logErrorf("Error reading %s directory '%s': %v", label, name, err)
+			continue
+		}
+		for _, file := range files {
+			if file.IsDir() || file.Name() == "index.yaml" || file.Name() == "README.md" || isJSONCopyName(file.Name()) {
+				continue
+			}
+			if !hashesInUse[file.Name()] {
+				logDebugf("Removing unused %s '%s' from '%s'", label, file.Name(), name)
+				_ = os.Remove(filepath.Join(storedPath, file.Name()))
+			}
+		}
+	}
+	return nil
+}
+
+// generateActionDefinitionReadmeFiles creates a README.md file in each action definition directory
+// listing the repositories that use each version.
+func generateActionDefinitionReadmeFiles(dbPath, org string) error {
+	actionsPath := filepath.Join(dbPath, "actions")
+	names, err := readSubdirectories(actionsPath)
+	if err != nil {
+		return fmt.Errorf("failed to read actions directory: %v", err)
+	}
+
+	forEachParallel(names, func(name string) {
+		index, err := readActionIndex(filepath.Join(actionsPath, name, "index.yaml"))
+		if err != nil {
+			logErrorf("Error reading index for action definition '%s': %v", name, err)
+			return
+		}
+
+		hashToRepos := make(map[string][]string)
+		for repo, hash := range index.Repositories {
+			hashToRepos[hash] = append(hashToRepos[hash], repo)
+		}
+		hashes := make([]string, 0, len(hashToRepos))
+		for hash := range hashToRepos {
+			hashes = append(hashes, hash)
+		}
+		sort.Strings(hashes)
+
+		var markdownBuilder strings.Builder
+		markdownBuilder.WriteString(fmt.Sprintf("# Action - %s\n\n", name))
+		for _, hash := range hashes {
+			repos := hashToRepos[hash]
+			sort.Strings(repos)
+			markdownBuilder.WriteString(fmt.Sprintf("## %s\n\n", versionReference(filepath.Join(actionsPath, name), hash)))
+			for _, repo := range repos {
+				url := fmt.Sprintf("%s/%s/%s/blob/main/%s", githubWebURL, org, repo, index.fileName(repo, actionDefinitionPath(name)))
+				markdownBuilder.WriteString(fmt.Sprintf("- [%s](%s)\n", repo, url))
+			}
+			markdownBuilder.WriteString("\n")
+		}
+
+		if err := os.WriteFile(filepath.Join(actionsPath, name, "README.md"), []byte(markdownBuilder.String()), 0644); err != nil {
+			logErrorf("Error writing README.md for action definition '%s': %v", name, err)
+			return
+		}
+		logDebugf("Generated README.md for action definition '%s'", name)
+	})
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v50/github"
+)
+
+func TestFetchActionDefinitions(t *testing.T) {
+	t.Parallel()
+
+	rootContent := "name: Tools\nruns:\n  using: node20\n  main: index.js\n"
+	setupContent := "name: Setup\nruns:\n  using: composite\n  steps: []\n"
+	blobs := map[string]string{
+		computeBlobSHA([]byte(rootContent)):  rootContent,
+		computeBlobSHA([]byte(setupContent)): setupContent,
+	}
+	listings := map[string]string{
+		"/repos/example-org/tools/contents/": fmt.Sprintf(`[{"type":"file","name":"action.yaml","path":"action.yaml","sha":%q}]`, computeBlobSHA([]byte(rootContent))),
+		"/repos/example-org/tools/contents/.github/actions": `[{"type":"dir","name":"setup","path":".github/actions/setup"},` +
+			`{"type":"dir","name":"empty","path":".github/actions/empty"},{"type":"file","name":"README.md","path":".github/actions/README.md"}]`,
+		"/repos/example-org/tools/contents/.github/actions/setup": fmt.Sprintf(`[{"type":"file","name":"action.yml","path":".github/actions/setup/action.yml","sha":%q}]`, computeBlobSHA([]byte(setupContent))),
+		"/repos/example-org/tools/contents/.github/actions/empty": `[]`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if listing, ok := listings[r.URL.Path]; ok {
+			fmt.Fprint(w, listing)
+			return
+		}
+		if sha, ok := strings.CutPrefix(r.URL.Path, "/repos/example-org/tools/git/blobs/"); ok && blobs[sha] != "" {
+			fmt.Fprintf(w, `{"sha":%q,"size":%d,"encoding":"base64","content":%q}`, sha, len(blobs[sha]), base64.StdEncoding.EncodeToString([]byte(blobs[sha])))
+			return
+		}
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	repo := &github.Repository{Name: github.String("tools"), Owner: &github.User{Login: github.String("example-org")}, DefaultBranch: github.String("main")}
+
+	definitions, err := fetchActionDefinitions(client, repo)
+	if err != nil {
+		t.Fatalf("fetchActionDefinitions returned error: %v", err)
+	}
+	if len(definitions) != 2 || definitions[0].Name != "tools" || definitions[0].FilePath != "action.yaml" ||
+		definitions[1].Name != "setup" || definitions[1].Content != setupContent {
+		t.Fatalf("unexpected action definitions: %+v", definitions)
+	}
+
+	dbPath := t.TempDir()
+	for _, definition := range definitions {
+		if err := updateActionDefinitionIndex(dbPath, definition); err != nil {
+			t.Fatalf("updateActionDefinitionIndex returned error: %v", err)
+		}
+		if err := storeActionDefinitionVersion(dbPath, definition); err != nil {
+			t.Fatalf("storeActionDefinitionVersion returned error: %v", err)
+		}
+	}
+
+	index, err := readActionIndex(filepath.Join(dbPath, "actions", "tools", "index.yaml"))
+	if err != nil {
+		t.Fatalf("readActionIndex returned error: %v", err)
+	}
+	if index.Repositories["tools"] != definitions[0].Hash || index.fileName("tools", actionDefinitionPath("tools")) != "action.yaml" {
+		t.Fatalf("unexpected index: %+v", index)
+	}
+
+	if err := generateActionDefinitionReadmeFiles(dbPath, "example-org"); err != nil {
+		t.Fatalf("generateActionDefinitionReadmeFiles returned error: %v", err)
+	}
+	readme, err := os.ReadFile(filepath.Join(dbPath, "actions", "setup", "README.md"))
+	if err != nil {
+		t.Fatalf("failed to read README.md: %v", err)
+	}
+	if !strings.Contains(string(readme), "- [tools](https://github.com/example-org/tools/blob/main/.github/actions/setup/action.yml)") {
+		t.Fatalf("unexpected README.md content:\n%s", readme)
+	}
+
+	stale := filepath.Join(dbPath, "actions", "setup", strings.Repeat("0", 64))
+	if err := os.WriteFile(stale, []byte("name: Old\n"), 0644); err != nil {
+		t.Fatalf("failed to write stale version: %v", err)
+	}
+	if err := garbageCollectActionDefinitions(dbPath); err != nil {
+		t.Fatalf("garbageCollectActionDefinitions returned error: %v", err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("expected the unused version to be removed, got err=%v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dbPath, "actions", "setup", definitions[1].Hash)); err != nil {
+		t.Fatalf("expected the version in use to be kept: %v", err)
+	}
+}
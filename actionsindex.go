@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Actions Reverse Index
+// ------------------------
+
+// ActionReference is a workflow file that references an action at a version.
+type ActionReference struct {
+	Repository string `yaml:"repository" json:"repository"`
+	Workflow   string `yaml:"workflow" json:"workflow"`
+	Version    string `yaml:"version" json:"version"`
+}
+
+// ActionsReverseIndex maps each third-party action to every workflow file that references it. It is
+// stored as actions.yaml so that questions such as which repositories use an action can be answered
+// without reading the stored workflow files.
+type ActionsReverseIndex struct {
+	Organization string                       `yaml:"organization"`
+	Actions      map[string][]ActionReference `yaml:"actions"`
+}
+
+// buildActionsReverseIndex collects the direct uses of third-party actions from the uses index. Uses
+// through composite actions are left out, since the workflow does not reference them itself. References
+// are sorted by repository, workflow, then version.
+func buildActionsReverseIndex(org string, usesIndex *ActionUsesIndex) *ActionsReverseIndex {
+	index := &ActionsReverseIndex{Organization: org, Actions: make(map[string][]ActionReference)}
+	for actionName, versions := range usesIndex.Actions {
+		if !isThirdPartyAction(actionName, org) {
+			continue
+		}
+
+		seen := make(map[ActionReference]bool)
+		var refs []ActionReference
+		for version, workflowRefs := range versions {
+			for _, workflowRef := range workflowRefs {
+				if len(workflowRef.Via) > 0 {
+					continue
+				}
+				ref := ActionReference{Repository: workflowRef.RepoName, Workflow: workflowRef.FilePath, Version: version}
+				if !seen[ref] {
+					seen[ref] = true
+					refs = append(refs, ref)
+				}
+			}
+		}
+		if len(refs) == 0 {
+			continue
+		}
+
+		sort.Slice(refs, func(i, j int) bool {
+			if refs[i].Repository != refs[j].Repository {
+				return refs[i].Repository < refs[j].Repository
+			}
+			if refs[i].Workflow != refs[j].Workflow {
+				return refs[i].Workflow < refs[j].Workflow
+			}
+			return refs[i].Version < refs[j].Version
+		})
+		index.Actions[actionName] = refs
+	}
+	return index
+}
+
+// writeActionsReverseIndex writes actions.yaml to the database.
+func writeActionsReverseIndex(dbPath, org string, usesIndex *ActionUsesIndex) error {
+	index := buildActionsReverseIndex(org, usesIndex)
+	data, err := yaml.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("error marshaling actions.yaml: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dbPath, "actions.yaml"), data, 0644); err != nil {
+		return fmt.Errorf("error writing actions.yaml: %v", err)
+	}
+
+	logInfof("Wrote actions.yaml with %d third-party actions", len(index.Actions))
+	return nil
+}
+
+// loadActionsReverseIndex reads actions.yaml from the database.
+func loadActionsReverseIndex(dbPath string) (*ActionsReverseIndex, error) {
+	data, err := os.ReadFile(filepath.Join(dbPath, "actions.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	var index ActionsReverseIndex
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("error parsing actions.yaml: %v", err)
+	}
+	return &index, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestWriteActionsReverseIndex(t *testing.T) {
+	t.Parallel()
+
+	usesIndex := &ActionUsesIndex{Actions: make(map[string]map[string][]WorkflowReference)}
+	addActionUses(usesIndex, extractActionUses(`jobs:
+  build:
+    steps:
+      - uses: actions/checkout@v4
+      - uses: codecov/codecov-action@v5
+      - uses: codecov/codecov-action@v5
+      - uses: example-org/shared-action@v1
+      - uses: ./local-action
+`, "repo-b", ".github/workflows/build.yml"))
+	addActionUses(usesIndex, extractActionUses("jobs:\n  test:\n    steps:\n      - uses: codecov/codecov-action@v4\n", "repo-a", ".github/workflows/test.yml"))
+	usesIndex.Actions["actions/cache"] = map[string][]WorkflowReference{
+		"v4": {{RepoName: "repo-a", FilePath: ".github/workflows/test.yml", Via: []string{"example-org/shared-action@v1"}}},
+	}
+
+	dbPath := t.TempDir()
+	if err := writeActionsReverseIndex(dbPath, "example-org", usesIndex); err != nil {
+		t.Fatalf("writeActionsReverseIndex returned error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dbPath, "actions.yaml"))
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+	var index ActionsReverseIndex
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+
+	if index.Organization != "example-org" || len(index.Actions) != 2 {
+		t.Fatalf("expected only direct third-party actions, got %+v", index.Actions)
+	}
+	codecov := index.Actions["codecov/codecov-action"]
+	want := []ActionReference{
+		{Repository: "repo-a", Workflow: ".github/workflows/test.yml", Version: "v4"},
+		{Repository: "repo-b", Workflow: ".github/workflows/build.yml", Version: "v5"},
+	}
+	if len(codecov) != len(want) || codecov[0] != want[0] || codecov[1] != want[1] {
+		t.Fatalf("unexpected codecov references: %+v", codecov)
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ------------------------
+// Section: Analyzer Selection
+// ------------------------
+
+// Analyzer is a named group of checks that can be turned on or off at runtime.
+type Analyzer struct {
+	Name        string
+	Description string
+	Rules       []string
+	// Scan checks a workflow file. It is nil for analyzers that run on other data, such as environments.
+	Scan func(content, repoName, filePath string) []Finding
+}
+
+// analyzers lists every analyzer in the order they run.
+var analyzers = []Analyzer{
+	{
+		Name:        "secrets",
+		Description: "Credentials committed in workflow files",
+		Rules:       []string{"github-token", "aws-access-key", "slack-token", "private-key", "hardcoded-secret"},
+		Scan:        scanForSecrets,
+	},
+	{
+		Name:        "compromised-actions",
+		Description: "Action versions involved in published supply-chain incidents",
+		Rules:       []string{"compromised-action"},
+		Scan:        scanForCompromisedActions,
+	},
+	{
+		Name:        "modernization",
+		Description: "Deprecated workflow commands, unmaintained actions, retired runtimes, and renamed inputs",
+		Rules:       []string{"deprecated-command", "deprecated-action", "outdated-action-runtime", "deprecated-input"},
+		Scan:        scanForObsoleteSyntax,
+	},
+	{
+		Name:        "permissions",
+		Description: "Effective GITHUB_TOKEN permissions of each job",
+		Rules:       []string{"write-all-permissions", "default-write-token", "actions-can-approve-pull-requests"},
+		Scan:        scanForWritePermissions,
+	},
+	{
+		Name:        "poisoning",
+		Description: "Caches and artifacts a fork can control that privileged workflows restore and execute",
+		Rules:       []string{"artifact-poisoning", "cache-poisoning"},
+		Scan:        scanForPoisoning,
+	},
+	{
+		Name:        "budgets",
+		Description: "Job count, step count, and timeout limits configured in budgets.yaml",
+		Rules:       []string{"job-count-budget", "step-count-budget", "timeout-budget"},
+		Scan:        scanForBudgetViolations,
+	},
+	{
+		Name:        "env-naming",
+		Description: "Environment variable names against the convention in env-naming.yaml",
+		Rules:       []string{"env-var-naming"},
+		Scan:        scanForEnvNaming,
+	},
+	{
+		Name:        "github-script",
+		Description: "Size of actions/github-script inline scripts and their privileged API calls",
+		Rules:       []string{"github-script-size", "github-script-privileged-api"},
+		Scan:        scanForGitHubScripts,
+	},
+	{
+		Name:        "freeze",
+		Description: "Action versions outside the approved set in freeze.yaml",
+		Rules:       []string{"unfrozen-action-version"},
+		Scan:        scanForUnfrozenVersions,
+	},
+	{
+		Name:        "environments",
+		Description: "Deployment protection rules of production environments",
+		Rules:       []string{"unprotected-production-environment"},
+	},
+	{
+		Name:        "rulesets",
+		Description: "Workflows required by organization rulesets",
+		Rules:       []string{"required-workflow-missing"},
+	},
+}
+
+// enabledAnalyzers holds the names of the analyzers selected for this run; nil means all of them.
+var enabledAnalyzers map[string]bool
+
+// lookupAnalyzer returns the analyzer with the given name.
+func lookupAnalyzer(name string) (Analyzer, bool) {
+	for _, analyzer := range analyzers {
+		if analyzer.Name == name {
+			return analyzer, true
+		}
+	}
+	return Analyzer{}, false
+}
+
+// parseAnalyzerSelection turns a comma-separated selection into the set of enabled analyzers.
+// Plain names are included and names prefixed with '-' are excluded; when nothing is included
+// explicitly, every analyzer starts out enabled. An empty selection returns nil, meaning all.
+func parseAnalyzerSelection(spec string) (map[string]bool, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	var includes, excludes []string
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, excluded := strings.CutPrefix(item, "-")
+		if _, ok := lookupAnalyzer(name); !ok {
+			return nil, fmt.Errorf("unknown analyzer '%s' (available: %s)", name, strings.Join(analyzerNames(), ", "))
+		}
+		if excluded {
+			excludes = append(excludes, name)
+		} else {
+			includes = append(includes, name)
+		}
+	}
+
+	enabled := make(map[string]bool)
+	if len(includes) == 0 {
+		for _, analyzer := range analyzers {
+			enabled[analyzer.Name] = true
+		}
+	}
+	for _, name := range includes {
+		enabled[name] = true
+	}
+	for _, name := range excludes {
+		delete(enabled, name)
+	}
+	return enabled, nil
+}
+
+// setAnalyzerSelection configures which analyzers run from a comma-separated selection.
+func setAnalyzerSelection(spec string) error {
+	enabled, err := parseAnalyzerSelection(spec)
+	if err != nil {
+		return err
+	}
+	enabledAnalyzers = enabled
+	if enabled != nil {
+		logInfof("Running analyzers: %s", strings.Join(selectedAnalyzerNames(), ", "))
+	}
+	return nil
+}
+
+// analyzerEnabled reports whether the named analyzer runs in this invocation.
+func analyzerEnabled(name string) bool {
+	return enabledAnalyzers == nil || enabledAnalyzers[name]
+}
+
+// analyzerNames returns the names of every analyzer.
+func analyzerNames() []string {
+	names := make([]string, 0, len(analyzers))
+	for _, analyzer := range analyzers {
+		names = append(names, analyzer.Name)
+	}
+	return names
+}
+
+// selectedAnalyzerNames returns the names of the enabled analyzers in run order.
+func selectedAnalyzerNames() []string {
+	var names []string
+	for _, analyzer := range analyzers {
+		if analyzerEnabled(analyzer.Name) {
+			names = append(names, analyzer.Name)
+		}
+	}
+	return names
+}
+
+// runAnalyzersCommand dispatches the analyzers subcommands and returns the process exit code.
+func runAnalyzersCommand(args []string) int {
+	if len(args) == 0 || args[0] != "list" {
+		fmt.Println("Usage: dotgithubindexer analyzers list [-format text|json]")
+		return 1
+	}
+
+	fs := flag.NewFlagSet("analyzers list", flag.ContinueOnError)
+	format := fs.String("format", formatText, "Output format: text or json")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 1
+	}
+	if err := checkFormat(*format, formatText, formatJSON); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+
+	if *format == formatJSON {
+		content, err := formatJSONDocument(buildAnalyzerListing())
+		if err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+		fmt.Print(content)
+		return 0
+	}
+	fmt.Print(formatAnalyzerList())
+	return 0
+}
+
+// AnalyzerListing describes an analyzer and its rules in the JSON output of 'analyzers list'.
+type AnalyzerListing struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Rules       []Rule `json:"rules"`
+}
+
+// buildAnalyzerListing describes every analyzer in run order with its rules sorted by ID.
+func buildAnalyzerListing() []AnalyzerListing {
+	listing := make([]AnalyzerListing, 0, len(analyzers))
+	for _, analyzer := range analyzers {
+		rules := append([]string(nil), analyzer.Rules...)
+		sort.Strings(rules)
+		entry := AnalyzerListing{Name: analyzer.Name, Description: analyzer.Description, Rules: []Rule{}}
+		for _, id := range rules {
+			rule, _ := lookupRule(id)
+			entry.Rules = append(entry.Rules, rule)
+		}
+		listing = append(listing, entry)
+	}
+	return listing
+}
+
+// formatAnalyzerList describes every analyzer and the rules it reports.
+func formatAnalyzerList() string {
+	var builder strings.Builder
+	for _, analyzer := range analyzers {
+		builder.WriteString(fmt.Sprintf("%s\n    %s\n", analyzer.Name, analyzer.Description))
+		rules := append([]string(nil), analyzer.Rules...)
+		sort.Strings(rules)
+		for _, id := range rules {
+			rule, _ := lookupRule(id)
+			builder.WriteString(fmt.Sprintf("    - %s (%s): %s\n", rule.ID, rule.Severity, rule.Description))
+		}
+		builder.WriteString("\n")
+	}
+	builder.WriteString("Rule suppression-missing-reason is always reported.\n")
+	return builder.String()
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseAnalyzerSelection(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		spec string
+		want string
+	}{
+		{"permissions,secrets", "secrets,permissions"},
+		{"-modernization", "secrets,compromised-actions,permissions,poisoning,budgets,env-naming,github-script,freeze,environments,rulesets"},
+		{"secrets,modernization,-modernization", "secrets"},
+		{" secrets , ", "secrets"},
+	}
+	for _, c := range cases {
+		enabled, err := parseAnalyzerSelection(c.spec)
+		if err != nil {
+			t.Fatalf("parseAnalyzerSelection(%q) returned error: %v", c.spec, err)
+		}
+		var names []string
+		for _, name := range analyzerNames() {
+			if enabled[name] {
+				names = append(names, name)
+			}
+		}
+		if got := strings.Join(names, ","); got != c.want {
+			t.Fatalf("parseAnalyzerSelection(%q) enabled %q, want %q", c.spec, got, c.want)
+		}
+	}
+
+	if enabled, err := parseAnalyzerSelection(""); err != nil || enabled != nil {
+		t.Fatalf("expected an empty selection to enable all analyzers, got %v, %v", enabled, err)
+	}
+	if _, err := parseAnalyzerSelection("secrets,linting"); err == nil || !strings.Contains(err.Error(), "linting") {
+		t.Fatalf("expected an unknown analyzer error, got %v", err)
+	}
+}
+
+func TestAnalyzerRulesAreInCatalog(t *testing.T) {
+	t.Parallel()
+
+	covered := make(map[string]string)
+	for _, analyzer := range analyzers {
+		for _, id := range analyzer.Rules {
+			if _, ok := lookupRule(id); !ok {
+				t.Fatalf("analyzer %q lists unknown rule %q", analyzer.Name, id)
+			}
+			if other, ok := covered[id]; ok {
+				t.Fatalf("rule %q is listed by both %q and %q", id, other, analyzer.Name)
+			}
+			covered[id] = analyzer.Name
+		}
+	}
+	for _, rule := range ruleCatalog {
+		if _, ok := covered[rule.ID]; !ok && rule.ID != "suppression-missing-reason" {
+			t.Fatalf("rule %q is not reported by any analyzer", rule.ID)
+		}
+	}
+
+	listing := buildAnalyzerListing()
+	if len(listing) != len(analyzers) || listing[0].Name != "secrets" || listing[0].Rules[0].ID != "aws-access-key" {
+		t.Fatalf("unexpected analyzer listing: %+v", listing)
+	}
+	document, err := formatJSONDocument(listing)
+	if err != nil {
+		t.Fatalf("formatJSONDocument returned error: %v", err)
+	}
+	if !strings.Contains(document, `"name": "environments"`) || !strings.Contains(document, `"id": "unprotected-production-environment"`) {
+		t.Fatalf("unexpected analyzer listing JSON:\n%s", document)
+	}
+
+	list := formatAnalyzerList()
+	if !strings.Contains(list, "permissions\n    Effective GITHUB_TOKEN permissions of each job\n    - actions-can-approve-pull-requests (medium):") {
+		t.Fatalf("unexpected analyzer list:\n%s", list)
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Workflow Annotations
+// ------------------------
+
+// AnnotationConfig is the contents of annotations.yaml, listing the comment keys to extract from workflows.
+type AnnotationConfig struct {
+	Keys []string `yaml:"keys"`
+}
+
+// annotationKeys holds the lower-cased annotation keys in effect for this run; nil when annotations.yaml does not exist.
+var annotationKeys []string
+
+// loadAnnotationKeys reads the optional annotations.yaml in the database directory.
+func loadAnnotationKeys(dbPath string) error {
+	data, err := os.ReadFile(filepath.Join(dbPath, "annotations.yaml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			annotationKeys = nil
+			return nil
+		}
+		return err
+	}
+
+	var config AnnotationConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("failed to parse annotations: %v", err)
+	}
+	var keys []string
+	for _, key := range config.Keys {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == "" || strings.ContainsAny(key, ": ") {
+			return fmt.Errorf("invalid annotation key '%s'", key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	annotationKeys = keys
+	logInfof("Loaded %d annotation keys from 'annotations.yaml'", len(keys))
+	return nil
+}
+
+// extractAnnotations returns the values of the given keys found in whole-line comments such as "# owner: team-x".
+// Keys are matched case-insensitively and the first occurrence of a key wins. Returns nil when nothing matches.
+func extractAnnotations(content string, keys []string) map[string]string {
+	if len(keys) == 0 {
+		return nil
+	}
+	wanted := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		wanted[key] = true
+	}
+
+	var annotations map[string]string
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(strings.TrimSpace(strings.TrimLeft(trimmed, "#")), ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if !wanted[key] || value == "" {
+			continue
+		}
+		if _, exists := annotations[key]; exists {
+			continue
+		}
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[key] = value
+	}
+	return annotations
+}
+
+// annotateFindings attaches the annotations of the workflow the findings were raised in.
+func annotateFindings(findings []Finding, annotations map[string]string) {
+	if len(annotations) == 0 {
+		return
+	}
+	for i := range findings {
+		findings[i].Annotations = annotations
+	}
+}
+
+// recordAnnotations stores the annotations of a repository's workflow in the index, removing them when there are none.
+func recordAnnotations(index *ActionIndex, repoName string, annotations map[string]string) {
+	if len(annotations) == 0 {
+		delete(index.Annotations, repoName)
+		return
+	}
+	if index.Annotations == nil {
+		index.Annotations = make(map[string]map[string]string)
+	}
+	index.Annotations[repoName] = annotations
+}
+
+// updateWorkflowAnnotations records the annotations of a repository's workflow in its index.yaml.
+func updateWorkflowAnnotations(dbPath, actionName, repoName string, annotations map[string]string) error {
+	indexPath := filepath.Join(dbPath, "workflows", actionName, "index.yaml")
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		return err
+	}
+	var index ActionIndex
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		return err
+	}
+
+	_, existed := index.Annotations[repoName]
+	if !existed && len(annotations) == 0 {
+		return nil
+	}
+	recordAnnotations(&index, repoName, annotations)
+	return writeActionIndex(indexPath, &index)
+}
+
+// AnnotatedWorkflow is a workflow file of a repository carrying a given annotation value.
+type AnnotatedWorkflow struct {
+	RepoName string
+	FileName string
+}
+
+// loadAnnotatedWorkflows groups the indexed workflows by annotation key and value.
+func loadAnnotatedWorkflows(dbPath string) (map[string]map[string][]AnnotatedWorkflow, error) {
+	actionsPath := filepath.Join(dbPath, "workflows")
+	dirs, err := os.ReadDir(actionsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	groups := make(map[string]map[string][]AnnotatedWorkflow)
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		actionName := dir.Name()
+		data, err := os.ReadFile(filepath.Join(actionsPath, actionName, "index.yaml"))
+		if err != nil {
+			continue
+		}
+		var index ActionIndex
+		if err := yaml.Unmarshal(data, &index); err != nil {
+			logErrorf("Error parsing index.yaml for workflow '%s': %v", actionName, err)
+			continue
+		}
+		for repoName, annotations := range index.Annotations {
+			if _, indexed := index.Repositories[repoName]; !indexed {
+				continue
+			}
+			for key, value := range annotations {
+				if groups[key] == nil {
+					groups[key] = make(map[string][]AnnotatedWorkflow)
+				}
+				groups[key][value] = append(groups[key][value], AnnotatedWorkflow{RepoName: repoName, FileName: index.fileName(repoName, actionName)})
+			}
+		}
+	}
+
+	for _, values := range groups {
+		for _, workflows := range values {
+			sort.Slice(workflows, func(i, j int) bool {
+				if workflows[i].RepoName != workflows[j].RepoName {
+					return workflows[i].RepoName < workflows[j].RepoName
+				}
+				return workflows[i].FileName < workflows[j].FileName
+			})
+		}
+	}
+	return groups, nil
+}
+
+// generateAnnotationsMarkdown writes ANNOTATIONS.md, listing the workflows under each value of each annotation key.
+func generateAnnotationsMarkdown(dbPath, org string) error {
+	groups, err := loadAnnotatedWorkflows(dbPath)
+	if err != nil {
+		return err
+	}
+
+	var markdownBuilder strings.Builder
+	markdownBuilder.WriteString("# Annotations\n\n")
+	markdownBuilder.WriteString("This document groups workflows by the metadata embedded in their comments, such as `# owner: team-x`. The keys extracted are configured in `annotations.yaml`.\n\n")
+
+	if len(groups) == 0 {
+		markdownBuilder.WriteString("*No annotated workflows*\n")
+	}
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	count := 0
+	for _, key := range keys {
+		markdownBuilder.WriteString(fmt.Sprintf("## %s\n\n", key))
+		markdownBuilder.WriteString("| Value | Workflows |\n")
+		markdownBuilder.WriteString("|-------|-----------|\n")
+		values := make([]string, 0, len(groups[key]))
+		for value := range groups[key] {
+			values = append(values, value)
+		}
+		sort.Strings(values)
+		for _, value := range values {
+			var links []string
+			for _, workflow := range groups[key][value] {
+				count++
+				links = append(links, fmt.Sprintf("[%s/%s](%s/%s/%s/blob/main/.github/workflows/%s)",
+					workflow.RepoName, workflow.FileName, githubWebURL, org, workflow.RepoName, workflow.FileName))
+			}
+			markdownBuilder.WriteString(fmt.Sprintf("| `%s` | %s |\n", value, strings.Join(links, ", ")))
+		}
+		markdownBuilder.WriteString("\n")
+	}
+
+	markdownBuilder.WriteString("\n*This file is automatically generated after each data collection run.*\n")
+
+	if err := os.WriteFile(filepath.Join(dbPath, "ANNOTATIONS.md"), []byte(markdownBuilder.String()), 0644); err != nil {
+		return fmt.Errorf("error writing ANNOTATIONS.md: %v", err)
+	}
+
+	logInfof("Generated ANNOTATIONS.md with %d annotated workflow entries", count)
+	return nil
+}
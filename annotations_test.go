@@ -0,0 +1,112 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractAnnotations(t *testing.T) {
+	t.Parallel()
+
+	content := `# Owner: team-x
+# tier: prod
+# tier: staging
+# description: not configured
+name: build # owner: inline comments are ignored
+on: push
+jobs:
+  build:
+    # cost-center:
+    runs-on: ubuntu-latest
+`
+	annotations := extractAnnotations(content, []string{"cost-center", "owner", "tier"})
+	if len(annotations) != 2 || annotations["owner"] != "team-x" || annotations["tier"] != "prod" {
+		t.Fatalf("unexpected annotations: %v", annotations)
+	}
+
+	if annotations := extractAnnotations(content, nil); annotations != nil {
+		t.Fatalf("expected no annotations without configured keys, got %v", annotations)
+	}
+}
+
+func TestLoadAnnotationKeysRejectsInvalidKey(t *testing.T) {
+	dbPath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dbPath, "annotations.yaml"), []byte("keys: [owner, 'cost center']\n"), 0644); err != nil {
+		t.Fatalf("failed to write annotations.yaml: %v", err)
+	}
+	if err := loadAnnotationKeys(dbPath); err == nil {
+		t.Fatalf("expected an error for a key containing a space")
+	}
+}
+
+func TestGenerateAnnotationsMarkdown(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	for _, repo := range []string{"repo-b", "repo-a", "repo-c"} {
+		if err := updateActionIndex(dbPath, "build.yml", repo, "build.yml", "hash-one", ""); err != nil {
+			t.Fatalf("updateActionIndex returned error: %v", err)
+		}
+	}
+	if err := updateWorkflowAnnotations(dbPath, "build.yml", "repo-a", map[string]string{"owner": "team-x", "tier": "prod"}); err != nil {
+		t.Fatalf("updateWorkflowAnnotations returned error: %v", err)
+	}
+	if err := updateWorkflowAnnotations(dbPath, "build.yml", "repo-b", map[string]string{"owner": "team-x"}); err != nil {
+		t.Fatalf("updateWorkflowAnnotations returned error: %v", err)
+	}
+	if err := updateWorkflowAnnotations(dbPath, "build.yml", "repo-c", map[string]string{"owner": "team-y"}); err != nil {
+		t.Fatalf("updateWorkflowAnnotations returned error: %v", err)
+	}
+	// Removing the comments from a workflow drops its annotations
+	if err := updateWorkflowAnnotations(dbPath, "build.yml", "repo-c", nil); err != nil {
+		t.Fatalf("updateWorkflowAnnotations returned error: %v", err)
+	}
+
+	if err := generateAnnotationsMarkdown(dbPath, "example-org"); err != nil {
+		t.Fatalf("generateAnnotationsMarkdown returned error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dbPath, "ANNOTATIONS.md"))
+	if err != nil {
+		t.Fatalf("failed to read ANNOTATIONS.md: %v", err)
+	}
+	markdown := string(data)
+
+	wantRow := "| `team-x` | [repo-a/build.yml](https://github.com/example-org/repo-a/blob/main/.github/workflows/build.yml), [repo-b/build.yml](https://github.com/example-org/repo-b/blob/main/.github/workflows/build.yml) |"
+	if !strings.Contains(markdown, wantRow) {
+		t.Fatalf("expected owners to be grouped, got:\n%s", markdown)
+	}
+	if !strings.Contains(markdown, "## tier") || strings.Contains(markdown, "team-y") {
+		t.Fatalf("unexpected annotation groups:\n%s", markdown)
+	}
+	if strings.Index(markdown, "## owner") > strings.Index(markdown, "## tier") {
+		t.Fatalf("expected keys in alphabetical order:\n%s", markdown)
+	}
+}
+
+func TestNotificationSinkAcceptsAnnotations(t *testing.T) {
+	t.Parallel()
+
+	sink := NotificationSink{Annotations: map[string]string{"Owner": "Team-X"}}
+	cases := []struct {
+		event NotificationEvent
+		want  bool
+	}{
+		{NotificationEvent{Type: EventFinding, Annotations: map[string]string{"owner": "team-x", "tier": "prod"}}, true},
+		{NotificationEvent{Type: EventFinding, Annotations: map[string]string{"owner": "team-y"}}, false},
+		{NotificationEvent{Type: EventNewAction}, false},
+	}
+	for _, c := range cases {
+		if got := sink.accepts(c.event); got != c.want {
+			t.Fatalf("accepts(%+v) = %v, want %v", c.event, got, c.want)
+		}
+	}
+
+	findings := []Finding{{Rule: "secrets-inherit"}}
+	annotateFindings(findings, map[string]string{"owner": "team-x"})
+	events := buildNotificationEvents("example-org", &MetricsSnapshot{}, findings, &ActionUsesIndex{}, nil)
+	if len(events) != 1 || !sink.accepts(events[0]) {
+		t.Fatalf("expected the finding event to carry its workflow annotations, got %+v", events)
+	}
+}
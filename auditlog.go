@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Audit Log Correlation
+// ------------------------
+
+// defaultAuditLogWindow is how far back the audit log is searched when there is no previous run.
+// It matches the retention of git events in the organization audit log.
+const defaultAuditLogWindow = 7 * 24 * time.Hour
+
+// Audit-log actions that are attached to workflow changes.
+const (
+	auditActionPush           = "git.push"
+	auditActionPolicyOverride = "protected_branch.policy_override"
+)
+
+// AuditContext is what the organization audit log shows about a repository in the window a workflow changed in.
+type AuditContext struct {
+	Since              string   `yaml:"since"`
+	Pushers            []string `yaml:"pushers,omitempty"`
+	BypassedProtection bool     `yaml:"bypassed_protection,omitempty"`
+	BypassActors       []string `yaml:"bypass_actors,omitempty"`
+}
+
+// auditLogFetcher retrieves the audit-log entries of a repository created at or after a date.
+type auditLogFetcher func(repoName string, since time.Time) ([]*github.AuditEntry, error)
+
+// fetchAuditLogEntries searches the organization audit log, including git events, for a repository.
+func fetchAuditLogEntries(client *github.Client, org string) auditLogFetcher {
+	return func(repoName string, since time.Time) ([]*github.AuditEntry, error) {
+		ctx := context.Background()
+		opts := &github.GetAuditLogOptions{
+			Phrase:            github.String(fmt.Sprintf("repo:%s/%s created:>=%s", org, repoName, since.UTC().Format("2006-01-02"))),
+			Include:           github.String("all"),
+			ListCursorOptions: github.ListCursorOptions{PerPage: 100},
+		}
+
+		var entries []*github.AuditEntry
+		for {
+			page, resp, err := client.Organizations.GetAuditLog(ctx, org, opts)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, page...)
+			if resp.After == "" {
+				break
+			}
+			opts.After = resp.After
+		}
+		return entries, nil
+	}
+}
+
+// auditLogWindowStart returns the start of the window searched for a run: the date of the previous
+// run's metrics snapshot, or the default window when there is none.
+func auditLogWindowStart(dbPath string, now time.Time) time.Time {
+	since := now.Add(-defaultAuditLogWindow)
+	history, err := loadMetricsHistory(dbPath)
+	if err != nil || len(history.Snapshots) == 0 {
+		return since
+	}
+	previous, err := time.ParseInLocation("2006-01-02", history.Snapshots[len(history.Snapshots)-1].Date, reportLocation)
+	if err != nil {
+		return since
+	}
+	return previous
+}
+
+// summarizeAuditEntries reduces the audit-log entries of a repository to who pushed and who bypassed
+// branch protection at or after since.
+func summarizeAuditEntries(entries []*github.AuditEntry, since time.Time) *AuditContext {
+	pushers := make(map[string]bool)
+	bypassActors := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.CreatedAt != nil && entry.CreatedAt.Before(since) {
+			continue
+		}
+		switch entry.GetAction() {
+		case auditActionPush:
+			pushers[entry.GetActor()] = true
+		case auditActionPolicyOverride:
+			bypassActors[entry.GetActor()] = true
+		}
+	}
+
+	audit := &AuditContext{
+		Since:              formatReportDate(since),
+		Pushers:            sortedKeys(pushers),
+		BypassedProtection: len(bypassActors) > 0,
+		BypassActors:       sortedKeys(bypassActors),
+	}
+	return audit
+}
+
+// sortedKeys returns the non-empty keys of a set in sorted order.
+func sortedKeys(set map[string]bool) []string {
+	var keys []string
+	for key := range set {
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// correlateAuditLog looks up the audit log once for each repository with workflow changes in this run
+// and attaches what it found to the matching change log entries. Lookups stop at the first error,
+// since the audit log is only available to organizations on GitHub Enterprise Cloud.
+func correlateAuditLog(dbPath string, fetch auditLogFetcher, changes []WorkflowChange, since time.Time) {
+	changesByRepo := make(map[string][]WorkflowChange)
+	for _, change := range changes {
+		changesByRepo[change.RepoName] = append(changesByRepo[change.RepoName], change)
+	}
+	repoNames := make([]string, 0, len(changesByRepo))
+	for repoName := range changesByRepo {
+		repoNames = append(repoNames, repoName)
+	}
+	sort.Strings(repoNames)
+
+	today := formatReportDate(time.Now())
+	for _, repoName := range repoNames {
+		entries, err := fetch(repoName, since)
+		if err != nil {
+			logWarnf("Error fetching audit log for repository '%s', skipping correlation: %v", repoName, err)
+			return
+		}
+		audit := summarizeAuditEntries(entries, since)
+		for _, change := range changesByRepo[repoName] {
+			if err := annotateActionChange(dbPath, change.ActionName, repoName, change.To, today, audit); err != nil {
+				logErrorf("Error recording audit context for %s in %s: %v", change.ActionName, repoName, err)
+			}
+		}
+	}
+}
+
+// annotateActionChange attaches audit context to the change log entry recorded for a repository on a date.
+func annotateActionChange(dbPath, actionName, repoName, toHash, date string, audit *AuditContext) error {
+	changeLogPath := filepath.Join(dbPath, "workflows", actionName, "changelog.yaml")
+	data, err := os.ReadFile(changeLogPath)
+	if err != nil {
+		return err
+	}
+	var changeLog ActionChangeLog
+	if err := yaml.Unmarshal(data, &changeLog); err != nil {
+		return err
+	}
+
+	found := false
+	for i := len(changeLog.Changes) - 1; i >= 0; i-- {
+		change := &changeLog.Changes[i]
+		if change.Repository == repoName && change.To == toHash && change.Date == date {
+			change.Audit = audit
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("no change log entry for %s", shortHash(toHash))
+	}
+
+	updated, err := yaml.Marshal(&changeLog)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(changeLogPath, updated, 0644)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+)
+
+func auditEntry(action, actor string, createdAt time.Time) *github.AuditEntry {
+	return &github.AuditEntry{
+		Action:    github.String(action),
+		Actor:     github.String(actor),
+		CreatedAt: &github.Timestamp{Time: createdAt},
+	}
+}
+
+func TestSummarizeAuditEntries(t *testing.T) {
+	t.Parallel()
+
+	since := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	entries := []*github.AuditEntry{
+		auditEntry("git.push", "bob", since.Add(time.Hour)),
+		auditEntry("git.push", "alice", since.Add(2*time.Hour)),
+		auditEntry("git.push", "bob", since.Add(3*time.Hour)),
+		auditEntry("git.push", "mallory", since.Add(-time.Hour)),
+		auditEntry("protected_branch.policy_override", "carol", since.Add(time.Hour)),
+		auditEntry("repo.access", "dave", since.Add(time.Hour)),
+	}
+
+	audit := summarizeAuditEntries(entries, since)
+	if strings.Join(audit.Pushers, ",") != "alice,bob" {
+		t.Fatalf("unexpected pushers: %v", audit.Pushers)
+	}
+	if !audit.BypassedProtection || strings.Join(audit.BypassActors, ",") != "carol" {
+		t.Fatalf("expected carol to have bypassed protection, got %+v", audit)
+	}
+	if audit.Since != formatReportDate(since) {
+		t.Fatalf("unexpected since: %s", audit.Since)
+	}
+}
+
+func TestCorrelateAuditLog(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	if err := storeActionVersion(dbPath, "build.yml", "hash-one", "on: push\n"); err != nil {
+		t.Fatalf("storeActionVersion returned error: %v", err)
+	}
+	if err := recordActionChange(dbPath, "build.yml", "repo-a", "", "hash-one", "on: push\n", true); err != nil {
+		t.Fatalf("recordActionChange returned error: %v", err)
+	}
+
+	since := time.Now().Add(-24 * time.Hour)
+	calls := 0
+	fetch := func(repoName string, from time.Time) ([]*github.AuditEntry, error) {
+		calls++
+		if repoName != "repo-a" || !from.Equal(since) {
+			t.Errorf("unexpected lookup for %s since %v", repoName, from)
+		}
+		return []*github.AuditEntry{
+			auditEntry("git.push", "alice", time.Now()),
+			auditEntry("protected_branch.policy_override", "alice", time.Now()),
+		}, nil
+	}
+
+	changes := []WorkflowChange{{RepoName: "repo-a", FilePath: ".github/workflows/build.yml", ActionName: "build.yml", To: "hash-one"}}
+	correlateAuditLog(dbPath, fetch, changes, since)
+	if calls != 1 {
+		t.Fatalf("expected one audit log lookup, got %d", calls)
+	}
+
+	if err := generateActionChangelogs(dbPath); err != nil {
+		t.Fatalf("generateActionChangelogs returned error: %v", err)
+	}
+	changelog, err := os.ReadFile(filepath.Join(dbPath, "workflows", "build.yml", "CHANGELOG.md"))
+	if err != nil {
+		t.Fatalf("failed to read CHANGELOG.md: %v", err)
+	}
+	if !strings.Contains(string(changelog), "by `alice`") || !strings.Contains(string(changelog), "Branch protection bypassed by `alice`") {
+		t.Fatalf("audit context missing from changelog:\n%s", changelog)
+	}
+}
+
+func TestCorrelateAuditLogStopsOnError(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	fetch := func(repoName string, since time.Time) ([]*github.AuditEntry, error) {
+		calls++
+		return nil, errors.New("404 Not Found")
+	}
+	changes := []WorkflowChange{
+		{RepoName: "repo-a", ActionName: "build.yml", To: "hash-one"},
+		{RepoName: "repo-b", ActionName: "build.yml", To: "hash-two"},
+	}
+	correlateAuditLog(t.TempDir(), fetch, changes, time.Now())
+	if calls != 1 {
+		t.Fatalf("expected lookups to stop after the first error, got %d calls", calls)
+	}
+}
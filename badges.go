@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Repository Pages
+// ------------------------
+
+// RepositoryWorkflow is an indexed workflow file shown on a repository page.
+type RepositoryWorkflow struct {
+	FileName string
+	Name     string // The workflow's name field, or the file name when it has none
+	Source   WorkflowSource
+}
+
+// workflowBadgeMarkdown returns the Markdown for a workflow status badge that links to the workflow's runs.
+func workflowBadgeMarkdown(org, repoName, fileName, name, branch string) string {
+	workflowURL := fmt.Sprintf("%s/%s/%s/actions/workflows/%s", githubWebURL, org, repoName, fileName)
+	return fmt.Sprintf("[![%s](%s/badge.svg?branch=%s)](%s)", name, workflowURL, branch, workflowURL)
+}
+
+// workflowDisplayName returns the name field of a workflow, falling back to its file name.
+func workflowDisplayName(content, fileName string) string {
+	workflow, err := parseWorkflowDocument(content)
+	if err == nil {
+		if name := mappingValue(workflow, "name"); name != nil && name.Kind == yaml.ScalarNode && name.Value != "" {
+			return name.Value
+		}
+	}
+	return fileName
+}
+
+// generateRepositoryPages creates a page for each repository in db/repositories listing its indexed
+// workflows with ready-to-paste status badges for the repository's default branch.
+func generateRepositoryPages(dbPath, org string) error {
+	var manifest RepositoryManifest
+	data, err := os.ReadFile(filepath.Join(dbPath, "repositories.yaml"))
+	if err != nil {
+		return fmt.Errorf("failed to read repositories.yaml: %v", err)
+	}
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("failed to parse repositories.yaml: %v", err)
+	}
+
+	sources, err := loadWorkflowSources(dbPath)
+	if err != nil {
+		return err
+	}
+	workflows := make(map[string][]RepositoryWorkflow)
+	err = walkIndexedWorkflows(dbPath, func(fileName, repoName, content string) {
+		workflows[repoName] = append(workflows[repoName], RepositoryWorkflow{
+			FileName: fileName,
+			Name:     workflowDisplayName(content, fileName),
+			Source:   sources[repoName][fileName],
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	// Rewrite the folder so pages of removed repositories do not linger
+	pagesPath := filepath.Join(dbPath, "repositories")
+	if err := os.RemoveAll(pagesPath); err != nil {
+		return fmt.Errorf("failed to clear repositories directory: %v", err)
+	}
+	if err := os.MkdirAll(pagesPath, 0755); err != nil {
+		return fmt.Errorf("failed to create repositories directory: %v", err)
+	}
+
+	for _, repoName := range manifest.Repositories {
+		branch := manifest.DefaultBranches[repoName]
+		if branch == "" {
+			branch = "main"
+		}
+		repoWorkflows := workflows[repoName]
+		sort.Slice(repoWorkflows, func(i, j int) bool {
+			return repoWorkflows[i].FileName < repoWorkflows[j].FileName
+		})
+
+		page := formatRepositoryPage(org, repoName, branch, manifest.Toolchains[repoName], repoWorkflows)
+		if err := os.WriteFile(filepath.Join(pagesPath, repoName+".md"), []byte(page), 0644); err != nil {
+			return fmt.Errorf("error writing page for repository '%s': %v", repoName, err)
+		}
+	}
+
+	logInfof("Generated %d repository pages", len(manifest.Repositories))
+	return nil
+}
+
+// formatRepositoryPage renders the page of one repository.
+func formatRepositoryPage(org, repoName, branch string, toolchains []string, workflows []RepositoryWorkflow) string {
+	var markdownBuilder strings.Builder
+	markdownBuilder.WriteString(fmt.Sprintf("# %s\n\n", repoName))
+	markdownBuilder.WriteString(fmt.Sprintf("Repository: [%s/%s](%s/%s/%s) (default branch `%s`)\n\n", org, repoName, githubWebURL, org, repoName, branch))
+	if len(toolchains) > 0 {
+		markdownBuilder.WriteString(fmt.Sprintf("Toolchains: %s\n\n", strings.Join(toolchains, ", ")))
+	}
+	markdownBuilder.WriteString("## Workflows\n\n")
+
+	if len(workflows) == 0 {
+		markdownBuilder.WriteString("*No workflows indexed*\n")
+	}
+	for _, workflow := range workflows {
+		markdownBuilder.WriteString(fmt.Sprintf("### [%s](%s/%s/%s/blob/%s/%s)\n\n",
+			workflow.FileName, githubWebURL, org, repoName, branch, workflow.Source.repositoryPath(workflow.FileName)))
+		if workflow.Source.Symlink != "" {
+			markdownBuilder.WriteString(fmt.Sprintf("Symbolic link to `%s`\n\n", workflow.Source.Symlink))
+		}
+		if workflow.Source.Encoding != "" {
+			markdownBuilder.WriteString(fmt.Sprintf("Stored as `%s`, indexed as UTF-8\n\n", workflow.Source.Encoding))
+		}
+		if workflow.Source.Reason != "" {
+			// A file without content defines no workflow, so it has no runs to badge
+			markdownBuilder.WriteString(fmt.Sprintf("Not indexed: %s\n\n", workflow.Source.Reason))
+			continue
+		}
+		badge := workflowBadgeMarkdown(org, repoName, workflow.FileName, workflow.Name, branch)
+		markdownBuilder.WriteString(badge + "\n\n")
+		markdownBuilder.WriteString("```markdown\n" + badge + "\n```\n\n")
+	}
+
+	markdownBuilder.WriteString("\n*This file is automatically generated after each data collection run.*\n")
+	return markdownBuilder.String()
+}
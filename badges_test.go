@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWorkflowBadgeMarkdown(t *testing.T) {
+	t.Parallel()
+
+	got := workflowBadgeMarkdown("acme", "api", "build.yaml", "Build", "develop")
+	want := "[![Build](https://github.com/acme/api/actions/workflows/build.yaml/badge.svg?branch=develop)](https://github.com/acme/api/actions/workflows/build.yaml)"
+	if got != want {
+		t.Fatalf("workflowBadgeMarkdown = %q, want %q", got, want)
+	}
+}
+
+func TestGenerateRepositoryPages(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dbPath, "repositories.yaml"), []byte("organization: acme\nrepositories: []\n"), 0644); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+	if err := updateRepositoriesManifest(dbPath, "api", "develop"); err != nil {
+		t.Fatalf("updateRepositoriesManifest returned error: %v", err)
+	}
+	if err := updateRepositoriesManifest(dbPath, "docs", ""); err != nil {
+		t.Fatalf("updateRepositoriesManifest returned error: %v", err)
+	}
+
+	if err := updateActionIndex(dbPath, "build.yml", "api", "build.yaml", "hash-one", ""); err != nil {
+		t.Fatalf("updateActionIndex returned error: %v", err)
+	}
+	content := "name: Build\non: push\njobs: {}\n"
+	if err := os.WriteFile(filepath.Join(dbPath, "workflows", "build.yml", "hash-one"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write workflow: %v", err)
+	}
+
+	// A page of a repository that is no longer indexed is removed
+	stalePath := filepath.Join(dbPath, "repositories", "old.md")
+	if err := os.MkdirAll(filepath.Dir(stalePath), 0755); err != nil {
+		t.Fatalf("failed to create repositories directory: %v", err)
+	}
+	if err := os.WriteFile(stalePath, []byte("stale"), 0644); err != nil {
+		t.Fatalf("failed to write stale page: %v", err)
+	}
+
+	if err := generateRepositoryPages(dbPath, "acme"); err != nil {
+		t.Fatalf("generateRepositoryPages returned error: %v", err)
+	}
+
+	page, err := os.ReadFile(filepath.Join(dbPath, "repositories", "api.md"))
+	if err != nil {
+		t.Fatalf("failed to read api page: %v", err)
+	}
+	badge := "[![Build](https://github.com/acme/api/actions/workflows/build.yaml/badge.svg?branch=develop)](https://github.com/acme/api/actions/workflows/build.yaml)"
+	if !strings.Contains(string(page), "```markdown\n"+badge+"\n```") {
+		t.Fatalf("api page is missing the badge snippet:\n%s", page)
+	}
+
+	docs, err := os.ReadFile(filepath.Join(dbPath, "repositories", "docs.md"))
+	if err != nil {
+		t.Fatalf("failed to read docs page: %v", err)
+	}
+	if !strings.Contains(string(docs), "*No workflows indexed*") || !strings.Contains(string(docs), "default branch `main`") {
+		t.Fatalf("unexpected docs page:\n%s", docs)
+	}
+
+	if _, err := os.Stat(stalePath); !os.IsNotExist(err) {
+		t.Fatalf("expected stale page to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/google/go-github/v50/github"
+)
+
+// ------------------------
+// Section: Blob Cache
+// ------------------------
+
+// blobCache holds the decoded content of the blobs fetched during a run, keyed by blob SHA. A blob SHA
+// is the hash of the content, so byte-identical files in different repositories, such as workflows
+// copied from a template, share one entry and are downloaded once.
+type blobCache struct {
+	mu      sync.Mutex
+	content map[string]string
+	hits    int
+	misses  int
+}
+
+// fetchedBlobs is the blob cache of the current run.
+var fetchedBlobs = newBlobCache()
+
+// newBlobCache creates an empty blob cache.
+func newBlobCache() *blobCache {
+	return &blobCache{content: make(map[string]string)}
+}
+
+// fetch returns the content of a blob, downloading it only when no blob with the same SHA was fetched
+// before. Failed downloads are not cached.
+func (c *blobCache) fetch(client *github.Client, owner, repoName, sha string) (string, error) {
+	c.mu.Lock()
+	content, ok := c.content[sha]
+	if ok {
+		c.hits++
+	}
+	c.mu.Unlock()
+	if ok {
+		return content, nil
+	}
+
+	content, err := downloadBlob(client, owner, repoName, sha)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	c.content[sha] = content
+	c.misses++
+	c.mu.Unlock()
+	return content, nil
+}
+
+// stats returns how many blobs were served from the cache and how many were downloaded.
+func (c *blobCache) stats() (hits, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
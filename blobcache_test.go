@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-github/v50/github"
+)
+
+func TestBlobCacheFetch(t *testing.T) {
+	t.Parallel()
+
+	content := "on: push\njobs: {}\n"
+	sha := computeBlobSHA([]byte(content))
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if !strings.HasSuffix(r.URL.Path, "/git/blobs/"+sha) {
+			http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"sha":%q,"size":%d,"encoding":"base64","content":%q}`, sha, len(content), base64.StdEncoding.EncodeToString([]byte(content)))
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	cache := newBlobCache()
+	for _, repoName := range []string{"repo-a", "repo-b", "repo-c"} {
+		got, err := cache.fetch(client, "example-org", repoName, sha)
+		if err != nil {
+			t.Fatalf("fetch returned error: %v", err)
+		}
+		if got != content {
+			t.Fatalf("unexpected content for %s: %q", repoName, got)
+		}
+	}
+	if requests.Load() != 1 {
+		t.Fatalf("expected one download for identical files, got %d", requests.Load())
+	}
+	if hits, misses := cache.stats(); hits != 2 || misses != 1 {
+		t.Fatalf("unexpected stats: hits=%d misses=%d", hits, misses)
+	}
+
+	if _, err := cache.fetch(client, "example-org", "repo-a", "missing"); err == nil {
+		t.Fatalf("expected an error for a missing blob")
+	}
+	if _, err := cache.fetch(client, "example-org", "repo-a", "missing"); err == nil || requests.Load() != 3 {
+		t.Fatalf("expected failed downloads not to be cached, got %d requests", requests.Load())
+	}
+}
+
+func TestDownloadBlobRetriesTruncatedContent(t *testing.T) {
+	t.Parallel()
+
+	content := "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n"
+	sha := computeBlobSHA([]byte(content))
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoded := base64.StdEncoding.EncodeToString([]byte(content))
+		if requests.Add(1) == 1 {
+			encoded = encoded[:len(encoded)-7] // Truncated mid-quantum, so decoding fails
+		}
+		fmt.Fprintf(w, `{"sha":%q,"size":%d,"encoding":"base64","content":%q}`, sha, len(content), encoded)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	got, err := downloadBlob(client, "example-org", "repo-a", sha)
+	if err != nil {
+		t.Fatalf("downloadBlob returned error: %v", err)
+	}
+	if got != content || requests.Load() != 2 {
+		t.Fatalf("expected the content after a second download, got %q after %d requests", got, requests.Load())
+	}
+}
+
+func TestBlobIntegrityFailures(t *testing.T) {
+	t.Parallel()
+
+	err := verifyBlobContent([]byte("on: pu"), computeBlobSHA([]byte("on: push\n")), 9)
+	if len(blobIntegrityFailures(err)) != 0 {
+		t.Fatalf("expected no paths before the file is known")
+	}
+
+	joined := errors.Join(withBlobPath(err, ".github/workflows/a.yml"), withBlobPath(err, ".github/workflows/b.yml"))
+	wrapped := fmt.Errorf("failed to fetch workflow files: %w", joined)
+	got := blobIntegrityFailures(wrapped)
+	if len(got) != 2 || got[0] != ".github/workflows/a.yml" || got[1] != ".github/workflows/b.yml" {
+		t.Fatalf("unexpected paths: %v", got)
+	}
+
+	if paths := blobIntegrityFailures(withBlobPath(errors.New("502 Bad Gateway"), "dependabot.yml")); len(paths) != 0 {
+		t.Fatalf("expected other errors to be left alone, got %v", paths)
+	}
+}
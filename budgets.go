@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Structural Budgets
+// ------------------------
+
+// WorkflowBudgets are structural limits on workflows read from budgets.yaml. A zero value disables a limit.
+type WorkflowBudgets struct {
+	MaxJobsPerWorkflow int `yaml:"max_jobs_per_workflow"`
+	MaxStepsPerJob     int `yaml:"max_steps_per_job"`
+	MaxTimeoutMinutes  int `yaml:"max_timeout_minutes"`
+	// MaxGitHubScriptLines overrides the size above which github-script steps are reported
+	MaxGitHubScriptLines int `yaml:"max_github_script_lines"`
+}
+
+// workflowBudgets holds the budgets in effect for this run; nil when budgets.yaml does not exist.
+var workflowBudgets *WorkflowBudgets
+
+// loadBudgets reads the optional budgets.yaml in the database directory.
+func loadBudgets(dbPath string) error {
+	data, err := os.ReadFile(filepath.Join(dbPath, "budgets.yaml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			workflowBudgets = nil
+			return nil
+		}
+		return err
+	}
+
+	var budgets WorkflowBudgets
+	if err := yaml.Unmarshal(data, &budgets); err != nil {
+		return fmt.Errorf("failed to parse budgets: %v", err)
+	}
+	if err := budgets.validate(); err != nil {
+		return err
+	}
+	workflowBudgets = &budgets
+	logInfof("Loaded workflow budgets from 'budgets.yaml'")
+	return nil
+}
+
+// validate rejects negative budgets.
+func (b *WorkflowBudgets) validate() error {
+	if b.MaxJobsPerWorkflow < 0 || b.MaxStepsPerJob < 0 || b.MaxTimeoutMinutes < 0 || b.MaxGitHubScriptLines < 0 {
+		return fmt.Errorf("budgets must not be negative")
+	}
+	return nil
+}
+
+// scanForBudgetViolations checks a workflow against the configured budgets.
+func scanForBudgetViolations(content, repoName, filePath string) []Finding {
+	return checkBudgets(workflowBudgets, content, repoName, filePath)
+}
+
+// checkBudgets reports the jobs and steps of a workflow that exceed the budgets, and jobs whose
+// timeout is above the ceiling. Jobs with a timeout expression cannot be evaluated and are skipped.
+func checkBudgets(budgets *WorkflowBudgets, content, repoName, filePath string) []Finding {
+	if budgets == nil {
+		return nil
+	}
+	jobs, err := resolveWorkflowJobs(content)
+	if err != nil || len(jobs) == 0 {
+		return nil
+	}
+
+	var findings []Finding
+	if budgets.MaxJobsPerWorkflow > 0 && len(jobs) > budgets.MaxJobsPerWorkflow {
+		line := jobs[0].Line
+		for _, job := range jobs {
+			line = min(line, job.Line)
+		}
+		findings = append(findings, newFinding("job-count-budget", repoName, filePath, line,
+			fmt.Sprintf("Workflow has %d jobs, over the budget of %d", len(jobs), budgets.MaxJobsPerWorkflow)))
+	}
+
+	for _, job := range jobs {
+		if budgets.MaxStepsPerJob > 0 && job.Steps > budgets.MaxStepsPerJob {
+			findings = append(findings, newFinding("step-count-budget", repoName, filePath, job.Line,
+				fmt.Sprintf("Job '%s' has %d steps, over the budget of %d", job.ID, job.Steps, budgets.MaxStepsPerJob)))
+		}
+
+		if budgets.MaxTimeoutMinutes > 0 && job.ReusableWorkflow == "" && job.TimeoutMinutes > budgets.MaxTimeoutMinutes {
+			message := fmt.Sprintf("Job '%s' times out after %d minutes, over the ceiling of %d", job.ID, job.TimeoutMinutes, budgets.MaxTimeoutMinutes)
+			if job.TimeoutSource == SettingSourceDefault {
+				message = fmt.Sprintf("Job '%s' sets no timeout-minutes and falls back to %d minutes, over the ceiling of %d", job.ID, job.TimeoutMinutes, budgets.MaxTimeoutMinutes)
+			}
+			findings = append(findings, newFinding("timeout-budget", repoName, filePath, job.Line, message))
+		}
+	}
+	return findings
+}
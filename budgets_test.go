@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckBudgets(t *testing.T) {
+	t.Parallel()
+
+	content := `on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    timeout-minutes: 30
+    steps:
+      - run: make
+      - run: make test
+      - run: make package
+  lint:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make lint
+  deploy:
+    runs-on: ubuntu-latest
+    timeout-minutes: ${{ inputs.timeout }}
+    steps:
+      - run: make deploy
+  release:
+    uses: org/shared/.github/workflows/release.yml@main
+`
+	budgets := &WorkflowBudgets{MaxJobsPerWorkflow: 3, MaxStepsPerJob: 2, MaxTimeoutMinutes: 60}
+	findings := checkBudgets(budgets, content, "repo", ".github/workflows/ci.yml")
+
+	got := make(map[string]int)
+	for _, finding := range findings {
+		got[fmt.Sprintf("%s@%d", finding.Rule, finding.Line)]++
+	}
+	want := []string{"job-count-budget@3", "step-count-budget@3", "timeout-budget@10"}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(want), len(findings), findings)
+	}
+	for _, key := range want {
+		if got[key] != 1 {
+			t.Fatalf("expected finding %s, got %+v", key, findings)
+		}
+	}
+
+	if findings := checkBudgets(nil, content, "repo", ".github/workflows/ci.yml"); len(findings) != 0 {
+		t.Fatalf("expected no findings without budgets, got %+v", findings)
+	}
+	if findings := checkBudgets(&WorkflowBudgets{}, content, "repo", ".github/workflows/ci.yml"); len(findings) != 0 {
+		t.Fatalf("expected zero budgets to be disabled, got %+v", findings)
+	}
+}
+
+func TestLoadBudgets(t *testing.T) {
+	dbPath := t.TempDir()
+	if err := loadBudgets(dbPath); err != nil || workflowBudgets != nil {
+		t.Fatalf("expected no budgets without budgets.yaml, got %+v, %v", workflowBudgets, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dbPath, "budgets.yaml"), []byte("max_steps_per_job: 25\nmax_timeout_minutes: 60\n"), 0644); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	if err := loadBudgets(dbPath); err != nil {
+		t.Fatalf("loadBudgets returned error: %v", err)
+	}
+	defer func() { workflowBudgets = nil }()
+	if workflowBudgets == nil || workflowBudgets.MaxStepsPerJob != 25 || workflowBudgets.MaxTimeoutMinutes != 60 || workflowBudgets.MaxJobsPerWorkflow != 0 {
+		t.Fatalf("unexpected budgets: %+v", workflowBudgets)
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Workflow Change Log
+// ------------------------
+
+// ActionChange records a repository moving to a different version of a workflow file.
+type ActionChange struct {
+	Date       string `yaml:"date"`
+	Repository string `yaml:"repository"`
+	From       string `yaml:"from,omitempty"`
+	To         string `yaml:"to"`
+	NewVersion bool   `yaml:"new_version,omitempty"`
+	Added      int    `yaml:"added,omitempty"`
+	Removed    int    `yaml:"removed,omitempty"`
+	// Audit is the organization audit-log context of the change, recorded when -audit-log is set
+	Audit *AuditContext `yaml:"audit,omitempty"`
+}
+
+// ActionChangeLog is the history of changes for a single workflow file.
+type ActionChangeLog struct {
+	Changes []ActionChange `yaml:"changes"`
+}
+
+// currentActionHash returns the hash currently indexed for a repository, or an empty string if none.
+func currentActionHash(dbPath, actionName, repoName string) string {
+	indexPath := filepath.Join(dbPath, "workflows", actionName, "index.yaml")
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		return ""
+	}
+
+	var index ActionIndex
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		return ""
+	}
+	return index.Repositories[repoName]
+}
+
+// actionVersionExists reports whether a workflow file version is already stored, or, when contents are
+// not stored, whether its index has seen it before.
+func actionVersionExists(dbPath, actionName, hash string) bool {
+	actionPath := filepath.Join(dbPath, "workflows", actionName)
+	if _, err := os.Stat(versionPath(actionPath, hash)); err == nil {
+		return true
+	}
+	if storeContent {
+		return false
+	}
+	index, err := readActionIndex(filepath.Join(actionPath, "index.yaml"))
+	if err != nil {
+		return false
+	}
+	_, seen := index.Versions[hash]
+	return seen
+}
+
+// recordActionChange appends an entry to the workflow's changelog.yaml when a repository changes version.
+// It must be called before garbage collection so the previous version is still available for diffing.
+func recordActionChange(dbPath, actionName, repoName, fromHash, toHash, content string, newVersion bool) error {
+	if fromHash == toHash {
+		return nil
+	}
+
+	change := ActionChange{
+		Date:       formatReportDate(time.Now()),
+		Repository: repoName,
+		From:       fromHash,
+		To:         toHash,
+		NewVersion: newVersion,
+	}
+
+	if fromHash != "" {
+		previous, err := readVersionFile(versionPath(filepath.Join(dbPath, "workflows", actionName), fromHash))
+		if err == nil {
+			change.Added, change.Removed = diffLineCounts(string(previous), content)
+		}
+	}
+
+	changeLogPath := filepath.Join(dbPath, "workflows", actionName, "changelog.yaml")
+	var changeLog ActionChangeLog
+	if data, err := os.ReadFile(changeLogPath); err == nil {
+		if err := yaml.Unmarshal(data, &changeLog); err != nil {
+			return err
+		}
+	}
+
+	changeLog.Changes = append(changeLog.Changes, change)
+
+	data, err := yaml.Marshal(&changeLog)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(changeLogPath, data, 0644); err != nil {
+		return err
+	}
+
+	logDebugf("Recorded change for workflow '%s' in repository '%s'", actionName, repoName)
+	return nil
+}
+
+// diffLineCounts returns the number of lines added and removed between two versions of a file.
+func diffLineCounts(oldContent, newContent string) (added int, removed int) {
+	for _, op := range diffSequences(strings.Split(oldContent, "\n"), strings.Split(newContent, "\n")) {
+		switch op.Kind {
+		case '+':
+			added++
+		case '-':
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// shortHash abbreviates a content hash for display.
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
+// generateActionChangelogs creates a CHANGELOG.md file in each workflow directory from its changelog.yaml.
+func generateActionChangelogs(dbPath string) error {
+	actionsPath := filepath.Join(dbPath, "workflows")
+	dirs, err := os.ReadDir(actionsPath)
+	if err != nil {
+		return fmt.Errorf("failed to read workflows directory: %v", err)
+	}
+
+	forEachParallel(dirs, func(dir os.DirEntry) {
+		if !dir.IsDir() {
+			return
+		}
+
+		actionName := dir.Name()
+		data, err := os.ReadFile(filepath.Join(actionsPath, actionName, "changelog.yaml"))
+		if err != nil {
+			return
+		}
+
+		var changeLog ActionChangeLog
+		if err := yaml.Unmarshal(data, &changeLog); err != nil {
+			logErrorf("Error parsing changelog.yaml for workflow '%s': %v", actionName, err)
+			return
+		}
+
+		// Group changes by date, newest first
+		changesByDate := make(map[string][]ActionChange)
+		for _, change := range changeLog.Changes {
+			changesByDate[change.Date] = append(changesByDate[change.Date], change)
+		}
+		var dates []string
+		for date := range changesByDate {
+			dates = append(dates, date)
+		}
+		sort.Sort(sort.Reverse(sort.StringSlice(dates)))
+
+		var markdownBuilder strings.Builder
+		markdownBuilder.WriteString(fmt.Sprintf("# %s Changelog\n\n", actionName))
+		for _, date := range dates {
+			changes := changesByDate[date]
+			sort.SliceStable(changes, func(i, j int) bool {
+				return changes[i].Repository < changes[j].Repository
+			})
+
+			markdownBuilder.WriteString(fmt.Sprintf("## %s\n\n", date))
+			for _, change := range changes {
+				if change.NewVersion {
+					markdownBuilder.WriteString(fmt.Sprintf("- New version [`%s`](%s) first seen in %s\n", shortHash(change.To), change.To, change.Repository))
+				}
+				if change.From == "" {
+					markdownBuilder.WriteString(fmt.Sprintf("- %s added with [`%s`](%s)\n", change.Repository, shortHash(change.To), change.To))
+				} else {
+					markdownBuilder.WriteString(fmt.Sprintf("- %s moved from `%s` to [`%s`](%s) (+%d / -%d lines)\n",
+						change.Repository, shortHash(change.From), shortHash(change.To), change.To, change.Added, change.Removed))
+				}
+				markdownBuilder.WriteString(formatAuditContext(change.Audit))
+			}
+			markdownBuilder.WriteString("\n")
+		}
+
+		changelogPath := filepath.Join(actionsPath, actionName, "CHANGELOG.md")
+		if err := os.WriteFile(changelogPath, []byte(markdownBuilder.String()), 0644); err != nil {
+			logErrorf("Error writing CHANGELOG.md for workflow '%s': %v", actionName, err)
+			return
+		}
+
+		logDebugf("Generated CHANGELOG.md for workflow '%s'", actionName)
+	})
+
+	return nil
+}
+
+// formatAuditContext renders the audit-log context of a change as nested list items.
+func formatAuditContext(audit *AuditContext) string {
+	if audit == nil {
+		return ""
+	}
+	var builder strings.Builder
+	if len(audit.Pushers) == 0 {
+		builder.WriteString(fmt.Sprintf("  - No pushes in the audit log since %s\n", audit.Since))
+	} else {
+		builder.WriteString(fmt.Sprintf("  - Pushed since %s by `%s`\n", audit.Since, strings.Join(audit.Pushers, "`, `")))
+	}
+	if audit.BypassedProtection && len(audit.BypassActors) > 0 {
+		builder.WriteString(fmt.Sprintf("  - ⚠️ Branch protection bypassed by `%s`\n", strings.Join(audit.BypassActors, "`, `")))
+	} else if audit.BypassedProtection {
+		builder.WriteString("  - ⚠️ Branch protection bypassed\n")
+	}
+	return builder.String()
+}
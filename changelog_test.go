@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffLineCounts(t *testing.T) {
+	t.Parallel()
+
+	added, removed := diffLineCounts("a\nb\nc\n", "a\nc\nd\ne\n")
+	if added != 2 || removed != 1 {
+		t.Fatalf("diffLineCounts = (+%d, -%d), want (+2, -1)", added, removed)
+	}
+}
+
+func TestGenerateActionChangelogs(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	if err := updateActionIndex(dbPath, "build.yml", "repo-a", "build.yml", "hash-one", ""); err != nil {
+		t.Fatalf("updateActionIndex returned error: %v", err)
+	}
+	if err := storeActionVersion(dbPath, "build.yml", "hash-one", "name: build\n"); err != nil {
+		t.Fatalf("storeActionVersion returned error: %v", err)
+	}
+	if err := recordActionChange(dbPath, "build.yml", "repo-a", "", "hash-one", "name: build\n", true); err != nil {
+		t.Fatalf("recordActionChange returned error: %v", err)
+	}
+	if err := recordActionChange(dbPath, "build.yml", "repo-a", "hash-one", "hash-two", "name: build\non: push\n", true); err != nil {
+		t.Fatalf("recordActionChange returned error: %v", err)
+	}
+
+	if err := generateActionChangelogs(dbPath); err != nil {
+		t.Fatalf("generateActionChangelogs returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dbPath, "workflows", "build.yml", "CHANGELOG.md"))
+	if err != nil {
+		t.Fatalf("failed to read CHANGELOG.md: %v", err)
+	}
+
+	content := string(data)
+	if !strings.Contains(content, "- repo-a added with [`hash-one`](hash-one)") {
+		t.Fatalf("expected added entry, got:\n%s", content)
+	}
+	if !strings.Contains(content, "- repo-a moved from `hash-one` to [`hash-two`](hash-two) (+1 / -0 lines)") {
+		t.Fatalf("expected moved entry, got:\n%s", content)
+	}
+}
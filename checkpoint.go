@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Checkpoints
+// ------------------------
+
+// checkpointFile records the repositories a stopped run scanned, for -resume.
+const checkpointFile = "checkpoint.yaml"
+
+// errScanStopped is returned by auditGitHubActions when SIGINT or SIGTERM stops a run before it completes.
+var errScanStopped = errors.New("stopped")
+
+// Checkpoint is the contents of checkpoint.yaml, written when a run is stopped. The repositories it lists
+// are indexed in the database, so a run with -resume keeps their stored results instead of scanning them again.
+type Checkpoint struct {
+	ScanSettings   `yaml:",inline"`
+	Organization   string    `yaml:"organization"`
+	StartedAt      time.Time `yaml:"started_at"`
+	LastRepository string    `yaml:"last_repository,omitempty"`
+	Repositories   []string  `yaml:"repositories"` // Scanned by the stopped run and the runs it resumed
+}
+
+// loadCheckpoint reads checkpoint.yaml, returning nil if there is none.
+func loadCheckpoint(dbPath string) (*Checkpoint, error) {
+	data, err := os.ReadFile(filepath.Join(dbPath, checkpointFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	checkpoint := &Checkpoint{}
+	if err := yaml.Unmarshal(data, checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", checkpointFile, err)
+	}
+	return checkpoint, nil
+}
+
+// writeCheckpoint writes checkpoint.yaml with the repositories sorted.
+func writeCheckpoint(dbPath string, checkpoint *Checkpoint) error {
+	sort.Strings(checkpoint.Repositories)
+	return writeYAMLFile(dbPath, checkpointFile, checkpoint)
+}
+
+// removeCheckpoint deletes checkpoint.yaml once a run completes.
+func removeCheckpoint(dbPath string) error {
+	if err := os.Remove(filepath.Join(dbPath, checkpointFile)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// resumableCheckpoint returns the checkpoint a run of org with the given settings can resume, or nil when
+// there is none or it was written by a run of another organization or with other settings.
+func resumableCheckpoint(dbPath, org string, settings ScanSettings) (*Checkpoint, error) {
+	checkpoint, err := loadCheckpoint(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case checkpoint == nil:
+		logInfof("No checkpoint to resume from; scanning every repository")
+		return nil, nil
+	case checkpoint.Organization != org || !checkpoint.ScanSettings.equal(settings):
+		logWarnf("The checkpoint was written by a run of another organization, tool version, or with other dotfiles, paths, or indexed files; scanning every repository")
+		return nil, nil
+	}
+	return checkpoint, nil
+}
+
+// partitionCheckpointed splits repositories into those to scan and the names of those a checkpoint lists.
+func partitionCheckpointed(repos []*github.Repository, checkpoint *Checkpoint) ([]*github.Repository, map[string]bool) {
+	listed := make(map[string]bool, len(checkpoint.Repositories))
+	for _, repoName := range checkpoint.Repositories {
+		listed[repoName] = true
+	}
+	var scan []*github.Repository
+	skipped := make(map[string]bool)
+	for _, repo := range repos {
+		if listed[repo.GetName()] {
+			skipped[repo.GetName()] = true
+			continue
+		}
+		scan = append(scan, repo)
+	}
+	return scan, skipped
+}
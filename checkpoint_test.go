@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+)
+
+func TestCheckpointResume(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	settings := ScanSettings{Version: "1.2.3", Paths: []string{".github/workflows"}}
+
+	checkpoint, err := resumableCheckpoint(dbPath, "example-org", settings)
+	if err != nil {
+		t.Fatalf("resumableCheckpoint returned error: %v", err)
+	}
+	if checkpoint != nil {
+		t.Fatalf("expected no checkpoint, got %+v", checkpoint)
+	}
+
+	startedAt := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	written := &Checkpoint{ScanSettings: settings, Organization: "example-org", StartedAt: startedAt, LastRepository: "repo-a", Repositories: []string{"repo-c", "repo-a"}}
+	if err := writeCheckpoint(dbPath, written); err != nil {
+		t.Fatalf("writeCheckpoint returned error: %v", err)
+	}
+
+	checkpoint, err = resumableCheckpoint(dbPath, "example-org", settings)
+	if err != nil {
+		t.Fatalf("resumableCheckpoint returned error: %v", err)
+	}
+	if checkpoint == nil || !checkpoint.StartedAt.Equal(startedAt) || strings.Join(checkpoint.Repositories, ",") != "repo-a,repo-c" {
+		t.Fatalf("unexpected checkpoint: %+v", checkpoint)
+	}
+
+	repos := []*github.Repository{{Name: github.String("repo-a")}, {Name: github.String("repo-b")}, {Name: github.String("repo-c")}}
+	scan, skipped := partitionCheckpointed(repos, checkpoint)
+	if len(scan) != 1 || scan[0].GetName() != "repo-b" || len(skipped) != 2 || !skipped["repo-a"] || !skipped["repo-c"] {
+		t.Fatalf("expected only repo-b to be scanned, got %v and %v", scan, skipped)
+	}
+
+	// A checkpoint of another organization or with other settings is not resumed
+	for _, tc := range []struct {
+		org      string
+		settings ScanSettings
+	}{
+		{"other-org", settings},
+		{"example-org", ScanSettings{Version: "1.2.3", Paths: []string{"ci"}}},
+		{"example-org", ScanSettings{Version: "1.2.4", Paths: []string{".github/workflows"}}},
+	} {
+		checkpoint, err := resumableCheckpoint(dbPath, tc.org, tc.settings)
+		if err != nil {
+			t.Fatalf("resumableCheckpoint returned error: %v", err)
+		}
+		if checkpoint != nil {
+			t.Fatalf("expected the checkpoint not to be resumed for %s with %+v", tc.org, tc.settings)
+		}
+	}
+
+	if err := removeCheckpoint(dbPath); err != nil {
+		t.Fatalf("removeCheckpoint returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dbPath, checkpointFile)); !os.IsNotExist(err) {
+		t.Fatalf("expected the checkpoint to be removed, got %v", err)
+	}
+	if err := removeCheckpoint(dbPath); err != nil {
+		t.Fatalf("removeCheckpoint returned error for a missing checkpoint: %v", err)
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Status Check Names
+// ------------------------
+
+// checkExpressionRe matches the ${{ }} expressions in a job name, whose values are only known at run time.
+var checkExpressionRe = regexp.MustCompile(`\$\{\{.*?\}\}`)
+
+// CheckSource is a workflow job that reports a status check.
+type CheckSource struct {
+	Repository string `yaml:"repository" json:"repository"`
+	Workflow   string `yaml:"workflow" json:"workflow"`
+	Job        string `yaml:"job" json:"job"`
+	Line       int    `yaml:"line" json:"line"`
+}
+
+// ChecksIndex maps the status check names reported by workflow jobs to the jobs that report them. It is
+// stored as checks.yaml so that a required check in a branch protection rule can be traced back to the
+// workflow file that defines it. Names that depend on the run, such as matrix values, use * for the part
+// that varies.
+type ChecksIndex struct {
+	Organization string                   `yaml:"organization"`
+	Checks       map[string][]CheckSource `yaml:"checks"`
+}
+
+// CheckQueryResult lists the workflow jobs that can report a status check.
+type CheckQueryResult struct {
+	Check   string               `json:"check"`
+	Sources []MatchedCheckSource `json:"sources"`
+}
+
+// MatchedCheckSource is a workflow job with the check name pattern that matched a query.
+type MatchedCheckSource struct {
+	Pattern string `json:"pattern"`
+	CheckSource
+}
+
+// jobCheckName returns the status check name GitHub reports for a job. The job name is used when set and
+// the job ID otherwise. A matrix job gets its matrix values appended in parentheses unless its name refers
+// to the matrix itself, and the checks of a job calling a reusable workflow are named after the caller and
+// the called job. Parts that are only known at run time are replaced with *.
+func jobCheckName(job EffectiveJob) string {
+	name := job.ID
+	if job.Name != "" {
+		name = checkExpressionRe.ReplaceAllString(job.Name, "*")
+	}
+	if job.Matrix && !strings.Contains(job.Name, "matrix.") {
+		name += " (*)"
+	}
+	if job.ReusableWorkflow != "" {
+		name += " / *"
+	}
+	return name
+}
+
+// matchCheckName reports whether a check name matches a pattern from jobCheckName, comparing case-insensitively.
+func matchCheckName(pattern, name string) bool {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	matched, err := regexp.MatchString("(?is)^"+strings.Join(parts, ".*")+"$", name)
+	return err == nil && matched
+}
+
+// buildChecksIndex collects the status check names of every job in the indexed workflows. Sources are
+// sorted by repository, workflow, then job.
+func buildChecksIndex(dbPath, org string) (*ChecksIndex, error) {
+	index := &ChecksIndex{Organization: org, Checks: make(map[string][]CheckSource)}
+	err := walkIndexedWorkflows(dbPath, func(fileName, repoName, content string) {
+		jobs, err := resolveWorkflowJobs(content)
+		if err != nil {
+			return
+		}
+		for _, job := range jobs {
+			name := jobCheckName(job)
+			index.Checks[name] = append(index.Checks[name], CheckSource{
+				Repository: repoName,
+				Workflow:   ".github/workflows/" + fileName,
+				Job:        job.ID,
+				Line:       job.Line,
+			})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sources := range index.Checks {
+		sort.Slice(sources, func(i, j int) bool {
+			if sources[i].Repository != sources[j].Repository {
+				return sources[i].Repository < sources[j].Repository
+			}
+			if sources[i].Workflow != sources[j].Workflow {
+				return sources[i].Workflow < sources[j].Workflow
+			}
+			return sources[i].Job < sources[j].Job
+		})
+	}
+	return index, nil
+}
+
+// queryCheck returns the workflow jobs whose check name matches a required status check.
+func queryCheck(dbPath, name string) (*CheckQueryResult, error) {
+	if name == "" {
+		return nil, fmt.Errorf("no check given")
+	}
+	index, err := buildChecksIndex(dbPath, "")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CheckQueryResult{Check: name, Sources: []MatchedCheckSource{}}
+	for pattern, sources := range index.Checks {
+		if !matchCheckName(pattern, name) {
+			continue
+		}
+		for _, source := range sources {
+			result.Sources = append(result.Sources, MatchedCheckSource{Pattern: pattern, CheckSource: source})
+		}
+	}
+	sort.Slice(result.Sources, func(i, j int) bool {
+		a, b := result.Sources[i], result.Sources[j]
+		if a.Repository != b.Repository {
+			return a.Repository < b.Repository
+		}
+		if a.Workflow != b.Workflow {
+			return a.Workflow < b.Workflow
+		}
+		return a.Job < b.Job
+	})
+	return result, nil
+}
+
+// formatCheckQueryText formats a check query result as one line per job.
+func formatCheckQueryText(result *CheckQueryResult) string {
+	if len(result.Sources) == 0 {
+		return fmt.Sprintf("No indexed workflow job reports the check '%s'.\n", result.Check)
+	}
+	var builder strings.Builder
+	for _, source := range result.Sources {
+		builder.WriteString(fmt.Sprintf("%s\t%s:%d\t%s\t%s\n", source.Repository, source.Workflow, source.Line, source.Job, source.Pattern))
+	}
+	return builder.String()
+}
+
+// generateChecksIndex writes checks.yaml and CHECKS.md to the database.
+func generateChecksIndex(dbPath, org string) error {
+	index, err := buildChecksIndex(dbPath, org)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("error marshaling checks.yaml: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dbPath, "checks.yaml"), data, 0644); err != nil {
+		return fmt.Errorf("error writing checks.yaml: %v", err)
+	}
+
+	names := make([]string, 0, len(index.Checks))
+	for name := range index.Checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var markdownBuilder strings.Builder
+	markdownBuilder.WriteString("# Status Checks\n\n")
+	markdownBuilder.WriteString("This document lists the status check name each workflow job reports, so that a required check in a branch protection rule can be traced back to the workflow that defines it. `*` stands for a part of the name that is only known at run time, such as matrix values or the jobs of a called reusable workflow.\n\n")
+	markdownBuilder.WriteString("| Check | Repository | Workflow | Job |\n")
+	markdownBuilder.WriteString("|-------|------------|----------|-----|\n")
+
+	count := 0
+	for _, name := range names {
+		for _, source := range index.Checks[name] {
+			count++
+			markdownBuilder.WriteString(fmt.Sprintf("| `%s` | [%s](%s/%s/%s) | [%s](%s/%s/%s/blob/main/%s#L%d) | `%s` |\n",
+				strings.ReplaceAll(name, "|", "\\|"), source.Repository, githubWebURL, org, source.Repository,
+				source.Workflow, githubWebURL, org, source.Repository, source.Workflow, source.Line, source.Job))
+		}
+	}
+	if count == 0 {
+		markdownBuilder.WriteString("| *No workflow jobs* | - | - | - |\n")
+	}
+
+	markdownBuilder.WriteString("\n*This file is automatically generated after each data collection run.*\n")
+
+	if err := os.WriteFile(filepath.Join(dbPath, "CHECKS.md"), []byte(markdownBuilder.String()), 0644); err != nil {
+		return fmt.Errorf("error writing CHECKS.md: %v", err)
+	}
+
+	logInfof("Generated checks.yaml and CHECKS.md with %d check names from %d jobs", len(names), count)
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJobCheckName(t *testing.T) {
+	t.Parallel()
+
+	content := `jobs:
+  build:
+    runs-on: ubuntu-latest
+  lint:
+    name: Lint code
+  test:
+    strategy:
+      matrix:
+        go: ["1.22", "1.23"]
+  e2e:
+    name: E2E ${{ matrix.os }}
+    strategy:
+      matrix:
+        os: [ubuntu-latest, windows-latest]
+  release:
+    uses: example-org/workflows/.github/workflows/release.yml@main
+`
+	jobs, err := resolveWorkflowJobs(content)
+	if err != nil {
+		t.Fatalf("resolveWorkflowJobs returned error: %v", err)
+	}
+	want := map[string]string{
+		"build":   "build",
+		"lint":    "Lint code",
+		"test":    "test (*)",
+		"e2e":     "E2E *",
+		"release": "release / *",
+	}
+	for _, job := range jobs {
+		if got := jobCheckName(job); got != want[job.ID] {
+			t.Fatalf("jobCheckName(%s) = %q, want %q", job.ID, got, want[job.ID])
+		}
+	}
+
+	cases := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"build", "build", true},
+		{"build", "Build", true},
+		{"build", "build (1.22)", false},
+		{"test (*)", "test (1.22, ubuntu-latest)", true},
+		{"release / *", "release / publish", true},
+		{"E2E *", "E2E windows-latest", true},
+		{"a.b", "axb", false},
+	}
+	for _, c := range cases {
+		if got := matchCheckName(c.pattern, c.name); got != c.want {
+			t.Fatalf("matchCheckName(%q, %q) = %v, want %v", c.pattern, c.name, got, c.want)
+		}
+	}
+}
+
+func TestQueryCheck(t *testing.T) {
+	t.Parallel()
+
+	dbPath := writeQueryTestDB(t)
+
+	result, err := queryCheck(dbPath, "build")
+	if err != nil {
+		t.Fatalf("queryCheck returned error: %v", err)
+	}
+	if len(result.Sources) != 2 || result.Sources[0].Repository != "repo-a" || result.Sources[1].Workflow != ".github/workflows/build.yml" {
+		t.Fatalf("unexpected sources: %+v", result.Sources)
+	}
+	if text := formatCheckQueryText(result); !strings.Contains(text, "repo-b\t.github/workflows/build.yml:2\tbuild\tbuild\n") {
+		t.Fatalf("unexpected text output:\n%s", text)
+	}
+
+	result, err = queryCheck(dbPath, "deploy")
+	if err != nil {
+		t.Fatalf("queryCheck returned error: %v", err)
+	}
+	if len(result.Sources) != 0 {
+		t.Fatalf("expected no sources, got %+v", result.Sources)
+	}
+}
+
+func TestGenerateChecksIndex(t *testing.T) {
+	t.Parallel()
+
+	dbPath := writeQueryTestDB(t)
+	if err := generateChecksIndex(dbPath, "example-org"); err != nil {
+		t.Fatalf("generateChecksIndex returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dbPath, "checks.yaml"))
+	if err != nil {
+		t.Fatalf("failed to read checks.yaml: %v", err)
+	}
+	if !strings.Contains(string(data), "build:\n        - repository: repo-a\n") {
+		t.Fatalf("unexpected checks.yaml:\n%s", data)
+	}
+
+	markdown, err := os.ReadFile(filepath.Join(dbPath, "CHECKS.md"))
+	if err != nil {
+		t.Fatalf("failed to read CHECKS.md: %v", err)
+	}
+	if !strings.Contains(string(markdown), "| `build` | [repo-b](https://github.com/example-org/repo-b) | [.github/workflows/build.yml](https://github.com/example-org/repo-b/blob/main/.github/workflows/build.yml#L2) | `build` |") {
+		t.Fatalf("unexpected CHECKS.md:\n%s", markdown)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Database Commit Summaries
+// ------------------------
+
+// dbNotesRef is the notes ref that -commit-notes attaches commit summaries under.
+const dbNotesRef = "refs/notes/dotgithubindexer"
+
+// storedVersionRe matches the names of stored file versions, which are the SHA-256 hash of their content.
+var storedVersionRe = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// DBCommitSummary counts what a database commit changes. It is added to the commit message as trailers
+// and, with -commit-notes, attached to the commit as a JSON git note.
+type DBCommitSummary struct {
+	Scanned          int `json:"repositories_scanned,omitempty"` // Repositories the run scanned; zero when the command does not scan
+	Repositories     int `json:"repositories_changed"`           // Repositories whose stored version of any file changed
+	NewVersions      int `json:"new_versions"`                   // File versions stored for the first time
+	RemovedVersions  int `json:"removed_versions"`               // Stored file versions deleted, such as by garbage collection
+	FindingsOpened   int `json:"findings_opened"`
+	FindingsResolved int `json:"findings_resolved"`
+	Files            int `json:"files_changed"`
+}
+
+// summarizeDBChanges counts the changes in a database update. readOld reads a file as it was before the
+// update and readNew as it is now; either returns an error for a file that does not exist at that point.
+func summarizeDBChanges(changes []DBFileChange, readOld, readNew func(string) ([]byte, error)) DBCommitSummary {
+	summary := DBCommitSummary{Files: len(changes)}
+	changedRepos := make(map[string]bool)
+	for _, change := range changes {
+		name := path.Base(change.Path)
+		switch {
+		case change.Status == "A" && storedVersionRe.MatchString(name):
+			summary.NewVersions++
+		case change.Status == "D" && storedVersionRe.MatchString(name):
+			summary.RemovedVersions++
+		case name == "index.yaml":
+			before, after := indexedRepositories(readOld, change.Path), indexedRepositories(readNew, change.Path)
+			for repoName, value := range before {
+				if !reflect.DeepEqual(value, after[repoName]) {
+					changedRepos[repoName] = true
+				}
+			}
+			for repoName := range after {
+				if _, ok := before[repoName]; !ok {
+					changedRepos[repoName] = true
+				}
+			}
+		case change.Path == "metrics.yaml":
+			before, after := latestFindings(readOld), latestFindings(readNew)
+			for fingerprint := range after {
+				if !before[fingerprint] {
+					summary.FindingsOpened++
+				}
+			}
+			for fingerprint := range before {
+				if !after[fingerprint] {
+					summary.FindingsResolved++
+				}
+			}
+		}
+	}
+	summary.Repositories = len(changedRepos)
+	return summary
+}
+
+// indexedRepositories reads the repositories section of a workflow, dependabot, or dotfile index.
+func indexedRepositories(read func(string) ([]byte, error), indexPath string) map[string]any {
+	var index struct {
+		Repositories map[string]any `yaml:"repositories"`
+	}
+	if data, err := read(indexPath); err == nil {
+		_ = yaml.Unmarshal(data, &index)
+	}
+	return index.Repositories
+}
+
+// latestFindings reads the fingerprints of the findings open in the latest snapshot of metrics.yaml.
+func latestFindings(read func(string) ([]byte, error)) map[string]bool {
+	fingerprints := make(map[string]bool)
+	data, err := read("metrics.yaml")
+	if err != nil {
+		return fingerprints
+	}
+	var history MetricsHistory
+	if err := yaml.Unmarshal(data, &history); err != nil || len(history.Snapshots) == 0 {
+		return fingerprints
+	}
+	for _, fingerprint := range history.Snapshots[len(history.Snapshots)-1].Findings {
+		fingerprints[fingerprint] = true
+	}
+	return fingerprints
+}
+
+// formatCommitTrailers formats a summary as git trailers for the end of a commit message.
+func formatCommitTrailers(summary DBCommitSummary) string {
+	var trailers strings.Builder
+	if summary.Scanned > 0 {
+		trailers.WriteString(fmt.Sprintf("Repositories-Scanned: %d\n", summary.Scanned))
+	}
+	trailers.WriteString(fmt.Sprintf("Repositories-Changed: %d\nNew-Versions: %d\nRemoved-Versions: %d\nFindings-Opened: %d\nFindings-Resolved: %d\nFiles-Changed: %d\n",
+		summary.Repositories, summary.NewVersions, summary.RemovedVersions, summary.FindingsOpened, summary.FindingsResolved, summary.Files))
+	return trailers.String()
+}
+
+// summarizeStaged summarizes the changes staged in a database checkout against its last commit. Paths are
+// relative to the database directory, which for -git-commit may be a folder of a larger repository.
+func (c *DBCheckout) summarizeStaged() (DBCommitSummary, error) {
+	nameStatus, err := c.gitOutput("-C", c.Dir, "diff", "--cached", "--name-status", "--no-renames", "--relative")
+	if err != nil {
+		return DBCommitSummary{}, err
+	}
+	readOld := func(file string) ([]byte, error) {
+		content, err := c.gitOutput("-C", c.Dir, "show", "HEAD:./"+file)
+		return []byte(content), err
+	}
+	readNew := func(file string) ([]byte, error) {
+		return os.ReadFile(filepath.Join(c.Dir, filepath.FromSlash(file)))
+	}
+	return summarizeDBChanges(parseNameStatus(nameStatus), readOld, readNew), nil
+}
+
+// addCommitNote attaches a summary to the last commit as a JSON note under dbNotesRef, first fetching the
+// notes already on the remote so that pushing them does not discard the notes of earlier runs. Notes are
+// commits too, so identity holds the arguments selecting the committer.
+func (c *DBCheckout) addCommitNote(summary DBCommitSummary, identity []string) error {
+	// The notes ref does not exist before the first note is pushed
+	_ = c.git("-C", c.Dir, "fetch", "-q", "origin", "+"+dbNotesRef+":"+dbNotesRef)
+
+	note, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	return c.git(append(identity, "notes", "--ref", dbNotesRef, "add", "-f", "-m", string(note), "HEAD")...)
+}
+
+// pushCommitNotes pushes the notes ref when the checkout attaches commit notes.
+func (c *DBCheckout) pushCommitNotes() error {
+	if !c.Notes {
+		return nil
+	}
+	return c.git("-C", c.Dir, "push", "-q", "origin", dbNotesRef)
+}
+
+// commitMessage appends the trailers summarizing the staged changes to a commit message.
+func (c *DBCheckout) commitMessage(message string) (string, DBCommitSummary) {
+	summary, err := c.summarizeStaged()
+	if err != nil {
+		logErrorf("Error summarizing database changes: %v", err)
+		return message, summary
+	}
+	if c.Scanned != nil {
+		summary.Scanned = c.Scanned()
+	}
+	return strings.TrimRight(message, "\n") + "\n\n" + formatCommitTrailers(summary), summary
+}
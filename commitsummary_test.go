@@ -0,0 +1,113 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSummarizeDBChanges(t *testing.T) {
+	t.Parallel()
+
+	hashOne := strings.Repeat("1", 64)
+	hashTwo := strings.Repeat("2", 64)
+	old := map[string]string{
+		"workflows/build.yml/index.yaml": "repositories:\n    repo-a: " + hashOne + "\n    repo-b: " + hashOne + "\n    repo-c: " + hashOne + "\n",
+		"metrics.yaml":                   "snapshots:\n    - date: \"2026-01-01\"\n      findings: [f1, f2]\n",
+	}
+	updated := map[string]string{
+		"workflows/build.yml/index.yaml":       "repositories:\n    repo-a: " + hashTwo + "\n    repo-b: " + hashOne + "\n",
+		"workflows/release.yml/index.yaml":     "repositories:\n    repo-d: " + hashTwo + "\n",
+		"workflows/build.yml/" + hashTwo:       "jobs: {}\n",
+		"workflows/build.yml/README.md":        "# build.yml\n",
+		"metrics.yaml":                         "snapshots:\n    - date: \"2026-01-01\"\n      findings: [f1, f2]\n    - date: \"2026-01-02\"\n      findings: [f2, f3, f4]\n",
+		"dotfiles/.gitignore/index.yaml":       "repositories:\n    repo-a:\n        hash: " + hashOne + "\n",
+		"workflows/release.yml/" + hashTwo:     "jobs: {}\n",
+		"workflows/release.yml/changelog.yaml": "entries: []\n",
+	}
+	reader := func(files map[string]string) func(string) ([]byte, error) {
+		return func(file string) ([]byte, error) {
+			content, ok := files[file]
+			if !ok {
+				return nil, os.ErrNotExist
+			}
+			return []byte(content), nil
+		}
+	}
+	changes := parseNameStatus("M\tworkflows/build.yml/index.yaml\nA\tworkflows/release.yml/index.yaml\nA\tworkflows/build.yml/" + hashTwo +
+		"\nM\tworkflows/build.yml/README.md\nM\tmetrics.yaml\nA\tdotfiles/.gitignore/index.yaml\nA\tworkflows/release.yml/" + hashTwo +
+		"\nA\tworkflows/release.yml/changelog.yaml\nD\tworkflows/build.yml/" + hashOne + "\n")
+
+	summary := summarizeDBChanges(changes, reader(old), reader(updated))
+	// repo-a changed its build.yml and gained a dotfile, repo-c left, and repo-d is new
+	want := DBCommitSummary{Repositories: 3, NewVersions: 2, RemovedVersions: 1, FindingsOpened: 2, FindingsResolved: 1, Files: 9}
+	if summary != want {
+		t.Fatalf("summarizeDBChanges = %+v, want %+v", summary, want)
+	}
+
+	trailers := formatCommitTrailers(summary)
+	if !strings.HasPrefix(trailers, "Repositories-Changed: 3\nNew-Versions: 2\nRemoved-Versions: 1\nFindings-Opened: 2\nFindings-Resolved: 1\n") {
+		t.Fatalf("unexpected trailers:\n%s", trailers)
+	}
+
+	// Commands that scan also count the repositories they scanned
+	summary.Scanned = 12
+	if trailers := formatCommitTrailers(summary); !strings.HasPrefix(trailers, "Repositories-Scanned: 12\nRepositories-Changed: 3\n") {
+		t.Fatalf("unexpected trailers:\n%s", trailers)
+	}
+}
+
+func TestPublishCommitSummary(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	remote := filepath.Join(t.TempDir(), "db.git")
+	if out, err := exec.Command("git", "init", "-q", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v: %s", err, out)
+	}
+	url := "file://" + remote
+
+	for i, hash := range []string{strings.Repeat("a", 64), strings.Repeat("b", 64)} {
+		checkout, err := openDB(url, "")
+		if err != nil {
+			t.Fatalf("openDB returned error: %v", err)
+		}
+		checkout.Notes = true
+		dir := filepath.Join(checkout.Dir, "workflows", "build.yml")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("MkdirAll returned error: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, hash), []byte("jobs: {}\n"), 0644); err != nil {
+			t.Fatalf("WriteFile returned error: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "index.yaml"), []byte("repositories:\n    repo-a: "+hash+"\n"), 0644); err != nil {
+			t.Fatalf("WriteFile returned error: %v", err)
+		}
+		if err := checkout.Publish("Update example-org index"); err != nil {
+			t.Fatalf("Publish %d returned error: %v", i, err)
+		}
+		checkout.Close()
+	}
+
+	message, err := exec.Command("git", "-C", remote, "log", "-1", "--format=%B").Output()
+	if err != nil {
+		t.Fatalf("git log failed: %v", err)
+	}
+	if !strings.HasPrefix(string(message), "Update example-org index\n\nRepositories-Changed: 1\nNew-Versions: 1\nRemoved-Versions: 0\n") {
+		t.Fatalf("unexpected commit message:\n%s", message)
+	}
+
+	// Both runs' notes survive, since each run fetches the notes before adding its own
+	notes, err := exec.Command("git", "-C", remote, "log", "--format=%N", "--notes="+dbNotesRef).Output()
+	if err != nil {
+		t.Fatalf("git log failed: %v", err)
+	}
+	if strings.Count(string(notes), `"new_versions":1`) != 2 {
+		t.Fatalf("expected a note on both commits, got:\n%s", notes)
+	}
+}
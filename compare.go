@@ -0,0 +1,318 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ------------------------
+// Section: Organization Comparison
+// ------------------------
+
+// ComparedOrganization summarizes the third-party action references recorded in one database.
+type ComparedOrganization struct {
+	Organization string `json:"organization"`
+	Repositories int    `json:"repositories"`
+	Actions      int    `json:"actions"`
+	Uses         int    `json:"uses"`
+	PinnedUses   int    `json:"pinned_uses"`
+}
+
+// ComparedVersion counts the references to one version of an action.
+type ComparedVersion struct {
+	Version string `json:"version"`
+	Uses    int    `json:"uses"`
+}
+
+// SharedAction compares an action both organizations use. Versions are listed most used first.
+type SharedAction struct {
+	Action        string            `json:"action"`
+	Versions      []ComparedVersion `json:"versions"`
+	OtherVersions []ComparedVersion `json:"other_versions"`
+	Skewed        bool              `json:"skewed"` // The most used versions point at different refs
+}
+
+// OrganizationComparison compares the third-party action use of an organization with another's.
+type OrganizationComparison struct {
+	Organization ComparedOrganization `json:"organization"`
+	Other        ComparedOrganization `json:"other"`
+	Shared       []SharedAction       `json:"shared_actions"`
+	Only         []string             `json:"only_actions"`       // Actions only the organization uses
+	OtherOnly    []string             `json:"other_only_actions"` // Actions only the other organization uses
+}
+
+// runCompareOrgCommand compares the database with another organization's database and returns the
+// process exit code.
+func runCompareOrgCommand(args []string) int {
+	fs := flag.NewFlagSet("compare-org", flag.ContinueOnError)
+	compareDB := fs.String("db", "./db", "Path to the database repository, or a git URL to clone")
+	compareToken := fs.String("token", "", "GitHub API token used to clone HTTPS database URLs")
+	format := fs.String("format", formatMarkdown, "Output format: markdown or json")
+	output := fs.String("output", "", "File to write the report to; defaults to standard output")
+	logFlags := addLogFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if err := applyEnvironmentDefaults(fs); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := logFlags.apply(); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := checkFormat(*format, formatMarkdown, formatJSON); err != nil {
+		logErrorf("%v", err)
+		printCompareOrgUsage()
+		fs.PrintDefaults()
+		return 1
+	}
+	if fs.NArg() != 1 {
+		printCompareOrgUsage()
+		fs.PrintDefaults()
+		return 1
+	}
+	if *format == formatJSON && *output == "" {
+		useJSONOutput()
+	}
+
+	checkout, err := openDB(*compareDB, *compareToken)
+	if err != nil {
+		logErrorf("Failed to open database: %v", err)
+		return 1
+	}
+	defer checkout.Close()
+	otherCheckout, err := openDB(fs.Arg(0), *compareToken)
+	if err != nil {
+		logErrorf("Failed to open database to compare with: %v", err)
+		return 1
+	}
+	defer otherCheckout.Close()
+
+	if err := writeOrganizationComparison(checkout.Dir, otherCheckout.Dir, *format, *output); err != nil {
+		logErrorf("Comparison failed: %v", err)
+		return 1
+	}
+	return 0
+}
+
+// printCompareOrgUsage prints the usage for the compare-org command.
+func printCompareOrgUsage() {
+	fmt.Println("Usage: dotgithubindexer compare-org [-db <path or git URL>] [-format markdown|json] [-output <file>] <other database path or git URL>")
+}
+
+// compareOrganizations builds the comparison of two databases from their actions.yaml and
+// repositories.yaml.
+func compareOrganizations(dbPath, otherPath string) (*OrganizationComparison, error) {
+	summary, actions, err := loadComparedOrganization(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	otherSummary, otherActions, err := loadComparedOrganization(otherPath)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := &OrganizationComparison{Organization: *summary, Other: *otherSummary}
+	for actionName, versions := range actions {
+		otherVersions, ok := otherActions[actionName]
+		if !ok {
+			comparison.Only = append(comparison.Only, actionName)
+			continue
+		}
+		comparison.Shared = append(comparison.Shared, SharedAction{
+			Action:        actionName,
+			Versions:      versions,
+			OtherVersions: otherVersions,
+			Skewed:        versionRef(versions[0].Version) != versionRef(otherVersions[0].Version),
+		})
+	}
+	for actionName := range otherActions {
+		if _, ok := actions[actionName]; !ok {
+			comparison.OtherOnly = append(comparison.OtherOnly, actionName)
+		}
+	}
+
+	// Skewed actions come first, then the most used across both organizations
+	sort.Slice(comparison.Shared, func(i, j int) bool {
+		a, b := comparison.Shared[i], comparison.Shared[j]
+		if a.Skewed != b.Skewed {
+			return a.Skewed
+		}
+		usesA := countVersionUses(a.Versions) + countVersionUses(a.OtherVersions)
+		usesB := countVersionUses(b.Versions) + countVersionUses(b.OtherVersions)
+		if usesA != usesB {
+			return usesA > usesB
+		}
+		return a.Action < b.Action
+	})
+	sort.Strings(comparison.Only)
+	sort.Strings(comparison.OtherOnly)
+	return comparison, nil
+}
+
+// loadComparedOrganization summarizes a database and returns the versions of each third-party action it
+// references, most used first.
+func loadComparedOrganization(dbPath string) (*ComparedOrganization, map[string][]ComparedVersion, error) {
+	manifest, err := loadRepositoryManifest(dbPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load repositories manifest of '%s': %v", dbPath, err)
+	}
+	index, err := loadActionsReverseIndex(dbPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load actions.yaml of '%s': %v", dbPath, err)
+	}
+
+	summary := &ComparedOrganization{Organization: manifest.Organization, Repositories: len(manifest.Repositories)}
+	actions := make(map[string][]ComparedVersion)
+	for actionName, refs := range index.Actions {
+		if len(refs) == 0 {
+			continue
+		}
+		counts := make(map[string]int)
+		for _, ref := range refs {
+			counts[ref.Version]++
+			summary.Uses++
+			if isPinnedVersion(ref.Version) {
+				summary.PinnedUses++
+			}
+		}
+		var versions []ComparedVersion
+		for version, uses := range counts {
+			versions = append(versions, ComparedVersion{Version: version, Uses: uses})
+		}
+		sort.Slice(versions, func(i, j int) bool {
+			if versions[i].Uses != versions[j].Uses {
+				return versions[i].Uses > versions[j].Uses
+			}
+			return versions[i].Version < versions[j].Version
+		})
+		actions[actionName] = versions
+	}
+	summary.Actions = len(actions)
+	return summary, actions, nil
+}
+
+// versionRef returns the ref of a version, without the tag comment of a pinned SHA.
+func versionRef(version string) string {
+	ref, _, _ := strings.Cut(version, " ")
+	return ref
+}
+
+// countVersionUses returns the references to all versions of an action.
+func countVersionUses(versions []ComparedVersion) int {
+	total := 0
+	for _, version := range versions {
+		total += version.Uses
+	}
+	return total
+}
+
+// formatComparedVersions renders versions with their reference counts, such as `v4` (12), `v3` (2).
+func formatComparedVersions(versions []ComparedVersion) string {
+	parts := make([]string, 0, len(versions))
+	for _, version := range versions {
+		if version.Version == "" {
+			parts = append(parts, fmt.Sprintf("no version (%d)", version.Uses))
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("`%s` (%d)", version.Version, version.Uses))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatComparisonMarkdown renders an organization comparison as Markdown.
+func formatComparisonMarkdown(comparison *OrganizationComparison) string {
+	org, other := comparison.Organization, comparison.Other
+	var markdownBuilder strings.Builder
+	markdownBuilder.WriteString("# Organization Comparison\n\n")
+	markdownBuilder.WriteString(fmt.Sprintf("Third-party action use of **%s** compared with **%s**.\n\n", org.Organization, other.Organization))
+
+	markdownBuilder.WriteString("## Overview\n\n")
+	markdownBuilder.WriteString(fmt.Sprintf("| Metric | %s | %s |\n", org.Organization, other.Organization))
+	markdownBuilder.WriteString("|--------|------|------|\n")
+	markdownBuilder.WriteString(fmt.Sprintf("| Repositories | %d | %d |\n", org.Repositories, other.Repositories))
+	markdownBuilder.WriteString(fmt.Sprintf("| Third-party actions | %d | %d |\n", org.Actions, other.Actions))
+	markdownBuilder.WriteString(fmt.Sprintf("| Action uses | %d | %d |\n", org.Uses, other.Uses))
+	markdownBuilder.WriteString(fmt.Sprintf("| Pinned to a commit SHA | %s | %s |\n\n", percent(org.PinnedUses, org.Uses), percent(other.PinnedUses, other.Uses)))
+
+	union := len(comparison.Shared) + len(comparison.Only) + len(comparison.OtherOnly)
+	skewed := 0
+	for _, action := range comparison.Shared {
+		if action.Skewed {
+			skewed++
+		}
+	}
+	markdownBuilder.WriteString(fmt.Sprintf("%d of the %d actions used by either organization are shared (%s), and %d of them are most used at different versions.\n\n",
+		len(comparison.Shared), union, percent(len(comparison.Shared), union), skewed))
+
+	markdownBuilder.WriteString("## Shared Actions\n\n")
+	markdownBuilder.WriteString(fmt.Sprintf("| Action | %s | %s | Skew |\n", org.Organization, other.Organization))
+	markdownBuilder.WriteString("|--------|------|------|------|\n")
+	if len(comparison.Shared) == 0 {
+		markdownBuilder.WriteString("| *None* | - | - | - |\n")
+	}
+	for _, action := range comparison.Shared {
+		skew := "No"
+		if action.Skewed {
+			skew = "Yes"
+		}
+		markdownBuilder.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s |\n", action.Action, formatComparedVersions(action.Versions), formatComparedVersions(action.OtherVersions), skew))
+	}
+	markdownBuilder.WriteString("\n")
+
+	for _, section := range []struct {
+		organization string
+		actions      []string
+	}{{org.Organization, comparison.Only}, {other.Organization, comparison.OtherOnly}} {
+		markdownBuilder.WriteString(fmt.Sprintf("## Only Used by %s\n\n", section.organization))
+		if len(section.actions) == 0 {
+			markdownBuilder.WriteString("*None*\n\n")
+			continue
+		}
+		for _, actionName := range section.actions {
+			markdownBuilder.WriteString(fmt.Sprintf("- `%s`\n", actionName))
+		}
+		markdownBuilder.WriteString("\n")
+	}
+
+	return markdownBuilder.String()
+}
+
+// formatComparisonJSON renders an organization comparison as JSON. Empty lists are written as [] rather
+// than null.
+func formatComparisonJSON(comparison *OrganizationComparison) (string, error) {
+	document := *comparison
+	document.Shared = emptyIfNil(document.Shared)
+	document.Only = emptyIfNil(document.Only)
+	document.OtherOnly = emptyIfNil(document.OtherOnly)
+	return formatJSONDocument(document)
+}
+
+// writeOrganizationComparison compares two databases and writes the report to output or standard output.
+func writeOrganizationComparison(dbPath, otherPath, format, output string) error {
+	comparison, err := compareOrganizations(dbPath, otherPath)
+	if err != nil {
+		return err
+	}
+
+	content := formatComparisonMarkdown(comparison)
+	if format == formatJSON {
+		content, err = formatComparisonJSON(comparison)
+		if err != nil {
+			return err
+		}
+	}
+	if output == "" {
+		fmt.Fprint(resultWriter, content)
+		return nil
+	}
+	if err := os.WriteFile(output, []byte(content), 0644); err != nil {
+		return fmt.Errorf("error writing %s: %v", output, err)
+	}
+	logInfof("Wrote organization comparison to %s", output)
+	return nil
+}
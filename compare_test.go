@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// writeComparedDatabase writes the repositories.yaml and actions.yaml that compare-org reads.
+func writeComparedDatabase(t *testing.T, org string, repos []string, actions map[string][]ActionReference) string {
+	t.Helper()
+	dbPath := t.TempDir()
+	if err := writeYAMLFile(dbPath, "repositories.yaml", RepositoryManifest{Organization: org, Repositories: repos}); err != nil {
+		t.Fatalf("writeYAMLFile returned error: %v", err)
+	}
+	if err := writeYAMLFile(dbPath, "actions.yaml", ActionsReverseIndex{Organization: org, Actions: actions}); err != nil {
+		t.Fatalf("writeYAMLFile returned error: %v", err)
+	}
+	return dbPath
+}
+
+func TestCompareOrganizations(t *testing.T) {
+	t.Parallel()
+
+	pinned := "11bd71901bbe5b1630ceea73d27597364c9af683 # v4.2.2"
+	dbPath := writeComparedDatabase(t, "org-a", []string{"api", "web"}, map[string][]ActionReference{
+		"actions/checkout": {
+			{Repository: "api", Workflow: ".github/workflows/ci.yml", Version: "v4"},
+			{Repository: "web", Workflow: ".github/workflows/ci.yml", Version: "v4"},
+			{Repository: "web", Workflow: ".github/workflows/deploy.yml", Version: "v3"},
+		},
+		"actions/setup-go":    {{Repository: "api", Workflow: ".github/workflows/ci.yml", Version: pinned}},
+		"docker/login-action": {{Repository: "web", Workflow: ".github/workflows/deploy.yml", Version: "v3"}},
+	})
+	otherPath := writeComparedDatabase(t, "org-b", []string{"service"}, map[string][]ActionReference{
+		"actions/checkout": {{Repository: "service", Workflow: ".github/workflows/ci.yml", Version: "v4"}},
+		// The same commit with another tag comment is not skew
+		"actions/setup-go": {{Repository: "service", Workflow: ".github/workflows/ci.yml", Version: "11bd71901bbe5b1630ceea73d27597364c9af683 # v4"}},
+		"actions/cache":    {{Repository: "service", Workflow: ".github/workflows/ci.yml", Version: "v4"}},
+	})
+
+	comparison, err := compareOrganizations(dbPath, otherPath)
+	if err != nil {
+		t.Fatalf("compareOrganizations returned error: %v", err)
+	}
+	org := comparison.Organization
+	if org.Organization != "org-a" || org.Repositories != 2 || org.Actions != 3 || org.Uses != 5 || org.PinnedUses != 1 {
+		t.Fatalf("unexpected summary: %+v", org)
+	}
+	if len(comparison.Shared) != 2 || comparison.Shared[0].Action != "actions/checkout" || comparison.Shared[0].Versions[0] != (ComparedVersion{Version: "v4", Uses: 2}) {
+		t.Fatalf("unexpected shared actions: %+v", comparison.Shared)
+	}
+	for _, action := range comparison.Shared {
+		if action.Skewed {
+			t.Fatalf("expected no skew, got %+v", action)
+		}
+	}
+	if strings.Join(comparison.Only, ",") != "docker/login-action" || strings.Join(comparison.OtherOnly, ",") != "actions/cache" {
+		t.Fatalf("unexpected actions used by one organization: %v, %v", comparison.Only, comparison.OtherOnly)
+	}
+
+	// Moving the other organization to v3 makes checkout skewed, which lists it first
+	otherPath = writeComparedDatabase(t, "org-b", []string{"service"}, map[string][]ActionReference{
+		"actions/checkout": {{Repository: "service", Workflow: ".github/workflows/ci.yml", Version: "v3"}},
+		"actions/setup-go": {{Repository: "service", Workflow: ".github/workflows/ci.yml", Version: "v5"}},
+	})
+	comparison, err = compareOrganizations(dbPath, otherPath)
+	if err != nil {
+		t.Fatalf("compareOrganizations returned error: %v", err)
+	}
+	markdown := formatComparisonMarkdown(comparison)
+	for _, want := range []string{
+		"| Pinned to a commit SHA | 20.0% | 0.0% |",
+		"2 of the 3 actions used by either organization are shared (66.7%), and 2 of them are most used at different versions.",
+		"| `actions/checkout` | `v4` (2), `v3` (1) | `v3` (1) | Yes |",
+		"## Only Used by org-a\n\n- `docker/login-action`",
+		"## Only Used by org-b\n\n*None*",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Fatalf("expected the comparison to contain %q, got:\n%s", want, markdown)
+		}
+	}
+
+	if _, err := compareOrganizations(dbPath, t.TempDir()); err == nil {
+		t.Fatalf("expected an error for a directory without a database")
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v50/github"
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Composite Action Dependencies
+// ------------------------
+
+// maxCompositeDepth limits how many levels of nested composite actions are followed.
+const maxCompositeDepth = 5
+
+// compositeFetcher retrieves the action.yml content for an action at a ref.
+// An empty string with no error means the action definition does not exist.
+type compositeFetcher func(owner, repo, actionPath, ref string) (string, error)
+
+// extractCompositeUses returns the actions used by the steps of a composite action definition.
+// Definitions for JavaScript and Docker actions return no uses.
+func extractCompositeUses(content string) []ActionUse {
+	definition, err := parseWorkflowDocument(content)
+	if err != nil {
+		return nil
+	}
+
+	runs := mappingValue(definition, "runs")
+	using := mappingValue(runs, "using")
+	if using == nil || !strings.EqualFold(using.Value, "composite") {
+		return nil
+	}
+
+	steps := mappingValue(runs, "steps")
+	if steps == nil || steps.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	var uses []ActionUse
+	for _, step := range steps.Content {
+		usesNode := mappingValue(step, "uses")
+		if usesNode == nil || usesNode.Kind != yaml.ScalarNode {
+			continue
+		}
+		action, version := parseUsesString(usesNode.Value, usesNode.LineComment)
+		if action != "" {
+			uses = append(uses, ActionUse{Action: action, Version: version})
+		}
+	}
+	return uses
+}
+
+// compositeLocation resolves where the definition of a used action lives.
+// Local actions (./path) are resolved in the repository that references them.
+func compositeLocation(org, repoName, action, version string) (owner, repo, actionPath, ref string, ok bool) {
+	if strings.HasPrefix(action, "docker://") {
+		return "", "", "", "", false
+	}
+	if local, isLocal := strings.CutPrefix(action, "./"); isLocal {
+		return org, repoName, path.Clean(local), "", true
+	}
+
+	owner, repo, ok = actionRepository(action)
+	if !ok {
+		return "", "", "", "", false
+	}
+	parts := strings.SplitN(action, "/", 3)
+	if len(parts) == 3 {
+		actionPath = parts[2]
+	}
+	ref, _, _ = strings.Cut(version, " ")
+	return owner, repo, actionPath, ref, true
+}
+
+// fetchActionDefinition retrieves the action.yml (or action.yaml) of an action from GitHub.
+func fetchActionDefinition(client *github.Client) compositeFetcher {
+	return func(owner, repo, actionPath, ref string) (string, error) {
+		ctx := context.Background()
+		for _, fileName := range []string{"action.yml", "action.yaml"} {
+			filePath := path.Join(actionPath, fileName)
+			fileContent, _, _, err := client.Repositories.GetContents(ctx, owner, repo, filePath, &github.RepositoryContentGetOptions{
+				Ref: ref,
+			})
+			if err != nil {
+				if isNotFoundError(err) {
+					continue
+				}
+				return "", err
+			}
+			if fileContent == nil {
+				continue
+			}
+			content, err := fileContent.GetContent()
+			if err == nil {
+				err = verifyBlobContent([]byte(content), fileContent.GetSHA(), reportedSize(fileContent.Size))
+			}
+			if err != nil {
+				// A truncated response is fetched again as a blob, which is retried until it verifies
+				return fetchBlobContent(client, owner, repo, fileContent.GetSHA())
+			}
+			return content, nil
+		}
+		return "", nil
+	}
+}
+
+// resolveCompositeDependencies follows composite actions referenced by indexed workflows and adds the
+// actions they use to the uses index, recording the chain of composite actions in each reference.
+func resolveCompositeDependencies(org string, usesIndex *ActionUsesIndex, fetch compositeFetcher) {
+	if usesIndex == nil {
+		return
+	}
+
+	type pending struct {
+		action  string
+		version string
+		ref     WorkflowReference
+	}
+
+	// Snapshot the direct uses so the index can be extended while walking
+	var queue []pending
+	var actionNames []string
+	for actionName := range usesIndex.Actions {
+		actionNames = append(actionNames, actionName)
+	}
+	sort.Strings(actionNames)
+	for _, actionName := range actionNames {
+		for version, refs := range usesIndex.Actions[actionName] {
+			for _, ref := range refs {
+				queue = append(queue, pending{action: actionName, version: version, ref: ref})
+			}
+		}
+	}
+
+	cache := make(map[string][]ActionUse)
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if len(current.ref.Via) >= maxCompositeDepth {
+			continue
+		}
+		// Local actions nested inside a remote action cannot be resolved without the caller's checkout
+		if strings.HasPrefix(current.action, "./") && len(current.ref.Via) > 0 && !strings.HasPrefix(current.ref.Via[len(current.ref.Via)-1], "./") {
+			continue
+		}
+
+		owner, repo, actionPath, ref, ok := compositeLocation(org, current.ref.RepoName, current.action, current.version)
+		if !ok {
+			continue
+		}
+
+		cacheKey := strings.ToLower(fmt.Sprintf("%s/%s/%s@%s", owner, repo, actionPath, ref))
+		children, cached := cache[cacheKey]
+		if !cached {
+			content, err := fetch(owner, repo, actionPath, ref)
+			if err != nil {
+				logErrorf("Error fetching action definition for '%s@%s': %v", current.action, current.version, err)
+			}
+			children = extractCompositeUses(content)
+			cache[cacheKey] = children
+			if len(children) > 0 {
+				logDebugf("Composite action '%s@%s' uses %d action(s)", current.action, current.version, len(children))
+			}
+		}
+
+		label := current.action
+		if current.version != "" {
+			label += "@" + strings.SplitN(current.version, " ", 2)[0]
+		}
+		for _, child := range children {
+			if chainContainsAction(current.ref.Via, child.Action) || strings.EqualFold(child.Action, current.action) {
+				continue // Cycle
+			}
+			via := append(append([]string{}, current.ref.Via...), label)
+			childRef := WorkflowReference{RepoName: current.ref.RepoName, FilePath: current.ref.FilePath, Via: via}
+
+			if _, ok := usesIndex.Actions[child.Action]; !ok {
+				usesIndex.Actions[child.Action] = make(map[string][]WorkflowReference)
+			}
+			usesIndex.Actions[child.Action][child.Version] = append(usesIndex.Actions[child.Action][child.Version], childRef)
+			queue = append(queue, pending{action: child.Action, version: child.Version, ref: childRef})
+		}
+	}
+}
+
+// chainContainsAction reports whether any chain label refers to the given action.
+func chainContainsAction(chain []string, action string) bool {
+	for _, label := range chain {
+		name, _, _ := strings.Cut(label, "@")
+		if strings.EqualFold(name, action) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestExtractCompositeUses(t *testing.T) {
+	t.Parallel()
+
+	composite := "runs:\n  using: composite\n  steps:\n    - uses: actions/setup-node@v4\n    - run: npm ci\n      shell: bash\n    - uses: actions/cache@v4 # pinned\n"
+	uses := extractCompositeUses(composite)
+	if len(uses) != 2 || uses[0].Action != "actions/setup-node" || uses[1].Version != "v4 # pinned" {
+		t.Fatalf("unexpected composite uses: %+v", uses)
+	}
+
+	javascript := "runs:\n  using: node20\n  main: index.js\n"
+	if uses := extractCompositeUses(javascript); len(uses) != 0 {
+		t.Fatalf("expected no uses for a JavaScript action, got %+v", uses)
+	}
+}
+
+func TestResolveCompositeDependencies(t *testing.T) {
+	t.Parallel()
+
+	definitions := map[string]string{
+		"example-org/shared-actions/setup@v1":       "runs:\n  using: composite\n  steps:\n    - uses: third-party/installer@v2\n",
+		"example-org/repo-a/.github/actions/build@": "runs:\n  using: composite\n  steps:\n    - uses: example-org/shared-actions/setup@v1\n",
+		"third-party/installer/@v2":                 "runs:\n  using: composite\n  steps:\n    - uses: example-org/shared-actions/setup@v1\n",
+	}
+	fetches := 0
+	fetch := func(owner, repo, actionPath, ref string) (string, error) {
+		fetches++
+		return definitions[owner+"/"+repo+"/"+actionPath+"@"+ref], nil
+	}
+
+	usesIndex := &ActionUsesIndex{Actions: map[string]map[string][]WorkflowReference{
+		"./.github/actions/build": {"": {{RepoName: "repo-a", FilePath: ".github/workflows/build.yml"}}},
+		"example-org/shared-actions/setup": {"v1": {
+			{RepoName: "repo-b", FilePath: ".github/workflows/ci.yml"},
+		}},
+	}}
+
+	resolveCompositeDependencies("example-org", usesIndex, fetch)
+
+	installerRefs := usesIndex.Actions["third-party/installer"]["v2"]
+	if len(installerRefs) != 2 {
+		t.Fatalf("expected 2 transitive references to third-party/installer, got %+v", installerRefs)
+	}
+	for _, ref := range installerRefs {
+		if len(ref.Via) == 0 || ref.Via[len(ref.Via)-1] != "example-org/shared-actions/setup@v1" {
+			t.Fatalf("unexpected via chain: %+v", ref)
+		}
+	}
+
+	setupRefs := usesIndex.Actions["example-org/shared-actions/setup"]["v1"]
+	if len(setupRefs) != 2 {
+		t.Fatalf("expected direct and transitive references to shared setup, got %+v", setupRefs)
+	}
+	if fetches != 3 {
+		t.Fatalf("expected definitions to be fetched once each, got %d fetches", fetches)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+)
+
+// ------------------------
+// Section: Concurrency Tuning
+// ------------------------
+
+const (
+	// defaultAdaptiveMaxWorkers is the worker ceiling used in adaptive mode when -concurrency is left at 1.
+	defaultAdaptiveMaxWorkers = 8
+	// maxPacing caps the delay inserted between repository scans.
+	maxPacing = 5 * time.Second
+)
+
+// concurrencyTuner gates how many repositories are scanned in parallel.
+// In adaptive mode the worker limit and the pacing between scans are adjusted
+// after each scan based on the remaining rate limit and observed latency.
+type concurrencyTuner struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	adaptive bool
+	limit    int
+	maxLimit int
+	active   int
+	pacing   time.Duration
+	baseline time.Duration
+}
+
+// newConcurrencyTuner creates a tuner; adaptive tuners start with a single worker and grow.
+func newConcurrencyTuner(concurrency int, adaptive bool) *concurrencyTuner {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	t := &concurrencyTuner{
+		adaptive: adaptive,
+		limit:    concurrency,
+		maxLimit: concurrency,
+	}
+	if adaptive {
+		t.limit = 1
+		if t.maxLimit == 1 {
+			t.maxLimit = defaultAdaptiveMaxWorkers
+		}
+	}
+	t.cond = sync.NewCond(&t.mu)
+	return t
+}
+
+// acquire blocks until a worker slot is available and waits for the current pacing delay.
+func (t *concurrencyTuner) acquire() {
+	t.mu.Lock()
+	for t.active >= t.limit {
+		t.cond.Wait()
+	}
+	t.active++
+	pacing := t.pacing
+	t.mu.Unlock()
+
+	if pacing > 0 {
+		time.Sleep(pacing)
+	}
+}
+
+// release frees a worker slot and, in adaptive mode, adjusts the limit and pacing from the observed scan.
+func (t *concurrencyTuner) release(latency time.Duration, rate *github.Rate, scanErr error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.active--
+	if t.adaptive {
+		t.adjust(latency, rate, scanErr)
+	}
+	t.cond.Broadcast()
+}
+
+// adjust applies additive increase and multiplicative decrease to the worker limit.
+// It must be called with t.mu held.
+func (t *concurrencyTuner) adjust(latency time.Duration, rate *github.Rate, scanErr error) {
+	previousLimit, previousPacing := t.limit, t.pacing
+
+	var abuseErr *github.AbuseRateLimitError
+	var rateErr *github.RateLimitError
+	secondaryLimited := errors.As(scanErr, &abuseErr) || errors.As(scanErr, &rateErr)
+
+	headroom := 1.0
+	if rate != nil && rate.Limit > 0 {
+		headroom = float64(rate.Remaining) / float64(rate.Limit)
+	}
+
+	if t.baseline == 0 || latency < t.baseline {
+		t.baseline = latency
+	}
+	slow := t.baseline > 0 && latency > 3*t.baseline
+
+	switch {
+	case secondaryLimited || headroom < 0.1:
+		t.limit = max(1, t.limit/2)
+		t.pacing = min(maxPacing, max(500*time.Millisecond, t.pacing*2))
+	case headroom < 0.25 || slow:
+		t.limit = max(1, t.limit-1)
+		t.pacing = min(maxPacing, t.pacing+250*time.Millisecond)
+	case headroom > 0.5:
+		t.limit = min(t.maxLimit, t.limit+1)
+		t.pacing = max(0, t.pacing-100*time.Millisecond)
+	}
+
+	if t.limit != previousLimit || t.pacing != previousPacing {
+		logInfof("Adaptive concurrency: %d workers, %v pacing (rate limit headroom %.0f%%, latency %v)", t.limit, t.pacing, headroom*100, latency.Round(time.Millisecond))
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+)
+
+func TestConcurrencyTunerAdaptive(t *testing.T) {
+	t.Parallel()
+
+	tuner := newConcurrencyTuner(1, true)
+	if tuner.limit != 1 || tuner.maxLimit != defaultAdaptiveMaxWorkers {
+		t.Fatalf("unexpected initial tuner state: limit=%d max=%d", tuner.limit, tuner.maxLimit)
+	}
+
+	// Plenty of headroom grows the worker limit
+	for range 3 {
+		tuner.acquire()
+		tuner.release(100*time.Millisecond, &github.Rate{Limit: 5000, Remaining: 4000}, nil)
+	}
+	if tuner.limit != 4 {
+		t.Fatalf("expected limit to grow to 4, got %d", tuner.limit)
+	}
+
+	// A secondary rate limit halves the limit and adds pacing
+	tuner.acquire()
+	tuner.release(100*time.Millisecond, &github.Rate{Limit: 5000, Remaining: 4000}, &github.AbuseRateLimitError{})
+	if tuner.limit != 2 || tuner.pacing == 0 {
+		t.Fatalf("expected limit 2 with pacing after secondary limit, got limit=%d pacing=%v", tuner.limit, tuner.pacing)
+	}
+}
+
+func TestConcurrencyTunerFixed(t *testing.T) {
+	t.Parallel()
+
+	tuner := newConcurrencyTuner(3, false)
+	tuner.acquire()
+	tuner.release(time.Second, &github.Rate{Limit: 5000, Remaining: 10}, nil)
+	if tuner.limit != 3 || tuner.pacing != 0 {
+		t.Fatalf("expected fixed tuner to stay unchanged, got limit=%d pacing=%v", tuner.limit, tuner.pacing)
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ------------------------
+// Section: Version Consolidation
+// ------------------------
+
+// histogramWidth is the length of the longest bar in a version histogram.
+const histogramWidth = 20
+
+// versionTagRe matches version tags such as v4, v4.2, or 4.2.1.
+var versionTagRe = regexp.MustCompile(`^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?$`)
+
+// VersionCount is the number of workflow files using one version of an action.
+type VersionCount struct {
+	Version string
+	Uses    int
+}
+
+// ConsolidationMove is a workflow file that uses an action at a version other than the target.
+type ConsolidationMove struct {
+	RepoName string
+	FilePath string
+	From     string
+}
+
+// ActionConsolidation describes how many versions of an action are in use and which one to converge on.
+type ActionConsolidation struct {
+	Action     string
+	Versions   []VersionCount // Newest first; versions without a recognizable tag come last
+	Uses       int
+	MostCommon string
+	Latest     string // Newest tagged version in use; empty when no version has a tag
+	Target     string
+	Moves      []ConsolidationMove
+}
+
+// versionParts extracts the numeric parts of a version tag. A SHA pin is ranked by the tag in its comment.
+func versionParts(version string) ([3]int, bool) {
+	var parts [3]int
+	ref, comment, _ := strings.Cut(version, "#")
+	m := versionTagRe.FindStringSubmatch(strings.TrimSpace(ref))
+	if m == nil {
+		m = versionTagRe.FindStringSubmatch(strings.TrimSpace(comment))
+	}
+	if m == nil {
+		return parts, false
+	}
+	for i := range parts {
+		if m[i+1] != "" {
+			parts[i], _ = strconv.Atoi(m[i+1])
+		}
+	}
+	return parts, true
+}
+
+// newerVersion reports whether version a sorts before b, newest first. Tagged versions come before
+// untagged ones, and ties are broken alphabetically to keep the order stable.
+func newerVersion(a, b string) bool {
+	aParts, aOK := versionParts(a)
+	bParts, bOK := versionParts(b)
+	if aOK != bOK {
+		return aOK
+	}
+	if aOK && aParts != bParts {
+		for i := range aParts {
+			if aParts[i] != bParts[i] {
+				return aParts[i] > bParts[i]
+			}
+		}
+	}
+	return a < b
+}
+
+// buildConsolidation summarizes the versions of each action used directly by workflows, keeping only
+// actions used at more than one version. The target is the newest tagged version in use, or the most
+// common version when none is tagged. Results are sorted by number of versions, then action.
+func buildConsolidation(usesIndex *ActionUsesIndex) []ActionConsolidation {
+	var consolidations []ActionConsolidation
+	for action, versions := range usesIndex.Actions {
+		c := ActionConsolidation{Action: action}
+		refsByVersion := make(map[string][]WorkflowReference)
+		for version, refs := range versions {
+			for _, ref := range refs {
+				// Versions used through composite actions are chosen by the composite's owner
+				if len(ref.Via) > 0 {
+					continue
+				}
+				refsByVersion[version] = append(refsByVersion[version], ref)
+			}
+		}
+		if len(refsByVersion) < 2 {
+			continue
+		}
+
+		for version, refs := range refsByVersion {
+			c.Versions = append(c.Versions, VersionCount{Version: version, Uses: len(refs)})
+			c.Uses += len(refs)
+		}
+		sort.Slice(c.Versions, func(i, j int) bool {
+			return newerVersion(c.Versions[i].Version, c.Versions[j].Version)
+		})
+
+		mostCommon := c.Versions[0]
+		for _, count := range c.Versions {
+			if count.Uses > mostCommon.Uses {
+				mostCommon = count
+			}
+		}
+		c.MostCommon = mostCommon.Version
+		if _, ok := versionParts(c.Versions[0].Version); ok {
+			c.Latest = c.Versions[0].Version
+		}
+		c.Target = c.Latest
+		if c.Target == "" {
+			c.Target = c.MostCommon
+		}
+
+		for version, refs := range refsByVersion {
+			if version == c.Target {
+				continue
+			}
+			for _, ref := range refs {
+				c.Moves = append(c.Moves, ConsolidationMove{RepoName: ref.RepoName, FilePath: ref.FilePath, From: version})
+			}
+		}
+		sort.Slice(c.Moves, func(i, j int) bool {
+			if c.Moves[i].RepoName != c.Moves[j].RepoName {
+				return c.Moves[i].RepoName < c.Moves[j].RepoName
+			}
+			if c.Moves[i].FilePath != c.Moves[j].FilePath {
+				return c.Moves[i].FilePath < c.Moves[j].FilePath
+			}
+			return c.Moves[i].From < c.Moves[j].From
+		})
+
+		consolidations = append(consolidations, c)
+	}
+
+	sort.Slice(consolidations, func(i, j int) bool {
+		if len(consolidations[i].Versions) != len(consolidations[j].Versions) {
+			return len(consolidations[i].Versions) > len(consolidations[j].Versions)
+		}
+		return consolidations[i].Action < consolidations[j].Action
+	})
+	return consolidations
+}
+
+// reportQuarter formats the calendar quarter of a time, such as 2026-Q3.
+func reportQuarter(t time.Time) string {
+	local := t.In(reportLocation)
+	return fmt.Sprintf("%d-Q%d", local.Year(), (int(local.Month())-1)/3+1)
+}
+
+// formatVersionHistogram draws the version distribution of an action as text bars.
+func formatVersionHistogram(versions []VersionCount) string {
+	width, most := 0, 0
+	for _, count := range versions {
+		width = max(width, len(count.Version))
+		most = max(most, count.Uses)
+	}
+
+	var builder strings.Builder
+	for _, count := range versions {
+		bar := max(1, count.Uses*histogramWidth/most)
+		builder.WriteString(fmt.Sprintf("%-*s  %s %d\n", width, count.Version, strings.Repeat("█", bar), count.Uses))
+	}
+	return builder.String()
+}
+
+// generateConsolidationMarkdown creates a CONSOLIDATION.md file in the db folder listing, for each action
+// used at several versions, its version distribution, the recommended target, and the files to move.
+func generateConsolidationMarkdown(dbPath, org string, usesIndex *ActionUsesIndex, now time.Time) error {
+	consolidations := buildConsolidation(usesIndex)
+
+	var markdownBuilder strings.Builder
+	markdownBuilder.WriteString("# Action Version Consolidation\n\n")
+	markdownBuilder.WriteString(fmt.Sprintf("Consolidation list for **%s**. Each action used directly by workflows at more than one version is listed with its version distribution. The recommended target is the newest tagged version in use, or the most common version when no version is tagged.\n\n", reportQuarter(now)))
+	markdownBuilder.WriteString("| Action | Versions | Uses | Most Common | Recommended Target | Files to Move |\n")
+	markdownBuilder.WriteString("|--------|----------|------|-------------|--------------------|---------------|\n")
+	if len(consolidations) == 0 {
+		markdownBuilder.WriteString("| *Every action is used at a single version* | - | - | - | - | - |\n")
+	}
+	for _, c := range consolidations {
+		markdownBuilder.WriteString(fmt.Sprintf("| [%s](#%s) | %d | %d | `%s` | `%s` | %d |\n",
+			c.Action, markdownAnchor(c.Action), len(c.Versions), c.Uses, c.MostCommon, c.Target, len(c.Moves)))
+	}
+	markdownBuilder.WriteString("\n")
+
+	for _, c := range consolidations {
+		markdownBuilder.WriteString(fmt.Sprintf("## %s\n\n", c.Action))
+		markdownBuilder.WriteString(fmt.Sprintf("**Recommended target**: `%s`\n\n", c.Target))
+		markdownBuilder.WriteString("```text\n" + formatVersionHistogram(c.Versions) + "```\n\n")
+
+		markdownBuilder.WriteString("<details>\n")
+		markdownBuilder.WriteString(fmt.Sprintf("<summary>%d workflow file(s) to move to %s</summary>\n\n", len(c.Moves), c.Target))
+		for _, move := range c.Moves {
+			link := fmt.Sprintf("%s/%s/%s/blob/main/%s", githubWebURL, org, move.RepoName, move.FilePath)
+			markdownBuilder.WriteString(fmt.Sprintf("- [%s/%s](%s) from `%s`\n", move.RepoName, move.FilePath, link, move.From))
+		}
+		markdownBuilder.WriteString("\n</details>\n\n")
+	}
+
+	markdownBuilder.WriteString("*This file is automatically generated after each data collection run.*\n")
+
+	if err := os.WriteFile(filepath.Join(dbPath, "CONSOLIDATION.md"), []byte(markdownBuilder.String()), 0644); err != nil {
+		return fmt.Errorf("error writing CONSOLIDATION.md: %v", err)
+	}
+
+	logInfof("Generated CONSOLIDATION.md with %d actions to consolidate", len(consolidations))
+	return nil
+}
+
+// markdownAnchor returns the anchor GitHub generates for a heading.
+func markdownAnchor(heading string) string {
+	var builder strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			builder.WriteRune(r)
+		case r == ' ':
+			builder.WriteRune('-')
+		}
+	}
+	return builder.String()
+}
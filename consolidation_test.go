@@ -0,0 +1,106 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewerVersion(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		a, b string
+		want bool
+	}{
+		{"v4", "v3", true},
+		{"v3.1.0", "v3", true},
+		{"0123456789abcdef0123456789abcdef01234567 # v4.2.0", "v4.1", true},
+		{"v2", "main", true},
+		{"main", "v2", false},
+		{"develop", "main", true},
+	}
+	for _, tc := range cases {
+		if got := newerVersion(tc.a, tc.b); got != tc.want {
+			t.Fatalf("newerVersion(%q, %q) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestBuildConsolidation(t *testing.T) {
+	t.Parallel()
+
+	ref := func(repoName string) WorkflowReference {
+		return WorkflowReference{RepoName: repoName, FilePath: ".github/workflows/build.yml"}
+	}
+	usesIndex := &ActionUsesIndex{Actions: map[string]map[string][]WorkflowReference{
+		"actions/cache": {
+			"v3":     {ref("repo-a"), ref("repo-b"), ref("repo-c")},
+			"v4":     {ref("repo-d")},
+			"v2":     {ref("repo-e")},
+			"v1.0.0": {{RepoName: "repo-f", FilePath: ".github/workflows/build.yml", Via: []string{"acme/setup@v1"}}},
+		},
+		"actions/checkout": {
+			"v4": {ref("repo-a"), ref("repo-b")},
+		},
+		"acme/tool": {
+			"main":    {ref("repo-a"), ref("repo-b")},
+			"develop": {ref("repo-c")},
+		},
+	}}
+
+	consolidations := buildConsolidation(usesIndex)
+	if len(consolidations) != 2 {
+		t.Fatalf("expected 2 actions to consolidate, got %+v", consolidations)
+	}
+
+	cache := consolidations[0]
+	if cache.Action != "actions/cache" || len(cache.Versions) != 3 || cache.Uses != 5 {
+		t.Fatalf("unexpected actions/cache consolidation: %+v", cache)
+	}
+	if cache.Versions[0].Version != "v4" || cache.Versions[2].Version != "v2" {
+		t.Fatalf("expected versions newest first, got %+v", cache.Versions)
+	}
+	if cache.MostCommon != "v3" || cache.Target != "v4" || len(cache.Moves) != 4 {
+		t.Fatalf("unexpected target: most common %s, target %s, %d moves", cache.MostCommon, cache.Target, len(cache.Moves))
+	}
+
+	tool := consolidations[1]
+	if tool.Latest != "" || tool.Target != "main" || len(tool.Moves) != 1 || tool.Moves[0].From != "develop" {
+		t.Fatalf("expected untagged action to converge on the most common version, got %+v", tool)
+	}
+}
+
+func TestGenerateConsolidationMarkdown(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	usesIndex := &ActionUsesIndex{Actions: map[string]map[string][]WorkflowReference{
+		"actions/cache": {
+			"v3": {{RepoName: "repo-a", FilePath: ".github/workflows/build.yml"}, {RepoName: "repo-b", FilePath: ".github/workflows/build.yml"}},
+			"v4": {{RepoName: "repo-c", FilePath: ".github/workflows/build.yml"}},
+		},
+	}}
+	now := time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)
+	if err := generateConsolidationMarkdown(dbPath, "acme", usesIndex, now); err != nil {
+		t.Fatalf("generateConsolidationMarkdown returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dbPath, "CONSOLIDATION.md"))
+	if err != nil {
+		t.Fatalf("failed to read CONSOLIDATION.md: %v", err)
+	}
+	content := string(data)
+	for _, want := range []string{
+		"Consolidation list for **2026-Q3**",
+		"| [actions/cache](#actionscache) | 2 | 3 | `v3` | `v4` | 2 |",
+		"v4  " + strings.Repeat("█", 10) + " 1\nv3  " + strings.Repeat("█", 20) + " 2\n",
+		"- [repo-a/.github/workflows/build.yml](https://github.com/acme/repo-a/blob/main/.github/workflows/build.yml) from `v3`",
+	} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("CONSOLIDATION.md is missing %q:\n%s", want, content)
+		}
+	}
+}
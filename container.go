@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// ------------------------
+// Section: Container Support
+// ------------------------
+
+// envPrefix is the prefix of the environment variables that supply flag values, e.g. DOTGITHUBINDEXER_ORG for -org.
+const envPrefix = "DOTGITHUBINDEXER_"
+
+// fallbackEnvNames are further environment variables read for a flag when its own is not set, in order.
+var fallbackEnvNames = map[string][]string{
+	"token": {"GITHUB_TOKEN"},
+}
+
+// secretFlags are the flags whose values are redacted from printed output.
+var secretFlags = map[string]bool{
+	"token": true,
+	"salt":  true,
+}
+
+// flagEnvName returns the environment variable that supplies a flag's value.
+func flagEnvName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// applyEnvironmentDefaults sets every flag that was not given on the command line from its environment
+// variable, so that a container can be configured without arguments and secrets need not appear in the
+// process arguments. Flags on the command line win. The values of secret flags are then registered for
+// redaction, wherever they came from.
+func applyEnvironmentDefaults(fs *flag.FlagSet) error {
+	defer fs.VisitAll(func(f *flag.Flag) {
+		if !secretFlags[f.Name] {
+			return
+		}
+		// The -token of index may hold several tokens
+		if tokens, ok := f.Value.(*tokenList); ok {
+			for _, secret := range *tokens {
+				redactSecret(secret)
+			}
+			return
+		}
+		redactSecret(f.Value.String())
+	})
+
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || explicit[f.Name] {
+			return
+		}
+		for _, envName := range append([]string{flagEnvName(f.Name)}, fallbackEnvNames[f.Name]...) {
+			value, ok := os.LookupEnv(envName)
+			if !ok {
+				continue
+			}
+			if setErr := fs.Set(f.Name, value); setErr != nil {
+				err = fmt.Errorf("invalid value for %s: %v", envName, setErr)
+			}
+			return
+		}
+	})
+	return err
+}
+
+// watchTermination returns a channel that is closed when the process receives SIGTERM or SIGINT, as sent
+// by Kubernetes when a pod is stopped. A second signal exits immediately.
+func watchTermination() <-chan struct{} {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+
+	stop := make(chan struct{})
+	go func() {
+		sig := <-signals
+		logWarnf("Received %v; finishing repositories in progress before exiting", sig)
+		close(stop)
+		sig = <-signals
+		logWarnf("Received %v again; exiting immediately", sig)
+		os.Exit(1)
+	}()
+	return stop
+}
+
+// stopRequested reports whether a stop channel has been closed. A nil channel never stops.
+func stopRequested(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// checkWritableDir verifies that a directory can be written, creating it if needed, so that a read-only
+// filesystem is reported before any repository is scanned rather than partway through the run.
+func checkWritableDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("directory '%s' is not writable: %v", dir, err)
+	}
+	probe, err := os.CreateTemp(dir, ".dotgithubindexer-write-check-")
+	if err != nil {
+		return fmt.Errorf("directory '%s' is not writable: %v", dir, err)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyEnvironmentDefaults(t *testing.T) {
+	t.Setenv("DOTGITHUBINDEXER_ORG", "env-org")
+	t.Setenv("DOTGITHUBINDEXER_CONCURRENCY", "4")
+	t.Setenv("DOTGITHUBINDEXER_CHECK_UPDATE", "false")
+	t.Setenv("DOTGITHUBINDEXER_DB", "/data/db")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	orgFlag := fs.String("org", "", "")
+	concurrencyFlag := fs.Int("concurrency", 1, "")
+	checkUpdateFlag := fs.Bool("check-update", true, "")
+	dbFlag := fs.String("db", "./db", "")
+	if err := fs.Parse([]string{"-db", "./local"}); err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if err := applyEnvironmentDefaults(fs); err != nil {
+		t.Fatalf("applyEnvironmentDefaults returned error: %v", err)
+	}
+
+	if *orgFlag != "env-org" || *concurrencyFlag != 4 || *checkUpdateFlag {
+		t.Fatalf("expected environment values, got org=%q concurrency=%d check-update=%t", *orgFlag, *concurrencyFlag, *checkUpdateFlag)
+	}
+	if *dbFlag != "./local" {
+		t.Fatalf("expected the command line to win over the environment, got %q", *dbFlag)
+	}
+
+	t.Setenv("DOTGITHUBINDEXER_CONCURRENCY", "many")
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("concurrency", 1, "")
+	if err := applyEnvironmentDefaults(fs); err == nil {
+		t.Fatalf("expected an error for an invalid value")
+	}
+}
+
+func TestApplyEnvironmentTokenFallback(t *testing.T) {
+	// Values shorter than minSecretLength, so that no redaction filter is installed in the test process
+	t.Setenv("GITHUB_TOKEN", "gh-tok")
+	t.Setenv("DOTGITHUBINDEXER_TOKEN", "")
+	os.Unsetenv("DOTGITHUBINDEXER_TOKEN")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	tokenFlag := fs.String("token", "", "")
+	if err := applyEnvironmentDefaults(fs); err != nil {
+		t.Fatalf("applyEnvironmentDefaults returned error: %v", err)
+	}
+	if *tokenFlag != "gh-tok" {
+		t.Fatalf("expected GITHUB_TOKEN to be used, got %q", *tokenFlag)
+	}
+
+	t.Setenv("DOTGITHUBINDEXER_TOKEN", "dgi-tok")
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	tokenFlag = fs.String("token", "", "")
+	if err := applyEnvironmentDefaults(fs); err != nil {
+		t.Fatalf("applyEnvironmentDefaults returned error: %v", err)
+	}
+	if *tokenFlag != "dgi-tok" {
+		t.Fatalf("expected DOTGITHUBINDEXER_TOKEN to win over GITHUB_TOKEN, got %q", *tokenFlag)
+	}
+}
+
+func TestStopRequested(t *testing.T) {
+	t.Parallel()
+
+	if stopRequested(nil) {
+		t.Fatalf("expected a nil channel to never stop")
+	}
+	stop := make(chan struct{})
+	if stopRequested(stop) {
+		t.Fatalf("expected an open channel not to stop")
+	}
+	close(stop)
+	if !stopRequested(stop) {
+		t.Fatalf("expected a closed channel to stop")
+	}
+}
+
+func TestCheckWritableDir(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "db")
+	if err := checkWritableDir(dir); err != nil {
+		t.Fatalf("checkWritableDir returned error: %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir returned error: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected the write check to leave no files, got %d", len(entries))
+	}
+
+	readOnly := filepath.Join(t.TempDir(), "readonly")
+	if err := os.Mkdir(readOnly, 0555); err != nil {
+		t.Fatalf("Mkdir returned error: %v", err)
+	}
+	if os.Getuid() != 0 {
+		if err := checkWritableDir(readOnly); err == nil {
+			t.Fatalf("expected an error for a read-only directory")
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: JSON Database Copies
+// ------------------------
+
+// dbFormat is the -format of the index and merge commands. With formatJSON, every generated YAML file in
+// the database gets a JSON copy; the YAML files stay, since later runs read them.
+var dbFormat = formatYAML
+
+// dbConfigFiles are the YAML files in the database root that users write to configure the tool. They are
+// inputs rather than indexes, so they get no JSON copy.
+var dbConfigFiles = map[string]bool{
+	"allowlist.yaml":     true,
+	"annotations.yaml":   true,
+	"budgets.yaml":       true,
+	"denylist.yaml":      true,
+	"dotfiles.yaml":      true,
+	"env-naming.yaml":    true,
+	"notifications.yaml": true,
+	"scope.yaml":         true,
+	"scorecard.yaml":     true,
+	"severities.yaml":    true,
+}
+
+// writeJSONCopies writes a .json file next to every generated .yaml file in the database and removes
+// JSON copies whose YAML file no longer exists. It returns the number of files written.
+func writeJSONCopies(dbPath string) (int, error) {
+	written := 0
+	err := filepath.WalkDir(dbPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if entry.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Dir(path) == filepath.Clean(dbPath) && (dbConfigFiles[entry.Name()] || entry.Name() == dbLockFile) {
+			return nil
+		}
+
+		switch filepath.Ext(path) {
+		case ".yaml":
+			if err := writeJSONCopy(path); err != nil {
+				return err
+			}
+			written++
+		case ".json":
+			// Remove the copy of an index whose YAML file was removed; other JSON files may be the user's
+			yamlPath := strings.TrimSuffix(path, ".json") + ".yaml"
+			if _, err := os.Stat(yamlPath); os.IsNotExist(err) && isJSONCopyName(entry.Name()) {
+				logInfof("Removing JSON copy '%s' of a deleted YAML file", path)
+				if err := os.Remove(path); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return written, fmt.Errorf("failed to write JSON copies: %v", err)
+	}
+	return written, nil
+}
+
+// isJSONCopyName reports whether a file name is one writeJSONCopies produces inside the indexed folders.
+func isJSONCopyName(name string) bool {
+	return name == "index.json" || name == "changelog.json"
+}
+
+// writeJSONCopy converts one YAML file to JSON and writes it next to the original.
+func writeJSONCopy(yamlPath string) error {
+	data, err := os.ReadFile(yamlPath)
+	if err != nil {
+		return err
+	}
+	var document any
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return fmt.Errorf("failed to parse '%s': %v", yamlPath, err)
+	}
+	output, err := formatJSONDocument(jsonCompatible(document))
+	if err != nil {
+		return fmt.Errorf("failed to convert '%s': %v", yamlPath, err)
+	}
+	return os.WriteFile(strings.TrimSuffix(yamlPath, ".yaml")+".json", []byte(output), 0644)
+}
+
+// jsonCompatible converts the maps with non-string keys that YAML allows into maps JSON can encode.
+func jsonCompatible(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = jsonCompatible(item)
+		}
+		return v
+	case map[any]any:
+		converted := make(map[string]any, len(v))
+		for key, item := range v {
+			converted[fmt.Sprint(key)] = jsonCompatible(item)
+		}
+		return converted
+	case []any:
+		for i, item := range v {
+			v[i] = jsonCompatible(item)
+		}
+		return v
+	default:
+		return value
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteJSONCopies(t *testing.T) {
+	t.Parallel()
+
+	dbPath := writeQueryTestDB(t)
+	if err := os.WriteFile(filepath.Join(dbPath, "budgets.yaml"), []byte("max_steps_per_job: 30\n"), 0644); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dbPath, ".git"), 0755); err != nil {
+		t.Fatalf("MkdirAll returned error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dbPath, ".git", "state.yaml"), []byte("a: b\n"), 0644); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	// A copy left behind by a workflow that is no longer indexed, and a JSON file of the user's
+	staleDir := filepath.Join(dbPath, "workflows", "old.yml")
+	if err := os.MkdirAll(staleDir, 0755); err != nil {
+		t.Fatalf("MkdirAll returned error: %v", err)
+	}
+	for _, path := range []string{filepath.Join(staleDir, "index.json"), filepath.Join(dbPath, "extra.json")} {
+		if err := os.WriteFile(path, []byte("{}\n"), 0644); err != nil {
+			t.Fatalf("WriteFile returned error: %v", err)
+		}
+	}
+
+	written, err := writeJSONCopies(dbPath)
+	if err != nil {
+		t.Fatalf("writeJSONCopies returned error: %v", err)
+	}
+	if written != 2 {
+		t.Fatalf("expected repositories.yaml and one index.yaml to be copied, got %d", written)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dbPath, "workflows", "build.yml", "index.json"))
+	if err != nil {
+		t.Fatalf("failed to read index.json: %v", err)
+	}
+	var index struct {
+		Repositories map[string]string            `json:"repositories"`
+		Annotations  map[string]map[string]string `json:"annotations"`
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		t.Fatalf("failed to parse index.json: %v", err)
+	}
+	if index.Repositories["repo-a"] != "hash-one" || index.Annotations["repo-a"]["owner"] != "team-x" {
+		t.Fatalf("unexpected index.json: %s", data)
+	}
+
+	data, err = os.ReadFile(filepath.Join(dbPath, "repositories.json"))
+	if err != nil {
+		t.Fatalf("failed to read repositories.json: %v", err)
+	}
+	var manifest struct {
+		Organization string   `json:"organization"`
+		Repositories []string `json:"repositories"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("failed to parse repositories.json: %v", err)
+	}
+	if manifest.Organization != "example-org" || len(manifest.Repositories) != 2 {
+		t.Fatalf("unexpected repositories.json: %s", data)
+	}
+
+	for path, want := range map[string]bool{
+		filepath.Join(dbPath, "budgets.json"):           false,
+		filepath.Join(dbPath, ".git", "state.json"):     false,
+		filepath.Join(staleDir, "index.json"):           false,
+		filepath.Join(dbPath, "extra.json"):             true,
+		filepath.Join(dbPath, "workflows", "build.yml"): true,
+	} {
+		if _, err := os.Stat(path); (err == nil) != want {
+			t.Fatalf("expected %s to exist: %v, got %v", path, want, err)
+		}
+	}
+}
+
+func TestJSONCompatible(t *testing.T) {
+	t.Parallel()
+
+	converted := jsonCompatible(map[string]any{"versions": []any{map[any]any{1: "one"}}})
+	if _, err := json.Marshal(converted); err != nil {
+		t.Fatalf("expected a JSON-encodable value, got %v", err)
+	}
+}
+
+func TestGarbageCollectKeepsJSONCopies(t *testing.T) {
+	t.Parallel()
+
+	// A layout 1 database keeps the JSON copies and READMEs next to the stored versions
+	dbPath := t.TempDir()
+	content := "version: 2\n"
+	hash := computeHash([]byte(content))
+	if err := updateActionIndex(dbPath, "build.yml", "repo-a", "build.yml", hash, ""); err != nil {
+		t.Fatalf("updateActionIndex returned error: %v", err)
+	}
+	if err := updateDependabotIndex(dbPath, "repo-a", ".github/dependabot.yml", hash, "", "Default"); err != nil {
+		t.Fatalf("updateDependabotIndex returned error: %v", err)
+	}
+	if err := updateDotfileIndex(dbPath, ".editorconfig", "repo-a", hash, "", "Default"); err != nil {
+		t.Fatalf("updateDotfileIndex returned error: %v", err)
+	}
+	renovatePath := filepath.Join(dbPath, renovateDir, "renovate.json")
+	if err := os.MkdirAll(renovatePath, 0755); err != nil {
+		t.Fatalf("MkdirAll returned error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(renovatePath, "index.yaml"), []byte("repositories:\n  repo-a: "+hash+"\n"), 0644); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	folders := []string{
+		filepath.Join(dbPath, "workflows", "build.yml"),
+		filepath.Join(dbPath, "dependabot", "Default"),
+		dotfileStoragePath(dbPath, ".editorconfig"),
+		renovatePath,
+	}
+	unused := strings.Repeat("0", 64)
+	for _, folder := range folders {
+		for _, name := range []string{hash, unused, "README.md", "changelog.yaml"} {
+			if err := os.WriteFile(filepath.Join(folder, name), []byte(content), 0644); err != nil {
+				t.Fatalf("WriteFile returned error: %v", err)
+			}
+		}
+	}
+	if _, err := writeJSONCopies(dbPath); err != nil {
+		t.Fatalf("writeJSONCopies returned error: %v", err)
+	}
+
+	for _, collect := range []func(string) error{garbageCollect, garbageCollectDependabot, garbageCollectDotfiles, garbageCollectRenovate} {
+		if err := collect(dbPath); err != nil {
+			t.Fatalf("garbage collection returned error: %v", err)
+		}
+	}
+	for _, folder := range folders {
+		for name, want := range map[string]bool{
+			hash:             true,
+			unused:           false,
+			"README.md":      true,
+			"index.yaml":     true,
+			"index.json":     true,
+			"changelog.json": true,
+		} {
+			if _, err := os.Stat(filepath.Join(folder, name)); (err == nil) != want {
+				t.Fatalf("expected %s in %s to exist: %v, got %v", name, folder, want, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+)
+
+// ------------------------
+// Section: Reviewed Database Updates
+// ------------------------
+
+// reviewBranchPrefix is the prefix of the branches database changes are pushed to in review mode.
+const reviewBranchPrefix = "dotgithubindexer/"
+
+// maxReviewFiles limits how many changed files are listed in a review pull request body.
+const maxReviewFiles = 100
+
+// pullRequestOpener opens a pull request in the database repository and returns its URL.
+type pullRequestOpener func(title, head, base, body string) (string, error)
+
+// githubPullRequestOpener returns a pullRequestOpener for a GitHub repository.
+func githubPullRequestOpener(client *github.Client, owner, repo string) pullRequestOpener {
+	return func(title, head, base, body string) (string, error) {
+		pr, _, err := client.PullRequests.Create(context.Background(), owner, repo, &github.NewPullRequest{
+			Title: github.String(title),
+			Head:  github.String(head),
+			Base:  github.String(base),
+			Body:  github.String(body),
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to open pull request: %v", err)
+		}
+		return pr.GetHTMLURL(), nil
+	}
+}
+
+// githubRepositoryFromURL returns the owner and name of the repository a git URL points to.
+func githubRepositoryFromURL(location string) (string, string, error) {
+	path := location
+	if scpLikeURLRe.MatchString(location) {
+		path = location[strings.Index(location, ":")+1:]
+	} else if parsed, err := url.Parse(location); err == nil && parsed.Host != "" {
+		path = parsed.Path
+	}
+	parts := strings.Split(strings.Trim(strings.TrimSuffix(path, ".git"), "/"), "/")
+	if len(parts) < 2 || parts[len(parts)-2] == "" || parts[len(parts)-1] == "" {
+		return "", "", fmt.Errorf("cannot determine the repository of '%s'", location)
+	}
+	return parts[len(parts)-2], parts[len(parts)-1], nil
+}
+
+// checkReviewMode validates -review against the -db value before anything is cloned.
+func checkReviewMode(review bool, location string) error {
+	if !review {
+		return nil
+	}
+	if !isGitURL(location) {
+		return fmt.Errorf("-review requires -db to be a git URL of a GitHub repository")
+	}
+	_, _, err := githubRepositoryFromURL(location)
+	return err
+}
+
+// publishDB publishes the changes in a database checkout, pushing them directly or, in review mode,
+// opening a pull request for them.
+func publishDB(checkout *DBCheckout, message string, review bool, token string) error {
+	if !review {
+		return checkout.Publish(message)
+	}
+	owner, repo, err := githubRepositoryFromURL(checkout.URL)
+	if err != nil {
+		return err
+	}
+	prURL, err := checkout.PublishForReview(message, time.Now(), githubPullRequestOpener(getGitHubClient(token), owner, repo))
+	if err != nil {
+		return err
+	}
+	if prURL != "" {
+		logInfof("Opened pull request for database changes: %s", prURL)
+	}
+	return nil
+}
+
+// PublishForReview commits every change in a cloned database to a new branch, pushes the branch, and opens
+// a pull request against the cloned branch summarizing the changes. It returns the pull request URL, or an
+// empty string when nothing changed.
+func (c *DBCheckout) PublishForReview(message string, now time.Time, openPR pullRequestOpener) (string, error) {
+	if c.URL == "" {
+		return "", fmt.Errorf("review mode requires a database cloned from a git URL")
+	}
+
+	if err := c.git("-C", c.Dir, "add", "-A"); err != nil {
+		return "", err
+	}
+	status, err := c.gitOutput("-C", c.Dir, "status", "--porcelain")
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(status) == "" {
+		logInfof("No database changes to review.")
+		return "", nil
+	}
+
+	base, err := c.gitOutput("-C", c.Dir, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	base = strings.TrimSpace(base)
+	nameStatus, err := c.gitOutput("-C", c.Dir, "diff", "--cached", "--name-status", "--no-renames")
+	if err != nil {
+		return "", err
+	}
+
+	branch := reviewBranchPrefix + "update-" + now.UTC().Format("20060102-150405")
+	if err := c.git("-C", c.Dir, "checkout", "-q", "-b", branch); err != nil {
+		return "", err
+	}
+	if err := c.commit(message); err != nil {
+		return "", err
+	}
+	if err := c.git("-C", c.Dir, "push", "-q", "origin", branch); err != nil {
+		return "", err
+	}
+	if err := c.pushCommitNotes(); err != nil {
+		return "", err
+	}
+	logInfof("Pushed database changes to branch '%s' of '%s'", branch, c.URL)
+
+	return openPR(message, branch, base, formatReviewBody(parseNameStatus(nameStatus)))
+}
+
+// DBFileChange is a file added, modified, or deleted in a database update.
+type DBFileChange struct {
+	Status string // A, M, or D as reported by git
+	Path   string
+}
+
+// parseNameStatus parses the output of git diff --name-status.
+func parseNameStatus(output string) []DBFileChange {
+	var changes []DBFileChange
+	for _, line := range strings.Split(output, "\n") {
+		status, path, found := strings.Cut(line, "\t")
+		if !found || status == "" {
+			continue
+		}
+		changes = append(changes, DBFileChange{Status: status[:1], Path: path})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// dbChangeArea returns the part of the database a changed file belongs to, such as workflows/build.yml.
+func dbChangeArea(path string) string {
+	parts := strings.Split(path, "/")
+	switch {
+	case len(parts) == 1:
+		return "reports and indexes"
+	case len(parts) == 2:
+		return parts[0]
+	default:
+		return parts[0] + "/" + parts[1]
+	}
+}
+
+// formatReviewBody builds the pull request body summarizing a database update.
+func formatReviewBody(changes []DBFileChange) string {
+	type areaCounts struct{ added, modified, deleted int }
+	counts := make(map[string]*areaCounts)
+	var areas []string
+	for _, change := range changes {
+		area := dbChangeArea(change.Path)
+		if counts[area] == nil {
+			counts[area] = &areaCounts{}
+			areas = append(areas, area)
+		}
+		switch change.Status {
+		case "A":
+			counts[area].added++
+		case "D":
+			counts[area].deleted++
+		default:
+			counts[area].modified++
+		}
+	}
+	sort.Strings(areas)
+
+	var body strings.Builder
+	body.WriteString("This pull request contains the database changes from a dotgithubindexer run. Review them before merging, as the index is only updated once this is merged.\n\n")
+	body.WriteString("| Area | Added | Modified | Deleted |\n")
+	body.WriteString("|------|-------|----------|---------|\n")
+	for _, area := range areas {
+		body.WriteString(fmt.Sprintf("| `%s` | %d | %d | %d |\n", area, counts[area].added, counts[area].modified, counts[area].deleted))
+	}
+
+	body.WriteString(fmt.Sprintf("\n<details>\n<summary>%d changed files</summary>\n\n", len(changes)))
+	for i, change := range changes {
+		if i == maxReviewFiles {
+			body.WriteString(fmt.Sprintf("- ...and %d more\n", len(changes)-maxReviewFiles))
+			break
+		}
+		body.WriteString(fmt.Sprintf("- `%s` %s\n", change.Status, change.Path))
+	}
+	body.WriteString("\n</details>\n\n")
+	body.WriteString("*Generated by dotgithubindexer.*\n")
+	return body.String()
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGitHubRepositoryFromURL(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"https://github.com/example-org/db.git":  "example-org/db",
+		"https://github.com/example-org/db":      "example-org/db",
+		"ssh://git@github.com/example-org/db":    "example-org/db",
+		"git@github.com:example-org/db.git":      "example-org/db",
+		"file:///srv/git/example-org/db.git":     "example-org/db",
+		"https://github.example.com/team/db.git": "team/db",
+	}
+	for location, want := range cases {
+		owner, repo, err := githubRepositoryFromURL(location)
+		if err != nil {
+			t.Fatalf("githubRepositoryFromURL(%q) returned error: %v", location, err)
+		}
+		if got := owner + "/" + repo; got != want {
+			t.Fatalf("githubRepositoryFromURL(%q) = %q, want %q", location, got, want)
+		}
+	}
+
+	if _, _, err := githubRepositoryFromURL("https://github.com/db.git"); err == nil {
+		t.Fatalf("expected an error for a URL without an owner")
+	}
+	if err := checkReviewMode(true, "./db"); err == nil {
+		t.Fatalf("expected review mode to require a git URL")
+	}
+	if err := checkReviewMode(false, "./db"); err != nil {
+		t.Fatalf("checkReviewMode returned error: %v", err)
+	}
+}
+
+func TestFormatReviewBody(t *testing.T) {
+	t.Parallel()
+
+	changes := parseNameStatus("M\tUSES.md\nA\tworkflows/build.yml/hash-two\nM\tworkflows/build.yml/index.yaml\nD\tworkflows/build.yml/hash-one\nA\trepositories/repo-a.md\n")
+	body := formatReviewBody(changes)
+
+	for _, want := range []string{
+		"| `reports and indexes` | 0 | 1 | 0 |",
+		"| `repositories` | 1 | 0 | 0 |",
+		"| `workflows/build.yml` | 1 | 1 | 1 |",
+		"<summary>5 changed files</summary>",
+		"- `D` workflows/build.yml/hash-one",
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected body to contain %q, got:\n%s", want, body)
+		}
+	}
+}
+
+func TestPublishForReview(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	remote := filepath.Join(t.TempDir(), "db.git")
+	if out, err := exec.Command("git", "init", "-q", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v: %s", err, out)
+	}
+	url := "file://" + remote
+
+	// Seed the default branch so the review branch has a base
+	seed, err := openDB(url, "")
+	if err != nil {
+		t.Fatalf("openDB returned error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(seed.Dir, "repositories.yaml"), []byte("organization: example-org\n"), 0644); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	if err := seed.Publish("Seed"); err != nil {
+		t.Fatalf("Publish returned error: %v", err)
+	}
+	seed.Close()
+	defaultBranch, err := exec.Command("git", "-C", remote, "symbolic-ref", "--short", "HEAD").Output()
+	if err != nil {
+		t.Fatalf("git symbolic-ref failed: %v", err)
+	}
+
+	checkout, err := openDB(url, "")
+	if err != nil {
+		t.Fatalf("openDB returned error: %v", err)
+	}
+	defer checkout.Close()
+
+	var head, base, body string
+	opener := func(title, h, b, text string) (string, error) {
+		head, base, body = h, b, text
+		return "https://github.com/example-org/db/pull/1", nil
+	}
+
+	// Nothing changed, so no pull request is opened
+	prURL, err := checkout.PublishForReview("Update example-org index", time.Now(), opener)
+	if err != nil || prURL != "" || head != "" {
+		t.Fatalf("expected no pull request without changes, got %q, %v", prURL, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(checkout.Dir, "USES.md"), []byte("# Uses\n"), 0644); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	now := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	prURL, err = checkout.PublishForReview("Update example-org index", now, opener)
+	if err != nil {
+		t.Fatalf("PublishForReview returned error: %v", err)
+	}
+	if prURL == "" || head != "dotgithubindexer/update-20260304-050607" || base != strings.TrimSpace(string(defaultBranch)) {
+		t.Fatalf("unexpected pull request %q from %q into %q", prURL, head, base)
+	}
+	if !strings.Contains(body, "- `A` USES.md") {
+		t.Fatalf("expected the body to list the change, got:\n%s", body)
+	}
+
+	// The change is on the review branch only
+	if err := exec.Command("git", "-C", remote, "cat-file", "-e", head+":USES.md").Run(); err != nil {
+		t.Fatalf("expected USES.md on the review branch: %v", err)
+	}
+	if err := exec.Command("git", "-C", remote, "cat-file", "-e", base+":USES.md").Run(); err == nil {
+		t.Fatalf("expected the default branch to be unchanged")
+	}
+}
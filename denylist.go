@@ -0,0 +1,190 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Compromised Action Denylist
+// ------------------------
+
+//go:embed compromised_actions.yaml
+var builtinDenylistData []byte
+
+// DenylistEntry describes an action whose versions were involved in a supply-chain incident.
+type DenylistEntry struct {
+	Action      string   `yaml:"action"`
+	Advisory    string   `yaml:"advisory"`
+	Description string   `yaml:"description"`
+	Refs        []string `yaml:"refs"`
+	SHAs        []string `yaml:"shas"`
+}
+
+// Denylist is the list of known-compromised actions.
+type Denylist struct {
+	Actions []DenylistEntry `yaml:"actions"`
+}
+
+// compromisedActions is the active denylist used by the analyzers.
+var compromisedActions = mustParseDenylist(builtinDenylistData)
+
+// mustParseDenylist parses the built-in denylist and panics if it is invalid.
+func mustParseDenylist(data []byte) []DenylistEntry {
+	var denylist Denylist
+	if err := yaml.Unmarshal(data, &denylist); err != nil {
+		panic(fmt.Sprintf("invalid built-in denylist: %v", err))
+	}
+	return denylist.Actions
+}
+
+// loadDenylist extends the built-in denylist with the optional denylist.yaml in the database directory.
+func loadDenylist(dbPath string) error {
+	entries := mustParseDenylist(builtinDenylistData)
+
+	data, err := os.ReadFile(filepath.Join(dbPath, "denylist.yaml"))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+	} else {
+		var extra Denylist
+		if err := yaml.Unmarshal(data, &extra); err != nil {
+			return fmt.Errorf("failed to parse denylist: %v", err)
+		}
+		entries = append(entries, extra.Actions...)
+		logInfof("Loaded %d additional denylist entries from 'denylist.yaml'", len(extra.Actions))
+	}
+
+	compromisedActions = entries
+	return nil
+}
+
+// matchDenylist returns the denylist entry matching an action use, or nil if the use is not compromised.
+func matchDenylist(action, version string) *DenylistEntry {
+	ref, _, _ := strings.Cut(version, " ")
+	for i := range compromisedActions {
+		entry := &compromisedActions[i]
+		if !strings.EqualFold(entry.Action, action) {
+			continue
+		}
+		if len(entry.Refs) == 0 && len(entry.SHAs) == 0 {
+			return entry
+		}
+		if slices.Contains(entry.SHAs, strings.ToLower(ref)) {
+			return entry
+		}
+		if isPinnedVersion(ref) {
+			continue
+		}
+		if slices.Contains(entry.Refs, "*") || slices.Contains(entry.Refs, ref) {
+			return entry
+		}
+	}
+	return nil
+}
+
+// scanForCompromisedActions reports every use of a known-compromised action as a critical finding.
+func scanForCompromisedActions(content, repoName, filePath string) []Finding {
+	var findings []Finding
+	for _, use := range extractActionUses(content, repoName, filePath) {
+		entry := matchDenylist(use.Action, use.Version)
+		if entry == nil {
+			continue
+		}
+		findings = append(findings, newFinding("compromised-action", repoName, filePath, findUsesLine(content, use.Action),
+			fmt.Sprintf("Known-compromised action %s@%s (%s)", use.Action, use.Version, entry.Advisory)))
+	}
+	return findings
+}
+
+// findUsesLine returns the first line number referencing an action in a uses statement, or 0 if not found.
+func findUsesLine(content, action string) int {
+	for i, line := range strings.Split(content, "\n") {
+		if strings.Contains(line, "uses:") && strings.Contains(line, action+"@") {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// generateIncidentsMarkdown creates an INCIDENTS.md file listing repositories that use known-compromised actions.
+func generateIncidentsMarkdown(dbPath, org string) error {
+	type incidentUse struct {
+		RepoName string
+		FilePath string
+		Version  string
+	}
+	incidents := make(map[string][]incidentUse)
+	entries := make(map[string]*DenylistEntry)
+
+	err := walkIndexedWorkflows(dbPath, func(fileName, repoName, content string) {
+		filePath := ".github/workflows/" + fileName
+		for _, use := range extractActionUses(content, repoName, filePath) {
+			entry := matchDenylist(use.Action, use.Version)
+			if entry == nil {
+				continue
+			}
+			key := entry.Action + " " + entry.Advisory
+			entries[key] = entry
+			incidents[key] = append(incidents[key], incidentUse{RepoName: repoName, FilePath: filePath, Version: use.Version})
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(incidents))
+	for key := range incidents {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var markdownBuilder strings.Builder
+	markdownBuilder.WriteString("# Incidents\n\n")
+	markdownBuilder.WriteString("This document lists repositories using actions involved in published supply-chain incidents.\n\n")
+
+	if len(keys) == 0 {
+		markdownBuilder.WriteString("*No known-compromised actions found.*\n")
+	}
+
+	for _, key := range keys {
+		entry := entries[key]
+		uses := incidents[key]
+		sort.Slice(uses, func(i, j int) bool {
+			if uses[i].RepoName == uses[j].RepoName {
+				if uses[i].FilePath == uses[j].FilePath {
+					return uses[i].Version < uses[j].Version
+				}
+				return uses[i].FilePath < uses[j].FilePath
+			}
+			return uses[i].RepoName < uses[j].RepoName
+		})
+
+		markdownBuilder.WriteString(fmt.Sprintf("## %s (%s)\n\n", entry.Action, entry.Advisory))
+		markdownBuilder.WriteString(fmt.Sprintf("%s\n\n", entry.Description))
+		markdownBuilder.WriteString("| Repository | File | Version |\n")
+		markdownBuilder.WriteString("|------------|------|---------|\n")
+		for _, use := range uses {
+			url := fmt.Sprintf("%s/%s/%s/blob/main/%s", githubWebURL, org, use.RepoName, use.FilePath)
+			markdownBuilder.WriteString(fmt.Sprintf("| %s | [%s](%s) | `%s` |\n", use.RepoName, use.FilePath, url, use.Version))
+		}
+		markdownBuilder.WriteString("\n")
+	}
+
+	markdownBuilder.WriteString("\n*This file is automatically generated after each data collection run.*\n")
+
+	if err := os.WriteFile(filepath.Join(dbPath, "INCIDENTS.md"), []byte(markdownBuilder.String()), 0644); err != nil {
+		return fmt.Errorf("error writing INCIDENTS.md: %v", err)
+	}
+
+	logInfof("Generated INCIDENTS.md with %d incidents", len(keys))
+	return nil
+}
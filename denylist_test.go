@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatchDenylist(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		action  string
+		version string
+		want    bool
+	}{
+		{name: "any tag", action: "tj-actions/changed-files", version: "v45", want: true},
+		{name: "compromised sha", action: "tj-actions/changed-files", version: "0e58ed8671d6b60d0890c21b07f8835ace038e67 # v45", want: true},
+		{name: "safe sha", action: "tj-actions/changed-files", version: "2f7c5bfce28377bc069a65ba478de0a74aa0ca32", want: false},
+		{name: "listed ref", action: "reviewdog/action-setup", version: "v1", want: true},
+		{name: "unlisted ref", action: "reviewdog/action-setup", version: "v1.3.0", want: false},
+		{name: "unrelated action", action: "actions/checkout", version: "v4", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := matchDenylist(tt.action, tt.version) != nil
+			if got != tt.want {
+				t.Fatalf("matchDenylist(%q, %q) = %t, want %t", tt.action, tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanForCompromisedActions(t *testing.T) {
+	t.Parallel()
+
+	content := "jobs:\n  build:\n    steps:\n      - uses: actions/checkout@v4\n      - uses: tj-actions/changed-files@v45\n"
+	findings := scanForCompromisedActions(content, "repo-a", ".github/workflows/build.yml")
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %+v", findings)
+	}
+	if findings[0].Severity != SeverityCritical || findings[0].Rule != "compromised-action" || findings[0].Line != 5 {
+		t.Fatalf("unexpected finding: %+v", findings[0])
+	}
+}
+
+func TestGenerateIncidentsMarkdown(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	workflowDir := filepath.Join(dbPath, "workflows", "build.yml")
+	if err := os.MkdirAll(workflowDir, 0755); err != nil {
+		t.Fatalf("MkdirAll returned error: %v", err)
+	}
+	content := "jobs:\n  build:\n    steps:\n      - uses: reviewdog/action-setup@v1\n"
+	if err := os.WriteFile(filepath.Join(workflowDir, "abc123"), []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(workflowDir, "index.yaml"), []byte("repositories:\n  repo-a: abc123\n"), 0644); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+
+	if err := generateIncidentsMarkdown(dbPath, "example-org"); err != nil {
+		t.Fatalf("generateIncidentsMarkdown returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dbPath, "INCIDENTS.md"))
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+	report := string(data)
+	for _, want := range []string{"## reviewdog/action-setup (GHSA-qmg3-hpqr-gqvc)", "| repo-a |", "`v1`"} {
+		if !strings.Contains(report, want) {
+			t.Fatalf("expected INCIDENTS.md to contain %q, got:\n%s", want, report)
+		}
+	}
+}
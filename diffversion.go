@@ -0,0 +1,547 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ------------------------
+// Section: Version Diff
+// ------------------------
+
+// Output formats of diff-version, besides formatHTML.
+const (
+	formatUnified    = "unified"
+	formatSideBySide = "side-by-side"
+)
+
+// diffOp is a line, or a word, of a diff: kept in both versions, removed from the first, or added in the second.
+type diffOp struct {
+	Kind byte // ' ' kept, '-' removed, or '+' added
+	Text string
+	Old  int // Line number in the first version; 0 for an added line
+	New  int // Line number in the second version; 0 for a removed line
+}
+
+// diffHunk is a run of changed lines with the unchanged lines around them.
+type diffHunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+	Ops                []diffOp
+}
+
+// header formats the range of the hunk as in a unified diff.
+func (h diffHunk) header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+}
+
+// VersionDiff is the comparison of two stored versions of a file.
+type VersionDiff struct {
+	Action  string
+	From    string
+	To      string
+	Added   int
+	Removed int
+	Hunks   []diffHunk
+}
+
+// runDiffVersionCommand prints the differences between two stored versions of a workflow file.
+func runDiffVersionCommand(args []string) int {
+	fs := flag.NewFlagSet("diff-version", flag.ContinueOnError)
+	diffDB := fs.String("db", "./db", "Path to the database repository, or a git URL to clone")
+	diffToken := fs.String("token", "", "GitHub API token used to clone an HTTPS database URL")
+	diffOrg := fs.String("org", "", "Organization whose versions are compared, in a database of several organizations")
+	format := fs.String("format", formatUnified, "Output format: unified, side-by-side, or html, which highlights the changed words of changed lines")
+	contextLines := fs.Int("context", 3, "Number of unchanged lines shown around each change")
+	width := fs.Int("width", 160, "Width of the side-by-side output in columns")
+	output := fs.String("output", "", "File to write the diff to; defaults to standard output")
+	logFlags := addLogFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if err := applyEnvironmentDefaults(fs); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := logFlags.apply(); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := checkFormat(*format, formatUnified, formatSideBySide, formatHTML); err != nil {
+		logErrorf("%v", err)
+		printDiffVersionUsage()
+		fs.PrintDefaults()
+		return 1
+	}
+	if fs.NArg() != 3 || *contextLines < 0 || *width < 40 {
+		printDiffVersionUsage()
+		fs.PrintDefaults()
+		return 1
+	}
+
+	checkout, err := openDB(*diffDB, *diffToken)
+	if err != nil {
+		logErrorf("Failed to open database: %v", err)
+		return 1
+	}
+	defer checkout.Close()
+
+	dir := checkout.Dir
+	orgs, err := loadOrganizations(dir)
+	if err != nil {
+		logErrorf("Failed to read organizations: %v", err)
+		return 1
+	}
+	if *diffOrg != "" && orgs != nil {
+		dir = filepath.Join(dir, *diffOrg)
+	} else if orgs != nil {
+		logErrorf("The database indexes several organizations; give one with -org: %s", strings.Join(orgs, ", "))
+		return 1
+	}
+
+	diff, err := buildVersionDiff(dir, fs.Arg(0), fs.Arg(1), fs.Arg(2), *contextLines)
+	if err != nil {
+		logErrorf("Diff failed: %v", err)
+		return 1
+	}
+
+	var content string
+	switch *format {
+	case formatSideBySide:
+		content = formatSideBySideDiff(diff, *width)
+	case formatHTML:
+		content, err = formatHTMLDiff(diff)
+	default:
+		content = formatUnifiedDiff(diff)
+	}
+	if err != nil {
+		logErrorf("Diff failed: %v", err)
+		return 1
+	}
+	if len(diff.Hunks) == 0 {
+		logInfof("The versions are identical")
+	}
+
+	if *output == "" {
+		fmt.Fprint(resultWriter, content)
+		return 0
+	}
+	if err := os.WriteFile(*output, []byte(content), 0644); err != nil {
+		logErrorf("Error writing %s: %v", *output, err)
+		return 1
+	}
+	logInfof("Wrote diff to %s", *output)
+	return 0
+}
+
+// printDiffVersionUsage prints the usage for the diff-version command.
+func printDiffVersionUsage() {
+	fmt.Println("Usage: dotgithubindexer diff-version [-db <path or git URL>] [-org <organization>] [-format unified|side-by-side|html] [-context <lines>] [-width <columns>] [-output <file>] <action> <hashA> <hashB>")
+}
+
+// versionFolder returns the folder of a file in the database: a workflow file name such as build.yml is in
+// workflows, and a path such as actions/setup or dependabot/dependabot.yml is relative to the database.
+func versionFolder(dbPath, action string) (string, error) {
+	rel := filepath.Join("workflows", action)
+	if strings.Contains(action, "/") {
+		rel = filepath.FromSlash(action)
+	}
+	if !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("'%s' is not a folder of the database", action)
+	}
+	folder := filepath.Join(dbPath, rel)
+	if _, err := os.Stat(filepath.Join(folder, "index.yaml")); err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("'%s' is not indexed", action)
+		}
+		return "", err
+	}
+	return folder, nil
+}
+
+// resolveStoredVersion returns the full hash of the stored version of a folder that a hash, or a unique
+// prefix of one such as the abbreviated hashes of CHANGELOG.md, names.
+func resolveStoredVersion(folder, hash string) (string, error) {
+	if hash == "" {
+		return "", errors.New("empty version hash")
+	}
+	hash = strings.ToLower(hash)
+	entries, err := os.ReadDir(versionsDir(folder))
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	var matches []string
+	for _, entry := range entries {
+		if storedVersionRe.MatchString(entry.Name()) && strings.HasPrefix(entry.Name(), hash) {
+			matches = append(matches, entry.Name())
+		}
+	}
+	sort.Strings(matches)
+
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		// A version indexed in a database built with -store-content=false has no content to compare
+		if index, err := readActionIndex(filepath.Join(folder, "index.yaml")); err == nil {
+			for indexed := range index.Versions {
+				if strings.HasPrefix(indexed, hash) {
+					return "", fmt.Errorf("version %s is indexed but its content is not stored", shortHash(indexed))
+				}
+			}
+		}
+		return "", fmt.Errorf("no stored version matches '%s'", hash)
+	}
+	short := make([]string, len(matches))
+	for i, match := range matches {
+		short[i] = shortHash(match)
+	}
+	return "", fmt.Errorf("'%s' matches several stored versions: %s", hash, strings.Join(short, ", "))
+}
+
+// buildVersionDiff compares two stored versions of a file, each named by its hash or a unique prefix.
+func buildVersionDiff(dbPath, action, from, to string, contextLines int) (*VersionDiff, error) {
+	folder, err := versionFolder(dbPath, action)
+	if err != nil {
+		return nil, err
+	}
+	diff := &VersionDiff{Action: action}
+	var contents [2]string
+	for i, hash := range []string{from, to} {
+		full, err := resolveStoredVersion(folder, hash)
+		if err != nil {
+			return nil, err
+		}
+		data, err := readVersionFile(versionPath(folder, full))
+		if err != nil {
+			return nil, err
+		}
+		contents[i] = string(data)
+		if i == 0 {
+			diff.From = full
+		} else {
+			diff.To = full
+		}
+	}
+
+	ops := diffSequences(splitDiffLines(contents[0]), splitDiffLines(contents[1]))
+	for _, op := range ops {
+		switch op.Kind {
+		case '+':
+			diff.Added++
+		case '-':
+			diff.Removed++
+		}
+	}
+	diff.Hunks = diffHunks(ops, contextLines)
+	return diff, nil
+}
+
+// splitDiffLines splits content into lines, without an empty line after its final newline.
+func splitDiffLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// diffSequences returns the edits turning a into b, from the longest common subsequence of their
+// elements. Removals are listed before the additions that replace them.
+func diffSequences(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, max(len(a), len(b)))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{Kind: ' ', Text: a[i], Old: i + 1, New: j + 1})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{Kind: '-', Text: a[i], Old: i + 1})
+			i++
+		default:
+			ops = append(ops, diffOp{Kind: '+', Text: b[j], New: j + 1})
+			j++
+		}
+	}
+	return ops
+}
+
+// diffHunks groups the changes of a diff into hunks with up to contextLines unchanged lines around each
+// change, merging hunks whose context would overlap.
+func diffHunks(ops []diffOp, contextLines int) []diffHunk {
+	var hunks []diffHunk
+	start, end := -1, -1
+	flush := func() {
+		if start < 0 {
+			return
+		}
+		hunk := diffHunk{Ops: ops[start : end+1]}
+		oldBefore, newBefore := 0, 0
+		for _, op := range ops[:start] {
+			if op.Kind != '+' {
+				oldBefore++
+			}
+			if op.Kind != '-' {
+				newBefore++
+			}
+		}
+		for _, op := range hunk.Ops {
+			if op.Kind != '+' {
+				hunk.OldLines++
+			}
+			if op.Kind != '-' {
+				hunk.NewLines++
+			}
+		}
+		// As in diff -u, an empty range starts at the line before it
+		hunk.OldStart, hunk.NewStart = oldBefore, newBefore
+		if hunk.OldLines > 0 {
+			hunk.OldStart++
+		}
+		if hunk.NewLines > 0 {
+			hunk.NewStart++
+		}
+		hunks = append(hunks, hunk)
+	}
+
+	for i, op := range ops {
+		if op.Kind == ' ' {
+			continue
+		}
+		from, to := max(0, i-contextLines), min(len(ops)-1, i+contextLines)
+		if start >= 0 && from <= end+1 {
+			end = max(end, to)
+			continue
+		}
+		flush()
+		start, end = from, to
+	}
+	flush()
+	return hunks
+}
+
+// formatUnifiedDiff renders a diff in the unified format of diff -u, naming each version by its short hash.
+func formatUnifiedDiff(diff *VersionDiff) string {
+	var b strings.Builder
+	if len(diff.Hunks) == 0 {
+		return ""
+	}
+	fmt.Fprintf(&b, "--- %s@%s\n", diff.Action, shortHash(diff.From))
+	fmt.Fprintf(&b, "+++ %s@%s\n", diff.Action, shortHash(diff.To))
+	for _, hunk := range diff.Hunks {
+		b.WriteString(hunk.header() + "\n")
+		for _, op := range hunk.Ops {
+			b.WriteByte(op.Kind)
+			b.WriteString(op.Text + "\n")
+		}
+	}
+	return b.String()
+}
+
+// diffRow is a row of a side-by-side diff, pairing a removed line with the added line that replaces it.
+type diffRow struct {
+	Kind    byte // ' ' kept, '|' changed, '<' removed, or '>' added
+	Old     int  // Line number in the first version; 0 when the side is empty
+	New     int  // Line number in the second version; 0 when the side is empty
+	OldText string
+	NewText string
+}
+
+// diffRows pairs the lines of a hunk for a side-by-side diff. A run of removed lines and the run of added
+// lines after it are paired in order, and the lines left over of the longer run stand alone.
+func diffRows(ops []diffOp) []diffRow {
+	var rows []diffRow
+	for i := 0; i < len(ops); {
+		if ops[i].Kind == ' ' {
+			rows = append(rows, diffRow{Kind: ' ', Old: ops[i].Old, New: ops[i].New, OldText: ops[i].Text, NewText: ops[i].Text})
+			i++
+			continue
+		}
+		var removed, added []diffOp
+		for ; i < len(ops) && ops[i].Kind == '-'; i++ {
+			removed = append(removed, ops[i])
+		}
+		for ; i < len(ops) && ops[i].Kind == '+'; i++ {
+			added = append(added, ops[i])
+		}
+		for k := range max(len(removed), len(added)) {
+			switch {
+			case k < len(removed) && k < len(added):
+				rows = append(rows, diffRow{Kind: '|', Old: removed[k].Old, New: added[k].New, OldText: removed[k].Text, NewText: added[k].Text})
+			case k < len(removed):
+				rows = append(rows, diffRow{Kind: '<', Old: removed[k].Old, OldText: removed[k].Text})
+			default:
+				rows = append(rows, diffRow{Kind: '>', New: added[k].New, NewText: added[k].Text})
+			}
+		}
+	}
+	return rows
+}
+
+// fitColumn expands the tabs of a line and pads or truncates it to width characters.
+func fitColumn(text string, width int) string {
+	text = strings.ReplaceAll(text, "\t", "    ")
+	if utf8.RuneCountInString(text) > width {
+		runes := []rune(text)
+		return string(runes[:width-1]) + "…"
+	}
+	return text + strings.Repeat(" ", width-utf8.RuneCountInString(text))
+}
+
+// formatSideBySideDiff renders a diff in two columns of width columns in all, marking changed lines with
+// |, removed lines with <, and added lines with >, as diff -y does.
+func formatSideBySideDiff(diff *VersionDiff, width int) string {
+	if len(diff.Hunks) == 0 {
+		return ""
+	}
+	column := (width - 3) / 2
+	var b strings.Builder
+	line := func(left string, marker byte, right string) {
+		b.WriteString(strings.TrimRight(fitColumn(left, column)+" "+string(marker)+" "+fitColumn(right, column), " ") + "\n")
+	}
+	line(diff.Action+"@"+shortHash(diff.From), ' ', diff.Action+"@"+shortHash(diff.To))
+	for _, hunk := range diff.Hunks {
+		b.WriteString(hunk.header() + "\n")
+		for _, row := range diffRows(hunk.Ops) {
+			line(row.OldText, row.Kind, row.NewText)
+		}
+	}
+	return b.String()
+}
+
+// diffSegment is a run of words of a line in the HTML diff, highlighted when it changed.
+type diffSegment struct {
+	Text    string
+	Changed bool
+}
+
+// diffWordRe splits a line into words, runs of whitespace, and single punctuation characters.
+var diffWordRe = regexp.MustCompile(`\w+|\s+|[^\w\s]`)
+
+// diffWords compares the words of a changed line with those of the line replacing it, returning the
+// segments of each with the words only it has marked as changed.
+func diffWords(oldText, newText string) (oldSegments, newSegments []diffSegment) {
+	appendSegment := func(segments []diffSegment, text string, changed bool) []diffSegment {
+		if n := len(segments); n > 0 && segments[n-1].Changed == changed {
+			segments[n-1].Text += text
+			return segments
+		}
+		return append(segments, diffSegment{Text: text, Changed: changed})
+	}
+	for _, op := range diffSequences(diffWordRe.FindAllString(oldText, -1), diffWordRe.FindAllString(newText, -1)) {
+		if op.Kind != '+' {
+			oldSegments = appendSegment(oldSegments, op.Text, op.Kind == '-')
+		}
+		if op.Kind != '-' {
+			newSegments = appendSegment(newSegments, op.Text, op.Kind == '+')
+		}
+	}
+	return oldSegments, newSegments
+}
+
+// htmlDiffRow is a row of the HTML diff: the header of a hunk, or a pair of lines split into segments.
+type htmlDiffRow struct {
+	Header      string
+	Old         int
+	New         int
+	OldClass    string
+	NewClass    string
+	OldSegments []diffSegment
+	NewSegments []diffSegment
+}
+
+var diffHTMLTemplate = template.Must(template.New("diff").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Action}}: {{.From}} → {{.To}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+td { font-family: monospace; padding: 0 6px; white-space: pre-wrap; vertical-align: top; width: 50%; }
+td.line { color: #888; text-align: right; width: 1%; white-space: nowrap; }
+tr.hunk td { background: #f1f8ff; color: #555; }
+td.removed { background: #ffeef0; }
+td.added { background: #e6ffed; }
+del { background: #fdb8c0; text-decoration: none; }
+ins { background: #acf2bd; text-decoration: none; }
+</style>
+</head>
+<body>
+<h1>{{.Action}}</h1>
+<p><code>{{.From}}</code> → <code>{{.To}}</code>: {{.Added}} added and {{.Removed}} removed lines</p>
+{{- if not .Rows}}
+<p><em>The versions are identical.</em></p>
+{{- else}}
+<table>
+{{- range .Rows}}
+{{- if .Header}}
+<tr class="hunk"><td colspan="4">{{.Header}}</td></tr>
+{{- else}}
+<tr><td class="line">{{if .Old}}{{.Old}}{{end}}</td><td class="{{.OldClass}}">{{range .OldSegments}}{{if .Changed}}<del>{{.Text}}</del>{{else}}{{.Text}}{{end}}{{end}}</td><td class="line">{{if .New}}{{.New}}{{end}}</td><td class="{{.NewClass}}">{{range .NewSegments}}{{if .Changed}}<ins>{{.Text}}</ins>{{else}}{{.Text}}{{end}}{{end}}</td></tr>
+{{- end}}
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`))
+
+// formatHTMLDiff renders a diff as a side-by-side HTML table, highlighting the changed words of changed lines.
+func formatHTMLDiff(diff *VersionDiff) (string, error) {
+	var rows []htmlDiffRow
+	for _, hunk := range diff.Hunks {
+		rows = append(rows, htmlDiffRow{Header: hunk.header()})
+		for _, row := range diffRows(hunk.Ops) {
+			html := htmlDiffRow{Old: row.Old, New: row.New}
+			switch row.Kind {
+			case '|':
+				html.OldClass, html.NewClass = "removed", "added"
+				html.OldSegments, html.NewSegments = diffWords(row.OldText, row.NewText)
+			case '<':
+				html.OldClass = "removed"
+				html.OldSegments = []diffSegment{{Text: row.OldText}}
+			case '>':
+				html.NewClass = "added"
+				html.NewSegments = []diffSegment{{Text: row.NewText}}
+			default:
+				html.OldSegments = []diffSegment{{Text: row.OldText}}
+				html.NewSegments = []diffSegment{{Text: row.NewText}}
+			}
+			rows = append(rows, html)
+		}
+	}
+
+	var htmlBuilder strings.Builder
+	err := diffHTMLTemplate.Execute(&htmlBuilder, struct {
+		*VersionDiff
+		Rows []htmlDiffRow
+	}{diff, rows})
+	if err != nil {
+		return "", err
+	}
+	return htmlBuilder.String(), nil
+}
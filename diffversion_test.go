@@ -0,0 +1,159 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeDiffTestDB stores two versions of build.yml, and indexes a third whose content is not stored,
+// returning the database and the hashes of the versions.
+func writeDiffTestDB(t *testing.T) (string, []string) {
+	t.Helper()
+
+	dbPath := t.TempDir()
+	contents := []string{
+		"name: build\non: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/checkout@v3\n      - run: make test\n      - run: make lint\n",
+		"name: build\non: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/checkout@v4\n      - run: make test\n      - run: make lint\n      - run: make release\n",
+		"name: unstored\n",
+	}
+	var hashes []string
+	for i, content := range contents {
+		sum := sha256.Sum256([]byte(content))
+		hash := hex.EncodeToString(sum[:])
+		hashes = append(hashes, hash)
+		if err := updateActionIndex(dbPath, "build.yml", "repo-"+string(rune('a'+i)), "build.yml", hash, ""); err != nil {
+			t.Fatalf("updateActionIndex returned error: %v", err)
+		}
+		if i < 2 {
+			if err := storeActionVersion(dbPath, "build.yml", hash, content); err != nil {
+				t.Fatalf("storeActionVersion returned error: %v", err)
+			}
+		}
+	}
+	return dbPath, hashes
+}
+
+func TestDiffHunks(t *testing.T) {
+	t.Parallel()
+
+	old := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}
+	changed := []string{"a", "B", "c", "d", "e", "f", "g", "h", "i", "j", "k"}
+	hunks := diffHunks(diffSequences(old, changed), 1)
+	if len(hunks) != 2 {
+		t.Fatalf("expected 2 hunks, got %+v", hunks)
+	}
+	if hunks[0].header() != "@@ -1,3 +1,3 @@" || hunks[1].header() != "@@ -10,1 +10,2 @@" {
+		t.Fatalf("unexpected hunk headers %q and %q", hunks[0].header(), hunks[1].header())
+	}
+
+	// Hunks whose context meets are merged, and an addition to an empty file starts at line 0
+	if hunks := diffHunks(diffSequences(old, changed), 3); len(hunks) != 2 {
+		t.Fatalf("expected 2 hunks with 3 lines of context, got %d", len(hunks))
+	}
+	if hunks := diffHunks(diffSequences(old, changed), 4); len(hunks) != 1 || hunks[0].header() != "@@ -1,10 +1,11 @@" {
+		t.Fatalf("expected a single hunk, got %+v", hunks)
+	}
+	if hunks := diffHunks(diffSequences(nil, []string{"a"}), 3); len(hunks) != 1 || hunks[0].header() != "@@ -0,0 +1,1 @@" {
+		t.Fatalf("unexpected hunks for an added file: %+v", hunks)
+	}
+}
+
+func TestResolveStoredVersion(t *testing.T) {
+	t.Parallel()
+
+	dbPath, hashes := writeDiffTestDB(t)
+	folder := filepath.Join(dbPath, "workflows", "build.yml")
+
+	if full, err := resolveStoredVersion(folder, strings.ToUpper(shortHash(hashes[1]))); err != nil || full != hashes[1] {
+		t.Fatalf("expected %s, got %s, %v", hashes[1], full, err)
+	}
+	if _, err := resolveStoredVersion(folder, shortHash(hashes[2])); err == nil || !strings.Contains(err.Error(), "not stored") {
+		t.Fatalf("expected an error for a version whose content is not stored, got %v", err)
+	}
+	if _, err := resolveStoredVersion(folder, strings.Repeat("f", 64)); err == nil {
+		t.Fatal("expected an error for an unknown version")
+	}
+
+	// A second version sharing the prefix makes it ambiguous
+	other := hashes[0][:4] + strings.Repeat("0", 60)
+	if err := os.WriteFile(filepath.Join(folder, other), []byte("name: other\n"), 0644); err != nil {
+		t.Fatalf("failed to write version: %v", err)
+	}
+	if _, err := resolveStoredVersion(folder, hashes[0][:4]); err == nil || !strings.Contains(err.Error(), "several stored versions") {
+		t.Fatalf("expected an ambiguous prefix, got %v", err)
+	}
+
+	if _, err := versionFolder(dbPath, "missing.yml"); err == nil {
+		t.Fatal("expected an error for a workflow that is not indexed")
+	}
+	if _, err := versionFolder(dbPath, "../outside"); err == nil {
+		t.Fatal("expected an error for a path outside the database")
+	}
+}
+
+func TestFormatVersionDiff(t *testing.T) {
+	t.Parallel()
+
+	dbPath, hashes := writeDiffTestDB(t)
+	diff, err := buildVersionDiff(dbPath, "build.yml", shortHash(hashes[0]), hashes[1], 1)
+	if err != nil {
+		t.Fatalf("buildVersionDiff returned error: %v", err)
+	}
+	if diff.Added != 2 || diff.Removed != 1 {
+		t.Fatalf("expected 2 lines added and 1 removed, got %d and %d", diff.Added, diff.Removed)
+	}
+
+	unified := formatUnifiedDiff(diff)
+	want := "--- build.yml@" + shortHash(hashes[0]) + "\n+++ build.yml@" + shortHash(hashes[1]) + "\n" +
+		"@@ -6,4 +6,5 @@\n     steps:\n-      - uses: actions/checkout@v3\n+      - uses: actions/checkout@v4\n       - run: make test\n       - run: make lint\n+      - run: make release\n"
+	if unified != want {
+		t.Fatalf("unexpected unified diff:\n%s", unified)
+	}
+
+	sideBySide := formatSideBySideDiff(diff, 80)
+	for _, want := range []string{
+		"      - uses: actions/checkout@v3      |       - uses: actions/checkout@v4\n",
+		strings.Repeat(" ", 38) + " >       - run: make release\n",
+	} {
+		if !strings.Contains(sideBySide, want) {
+			t.Fatalf("expected the side-by-side diff to contain %q:\n%s", want, sideBySide)
+		}
+	}
+
+	html, err := formatHTMLDiff(diff)
+	if err != nil {
+		t.Fatalf("formatHTMLDiff returned error: %v", err)
+	}
+	for _, want := range []string{
+		"actions/checkout@<del>v3</del>",
+		"actions/checkout@<ins>v4</ins>",
+		`<td class="added">      - run: make release</td>`,
+		"2 added and 1 removed lines",
+	} {
+		if !strings.Contains(html, want) {
+			t.Fatalf("expected the HTML diff to contain %q:\n%s", want, html)
+		}
+	}
+
+	// Identical versions have no hunks
+	same, err := buildVersionDiff(dbPath, "build.yml", hashes[1], hashes[1], 3)
+	if err != nil || len(same.Hunks) != 0 || formatUnifiedDiff(same) != "" {
+		t.Fatalf("expected no differences, got %+v, %v", same, err)
+	}
+}
+
+func TestDiffWords(t *testing.T) {
+	t.Parallel()
+
+	oldSegments, newSegments := diffWords("run: go test ./...", "run: go vet ./...")
+	if len(oldSegments) != 3 || oldSegments[1] != (diffSegment{Text: "test", Changed: true}) {
+		t.Fatalf("unexpected old segments: %+v", oldSegments)
+	}
+	if len(newSegments) != 3 || newSegments[0].Text != "run: go " || newSegments[1] != (diffSegment{Text: "vet", Changed: true}) {
+		t.Fatalf("unexpected new segments: %+v", newSegments)
+	}
+}
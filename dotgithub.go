@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v50/github"
+)
+
+// ------------------------
+// Section: .github Directory Snapshots
+// ------------------------
+
+// dotGitHubDir is the database directory the rest of the .github directory is stored in with -dotgithub-all.
+const dotGitHubDir = "dotgithub"
+
+// DotGitHubFile is a file of a repository's .github directory that is not indexed otherwise, such as
+// CODEOWNERS, SECURITY.md, or an issue template.
+type DotGitHubFile struct {
+	RepoName string
+	Name     string // Path within .github, such as ISSUE_TEMPLATE/bug_report.md
+	FilePath string
+	Content  string
+	Hash     string
+	BlobSHA  string
+}
+
+// isIndexedDotGitHubPath reports whether a path of the .github directory is already indexed on its own:
+// workflows, in-house actions, and dependabot and Renovate configs.
+func isIndexedDotGitHubPath(filePath string) bool {
+	for _, dir := range append([]string{".github/workflows", inHouseActionsDir}, workflowPaths...) {
+		if filePath == dir || strings.HasPrefix(filePath, dir+"/") {
+			return true
+		}
+	}
+	return slices.Contains(dependabotConfigPaths, filePath) || slices.Contains(renovateConfigPaths, filePath)
+}
+
+// fetchDotGitHubFiles retrieves every file of a repository's .github directory, including its
+// subdirectories, that is not indexed otherwise.
+func fetchDotGitHubFiles(client *github.Client, repo *github.Repository) ([]DotGitHubFile, error) {
+	ctx := context.Background()
+	owner := repo.GetOwner().GetLogin()
+	opts := &github.RepositoryContentGetOptions{Ref: getDefaultBranch(repo)}
+
+	var entries []*github.RepositoryContent
+	pending := []string{".github"}
+	for len(pending) > 0 {
+		dirPath := pending[0]
+		pending = pending[1:]
+		_, listing, _, err := client.Repositories.GetContents(ctx, owner, repo.GetName(), dirPath, opts)
+		if err != nil {
+			if isNotFoundError(err) {
+				continue
+			}
+			logErrorf("Error accessing %s in repository '%s': %v", dirPath, repo.GetName(), err)
+			return nil, err
+		}
+		for _, entry := range listing {
+			if isIndexedDotGitHubPath(entry.GetPath()) {
+				continue
+			}
+			switch entry.GetType() {
+			case "dir":
+				pending = append(pending, entry.GetPath())
+			case "file":
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	var files []DotGitHubFile
+	var integrityErrs []error
+	for _, entry := range entries {
+		logDebugf("Found .github file: %s in repository '%s'", entry.GetPath(), repo.GetName())
+		content, err := fetchBlobContent(client, owner, repo.GetName(), entry.GetSHA())
+		if err != nil {
+			logErrorf("Error fetching content for file '%s' in repository '%s': %v", entry.GetPath(), repo.GetName(), err)
+			err = withBlobPath(err, entry.GetPath())
+			if len(blobIntegrityFailures(err)) > 0 {
+				integrityErrs = append(integrityErrs, err)
+				continue
+			}
+			return nil, err
+		}
+		if content == "" {
+			logDebugf("Empty content for file '%s' in repository '%s'", entry.GetPath(), repo.GetName())
+			continue
+		}
+		files = append(files, DotGitHubFile{
+			RepoName: repo.GetName(),
+			Name:     strings.TrimPrefix(entry.GetPath(), ".github/"),
+			FilePath: entry.GetPath(),
+			Content:  content,
+			Hash:     computeHash([]byte(content)),
+			BlobSHA:  entry.GetSHA(),
+		})
+	}
+	if len(integrityErrs) > 0 {
+		return nil, errors.Join(integrityErrs...)
+	}
+	return files, nil
+}
+
+// dotGitHubStoragePath returns the database folder of a .github file.
+func dotGitHubStoragePath(dbPath, name string) string {
+	return filepath.Join(dbPath, dotGitHubDir, filepath.FromSlash(name))
+}
+
+// updateDotGitHubIndex maps a repository to a file hash in db/dotgithub/<path>/index.yaml.
+func updateDotGitHubIndex(dbPath string, file DotGitHubFile) error {
+	storagePath := dotGitHubStoragePath(dbPath, file.Name)
+	if err := os.MkdirAll(storagePath, os.ModePerm); err != nil {
+		return err
+	}
+
+	indexPath := filepath.Join(storagePath, "index.yaml")
+	index, err := readActionIndex(indexPath)
+	if err != nil {
+		return err
+	}
+	index.Repositories[file.RepoName] = file.Hash
+	recordBlobSHA(index, file.Hash, file.BlobSHA)
+	if err := writeActionIndex(indexPath, index); err != nil {
+		return err
+	}
+
+	logDebugf("Updated .github index for '%s' with repository '%s'", file.Name, file.RepoName)
+	return nil
+}
+
+// storeDotGitHubVersion saves the content of a .github file under its hash.
+func storeDotGitHubVersion(dbPath string, file DotGitHubFile) error {
+	storagePath := dotGitHubStoragePath(dbPath, file.Name)
+	if err := os.MkdirAll(versionsDir(storagePath), os.ModePerm); err != nil {
+		return err
+	}
+	storedPath := versionPath(storagePath, file.Hash)
+	if _, err := os.Stat(storedPath); os.IsNotExist(err) {
+		logDebugf("Storing .github file '%s' under hash '%s'", file.Name, file.Hash)
+		return writeVersionFile(storedPath, []byte(file.Content))
+	}
+	return nil
+}
+
+// garbageCollectDotGitHub removes unused versions of the .github files.
+func garbageCollectDotGitHub(dbPath string) error {
+	return garbageCollectIndexedFolders(filepath.Join(dbPath, dotGitHubDir), ".github file")
+}
+
+// generateDotGitHubReadmeFiles creates a README.md file in each .github file folder listing the
+// repositories that use each version.
+func generateDotGitHubReadmeFiles(dbPath, org string) error {
+	kindPath := filepath.Join(dbPath, dotGitHubDir)
+	names, err := walkIndexedFolders(kindPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s directory: %v", dotGitHubDir, err)
+	}
+
+	forEachParallel(names, func(name string) {
+		storagePath := dotGitHubStoragePath(dbPath, name)
+		index, err := readActionIndex(filepath.Join(storagePath, "index.yaml"))
+		if err != nil {
+			logErrorf("Error reading index for .github file '%s': %v", name, err)
+			return
+		}
+
+		hashToRepos := make(map[string][]string)
+		for repo, hash := range index.Repositories {
+			hashToRepos[hash] = append(hashToRepos[hash], repo)
+		}
+		hashes := make([]string, 0, len(hashToRepos))
+		for hash := range hashToRepos {
+			hashes = append(hashes, hash)
+		}
+		sort.Strings(hashes)
+
+		filePath := path.Join(".github", name)
+		var markdownBuilder strings.Builder
+		markdownBuilder.WriteString(fmt.Sprintf("# %s\n\n", filePath))
+		for _, hash := range hashes {
+			repos := hashToRepos[hash]
+			sort.Strings(repos)
+			markdownBuilder.WriteString(fmt.Sprintf("## %s\n\n", versionReference(storagePath, hash)))
+			for _, repo := range repos {
+				url := fmt.Sprintf("%s/%s/%s/blob/main/%s", githubWebURL, org, repo, filePath)
+				markdownBuilder.WriteString(fmt.Sprintf("- [%s](%s)\n", repo, url))
+			}
+			markdownBuilder.WriteString("\n")
+		}
+
+		if err := os.WriteFile(filepath.Join(storagePath, "README.md"), []byte(markdownBuilder.String()), 0644); err != nil {
+			logErrorf("Error writing README.md for .github file '%s': %v", name, err)
+			return
+		}
+		logDebugf("Generated README.md for .github file '%s'", name)
+	})
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v50/github"
+)
+
+func TestFetchDotGitHubFiles(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{
+		".github/CODEOWNERS":                    "* @example-org/platform\n",
+		".github/SECURITY.md":                   "# Security\n",
+		".github/ISSUE_TEMPLATE/bug_report.md":  "---\nname: Bug report\n---\n",
+		".github/workflows/ci.yml":              "on: push\n",
+		".github/actions/setup/action.yml":      "runs:\n  using: composite\n",
+		".github/dependabot.yml":                "version: 2\n",
+		".github/PULL_REQUEST_TEMPLATE.md":      "## Summary\n",
+		".github/ISSUE_TEMPLATE/config.yml":     "blank_issues_enabled: false\n",
+		".github/ISSUE_TEMPLATE/sub/feature.md": "---\nname: Feature\n---\n",
+	}
+	blobs := make(map[string]string)
+	listings := make(map[string][]string)
+	for filePath, content := range files {
+		sha := computeBlobSHA([]byte(content))
+		blobs[sha] = content
+		dir, name := filepath.Split(filePath)
+		dir = strings.TrimSuffix(dir, "/")
+		listings[dir] = append(listings[dir], fmt.Sprintf(`{"type":"file","name":%q,"path":%q,"sha":%q}`, name, filePath, sha))
+		for dir != ".github" {
+			parent, name := filepath.Split(dir)
+			parent = strings.TrimSuffix(parent, "/")
+			entry := fmt.Sprintf(`{"type":"dir","name":%q,"path":%q}`, name, dir)
+			if !strings.Contains(strings.Join(listings[parent], ","), entry) {
+				listings[parent] = append(listings[parent], entry)
+			}
+			dir = parent
+		}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if dir, ok := strings.CutPrefix(r.URL.Path, "/repos/example-org/repo-a/contents/"); ok && listings[dir] != nil {
+			fmt.Fprintf(w, "[%s]", strings.Join(listings[dir], ","))
+			return
+		}
+		if sha, ok := strings.CutPrefix(r.URL.Path, "/repos/example-org/repo-a/git/blobs/"); ok && blobs[sha] != "" {
+			fmt.Fprintf(w, `{"sha":%q,"size":%d,"encoding":"base64","content":%q}`, sha, len(blobs[sha]), base64.StdEncoding.EncodeToString([]byte(blobs[sha])))
+			return
+		}
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	owner := &github.User{Login: github.String("example-org")}
+
+	fetched, err := fetchDotGitHubFiles(client, &github.Repository{Name: github.String("repo-a"), Owner: owner, DefaultBranch: github.String("main")})
+	if err != nil {
+		t.Fatalf("fetchDotGitHubFiles returned error: %v", err)
+	}
+	var names []string
+	for _, file := range fetched {
+		names = append(names, file.Name)
+	}
+	got := strings.Join(names, ",")
+	for _, want := range []string{"CODEOWNERS", "SECURITY.md", "PULL_REQUEST_TEMPLATE.md", "ISSUE_TEMPLATE/bug_report.md", "ISSUE_TEMPLATE/config.yml", "ISSUE_TEMPLATE/sub/feature.md"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("expected %s among the fetched files, got %s", want, got)
+		}
+	}
+	if len(fetched) != 6 {
+		t.Fatalf("expected workflows, actions, and dependabot to be skipped, got %s", got)
+	}
+
+	if missing, err := fetchDotGitHubFiles(client, &github.Repository{Name: github.String("repo-b"), Owner: owner}); err != nil || missing != nil {
+		t.Fatalf("expected no files for a repository without .github, got %+v, %v", missing, err)
+	}
+}
+
+func TestDotGitHubIndexing(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	shared := "* @example-org/platform\n"
+	for _, file := range []DotGitHubFile{
+		{RepoName: "repo-a", Name: "CODEOWNERS", Content: shared, Hash: computeHash([]byte(shared))},
+		{RepoName: "repo-b", Name: "CODEOWNERS", Content: shared, Hash: computeHash([]byte(shared))},
+		{RepoName: "repo-a", Name: "ISSUE_TEMPLATE/bug_report.md", Content: "---\n", Hash: computeHash([]byte("---\n"))},
+	} {
+		if err := updateDotGitHubIndex(dbPath, file); err != nil {
+			t.Fatalf("updateDotGitHubIndex returned error: %v", err)
+		}
+		if err := storeDotGitHubVersion(dbPath, file); err != nil {
+			t.Fatalf("storeDotGitHubVersion returned error: %v", err)
+		}
+	}
+
+	names, err := walkIndexedFolders(filepath.Join(dbPath, "dotgithub"))
+	if err != nil {
+		t.Fatalf("walkIndexedFolders returned error: %v", err)
+	}
+	if strings.Join(names, ",") != "CODEOWNERS,ISSUE_TEMPLATE/bug_report.md" {
+		t.Fatalf("unexpected indexed folders: %v", names)
+	}
+
+	stale := versionPath(dotGitHubStoragePath(dbPath, "ISSUE_TEMPLATE/bug_report.md"), strings.Repeat("0", 64))
+	if err := os.WriteFile(stale, []byte("old\n"), 0644); err != nil {
+		t.Fatalf("failed to write stale version: %v", err)
+	}
+	if err := garbageCollectDotGitHub(dbPath); err != nil {
+		t.Fatalf("garbageCollectDotGitHub returned error: %v", err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("expected the unused version to be removed, got err=%v", err)
+	}
+
+	if err := generateDotGitHubReadmeFiles(dbPath, "example-org"); err != nil {
+		t.Fatalf("generateDotGitHubReadmeFiles returned error: %v", err)
+	}
+	readme, err := os.ReadFile(filepath.Join(dbPath, "dotgithub", "CODEOWNERS", "README.md"))
+	if err != nil {
+		t.Fatalf("failed to read README.md: %v", err)
+	}
+	for _, want := range []string{
+		"# .github/CODEOWNERS\n",
+		"- [repo-a](https://github.com/example-org/repo-a/blob/main/.github/CODEOWNERS)\n- [repo-b](https://github.com/example-org/repo-b/blob/main/.github/CODEOWNERS)\n",
+	} {
+		if !strings.Contains(string(readme), want) {
+			t.Fatalf("README.md does not contain %q:\n%s", want, readme)
+		}
+	}
+}
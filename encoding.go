@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"unicode/utf16"
+)
+
+// ------------------------
+// Section: Workflow Encodings
+// ------------------------
+
+// Encodings recorded in the encodings section of a workflow index for files that were not stored as UTF-8
+// without a byte order mark. Their content is indexed as UTF-8, so that a file with the same text in
+// another encoding has the same hash.
+const (
+	encodingUTF8BOM = "utf-8-bom"
+	encodingUTF16LE = "utf-16le"
+	encodingUTF16BE = "utf-16be"
+)
+
+// decodeWorkflowContent converts workflow content to UTF-8 without a byte order mark, returning the
+// encoding it was stored in, or an empty string when it already was UTF-8. UTF-16 is recognized by its
+// byte order mark, or without one by the zero bytes its ASCII characters contain, which UTF-8 YAML never
+// holds.
+func decodeWorkflowContent(content string) (string, string, error) {
+	data := []byte(content)
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return string(data[3:]), encodingUTF8BOM, nil
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		decoded, err := decodeUTF16(data[2:], binary.LittleEndian)
+		return decoded, encodingUTF16LE, err
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		decoded, err := decodeUTF16(data[2:], binary.BigEndian)
+		return decoded, encodingUTF16BE, err
+	case len(data) >= 2 && data[0] != 0 && data[1] == 0:
+		decoded, err := decodeUTF16(data, binary.LittleEndian)
+		return decoded, encodingUTF16LE, err
+	case len(data) >= 2 && data[0] == 0 && data[1] != 0:
+		decoded, err := decodeUTF16(data, binary.BigEndian)
+		return decoded, encodingUTF16BE, err
+	}
+	return content, "", nil
+}
+
+// decodeUTF16 converts UTF-16 content in the given byte order to UTF-8.
+func decodeUTF16(data []byte, order binary.ByteOrder) (string, error) {
+	if len(data)%2 != 0 {
+		return "", fmt.Errorf("odd number of bytes")
+	}
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+// workflowFileFromContent returns the workflow file to index for the fetched content of a file, decoded
+// to UTF-8 and hashed. A file that is empty or cannot be decoded is recorded under emptyWorkflowHash
+// with the reason.
+func workflowFileFromContent(repoName, filePath, content, blobSHA, symlink string) WorkflowFile {
+	decoded, encoding, err := decodeWorkflowContent(content)
+	if err != nil {
+		logErrorf("Error decoding %s file '%s' in repository '%s': %v", encoding, filePath, repoName, err)
+		workflow := emptyWorkflowFile(repoName, filePath, symlink, fmt.Sprintf("invalid %s content", encoding))
+		workflow.Encoding = encoding
+		return workflow
+	}
+	if decoded == "" {
+		logDebugf("Empty content for file '%s' in repository '%s'", filePath, repoName)
+		workflow := emptyWorkflowFile(repoName, filePath, symlink, emptyWorkflowReason)
+		workflow.Encoding = encoding
+		return workflow
+	}
+	if encoding != "" {
+		logDebugf("Decoded file '%s' in repository '%s' from %s", filePath, repoName, encoding)
+	}
+	hash := computeHash([]byte(decoded))
+	logDebugf("Hashing file '%s' in repository '%s': %s", filePath, repoName, hash)
+	return WorkflowFile{
+		RepoName: repoName,
+		FilePath: filePath,
+		Content:  decoded,
+		Hash:     hash,
+		BlobSHA:  blobSHA,
+		Symlink:  symlink,
+		Encoding: encoding,
+	}
+}
+
+// encodeUTF16 encodes UTF-8 text as UTF-16 in the given byte order, with a byte order mark when bom is set.
+func encodeUTF16(text string, order binary.ByteOrder, bom bool) []byte {
+	units := utf16.Encode([]rune(text))
+	if bom {
+		units = append([]uint16{0xFEFF}, units...)
+	}
+	data := make([]byte, 2*len(units))
+	for i, unit := range units {
+		order.PutUint16(data[2*i:], unit)
+	}
+	return data
+}
+
+// matchStoredBlob returns the bytes of a file whose stored content is data, as they were when it was
+// fetched with the given blob SHA. Content decoded from another encoding is encoded again in each of
+// them until one matches, since the index does not record whether a UTF-16 file had a byte order mark.
+func matchStoredBlob(data []byte, blobSHA string) ([]byte, bool) {
+	candidates := [][]byte{
+		data,
+		append([]byte{0xEF, 0xBB, 0xBF}, data...),
+		encodeUTF16(string(data), binary.LittleEndian, true),
+		encodeUTF16(string(data), binary.LittleEndian, false),
+		encodeUTF16(string(data), binary.BigEndian, true),
+		encodeUTF16(string(data), binary.BigEndian, false),
+	}
+	for _, candidate := range candidates {
+		if computeBlobSHA(candidate) == blobSHA {
+			return candidate, true
+		}
+	}
+	return nil, false
+}
+
+// recordEncodedBlob stores the GitHub blob SHA of a file decoded from another encoding with the hash of
+// its decoded content.
+func recordEncodedBlob(index *ActionIndex, blobSHA, hash string) {
+	if blobSHA == "" {
+		return
+	}
+	if index.EncodedBlobs == nil {
+		index.EncodedBlobs = make(map[string]string)
+	}
+	index.EncodedBlobs[blobSHA] = hash
+}
+
+// copyEncodedBlobs copies the encoded blobs of a content hash from one workflow index to another.
+func copyEncodedBlobs(to, from *ActionIndex, hash string) {
+	for blobSHA, h := range from.EncodedBlobs {
+		if h == hash {
+			recordEncodedBlob(to, blobSHA, hash)
+		}
+	}
+}
+
+// updateEncodedBlob records the blob SHA of a workflow file decoded from another encoding in its
+// index.yaml, so that -fetch tree reuses the stored content while the blob is unchanged.
+func updateEncodedBlob(dbPath, actionName, blobSHA, hash string) error {
+	indexPath := filepath.Join(dbPath, "workflows", actionName, "index.yaml")
+	index, err := readActionIndex(indexPath)
+	if err != nil {
+		return err
+	}
+	if blobSHA == "" || index.EncodedBlobs[blobSHA] == hash {
+		return nil
+	}
+	recordEncodedBlob(index, blobSHA, hash)
+	return writeActionIndex(indexPath, index)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestDecodeWorkflowContent(t *testing.T) {
+	t.Parallel()
+
+	text := "name: Café CI\non: push\njobs: {}\n"
+	tests := []struct {
+		name     string
+		content  string
+		encoding string
+	}{
+		{"utf-8", text, ""},
+		{"utf-8 with byte order mark", "\xEF\xBB\xBF" + text, encodingUTF8BOM},
+		{"utf-16le with byte order mark", string(encodeUTF16(text, binary.LittleEndian, true)), encodingUTF16LE},
+		{"utf-16be with byte order mark", string(encodeUTF16(text, binary.BigEndian, true)), encodingUTF16BE},
+		{"utf-16le", string(encodeUTF16(text, binary.LittleEndian, false)), encodingUTF16LE},
+		{"utf-16be", string(encodeUTF16(text, binary.BigEndian, false)), encodingUTF16BE},
+	}
+	for _, test := range tests {
+		decoded, encoding, err := decodeWorkflowContent(test.content)
+		if err != nil {
+			t.Fatalf("%s: decodeWorkflowContent returned error: %v", test.name, err)
+		}
+		if decoded != text || encoding != test.encoding {
+			t.Fatalf("%s: got %q as %q, want %q as %q", test.name, decoded, encoding, text, test.encoding)
+		}
+	}
+
+	if _, _, err := decodeWorkflowContent("\xFF\xFEn\x00a"); err == nil {
+		t.Fatalf("expected an error for UTF-16 content with an odd number of bytes")
+	}
+}
+
+func TestWorkflowFileFromContent(t *testing.T) {
+	t.Parallel()
+
+	text := "name: Stale\non: schedule\njobs: {}\n"
+	plain := workflowFileFromContent("repo-a", ".github/workflows/stale.yml", text, "sha-a", "")
+	encoded := workflowFileFromContent("repo-b", ".github/workflows/stale.yml", string(encodeUTF16(text, binary.LittleEndian, true)), "sha-b", "")
+	if plain.Hash != encoded.Hash || encoded.Content != text || encoded.Encoding != encodingUTF16LE || encoded.BlobSHA != "sha-b" {
+		t.Fatalf("expected the same logical content to have the same hash, got %+v and %+v", plain, encoded)
+	}
+
+	bomOnly := workflowFileFromContent("repo-c", ".github/workflows/empty.yml", "\xEF\xBB\xBF", "sha-c", "")
+	if bomOnly.Hash != emptyWorkflowHash || bomOnly.Reason != emptyWorkflowReason || bomOnly.Encoding != encodingUTF8BOM {
+		t.Fatalf("expected a file holding only a byte order mark to be empty, got %+v", bomOnly)
+	}
+	invalid := workflowFileFromContent("repo-c", ".github/workflows/broken.yml", "\xFE\xFF\x00", "sha-d", "")
+	if invalid.Hash != emptyWorkflowHash || invalid.Reason != "invalid utf-16be content" {
+		t.Fatalf("expected undecodable content to be recorded with its reason, got %+v", invalid)
+	}
+}
+
+func TestMatchStoredBlob(t *testing.T) {
+	t.Parallel()
+
+	text := "name: Café CI\non: push\njobs: {}\n"
+	for _, original := range [][]byte{
+		[]byte(text),
+		append([]byte{0xEF, 0xBB, 0xBF}, text...),
+		encodeUTF16(text, binary.LittleEndian, true),
+		encodeUTF16(text, binary.BigEndian, false),
+	} {
+		matched, ok := matchStoredBlob([]byte(text), computeBlobSHA(original))
+		if !ok || string(matched) != string(original) {
+			t.Fatalf("expected the stored content to match its original bytes %q, got %q", original, matched)
+		}
+	}
+	if _, ok := matchStoredBlob([]byte(text), computeBlobSHA([]byte("on: push\n"))); ok {
+		t.Fatal("expected no match for the blob of other content")
+	}
+}
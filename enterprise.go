@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-github/v50/github"
+)
+
+// ------------------------
+// Section: GitHub Enterprise Server
+// ------------------------
+
+// githubBaseURL and githubUploadURL are the API endpoints set with -base-url and -upload-url; both are
+// empty for github.com.
+var githubBaseURL, githubUploadURL string
+
+// githubWebURL is the web address of the GitHub server, used for the links in generated reports.
+var githubWebURL = "https://github.com"
+
+// setGitHubServer points API clients and report links at a GitHub Enterprise Server instance. An empty
+// base URL keeps github.com, and an empty upload URL uses the base URL, since GitHub Enterprise Server
+// serves both from one host.
+func setGitHubServer(baseURL, uploadURL string) error {
+	if baseURL == "" {
+		if uploadURL != "" {
+			return fmt.Errorf("-upload-url requires -base-url")
+		}
+		return nil
+	}
+	if uploadURL == "" {
+		uploadURL = baseURL
+	}
+
+	webURL, err := githubServerWebURL(baseURL)
+	if err != nil {
+		return err
+	}
+	if _, err := github.NewEnterpriseClient(baseURL, uploadURL, nil); err != nil {
+		return fmt.Errorf("invalid -base-url or -upload-url: %v", err)
+	}
+	githubBaseURL, githubUploadURL, githubWebURL = baseURL, uploadURL, webURL
+	return nil
+}
+
+// githubServerWebURL derives the web address of a GitHub server from its API base URL, such as
+// https://github.example.com for https://github.example.com/api/v3.
+func githubServerWebURL(baseURL string) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid -base-url: %v", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid -base-url '%s': expected an absolute URL such as https://github.example.com/api/v3", baseURL)
+	}
+	return u.Scheme + "://" + strings.TrimPrefix(u.Host, "api."), nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestGitHubServerWebURL(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"https://github.example.com/api/v3":  "https://github.example.com",
+		"https://github.example.com/api/v3/": "https://github.example.com",
+		"http://ghes.internal:8080/":         "http://ghes.internal:8080",
+		"https://api.example.ghe.com/":       "https://example.ghe.com",
+	}
+	for baseURL, want := range cases {
+		got, err := githubServerWebURL(baseURL)
+		if err != nil {
+			t.Fatalf("githubServerWebURL(%q) returned error: %v", baseURL, err)
+		}
+		if got != want {
+			t.Fatalf("githubServerWebURL(%q) = %q, want %q", baseURL, got, want)
+		}
+	}
+
+	if _, err := githubServerWebURL("github.example.com/api/v3"); err == nil {
+		t.Fatalf("expected an error for a URL without a scheme")
+	}
+}
+
+func TestSetGitHubServerValidation(t *testing.T) {
+	t.Parallel()
+
+	// Only calls that leave the server unchanged, since other tests use the github.com default
+	if err := setGitHubServer("", ""); err != nil {
+		t.Fatalf("setGitHubServer returned error: %v", err)
+	}
+	if err := setGitHubServer("", "https://github.example.com/api/uploads"); err == nil {
+		t.Fatalf("expected -upload-url without -base-url to be rejected")
+	}
+	if err := setGitHubServer("://bad", ""); err == nil {
+		t.Fatalf("expected an invalid -base-url to be rejected")
+	}
+	if githubBaseURL != "" || githubWebURL != "https://github.com" {
+		t.Fatalf("expected the github.com defaults to be kept, got %q and %q", githubBaseURL, githubWebURL)
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v50/github"
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Deployment Environments
+// ------------------------
+
+// Deployment branch policies an environment can use.
+const (
+	BranchPolicyAll       = "all branches"
+	BranchPolicyProtected = "protected branches"
+	BranchPolicyCustom    = "custom branches"
+)
+
+// productionEnvironmentRe matches environment names that conventionally deploy to production.
+var productionEnvironmentRe = regexp.MustCompile(`(?i)(^|[^a-z])(prod|prd|production|live)([^a-z]|$)`)
+
+// EnvironmentSnapshot records the deployment protection rules of one environment.
+type EnvironmentSnapshot struct {
+	Name           string   `yaml:"name"`
+	Reviewers      []string `yaml:"reviewers,omitempty"`
+	WaitTimer      int      `yaml:"wait_timer,omitempty"`
+	BranchPolicy   string   `yaml:"branch_policy"`
+	BranchPatterns []string `yaml:"branch_patterns,omitempty"`
+}
+
+// EnvironmentIndex maps repository names to the environments defined in them.
+type EnvironmentIndex struct {
+	Repositories map[string][]EnvironmentSnapshot `yaml:"repositories"`
+}
+
+// fetchEnvironments retrieves the deployment environments of a repository and their protection rules. It
+// returns none when the environments cannot be read, as when the token lacks the environments permission.
+func fetchEnvironments(client *github.Client, repo *github.Repository) ([]EnvironmentSnapshot, error) {
+	ctx := context.Background()
+	owner := repo.GetOwner().GetLogin()
+	name := repo.GetName()
+
+	var environments []*github.Environment
+	opts := &github.EnvironmentListOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		response, resp, err := client.Repositories.ListEnvironments(ctx, owner, name, opts)
+		if err != nil {
+			if isNotFoundError(err) || isForbiddenError(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		environments = append(environments, response.Environments...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	snapshots := make([]EnvironmentSnapshot, 0, len(environments))
+	for _, environment := range environments {
+		snapshot := environmentSnapshot(environment)
+		if snapshot.BranchPolicy == BranchPolicyCustom {
+			policies, _, err := client.Repositories.ListDeploymentBranchPolicies(ctx, owner, name, snapshot.Name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list branch policies for environment '%s': %w", snapshot.Name, err)
+			}
+			for _, policy := range policies.BranchPolicies {
+				snapshot.BranchPatterns = append(snapshot.BranchPatterns, policy.GetName())
+			}
+			sort.Strings(snapshot.BranchPatterns)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Name < snapshots[j].Name
+	})
+	logDebugf("Found %d environments in repository '%s'", len(snapshots), name)
+	return snapshots, nil
+}
+
+// environmentSnapshot converts an API environment into a snapshot without its custom branch patterns.
+func environmentSnapshot(environment *github.Environment) EnvironmentSnapshot {
+	snapshot := EnvironmentSnapshot{
+		Name:         environment.GetName(),
+		BranchPolicy: BranchPolicyAll,
+	}
+
+	for _, rule := range environment.ProtectionRules {
+		switch rule.GetType() {
+		case "required_reviewers":
+			for _, reviewer := range rule.Reviewers {
+				switch r := reviewer.Reviewer.(type) {
+				case *github.User:
+					snapshot.Reviewers = append(snapshot.Reviewers, r.GetLogin())
+				case *github.Team:
+					snapshot.Reviewers = append(snapshot.Reviewers, "team:"+r.GetSlug())
+				}
+			}
+		case "wait_timer":
+			snapshot.WaitTimer = rule.GetWaitTimer()
+		}
+	}
+	sort.Strings(snapshot.Reviewers)
+
+	if policy := environment.DeploymentBranchPolicy; policy != nil {
+		if policy.GetProtectedBranches() {
+			snapshot.BranchPolicy = BranchPolicyProtected
+		} else if policy.GetCustomBranchPolicies() {
+			snapshot.BranchPolicy = BranchPolicyCustom
+		}
+	}
+	return snapshot
+}
+
+// isProductionEnvironment reports whether an environment name suggests it deploys to production.
+func isProductionEnvironment(name string) bool {
+	return productionEnvironmentRe.MatchString(name)
+}
+
+// analyzeEnvironments reports production environments that deploy without a required reviewer.
+func analyzeEnvironments(org, repoName string, environments []EnvironmentSnapshot) []Finding {
+	var findings []Finding
+	for _, environment := range environments {
+		if !isProductionEnvironment(environment.Name) || len(environment.Reviewers) > 0 {
+			continue
+		}
+		finding := newFinding("unprotected-production-environment", repoName, "environments/"+environment.Name, 0,
+			fmt.Sprintf("Environment '%s' deploys from %s without required reviewers", environment.Name, environment.BranchPolicy))
+		finding.URL = fmt.Sprintf("%s/%s/%s/settings/environments", githubWebURL, org, repoName)
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// loadEnvironmentIndex reads environments.yaml from the database, returning an empty index if it does not exist.
+func loadEnvironmentIndex(dbPath string) (*EnvironmentIndex, error) {
+	index := &EnvironmentIndex{Repositories: make(map[string][]EnvironmentSnapshot)}
+	data, err := os.ReadFile(filepath.Join(dbPath, "environments.yaml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return index, nil
+		}
+		return nil, fmt.Errorf("error reading environments.yaml: %v", err)
+	}
+	if err := yaml.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("error parsing environments.yaml: %v", err)
+	}
+	if index.Repositories == nil {
+		index.Repositories = make(map[string][]EnvironmentSnapshot)
+	}
+	return index, nil
+}
+
+// updateEnvironmentIndex replaces the environments recorded for a repository in environments.yaml.
+func updateEnvironmentIndex(dbPath, repoName string, environments []EnvironmentSnapshot) error {
+	index, err := loadEnvironmentIndex(dbPath)
+	if err != nil {
+		return err
+	}
+	if len(environments) == 0 {
+		delete(index.Repositories, repoName)
+	} else {
+		index.Repositories[repoName] = environments
+	}
+
+	data, err := yaml.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("error marshaling environments.yaml: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dbPath, "environments.yaml"), data, 0644); err != nil {
+		return fmt.Errorf("error writing environments.yaml: %v", err)
+	}
+	return nil
+}
+
+// generateEnvironmentsMarkdown creates an ENVIRONMENTS.md file listing the deployment environments of every repository.
+func generateEnvironmentsMarkdown(dbPath, org string) error {
+	index, err := loadEnvironmentIndex(dbPath)
+	if err != nil {
+		return err
+	}
+
+	repoNames := make([]string, 0, len(index.Repositories))
+	for repoName := range index.Repositories {
+		repoNames = append(repoNames, repoName)
+	}
+	sort.Strings(repoNames)
+
+	var markdownBuilder strings.Builder
+	markdownBuilder.WriteString("# Environments\n\n")
+	markdownBuilder.WriteString("This document lists the deployment environments of each repository and their protection rules. Production environments without required reviewers are marked with ⚠️.\n\n")
+	markdownBuilder.WriteString("| Repository | Environment | Reviewers | Wait Timer | Branches |\n")
+	markdownBuilder.WriteString("|------------|-------------|-----------|------------|----------|\n")
+
+	count := 0
+	for _, repoName := range repoNames {
+		for _, environment := range index.Repositories[repoName] {
+			count++
+			name := environment.Name
+			if isProductionEnvironment(name) && len(environment.Reviewers) == 0 {
+				name += " ⚠️"
+			}
+			reviewers := "-"
+			if len(environment.Reviewers) > 0 {
+				reviewers = "`" + strings.Join(environment.Reviewers, "`, `") + "`"
+			}
+			waitTimer := "-"
+			if environment.WaitTimer > 0 {
+				waitTimer = fmt.Sprintf("%d min", environment.WaitTimer)
+			}
+			branches := environment.BranchPolicy
+			if len(environment.BranchPatterns) > 0 {
+				branches += ": `" + strings.Join(environment.BranchPatterns, "`, `") + "`"
+			}
+			markdownBuilder.WriteString(fmt.Sprintf("| [%s](%s/%s/%s/settings/environments) | %s | %s | %s | %s |\n",
+				repoName, githubWebURL, org, repoName, name, reviewers, waitTimer, branches))
+		}
+	}
+	if count == 0 {
+		markdownBuilder.WriteString("| *No environments* | - | - | - | - |\n")
+	}
+
+	markdownBuilder.WriteString("\n*This file is automatically generated after each data collection run.*\n")
+
+	if err := os.WriteFile(filepath.Join(dbPath, "ENVIRONMENTS.md"), []byte(markdownBuilder.String()), 0644); err != nil {
+		return fmt.Errorf("error writing ENVIRONMENTS.md: %v", err)
+	}
+
+	logInfof("Generated ENVIRONMENTS.md with %d environments", count)
+	return nil
+}
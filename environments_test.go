@@ -0,0 +1,164 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v50/github"
+)
+
+func TestEnvironmentSnapshot(t *testing.T) {
+	t.Parallel()
+
+	environment := &github.Environment{
+		Name: github.String("production"),
+		ProtectionRules: []*github.ProtectionRule{
+			{Type: github.String("wait_timer"), WaitTimer: github.Int(15)},
+			{Type: github.String("required_reviewers"), Reviewers: []*github.RequiredReviewer{
+				{Type: github.String("User"), Reviewer: &github.User{Login: github.String("octocat")}},
+				{Type: github.String("Team"), Reviewer: &github.Team{Slug: github.String("release")}},
+			}},
+		},
+		DeploymentBranchPolicy: &github.BranchPolicy{ProtectedBranches: github.Bool(true), CustomBranchPolicies: github.Bool(false)},
+	}
+
+	snapshot := environmentSnapshot(environment)
+	if snapshot.WaitTimer != 15 || snapshot.BranchPolicy != BranchPolicyProtected {
+		t.Fatalf("unexpected snapshot: %+v", snapshot)
+	}
+	if len(snapshot.Reviewers) != 2 || snapshot.Reviewers[0] != "octocat" || snapshot.Reviewers[1] != "team:release" {
+		t.Fatalf("unexpected reviewers: %+v", snapshot.Reviewers)
+	}
+
+	unprotected := environmentSnapshot(&github.Environment{Name: github.String("staging")})
+	if unprotected.BranchPolicy != BranchPolicyAll || len(unprotected.Reviewers) != 0 {
+		t.Fatalf("unexpected snapshot: %+v", unprotected)
+	}
+}
+
+func TestFetchEnvironments(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/example-org/repo-a/environments":
+			w.Write([]byte(`{"total_count":1,"environments":[{"name":"production"}]}`))
+		case "/repos/example-org/repo-b/environments":
+			http.Error(w, `{"message":"Resource not accessible by integration"}`, http.StatusForbidden)
+		case "/repos/example-org/repo-c/environments":
+			http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+		default:
+			http.Error(w, `{"message":"Server Error"}`, http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	repo := func(name string) *github.Repository {
+		return &github.Repository{Name: github.String(name), Owner: &github.User{Login: github.String("example-org")}}
+	}
+
+	environments, err := fetchEnvironments(client, repo("repo-a"))
+	if err != nil || len(environments) != 1 || environments[0].Name != "production" {
+		t.Fatalf("unexpected environments: %+v, %v", environments, err)
+	}
+	for _, name := range []string{"repo-b", "repo-c"} {
+		if environments, err := fetchEnvironments(client, repo(name)); err != nil || environments != nil {
+			t.Fatalf("expected no environments for unreadable %s, got %+v, %v", name, environments, err)
+		}
+	}
+	if _, err := fetchEnvironments(client, repo("repo-d")); err == nil {
+		t.Fatal("expected an error for a server error")
+	}
+}
+
+func TestIsProductionEnvironment(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]bool{
+		"production":      true,
+		"Prod":            true,
+		"prod-eu":         true,
+		"us_live":         true,
+		"staging":         false,
+		"product-preview": false,
+		"deliver":         false,
+	}
+	for name, want := range cases {
+		if got := isProductionEnvironment(name); got != want {
+			t.Fatalf("isProductionEnvironment(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestAnalyzeEnvironments(t *testing.T) {
+	t.Parallel()
+
+	environments := []EnvironmentSnapshot{
+		{Name: "production", BranchPolicy: BranchPolicyAll},
+		{Name: "prod-eu", Reviewers: []string{"octocat"}, BranchPolicy: BranchPolicyProtected},
+		{Name: "staging", BranchPolicy: BranchPolicyAll},
+	}
+
+	findings := analyzeEnvironments("example-org", "repo-a", environments)
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %+v", findings)
+	}
+	finding := findings[0]
+	if finding.Rule != "unprotected-production-environment" || finding.FilePath != "environments/production" {
+		t.Fatalf("unexpected finding: %+v", finding)
+	}
+	if finding.URL != "https://github.com/example-org/repo-a/settings/environments" {
+		t.Fatalf("unexpected finding URL: %s", finding.URL)
+	}
+}
+
+func TestUpdateEnvironmentIndexAndMarkdown(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	environments := []EnvironmentSnapshot{
+		{Name: "production", BranchPolicy: BranchPolicyAll},
+		{Name: "staging", Reviewers: []string{"team:qa"}, WaitTimer: 5, BranchPolicy: BranchPolicyCustom, BranchPatterns: []string{"release/*"}},
+	}
+	if err := updateEnvironmentIndex(dbPath, "repo-a", environments); err != nil {
+		t.Fatalf("updateEnvironmentIndex returned error: %v", err)
+	}
+	if err := updateEnvironmentIndex(dbPath, "repo-b", environments[:1]); err != nil {
+		t.Fatalf("updateEnvironmentIndex returned error: %v", err)
+	}
+	if err := updateEnvironmentIndex(dbPath, "repo-b", nil); err != nil {
+		t.Fatalf("updateEnvironmentIndex returned error: %v", err)
+	}
+
+	index, err := loadEnvironmentIndex(dbPath)
+	if err != nil {
+		t.Fatalf("loadEnvironmentIndex returned error: %v", err)
+	}
+	if len(index.Repositories) != 1 || len(index.Repositories["repo-a"]) != 2 {
+		t.Fatalf("unexpected environment index: %+v", index.Repositories)
+	}
+
+	if err := generateEnvironmentsMarkdown(dbPath, "example-org"); err != nil {
+		t.Fatalf("generateEnvironmentsMarkdown returned error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dbPath, "ENVIRONMENTS.md"))
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+	markdown := string(data)
+	for _, want := range []string{
+		"| production ⚠️ | - | - | all branches |",
+		"| staging | `team:qa` | 5 min | custom branches: `release/*` |",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Fatalf("expected markdown to contain %q, got:\n%s", want, markdown)
+		}
+	}
+}
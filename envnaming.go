@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Environment Variables
+// ------------------------
+
+// Where a workflow sets an environment variable.
+const (
+	EnvScopeWorkflow  = "workflow"
+	EnvScopeJob       = "job"
+	EnvScopeContainer = "container"
+	EnvScopeService   = "service"
+	EnvScopeStep      = "step"
+	EnvScopeGitHubEnv = "github-env" // Written to the $GITHUB_ENV file by a run step
+)
+
+// githubEnvWriteRe matches a line of a run script that writes a variable to $GITHUB_ENV, such as
+// `echo "NAME=value" >> $GITHUB_ENV`, `echo "NAME<<EOF" >> "$GITHUB_ENV"`, or, in PowerShell,
+// `"NAME=value" | Out-File -FilePath $env:GITHUB_ENV -Append`.
+var githubEnvWriteRe = regexp.MustCompile(`^\s*(?:(?:echo|printf)(?:\s+-[A-Za-z]+)*\s+)?["']?([A-Za-z_][A-Za-z0-9_]*)(?:=|<<).*GITHUB_ENV`)
+
+// EnvVariable is an environment variable a workflow sets.
+type EnvVariable struct {
+	Name    string
+	Value   string // Empty for variables written to $GITHUB_ENV
+	Scope   string
+	Job     string // Empty for workflow env
+	Service string // Set for service container env
+	Step    int    // 1-based index of the step, for step env and $GITHUB_ENV writes
+	Line    int
+}
+
+// Location describes where a variable is set, for messages.
+func (v EnvVariable) Location() string {
+	switch v.Scope {
+	case EnvScopeWorkflow:
+		return "the workflow env"
+	case EnvScopeJob:
+		return fmt.Sprintf("the env of job '%s'", v.Job)
+	case EnvScopeContainer:
+		return fmt.Sprintf("the container env of job '%s'", v.Job)
+	case EnvScopeService:
+		return fmt.Sprintf("the env of service '%s' of job '%s'", v.Service, v.Job)
+	case EnvScopeStep:
+		return fmt.Sprintf("the env of step %d of job '%s'", v.Step, v.Job)
+	}
+	return fmt.Sprintf("$GITHUB_ENV by step %d of job '%s'", v.Step, v.Job)
+}
+
+// extractEnvVariables returns every environment variable a workflow sets, in the order they appear: in the
+// workflow, job, container, service, and step env blocks, and written to $GITHUB_ENV by run steps. Names
+// built from expressions cannot be known statically and are left out.
+func extractEnvVariables(content string) ([]EnvVariable, error) {
+	workflow, err := parseWorkflowDocument(content)
+	if err != nil {
+		return nil, err
+	}
+
+	variables := envBlockVariables(mappingValue(workflow, "env"), EnvVariable{Scope: EnvScopeWorkflow})
+	jobsNode := mappingValue(workflow, "jobs")
+	if jobsNode == nil || jobsNode.Kind != yaml.MappingNode {
+		return variables, nil
+	}
+	for i := 0; i+1 < len(jobsNode.Content); i += 2 {
+		jobID, jobNode := jobsNode.Content[i].Value, jobsNode.Content[i+1]
+		variables = append(variables, envBlockVariables(mappingValue(jobNode, "env"), EnvVariable{Scope: EnvScopeJob, Job: jobID})...)
+		variables = append(variables, envBlockVariables(mappingValue(mappingValue(jobNode, "container"), "env"), EnvVariable{Scope: EnvScopeContainer, Job: jobID})...)
+
+		if services := mappingValue(jobNode, "services"); services != nil && services.Kind == yaml.MappingNode {
+			for j := 0; j+1 < len(services.Content); j += 2 {
+				service := EnvVariable{Scope: EnvScopeService, Job: jobID, Service: services.Content[j].Value}
+				variables = append(variables, envBlockVariables(mappingValue(services.Content[j+1], "env"), service)...)
+			}
+		}
+
+		steps := mappingValue(jobNode, "steps")
+		if steps == nil || steps.Kind != yaml.SequenceNode {
+			continue
+		}
+		for j, step := range steps.Content {
+			variables = append(variables, envBlockVariables(mappingValue(step, "env"), EnvVariable{Scope: EnvScopeStep, Job: jobID, Step: j + 1})...)
+			if run := mappingValue(step, "run"); run != nil && run.Kind == yaml.ScalarNode {
+				variables = append(variables, githubEnvWrites(run, EnvVariable{Scope: EnvScopeGitHubEnv, Job: jobID, Step: j + 1})...)
+			}
+		}
+	}
+	return variables, nil
+}
+
+// envBlockVariables returns the variables of an env mapping, filling in the location from where.
+func envBlockVariables(node *yaml.Node, where EnvVariable) []EnvVariable {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	var variables []EnvVariable
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if strings.Contains(key.Value, "${{") {
+			continue
+		}
+		variable := where
+		variable.Name, variable.Line = key.Value, key.Line
+		if value.Kind == yaml.ScalarNode {
+			variable.Value = value.Value
+		}
+		variables = append(variables, variable)
+	}
+	return variables
+}
+
+// githubEnvWrites returns the variables a run script writes to $GITHUB_ENV.
+func githubEnvWrites(run *yaml.Node, where EnvVariable) []EnvVariable {
+	// The script of a block scalar starts on the line after its indicator
+	firstLine := run.Line
+	if run.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+		firstLine++
+	}
+	var variables []EnvVariable
+	for i, line := range strings.Split(run.Value, "\n") {
+		if match := githubEnvWriteRe.FindStringSubmatch(line); match != nil {
+			variable := where
+			variable.Name, variable.Line = match[1], firstLine+i
+			variables = append(variables, variable)
+		}
+	}
+	return variables
+}
+
+// ------------------------
+// Section: Environment Variable Naming
+// ------------------------
+
+// EnvNaming is the contents of env-naming.yaml, the naming convention for environment variables.
+type EnvNaming struct {
+	Pattern string   `yaml:"pattern"`         // Regular expression every variable name must match
+	Allow   []string `yaml:"allow,omitempty"` // Names allowed regardless, such as variables a tool reads
+	re      *regexp.Regexp
+}
+
+// envNaming holds the naming convention in effect for this run; nil when env-naming.yaml does not exist.
+var envNaming *EnvNaming
+
+// loadEnvNaming reads the optional env-naming.yaml in the database directory.
+func loadEnvNaming(dbPath string) error {
+	data, err := os.ReadFile(filepath.Join(dbPath, "env-naming.yaml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			envNaming = nil
+			return nil
+		}
+		return err
+	}
+
+	var naming EnvNaming
+	if err := yaml.Unmarshal(data, &naming); err != nil {
+		return fmt.Errorf("failed to parse env-naming.yaml: %v", err)
+	}
+	if err := naming.validate(); err != nil {
+		return fmt.Errorf("invalid env-naming.yaml: %v", err)
+	}
+	envNaming = &naming
+	logInfof("Loaded the environment variable naming convention from 'env-naming.yaml'")
+	return nil
+}
+
+// validate compiles the pattern, which is required.
+func (n *EnvNaming) validate() error {
+	if n.Pattern == "" {
+		return fmt.Errorf("'pattern' is required")
+	}
+	re, err := regexp.Compile(n.Pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern: %v", err)
+	}
+	n.re = re
+	return nil
+}
+
+// conforms reports whether a variable name follows the convention.
+func (n *EnvNaming) conforms(name string) bool {
+	return slices.Contains(n.Allow, name) || n.re.MatchString(name)
+}
+
+// scanForEnvNaming checks the environment variables of a workflow against the configured convention.
+func scanForEnvNaming(content, repoName, filePath string) []Finding {
+	return checkEnvNaming(envNaming, content, repoName, filePath)
+}
+
+// checkEnvNaming reports every environment variable a workflow sets whose name does not follow the convention.
+func checkEnvNaming(naming *EnvNaming, content, repoName, filePath string) []Finding {
+	if naming == nil {
+		return nil
+	}
+	variables, err := extractEnvVariables(content)
+	if err != nil {
+		return nil
+	}
+
+	var findings []Finding
+	seen := make(map[string]bool)
+	for _, variable := range variables {
+		if naming.conforms(variable.Name) {
+			continue
+		}
+		message := fmt.Sprintf("Environment variable '%s' in %s does not match %s", variable.Name, variable.Location(), naming.Pattern)
+		if variable.Scope == EnvScopeGitHubEnv {
+			message = fmt.Sprintf("Environment variable '%s' written to %s does not match %s", variable.Name, variable.Location(), naming.Pattern)
+		}
+		// A step may write the same variable more than once
+		if seen[message] {
+			continue
+		}
+		seen[message] = true
+		findings = append(findings, newFinding("env-var-naming", repoName, filePath, variable.Line, message))
+	}
+	return findings
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const envNamingWorkflow = `name: Build
+on: push
+env:
+  APP_REGION: us-east-1
+  debug: "true"
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    env:
+      APP_MODE: release
+      NODE_OPTIONS: --max-old-space-size=4096
+    container:
+      image: node:20
+      env:
+        cacheDir: /tmp/cache
+    services:
+      db:
+        image: postgres:16
+        env:
+          POSTGRES_PASSWORD: ${{ secrets.DB_PASSWORD }}
+    steps:
+      - uses: actions/checkout@v4
+      - name: Configure
+        env:
+          ${{ matrix.name }}_TOKEN: x
+          Version: "1.0"
+        run: |
+          echo "APP_SHA=${GITHUB_SHA}" >> "$GITHUB_ENV"
+          echo "buildDate=$(date)" >> $GITHUB_ENV
+          echo "buildDate=$(date -u)" >> $GITHUB_ENV
+          echo "NOTES<<EOF" >> $GITHUB_ENV
+          echo "done"
+      - run: '"psVar=1" | Out-File -FilePath $env:GITHUB_ENV -Append'
+        shell: pwsh
+`
+
+func TestExtractEnvVariables(t *testing.T) {
+	t.Parallel()
+
+	variables, err := extractEnvVariables(envNamingWorkflow)
+	if err != nil {
+		t.Fatalf("extractEnvVariables returned error: %v", err)
+	}
+	var got []string
+	for _, variable := range variables {
+		got = append(got, fmt.Sprintf("%s:%d %s", variable.Name, variable.Line, variable.Location()))
+	}
+	want := []string{
+		"APP_REGION:4 the workflow env",
+		"debug:5 the workflow env",
+		"APP_MODE:10 the env of job 'build'",
+		"NODE_OPTIONS:11 the env of job 'build'",
+		"cacheDir:15 the container env of job 'build'",
+		"POSTGRES_PASSWORD:20 the env of service 'db' of job 'build'",
+		"Version:26 the env of step 2 of job 'build'",
+		"APP_SHA:28 $GITHUB_ENV by step 2 of job 'build'",
+		"buildDate:29 $GITHUB_ENV by step 2 of job 'build'",
+		"buildDate:30 $GITHUB_ENV by step 2 of job 'build'",
+		"NOTES:31 $GITHUB_ENV by step 2 of job 'build'",
+		"psVar:33 $GITHUB_ENV by step 3 of job 'build'",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected variables:\n%s", strings.Join(got, "\n"))
+	}
+	if variables[0].Value != "us-east-1" || variables[0].Scope != EnvScopeWorkflow {
+		t.Fatalf("unexpected workflow variable: %+v", variables[0])
+	}
+}
+
+func TestCheckEnvNaming(t *testing.T) {
+	t.Parallel()
+
+	naming := &EnvNaming{Pattern: `^(APP|POSTGRES)_[A-Z0-9_]+$`, Allow: []string{"NODE_OPTIONS", "NOTES"}}
+	if err := naming.validate(); err != nil {
+		t.Fatalf("validate returned error: %v", err)
+	}
+	findings := checkEnvNaming(naming, envNamingWorkflow, "repo-a", ".github/workflows/build.yml")
+
+	var got []string
+	for _, finding := range findings {
+		if finding.Rule != "env-var-naming" || finding.Severity != SeverityLow {
+			t.Fatalf("unexpected finding: %+v", finding)
+		}
+		got = append(got, fmt.Sprintf("%d %s", finding.Line, finding.Message))
+	}
+	want := []string{
+		"5 Environment variable 'debug' in the workflow env does not match ^(APP|POSTGRES)_[A-Z0-9_]+$",
+		"15 Environment variable 'cacheDir' in the container env of job 'build' does not match ^(APP|POSTGRES)_[A-Z0-9_]+$",
+		"26 Environment variable 'Version' in the env of step 2 of job 'build' does not match ^(APP|POSTGRES)_[A-Z0-9_]+$",
+		"29 Environment variable 'buildDate' written to $GITHUB_ENV by step 2 of job 'build' does not match ^(APP|POSTGRES)_[A-Z0-9_]+$",
+		"33 Environment variable 'psVar' written to $GITHUB_ENV by step 3 of job 'build' does not match ^(APP|POSTGRES)_[A-Z0-9_]+$",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected findings:\n%s", strings.Join(got, "\n"))
+	}
+
+	if findings := checkEnvNaming(nil, envNamingWorkflow, "repo-a", ".github/workflows/build.yml"); len(findings) != 0 {
+		t.Fatalf("expected no findings without a convention, got %+v", findings)
+	}
+}
+
+func TestEnvNamingValidate(t *testing.T) {
+	t.Parallel()
+
+	for _, naming := range []*EnvNaming{{}, {Pattern: "^APP_[A-Z"}} {
+		if err := naming.validate(); err == nil {
+			t.Fatalf("expected an error for pattern %q", naming.Pattern)
+		}
+	}
+}
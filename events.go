@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// ------------------------
+// Section: Scan Events
+// ------------------------
+
+// Scan event types, in the order they occur for a repository.
+const (
+	ScanEventRepoStarted     = "repo-started"
+	ScanEventWorkflowIndexed = "workflow-indexed"
+	ScanEventFindingRaised   = "finding-raised"
+	ScanEventRepoFailed      = "repo-failed"
+)
+
+// ScanEvent is a step of a scan reported to AuditOptions.OnEvent, so that embedding applications can
+// follow progress without parsing the log.
+type ScanEvent struct {
+	Type       string    `json:"type"`
+	Time       time.Time `json:"time"`
+	Repository string    `json:"repository"`
+	Attempt    int       `json:"attempt,omitempty"`  // 1 for the first scan of a repository; set for repo-started and repo-failed
+	Workflow   string    `json:"workflow,omitempty"` // Path of the indexed workflow file
+	Hash       string    `json:"hash,omitempty"`     // Hash of the indexed workflow version
+	Changed    bool      `json:"changed,omitempty"`  // The repository moved to a different version of the workflow
+	Finding    *Finding  `json:"finding,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Final      bool      `json:"final,omitempty"` // No retries are left for the failed repository
+}
+
+// newScanEventEmitter wraps an event callback so that it is never called concurrently and every event has
+// a time. A nil callback discards events.
+func newScanEventEmitter(fn func(ScanEvent)) func(ScanEvent) {
+	if fn == nil {
+		return func(ScanEvent) {}
+	}
+	var mu sync.Mutex
+	return func(event ScanEvent) {
+		if event.Time.IsZero() {
+			event.Time = time.Now()
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		fn(event)
+	}
+}
+
+// newScanEventWriter returns an event callback that writes each event to w as a line of JSON.
+func newScanEventWriter(w io.Writer) func(ScanEvent) {
+	encoder := json.NewEncoder(w)
+	return func(event ScanEvent) {
+		if err := encoder.Encode(event); err != nil {
+			logErrorf("Error writing scan event: %v", err)
+		}
+	}
+}
+
+// emitRepositoryEvents reports the workflows indexed and findings raised for a repository.
+func emitRepositoryEvents(emit func(ScanEvent), repoName string, files *RepositoryFiles, findings []Finding, changes []WorkflowChange) {
+	changed := make(map[string]bool)
+	for _, change := range changes {
+		changed[change.FilePath] = true
+	}
+	for _, wf := range files.Workflows {
+		emit(ScanEvent{Type: ScanEventWorkflowIndexed, Repository: repoName, Workflow: wf.FilePath, Hash: wf.Hash, Changed: changed[wf.FilePath]})
+	}
+	for i := range findings {
+		emit(ScanEvent{Type: ScanEventFindingRaised, Repository: repoName, Finding: &findings[i]})
+	}
+}
+
+// ScanCounter counts the repositories a scan indexed, from its events. Repositories are counted by their
+// first attempt, so that the same name in several organizations counts once for each.
+type ScanCounter struct {
+	started   int
+	failed    int
+	workflows int
+}
+
+// Observe returns an event callback that counts each event before passing it to next, which may be nil.
+func (c *ScanCounter) Observe(next func(ScanEvent)) func(ScanEvent) {
+	return func(event ScanEvent) {
+		switch {
+		case event.Type == ScanEventRepoStarted && event.Attempt == 1:
+			c.started++
+		case event.Type == ScanEventRepoFailed && event.Final:
+			c.failed++
+		case event.Type == ScanEventWorkflowIndexed:
+			c.workflows++
+		}
+		if next != nil {
+			next(event)
+		}
+	}
+}
+
+// Scanned returns the number of repositories started and not given up on after their last retry.
+func (c *ScanCounter) Scanned() int {
+	return c.started - c.failed
+}
+
+// Workflows returns the number of workflow files indexed.
+func (c *ScanCounter) Workflows() int {
+	return c.workflows
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestScanEventEmitterSerializesCalls(t *testing.T) {
+	t.Parallel()
+
+	var active, calls int
+	emit := newScanEventEmitter(func(event ScanEvent) {
+		active++
+		if active > 1 {
+			t.Errorf("callback called concurrently")
+		}
+		if event.Time.IsZero() {
+			t.Errorf("expected the event time to be set")
+		}
+		calls++
+		active--
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			emit(ScanEvent{Type: ScanEventRepoStarted, Repository: "repo-a", Attempt: 1})
+		}()
+	}
+	wg.Wait()
+	if calls != 50 {
+		t.Fatalf("expected 50 calls, got %d", calls)
+	}
+
+	// A nil callback discards events
+	newScanEventEmitter(nil)(ScanEvent{Type: ScanEventRepoStarted})
+}
+
+func TestScanEventWriter(t *testing.T) {
+	t.Parallel()
+
+	var output strings.Builder
+	emit := newScanEventEmitter(newScanEventWriter(&output))
+
+	files := &RepositoryFiles{Workflows: []WorkflowFile{
+		{RepoName: "repo-a", FilePath: ".github/workflows/build.yml", Hash: "hash-one"},
+		{RepoName: "repo-a", FilePath: ".github/workflows/test.yml", Hash: "hash-two"},
+	}}
+	findings := []Finding{{Severity: SeverityHigh, Rule: "secrets-inherit", RepoName: "repo-a", FilePath: ".github/workflows/build.yml", Line: 3}}
+	changes := []WorkflowChange{{RepoName: "repo-a", FilePath: ".github/workflows/test.yml", From: "hash-old", To: "hash-two"}}
+	emitRepositoryEvents(emit, "repo-a", files, findings, changes)
+	emit(ScanEvent{Type: ScanEventRepoFailed, Repository: "repo-b", Attempt: 3, Error: "not found", Final: true})
+
+	var events []ScanEvent
+	scanner := bufio.NewScanner(strings.NewReader(output.String()))
+	for scanner.Scan() {
+		var event ScanEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("failed to parse event line %q: %v", scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+
+	if len(events) != 4 {
+		t.Fatalf("expected 4 events, got %d:\n%s", len(events), output.String())
+	}
+	if events[0].Type != ScanEventWorkflowIndexed || events[0].Changed || !events[1].Changed || events[1].Hash != "hash-two" {
+		t.Fatalf("unexpected workflow events: %+v", events[:2])
+	}
+	if events[2].Type != ScanEventFindingRaised || events[2].Finding == nil || events[2].Finding.Rule != "secrets-inherit" {
+		t.Fatalf("unexpected finding event: %+v", events[2])
+	}
+	if events[3].Type != ScanEventRepoFailed || !events[3].Final || events[3].Attempt != 3 {
+		t.Fatalf("unexpected failure event: %+v", events[3])
+	}
+}
+
+func TestScanCounter(t *testing.T) {
+	t.Parallel()
+
+	var counter ScanCounter
+	var passed int
+	emit := counter.Observe(func(ScanEvent) { passed++ })
+	for _, event := range []ScanEvent{
+		{Type: ScanEventRepoStarted, Repository: "repo-a", Attempt: 1},
+		{Type: ScanEventRepoStarted, Repository: "repo-b", Attempt: 1},
+		{Type: ScanEventRepoFailed, Repository: "repo-b", Attempt: 1},
+		{Type: ScanEventRepoStarted, Repository: "repo-b", Attempt: 2},
+		{Type: ScanEventRepoStarted, Repository: "repo-c", Attempt: 1},
+		{Type: ScanEventRepoFailed, Repository: "repo-c", Attempt: 1, Final: true},
+		// The same name in another organization
+		{Type: ScanEventRepoStarted, Repository: "repo-a", Attempt: 1},
+	} {
+		emit(event)
+	}
+	if counter.Scanned() != 3 || passed != 7 {
+		t.Fatalf("expected 3 repositories scanned and 7 events passed on, got %d and %d", counter.Scanned(), passed)
+	}
+
+	// Without a next callback events are only counted
+	counter.Observe(nil)(ScanEvent{Type: ScanEventRepoStarted, Attempt: 1})
+	if counter.Scanned() != 4 {
+		t.Fatalf("expected 4 repositories scanned, got %d", counter.Scanned())
+	}
+}
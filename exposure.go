@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: External Consumers
+// ------------------------
+
+// codeSearchInterval spaces code search requests to stay within GitHub's limit of ten per minute.
+const codeSearchInterval = 6 * time.Second
+
+// codeSearchAttempts bounds how often a code search request is retried after hitting a rate limit.
+const codeSearchAttempts = 3
+
+// ExternalConsumer is a workflow outside the organization that references one of its repositories.
+type ExternalConsumer struct {
+	Repository string `yaml:"repository"`     // owner/name of the consuming repository
+	Workflow   string `yaml:"workflow"`       // Path of the workflow file in the consuming repository
+	Uses       string `yaml:"uses,omitempty"` // The reference as written, such as example-org/ci/.github/workflows/go.yml@v2; empty when the search did not return the matching line
+	URL        string `yaml:"url"`
+}
+
+// ExternalConsumerIndex is the contents of external_consumers.yaml, written by -external-consumers.
+type ExternalConsumerIndex struct {
+	Organization string                        `yaml:"organization"`
+	SearchedAt   string                        `yaml:"searched_at"`
+	Repositories map[string][]ExternalConsumer `yaml:"repositories"` // Repository of the organization: consumers
+}
+
+// publishedRepositories returns the repositories of the organization that its own workflows use as
+// actions or reusable workflows, which are the ones other organizations may consume too.
+func publishedRepositories(dbPath, org string, usesIndex *ActionUsesIndex) ([]string, error) {
+	seen := make(map[string]bool)
+	var repoNames []string
+	add := func(reference string) {
+		owner, repoName, ok := actionRepository(reference)
+		if !ok || !strings.EqualFold(owner, org) || seen[strings.ToLower(repoName)] {
+			return
+		}
+		seen[strings.ToLower(repoName)] = true
+		repoNames = append(repoNames, repoName)
+	}
+
+	if usesIndex != nil {
+		for actionName := range usesIndex.Actions {
+			add(actionName)
+		}
+	}
+	err := walkIndexedWorkflows(dbPath, func(fileName, repoName, content string) {
+		jobs, err := resolveWorkflowJobs(content)
+		if err != nil {
+			return
+		}
+		for _, job := range jobs {
+			if job.ReusableWorkflow != "" {
+				reference, _, _ := strings.Cut(job.ReusableWorkflow, "@")
+				add(reference)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(repoNames)
+	return repoNames, nil
+}
+
+// searchExternalConsumers uses code search to find workflow files outside the organization that reference
+// each of the given repositories. Requests are spaced by pause, and rate limited requests are retried
+// once the limit resets.
+func searchExternalConsumers(client *github.Client, org string, repoNames []string, pause time.Duration) (map[string][]ExternalConsumer, error) {
+	ctx := context.Background()
+	consumers := make(map[string][]ExternalConsumer)
+	first := true
+	for _, repoName := range repoNames {
+		target := org + "/" + repoName
+		referenceRe := regexp.MustCompile(`(?i)(?:^|[^\w.-])(` + regexp.QuoteMeta(target) + `(?:/[^\s@'"]*)?@[^\s'"#]+)`)
+		seen := make(map[ExternalConsumer]bool)
+
+		opts := &github.SearchOptions{TextMatch: true, ListOptions: github.ListOptions{PerPage: 100}}
+		for {
+			if !first {
+				time.Sleep(pause)
+			}
+			first = false
+
+			result, resp, err := searchCodeWithRetry(ctx, client, fmt.Sprintf(`"%s" path:.github/workflows`, target), opts)
+			if err != nil {
+				return nil, fmt.Errorf("failed to search for consumers of '%s': %v", target, err)
+			}
+
+			for _, item := range result.CodeResults {
+				repo := item.GetRepository()
+				if strings.EqualFold(repo.GetOwner().GetLogin(), org) {
+					continue
+				}
+				consumer := ExternalConsumer{Repository: repo.GetFullName(), Workflow: item.GetPath(), URL: item.GetHTMLURL()}
+
+				var references []string
+				for _, textMatch := range item.TextMatches {
+					for _, match := range referenceRe.FindAllStringSubmatch(textMatch.GetFragment(), -1) {
+						references = append(references, match[1])
+					}
+				}
+				if len(references) == 0 {
+					references = []string{""}
+				}
+				for _, reference := range references {
+					consumer.Uses = reference
+					if !seen[consumer] {
+						seen[consumer] = true
+						consumers[repoName] = append(consumers[repoName], consumer)
+					}
+				}
+			}
+
+			if resp.NextPage == 0 {
+				break
+			}
+			opts.Page = resp.NextPage
+		}
+
+		sort.Slice(consumers[repoName], func(i, j int) bool {
+			a, b := consumers[repoName][i], consumers[repoName][j]
+			if a.Repository != b.Repository {
+				return a.Repository < b.Repository
+			}
+			if a.Workflow != b.Workflow {
+				return a.Workflow < b.Workflow
+			}
+			return a.Uses < b.Uses
+		})
+		logDebugf("Found %d external references to '%s'", len(consumers[repoName]), target)
+	}
+	return consumers, nil
+}
+
+// searchCodeWithRetry runs a code search, waiting for the rate limit to reset when it is exceeded.
+func searchCodeWithRetry(ctx context.Context, client *github.Client, query string, opts *github.SearchOptions) (*github.CodeSearchResult, *github.Response, error) {
+	for attempt := 1; ; attempt++ {
+		result, resp, err := client.Search.Code(ctx, query, opts)
+		if err == nil || attempt == codeSearchAttempts {
+			return result, resp, err
+		}
+
+		var rateErr *github.RateLimitError
+		var abuseErr *github.AbuseRateLimitError
+		switch {
+		case errors.As(err, &rateErr):
+			wait := time.Until(rateErr.Rate.Reset.Time) + time.Second
+			logWarnf("Code search rate limit reached. Waiting for %v...", wait)
+			time.Sleep(wait)
+		case errors.As(err, &abuseErr):
+			wait := abuseErr.GetRetryAfter()
+			if wait == 0 {
+				wait = time.Minute
+			}
+			logWarnf("Code search secondary rate limit reached. Waiting for %v...", wait)
+			time.Sleep(wait)
+		default:
+			return result, resp, err
+		}
+	}
+}
+
+// updateExternalConsumers searches for external consumers of the organization's published repositories
+// and writes them to external_consumers.yaml. On failure the previous results are kept.
+func updateExternalConsumers(client *github.Client, dbPath, org string, usesIndex *ActionUsesIndex) error {
+	repoNames, err := publishedRepositories(dbPath, org, usesIndex)
+	if err != nil {
+		return err
+	}
+	logInfof("Searching for external consumers of %d repositories", len(repoNames))
+
+	consumers, err := searchExternalConsumers(client, org, repoNames, codeSearchInterval)
+	if err != nil {
+		return err
+	}
+
+	index := ExternalConsumerIndex{Organization: org, SearchedAt: formatReportDate(time.Now()), Repositories: consumers}
+	data, err := yaml.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("error marshaling external_consumers.yaml: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dbPath, "external_consumers.yaml"), data, 0644); err != nil {
+		return fmt.Errorf("error writing external_consumers.yaml: %v", err)
+	}
+	return nil
+}
+
+// loadExternalConsumers reads external_consumers.yaml, returning nil if the search has never run.
+func loadExternalConsumers(dbPath string) (*ExternalConsumerIndex, error) {
+	data, err := os.ReadFile(filepath.Join(dbPath, "external_consumers.yaml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var index ExternalConsumerIndex
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse external_consumers.yaml: %v", err)
+	}
+	return &index, nil
+}
+
+// generateExposureMarkdown writes EXPOSURE.md from external_consumers.yaml. Nothing is written until
+// -external-consumers has run once.
+func generateExposureMarkdown(dbPath, org string) error {
+	index, err := loadExternalConsumers(dbPath)
+	if err != nil || index == nil {
+		return err
+	}
+
+	repoNames := make([]string, 0, len(index.Repositories))
+	consumingRepos := make(map[string]bool)
+	for repoName, consumers := range index.Repositories {
+		if len(consumers) > 0 {
+			repoNames = append(repoNames, repoName)
+		}
+		for _, consumer := range consumers {
+			consumingRepos[strings.ToLower(consumer.Repository)] = true
+		}
+	}
+	sort.Strings(repoNames)
+
+	var markdownBuilder strings.Builder
+	markdownBuilder.WriteString("# External Consumers\n\n")
+	markdownBuilder.WriteString(fmt.Sprintf("This document lists the workflows outside %s that reference the actions and reusable workflows it publishes, found with code search on %s. Changes to these repositories affect the consumers below. Code search only covers repositories visible to the token, so private consumers are usually missing.\n\n", org, index.SearchedAt))
+	markdownBuilder.WriteString(fmt.Sprintf("%d external repositories reference %d repositories of %s.\n\n", len(consumingRepos), len(repoNames), org))
+	markdownBuilder.WriteString("| Repository | Consumer | Workflow | Uses |\n")
+	markdownBuilder.WriteString("|------------|----------|----------|------|\n")
+
+	count := 0
+	for _, repoName := range repoNames {
+		for _, consumer := range index.Repositories[repoName] {
+			count++
+			uses := "-"
+			if consumer.Uses != "" {
+				uses = "`" + consumer.Uses + "`"
+			}
+			markdownBuilder.WriteString(fmt.Sprintf("| [%s](%s/%s/%s) | [%s](%s/%s) | [%s](%s) | %s |\n",
+				repoName, githubWebURL, org, repoName, consumer.Repository, githubWebURL, consumer.Repository, consumer.Workflow, consumer.URL, uses))
+		}
+	}
+	if count == 0 {
+		markdownBuilder.WriteString("| *No external consumers found* | - | - | - |\n")
+	}
+
+	markdownBuilder.WriteString("\n*This file is automatically generated after each data collection run.*\n")
+
+	if err := os.WriteFile(filepath.Join(dbPath, "EXPOSURE.md"), []byte(markdownBuilder.String()), 0644); err != nil {
+		return fmt.Errorf("error writing EXPOSURE.md: %v", err)
+	}
+
+	logInfof("Generated EXPOSURE.md with %d external references", count)
+	return nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v50/github"
+)
+
+func TestPublishedRepositories(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	content := "jobs:\n  lint:\n    uses: Example-Org/shared-workflows/.github/workflows/lint.yml@v1\n  external:\n    uses: other-org/workflows/.github/workflows/x.yml@main\n"
+	if err := updateActionIndex(dbPath, "ci.yml", "repo-a", "ci.yml", "hash-one", ""); err != nil {
+		t.Fatalf("updateActionIndex returned error: %v", err)
+	}
+	if err := storeActionVersion(dbPath, "ci.yml", "hash-one", content); err != nil {
+		t.Fatalf("storeActionVersion returned error: %v", err)
+	}
+	usesIndex := &ActionUsesIndex{Actions: map[string]map[string][]WorkflowReference{
+		"example-org/setup-tool":       {"v2": nil},
+		"example-org/actions/lint":     {"v1": nil},
+		"actions/checkout":             {"v4": nil},
+		"./.github/actions/local":      {"": nil},
+		"example-org/shared-workflows": {"v1": nil},
+	}}
+
+	repoNames, err := publishedRepositories(dbPath, "example-org", usesIndex)
+	if err != nil {
+		t.Fatalf("publishedRepositories returned error: %v", err)
+	}
+	if fmt.Sprint(repoNames) != "[actions setup-tool shared-workflows]" {
+		t.Fatalf("unexpected repositories: %v", repoNames)
+	}
+}
+
+func TestSearchExternalConsumers(t *testing.T) {
+	t.Parallel()
+
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search/code" {
+			http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+			return
+		}
+		queries = append(queries, r.URL.Query().Get("q"))
+		if r.URL.Query().Get("page") == "2" {
+			w.Write([]byte(`{"total_count":3,"items":[
+				{"path":".github/workflows/release.yml","html_url":"https://github.com/acme/tool/blob/abc/.github/workflows/release.yml",
+				 "repository":{"full_name":"acme/tool","owner":{"login":"acme"}}}]}`))
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s/search/code?page=2>; rel="next"`, "http://"+r.Host))
+		w.Write([]byte(`{"total_count":3,"items":[
+			{"path":".github/workflows/ci.yml","html_url":"https://github.com/acme/app/blob/abc/.github/workflows/ci.yml",
+			 "repository":{"full_name":"acme/app","owner":{"login":"acme"}},
+			 "text_matches":[{"fragment":"    uses: example-org/shared-workflows/.github/workflows/lint.yml@v1\n    uses: 'example-org/shared-workflows/.github/workflows/test.yml@main' # pinned later\n"}]},
+			{"path":".github/workflows/ci.yml","html_url":"https://github.com/example-org/repo-a/blob/abc/.github/workflows/ci.yml",
+			 "repository":{"full_name":"example-org/repo-a","owner":{"login":"Example-Org"}},
+			 "text_matches":[{"fragment":"uses: example-org/shared-workflows/.github/workflows/lint.yml@v1"}]}]}`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	consumers, err := searchExternalConsumers(client, "example-org", []string{"shared-workflows"}, 0)
+	if err != nil {
+		t.Fatalf("searchExternalConsumers returned error: %v", err)
+	}
+	if len(queries) != 2 || queries[0] != `"example-org/shared-workflows" path:.github/workflows` {
+		t.Fatalf("unexpected queries: %q", queries)
+	}
+	got := consumers["shared-workflows"]
+	if len(got) != 3 {
+		t.Fatalf("expected 3 consumers outside the organization, got %+v", got)
+	}
+	if got[0].Uses != "example-org/shared-workflows/.github/workflows/lint.yml@v1" || got[1].Uses != "example-org/shared-workflows/.github/workflows/test.yml@main" {
+		t.Fatalf("unexpected references: %+v", got)
+	}
+	if got[2].Repository != "acme/tool" || got[2].Uses != "" {
+		t.Fatalf("expected a consumer without a matched line, got %+v", got[2])
+	}
+
+	dbPath := t.TempDir()
+	if err := generateExposureMarkdown(dbPath, "example-org"); err != nil {
+		t.Fatalf("generateExposureMarkdown returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dbPath, "EXPOSURE.md")); !os.IsNotExist(err) {
+		t.Fatalf("expected no EXPOSURE.md before the first search, got %v", err)
+	}
+}
+
+func TestGenerateExposureMarkdown(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	index := "organization: example-org\nsearched_at: \"2026-03-04\"\nrepositories:\n    setup-tool: []\n    shared-workflows:\n" +
+		"        - repository: acme/app\n          workflow: .github/workflows/ci.yml\n          uses: example-org/shared-workflows/.github/workflows/lint.yml@v1\n          url: https://github.com/acme/app/blob/abc/.github/workflows/ci.yml\n"
+	if err := os.WriteFile(filepath.Join(dbPath, "external_consumers.yaml"), []byte(index), 0644); err != nil {
+		t.Fatalf("failed to write external_consumers.yaml: %v", err)
+	}
+	if err := generateExposureMarkdown(dbPath, "example-org"); err != nil {
+		t.Fatalf("generateExposureMarkdown returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dbPath, "EXPOSURE.md"))
+	if err != nil {
+		t.Fatalf("failed to read EXPOSURE.md: %v", err)
+	}
+	for _, want := range []string{
+		"1 external repositories reference 1 repositories of example-org.",
+		"| [shared-workflows](https://github.com/example-org/shared-workflows) | [acme/app](https://github.com/acme/app) | [.github/workflows/ci.yml](https://github.com/acme/app/blob/abc/.github/workflows/ci.yml) | `example-org/shared-workflows/.github/workflows/lint.yml@v1` |",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected EXPOSURE.md to contain %q, got:\n%s", want, data)
+		}
+	}
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ------------------------
+// Section: Findings
+// ------------------------
+
+// Severity levels assigned to rules, from most to least urgent.
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+	SeverityLow      = "low"
+)
+
+// Rule describes a check performed by an analyzer. Every finding references a rule in the catalog.
+type Rule struct {
+	ID          string `yaml:"id" json:"id"`
+	Severity    string `yaml:"severity" json:"severity"`
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description" json:"description"`
+	Remediation string `yaml:"remediation" json:"remediation"`
+}
+
+// ruleCatalog lists every rule that analyzers can report.
+var ruleCatalog = []Rule{
+	{
+		ID:          "github-token",
+		Severity:    SeverityCritical,
+		Name:        "GitHub token in workflow",
+		Description: "A GitHub personal access, OAuth, or app token is committed in the workflow file.",
+		Remediation: "Revoke the token, remove it from the file and its history, and reference it through `secrets` instead.",
+	},
+	{
+		ID:          "aws-access-key",
+		Severity:    SeverityCritical,
+		Name:        "AWS access key in workflow",
+		Description: "An AWS access key ID is committed in the workflow file.",
+		Remediation: "Deactivate the key in IAM, and prefer OIDC with `aws-actions/configure-aws-credentials` over long-lived keys.",
+	},
+	{
+		ID:          "slack-token",
+		Severity:    SeverityCritical,
+		Name:        "Slack token in workflow",
+		Description: "A Slack API token is committed in the workflow file.",
+		Remediation: "Revoke the token in Slack and store the replacement as a repository or organization secret.",
+	},
+	{
+		ID:          "private-key",
+		Severity:    SeverityCritical,
+		Name:        "Private key in workflow",
+		Description: "A private key block is committed in the workflow file.",
+		Remediation: "Rotate the key pair and load the private key from a secret at runtime.",
+	},
+	{
+		ID:          "hardcoded-secret",
+		Severity:    SeverityCritical,
+		Name:        "Hardcoded credential value",
+		Description: "A literal value is assigned to a key whose name suggests a credential.",
+		Remediation: "Move the value into a secret and reference it with `${{ secrets.NAME }}`; rotate it if it was a real credential.",
+	},
+	{
+		ID:          "compromised-action",
+		Severity:    SeverityCritical,
+		Name:        "Known-compromised action",
+		Description: "The workflow uses an action version involved in a published supply-chain incident.",
+		Remediation: "Remove the action or pin it to a known-good commit SHA, then rotate any secrets the workflow could access.",
+	},
+	{
+		ID:          "unprotected-production-environment",
+		Severity:    SeverityHigh,
+		Name:        "Production environment without required reviewers",
+		Description: "A deployment environment whose name suggests production can be deployed to without approval.",
+		Remediation: "Add required reviewers to the environment and restrict deployments to protected branches.",
+	},
+	{
+		ID:          "required-workflow-missing",
+		Severity:    SeverityHigh,
+		Name:        "Required workflow missing",
+		Description: "An active organization ruleset requires a workflow file that is not in the index, so pull requests covered by the ruleset cannot merge.",
+		Remediation: "Restore the workflow file, or update the ruleset to point at the workflow's new repository or path.",
+	},
+	{
+		ID:          "write-all-permissions",
+		Severity:    SeverityMedium,
+		Name:        "Job with write-all token permissions",
+		Description: "A job's effective `GITHUB_TOKEN` permissions are `write-all`, either declared on the job or inherited from the workflow.",
+		Remediation: "Declare only the scopes the job needs, for example `contents: read`, at the workflow level and widen them on the jobs that need more.",
+	},
+	{
+		ID:          "default-write-token",
+		Severity:    SeverityMedium,
+		Name:        "Job relies on a read and write default token",
+		Description: "A job declares no `permissions`, so it gets the repository's default `GITHUB_TOKEN`, which is set to read and write.",
+		Remediation: "Declare `permissions` on the workflow or job, or set the repository's default workflow permissions to read.",
+	},
+	{
+		ID:          "actions-can-approve-pull-requests",
+		Severity:    SeverityMedium,
+		Name:        "Actions can approve pull requests",
+		Description: "The repository allows GitHub Actions to create and approve pull requests, so a workflow can satisfy required reviews on its own changes.",
+		Remediation: "Turn off \"Allow GitHub Actions to create and approve pull requests\" in the repository's Actions settings.",
+	},
+	{
+		ID:          "artifact-poisoning",
+		Severity:    SeverityHigh,
+		Name:        "Artifact from another run executed in a privileged workflow",
+		Description: "A job of a `workflow_run` or `pull_request_target` workflow downloads the artifacts of another run, which may be a pull request from a fork, and then runs a script or a local action. A fork can replace the artifact's contents with code that runs with the base repository's secrets and token.",
+		Remediation: "Treat the artifact as untrusted data: extract it outside the workspace, validate it before use, and never execute files from it. Keep privileged steps in a job that does not download it.",
+	},
+	{
+		ID:          "cache-poisoning",
+		Severity:    SeverityMedium,
+		Name:        "Cache restored and executed in a pull_request_target workflow",
+		Description: "A job of a `pull_request_target` workflow restores a cache, which shares its scope with the base branch, and then runs a script or a local action. A run that built a fork's code can save a cache entry that later runs with the base repository's secrets and token.",
+		Remediation: "Do not use caches in `pull_request_target` workflows, or do not run a fork's code in any of them, so their cache entries cannot be poisoned.",
+	},
+	{
+		ID:          "deprecated-command",
+		Severity:    SeverityMedium,
+		Name:        "Deprecated workflow command",
+		Description: "The workflow uses `::set-output`, `::save-state`, `::set-env`, or `::add-path`, which GitHub has disabled or deprecated.",
+		Remediation: "Write to the `$GITHUB_OUTPUT`, `$GITHUB_STATE`, `$GITHUB_ENV`, or `$GITHUB_PATH` environment files instead.",
+	},
+	{
+		ID:          "deprecated-action",
+		Severity:    SeverityMedium,
+		Name:        "Unmaintained action",
+		Description: "The workflow uses an action that has been archived or is no longer maintained.",
+		Remediation: "Switch to the suggested replacement action or an equivalent `run` step.",
+	},
+	{
+		ID:          "outdated-action-runtime",
+		Severity:    SeverityMedium,
+		Name:        "Action on a retired Node.js runtime",
+		Description: "The workflow uses a major version of an action that runs on node12 or node16, which GitHub no longer supports.",
+		Remediation: "Upgrade to the suggested major version and review its release notes for breaking changes.",
+	},
+	{
+		ID:          "suppression-missing-reason",
+		Severity:    SeverityLow,
+		Name:        "Suppression without a reason",
+		Description: "A `# dotgithubindexer:ignore` comment suppresses a rule without explaining why.",
+		Remediation: "Add `reason=...` to the comment describing why the finding is acceptable.",
+	},
+	{
+		ID:          "job-count-budget",
+		Severity:    SeverityLow,
+		Name:        "Too many jobs in workflow",
+		Description: "The workflow defines more jobs than the `max_jobs_per_workflow` budget in `budgets.yaml` allows.",
+		Remediation: "Split the workflow by purpose or move repeated jobs into a reusable workflow or matrix.",
+	},
+	{
+		ID:          "step-count-budget",
+		Severity:    SeverityLow,
+		Name:        "Too many steps in job",
+		Description: "A job has more steps than the `max_steps_per_job` budget in `budgets.yaml` allows.",
+		Remediation: "Group related steps into a script or composite action, or split the job.",
+	},
+	{
+		ID:          "timeout-budget",
+		Severity:    SeverityMedium,
+		Name:        "Job timeout above ceiling",
+		Description: "A job's timeout, or GitHub's 360 minute default when it sets none, exceeds the `max_timeout_minutes` ceiling in `budgets.yaml`.",
+		Remediation: "Set `timeout-minutes` on the job to a value within the ceiling.",
+	},
+	{
+		ID:          "env-var-naming",
+		Severity:    SeverityLow,
+		Name:        "Environment variable outside the naming convention",
+		Description: "A workflow sets an environment variable, in an `env` block or by writing to `$GITHUB_ENV`, whose name does not match the `pattern` in `env-naming.yaml`.",
+		Remediation: "Rename the variable to follow the convention, along with the steps that read it, or add it to `allow` in `env-naming.yaml` if a tool requires its name.",
+	},
+	{
+		ID:          "github-script-size",
+		Severity:    SeverityLow,
+		Name:        "Oversized github-script",
+		Description: "An `actions/github-script` step runs an inline script longer than `max_github_script_lines` in `budgets.yaml`, or 50 lines by default. Inline JavaScript is not linted, tested, or reviewed like the repository's code.",
+		Remediation: "Move the script into a file in the repository, or into a JavaScript action, so it can be linted and tested, and call it from the step.",
+	},
+	{
+		ID:          "github-script-privileged-api",
+		Severity:    SeverityMedium,
+		Name:        "github-script calls a privileged API",
+		Description: "An `actions/github-script` step interpolates secrets into its script, manages Actions secrets, writes git data, repository settings, or organization and team membership, merges pull requests, or sends raw write requests or GraphQL mutations.",
+		Remediation: "Confirm the call is needed and the job's token is scoped to it. Pass secrets through `env` instead of interpolating them into the script.",
+	},
+	{
+		ID:          "unfrozen-action-version",
+		Severity:    SeverityMedium,
+		Name:        "Action version outside the freeze",
+		Description: "The workflow uses a version of an action that is not among the versions allowed in `freeze.yaml`, or, with a strict freeze, an action that is not listed at all.",
+		Remediation: "Move to an allowed version, for example with `dotgithubindexer freeze -open-pr`, or ask the platform owners to add the version to the freeze.",
+	},
+	{
+		ID:          "deprecated-input",
+		Severity:    SeverityLow,
+		Name:        "Renamed action input",
+		Description: "The workflow passes an input under a name that the action has since renamed.",
+		Remediation: "Rename the input to its current name.",
+	},
+}
+
+// lookupRule returns the catalog entry for a rule ID.
+func lookupRule(id string) (Rule, bool) {
+	for _, rule := range ruleCatalog {
+		if rule.ID == id {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Finding represents an issue detected while indexing a repository file.
+type Finding struct {
+	Severity    string `json:"severity"`
+	Rule        string `json:"rule"` // ID of the rule in the catalog
+	RepoName    string `json:"repository"`
+	FilePath    string `json:"file_path"`
+	Line        int    `json:"line"`
+	Message     string `json:"message"`
+	Remediation string `json:"remediation"`
+	Suggestion  string `json:"suggestion,omitempty"` // Optional concrete change for this occurrence, e.g. a replacement action
+	Fingerprint string `json:"fingerprint"`          // Stable identifier that survives line number changes
+	URL         string `json:"url,omitempty"`        // Optional link used instead of the file link, for findings outside workflow files
+	// Annotations are the comment-based metadata of the workflow the finding was raised in
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// newFinding creates a finding for a catalog rule, filling in its severity, remediation, and fingerprint.
+// The severity is the rule's override in severities.yaml, if any.
+func newFinding(ruleID, repoName, filePath string, line int, message string) Finding {
+	rule, ok := lookupRule(ruleID)
+	if !ok {
+		panic(fmt.Sprintf("finding reported for unknown rule '%s'", ruleID))
+	}
+	return Finding{
+		Severity:    ruleSeverity(rule),
+		Rule:        rule.ID,
+		RepoName:    repoName,
+		FilePath:    filePath,
+		Line:        line,
+		Message:     message,
+		Remediation: rule.Remediation,
+		Fingerprint: findingFingerprint(rule.ID, repoName, filePath, message),
+	}
+}
+
+// findingFingerprint hashes the parts of a finding that identify it across runs.
+func findingFingerprint(ruleID, repoName, filePath, message string) string {
+	return computeHash([]byte(strings.Join([]string{ruleID, repoName, filePath, message}, "\x00")))[:16]
+}
+
+// analyzeWorkflow runs every enabled analyzer over a workflow file and returns the findings that are not suppressed.
+func analyzeWorkflow(content, repoName, filePath string) []Finding {
+	findings, _ := analyzeWorkflowWithSuppressions(content, repoName, filePath)
+	return findings
+}
+
+// analyzeWorkflowWithSuppressions runs every enabled analyzer over a workflow file and splits the findings
+// into open ones and those acknowledged by an inline suppression comment.
+func analyzeWorkflowWithSuppressions(content, repoName, filePath string) ([]Finding, []SuppressedFinding) {
+	var findings []Finding
+	for _, analyzer := range analyzers {
+		if analyzer.Scan != nil && analyzerEnabled(analyzer.Name) {
+			findings = append(findings, analyzer.Scan(content, repoName, filePath)...)
+		}
+	}
+	return applySuppressions(findings, parseSuppressions(content), repoName, filePath)
+}
+
+// generateFindingsMarkdown creates a FINDINGS.md file in the db folder listing all findings.
+func generateFindingsMarkdown(dbPath, org string, findings []Finding) error {
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].RepoName != findings[j].RepoName {
+			return findings[i].RepoName < findings[j].RepoName
+		}
+		if findings[i].FilePath != findings[j].FilePath {
+			return findings[i].FilePath < findings[j].FilePath
+		}
+		if findings[i].Line != findings[j].Line {
+			return findings[i].Line < findings[j].Line
+		}
+		if findings[i].Rule != findings[j].Rule {
+			return findings[i].Rule < findings[j].Rule
+		}
+		return findings[i].Message < findings[j].Message
+	})
+
+	var markdownBuilder strings.Builder
+	markdownBuilder.WriteString("# Findings\n\n")
+	markdownBuilder.WriteString("This document lists issues detected in workflow files and repository settings across the organization. See [RULES.md](RULES.md) for how to fix each rule.\n\n")
+	if enabledAnalyzers != nil {
+		markdownBuilder.WriteString(fmt.Sprintf("Only these analyzers were run: %s.\n\n", strings.Join(selectedAnalyzerNames(), ", ")))
+	}
+	markdownBuilder.WriteString("| Severity | Rule | Repository | File | Message |\n")
+	markdownBuilder.WriteString("|----------|------|------------|------|---------|\n")
+
+	if len(findings) == 0 {
+		markdownBuilder.WriteString("| *No findings* | - | - | - | - |\n")
+	} else {
+		defaultBranches := loadDefaultBranches(dbPath)
+		for _, finding := range findings {
+			location := fmt.Sprintf("%s:%d", finding.FilePath, finding.Line)
+			url := fmt.Sprintf("%s#L%d", repositoryFileURL(org, finding.RepoName, defaultBranches, finding.FilePath), finding.Line)
+			if finding.URL != "" {
+				location = finding.FilePath
+				url = finding.URL
+			}
+			message := finding.Message
+			if finding.Suggestion != "" {
+				message += "<br>**Suggestion**: " + finding.Suggestion
+			}
+			markdownBuilder.WriteString(fmt.Sprintf("| %s | %s | %s | [%s](%s) | %s |\n",
+				finding.Severity, finding.Rule, finding.RepoName, location, url, message))
+		}
+	}
+
+	markdownBuilder.WriteString("\n*This file is automatically generated after each data collection run.*\n")
+
+	findingsPath := filepath.Join(dbPath, "FINDINGS.md")
+	if err := os.WriteFile(findingsPath, []byte(markdownBuilder.String()), 0644); err != nil {
+		return fmt.Errorf("error writing FINDINGS.md: %v", err)
+	}
+
+	logInfof("Generated FINDINGS.md with %d findings", len(findings))
+	return nil
+}
+
+// generateRulesMarkdown creates a RULES.md file in the db folder documenting the rule catalog.
+func generateRulesMarkdown(dbPath string) error {
+	var markdownBuilder strings.Builder
+	markdownBuilder.WriteString("# Rules\n\n")
+	markdownBuilder.WriteString("This document lists every rule that can be reported in [FINDINGS.md](FINDINGS.md).\n\n")
+	if severityOverrides != nil && len(severityOverrides.Rules) > 0 {
+		markdownBuilder.WriteString("Severities overridden in `severities.yaml` are followed by the default severity of the rule.\n\n")
+	}
+	markdownBuilder.WriteString("| Rule | Severity | Name |\n")
+	markdownBuilder.WriteString("|------|----------|------|\n")
+	for _, rule := range ruleCatalog {
+		markdownBuilder.WriteString(fmt.Sprintf("| [%s](#%s) | %s | %s |\n", rule.ID, rule.ID, describeRuleSeverity(rule), rule.Name))
+	}
+	markdownBuilder.WriteString("\n")
+
+	for _, rule := range ruleCatalog {
+		markdownBuilder.WriteString(fmt.Sprintf("## %s\n\n", rule.ID))
+		markdownBuilder.WriteString(fmt.Sprintf("**%s** (%s)\n\n", rule.Name, describeRuleSeverity(rule)))
+		markdownBuilder.WriteString(fmt.Sprintf("%s\n\n", rule.Description))
+		markdownBuilder.WriteString(fmt.Sprintf("**Remediation**: %s\n\n", rule.Remediation))
+	}
+
+	markdownBuilder.WriteString("\n*This file is automatically generated after each data collection run.*\n")
+
+	if err := os.WriteFile(filepath.Join(dbPath, "RULES.md"), []byte(markdownBuilder.String()), 0644); err != nil {
+		return fmt.Errorf("error writing RULES.md: %v", err)
+	}
+
+	logInfof("Generated RULES.md with %d rules", len(ruleCatalog))
+	return nil
+}
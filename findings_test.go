@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewFinding(t *testing.T) {
+	t.Parallel()
+
+	finding := newFinding("compromised-action", "repo-a", ".github/workflows/build.yml", 12, "Known-compromised action")
+	if finding.Severity != SeverityCritical || finding.Remediation == "" || len(finding.Fingerprint) != 16 {
+		t.Fatalf("unexpected finding: %+v", finding)
+	}
+
+	moved := newFinding("compromised-action", "repo-a", ".github/workflows/build.yml", 40, "Known-compromised action")
+	if moved.Fingerprint != finding.Fingerprint {
+		t.Fatalf("expected fingerprint to ignore line changes, got %q and %q", finding.Fingerprint, moved.Fingerprint)
+	}
+
+	other := newFinding("compromised-action", "repo-b", ".github/workflows/build.yml", 12, "Known-compromised action")
+	if other.Fingerprint == finding.Fingerprint {
+		t.Fatalf("expected different repositories to have different fingerprints")
+	}
+}
+
+func TestRuleCatalogIsComplete(t *testing.T) {
+	t.Parallel()
+
+	seen := make(map[string]bool)
+	for _, rule := range ruleCatalog {
+		if rule.ID == "" || rule.Severity == "" || rule.Name == "" || rule.Description == "" || rule.Remediation == "" {
+			t.Fatalf("rule has missing fields: %+v", rule)
+		}
+		if seen[rule.ID] {
+			t.Fatalf("duplicate rule ID %q", rule.ID)
+		}
+		seen[rule.ID] = true
+	}
+	for _, sp := range secretPatterns {
+		if !seen[sp.Rule] {
+			t.Fatalf("secret pattern rule %q is missing from the catalog", sp.Rule)
+		}
+	}
+}
+
+func TestGenerateRulesMarkdown(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	if err := generateRulesMarkdown(dbPath); err != nil {
+		t.Fatalf("generateRulesMarkdown returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dbPath, "RULES.md"))
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+	if !strings.Contains(string(data), "## hardcoded-secret") || !strings.Contains(string(data), "**Remediation**:") {
+		t.Fatalf("unexpected RULES.md content:\n%s", data)
+	}
+}
@@ -0,0 +1,516 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Action Version Freeze
+// ------------------------
+
+// freezeBranch is the branch used for pull requests that move workflows onto the frozen versions.
+const freezeBranch = "dotgithubindexer/freeze-action-versions"
+
+// usesLineRe matches the action and ref of a uses: line, and any comment after the ref.
+var usesLineRe = regexp.MustCompile(`^(\s*(?:-\s+)?uses:\s*["']?)([^@\s"']+)@([^\s"'#]+)(["']?)(\s+#.*)?$`)
+
+// FrozenAction lists the versions of an action that workflows may use.
+type FrozenAction struct {
+	Action   string   `yaml:"action"`
+	Versions []string `yaml:"versions"` // Allowed refs or commit SHAs; the first is the one remediation moves workflows onto
+}
+
+// ActionFreeze is the contents of freeze.yaml, the set of action versions that platform owners approve for CI.
+type ActionFreeze struct {
+	Strict  bool           `yaml:"strict"` // Actions that are not listed are nonconformant too
+	Actions []FrozenAction `yaml:"actions"`
+}
+
+// actionFreeze holds the freeze in effect for this run; nil when freeze.yaml does not exist.
+var actionFreeze *ActionFreeze
+
+// loadFreeze reads the optional freeze.yaml in the database directory.
+func loadFreeze(dbPath string) error {
+	freeze, err := readFreeze(dbPath)
+	if err != nil {
+		return err
+	}
+	actionFreeze = freeze
+	if freeze != nil {
+		logInfof("Loaded %d frozen actions from 'freeze.yaml'", len(freeze.Actions))
+	}
+	return nil
+}
+
+// readFreeze parses freeze.yaml, returning nil when it does not exist.
+func readFreeze(dbPath string) (*ActionFreeze, error) {
+	data, err := os.ReadFile(filepath.Join(dbPath, "freeze.yaml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var freeze ActionFreeze
+	if err := yaml.Unmarshal(data, &freeze); err != nil {
+		return nil, fmt.Errorf("failed to parse freeze.yaml: %v", err)
+	}
+	if err := freeze.validate(); err != nil {
+		return nil, fmt.Errorf("invalid freeze.yaml %v", err)
+	}
+	return &freeze, nil
+}
+
+// validate checks that every entry names an action and at least one version.
+func (f *ActionFreeze) validate() error {
+	for i, entry := range f.Actions {
+		if entry.Action == "" || len(entry.Versions) == 0 {
+			return fmt.Errorf("entry %d: 'action' and at least one of 'versions' are required", i+1)
+		}
+	}
+	return nil
+}
+
+// lookup returns the freeze entry of an action, or nil if it is not listed.
+func (f *ActionFreeze) lookup(action string) *FrozenAction {
+	for i := range f.Actions {
+		if strings.EqualFold(f.Actions[i].Action, action) {
+			return &f.Actions[i]
+		}
+	}
+	return nil
+}
+
+// check reports whether a use of an action conforms to the freeze, along with the entry of the action.
+// Local actions and Docker images are not covered, and unlisted actions only fail a strict freeze.
+func (f *ActionFreeze) check(action, version string) (*FrozenAction, bool) {
+	if _, _, ok := actionRepository(action); !ok {
+		return nil, true
+	}
+	entry := f.lookup(action)
+	if entry == nil {
+		return nil, !f.Strict
+	}
+	ref, _, _ := strings.Cut(version, " ")
+	for _, allowed := range entry.Versions {
+		if strings.EqualFold(ref, allowed) {
+			return entry, true
+		}
+	}
+	return entry, false
+}
+
+// target returns the version remediation moves workflows onto.
+func (e *FrozenAction) target() string {
+	return e.Versions[0]
+}
+
+// scanForUnfrozenVersions reports the action uses of a workflow that do not conform to the freeze.
+func scanForUnfrozenVersions(content, repoName, filePath string) []Finding {
+	if actionFreeze == nil {
+		return nil
+	}
+	var findings []Finding
+	for _, use := range extractActionUses(content, repoName, filePath) {
+		entry, ok := actionFreeze.check(use.Action, use.Version)
+		if ok {
+			continue
+		}
+		line := findUsesLine(content, use.Action)
+		if entry == nil {
+			findings = append(findings, newFinding("unfrozen-action-version", repoName, filePath, line,
+				fmt.Sprintf("%s is not listed in the freeze", use.Action)))
+			continue
+		}
+		finding := newFinding("unfrozen-action-version", repoName, filePath, line,
+			fmt.Sprintf("%s@%s is not a frozen version; allowed: %s", use.Action, use.Version, strings.Join(entry.Versions, ", ")))
+		finding.Suggestion = fmt.Sprintf("Use %s@%s", use.Action, entry.target())
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// generateFreezeMarkdown writes FREEZE.md, the conformance of every direct action use to the freeze.
+// Nothing is written without freeze.yaml.
+func generateFreezeMarkdown(dbPath, org string, usesIndex *ActionUsesIndex) error {
+	if actionFreeze == nil || usesIndex == nil {
+		return nil
+	}
+
+	type nonconformingUse struct {
+		Action, Version string
+		Ref             WorkflowReference
+		Target          string
+	}
+	conforming := make(map[string]int)
+	nonconforming := make(map[string]int)
+	var uses []nonconformingUse
+	for actionName, versions := range usesIndex.Actions {
+		for version, refs := range versions {
+			entry, ok := actionFreeze.check(actionName, version)
+			for _, ref := range refs {
+				if len(ref.Via) > 0 {
+					continue // Used by a composite action rather than a workflow of the organization
+				}
+				key := strings.ToLower(actionName)
+				if entry != nil {
+					key = strings.ToLower(entry.Action)
+				}
+				if ok {
+					conforming[key]++
+					continue
+				}
+				nonconforming[key]++
+				use := nonconformingUse{Action: actionName, Version: version, Ref: ref}
+				if entry != nil {
+					use.Target = entry.target()
+				}
+				uses = append(uses, use)
+			}
+		}
+	}
+	sort.Slice(uses, func(i, j int) bool {
+		a, b := uses[i], uses[j]
+		if a.Ref.RepoName != b.Ref.RepoName {
+			return a.Ref.RepoName < b.Ref.RepoName
+		}
+		if a.Ref.FilePath != b.Ref.FilePath {
+			return a.Ref.FilePath < b.Ref.FilePath
+		}
+		return a.Action < b.Action
+	})
+
+	var markdownBuilder strings.Builder
+	markdownBuilder.WriteString("# Action Version Freeze\n\n")
+	markdownBuilder.WriteString(fmt.Sprintf("This document compares the actions used by the workflows of %s against the versions allowed in `freeze.yaml`. ", org))
+	if actionFreeze.Strict {
+		markdownBuilder.WriteString("The freeze is strict, so actions that are not listed are nonconformant too.\n\n")
+	} else {
+		markdownBuilder.WriteString("Actions that are not listed are not checked.\n\n")
+	}
+	markdownBuilder.WriteString("| Action | Allowed Versions | Conforming Uses | Nonconforming Uses |\n")
+	markdownBuilder.WriteString("|--------|------------------|-----------------|--------------------|\n")
+	for _, entry := range actionFreeze.Actions {
+		key := strings.ToLower(entry.Action)
+		markdownBuilder.WriteString(fmt.Sprintf("| `%s` | `%s` | %d | %d |\n", entry.Action, strings.Join(entry.Versions, "`, `"), conforming[key], nonconforming[key]))
+	}
+	if len(actionFreeze.Actions) == 0 {
+		markdownBuilder.WriteString("| *No frozen actions* | - | - | - |\n")
+	}
+
+	markdownBuilder.WriteString("\n## Nonconforming Uses\n\n")
+	markdownBuilder.WriteString("| Repository | Workflow | Action | Version | Move To |\n")
+	markdownBuilder.WriteString("|------------|----------|--------|---------|---------|\n")
+	for _, use := range uses {
+		target := "*Not listed*"
+		if use.Target != "" {
+			target = "`" + use.Target + "`"
+		}
+		markdownBuilder.WriteString(fmt.Sprintf("| [%s](%s/%s/%s) | [%s](%s/%s/%s/blob/main/%s) | `%s` | `%s` | %s |\n",
+			use.Ref.RepoName, githubWebURL, org, use.Ref.RepoName, use.Ref.FilePath, githubWebURL, org, use.Ref.RepoName, use.Ref.FilePath,
+			use.Action, use.Version, target))
+	}
+	if len(uses) == 0 {
+		markdownBuilder.WriteString("| *All uses conform* | - | - | - | - |\n")
+	}
+
+	markdownBuilder.WriteString("\n*This file is automatically generated after each data collection run.*\n")
+
+	if err := os.WriteFile(filepath.Join(dbPath, "FREEZE.md"), []byte(markdownBuilder.String()), 0644); err != nil {
+		return fmt.Errorf("error writing FREEZE.md: %v", err)
+	}
+
+	logInfof("Generated FREEZE.md with %d nonconforming action uses", len(uses))
+	return nil
+}
+
+// freezeWorkflow moves every nonconforming use of a listed action onto its frozen version and returns the
+// updated content along with a description of each change. A comment after a replaced commit SHA usually
+// names its version, so it is removed rather than left describing the old version.
+func freezeWorkflow(freeze *ActionFreeze, content string) (string, []string) {
+	lines := strings.Split(content, "\n")
+	var applied []string
+	for i, line := range lines {
+		body := strings.TrimSuffix(line, "\r")
+		match := usesLineRe.FindStringSubmatch(body)
+		if match == nil {
+			continue
+		}
+		prefix, action, ref, quote, comment := match[1], match[2], match[3], match[4], match[5]
+		entry, ok := freeze.check(action, ref)
+		if ok || entry == nil {
+			continue
+		}
+		if isPinnedVersion(ref) {
+			comment = ""
+		}
+		lines[i] = prefix + action + "@" + entry.target() + quote + comment + line[len(body):]
+		applied = append(applied, fmt.Sprintf("Line %d: moved %s from %s to %s", i+1, action, ref, entry.target()))
+	}
+	return strings.Join(lines, "\n"), applied
+}
+
+// ------------------------
+// Section: Freeze Command
+// ------------------------
+
+// FrozenFile lists the changes that move one workflow file onto the frozen versions.
+type FrozenFile struct {
+	FilePath string   `json:"file_path"`
+	Changes  []string `json:"changes"`
+}
+
+// FreezeRemediation is the outcome of the freeze command for a repository.
+type FreezeRemediation struct {
+	Repository     string       `json:"repository"`
+	Files          []FrozenFile `json:"files"`
+	PullRequestURL string       `json:"pull_request_url,omitempty"` // Set when a pull request was opened
+	pullRequest    int          // Number of the pull request, recorded in remediation.yaml
+}
+
+// runFreezeCommand moves the workflows of one repository, or of every indexed repository with
+// nonconforming uses, onto the versions in freeze.yaml, optionally opening a pull request for each.
+// Across indexed repositories, pull requests are opened in waves planned from rollout.yaml and tracked
+// in remediation.yaml. It returns the process exit code.
+func runFreezeCommand(args []string) int {
+	fs := flag.NewFlagSet("freeze", flag.ContinueOnError)
+	freezeOrg := fs.String("org", "", "GitHub Organization name (required)")
+	freezeToken := fs.String("token", "", "GitHub API token (required); defaults to the DOTGITHUBINDEXER_TOKEN or GITHUB_TOKEN environment variable")
+	baseURL := fs.String("base-url", "", "GitHub Enterprise Server API URL, e.g. https://github.example.com/api/v3; defaults to github.com")
+	uploadURL := fs.String("upload-url", "", "GitHub Enterprise Server upload URL; defaults to -base-url")
+	freezeDB := fs.String("db", "./db", "Path to the database repository holding freeze.yaml, or a git URL to clone, update, and push")
+	freezeRepo := fs.String("repo", "", "Repository name; defaults to every indexed repository with nonconforming uses")
+	openPR := fs.Bool("open-pr", false, "Open a pull request in each repository moving its workflows onto the frozen versions")
+	waveSize := fs.Int("wave-size", -1, "Repositories to open pull requests in per run, in the order of the tiers in rollout.yaml; 0 is all of them, and -1 uses wave_size from rollout.yaml")
+	review := fs.Bool("review", false, "Push the updated remediation.yaml to a new branch and open a pull request instead of pushing it directly; requires a git URL -db")
+	format := fs.String("format", formatText, "Output format: text or json")
+	logFlags := addLogFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if err := applyEnvironmentDefaults(fs); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := logFlags.apply(); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+
+	if *freezeOrg == "" || *freezeToken == "" {
+		fmt.Println("Usage: dotgithubindexer freeze -org <organization> -token <token> [-db <path>] [-repo <repository>] [-open-pr] [-wave-size <n>] [-review] [-format text|json]")
+		fs.PrintDefaults()
+		return 1
+	}
+	if err := checkFormat(*format, formatText, formatJSON); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := setGitHubServer(*baseURL, *uploadURL); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := checkReviewMode(*review, *freezeDB); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if *format == formatJSON {
+		useJSONOutput()
+	}
+
+	checkout, err := openDB(*freezeDB, *freezeToken)
+	if err != nil {
+		logErrorf("Failed to open database: %v", err)
+		return 1
+	}
+	defer checkout.Close()
+
+	freeze, err := readFreeze(checkout.Dir)
+	if err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if freeze == nil {
+		logInfof("No freeze.yaml found in '%s'", *freezeDB)
+		return 1
+	}
+
+	repoNames := []string{*freezeRepo}
+	var tracking *RemediationTracking
+	wave := 0
+	if *freezeRepo == "" {
+		repoNames, err = nonconformingRepositories(checkout.Dir, freeze)
+		if err != nil {
+			logErrorf("Failed to read the indexed workflows: %v", err)
+			return 1
+		}
+		config, err := readRolloutConfig(checkout.Dir)
+		if err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+		tracking, err = loadRemediationTracking(checkout.Dir)
+		if err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+		if *waveSize < 0 {
+			*waveSize = config.WaveSize
+		}
+		nonconforming := len(repoNames)
+		repoNames = planRolloutWave(repoNames, freezeBranch, config, tracking, *waveSize)
+		wave = tracking.nextWave()
+		logInfof("Wave %d: %d of %d repositories with nonconforming uses", wave, len(repoNames), nonconforming)
+	}
+
+	client := getGitHubClient(*freezeToken)
+	var results []FreezeRemediation
+	failed := false
+	now := time.Now()
+	for _, repoName := range repoNames {
+		result, err := freezeRepository(client, freeze, *freezeOrg, repoName, *openPR)
+		if err != nil {
+			logErrorf("Freeze failed for repository '%s': %v", repoName, err)
+			failed = true
+			continue
+		}
+		results = append(results, *result)
+		if tracking != nil && result.pullRequest != 0 {
+			tracking.PullRequests = append(tracking.PullRequests, RemediationPullRequest{
+				Repository: repoName,
+				Branch:     freezeBranch,
+				Number:     result.pullRequest,
+				URL:        result.PullRequestURL,
+				Wave:       wave,
+				State:      remediationOpen,
+				Opened:     formatReportDate(now),
+			})
+		}
+	}
+
+	// Record the opened pull requests, so that later waves skip their repositories and index runs follow them up
+	if tracking != nil && *openPR {
+		if err := writeYAMLFile(checkout.Dir, remediationFile, tracking); err != nil {
+			logErrorf("Failed to write %s: %v", remediationFile, err)
+			return 1
+		}
+		if err := publishDB(checkout, fmt.Sprintf("Open freeze remediation wave %d for %s (%s)", wave, *freezeOrg, formatReportDate(now)), *review, *freezeToken); err != nil {
+			logErrorf("Failed to publish database: %v", err)
+			return 1
+		}
+	}
+
+	content := formatFreezeText(results, *openPR)
+	if *format == formatJSON {
+		content, err = formatFreezeJSON(results)
+		if err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+	}
+	fmt.Fprint(resultWriter, content)
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// nonconformingRepositories returns the indexed repositories with at least one use that remediation can
+// move onto a frozen version.
+func nonconformingRepositories(dbPath string, freeze *ActionFreeze) ([]string, error) {
+	seen := make(map[string]bool)
+	var repoNames []string
+	err := walkIndexedWorkflows(dbPath, func(fileName, repoName, content string) {
+		if seen[repoName] {
+			return
+		}
+		if _, applied := freezeWorkflow(freeze, content); len(applied) > 0 {
+			seen[repoName] = true
+			repoNames = append(repoNames, repoName)
+		}
+	})
+	sort.Strings(repoNames)
+	return repoNames, err
+}
+
+// freezeRepository fetches the workflows of a repository, moves their nonconforming uses onto the frozen
+// versions, and opens a pull request with the changes when openPR is set.
+func freezeRepository(client *github.Client, freeze *ActionFreeze, owner, repoName string, openPR bool) (*FreezeRemediation, error) {
+	repo, _, err := client.Repositories.Get(context.Background(), owner, repoName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch repository: %v", err)
+	}
+
+	workflows, err := fetchWorkflowFiles(client, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &FreezeRemediation{Repository: repoName, Files: []FrozenFile{}}
+	var updates []workflowUpdate
+	for _, wf := range workflows {
+		updated, applied := freezeWorkflow(freeze, wf.Content)
+		if len(applied) == 0 {
+			continue
+		}
+		result.Files = append(result.Files, FrozenFile{FilePath: wf.FilePath, Changes: applied})
+		updates = append(updates, workflowUpdate{File: wf, Content: updated, Applied: applied})
+	}
+
+	if len(updates) == 0 || !openPR {
+		return result, nil
+	}
+	pr, err := openWorkflowPullRequest(client, repo, freezeBranch, "Move GitHub Actions onto frozen versions",
+		"This pull request moves actions onto the versions approved in the organization's action version freeze.", "Freeze action versions in", updates)
+	if err != nil {
+		return nil, err
+	}
+	result.PullRequestURL = pr.GetHTMLURL()
+	result.pullRequest = pr.GetNumber()
+	return result, nil
+}
+
+// formatFreezeText renders the changes for each repository and what happened to them.
+func formatFreezeText(results []FreezeRemediation, openPR bool) string {
+	var builder strings.Builder
+	for _, result := range results {
+		builder.WriteString(fmt.Sprintf("\n%s\n", result.Repository))
+		for _, file := range result.Files {
+			builder.WriteString(fmt.Sprintf("  %s\n", file.FilePath))
+			for _, change := range file.Changes {
+				builder.WriteString(fmt.Sprintf("    %s\n", change))
+			}
+		}
+		switch {
+		case len(result.Files) == 0:
+			builder.WriteString("  All uses conform to the freeze.\n")
+		case result.PullRequestURL != "":
+			builder.WriteString(fmt.Sprintf("  Opened pull request %s\n", result.PullRequestURL))
+		}
+	}
+	if len(results) == 0 {
+		builder.WriteString("No repositories need changes to conform to the freeze.\n")
+	} else if !openPR {
+		builder.WriteString("\nRun with -open-pr to open a pull request in each repository.\n")
+	}
+	return builder.String()
+}
+
+// formatFreezeJSON renders the result of the freeze command as JSON.
+func formatFreezeJSON(results []FreezeRemediation) (string, error) {
+	return formatJSONDocument(struct {
+		Repositories []FreezeRemediation `json:"repositories"`
+	}{Repositories: emptyIfNil(results)})
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testFreeze = `actions:
+  - action: actions/checkout
+    versions:
+      - 11bd71901bbe5b1630ceea73d27597364c9af683
+      - v4.2.2
+  - action: actions/setup-go
+    versions: [v5]
+`
+
+func TestReadFreeze(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	if freeze, err := readFreeze(dbPath); err != nil || freeze != nil {
+		t.Fatalf("expected no freeze without freeze.yaml, got %+v, %v", freeze, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dbPath, "freeze.yaml"), []byte("actions:\n  - action: actions/checkout\n"), 0644); err != nil {
+		t.Fatalf("failed to write freeze.yaml: %v", err)
+	}
+	if _, err := readFreeze(dbPath); err == nil {
+		t.Fatalf("expected an error for an entry without versions")
+	}
+}
+
+func TestActionFreezeCheck(t *testing.T) {
+	t.Parallel()
+
+	freeze := &ActionFreeze{Actions: []FrozenAction{{Action: "actions/checkout", Versions: []string{"11bd71901bbe5b1630ceea73d27597364c9af683", "v4.2.2"}}}}
+	tests := []struct {
+		action, version string
+		strict, want    bool
+	}{
+		{"actions/checkout", "v4.2.2", false, true},
+		{"Actions/Checkout", "11bd71901bbe5b1630ceea73d27597364c9af683 # v4.2.2", false, true},
+		{"actions/checkout", "v4", false, false},
+		{"actions/cache", "v4", false, true},
+		{"actions/cache", "v4", true, false},
+		{"./.github/actions/local", "", true, true},
+	}
+	for _, tt := range tests {
+		freeze.Strict = tt.strict
+		if _, got := freeze.check(tt.action, tt.version); got != tt.want {
+			t.Fatalf("check(%q, %q) with strict=%v = %v, want %v", tt.action, tt.version, tt.strict, got, tt.want)
+		}
+	}
+}
+
+func TestFreezeWorkflow(t *testing.T) {
+	t.Parallel()
+
+	freeze := &ActionFreeze{Actions: []FrozenAction{
+		{Action: "actions/checkout", Versions: []string{"11bd71901bbe5b1630ceea73d27597364c9af683", "v4.2.2"}},
+		{Action: "actions/setup-go", Versions: []string{"v5"}},
+	}}
+	content := "jobs:\n  build:\n    steps:\n" +
+		"      - uses: actions/checkout@v3 # keep this note\n" +
+		"      - uses: 'actions/setup-go@0c52d547c9bc32b1aa3301fd7a9cb496313a4491' # v4\r\n" +
+		"      - uses: actions/checkout@v4.2.2\n" +
+		"      - uses: actions/cache@v3\n"
+
+	updated, applied := freezeWorkflow(freeze, content)
+	want := "jobs:\n  build:\n    steps:\n" +
+		"      - uses: actions/checkout@11bd71901bbe5b1630ceea73d27597364c9af683 # keep this note\n" +
+		"      - uses: 'actions/setup-go@v5'\r\n" +
+		"      - uses: actions/checkout@v4.2.2\n" +
+		"      - uses: actions/cache@v3\n"
+	if updated != want {
+		t.Fatalf("unexpected content:\n%q", updated)
+	}
+	if len(applied) != 2 || applied[0] != "Line 4: moved actions/checkout from v3 to 11bd71901bbe5b1630ceea73d27597364c9af683" {
+		t.Fatalf("unexpected changes: %v", applied)
+	}
+}
+
+func TestGenerateFreezeMarkdown(t *testing.T) {
+	dbPath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dbPath, "freeze.yaml"), []byte(testFreeze), 0644); err != nil {
+		t.Fatalf("failed to write freeze.yaml: %v", err)
+	}
+	if err := loadFreeze(dbPath); err != nil {
+		t.Fatalf("loadFreeze returned error: %v", err)
+	}
+	defer func() { actionFreeze = nil }()
+
+	content := "jobs:\n  build:\n    steps:\n      - uses: actions/checkout@v3\n      - uses: actions/setup-go@v5\n"
+	findings := scanForUnfrozenVersions(content, "repo-a", ".github/workflows/ci.yml")
+	if len(findings) != 1 || findings[0].Line != 4 || findings[0].Suggestion != "Use actions/checkout@11bd71901bbe5b1630ceea73d27597364c9af683" {
+		t.Fatalf("unexpected findings: %+v", findings)
+	}
+
+	usesIndex := &ActionUsesIndex{Actions: map[string]map[string][]WorkflowReference{
+		"actions/checkout": {
+			"v3":     {{RepoName: "repo-a", FilePath: ".github/workflows/ci.yml"}},
+			"v4.2.2": {{RepoName: "repo-b", FilePath: ".github/workflows/ci.yml"}},
+		},
+		"actions/setup-go": {"v4": {{RepoName: "repo-c", FilePath: ".github/workflows/ci.yml", Via: []string{"example-org/setup@v1"}}}},
+	}}
+	if err := generateFreezeMarkdown(dbPath, "example-org", usesIndex); err != nil {
+		t.Fatalf("generateFreezeMarkdown returned error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dbPath, "FREEZE.md"))
+	if err != nil {
+		t.Fatalf("failed to read FREEZE.md: %v", err)
+	}
+	report := string(data)
+	if !strings.Contains(report, "| `actions/checkout` | `11bd71901bbe5b1630ceea73d27597364c9af683`, `v4.2.2` | 1 | 1 |") ||
+		!strings.Contains(report, "| `actions/setup-go` | `v5` | 0 | 0 |") ||
+		!strings.Contains(report, "| `actions/checkout` | `v3` | `11bd71901bbe5b1630ceea73d27597364c9af683` |") {
+		t.Fatalf("unexpected FREEZE.md content:\n%s", report)
+	}
+
+	if err := updateActionIndex(dbPath, "ci.yml", "repo-a", "ci.yml", "hash-one", ""); err != nil {
+		t.Fatalf("updateActionIndex returned error: %v", err)
+	}
+	if err := storeActionVersion(dbPath, "ci.yml", "hash-one", content); err != nil {
+		t.Fatalf("storeActionVersion returned error: %v", err)
+	}
+	repoNames, err := nonconformingRepositories(dbPath, actionFreeze)
+	if err != nil || len(repoNames) != 1 || repoNames[0] != "repo-a" {
+		t.Fatalf("unexpected nonconforming repositories: %v, %v", repoNames, err)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ------------------------
+// Section: Policy Gate
+// ------------------------
+
+// Conditions accepted by -fail-on.
+const (
+	FailOnUnpinned   = "unpinned"    // A uses: reference is not pinned to a full commit SHA or image digest
+	FailOnThirdParty = "third-party" // An action hosted outside the organization is used
+	FailOnViolations = "violations"  // A finding is open; violations:<severity> counts only findings of that severity or higher
+)
+
+// exitPolicyFailure is the exit code of a run that completed but failed a -fail-on condition, so CI can
+// tell a policy failure from an error.
+const exitPolicyFailure = 2
+
+// parseFailOn parses the comma-separated conditions of -fail-on.
+func parseFailOn(value string) ([]string, error) {
+	var conditions []string
+	for _, condition := range strings.Split(value, ",") {
+		condition = strings.TrimSpace(condition)
+		switch condition {
+		case "":
+		case FailOnUnpinned, FailOnThirdParty, FailOnViolations:
+			conditions = append(conditions, condition)
+		default:
+			if severity, ok := strings.CutPrefix(condition, FailOnViolations+":"); ok {
+				if _, known := severityRank[severity]; !known {
+					return nil, fmt.Errorf("unknown severity '%s' in -fail-on condition '%s'", severity, condition)
+				}
+				conditions = append(conditions, condition)
+				continue
+			}
+			return nil, fmt.Errorf("unknown -fail-on condition '%s'; expected %s, %s, %s, or %s:<severity>", condition, FailOnUnpinned, FailOnThirdParty, FailOnViolations, FailOnViolations)
+		}
+	}
+	return conditions, nil
+}
+
+// checkPolicyGate evaluates the -fail-on conditions against the database of a completed run and returns
+// a description of each condition that holds.
+func checkPolicyGate(dbPath string, conditions []string) ([]string, error) {
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+
+	history, err := loadMetricsHistory(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot MetricsSnapshot
+	if n := len(history.Snapshots); n > 0 {
+		snapshot = history.Snapshots[n-1]
+	}
+
+	var failures []string
+	for _, condition := range conditions {
+		switch condition {
+		case FailOnUnpinned:
+			report, err := buildUnpinnedReport(dbPath)
+			if err != nil {
+				return nil, err
+			}
+			if len(report.Unpinned) > 0 {
+				failures = append(failures, fmt.Sprintf("%d of %d references are not pinned to a commit SHA; see reports/unpinned.yaml", len(report.Unpinned), report.TotalUses))
+			}
+		case FailOnThirdParty:
+			if len(snapshot.ThirdPartyActions) > 0 {
+				failures = append(failures, fmt.Sprintf("%d third-party actions are used: %s", len(snapshot.ThirdPartyActions), strings.Join(snapshot.ThirdPartyActions, ", ")))
+			}
+		case FailOnViolations:
+			if len(snapshot.Findings) > 0 {
+				failures = append(failures, fmt.Sprintf("%d findings are open; see FINDINGS.md", len(snapshot.Findings)))
+			}
+		default:
+			severity := strings.TrimPrefix(condition, FailOnViolations+":")
+			if count := countFindingsAtLeast(snapshot, severity); count > 0 {
+				failures = append(failures, fmt.Sprintf("%d findings of severity %s or higher are open; see FINDINGS.md", count, severity))
+			}
+		}
+	}
+	return failures, nil
+}
+
+// countFindingsAtLeast counts the open findings of a snapshot whose severity is at least the threshold.
+// Snapshots recorded before severities were counted have only fingerprints, so all their findings count.
+func countFindingsAtLeast(snapshot MetricsSnapshot, threshold string) int {
+	if snapshot.Severities == nil {
+		return len(snapshot.Findings)
+	}
+	count := 0
+	for severity, n := range snapshot.Severities {
+		if severityRank[severity] >= severityRank[threshold] {
+			count += n
+		}
+	}
+	return count
+}
+
+// enforcePolicyGate prints the -fail-on conditions that hold after a run and returns the process exit code.
+func enforcePolicyGate(dbPath string, conditions []string) int {
+	failures, err := checkPolicyGate(dbPath, conditions)
+	if err != nil {
+		logErrorf("Failed to check -fail-on conditions: %v", err)
+		return 1
+	}
+	if len(failures) == 0 {
+		return 0
+	}
+	for _, failure := range failures {
+		logErrorf("Policy check failed: %s", failure)
+	}
+	return exitPolicyFailure
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseFailOn(t *testing.T) {
+	t.Parallel()
+
+	conditions, err := parseFailOn("unpinned, violations,")
+	if err != nil {
+		t.Fatalf("parseFailOn returned error: %v", err)
+	}
+	if len(conditions) != 2 || conditions[0] != FailOnUnpinned || conditions[1] != FailOnViolations {
+		t.Fatalf("unexpected conditions: %v", conditions)
+	}
+	if _, err := parseFailOn("unsigned"); err == nil {
+		t.Fatalf("expected an error for an unknown condition")
+	}
+	if conditions, err := parseFailOn("violations:high"); err != nil || len(conditions) != 1 || conditions[0] != "violations:high" {
+		t.Fatalf("unexpected conditions %v, %v", conditions, err)
+	}
+	if _, err := parseFailOn("violations:urgent"); err == nil {
+		t.Fatalf("expected an error for an unknown severity")
+	}
+}
+
+func TestCheckPolicyGate(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	content := "jobs:\n  build:\n    steps:\n      - uses: actions/checkout@v4\n"
+	if err := updateActionIndex(dbPath, "ci.yml", "repo-a", "ci.yml", "hash-one", ""); err != nil {
+		t.Fatalf("updateActionIndex returned error: %v", err)
+	}
+	if err := storeActionVersion(dbPath, "ci.yml", "hash-one", content); err != nil {
+		t.Fatalf("storeActionVersion returned error: %v", err)
+	}
+	metrics := "snapshots:\n  - date: \"2026-01-01\"\n    third_party_actions: [actions/checkout]\n"
+	if err := os.WriteFile(filepath.Join(dbPath, "metrics.yaml"), []byte(metrics), 0644); err != nil {
+		t.Fatalf("failed to write metrics.yaml: %v", err)
+	}
+
+	if code := enforcePolicyGate(dbPath, nil); code != 0 {
+		t.Fatalf("expected exit code 0 without conditions, got %d", code)
+	}
+
+	failures, err := checkPolicyGate(dbPath, []string{FailOnUnpinned, FailOnThirdParty, FailOnViolations})
+	if err != nil {
+		t.Fatalf("checkPolicyGate returned error: %v", err)
+	}
+	if len(failures) != 2 || !strings.HasPrefix(failures[0], "1 of 1 references") || failures[1] != "1 third-party actions are used: actions/checkout" {
+		t.Fatalf("unexpected failures: %v", failures)
+	}
+	if code := enforcePolicyGate(dbPath, []string{FailOnViolations}); code != 0 {
+		t.Fatalf("expected exit code 0 without open findings, got %d", code)
+	}
+	if code := enforcePolicyGate(dbPath, []string{FailOnUnpinned}); code != exitPolicyFailure {
+		t.Fatalf("expected exit code %d, got %d", exitPolicyFailure, code)
+	}
+}
+
+func TestCountFindingsAtLeast(t *testing.T) {
+	t.Parallel()
+
+	snapshot := MetricsSnapshot{Findings: []string{"f1", "f2", "f3"}, Severities: map[string]int{SeverityCritical: 1, SeverityMedium: 2}}
+	if count := countFindingsAtLeast(snapshot, SeverityHigh); count != 1 {
+		t.Fatalf("expected 1 finding of high or higher, got %d", count)
+	}
+	if count := countFindingsAtLeast(snapshot, SeverityLow); count != 3 {
+		t.Fatalf("expected 3 findings of low or higher, got %d", count)
+	}
+
+	// A snapshot recorded before severities were counted fails on any open finding
+	if count := countFindingsAtLeast(MetricsSnapshot{Findings: []string{"f1"}}, SeverityCritical); count != 1 {
+		t.Fatalf("expected the finding without a severity to count, got %d", count)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+)
+
+// ------------------------
+// Section: Garbage Collection Command
+// ------------------------
+
+// runGCCommand removes unused file versions from an existing database without scanning the organization.
+func runGCCommand(args []string) int {
+	fs := flag.NewFlagSet("gc", flag.ContinueOnError)
+	gcDBPath := fs.String("db", "./db", "Path to the database repository, or a git URL to clone, update, and push")
+	gcToken := fs.String("token", "", "GitHub API token used to clone and push an HTTPS database URL")
+	baseURL := fs.String("base-url", "", "GitHub Enterprise Server API URL, e.g. https://github.example.com/api/v3; defaults to github.com")
+	uploadURL := fs.String("upload-url", "", "GitHub Enterprise Server upload URL; defaults to -base-url")
+	review := fs.Bool("review", false, "Push database changes to a new branch and open a pull request instead of pushing them directly; requires a git URL -db")
+	logFlags := addLogFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if err := applyEnvironmentDefaults(fs); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := logFlags.apply(); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if fs.NArg() != 0 {
+		fmt.Println("Usage: dotgithubindexer gc [-db <path or git URL>] [-token <token>] [-review]")
+		fs.PrintDefaults()
+		return 1
+	}
+	if err := setGitHubServer(*baseURL, *uploadURL); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := checkReviewMode(*review, *gcDBPath); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+
+	checkout, err := openDB(*gcDBPath, *gcToken)
+	if err != nil {
+		logErrorf("Failed to open database: %v", err)
+		return 1
+	}
+	defer checkout.Close()
+
+	dotfilesConfig, err := loadDotfilesConfig(checkout.Dir)
+	if err != nil {
+		logErrorf("Failed to load dotfiles config: %v", err)
+		return 1
+	}
+	// Garbage collection changes the indexes, so the recorded state follows it unless it had already diverged
+	divergences, err := checkDBState(checkout.Dir)
+	if err != nil {
+		logErrorf("Failed to check the database against its recorded state: %v", err)
+		return 1
+	}
+	startTime := time.Now()
+	collectGarbage(checkout.Dir, dotfilesConfig != nil && len(dotfilesConfig.Dotfiles) > 0)
+	if len(divergences) > 0 {
+		logWarnf("The database changed outside dotgithubindexer since the last run for %d repositories; run 'index -repair' to re-fetch them", len(divergedRepositories(divergences)))
+	} else if state, _ := loadDBState(checkout.Dir); state != nil {
+		if err := recordDBState(checkout.Dir, state.Slim, startTime); err != nil {
+			logErrorf("Error recording the database state: %v", err)
+		}
+	}
+
+	if err := publishDB(checkout, fmt.Sprintf("Collect garbage (%s)", formatReportDate(startTime)), *review, *gcToken); err != nil {
+		logErrorf("Failed to publish database: %v", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: GitHub Script Inventory
+// ------------------------
+
+// githubScriptAction is the action whose steps run inline JavaScript from their 'script' input.
+const githubScriptAction = "actions/github-script"
+
+// defaultGitHubScriptMaxLines is the size above which a script is reported when budgets.yaml sets no
+// max_github_script_lines.
+const defaultGitHubScriptMaxLines = 50
+
+// githubScriptDir is the database folder holding the extracted scripts.
+const githubScriptDir = "github-script"
+
+// GitHubScript is the inline script of an actions/github-script step.
+type GitHubScript struct {
+	Job    string
+	Step   int // 1-based index of the step in its job
+	Ref    string
+	Script string
+	Line   int // Line of the first line of the script
+}
+
+// Lines returns the number of lines of the script, ignoring a trailing newline.
+func (s GitHubScript) Lines() int {
+	return strings.Count(strings.TrimRight(s.Script, "\n"), "\n") + 1
+}
+
+// PrivilegedAPI is a group of privileged calls a script can make.
+type PrivilegedAPI struct {
+	Name string
+	re   *regexp.Regexp
+}
+
+// privilegedAPIs lists the calls reported by github-script-privileged-api. Octokit methods are matched with
+// and without the 'rest' namespace, which versions before v5 of the action did not use.
+var privilegedAPIs = []PrivilegedAPI{
+	{"secrets", regexp.MustCompile(`\$\{\{\s*secrets\.`)},
+	{"Actions secrets", regexp.MustCompile(`github\.(?:rest\.)?actions\.(?:createOrUpdate|delete)\w*Secret\b`)},
+	{"git data writes", regexp.MustCompile(`github\.(?:rest\.)?git\.(?:create|update|delete)\w*`)},
+	{"repository writes", regexp.MustCompile(`github\.(?:rest\.)?repos\.(?:create|update|delete|merge|add|remove|replace|set|transfer)\w*`)},
+	{"pull request merges", regexp.MustCompile(`github\.(?:rest\.)?pulls\.merge\b`)},
+	{"organization writes", regexp.MustCompile(`github\.(?:rest\.)?(?:orgs|teams)\.(?:create|update|delete|add|remove|set|convert)\w*`)},
+	{"raw write requests", regexp.MustCompile(`github\.request\(\s*['"\x60](?:POST|PUT|PATCH|DELETE)\s`)},
+	{"GraphQL mutations", regexp.MustCompile(`\bmutation\s*(?:\w+\s*)?(?:\([^)]*\)\s*)?\{`)},
+}
+
+// extractGitHubScripts returns the inline scripts of the actions/github-script steps of a workflow.
+func extractGitHubScripts(content string) ([]GitHubScript, error) {
+	workflow, err := parseWorkflowDocument(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var scripts []GitHubScript
+	jobsNode := mappingValue(workflow, "jobs")
+	if jobsNode == nil || jobsNode.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(jobsNode.Content); i += 2 {
+		jobID, jobNode := jobsNode.Content[i].Value, jobsNode.Content[i+1]
+		steps := mappingValue(jobNode, "steps")
+		if steps == nil || steps.Kind != yaml.SequenceNode {
+			continue
+		}
+		for j, step := range steps.Content {
+			uses := mappingValue(step, "uses")
+			if uses == nil || uses.Kind != yaml.ScalarNode {
+				continue
+			}
+			action, ref := parseUsesString(uses.Value, "")
+			if action != githubScriptAction {
+				continue
+			}
+			script := mappingValue(mappingValue(step, "with"), "script")
+			if script == nil || script.Kind != yaml.ScalarNode || strings.TrimSpace(script.Value) == "" {
+				continue
+			}
+			// A script passed in whole as an expression cannot be known statically
+			if value := strings.TrimSpace(script.Value); strings.HasPrefix(value, "${{") && strings.HasSuffix(value, "}}") && strings.Count(value, "${{") == 1 {
+				continue
+			}
+			// The script of a block scalar starts on the line after its indicator
+			line := script.Line
+			if script.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+				line++
+			}
+			scripts = append(scripts, GitHubScript{Job: jobID, Step: j + 1, Ref: ref, Script: script.Value, Line: line})
+		}
+	}
+	return scripts, nil
+}
+
+// PrivilegedAPIUse is the first call of a script to a privileged API.
+type PrivilegedAPIUse struct {
+	API  string
+	Call string
+	Line int // Line of the script, starting at 1
+}
+
+// privilegedAPIUses returns the privileged APIs a script calls, in the order of privilegedAPIs.
+func privilegedAPIUses(script string) []PrivilegedAPIUse {
+	lines := strings.Split(script, "\n")
+	var uses []PrivilegedAPIUse
+	for _, api := range privilegedAPIs {
+		for i, line := range lines {
+			if match := api.re.FindString(line); match != "" {
+				uses = append(uses, PrivilegedAPIUse{API: api.Name, Call: strings.TrimSpace(match), Line: i + 1})
+				break
+			}
+		}
+	}
+	return uses
+}
+
+// githubScriptMaxLines returns the size above which scripts are reported.
+func githubScriptMaxLines(budgets *WorkflowBudgets) int {
+	if budgets != nil && budgets.MaxGitHubScriptLines > 0 {
+		return budgets.MaxGitHubScriptLines
+	}
+	return defaultGitHubScriptMaxLines
+}
+
+// scanForGitHubScripts checks the inline scripts of a workflow against the size limit and for privileged calls.
+func scanForGitHubScripts(content, repoName, filePath string) []Finding {
+	return checkGitHubScripts(githubScriptMaxLines(workflowBudgets), content, repoName, filePath)
+}
+
+// checkGitHubScripts reports scripts longer than maxLines and the privileged APIs each script calls.
+func checkGitHubScripts(maxLines int, content, repoName, filePath string) []Finding {
+	scripts, err := extractGitHubScripts(content)
+	if err != nil {
+		return nil
+	}
+
+	var findings []Finding
+	for _, script := range scripts {
+		if lines := script.Lines(); lines > maxLines {
+			findings = append(findings, newFinding("github-script-size", repoName, filePath, script.Line,
+				fmt.Sprintf("github-script in step %d of job '%s' is %d lines long, over the limit of %d", script.Step, script.Job, lines, maxLines)))
+		}
+		for _, use := range privilegedAPIUses(script.Script) {
+			findings = append(findings, newFinding("github-script-privileged-api", repoName, filePath, script.Line+use.Line-1,
+				fmt.Sprintf("github-script in step %d of job '%s' uses %s (%s)", script.Step, script.Job, use.API, use.Call)))
+		}
+	}
+	return findings
+}
+
+// GitHubScriptEntry is an inline script listed in github-script/index.yaml.
+type GitHubScriptEntry struct {
+	Repository     string   `yaml:"repository"`
+	Workflow       string   `yaml:"workflow"`
+	Job            string   `yaml:"job"`
+	Step           int      `yaml:"step"`
+	Line           int      `yaml:"line"`
+	Ref            string   `yaml:"ref,omitempty"`
+	Lines          int      `yaml:"lines"`
+	Bytes          int      `yaml:"bytes"`
+	PrivilegedAPIs []string `yaml:"privileged_apis,omitempty"`
+	File           string   `yaml:"file"`
+}
+
+// GitHubScriptIndex is the contents of github-script/index.yaml.
+type GitHubScriptIndex struct {
+	Scripts []GitHubScriptEntry `yaml:"scripts"`
+}
+
+// buildGitHubScriptIndex lists the inline scripts of every stored workflow, with the script contents by file name.
+func buildGitHubScriptIndex(dbPath string) (*GitHubScriptIndex, map[string]string, error) {
+	index := &GitHubScriptIndex{}
+	contents := make(map[string]string)
+	err := walkIndexedWorkflows(dbPath, func(fileName, repoName, content string) {
+		scripts, err := extractGitHubScripts(content)
+		if err != nil {
+			return
+		}
+		for _, script := range scripts {
+			file := computeHash([]byte(script.Script)) + ".js"
+			contents[file] = script.Script
+			var apis []string
+			for _, use := range privilegedAPIUses(script.Script) {
+				apis = append(apis, use.API)
+			}
+			index.Scripts = append(index.Scripts, GitHubScriptEntry{
+				Repository:     repoName,
+				Workflow:       ".github/workflows/" + fileName,
+				Job:            script.Job,
+				Step:           script.Step,
+				Line:           script.Line,
+				Ref:            script.Ref,
+				Lines:          script.Lines(),
+				Bytes:          len(script.Script),
+				PrivilegedAPIs: apis,
+				File:           file,
+			})
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sort.SliceStable(index.Scripts, func(i, j int) bool {
+		a, b := index.Scripts[i], index.Scripts[j]
+		if a.Repository != b.Repository {
+			return a.Repository < b.Repository
+		}
+		if a.Workflow != b.Workflow {
+			return a.Workflow < b.Workflow
+		}
+		return a.Line < b.Line
+	})
+	return index, contents, nil
+}
+
+// generateGitHubScriptInventory extracts the inline scripts of stored workflows into the github-script folder,
+// named by the hash of their contents so they can be searched, and writes GITHUB_SCRIPT.md listing them.
+func generateGitHubScriptInventory(dbPath, org string) error {
+	index, contents, err := buildGitHubScriptIndex(dbPath)
+	if err != nil {
+		return err
+	}
+
+	// Rewrite the folder so scripts that are no longer used do not linger
+	scriptPath := filepath.Join(dbPath, githubScriptDir)
+	if err := os.RemoveAll(scriptPath); err != nil {
+		return fmt.Errorf("failed to clear github-script directory: %v", err)
+	}
+	if err := os.MkdirAll(scriptPath, 0755); err != nil {
+		return fmt.Errorf("failed to create github-script directory: %v", err)
+	}
+	for file, script := range contents {
+		if err := os.WriteFile(filepath.Join(scriptPath, file), []byte(script), 0644); err != nil {
+			return fmt.Errorf("error writing script '%s': %v", file, err)
+		}
+	}
+	if err := writeYAMLFile(scriptPath, "index.yaml", index); err != nil {
+		return fmt.Errorf("error writing github-script/index.yaml: %v", err)
+	}
+
+	maxLines := githubScriptMaxLines(workflowBudgets)
+	var markdownBuilder strings.Builder
+	markdownBuilder.WriteString("# GitHub Script Steps\n\n")
+	markdownBuilder.WriteString(fmt.Sprintf("This document lists the `actions/github-script` steps of %s and the inline JavaScript they run. Scripts over %d lines are marked in bold. Each script is stored in the github-script folder under the hash of its contents.\n\n", org, maxLines))
+	markdownBuilder.WriteString("| Repository | Workflow | Job | Step | Ref | Lines | Privileged APIs | Script |\n")
+	markdownBuilder.WriteString("|------------|----------|-----|------|-----|-------|-----------------|--------|\n")
+	for _, entry := range index.Scripts {
+		lines := fmt.Sprintf("%d", entry.Lines)
+		if entry.Lines > maxLines {
+			lines = "**" + lines + "**"
+		}
+		apis := "-"
+		if len(entry.PrivilegedAPIs) > 0 {
+			apis = strings.Join(entry.PrivilegedAPIs, ", ")
+		}
+		markdownBuilder.WriteString(fmt.Sprintf("| [%s](%s/%s/%s) | %s | %s | %d | %s | %s | %s | [%s](%s/%s) |\n",
+			entry.Repository, githubWebURL, org, entry.Repository, entry.Workflow, entry.Job, entry.Step, entry.Ref, lines, apis,
+			strings.TrimSuffix(entry.File, ".js")[:12], githubScriptDir, entry.File))
+	}
+	if len(index.Scripts) == 0 {
+		markdownBuilder.WriteString("| *No github-script steps found* | - | - | - | - | - | - | - |\n")
+	}
+
+	markdownBuilder.WriteString("\n*This file is automatically generated after each data collection run.*\n")
+
+	if err := os.WriteFile(filepath.Join(dbPath, "GITHUB_SCRIPT.md"), []byte(markdownBuilder.String()), 0644); err != nil {
+		return fmt.Errorf("error writing GITHUB_SCRIPT.md: %v", err)
+	}
+
+	logInfof("Generated GITHUB_SCRIPT.md with %d scripts", len(index.Scripts))
+	return nil
+}
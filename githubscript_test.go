@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const githubScriptWorkflow = `name: Release
+on: push
+jobs:
+  label:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/github-script@v7
+        with:
+          script: |
+            const labels = ['release']
+            await github.rest.issues.addLabels({ ...context.issue, labels })
+  publish:
+    runs-on: ubuntu-latest
+    steps:
+      - name: Publish
+        uses: actions/github-script@60a0d83039c74a4aee543508d2ffcb1c3799cdea # v7.0.1
+        with:
+          github-token: ${{ secrets.RELEASE_TOKEN }}
+          script: |
+            const token = '${{ secrets.NPM_TOKEN }}'
+            await github.rest.git.createRef({ owner: context.repo.owner, repo: context.repo.repo, ref: 'refs/tags/v1', sha: context.sha })
+            await github.graphql('mutation { addStar(input: {starrableId: "x"}) { clientMutationId } }')
+            await github.request('PUT /repos/{owner}/{repo}/topics', { names: ['released'] })
+      - uses: actions/github-script@v7
+        with:
+          script: console.log(context.payload)
+      - uses: actions/github-script@v7
+        with:
+          script: ${{ inputs.script }}
+          result-encoding: string
+`
+
+func TestExtractGitHubScripts(t *testing.T) {
+	t.Parallel()
+
+	scripts, err := extractGitHubScripts(githubScriptWorkflow)
+	if err != nil {
+		t.Fatalf("extractGitHubScripts returned error: %v", err)
+	}
+	var got []string
+	for _, script := range scripts {
+		got = append(got, fmt.Sprintf("%s/%d %s line %d, %d lines", script.Job, script.Step, script.Ref, script.Line, script.Lines()))
+	}
+	want := []string{
+		"label/2 v7 line 11, 2 lines",
+		"publish/1 60a0d83039c74a4aee543508d2ffcb1c3799cdea line 21, 4 lines",
+		"publish/2 v7 line 27, 1 lines",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected scripts:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestCheckGitHubScripts(t *testing.T) {
+	t.Parallel()
+
+	findings := checkGitHubScripts(3, githubScriptWorkflow, "repo-a", ".github/workflows/release.yml")
+	var got []string
+	for _, finding := range findings {
+		got = append(got, fmt.Sprintf("%s %d %s", finding.Rule, finding.Line, finding.Message))
+	}
+	want := []string{
+		"github-script-size 21 github-script in step 1 of job 'publish' is 4 lines long, over the limit of 3",
+		"github-script-privileged-api 21 github-script in step 1 of job 'publish' uses secrets (${{ secrets.)",
+		"github-script-privileged-api 22 github-script in step 1 of job 'publish' uses git data writes (github.rest.git.createRef)",
+		"github-script-privileged-api 24 github-script in step 1 of job 'publish' uses raw write requests (github.request('PUT)",
+		"github-script-privileged-api 23 github-script in step 1 of job 'publish' uses GraphQL mutations (mutation {)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected findings:\n%s", strings.Join(got, "\n"))
+	}
+
+	if findings := checkGitHubScripts(defaultGitHubScriptMaxLines, "on: push\njobs:\n  a:\n    steps:\n      - uses: actions/github-script@v7\n        with:\n          script: github.rest.repos.get(context.repo)\n", "repo-a", "a.yml"); len(findings) != 0 {
+		t.Fatalf("expected no findings for a read-only script, got %+v", findings)
+	}
+}
+
+func TestGitHubScriptMaxLines(t *testing.T) {
+	t.Parallel()
+
+	if got := githubScriptMaxLines(nil); got != defaultGitHubScriptMaxLines {
+		t.Fatalf("expected the default limit without budgets, got %d", got)
+	}
+	if got := githubScriptMaxLines(&WorkflowBudgets{MaxJobsPerWorkflow: 5}); got != defaultGitHubScriptMaxLines {
+		t.Fatalf("expected the default limit when budgets.yaml sets none, got %d", got)
+	}
+	if got := githubScriptMaxLines(&WorkflowBudgets{MaxGitHubScriptLines: 20}); got != 20 {
+		t.Fatalf("expected the configured limit, got %d", got)
+	}
+}
+
+func TestGenerateGitHubScriptInventory(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	contents := map[string]string{
+		"repo-a": githubScriptWorkflow,
+		"repo-b": "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/github-script@v6\n        with:\n          script: core.info('inventory test')\n",
+	}
+	for repoName, content := range contents {
+		hash := computeHash([]byte(content))
+		if err := updateActionIndex(dbPath, "release.yml", repoName, "release.yml", hash, ""); err != nil {
+			t.Fatalf("updateActionIndex returned error: %v", err)
+		}
+		if err := storeActionVersion(dbPath, "release.yml", hash, content); err != nil {
+			t.Fatalf("storeActionVersion returned error: %v", err)
+		}
+	}
+
+	// A script no longer used is removed
+	stale := filepath.Join(dbPath, githubScriptDir, "stale.js")
+	if err := os.MkdirAll(filepath.Dir(stale), 0755); err != nil {
+		t.Fatalf("failed to create github-script folder: %v", err)
+	}
+	if err := os.WriteFile(stale, []byte("old"), 0644); err != nil {
+		t.Fatalf("failed to write stale script: %v", err)
+	}
+
+	if err := generateGitHubScriptInventory(dbPath, "example-org"); err != nil {
+		t.Fatalf("generateGitHubScriptInventory returned error: %v", err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("expected the stale script to be removed, got %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dbPath, githubScriptDir, "index.yaml"))
+	if err != nil {
+		t.Fatalf("failed to read index.yaml: %v", err)
+	}
+	var index GitHubScriptIndex
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		t.Fatalf("failed to parse index.yaml: %v", err)
+	}
+	if len(index.Scripts) != 4 {
+		t.Fatalf("expected 4 scripts, got %+v", index.Scripts)
+	}
+	publish := index.Scripts[1]
+	if publish.Repository != "repo-a" || publish.Job != "publish" || publish.Step != 1 || publish.Lines != 4 ||
+		strings.Join(publish.PrivilegedAPIs, ",") != "secrets,git data writes,raw write requests,GraphQL mutations" {
+		t.Fatalf("unexpected entry: %+v", publish)
+	}
+	if last := index.Scripts[3]; last.Repository != "repo-b" || last.Ref != "v6" || last.Workflow != ".github/workflows/release.yml" {
+		t.Fatalf("unexpected entry: %+v", last)
+	}
+
+	script, err := os.ReadFile(filepath.Join(dbPath, githubScriptDir, index.Scripts[3].File))
+	if err != nil {
+		t.Fatalf("failed to read extracted script: %v", err)
+	}
+	if string(script) != "core.info('inventory test')" {
+		t.Fatalf("unexpected script contents: %q", script)
+	}
+
+	markdown, err := os.ReadFile(filepath.Join(dbPath, "GITHUB_SCRIPT.md"))
+	if err != nil {
+		t.Fatalf("failed to read GITHUB_SCRIPT.md: %v", err)
+	}
+	if !strings.Contains(string(markdown), "| [repo-b](https://github.com/example-org/repo-b) | .github/workflows/release.yml | build | 1 | v6 | 1 | - |") {
+		t.Fatalf("unexpected GITHUB_SCRIPT.md:\n%s", markdown)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+
+	"github.com/google/go-github/v50/github"
+)
+
+// ------------------------
+// Section: Git Tree Workflow Fetch
+// ------------------------
+
+const (
+	fetchModeGraphQL = "graphql" // List the workflow directories and their content in one GraphQL request
+	fetchModeTree    = "tree"    // List the repository with the Git trees API and reuse stored content
+)
+
+// workflowFetchMode is how workflow directories are listed, set with -fetch.
+var workflowFetchMode = fetchModeGraphQL
+
+// storedWorkflowBlobs maps the blob SHAs recorded in the database's workflow indexes to the stored
+// version with their content. It is loaded before a scan with -fetch tree.
+var storedWorkflowBlobs map[string]string
+
+// loadStoredWorkflowBlobs maps the blob SHA of each stored workflow version to its version file, including
+// the blobs of files that were decoded from another encoding.
+func loadStoredWorkflowBlobs(dbPath string) (map[string]string, error) {
+	workflowsPath := filepath.Join(dbPath, "workflows")
+	names, err := readSubdirectories(workflowsPath)
+	if err != nil {
+		return nil, err
+	}
+	blobs := make(map[string]string)
+	for _, name := range names {
+		index, err := readActionIndex(filepath.Join(workflowsPath, name, "index.yaml"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read index of workflow '%s': %v", name, err)
+		}
+		for hash, blobSHA := range index.Blobs {
+			blobs[blobSHA] = versionPath(filepath.Join(workflowsPath, name), hash)
+		}
+		for blobSHA, hash := range index.EncodedBlobs {
+			blobs[blobSHA] = versionPath(filepath.Join(workflowsPath, name), hash)
+		}
+	}
+	return blobs, nil
+}
+
+// isEmptyRepositoryError reports whether the GitHub API error is the conflict returned for a repository
+// without commits.
+func isEmptyRepositoryError(err error) bool {
+	errResp, ok := err.(*github.ErrorResponse)
+	return ok && errResp.Response != nil && errResp.Response.StatusCode == http.StatusConflict
+}
+
+// listWorkflowTree lists the files of several directories of a repository with a single recursive Git
+// trees API call, returning the entries of each existing directory and the stored content of the files
+// whose blob SHA is already in the database. A nil listing means the tree was truncated, so the
+// directories must be listed some other way.
+func listWorkflowTree(client *github.Client, repo *github.Repository, ref string, dirs []string) (map[string][]*github.RepositoryContent, map[string]string, error) {
+	tree, _, err := client.Git.GetTree(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), ref, true)
+	if err != nil {
+		if isNotFoundError(err) || isEmptyRepositoryError(err) {
+			return map[string][]*github.RepositoryContent{}, map[string]string{}, nil
+		}
+		return nil, nil, err
+	}
+	if tree.GetTruncated() {
+		logDebugf("Tree of repository '%s' is truncated; listing workflow directories through GraphQL", repo.GetName())
+		return nil, nil, nil
+	}
+
+	listing := make(map[string][]*github.RepositoryContent)
+	for _, entry := range tree.Entries {
+		if entry.GetType() == "tree" && slices.Contains(dirs, entry.GetPath()) {
+			if _, ok := listing[entry.GetPath()]; !ok {
+				listing[entry.GetPath()] = nil
+			}
+			continue
+		}
+		dir := path.Dir(entry.GetPath())
+		if entry.GetType() != "blob" || !slices.Contains(dirs, dir) {
+			continue
+		}
+		// A symbolic link is a blob holding the target path, so it is resolved when the files are fetched
+		fileType := "file"
+		if entry.GetMode() == symlinkFileMode {
+			fileType = "symlink"
+		}
+		listing[dir] = append(listing[dir], &github.RepositoryContent{
+			Type: github.String(fileType),
+			Name: github.String(path.Base(entry.GetPath())),
+			Path: github.String(entry.GetPath()),
+			SHA:  github.String(entry.GetSHA()),
+		})
+	}
+
+	// Stored content is reused only when it still matches the blob SHA it was recorded under, once
+	// encoded again when the file was decoded from another encoding
+	contents := make(map[string]string)
+	for _, files := range listing {
+		for _, file := range files {
+			storedPath, ok := storedWorkflowBlobs[file.GetSHA()]
+			if !ok {
+				continue
+			}
+			data, err := os.ReadFile(storedPath)
+			if err != nil {
+				continue
+			}
+			original, ok := matchStoredBlob(data, file.GetSHA())
+			if !ok {
+				continue
+			}
+			contents[file.GetSHA()] = string(original)
+		}
+	}
+	if len(contents) > 0 {
+		logDebugf("Reusing %d stored workflow files for repository '%s'", len(contents), repo.GetName())
+	}
+	return listing, contents, nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-github/v50/github"
+)
+
+// TestFetchWorkflowFilesFromTree mutates the fetch mode and the stored blobs, so it does not run in parallel.
+func TestFetchWorkflowFilesFromTree(t *testing.T) {
+	previousMode, previousBlobs := workflowFetchMode, storedWorkflowBlobs
+	defer func() { workflowFetchMode, storedWorkflowBlobs = previousMode, previousBlobs }()
+
+	staleText := "on: schedule\njobs: {}\n"
+	files := map[string]string{
+		".github/workflows/build.yml": "on: push\njobs: {}\n",
+		".github/workflows/lint.yml":  "on: pull_request\njobs: {}\n",
+		".github/workflows/stale.yml": string(encodeUTF16(staleText, binary.LittleEndian, true)),
+	}
+
+	// build.yml is already stored in the database, so only lint.yml is downloaded
+	dbPath := t.TempDir()
+	storagePath := filepath.Join(dbPath, "workflows", "build.yml")
+	if err := os.MkdirAll(versionsDir(storagePath), os.ModePerm); err != nil {
+		t.Fatalf("MkdirAll returned error: %v", err)
+	}
+	content := files[".github/workflows/build.yml"]
+	hash := computeHash([]byte(content))
+	if err := os.WriteFile(versionPath(storagePath, hash), []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	index := &ActionIndex{Repositories: map[string]string{"repo-a": hash}}
+	recordBlobSHA(index, hash, computeBlobSHA([]byte(content)))
+	if err := writeActionIndex(filepath.Join(storagePath, "index.yaml"), index); err != nil {
+		t.Fatalf("writeActionIndex returned error: %v", err)
+	}
+
+	// stale.yml is stored decoded from UTF-16 under the blob SHA of its original bytes
+	staleStoragePath := filepath.Join(dbPath, "workflows", "stale.yml")
+	if err := os.MkdirAll(versionsDir(staleStoragePath), os.ModePerm); err != nil {
+		t.Fatalf("MkdirAll returned error: %v", err)
+	}
+	staleHash := computeHash([]byte(staleText))
+	if err := os.WriteFile(versionPath(staleStoragePath, staleHash), []byte(staleText), 0644); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	staleIndex := &ActionIndex{Repositories: map[string]string{"repo-a": staleHash}}
+	recordEncodedBlob(staleIndex, computeBlobSHA([]byte(files[".github/workflows/stale.yml"])), staleHash)
+	if err := writeActionIndex(filepath.Join(staleStoragePath, "index.yaml"), staleIndex); err != nil {
+		t.Fatalf("writeActionIndex returned error: %v", err)
+	}
+
+	var blobRequests atomic.Int32
+	var downloaded string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/example-org/repo-a/git/trees/main" {
+			if r.URL.Query().Get("recursive") == "" {
+				t.Errorf("expected a recursive tree request, got %s", r.URL)
+			}
+			entries := []map[string]any{
+				{"path": ".github", "type": "tree", "sha": "1111111111111111111111111111111111111111"},
+				{"path": ".github/workflows", "type": "tree", "sha": "2222222222222222222222222222222222222222"},
+				{"path": ".github/workflows/scripts/setup.sh", "type": "blob", "sha": "3333333333333333333333333333333333333333"},
+				{"path": "README.md", "type": "blob", "sha": "4444444444444444444444444444444444444444"},
+			}
+			for filePath, content := range files {
+				entries = append(entries, map[string]any{"path": filePath, "type": "blob", "sha": computeBlobSHA([]byte(content))})
+			}
+			json.NewEncoder(w).Encode(map[string]any{"sha": "5555555555555555555555555555555555555555", "tree": entries, "truncated": false})
+			return
+		}
+		if sha, ok := strings.CutPrefix(r.URL.Path, "/repos/example-org/repo-a/git/blobs/"); ok {
+			blobRequests.Add(1)
+			for filePath, content := range files {
+				if computeBlobSHA([]byte(content)) == sha {
+					downloaded = filePath
+					fmt.Fprintf(w, `{"sha":%q,"size":%d,"encoding":"base64","content":%q}`, sha, len(content), base64.StdEncoding.EncodeToString([]byte(content)))
+					return
+				}
+			}
+		}
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	repo := &github.Repository{Name: github.String("repo-a"), Owner: &github.User{Login: github.String("example-org")}, DefaultBranch: github.String("main")}
+
+	blobs, err := loadStoredWorkflowBlobs(dbPath)
+	if err != nil {
+		t.Fatalf("loadStoredWorkflowBlobs returned error: %v", err)
+	}
+	workflowFetchMode, storedWorkflowBlobs = fetchModeTree, blobs
+
+	workflows, err := fetchWorkflowFiles(client, repo)
+	if err != nil {
+		t.Fatalf("fetchWorkflowFiles returned error: %v", err)
+	}
+	if len(workflows) != 3 || blobRequests.Load() != 1 || downloaded != ".github/workflows/lint.yml" {
+		t.Fatalf("expected only lint.yml to be downloaded, got %d requests for %q and %+v", blobRequests.Load(), downloaded, workflows)
+	}
+	for _, wf := range workflows {
+		decoded, encoding, _ := decodeWorkflowContent(files[wf.FilePath])
+		if wf.Content != decoded || wf.Encoding != encoding || wf.BlobSHA != computeBlobSHA([]byte(files[wf.FilePath])) {
+			t.Fatalf("unexpected workflow: %+v", wf)
+		}
+	}
+}
+
+func TestListWorkflowTreeFallsBackWhenTruncated(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"sha":"5555555555555555555555555555555555555555","tree":[],"truncated":true}`)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	repo := &github.Repository{Name: github.String("repo-a"), Owner: &github.User{Login: github.String("example-org")}}
+
+	listing, _, err := listWorkflowTree(client, repo, "main", []string{".github/workflows"})
+	if err != nil {
+		t.Fatalf("listWorkflowTree returned error: %v", err)
+	}
+	if listing != nil {
+		t.Fatalf("expected no listing for a truncated tree, got %+v", listing)
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ------------------------
+// Section: Golden Reports
+// ------------------------
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden reports in testdata/golden from the fixture corpus")
+
+const (
+	fixturesPath = "testdata/fixtures"
+	goldenPath   = "testdata/golden"
+	fixturesOrg  = "example-org"
+	// goldenDate replaces the date of the run in the generated reports, so the goldens do not change every day
+	goldenDate = "2000-01-01"
+)
+
+// contentHashRe matches the names of content-addressed files, whose content is the fixture itself.
+var contentHashRe = regexp.MustCompile(`^[0-9a-f]{64}(\.[a-z]+)?$`)
+
+// gitBlobSHA returns the SHA the Git Data API reports for a file's content.
+func gitBlobSHA(content string) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("blob %d\x00%s", len(content), content)))
+	return hex.EncodeToString(sum[:])
+}
+
+// fixtureRepository is a repository of the fixture organization with the files a scan would have fetched.
+type fixtureRepository struct {
+	Name  string
+	Files *RepositoryFiles
+}
+
+// loadFixtureRepositories reads the fixture organization, one directory per repository laid out as on GitHub,
+// in name order.
+func loadFixtureRepositories(t *testing.T) []fixtureRepository {
+	t.Helper()
+
+	orgPath := filepath.Join(fixturesPath, fixturesOrg)
+	dirs, err := os.ReadDir(orgPath)
+	if err != nil {
+		t.Fatalf("failed to read fixtures: %v", err)
+	}
+	var repos []fixtureRepository
+	for _, dir := range dirs {
+		repoName := dir.Name()
+		files := &RepositoryFiles{DefaultBranch: "main"}
+		repoPath := filepath.Join(orgPath, repoName)
+		err := filepath.WalkDir(repoPath, func(path string, entry os.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return err
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			content := string(data)
+			rel, _ := filepath.Rel(repoPath, path)
+			filePath := filepath.ToSlash(rel)
+			hash := computeHash(data)
+			switch {
+			case strings.HasPrefix(filePath, ".github/workflows/"):
+				files.Workflows = append(files.Workflows, workflowFileFromContent(repoName, filePath, content, gitBlobSHA(content), ""))
+			case filePath == ".github/dependabot.yml":
+				files.Dependabot = &DependabotFile{RepoName: repoName, FilePath: filePath, Content: content, Hash: hash, BlobSHA: gitBlobSHA(content), Category: extractCategory(content)}
+			case strings.HasPrefix(filePath, ".github/actions/"):
+				name := strings.Split(filePath, "/")[2]
+				files.ActionDefinitions = append(files.ActionDefinitions, ActionDefinitionFile{RepoName: repoName, Name: name, FilePath: filePath, Content: content, Hash: hash, BlobSHA: gitBlobSHA(content)})
+			default:
+				t.Fatalf("unexpected fixture file %s", path)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("failed to read fixture repository %s: %v", repoName, err)
+		}
+		repos = append(repos, fixtureRepository{Name: repoName, Files: files})
+	}
+	return repos
+}
+
+// generateFixtureReports indexes the fixture organization into a new database and generates every report
+// from it as a run without network access would, returning the database path.
+func generateFixtureReports(t *testing.T) string {
+	t.Helper()
+
+	previousOrg := org
+	org = fixturesOrg
+	defer func() { org = previousOrg }()
+
+	dbPath := t.TempDir()
+	if err := initializeDB(dbPath); err != nil {
+		t.Fatalf("initializeDB returned error: %v", err)
+	}
+	usesIndex := &ActionUsesIndex{Actions: make(map[string]map[string][]WorkflowReference)}
+	var findings []Finding
+	names := make(map[string]bool)
+	for _, repo := range loadFixtureRepositories(t) {
+		names[repo.Name] = true
+		repoFindings, _, err := indexRepositoryFiles(dbPath, repo.Name, repo.Files, usesIndex)
+		if err != nil {
+			t.Fatalf("indexRepositoryFiles returned error: %v", err)
+		}
+		findings = append(findings, repoFindings...)
+	}
+
+	// Rebuild the results from the database, as regenerating the reports does, so they do not depend on the order of the scan
+	usesIndex, findings, err := collectIndexedResults(dbPath, fixturesOrg, names)
+	if err != nil {
+		t.Fatalf("collectIndexedResults returned error: %v", err)
+	}
+
+	collectGarbage(dbPath, false)
+	runReportGenerators(databaseReportGenerators(dbPath, fixturesOrg, false))
+	runReportGenerators(indexReportGenerators(dbPath, fixturesOrg, usesIndex, findings, nil, &ReleaseCache{}))
+	if err := recordMetricsSnapshot(dbPath, fixturesOrg, usesIndex, findings); err != nil {
+		t.Fatalf("recordMetricsSnapshot returned error: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dbPath, "trend"), 0755); err != nil {
+		t.Fatalf("failed to create trend folder: %v", err)
+	}
+	for format, extension := range map[string]string{formatMarkdown: "md", formatJSON: "json", formatHTML: "html"} {
+		if err := writeTrendReport(dbPath, "", format, filepath.Join(dbPath, "trend", "TREND."+extension)); err != nil {
+			t.Fatalf("writeTrendReport(%s) returned error: %v", format, err)
+		}
+	}
+	return dbPath
+}
+
+// readGeneratedReports returns the files of a generated database by relative path, skipping the stored file
+// versions and with the date of the run replaced by goldenDate.
+func readGeneratedReports(t *testing.T, dbPath string) map[string][]byte {
+	t.Helper()
+
+	today := []byte(formatReportDate(time.Now()))
+	reports := make(map[string][]byte)
+	err := filepath.WalkDir(dbPath, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || contentHashRe.MatchString(entry.Name()) {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dbPath, path)
+		reports[filepath.ToSlash(rel)] = bytes.ReplaceAll(data, today, []byte(goldenDate))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to read generated reports: %v", err)
+	}
+	return reports
+}
+
+// TestGoldenReports compares every report generated from the fixture corpus with testdata/golden.
+// Run it with -update after an intended change to analyzers or reports, and review the diff of the goldens.
+func TestGoldenReports(t *testing.T) {
+	reports := readGeneratedReports(t, generateFixtureReports(t))
+
+	if *updateGolden {
+		if err := os.RemoveAll(goldenPath); err != nil {
+			t.Fatalf("failed to remove goldens: %v", err)
+		}
+		for rel, data := range reports {
+			path := filepath.Join(goldenPath, filepath.FromSlash(rel))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatalf("failed to create golden folder: %v", err)
+			}
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatalf("failed to write golden %s: %v", rel, err)
+			}
+		}
+		return
+	}
+
+	goldens := readGeneratedReports(t, goldenPath)
+	for rel, want := range goldens {
+		got, ok := reports[rel]
+		if !ok {
+			t.Errorf("%s is no longer generated; run go test -run TestGoldenReports -update", rel)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s differs from its golden; run go test -run TestGoldenReports -update and review the diff:\n%s", rel, firstDifference(string(want), string(got)))
+		}
+	}
+	for rel := range reports {
+		if _, ok := goldens[rel]; !ok {
+			t.Errorf("%s has no golden; run go test -run TestGoldenReports -update", rel)
+		}
+	}
+}
+
+// firstDifference describes the first line at which two reports differ.
+func firstDifference(want, got string) string {
+	wantLines, gotLines := strings.Split(want, "\n"), strings.Split(got, "\n")
+	for i := 0; i < max(len(wantLines), len(gotLines)); i++ {
+		var wantLine, gotLine string
+		if i < len(wantLines) {
+			wantLine = wantLines[i]
+		}
+		if i < len(gotLines) {
+			gotLine = gotLines[i]
+		}
+		if wantLine != gotLine {
+			return fmt.Sprintf("line %d:\n  want: %s\n  got:  %s", i+1, wantLine, gotLine)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ------------------------
+// Section: Usage Graph
+// ------------------------
+
+// Node kinds of the usage graph.
+const (
+	graphRepository = "repository"
+	graphWorkflow   = "workflow"
+	graphAction     = "action"
+)
+
+// Edge kinds of the usage graph.
+const (
+	graphContains = "contains" // A repository contains a workflow file
+	graphDefines  = "defines"  // A repository defines an in-house action
+	graphUses     = "uses"     // A workflow step or composite action step uses an action
+	graphCalls    = "calls"    // A workflow job calls a reusable workflow
+)
+
+// GraphNode is a repository, workflow file, or action of the usage graph. IDs are prefixed with the kind,
+// such as action:actions/checkout, so that an action at the root of a repository and the repository
+// itself remain distinct nodes.
+type GraphNode struct {
+	ID    string `json:"id"`
+	Kind  string `json:"kind"`
+	Label string `json:"label"`
+}
+
+// GraphEdge connects two nodes of the usage graph. Version is the reference of a use or call as written.
+type GraphEdge struct {
+	Source  string `json:"source"`
+	Target  string `json:"target"`
+	Kind    string `json:"kind"`
+	Version string `json:"version,omitempty"`
+}
+
+// UsageGraph is the network of repositories, the workflow files they contain, and the actions and
+// reusable workflows those use, following in-house composite actions to the actions they use in turn.
+type UsageGraph struct {
+	Organization string      `json:"organization"`
+	Nodes        []GraphNode `json:"nodes"`
+	Edges        []GraphEdge `json:"edges"`
+}
+
+// graphBuilder collects the nodes and edges of a usage graph without duplicates.
+type graphBuilder struct {
+	nodes map[string]GraphNode
+	edges map[GraphEdge]bool
+}
+
+// node adds a node and returns its ID.
+func (b *graphBuilder) node(kind, label string) string {
+	id := kind + ":" + label
+	b.nodes[id] = GraphNode{ID: id, Kind: kind, Label: label}
+	return id
+}
+
+// edge adds an edge between two nodes.
+func (b *graphBuilder) edge(source, target, kind, version string) {
+	b.edges[GraphEdge{Source: source, Target: target, Kind: kind, Version: version}] = true
+}
+
+// graphActionName returns the full name of a used action, resolving a local action (./path) in the
+// repository that uses it.
+func graphActionName(org, repoName, action string) string {
+	if local, ok := strings.CutPrefix(action, "./"); ok {
+		return org + "/" + repoName + "/" + path.Clean(local)
+	}
+	return action
+}
+
+// buildUsageGraph builds the usage graph of the organization from the indexed workflows and action definitions.
+func buildUsageGraph(dbPath, org string) (*UsageGraph, error) {
+	b := &graphBuilder{nodes: make(map[string]GraphNode), edges: make(map[GraphEdge]bool)}
+
+	manifest, err := loadRepositoryManifest(dbPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if manifest != nil {
+		for _, repoName := range manifest.Repositories {
+			b.node(graphRepository, org+"/"+repoName)
+		}
+	}
+
+	err = walkIndexedWorkflows(dbPath, func(fileName, repoName, content string) {
+		repoID := b.node(graphRepository, org+"/"+repoName)
+		filePath := ".github/workflows/" + fileName
+		workflowID := b.node(graphWorkflow, org+"/"+repoName+"/"+filePath)
+		b.edge(repoID, workflowID, graphContains, "")
+
+		for _, use := range extractActionUses(content, repoName, filePath) {
+			b.edge(workflowID, b.node(graphAction, graphActionName(org, repoName, use.Action)), graphUses, use.Version)
+		}
+
+		jobs, err := resolveWorkflowJobs(content)
+		if err != nil {
+			return
+		}
+		for _, job := range jobs {
+			if job.ReusableWorkflow == "" {
+				continue
+			}
+			target, ref, _ := strings.Cut(job.ReusableWorkflow, "@")
+			b.edge(workflowID, b.node(graphWorkflow, graphActionName(org, repoName, target)), graphCalls, ref)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// In-house composite actions lead on to the actions their steps use
+	actionsPath := filepath.Join(dbPath, "actions")
+	names, err := readSubdirectories(actionsPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		index, err := readActionIndex(filepath.Join(actionsPath, name, "index.yaml"))
+		if err != nil {
+			logErrorf("Error reading index for action definition '%s': %v", name, err)
+			continue
+		}
+		for repoName, hash := range index.Repositories {
+			actionName := org + "/" + repoName
+			if dir := path.Dir(index.fileName(repoName, actionDefinitionPath(name))); dir != "." {
+				actionName += "/" + dir
+			}
+			actionID := b.node(graphAction, actionName)
+			b.edge(b.node(graphRepository, org+"/"+repoName), actionID, graphDefines, "")
+
+			content, err := readVersionFile(versionPath(filepath.Join(actionsPath, name), hash))
+			if err != nil {
+				logErrorf("Error reading action definition '%s' of repository '%s': %v", name, repoName, err)
+				continue
+			}
+			for _, use := range extractCompositeUses(string(content)) {
+				b.edge(actionID, b.node(graphAction, graphActionName(org, repoName, use.Action)), graphUses, use.Version)
+			}
+		}
+	}
+
+	graph := &UsageGraph{Organization: org, Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	for _, node := range b.nodes {
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool {
+		return graph.Nodes[i].ID < graph.Nodes[j].ID
+	})
+	for edge := range b.edges {
+		graph.Edges = append(graph.Edges, edge)
+	}
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		a, c := graph.Edges[i], graph.Edges[j]
+		if a.Source != c.Source {
+			return a.Source < c.Source
+		}
+		if a.Target != c.Target {
+			return a.Target < c.Target
+		}
+		if a.Kind != c.Kind {
+			return a.Kind < c.Kind
+		}
+		return a.Version < c.Version
+	})
+	return graph, nil
+}
+
+// dotQuote quotes a string as a DOT identifier.
+func dotQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// formatGraphDOT renders a usage graph in Graphviz DOT format.
+func formatGraphDOT(graph *UsageGraph) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("digraph %s {\n", dotQuote(graph.Organization)))
+	for _, node := range graph.Nodes {
+		builder.WriteString(fmt.Sprintf("  %s [label=%s, kind=%s];\n", dotQuote(node.ID), dotQuote(node.Label), dotQuote(node.Kind)))
+	}
+	for _, edge := range graph.Edges {
+		attributes := "kind=" + dotQuote(edge.Kind)
+		if edge.Version != "" {
+			attributes += ", version=" + dotQuote(edge.Version)
+		}
+		builder.WriteString(fmt.Sprintf("  %s -> %s [%s];\n", dotQuote(edge.Source), dotQuote(edge.Target), attributes))
+	}
+	builder.WriteString("}\n")
+	return builder.String()
+}
+
+// xmlEscape escapes a string for use in GraphML text and attribute values.
+func xmlEscape(value string) string {
+	var builder strings.Builder
+	_ = xml.EscapeText(&builder, []byte(value))
+	return builder.String()
+}
+
+// formatGraphGraphML renders a usage graph in GraphML format, which graph databases such as Neo4j and
+// tools such as Gephi import.
+func formatGraphGraphML(graph *UsageGraph) string {
+	var builder strings.Builder
+	builder.WriteString(xml.Header)
+	builder.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	builder.WriteString(`  <key id="kind" for="all" attr.name="kind" attr.type="string"/>` + "\n")
+	builder.WriteString(`  <key id="label" for="node" attr.name="label" attr.type="string"/>` + "\n")
+	builder.WriteString(`  <key id="version" for="edge" attr.name="version" attr.type="string"/>` + "\n")
+	builder.WriteString(fmt.Sprintf("  <graph id=\"%s\" edgedefault=\"directed\">\n", xmlEscape(graph.Organization)))
+	for _, node := range graph.Nodes {
+		builder.WriteString(fmt.Sprintf("    <node id=\"%s\"><data key=\"kind\">%s</data><data key=\"label\">%s</data></node>\n",
+			xmlEscape(node.ID), node.Kind, xmlEscape(node.Label)))
+	}
+	for i, edge := range graph.Edges {
+		builder.WriteString(fmt.Sprintf("    <edge id=\"e%d\" source=\"%s\" target=\"%s\"><data key=\"kind\">%s</data>", i, xmlEscape(edge.Source), xmlEscape(edge.Target), edge.Kind))
+		if edge.Version != "" {
+			builder.WriteString(fmt.Sprintf("<data key=\"version\">%s</data>", xmlEscape(edge.Version)))
+		}
+		builder.WriteString("</edge>\n")
+	}
+	builder.WriteString("  </graph>\n</graphml>\n")
+	return builder.String()
+}
+
+// writeUsageGraph builds the usage graph of the database and writes it to output or standard output.
+func writeUsageGraph(dbPath, format, output string) error {
+	manifest, err := loadRepositoryManifest(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to load repositories manifest: %v", err)
+	}
+	graph, err := buildUsageGraph(dbPath, manifest.Organization)
+	if err != nil {
+		return err
+	}
+
+	var content string
+	switch format {
+	case formatGraphML:
+		content = formatGraphGraphML(graph)
+	case formatDOT:
+		content = formatGraphDOT(graph)
+	default:
+		content, err = formatJSONDocument(graph)
+		if err != nil {
+			return err
+		}
+	}
+
+	if output == "" {
+		fmt.Fprint(resultWriter, content)
+		return nil
+	}
+	if err := os.WriteFile(output, []byte(content), 0644); err != nil {
+		return fmt.Errorf("error writing %s: %v", output, err)
+	}
+	logInfof("Wrote usage graph with %d nodes and %d edges to %s", len(graph.Nodes), len(graph.Edges), output)
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeGraphTestDB writes a database with two workflows of repo-a and an in-house composite action.
+func writeGraphTestDB(t *testing.T) string {
+	t.Helper()
+	dbPath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dbPath, "repositories.yaml"), []byte("organization: example-org\nrepositories:\n    - repo-a\n    - repo-b\n"), 0644); err != nil {
+		t.Fatalf("failed to write repositories.yaml: %v", err)
+	}
+
+	workflows := map[string]string{
+		"ci.yml":      "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/checkout@v4\n      - uses: ./.github/actions/setup\n",
+		"release.yml": "on: push\njobs:\n  release:\n    uses: example-org/repo-a/.github/workflows/ci.yml@main\n",
+	}
+	for fileName, content := range workflows {
+		hash := computeHash([]byte(content))
+		if err := updateActionIndex(dbPath, fileName, "repo-a", fileName, hash, ""); err != nil {
+			t.Fatalf("updateActionIndex returned error: %v", err)
+		}
+		if err := storeActionVersion(dbPath, fileName, hash, content); err != nil {
+			t.Fatalf("storeActionVersion returned error: %v", err)
+		}
+	}
+
+	content := "name: Setup\nruns:\n  using: composite\n  steps:\n    - uses: actions/setup-go@v5\n"
+	definition := ActionDefinitionFile{RepoName: "repo-a", Name: "setup", FilePath: actionDefinitionPath("setup"), Content: content, Hash: computeHash([]byte(content))}
+	if err := updateActionDefinitionIndex(dbPath, definition); err != nil {
+		t.Fatalf("updateActionDefinitionIndex returned error: %v", err)
+	}
+	if err := storeActionDefinitionVersion(dbPath, definition); err != nil {
+		t.Fatalf("storeActionDefinitionVersion returned error: %v", err)
+	}
+	return dbPath
+}
+
+func TestBuildUsageGraph(t *testing.T) {
+	t.Parallel()
+
+	graph, err := buildUsageGraph(writeGraphTestDB(t), "example-org")
+	if err != nil {
+		t.Fatalf("buildUsageGraph returned error: %v", err)
+	}
+
+	if len(graph.Nodes) != 7 || graph.Nodes[0].ID != "action:actions/checkout" || graph.Nodes[6].ID != "workflow:example-org/repo-a/.github/workflows/release.yml" {
+		t.Fatalf("unexpected nodes: %+v", graph.Nodes)
+	}
+
+	edges := make(map[string]bool)
+	for _, edge := range graph.Edges {
+		edges[edge.Source+" -"+edge.Kind+"-> "+edge.Target+" "+edge.Version] = true
+	}
+	for _, want := range []string{
+		"repository:example-org/repo-a -contains-> workflow:example-org/repo-a/.github/workflows/ci.yml ",
+		"repository:example-org/repo-a -defines-> action:example-org/repo-a/.github/actions/setup ",
+		"workflow:example-org/repo-a/.github/workflows/ci.yml -uses-> action:actions/checkout v4",
+		"workflow:example-org/repo-a/.github/workflows/ci.yml -uses-> action:example-org/repo-a/.github/actions/setup ",
+		"action:example-org/repo-a/.github/actions/setup -uses-> action:actions/setup-go v5",
+		"workflow:example-org/repo-a/.github/workflows/release.yml -calls-> workflow:example-org/repo-a/.github/workflows/ci.yml main",
+	} {
+		if !edges[want] {
+			t.Fatalf("missing edge %q in %v", want, edges)
+		}
+	}
+	if len(graph.Edges) != 7 {
+		t.Fatalf("unexpected edges: %+v", graph.Edges)
+	}
+}
+
+func TestFormatUsageGraph(t *testing.T) {
+	t.Parallel()
+
+	graph := &UsageGraph{
+		Organization: "example-org",
+		Nodes: []GraphNode{
+			{ID: "action:actions/checkout", Kind: graphAction, Label: "actions/checkout"},
+			{ID: "workflow:example-org/repo-a/.github/workflows/ci.yml", Kind: graphWorkflow, Label: "example-org/repo-a/.github/workflows/ci.yml"},
+		},
+		Edges: []GraphEdge{{Source: "workflow:example-org/repo-a/.github/workflows/ci.yml", Target: "action:actions/checkout", Kind: graphUses, Version: `abc # "v4" <pinned>`}},
+	}
+
+	var document struct {
+		Graph struct {
+			Nodes []struct {
+				ID string `xml:"id,attr"`
+			} `xml:"node"`
+			Edges []struct {
+				Source string   `xml:"source,attr"`
+				Data   []string `xml:"data"`
+			} `xml:"edge"`
+		} `xml:"graph"`
+	}
+	if err := xml.Unmarshal([]byte(formatGraphGraphML(graph)), &document); err != nil {
+		t.Fatalf("GraphML output does not parse: %v", err)
+	}
+	if len(document.Graph.Nodes) != 2 || len(document.Graph.Edges) != 1 || document.Graph.Edges[0].Data[1] != `abc # "v4" <pinned>` {
+		t.Fatalf("unexpected GraphML document: %+v", document)
+	}
+
+	dot := formatGraphDOT(graph)
+	if !strings.Contains(dot, `"workflow:example-org/repo-a/.github/workflows/ci.yml" -> "action:actions/checkout" [kind="uses", version="abc # \"v4\" <pinned>"];`) {
+		t.Fatalf("unexpected DOT output:\n%s", dot)
+	}
+
+	data, err := formatJSONDocument(graph)
+	if err != nil {
+		t.Fatalf("formatJSONDocument returned error: %v", err)
+	}
+	var decoded UsageGraph
+	if err := json.Unmarshal([]byte(data), &decoded); err != nil || len(decoded.Nodes) != 2 || decoded.Edges[0].Kind != graphUses {
+		t.Fatalf("unexpected JSON output: %v\n%s", err, data)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v50/github"
+)
+
+// ------------------------
+// Section: GraphQL Workflow Fetch
+// ------------------------
+
+// graphqlRequest is the body of a GraphQL API request.
+type graphqlRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables"`
+}
+
+// graphqlError is an error reported in the body of a GraphQL API response.
+type graphqlError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// graphqlTreeEntry is an entry of a directory listed through the GraphQL API. Object holds the content
+// of a file; Text is nil for binary files.
+type graphqlTreeEntry struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"` // blob, tree, or commit for a submodule
+	Mode   int    `json:"mode"` // Git file mode in decimal, such as 40960 for a symbolic link
+	Oid    string `json:"oid"`
+	Object *struct {
+		Text        *string `json:"text"`
+		IsTruncated bool    `json:"isTruncated"`
+	} `json:"object"`
+}
+
+// graphqlPath returns the GraphQL endpoint relative to a client's REST base URL: /graphql on github.com
+// and /api/graphql next to the /api/v3 REST API of GitHub Enterprise Server.
+func graphqlPath(client *github.Client) string {
+	if strings.HasSuffix(client.BaseURL.Path, "/api/v3/") {
+		return "../graphql"
+	}
+	return "graphql"
+}
+
+// fetchDirectoryTrees lists several directories of a repository at a ref, with the content of their
+// files, in a single GraphQL request. Directories that do not exist are missing from the result.
+func fetchDirectoryTrees(client *github.Client, owner, repoName, ref string, dirs []string) (map[string][]graphqlTreeEntry, error) {
+	var query strings.Builder
+	variables := map[string]any{"owner": owner, "name": repoName}
+	query.WriteString("query($owner: String!, $name: String!")
+	for i, dir := range dirs {
+		query.WriteString(fmt.Sprintf(", $e%d: String!", i))
+		variables[fmt.Sprintf("e%d", i)] = ref + ":" + dir
+	}
+	query.WriteString(") { repository(owner: $owner, name: $name) {")
+	for i := range dirs {
+		query.WriteString(fmt.Sprintf(" d%d: object(expression: $e%d) { ... on Tree { entries { name type mode oid object { ... on Blob { text isTruncated } } } } }", i, i))
+	}
+	query.WriteString(" } }")
+
+	req, err := client.NewRequest("POST", graphqlPath(client), &graphqlRequest{Query: query.String(), Variables: variables})
+	if err != nil {
+		return nil, err
+	}
+	var response struct {
+		Data struct {
+			Repository map[string]*struct {
+				Entries []graphqlTreeEntry `json:"entries"`
+			} `json:"repository"`
+		} `json:"data"`
+		Errors []graphqlError `json:"errors"`
+	}
+	if _, err := client.Do(context.Background(), req, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Errors) > 0 {
+		return nil, fmt.Errorf("GraphQL query for repository '%s' failed: %s", repoName, response.Errors[0].Message)
+	}
+
+	trees := make(map[string][]graphqlTreeEntry)
+	for i, dir := range dirs {
+		if tree := response.Data.Repository[fmt.Sprintf("d%d", i)]; tree != nil && tree.Entries != nil {
+			trees[dir] = tree.Entries
+		}
+	}
+	return trees, nil
+}
+
+// treeEntryContents converts the files of a directory listed through GraphQL into directory entries,
+// returning the content of each file whose text matches its blob SHA. Other files, such as binary or
+// truncated ones, are left to be downloaded through the REST API.
+func treeEntryContents(dir string, entries []graphqlTreeEntry) ([]*github.RepositoryContent, map[string]string) {
+	var files []*github.RepositoryContent
+	contents := make(map[string]string)
+	for _, entry := range entries {
+		if entry.Type != "blob" {
+			continue
+		}
+		// The text of a symbolic link is its target path, so it is resolved when the files are fetched
+		fileType := "file"
+		if fmt.Sprintf("%o", entry.Mode) == symlinkFileMode {
+			fileType = "symlink"
+		}
+		files = append(files, &github.RepositoryContent{
+			Type: github.String(fileType),
+			Name: github.String(entry.Name),
+			Path: github.String(dir + "/" + entry.Name),
+			SHA:  github.String(entry.Oid),
+		})
+		if fileType == "symlink" || entry.Object == nil || entry.Object.Text == nil || entry.Object.IsTruncated {
+			continue
+		}
+		if computeBlobSHA([]byte(*entry.Object.Text)) == entry.Oid {
+			contents[entry.Oid] = *entry.Object.Text
+		}
+	}
+	return files, contents
+}
+
+// listDirectoryTrees lists the files of several directories of a repository through GraphQL, returning
+// the entries of each existing directory and the content of the files that came with them.
+func listDirectoryTrees(client *github.Client, repo *github.Repository, ref string, dirs []string) (map[string][]*github.RepositoryContent, map[string]string, error) {
+	trees, err := fetchDirectoryTrees(client, repo.GetOwner().GetLogin(), repo.GetName(), ref, dirs)
+	if err != nil {
+		return nil, nil, err
+	}
+	listing := make(map[string][]*github.RepositoryContent)
+	contents := make(map[string]string)
+	for dir, entries := range trees {
+		files, fetched := treeEntryContents(dir, entries)
+		listing[dir] = files
+		for sha, content := range fetched {
+			contents[sha] = content
+		}
+	}
+	return listing, contents, nil
+}
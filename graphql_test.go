@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-github/v50/github"
+)
+
+// serveGraphQLTrees answers a GraphQL directory listing built by fetchDirectoryTrees from the files of a
+// repository, keyed by path. texts overrides the text returned for a file.
+func serveGraphQLTrees(t *testing.T, w http.ResponseWriter, r *http.Request, files, texts map[string]string) {
+	t.Helper()
+	var request graphqlRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		t.Errorf("failed to decode GraphQL request: %v", err)
+		return
+	}
+	repository := make(map[string]any)
+	for name, value := range request.Variables {
+		index, ok := strings.CutPrefix(name, "e")
+		if !ok {
+			continue
+		}
+		_, dir, _ := strings.Cut(value.(string), ":")
+		var entries []map[string]any
+		for filePath, content := range files {
+			if path.Dir(filePath) != dir {
+				continue
+			}
+			text := content
+			if override, ok := texts[filePath]; ok {
+				text = override
+			}
+			entries = append(entries, map[string]any{"name": path.Base(filePath), "type": "blob", "oid": computeBlobSHA([]byte(content)), "object": map[string]any{"text": text}})
+		}
+		if entries != nil {
+			repository["d"+index] = map[string]any{"entries": entries}
+		} else {
+			repository["d"+index] = nil
+		}
+	}
+	json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"repository": repository}})
+}
+
+func TestGraphQLPath(t *testing.T) {
+	t.Parallel()
+
+	client := github.NewClient(nil)
+	if got := graphqlPath(client); got != "graphql" {
+		t.Fatalf("unexpected github.com GraphQL path: %s", got)
+	}
+	req, err := client.NewRequest("POST", graphqlPath(client), nil)
+	if err != nil || req.URL.String() != "https://api.github.com/graphql" {
+		t.Fatalf("unexpected github.com GraphQL URL: %v, %v", req.URL, err)
+	}
+
+	enterprise, err := github.NewEnterpriseClient("https://github.example.com/api/v3", "https://github.example.com/api/v3", nil)
+	if err != nil {
+		t.Fatalf("NewEnterpriseClient returned error: %v", err)
+	}
+	req, err = enterprise.NewRequest("POST", graphqlPath(enterprise), nil)
+	if err != nil || req.URL.String() != "https://github.example.com/api/graphql" {
+		t.Fatalf("unexpected enterprise GraphQL URL: %v, %v", req.URL, err)
+	}
+}
+
+func TestFetchWorkflowFilesFallsBackToREST(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{
+		".github/workflows/build.yml": "on: push\n",
+		".github/workflows/lint.yml":  "on: pull_request\n",
+	}
+	var blobRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/graphql" {
+			// The text of lint.yml does not match its blob SHA, as when it is not valid UTF-8
+			serveGraphQLTrees(t, w, r, files, map[string]string{".github/workflows/lint.yml": "on: pull_request�\n"})
+			return
+		}
+		if sha, ok := strings.CutPrefix(r.URL.Path, "/repos/example-org/repo-a/git/blobs/"); ok {
+			blobRequests.Add(1)
+			content := files[".github/workflows/lint.yml"]
+			fmt.Fprintf(w, `{"sha":%q,"size":%d,"encoding":"base64","content":%q}`, sha, len(content), base64.StdEncoding.EncodeToString([]byte(content)))
+			return
+		}
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	repo := &github.Repository{Name: github.String("repo-a"), Owner: &github.User{Login: github.String("example-org")}, DefaultBranch: github.String("main")}
+
+	workflows, err := fetchWorkflowFiles(client, repo)
+	if err != nil {
+		t.Fatalf("fetchWorkflowFiles returned error: %v", err)
+	}
+	if len(workflows) != 2 || blobRequests.Load() != 1 {
+		t.Fatalf("expected one blob to be downloaded over REST, got %d requests and %+v", blobRequests.Load(), workflows)
+	}
+	for _, wf := range workflows {
+		if wf.Content != files[wf.FilePath] || wf.BlobSHA != computeBlobSHA([]byte(wf.Content)) {
+			t.Fatalf("unexpected workflow: %+v", wf)
+		}
+	}
+}
+
+func TestFetchDirectoryTreesReportsErrors(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"repository":null},"errors":[{"type":"NOT_FOUND","message":"Could not resolve to a Repository with the name 'example-org/gone'."}]}`)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	if _, err := fetchDirectoryTrees(client, "example-org", "gone", "main", []string{".github/workflows"}); err == nil || !strings.Contains(err.Error(), "Could not resolve") {
+		t.Fatalf("expected the GraphQL error to be returned, got %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ------------------------
+// Section: HTTP Cache
+// ------------------------
+
+// httpCache is an on-disk cache of GitHub API responses. Cached responses are revalidated with
+// If-None-Match and If-Modified-Since, and a 304 Not Modified, which does not count against the rate
+// limit, is answered with the cached response. Responses are cached per token, since what a token may
+// see differs.
+type httpCache struct {
+	dir         string
+	base        http.RoundTripper
+	mu          sync.Mutex
+	revalidated int
+	stored      int
+}
+
+// httpResponseCache is the HTTP cache API clients use, set with -http-cache; nil disables caching.
+var httpResponseCache *httpCache
+
+// newHTTPCache creates an HTTP cache in a directory, creating the directory if needed.
+func newHTTPCache(dir string) (*httpCache, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("failed to create HTTP cache directory: %v", err)
+	}
+	return &httpCache{dir: dir, base: http.DefaultTransport}, nil
+}
+
+// path returns the file a request's response is cached in.
+func (c *httpCache) path(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.URL.String() + "\n" + req.Header.Get("Accept") + "\n" + req.Header.Get("Authorization")))
+	key := hex.EncodeToString(sum[:])
+	return filepath.Join(c.dir, key[:2], key)
+}
+
+// RoundTrip sends a request, revalidating the cached response of a GET request instead of downloading
+// it again when the cache has one.
+func (c *httpCache) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return c.base.RoundTrip(req)
+	}
+
+	cachePath := c.path(req)
+	cached := c.load(cachePath, req)
+	outgoing := req
+	if cached != nil {
+		outgoing = req.Clone(req.Context())
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			outgoing.Header.Set("If-None-Match", etag)
+		}
+		if lastModified := cached.Header.Get("Last-Modified"); lastModified != "" {
+			outgoing.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+
+	resp, err := c.base.RoundTrip(outgoing)
+	if err != nil {
+		if cached != nil {
+			cached.Body.Close()
+		}
+		return nil, err
+	}
+	if cached != nil && resp.StatusCode == http.StatusNotModified {
+		// The 304 carries the current rate limit, which the client tracks
+		for name, values := range resp.Header {
+			if strings.HasPrefix(name, "X-Ratelimit-") {
+				cached.Header[name] = values
+			}
+		}
+		resp.Body.Close()
+		c.mu.Lock()
+		c.revalidated++
+		c.mu.Unlock()
+		return cached, nil
+	}
+	if cached != nil {
+		cached.Body.Close()
+	}
+
+	if resp.StatusCode == http.StatusOK && (resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != "") {
+		if err := c.store(cachePath, resp); err != nil {
+			logErrorf("Error caching response for %s: %v", req.URL.Path, err)
+		}
+	}
+	return resp, nil
+}
+
+// load returns the cached response for a request, or nil if there is none or it cannot be read.
+func (c *httpCache) load(cachePath string, req *http.Request) *http.Response {
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		return nil
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), req)
+	if err != nil {
+		return nil
+	}
+	return resp
+}
+
+// store writes a response to the cache, leaving its body readable. The file is replaced atomically, so
+// that concurrent requests never read a partial response.
+func (c *httpCache) store(cachePath string, resp *http.Response) error {
+	data, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(cachePath), os.ModePerm); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(cachePath), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), cachePath); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	c.mu.Lock()
+	c.stored++
+	c.mu.Unlock()
+	return nil
+}
+
+// stats returns how many responses were served from the cache after a 304 and how many were cached.
+func (c *httpCache) stats() (revalidated, stored int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.revalidated, c.stored
+}
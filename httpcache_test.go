@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestHTTPCacheRevalidates(t *testing.T) {
+	t.Parallel()
+
+	var served, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := `"v1-` + r.Header.Get("Authorization") + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			notModified.Add(1)
+			w.Header().Set("X-RateLimit-Remaining", "4999")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		served.Add(1)
+		w.Header().Set("ETag", etag)
+		w.Header().Set("X-RateLimit-Remaining", "4000")
+		fmt.Fprint(w, `{"name":"repo-a"}`)
+	}))
+	defer server.Close()
+
+	cache, err := newHTTPCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("newHTTPCache returned error: %v", err)
+	}
+	client := &http.Client{Transport: cache}
+	get := func(token string) (*http.Response, string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/repos/example-org/repo-a", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request returned error: %v", err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("reading body returned error: %v", err)
+		}
+		return resp, string(body)
+	}
+
+	get("token-a")
+	resp, body := get("token-a")
+	if resp.StatusCode != http.StatusOK || body != `{"name":"repo-a"}` {
+		t.Fatalf("expected the cached response, got %d %q", resp.StatusCode, body)
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") != "4999" {
+		t.Fatalf("expected the rate limit of the 304, got %q", resp.Header.Get("X-RateLimit-Remaining"))
+	}
+	if served.Load() != 1 || notModified.Load() != 1 {
+		t.Fatalf("expected one full response and one 304, got %d and %d", served.Load(), notModified.Load())
+	}
+
+	// Another token does not share the cached response
+	get("token-b")
+	if served.Load() != 2 {
+		t.Fatalf("expected a full response for another token, got %d", served.Load())
+	}
+	if revalidated, stored := cache.stats(); revalidated != 1 || stored != 2 {
+		t.Fatalf("unexpected cache stats: %d revalidated, %d stored", revalidated, stored)
+	}
+}
+
+func TestHTTPCacheSkipsUncacheableResponses(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") != "" {
+			t.Errorf("unexpected conditional request for %s %s", r.Method, r.URL.Path)
+		}
+		if r.URL.Path == "/missing" {
+			http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+
+	cache, err := newHTTPCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("newHTTPCache returned error: %v", err)
+	}
+	client := &http.Client{Transport: cache}
+	for i := 0; i < 2; i++ {
+		for _, req := range []struct{ method, path string }{{http.MethodPost, "/graphql"}, {http.MethodGet, "/missing"}} {
+			r, _ := http.NewRequest(req.method, server.URL+req.path, nil)
+			resp, err := client.Do(r)
+			if err != nil {
+				t.Fatalf("request returned error: %v", err)
+			}
+			resp.Body.Close()
+		}
+	}
+	if _, stored := cache.stats(); stored != 0 || requests.Load() != 4 {
+		t.Fatalf("expected nothing to be cached, got %d stored and %d requests", stored, requests.Load())
+	}
+}
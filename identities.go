@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Automation Identity Inventory
+// ------------------------
+
+// Kinds of automation identities referenced by workflows.
+const (
+	IdentityKindSecret    = "Bot token secret"
+	IdentityKindGitHubApp = "GitHub App"
+	IdentityKindBotUser   = "Bot account"
+)
+
+// AutomationIdentity is a service account, bot token, or GitHub App that a workflow depends on.
+type AutomationIdentity struct {
+	Kind string
+	Name string
+}
+
+// IdentityInventory maps automation identities to the workflow files that reference them.
+type IdentityInventory map[AutomationIdentity][]WorkflowReference
+
+var (
+	secretReferenceRe = regexp.MustCompile(`secrets\.([A-Za-z0-9_]+)`)
+	botSecretNameRe   = regexp.MustCompile(`(?i)(^|_)(BOT|PAT|MACHINE_USER|SERVICE_ACCOUNT|AUTOMATION)(_|$)|APP_(ID|PRIVATE_KEY|KEY)$`)
+	expressionNameRe  = regexp.MustCompile(`(?:vars|secrets)\.([A-Za-z0-9_]+)`)
+	botUserRe         = regexp.MustCompile(`\b([A-Za-z0-9][A-Za-z0-9-]*)\[bot\]`)
+)
+
+// githubAppTokenActions are actions that mint installation tokens for a GitHub App.
+var githubAppTokenActions = []string{
+	"actions/create-github-app-token",
+	"tibdex/github-app-token",
+	"peter-murray/workflow-application-token-action",
+}
+
+// githubAppIDInputs are the inputs used by app token actions to identify the app.
+var githubAppIDInputs = []string{"app-id", "app_id", "application_id", "client-id"}
+
+// extractAutomationIdentities returns the automation identities referenced by a workflow file.
+func extractAutomationIdentities(content string) []AutomationIdentity {
+	seen := make(map[AutomationIdentity]bool)
+	var identities []AutomationIdentity
+	add := func(identity AutomationIdentity) {
+		if !seen[identity] {
+			seen[identity] = true
+			identities = append(identities, identity)
+		}
+	}
+
+	for _, m := range secretReferenceRe.FindAllStringSubmatch(content, -1) {
+		name := m[1]
+		if name != "GITHUB_TOKEN" && botSecretNameRe.MatchString(name) {
+			add(AutomationIdentity{Kind: IdentityKindSecret, Name: name})
+		}
+	}
+
+	for _, m := range botUserRe.FindAllStringSubmatch(content, -1) {
+		add(AutomationIdentity{Kind: IdentityKindBotUser, Name: m[1] + "[bot]"})
+	}
+
+	workflow, err := parseWorkflowDocument(content)
+	if err == nil {
+		jobs := mappingValue(workflow, "jobs")
+		if jobs != nil && jobs.Kind == yaml.MappingNode {
+			for i := 1; i < len(jobs.Content); i += 2 {
+				steps := mappingValue(jobs.Content[i], "steps")
+				if steps == nil || steps.Kind != yaml.SequenceNode {
+					continue
+				}
+				for _, step := range steps.Content {
+					if name := githubAppIdentity(step); name != "" {
+						add(AutomationIdentity{Kind: IdentityKindGitHubApp, Name: name})
+					}
+				}
+			}
+		}
+	}
+
+	return identities
+}
+
+// githubAppIdentity returns the app identifier used by a step that mints a GitHub App token.
+// Expressions such as ${{ vars.RELEASE_APP_ID }} are reduced to the variable name.
+func githubAppIdentity(step *yaml.Node) string {
+	usesNode := mappingValue(step, "uses")
+	if usesNode == nil {
+		return ""
+	}
+	action, _ := parseUsesString(usesNode.Value, "")
+	isAppTokenAction := false
+	for _, candidate := range githubAppTokenActions {
+		if strings.EqualFold(action, candidate) {
+			isAppTokenAction = true
+			break
+		}
+	}
+	if !isAppTokenAction {
+		return ""
+	}
+
+	with := mappingValue(step, "with")
+	for _, input := range githubAppIDInputs {
+		value := mappingValue(with, input)
+		if value == nil || value.Value == "" {
+			continue
+		}
+		if m := expressionNameRe.FindStringSubmatch(value.Value); m != nil {
+			return m[1]
+		}
+		return strings.TrimSpace(value.Value)
+	}
+
+	return "(unspecified app via " + action + ")"
+}
+
+// buildIdentityInventory collects the automation identities referenced by every indexed workflow.
+func buildIdentityInventory(dbPath string) (IdentityInventory, error) {
+	inventory := make(IdentityInventory)
+	err := walkIndexedWorkflows(dbPath, func(fileName, repoName, content string) {
+		for _, identity := range extractAutomationIdentities(content) {
+			inventory[identity] = append(inventory[identity], WorkflowReference{
+				RepoName: repoName,
+				FilePath: ".github/workflows/" + fileName,
+			})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return inventory, nil
+}
+
+// generateIdentitiesMarkdown creates an IDENTITIES.md file in the db folder listing automation identities.
+func generateIdentitiesMarkdown(dbPath, org string) error {
+	inventory, err := buildIdentityInventory(dbPath)
+	if err != nil {
+		return err
+	}
+
+	identities := make([]AutomationIdentity, 0, len(inventory))
+	for identity := range inventory {
+		identities = append(identities, identity)
+	}
+	sort.Slice(identities, func(i, j int) bool {
+		if identities[i].Kind != identities[j].Kind {
+			return identities[i].Kind < identities[j].Kind
+		}
+		return identities[i].Name < identities[j].Name
+	})
+
+	var markdownBuilder strings.Builder
+	markdownBuilder.WriteString("# Automation Identities\n\n")
+	markdownBuilder.WriteString("This document inventories the bot tokens, GitHub Apps, and bot accounts that workflows depend on.\n\n")
+	markdownBuilder.WriteString("**Legend:**\n")
+	markdownBuilder.WriteString(fmt.Sprintf("- **%s**: A secret whose name suggests a bot or service account token (for example `*_BOT_TOKEN`)\n", IdentityKindSecret))
+	markdownBuilder.WriteString(fmt.Sprintf("- **%s**: An app whose installation token is minted with an action such as `actions/create-github-app-token`\n", IdentityKindGitHubApp))
+	markdownBuilder.WriteString(fmt.Sprintf("- **%s**: A `<name>[bot]` account referenced in the workflow\n\n", IdentityKindBotUser))
+
+	if len(identities) == 0 {
+		markdownBuilder.WriteString("*No automation identities found.*\n")
+	}
+
+	currentKind := ""
+	for _, identity := range identities {
+		if identity.Kind != currentKind {
+			currentKind = identity.Kind
+			markdownBuilder.WriteString(fmt.Sprintf("## %s\n\n", currentKind))
+		}
+
+		refs := inventory[identity]
+		sort.Slice(refs, func(i, j int) bool {
+			if refs[i].RepoName == refs[j].RepoName {
+				return refs[i].FilePath < refs[j].FilePath
+			}
+			return refs[i].RepoName < refs[j].RepoName
+		})
+		repoSet := make(map[string]bool)
+		for _, ref := range refs {
+			repoSet[ref.RepoName] = true
+		}
+
+		markdownBuilder.WriteString(fmt.Sprintf("### `%s`\n\n", identity.Name))
+		markdownBuilder.WriteString(fmt.Sprintf("**Repositories**: %d\n\n", len(repoSet)))
+		for _, ref := range refs {
+			url := fmt.Sprintf("%s/%s/%s/blob/main/%s", githubWebURL, org, ref.RepoName, ref.FilePath)
+			markdownBuilder.WriteString(fmt.Sprintf("- [%s: %s](%s)\n", ref.RepoName, ref.FilePath, url))
+		}
+		markdownBuilder.WriteString("\n")
+	}
+
+	markdownBuilder.WriteString("\n*This file is automatically generated after each data collection run.*\n")
+
+	if err := os.WriteFile(filepath.Join(dbPath, "IDENTITIES.md"), []byte(markdownBuilder.String()), 0644); err != nil {
+		return fmt.Errorf("error writing IDENTITIES.md: %v", err)
+	}
+
+	logInfof("Generated IDENTITIES.md with %d automation identities", len(identities))
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractAutomationIdentities(t *testing.T) {
+	t.Parallel()
+
+	content := `jobs:
+  release:
+    steps:
+      - uses: actions/create-github-app-token@v1
+        with:
+          app-id: ${{ vars.RELEASE_APP_ID }}
+          private-key: ${{ secrets.RELEASE_APP_PRIVATE_KEY }}
+      - run: git config user.name "renovate[bot]"
+        env:
+          TOKEN: ${{ secrets.DEPLOY_BOT_TOKEN }}
+          GH: ${{ secrets.GITHUB_TOKEN }}
+`
+
+	got := make(map[AutomationIdentity]bool)
+	for _, identity := range extractAutomationIdentities(content) {
+		got[identity] = true
+	}
+
+	want := []AutomationIdentity{
+		{Kind: IdentityKindGitHubApp, Name: "RELEASE_APP_ID"},
+		{Kind: IdentityKindSecret, Name: "RELEASE_APP_PRIVATE_KEY"},
+		{Kind: IdentityKindSecret, Name: "DEPLOY_BOT_TOKEN"},
+		{Kind: IdentityKindBotUser, Name: "renovate[bot]"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected identities: %v", got)
+	}
+	for _, identity := range want {
+		if !got[identity] {
+			t.Fatalf("expected identity %+v, got %v", identity, got)
+		}
+	}
+}
+
+func TestGenerateIdentitiesMarkdown(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	content := "jobs:\n  build:\n    steps:\n      - run: echo ${{ secrets.CI_BOT_TOKEN }}\n"
+	for _, repo := range []string{"repo-a", "repo-b"} {
+		if err := updateActionIndex(dbPath, "build.yml", repo, "build.yml", "hash-one", ""); err != nil {
+			t.Fatalf("updateActionIndex returned error: %v", err)
+		}
+	}
+	if err := storeActionVersion(dbPath, "build.yml", "hash-one", content); err != nil {
+		t.Fatalf("storeActionVersion returned error: %v", err)
+	}
+
+	if err := generateIdentitiesMarkdown(dbPath, "UnitVectorY-Labs"); err != nil {
+		t.Fatalf("generateIdentitiesMarkdown returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dbPath, "IDENTITIES.md"))
+	if err != nil {
+		t.Fatalf("failed to read IDENTITIES.md: %v", err)
+	}
+	if !strings.Contains(string(data), "### `CI_BOT_TOKEN`\n\n**Repositories**: 2") {
+		t.Fatalf("unexpected identities report:\n%s", data)
+	}
+}
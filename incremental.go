@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Incremental Indexing
+// ------------------------
+
+// RepositoryScanState records the state of a repository when it was last indexed.
+type RepositoryScanState struct {
+	PushedAt  time.Time `yaml:"pushed_at"`
+	ScannedAt time.Time `yaml:"scanned_at"`
+}
+
+// ScanSettings are the options of a scan that change which files are indexed. When they differ from
+// those of the recorded scans, every repository is scanned again.
+type ScanSettings struct {
+	Version      string   `yaml:"version"`
+	Dotfiles     []string `yaml:"dotfiles,omitempty"`
+	Paths        []string `yaml:"paths,omitempty"`
+	Renovate     bool     `yaml:"renovate,omitempty"`
+	DotGitHubAll bool     `yaml:"dotgithub_all,omitempty"`
+}
+
+// equal reports whether two scan settings index the same files.
+func (s ScanSettings) equal(other ScanSettings) bool {
+	return s.Version == other.Version && slices.Equal(s.Dotfiles, other.Dotfiles) && slices.Equal(s.Paths, other.Paths) &&
+		s.Renovate == other.Renovate && s.DotGitHubAll == other.DotGitHubAll
+}
+
+// ScanState is the contents of scan_state.yaml, used by -incremental to skip repositories that have not
+// been pushed to since they were last indexed, and by -max-duration to scan the least recently scanned first.
+type ScanState struct {
+	ScanSettings `yaml:",inline"`
+	Repositories map[string]RepositoryScanState `yaml:"repositories"`
+}
+
+// loadScanState reads scan_state.yaml, returning an empty state if it does not exist.
+func loadScanState(dbPath string) (*ScanState, error) {
+	state := &ScanState{Repositories: make(map[string]RepositoryScanState)}
+	data, err := os.ReadFile(filepath.Join(dbPath, "scan_state.yaml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse scan_state.yaml: %v", err)
+	}
+	if state.Repositories == nil {
+		state.Repositories = make(map[string]RepositoryScanState)
+	}
+	return state, nil
+}
+
+// writeScanState writes scan_state.yaml.
+func writeScanState(dbPath string, state *ScanState) error {
+	data, err := yaml.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dbPath, "scan_state.yaml"), data, 0644)
+}
+
+// prepare discards the recorded repositories when they were scanned with other settings, such as by
+// another version or with other dotfiles, since their stored results may then be incomplete.
+func (s *ScanState) prepare(settings ScanSettings) {
+	if s.ScanSettings.equal(settings) {
+		return
+	}
+	if len(s.Repositories) > 0 {
+		logInfof("The tool version, configured dotfiles, scanned paths, or indexed files changed since the last incremental scan; scanning every repository")
+	}
+	settings.Dotfiles = slices.Clone(settings.Dotfiles)
+	settings.Paths = slices.Clone(settings.Paths)
+	s.ScanSettings = settings
+	s.Repositories = make(map[string]RepositoryScanState)
+}
+
+// unchanged reports whether a repository has not been pushed to since it was last indexed.
+func (s *ScanState) unchanged(repo *github.Repository) bool {
+	recorded, ok := s.Repositories[repo.GetName()]
+	pushedAt := repo.GetPushedAt().Time
+	return ok && !pushedAt.IsZero() && recorded.PushedAt.Equal(pushedAt)
+}
+
+// record marks a repository as indexed at its current push time.
+func (s *ScanState) record(repo *github.Repository, now time.Time) {
+	s.Repositories[repo.GetName()] = RepositoryScanState{PushedAt: repo.GetPushedAt().Time, ScannedAt: now}
+}
+
+// forget drops repositories from the state, so that they are scanned as if never indexed.
+func (s *ScanState) forget(repoNames map[string]bool) {
+	for repoName := range repoNames {
+		delete(s.Repositories, repoName)
+	}
+}
+
+// prune drops repositories that are no longer listed, so that they are scanned if they return.
+func (s *ScanState) prune(repos []*github.Repository) {
+	listed := make(map[string]bool, len(repos))
+	for _, repo := range repos {
+		listed[repo.GetName()] = true
+	}
+	for repoName := range s.Repositories {
+		if !listed[repoName] {
+			delete(s.Repositories, repoName)
+		}
+	}
+}
+
+// partitionUnchanged splits repositories into those to scan and the names of those to skip.
+func partitionUnchanged(repos []*github.Repository, state *ScanState) ([]*github.Repository, map[string]bool) {
+	var scan []*github.Repository
+	skipped := make(map[string]bool)
+	for _, repo := range repos {
+		if state.unchanged(repo) {
+			skipped[repo.GetName()] = true
+			continue
+		}
+		scan = append(scan, repo)
+	}
+	return scan, skipped
+}
+
+// sortLeastRecentlyScanned orders repositories by when they were last scanned, those never scanned first,
+// so that time-boxed runs that each scan only some of them rotate through all of them.
+func sortLeastRecentlyScanned(repos []*github.Repository, state *ScanState) {
+	sort.SliceStable(repos, func(i, j int) bool {
+		return state.Repositories[repos[i].GetName()].ScannedAt.Before(state.Repositories[repos[j].GetName()].ScannedAt)
+	})
+}
+
+// mergeActionUses adds every reference in from to into.
+func mergeActionUses(into, from *ActionUsesIndex) {
+	for actionName, versions := range from.Actions {
+		if into.Actions[actionName] == nil {
+			into.Actions[actionName] = make(map[string][]WorkflowReference)
+		}
+		for version, refs := range versions {
+			into.Actions[actionName][version] = append(into.Actions[actionName][version], refs...)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+)
+
+func TestScanStatePartitionsUnchangedRepositories(t *testing.T) {
+	t.Parallel()
+
+	pushed := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := func(name string, pushedAt time.Time) *github.Repository {
+		r := &github.Repository{Name: github.String(name)}
+		if !pushedAt.IsZero() {
+			r.PushedAt = &github.Timestamp{Time: pushedAt}
+		}
+		return r
+	}
+
+	dbPath := t.TempDir()
+	state, err := loadScanState(dbPath)
+	if err != nil {
+		t.Fatalf("loadScanState returned error: %v", err)
+	}
+	state.prepare(ScanSettings{Version: "v1.2.0", Dotfiles: []string{".gitignore"}})
+	for _, r := range []*github.Repository{repo("repo-a", pushed), repo("repo-b", pushed), repo("repo-c", time.Time{}), repo("gone", pushed)} {
+		state.record(r, pushed.Add(time.Hour))
+	}
+	state.prune([]*github.Repository{repo("repo-a", pushed), repo("repo-b", pushed), repo("repo-c", time.Time{})})
+	if err := writeScanState(dbPath, state); err != nil {
+		t.Fatalf("writeScanState returned error: %v", err)
+	}
+
+	state, err = loadScanState(dbPath)
+	if err != nil {
+		t.Fatalf("loadScanState returned error: %v", err)
+	}
+	if _, ok := state.Repositories["gone"]; ok {
+		t.Fatalf("expected repositories no longer listed to be pruned")
+	}
+	state.prepare(ScanSettings{Version: "v1.2.0", Dotfiles: []string{".gitignore"}})
+
+	scan, skipped := partitionUnchanged([]*github.Repository{
+		repo("repo-a", pushed),                // unchanged
+		repo("repo-b", pushed.Add(time.Hour)), // pushed since
+		repo("repo-c", time.Time{}),           // no push time is always scanned
+		repo("repo-d", pushed),                // never indexed
+	}, state)
+	if len(skipped) != 1 || !skipped["repo-a"] || len(scan) != 3 {
+		t.Fatalf("unexpected partition: scan %d, skipped %v", len(scan), skipped)
+	}
+
+	// Another version rescans everything
+	state.prepare(ScanSettings{Version: "v1.3.0", Dotfiles: []string{".gitignore"}})
+	if _, skipped := partitionUnchanged([]*github.Repository{repo("repo-a", pushed)}, state); len(skipped) != 0 {
+		t.Fatalf("expected a version change to scan every repository, skipped %v", skipped)
+	}
+
+	// So do other scanned paths
+	state.record(repo("repo-a", pushed), pushed.Add(time.Hour))
+	state.prepare(ScanSettings{Version: "v1.3.0", Dotfiles: []string{".gitignore"}, Paths: []string{".github/workflows", "ci"}})
+	if _, skipped := partitionUnchanged([]*github.Repository{repo("repo-a", pushed)}, state); len(skipped) != 0 {
+		t.Fatalf("expected a change of scanned paths to scan every repository, skipped %v", skipped)
+	}
+
+	// And enabling Renovate indexing
+	state.record(repo("repo-a", pushed), pushed.Add(time.Hour))
+	state.prepare(ScanSettings{Version: "v1.3.0", Dotfiles: []string{".gitignore"}, Paths: []string{".github/workflows", "ci"}, Renovate: true})
+	if _, skipped := partitionUnchanged([]*github.Repository{repo("repo-a", pushed)}, state); len(skipped) != 0 {
+		t.Fatalf("expected enabling Renovate indexing to scan every repository, skipped %v", skipped)
+	}
+	state.record(repo("repo-a", pushed), pushed.Add(time.Hour))
+	state.prepare(ScanSettings{Version: "v1.3.0", Dotfiles: []string{".gitignore"}, Paths: []string{".github/workflows", "ci"}, Renovate: true, DotGitHubAll: true})
+	if _, skipped := partitionUnchanged([]*github.Repository{repo("repo-a", pushed)}, state); len(skipped) != 0 {
+		t.Fatalf("expected enabling -dotgithub-all to scan every repository, skipped %v", skipped)
+	}
+}
+
+func TestMergeActionUses(t *testing.T) {
+	t.Parallel()
+
+	into := &ActionUsesIndex{Actions: make(map[string]map[string][]WorkflowReference)}
+	addActionUses(into, []ActionUse{{Action: "actions/checkout", Version: "v4", RepoName: "repo-a", FilePath: ".github/workflows/build.yml"}})
+	from := &ActionUsesIndex{Actions: make(map[string]map[string][]WorkflowReference)}
+	addActionUses(from, []ActionUse{
+		{Action: "actions/checkout", Version: "v4", RepoName: "repo-b", FilePath: ".github/workflows/build.yml"},
+		{Action: "actions/setup-go", Version: "v5", RepoName: "repo-b", FilePath: ".github/workflows/build.yml"},
+	})
+
+	mergeActionUses(into, from)
+	if len(into.Actions["actions/checkout"]["v4"]) != 2 || len(into.Actions["actions/setup-go"]["v5"]) != 1 {
+		t.Fatalf("unexpected merged index: %+v", into.Actions)
+	}
+}
+
+func TestSortLeastRecentlyScanned(t *testing.T) {
+	t.Parallel()
+
+	scanned := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	state := &ScanState{Repositories: map[string]RepositoryScanState{
+		"repo-a": {ScannedAt: scanned.Add(2 * time.Hour)},
+		"repo-b": {ScannedAt: scanned},
+		"repo-d": {ScannedAt: scanned.Add(time.Hour)},
+	}}
+	var repos []*github.Repository
+	for _, name := range []string{"repo-a", "repo-b", "repo-c", "repo-d", "repo-e"} {
+		repos = append(repos, &github.Repository{Name: github.String(name)})
+	}
+
+	sortLeastRecentlyScanned(repos, state)
+	var order []string
+	for _, repo := range repos {
+		order = append(order, repo.GetName())
+	}
+	// Repositories never scanned come first, in listing order
+	if got := strings.Join(order, ","); got != "repo-c,repo-e,repo-b,repo-d,repo-a" {
+		t.Fatalf("unexpected scan order: %s", got)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v50/github"
+)
+
+// ------------------------
+// Section: GitHub App Installations
+// ------------------------
+
+// fetchInstallationRepositories lists the repositories a GitHub App installation token can access, for
+// installations limited to selected repositories that cannot list the whole organization. Only
+// repositories owned by org are returned, filtered by the same visibility options and filter as fetchRepositories.
+func fetchInstallationRepositories(client *github.Client, org string, includePub, includePrv bool, filter *RepositoryFilter) ([]*github.Repository, error) {
+	ctx := context.Background()
+	var allRepos []*github.Repository
+	opt := &github.ListOptions{PerPage: 100}
+	var otherOwners []string
+
+	for {
+		list, resp, err := client.Apps.ListRepos(ctx, opt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list installation repositories; -installation requires a GitHub App installation token: %v", err)
+		}
+
+		for _, repo := range list.Repositories {
+			if owner := repo.GetOwner().GetLogin(); !strings.EqualFold(owner, org) {
+				otherOwners = append(otherOwners, owner)
+				continue
+			}
+			if includeRepository(repo, includePub, includePrv, filter) {
+				allRepos = append(allRepos, repo)
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+
+		// Handle rate limiting
+		if _, err := checkRateLimit(client); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(allRepos) == 0 && len(otherOwners) > 0 {
+		return nil, fmt.Errorf("the installation has no repositories in '%s'; it is installed on '%s'", org, otherOwners[0])
+	}
+	logInfof("Found %d repositories in the GitHub App installation", len(allRepos))
+	return allRepos, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v50/github"
+)
+
+func TestFetchInstallationRepositories(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/installation/repositories":
+			if r.URL.Query().Get("page") == "2" {
+				w.Write([]byte(`{"total_count":4,"repositories":[
+					{"name":"repo-c","visibility":"private","owner":{"login":"example-org"}},
+					{"name":"repo-d","visibility":"public","archived":true,"owner":{"login":"example-org"}}]}`))
+				return
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<%s/installation/repositories?page=2>; rel="next"`, "http://"+r.Host))
+			w.Write([]byte(`{"total_count":4,"repositories":[
+				{"name":"repo-a","visibility":"public","owner":{"login":"Example-Org"}},
+				{"name":"repo-b","visibility":"public","owner":{"login":"other-org"}}]}`))
+		case "/rate_limit":
+			w.Write([]byte(`{"resources":{"core":{"limit":5000,"remaining":5000,"reset":0}}}`))
+		default:
+			http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	repos, err := fetchInstallationRepositories(client, "example-org", true, true, nil)
+	if err != nil {
+		t.Fatalf("fetchInstallationRepositories returned error: %v", err)
+	}
+	var names []string
+	for _, repo := range repos {
+		names = append(names, repo.GetName())
+	}
+	if fmt.Sprint(names) != "[repo-a repo-c]" {
+		t.Fatalf("unexpected repositories: %v", names)
+	}
+
+	repos, err = fetchInstallationRepositories(client, "example-org", true, false, nil)
+	if err != nil || len(repos) != 1 || repos[0].GetName() != "repo-a" {
+		t.Fatalf("expected only public repositories, got %v, %v", repos, err)
+	}
+
+	if _, err := fetchInstallationRepositories(client, "other-org", false, true, nil); err == nil {
+		t.Fatalf("expected an error for an installation without repositories in the organization")
+	}
+}
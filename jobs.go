@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Effective Job Configuration
+// ------------------------
+
+// defaultJobTimeoutMinutes is the timeout GitHub applies to jobs that do not set timeout-minutes.
+const defaultJobTimeoutMinutes = 360
+
+// Where an effective job setting comes from.
+const (
+	SettingSourceJob      = "job"
+	SettingSourceWorkflow = "workflow"
+	SettingSourceDefault  = "default"
+)
+
+// Permissions is a GITHUB_TOKEN permissions declaration.
+type Permissions struct {
+	All    string            // "read-all" or "write-all" when declared as a single value
+	Scopes map[string]string // Scope to access level when declared as a mapping; empty for `permissions: {}`
+	Line   int               // Line of the declaration
+}
+
+// EffectiveJob is a job with the settings it inherits from the workflow applied, so analyzers
+// can evaluate what the job actually runs with rather than what is written on it.
+type EffectiveJob struct {
+	ID                string
+	Name              string // Display name set with `name`; empty when the job is shown by its ID
+	Line              int
+	Needs             []string // Direct dependencies as declared
+	Upstream          []string // Every job that must finish first, following needs transitively
+	Permissions       *Permissions
+	PermissionsSource string // nil Permissions with SettingSourceDefault means the repository default applies
+	TimeoutMinutes    int
+	TimeoutSource     string
+	Env               map[string]string // Workflow env overridden by job env
+	ReusableWorkflow  string            // Set when the job calls a reusable workflow with `uses`
+	Matrix            bool              // The job runs once per combination of strategy.matrix
+	Steps             int
+}
+
+// resolveWorkflowJobs parses a workflow and returns the effective configuration of each job, sorted by job ID.
+func resolveWorkflowJobs(content string) ([]EffectiveJob, error) {
+	workflow, err := parseWorkflowDocument(content)
+	if err != nil {
+		return nil, err
+	}
+
+	workflowPerms := parsePermissions(mappingValue(workflow, "permissions"))
+	workflowEnv := parseEnv(mappingValue(workflow, "env"))
+
+	jobsNode := mappingValue(workflow, "jobs")
+	if jobsNode == nil || jobsNode.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	byID := make(map[string]*EffectiveJob)
+	var jobs []*EffectiveJob
+	for i := 0; i+1 < len(jobsNode.Content); i += 2 {
+		key, jobNode := jobsNode.Content[i], jobsNode.Content[i+1]
+		job := &EffectiveJob{ID: key.Value, Line: key.Line}
+		if name := mappingValue(jobNode, "name"); name != nil && name.Kind == yaml.ScalarNode {
+			job.Name = name.Value
+		}
+		job.Matrix = mappingValue(mappingValue(jobNode, "strategy"), "matrix") != nil
+
+		if needs := mappingValue(jobNode, "needs"); needs != nil {
+			switch needs.Kind {
+			case yaml.ScalarNode:
+				job.Needs = []string{needs.Value}
+			case yaml.SequenceNode:
+				for _, need := range needs.Content {
+					job.Needs = append(job.Needs, need.Value)
+				}
+			}
+		}
+
+		if perms := parsePermissions(mappingValue(jobNode, "permissions")); perms != nil {
+			job.Permissions, job.PermissionsSource = perms, SettingSourceJob
+		} else if workflowPerms != nil {
+			job.Permissions, job.PermissionsSource = workflowPerms, SettingSourceWorkflow
+		} else {
+			job.PermissionsSource = SettingSourceDefault
+		}
+
+		if uses := mappingValue(jobNode, "uses"); uses != nil {
+			job.ReusableWorkflow = uses.Value
+		} else if timeout := mappingValue(jobNode, "timeout-minutes"); timeout != nil {
+			// Expressions cannot be evaluated statically, so any non-numeric value counts as set
+			job.TimeoutMinutes, job.TimeoutSource = -1, SettingSourceJob
+			var minutes int
+			if err := timeout.Decode(&minutes); err == nil {
+				job.TimeoutMinutes = minutes
+			}
+		} else {
+			job.TimeoutMinutes, job.TimeoutSource = defaultJobTimeoutMinutes, SettingSourceDefault
+		}
+
+		if steps := mappingValue(jobNode, "steps"); steps != nil && steps.Kind == yaml.SequenceNode {
+			job.Steps = len(steps.Content)
+		}
+
+		job.Env = make(map[string]string)
+		for name, value := range workflowEnv {
+			job.Env[name] = value
+		}
+		for name, value := range parseEnv(mappingValue(jobNode, "env")) {
+			job.Env[name] = value
+		}
+
+		byID[job.ID] = job
+		jobs = append(jobs, job)
+	}
+
+	for _, job := range jobs {
+		job.Upstream = upstreamJobs(job.ID, byID)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].ID < jobs[j].ID
+	})
+	result := make([]EffectiveJob, len(jobs))
+	for i, job := range jobs {
+		result[i] = *job
+	}
+	return result, nil
+}
+
+// upstreamJobs follows needs from a job and returns every job it transitively depends on, sorted.
+// Cycles and unknown job IDs are tolerated so that invalid workflows can still be analyzed.
+func upstreamJobs(id string, byID map[string]*EffectiveJob) []string {
+	seen := map[string]bool{id: true}
+	queue := []string{id}
+	var upstream []string
+	for len(queue) > 0 {
+		current := byID[queue[0]]
+		queue = queue[1:]
+		if current == nil {
+			continue
+		}
+		for _, need := range current.Needs {
+			if !seen[need] {
+				seen[need] = true
+				upstream = append(upstream, need)
+				queue = append(queue, need)
+			}
+		}
+	}
+	sort.Strings(upstream)
+	return upstream
+}
+
+// parsePermissions decodes a permissions node, returning nil when it is absent.
+func parsePermissions(node *yaml.Node) *Permissions {
+	if node == nil {
+		return nil
+	}
+	perms := &Permissions{Line: node.Line, Scopes: make(map[string]string)}
+	switch node.Kind {
+	case yaml.ScalarNode:
+		perms.All = node.Value
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			perms.Scopes[node.Content[i].Value] = node.Content[i+1].Value
+		}
+	}
+	return perms
+}
+
+// parseEnv decodes an env mapping into strings, ignoring values that are not scalars.
+func parseEnv(node *yaml.Node) map[string]string {
+	env := make(map[string]string)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return env
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i+1].Kind == yaml.ScalarNode {
+			env[node.Content[i].Value] = node.Content[i+1].Value
+		}
+	}
+	return env
+}
+
+// HasTimeout reports whether the job sets its own timeout rather than relying on GitHub's six hour default.
+// Jobs that call reusable workflows cannot set a timeout and report true.
+func (j EffectiveJob) HasTimeout() bool {
+	return j.ReusableWorkflow != "" || j.TimeoutSource == SettingSourceJob
+}
+
+// scanForWritePermissions reports jobs whose effective token permissions are write-all,
+// pointing at the declaration the job inherits them from.
+func scanForWritePermissions(content, repoName, filePath string) []Finding {
+	jobs, err := resolveWorkflowJobs(content)
+	if err != nil {
+		return nil
+	}
+
+	var findings []Finding
+	for _, job := range jobs {
+		if job.Permissions == nil || job.Permissions.All != "write-all" {
+			continue
+		}
+		message := fmt.Sprintf("Job '%s' runs with write-all permissions", job.ID)
+		if job.PermissionsSource == SettingSourceWorkflow {
+			message += " inherited from the workflow"
+		}
+		findings = append(findings, newFinding("write-all-permissions", repoName, filePath, job.Permissions.Line, message))
+	}
+	return findings
+}
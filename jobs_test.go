@@ -0,0 +1,96 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const inheritanceWorkflow = `on: push
+permissions: write-all
+env:
+  STAGE: test
+  REGION: us-east-1
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    timeout-minutes: 10
+    steps:
+      - run: make
+  test:
+    needs: build
+    runs-on: ubuntu-latest
+    permissions:
+      contents: read
+    env:
+      STAGE: ci
+    steps:
+      - run: make test
+  deploy:
+    needs: [test]
+    uses: example-org/shared/.github/workflows/deploy.yml@v1
+`
+
+func TestResolveWorkflowJobs(t *testing.T) {
+	t.Parallel()
+
+	jobs, err := resolveWorkflowJobs(inheritanceWorkflow)
+	if err != nil {
+		t.Fatalf("resolveWorkflowJobs returned error: %v", err)
+	}
+	if len(jobs) != 3 || jobs[0].ID != "build" || jobs[1].ID != "deploy" || jobs[2].ID != "test" {
+		t.Fatalf("unexpected jobs: %+v", jobs)
+	}
+	build, deploy, test := jobs[0], jobs[1], jobs[2]
+
+	if build.PermissionsSource != SettingSourceWorkflow || build.Permissions.All != "write-all" || build.Permissions.Line != 2 {
+		t.Fatalf("expected build to inherit workflow permissions, got %+v", build.Permissions)
+	}
+	if test.PermissionsSource != SettingSourceJob || test.Permissions.Scopes["contents"] != "read" {
+		t.Fatalf("expected test to override permissions, got %+v", test.Permissions)
+	}
+	if test.Env["STAGE"] != "ci" || test.Env["REGION"] != "us-east-1" || build.Env["STAGE"] != "test" {
+		t.Fatalf("unexpected effective env: build %+v, test %+v", build.Env, test.Env)
+	}
+	if build.TimeoutMinutes != 10 || !build.HasTimeout() || test.TimeoutMinutes != defaultJobTimeoutMinutes || test.HasTimeout() {
+		t.Fatalf("unexpected timeouts: build %d, test %d", build.TimeoutMinutes, test.TimeoutMinutes)
+	}
+	if deploy.ReusableWorkflow == "" || !deploy.HasTimeout() {
+		t.Fatalf("expected deploy to be a reusable workflow call, got %+v", deploy)
+	}
+	if strings.Join(deploy.Upstream, ",") != "build,test" || strings.Join(deploy.Needs, ",") != "test" {
+		t.Fatalf("unexpected needs: direct %v, upstream %v", deploy.Needs, deploy.Upstream)
+	}
+}
+
+func TestResolveWorkflowJobsDefaultsAndCycles(t *testing.T) {
+	t.Parallel()
+
+	content := "jobs:\n  a:\n    needs: b\n    runs-on: ubuntu-latest\n  b:\n    needs: a\n    runs-on: ubuntu-latest\n    timeout-minutes: ${{ inputs.timeout }}\n"
+	jobs, err := resolveWorkflowJobs(content)
+	if err != nil {
+		t.Fatalf("resolveWorkflowJobs returned error: %v", err)
+	}
+	if jobs[0].Permissions != nil || jobs[0].PermissionsSource != SettingSourceDefault {
+		t.Fatalf("expected repository default permissions, got %+v", jobs[0])
+	}
+	if strings.Join(jobs[0].Upstream, ",") != "b" {
+		t.Fatalf("expected cyclic needs to terminate, got %v", jobs[0].Upstream)
+	}
+	if !jobs[1].HasTimeout() {
+		t.Fatalf("expected an expression timeout to count as set")
+	}
+}
+
+func TestScanForWritePermissions(t *testing.T) {
+	t.Parallel()
+
+	findings := scanForWritePermissions(inheritanceWorkflow, "repo-a", ".github/workflows/build.yml")
+	// Reusable workflow calls pass the caller's token permissions on, so deploy is reported too
+	if len(findings) != 2 || findings[1].Message != "Job 'deploy' runs with write-all permissions inherited from the workflow" {
+		t.Fatalf("expected build and deploy to be reported, got %+v", findings)
+	}
+	finding := findings[0]
+	if finding.Rule != "write-all-permissions" || finding.Line != 2 || finding.Message != "Job 'build' runs with write-all permissions inherited from the workflow" {
+		t.Fatalf("unexpected finding: %+v", finding)
+	}
+}
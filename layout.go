@@ -0,0 +1,356 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ------------------------
+// Section: Database Layout
+// ------------------------
+
+// Database layouts. The layout of a database is recorded in its version file; one without it is layout 1.
+const (
+	layoutV1     = 1 // Stored file versions sit next to index.yaml in each folder
+	layoutV2     = 2 // Stored file versions sit in a blobs folder, apart from the index, change log, and README
+	latestLayout = layoutV2
+)
+
+// layoutMarker is the file in the database directory that records its layout.
+const layoutMarker = "version"
+
+// blobsDir is the folder of stored file versions in layout 2.
+const blobsDir = "blobs"
+
+// dbLayout is the layout of the database in use. openDB sets it from the database's version file.
+var dbLayout = layoutV1
+
+// layoutUpgrades converts a database from each layout to the next.
+var layoutUpgrades = map[int]func(dbPath string) error{
+	layoutV1: moveVersionsToBlobs,
+}
+
+// readLayout returns the layout recorded in a database's version file.
+func readLayout(dbPath string) (int, error) {
+	data, err := os.ReadFile(filepath.Join(dbPath, layoutMarker))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return layoutV1, nil
+		}
+		return 0, err
+	}
+	layout, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || layout < layoutV1 {
+		return 0, fmt.Errorf("database version file holds '%s', not a layout number", strings.TrimSpace(string(data)))
+	}
+	if layout > latestLayout {
+		return 0, fmt.Errorf("database uses layout %d, but this version of dotgithubindexer supports up to layout %d; update dotgithubindexer", layout, latestLayout)
+	}
+	return layout, nil
+}
+
+// detectLayout sets dbLayout from a database's version file.
+func detectLayout(dbPath string) error {
+	layout, err := readLayout(dbPath)
+	if err != nil {
+		return err
+	}
+	dbLayout = layout
+	return nil
+}
+
+// writeLayout records a layout in a database's version file.
+func writeLayout(dbPath string, layout int) error {
+	return os.WriteFile(filepath.Join(dbPath, layoutMarker), []byte(strconv.Itoa(layout)+"\n"), 0644)
+}
+
+// isNewDB reports whether a database has not been indexed yet.
+func isNewDB(dbPath string) bool {
+	_, err := os.Stat(filepath.Join(dbPath, "repositories.yaml"))
+	return os.IsNotExist(err)
+}
+
+// selectLayout applies -layout to an index run. A new database is created with the requested layout;
+// an existing one keeps its own, which any requested layout must match. Zero requests no layout.
+func selectLayout(dbPath string, requested int) error {
+	if requested == 0 {
+		return nil
+	}
+	if requested < layoutV1 || requested > latestLayout {
+		return fmt.Errorf("-layout must be between %d and %d", layoutV1, latestLayout)
+	}
+	if isNewDB(dbPath) {
+		dbLayout = requested
+		return nil
+	}
+	if requested != dbLayout {
+		return fmt.Errorf("database uses layout %d, not %d; run 'upgrade-db' to convert it", dbLayout, requested)
+	}
+	return nil
+}
+
+// selectShardLayout checks that every shard uses the layout of the database they are merged into.
+// A new database takes the layout of its shards.
+func selectShardLayout(dbPath string, shardPaths []string) error {
+	for i, shardPath := range shardPaths {
+		layout, err := readLayout(shardPath)
+		if err != nil {
+			return fmt.Errorf("shard '%s': %v", shardPath, err)
+		}
+		if i == 0 && isNewDB(dbPath) {
+			dbLayout = layout
+		}
+		if layout != dbLayout {
+			return fmt.Errorf("shard '%s' uses layout %d, but the database uses layout %d", shardPath, layout, dbLayout)
+		}
+	}
+	return nil
+}
+
+// versionsDir returns the folder holding the stored versions of a folder such as workflows/build.yml.
+func versionsDir(dir string) string {
+	if dbLayout >= layoutV2 {
+		return filepath.Join(dir, blobsDir)
+	}
+	return dir
+}
+
+// versionPath returns the path of a stored file version in a folder such as workflows/build.yml.
+func versionPath(dir, hash string) string {
+	return filepath.Join(versionsDir(dir), hash)
+}
+
+// versionLink returns the link to a stored file version from the README.md of its folder.
+func versionLink(hash string) string {
+	if dbLayout >= layoutV2 {
+		return blobsDir + "/" + hash
+	}
+	return hash
+}
+
+// storeContent is false with -store-content=false, which keeps the indexes, hashes, and extracted
+// metadata of every file but not the file versions themselves.
+var storeContent = true
+
+// unstoredVersions holds, by path, the file versions a run did not write with -store-content=false, so
+// that the reports of that run still cover their content.
+var unstoredVersions = struct {
+	sync.Mutex
+	content map[string][]byte
+}{content: make(map[string][]byte)}
+
+// writeVersionFile writes a file version, or with -store-content=false only holds it for the rest of the run.
+func writeVersionFile(storedPath string, content []byte) error {
+	if !storeContent {
+		unstoredVersions.Lock()
+		unstoredVersions.content[storedPath] = content
+		unstoredVersions.Unlock()
+		return nil
+	}
+	return os.WriteFile(storedPath, content, 0644)
+}
+
+// readVersionFile reads a stored file version, falling back to a version this run did not write.
+func readVersionFile(storedPath string) ([]byte, error) {
+	content, err := os.ReadFile(storedPath)
+	if os.IsNotExist(err) {
+		unstoredVersions.Lock()
+		held, ok := unstoredVersions.content[storedPath]
+		unstoredVersions.Unlock()
+		if ok {
+			return held, nil
+		}
+	}
+	return content, err
+}
+
+// versionReference formats a file version for the README.md of its folder: a link to the stored file,
+// or the bare hash when its content is not stored.
+func versionReference(dir, hash string) string {
+	if _, err := os.Stat(versionPath(dir, hash)); err != nil {
+		return hash
+	}
+	return fmt.Sprintf("[%s](%s)", hash, versionLink(hash))
+}
+
+// versionFolders returns every folder of the database that stores file versions.
+func versionFolders(dbPath string) ([]string, error) {
+	var folders []string
+	for _, kind := range []string{"workflows", "dependabot", "actions", "templates", "profile", renovateDir} {
+		names, err := readSubdirectories(filepath.Join(dbPath, kind))
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			folders = append(folders, filepath.Join(dbPath, kind, name))
+		}
+	}
+	dotfilePaths, err := walkDotfileIndexes(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, dotfilePath := range dotfilePaths {
+		folders = append(folders, dotfileStoragePath(dbPath, dotfilePath))
+	}
+	dotGitHubNames, err := walkIndexedFolders(filepath.Join(dbPath, dotGitHubDir))
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range dotGitHubNames {
+		folders = append(folders, dotGitHubStoragePath(dbPath, name))
+	}
+	return folders, nil
+}
+
+// moveVersionsToBlobs converts a layout 1 database to layout 2 by moving the stored file versions of
+// every folder into its blobs folder.
+func moveVersionsToBlobs(dbPath string) error {
+	folders, err := versionFolders(dbPath)
+	if err != nil {
+		return err
+	}
+	moved := 0
+	for _, folder := range folders {
+		entries, err := os.ReadDir(folder)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !storedVersionRe.MatchString(entry.Name()) {
+				continue
+			}
+			if err := os.MkdirAll(filepath.Join(folder, blobsDir), os.ModePerm); err != nil {
+				return err
+			}
+			if err := os.Rename(filepath.Join(folder, entry.Name()), filepath.Join(folder, blobsDir, entry.Name())); err != nil {
+				return err
+			}
+			moved++
+		}
+	}
+	logInfof("Moved %d stored file versions in %d folders into blobs folders", moved, len(folders))
+	return nil
+}
+
+// backupDB copies a database to backupPath, leaving out its .git directory.
+func backupDB(dbPath, backupPath string) error {
+	if _, err := os.Stat(backupPath); err == nil {
+		return fmt.Errorf("backup '%s' already exists", backupPath)
+	}
+	return filepath.WalkDir(dbPath, func(current string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dbPath, current)
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if entry.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(filepath.Join(backupPath, rel), os.ModePerm)
+		}
+		return copyFileIfMissing(current, filepath.Join(backupPath, rel))
+	})
+}
+
+// upgradeDB converts a database to the target layout, one layout at a time, after copying it to
+// backupPath unless that is empty. The README.md files are regenerated so their links follow the new layout.
+func upgradeDB(dbPath string, target int, backupPath string) error {
+	if target < layoutV1 || target > latestLayout {
+		return fmt.Errorf("-to must be between %d and %d", layoutV1, latestLayout)
+	}
+	if dbLayout >= target {
+		return fmt.Errorf("database already uses layout %d", dbLayout)
+	}
+
+	if backupPath != "" {
+		logInfof("Backing up the database to '%s'", backupPath)
+		if err := backupDB(dbPath, backupPath); err != nil {
+			return fmt.Errorf("failed to back up the database: %v", err)
+		}
+	}
+
+	for dbLayout < target {
+		logInfof("Upgrading the database from layout %d to %d", dbLayout, dbLayout+1)
+		if err := layoutUpgrades[dbLayout](dbPath); err != nil {
+			return fmt.Errorf("failed to upgrade from layout %d: %v", dbLayout, err)
+		}
+		dbLayout++
+		if err := writeLayout(dbPath, dbLayout); err != nil {
+			return err
+		}
+	}
+
+	if err := regenerateReports(nil, dbPath); err != nil {
+		logErrorf("Error regenerating reports: %v", err)
+	}
+	return nil
+}
+
+// runUpgradeDBCommand converts a database to a newer layout and returns the process exit code.
+func runUpgradeDBCommand(args []string) int {
+	fs := flag.NewFlagSet("upgrade-db", flag.ContinueOnError)
+	upgradeDBPath := fs.String("db", "./db", "Path to the database repository, or a git URL to clone, update, and push")
+	upgradeToken := fs.String("token", "", "GitHub API token used to clone and push an HTTPS database URL")
+	baseURL := fs.String("base-url", "", "GitHub Enterprise Server API URL, e.g. https://github.example.com/api/v3; defaults to github.com")
+	uploadURL := fs.String("upload-url", "", "GitHub Enterprise Server upload URL; defaults to -base-url")
+	review := fs.Bool("review", false, "Push database changes to a new branch and open a pull request instead of pushing them directly; requires a git URL -db")
+	target := fs.Int("to", latestLayout, "Layout to convert the database to")
+	backup := fs.String("backup", "", "Directory to copy a local database to before converting it; defaults to <db>.layout-v<current>-backup")
+	logFlags := addLogFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if err := applyEnvironmentDefaults(fs); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := logFlags.apply(); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if fs.NArg() != 0 {
+		fmt.Println("Usage: dotgithubindexer upgrade-db [-db <path or git URL>] [-token <token>] [-to <layout>] [-backup <path>]")
+		fs.PrintDefaults()
+		return 1
+	}
+	if err := setGitHubServer(*baseURL, *uploadURL); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := checkReviewMode(*review, *upgradeDBPath); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+
+	checkout, err := openDB(*upgradeDBPath, *upgradeToken)
+	if err != nil {
+		logErrorf("Failed to open database: %v", err)
+		return 1
+	}
+	defer checkout.Close()
+
+	// A remote database keeps its previous layout in its git history
+	backupPath := *backup
+	if backupPath == "" && checkout.URL == "" {
+		backupPath = fmt.Sprintf("%s.layout-v%d-backup", filepath.Clean(*upgradeDBPath), dbLayout)
+	}
+	from := dbLayout
+	if err := upgradeDB(checkout.Dir, *target, backupPath); err != nil {
+		logErrorf("Upgrade failed: %v", err)
+		return 1
+	}
+	if err := publishDB(checkout, fmt.Sprintf("Upgrade database layout from %d to %d", from, dbLayout), *review, *upgradeToken); err != nil {
+		logErrorf("Failed to publish database: %v", err)
+		return 1
+	}
+	return 0
+}
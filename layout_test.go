@@ -0,0 +1,148 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadLayout(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	if layout, err := readLayout(dbPath); err != nil || layout != layoutV1 {
+		t.Fatalf("expected layout 1 without a version file, got %d, %v", layout, err)
+	}
+	for content, valid := range map[string]bool{"2\n": true, "3\n": false, "two\n": false} {
+		if err := os.WriteFile(filepath.Join(dbPath, layoutMarker), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write version file: %v", err)
+		}
+		if _, err := readLayout(dbPath); (err == nil) != valid {
+			t.Fatalf("readLayout with %q returned err=%v", content, err)
+		}
+	}
+}
+
+func TestUpgradeDB(t *testing.T) {
+	previousOrg := org
+	org = "example-org"
+	defer func() { dbLayout, org = layoutV1, previousOrg }()
+	dbPath := filepath.Join(t.TempDir(), "db")
+	if err := detectLayout(dbPath); err != nil {
+		t.Fatalf("detectLayout returned error: %v", err)
+	}
+	if err := initializeDB(dbPath); err != nil {
+		t.Fatalf("initializeDB returned error: %v", err)
+	}
+
+	content := "on: push\n"
+	hash := computeHash([]byte(content))
+	if err := updateActionIndex(dbPath, "ci.yml", "repo-a", "ci.yml", hash, ""); err != nil {
+		t.Fatalf("updateActionIndex returned error: %v", err)
+	}
+	if err := storeActionVersion(dbPath, "ci.yml", hash, content); err != nil {
+		t.Fatalf("storeActionVersion returned error: %v", err)
+	}
+	if err := updateDependabotIndex(dbPath, "repo-a", ".github/dependabot.yml", hash, "", "Default"); err != nil {
+		t.Fatalf("updateDependabotIndex returned error: %v", err)
+	}
+	if err := storeDependabotVersion(dbPath, "Default", hash, content); err != nil {
+		t.Fatalf("storeDependabotVersion returned error: %v", err)
+	}
+	if err := selectLayout(dbPath, layoutV2); err == nil {
+		t.Fatalf("expected an error requesting layout 2 for an existing layout 1 database")
+	}
+
+	backupPath := dbPath + ".backup"
+	if err := upgradeDB(dbPath, layoutV2, backupPath); err != nil {
+		t.Fatalf("upgradeDB returned error: %v", err)
+	}
+	if layout, err := readLayout(dbPath); err != nil || layout != layoutV2 || dbLayout != layoutV2 {
+		t.Fatalf("expected layout 2 after the upgrade, got %d, %v", layout, err)
+	}
+	for _, kind := range []string{"workflows/ci.yml", "dependabot/Default"} {
+		if _, err := os.Stat(filepath.Join(dbPath, kind, blobsDir, hash)); err != nil {
+			t.Fatalf("expected %s to store its version in blobs: %v", kind, err)
+		}
+		if _, err := os.Stat(filepath.Join(dbPath, kind, hash)); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to no longer store its version next to index.yaml, got err=%v", kind, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(backupPath, "workflows", "ci.yml", hash)); err != nil {
+		t.Fatalf("expected the backup to keep layout 1: %v", err)
+	}
+
+	var read []string
+	if err := walkIndexedWorkflows(dbPath, func(fileName, repoName, stored string) { read = append(read, stored) }); err != nil {
+		t.Fatalf("walkIndexedWorkflows returned error: %v", err)
+	}
+	if len(read) != 1 || read[0] != content {
+		t.Fatalf("unexpected workflows after the upgrade: %q", read)
+	}
+	readme, err := os.ReadFile(filepath.Join(dbPath, "workflows", "ci.yml", "README.md"))
+	if err != nil {
+		t.Fatalf("failed to read README.md: %v", err)
+	}
+	if !strings.Contains(string(readme), "## ["+hash+"](blobs/"+hash+")") {
+		t.Fatalf("unexpected README.md content:\n%s", readme)
+	}
+
+	// Versions stored after the upgrade go to blobs, and unused ones are collected from there
+	if err := storeActionVersion(dbPath, "ci.yml", strings.Repeat("0", 64), "on: pull_request\n"); err != nil {
+		t.Fatalf("storeActionVersion returned error: %v", err)
+	}
+	if err := garbageCollect(dbPath); err != nil {
+		t.Fatalf("garbageCollect returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dbPath, "workflows", "ci.yml", blobsDir, strings.Repeat("0", 64))); !os.IsNotExist(err) {
+		t.Fatalf("expected the unused version to be removed, got err=%v", err)
+	}
+	if err := upgradeDB(dbPath, layoutV2, ""); err == nil {
+		t.Fatalf("expected an error upgrading a database already at layout 2")
+	}
+}
+
+// TestStoreContentDisabled changes whether contents are stored, so it does not run in parallel.
+func TestStoreContentDisabled(t *testing.T) {
+	previous := storeContent
+	defer func() { storeContent = previous }()
+	storeContent = false
+
+	dbPath := t.TempDir()
+	actionPath := filepath.Join(dbPath, "workflows", "build.yml")
+	content := "on: push\njobs: {}\n"
+	hash := computeHash([]byte(content))
+	if actionVersionExists(dbPath, "build.yml", hash) {
+		t.Fatalf("expected a version never indexed to be new")
+	}
+	if err := updateActionIndex(dbPath, "build.yml", "repo-a", "build.yml", hash, computeBlobSHA([]byte(content))); err != nil {
+		t.Fatalf("updateActionIndex returned error: %v", err)
+	}
+	if err := storeActionVersion(dbPath, "build.yml", hash, content); err != nil {
+		t.Fatalf("storeActionVersion returned error: %v", err)
+	}
+
+	if _, err := os.Stat(versionPath(actionPath, hash)); !os.IsNotExist(err) {
+		t.Fatalf("expected no stored version, got %v", err)
+	}
+	if !actionVersionExists(dbPath, "build.yml", hash) {
+		t.Fatalf("expected an indexed version to be known without its content")
+	}
+	if got := versionReference(actionPath, hash); got != hash {
+		t.Fatalf("expected a bare hash for a version that is not stored, got %q", got)
+	}
+
+	// The run that fetched the content still covers it in its reports
+	var walked []string
+	if err := walkIndexedWorkflows(dbPath, func(fileName, repoName, walkedContent string) {
+		if walkedContent == content {
+			walked = append(walked, repoName+"/"+fileName)
+		}
+	}); err != nil {
+		t.Fatalf("walkIndexedWorkflows returned error: %v", err)
+	}
+	if strings.Join(walked, ",") != "repo-a/build.yml" {
+		t.Fatalf("expected the unstored workflow to be walked, got %v", walked)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Database Lock
+// ------------------------
+
+// dbLockFile is the lock a run holds in the database root while it scans, so that several schedulers
+// sharing one database do not scan at the same time.
+const dbLockFile = "lock.yaml"
+
+// errDBLocked is returned when another run holds an unexpired lock on the database.
+var errDBLocked = errors.New("database is locked by another run")
+
+// DBLock is the content of lock.yaml.
+type DBLock struct {
+	Holder       string    `yaml:"holder"`
+	Organization string    `yaml:"organization,omitempty"`
+	Acquired     time.Time `yaml:"acquired"`
+	Expires      time.Time `yaml:"expires"`
+	branch       string    // Branch of a cloned database the lock was pushed to
+}
+
+// newLockHolder names the process taking a lock by its host and process ID.
+func newLockHolder() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// scheduleJitter returns a random delay below max, spreading the starts of scheduled runs so that they do
+// not use the API at the same moment.
+func scheduleJitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return rand.N(max)
+}
+
+// parseDBLock reads a lock, returning nil when there is none.
+func parseDBLock(data []byte) (*DBLock, error) {
+	var lock DBLock
+	if err := yaml.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", dbLockFile, err)
+	}
+	if lock.Holder == "" {
+		return nil, nil
+	}
+	return &lock, nil
+}
+
+// readDBLock reads the lock in a database directory, returning nil when there is none.
+func readDBLock(dir string) (*DBLock, error) {
+	data, err := os.ReadFile(filepath.Join(dir, dbLockFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return parseDBLock(data)
+}
+
+// heldBy returns errDBLocked when the lock is held by another holder and has not expired.
+func (l *DBLock) heldBy(holder string, now time.Time) error {
+	if l == nil || l.Holder == holder || !now.Before(l.Expires) {
+		return nil
+	}
+	return fmt.Errorf("%w: held by %s since %s until %s", errDBLocked, l.Holder, formatReportTime(l.Acquired), formatReportTime(l.Expires))
+}
+
+// Lock takes the lock of the database for ttl, taking over a lock that has expired. In a cloned database
+// the lock is committed and pushed, so a run that pushed its own lock first wins; a local database is
+// locked by creating lock.yaml.
+func (c *DBCheckout) Lock(holder, org string, ttl time.Duration, now time.Time) (*DBLock, error) {
+	existing, err := readDBLock(c.Dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := existing.heldBy(holder, now); err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.Holder != holder {
+		logWarnf("Taking over the database lock of %s, which expired at %s", existing.Holder, formatReportTime(existing.Expires))
+	}
+
+	lock := &DBLock{Holder: holder, Organization: org, Acquired: now, Expires: now.Add(ttl)}
+	data, err := yaml.Marshal(lock)
+	if err != nil {
+		return nil, err
+	}
+	lockPath := filepath.Join(c.Dir, dbLockFile)
+	if c.URL == "" {
+		// Without a lock to take over, creating the file fails when another run created it first
+		flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if existing == nil {
+			flags |= os.O_EXCL
+		}
+		file, err := os.OpenFile(lockPath, flags, 0644)
+		if err != nil {
+			if os.IsExist(err) {
+				return nil, fmt.Errorf("%w: %s was created by another run", errDBLocked, dbLockFile)
+			}
+			return nil, err
+		}
+		defer file.Close()
+		if _, err := file.Write(data); err != nil {
+			return nil, err
+		}
+		logInfof("Locked database until %s", formatReportTime(lock.Expires))
+		return lock, nil
+	}
+
+	branch, err := c.gitOutput("-C", c.Dir, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	lock.branch = strings.TrimSpace(branch)
+	if err := os.WriteFile(lockPath, data, 0644); err != nil {
+		return nil, err
+	}
+	if err := c.git("-C", c.Dir, "add", dbLockFile); err != nil {
+		return nil, err
+	}
+	if err := c.git(append(c.commitIdentity(), "commit", "-q", "-m", fmt.Sprintf("Lock database for %s run", org))...); err != nil {
+		return nil, err
+	}
+	if err := c.git("-C", c.Dir, "push", "-q", "origin", "HEAD"); err != nil {
+		// A rejected push means the branch moved; it is only a lost race when the new tip holds a lock
+		if fetchErr := c.git("-C", c.Dir, "fetch", "-q", "--depth", "1", "origin", lock.branch); fetchErr == nil {
+			if remote, _ := c.gitOutput("-C", c.Dir, "show", "FETCH_HEAD:"+dbLockFile); remote != "" {
+				if remoteLock, _ := parseDBLock([]byte(remote)); remoteLock.heldBy(holder, now) != nil {
+					return nil, remoteLock.heldBy(holder, now)
+				}
+			}
+		}
+		return nil, err
+	}
+	logInfof("Locked database '%s' until %s", c.URL, formatReportTime(lock.Expires))
+	return lock, nil
+}
+
+// Unlock releases a lock taken by Lock. A lock another run has taken over since it expired is left alone.
+// In a cloned database the removal is committed and pushed to the branch the lock was pushed to.
+func (c *DBCheckout) Unlock(lock *DBLock) error {
+	if c.URL != "" {
+		current, err := c.gitOutput("-C", c.Dir, "rev-parse", "--abbrev-ref", "HEAD")
+		if err != nil {
+			return err
+		}
+		// Review mode leaves the clone on the branch of its pull request
+		if strings.TrimSpace(current) != lock.branch {
+			if err := c.git("-C", c.Dir, "checkout", "-q", lock.branch); err != nil {
+				return err
+			}
+		}
+	}
+
+	existing, err := readDBLock(c.Dir)
+	if err != nil {
+		return err
+	}
+	if existing == nil || existing.Holder != lock.Holder {
+		logWarnf("The database lock was taken over by another run; leaving it in place")
+		return nil
+	}
+	if c.URL == "" {
+		if err := os.Remove(filepath.Join(c.Dir, dbLockFile)); err != nil {
+			return err
+		}
+		logInfof("Unlocked database")
+		return nil
+	}
+
+	if err := c.git("-C", c.Dir, "rm", "-q", dbLockFile); err != nil {
+		return err
+	}
+	if err := c.git(append(c.commitIdentity(), "commit", "-q", "-m", fmt.Sprintf("Unlock database after %s run", lock.Organization))...); err != nil {
+		return err
+	}
+	if err := c.git("-C", c.Dir, "push", "-q", "origin", "HEAD"); err != nil {
+		return err
+	}
+	logInfof("Unlocked database '%s'", c.URL)
+	return nil
+}
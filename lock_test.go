@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockLocalDatabase(t *testing.T) {
+	t.Parallel()
+
+	checkout := &DBCheckout{Dir: t.TempDir()}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	lock, err := checkout.Lock("runner-a", "example-org", time.Hour, now)
+	if err != nil {
+		t.Fatalf("Lock returned error: %v", err)
+	}
+	if _, err := checkout.Lock("runner-b", "example-org", time.Hour, now.Add(30*time.Minute)); !errors.Is(err, errDBLocked) {
+		t.Fatalf("expected errDBLocked while the lock is held, got %v", err)
+	}
+
+	// An expired lock is taken over, and the first holder then leaves it in place
+	if _, err := checkout.Lock("runner-b", "example-org", time.Hour, now.Add(2*time.Hour)); err != nil {
+		t.Fatalf("expected to take over the expired lock, got %v", err)
+	}
+	if err := checkout.Unlock(lock); err != nil {
+		t.Fatalf("Unlock returned error: %v", err)
+	}
+	current, err := readDBLock(checkout.Dir)
+	if err != nil || current == nil || current.Holder != "runner-b" {
+		t.Fatalf("expected runner-b to keep the lock, got %+v, %v", current, err)
+	}
+
+	if err := checkout.Unlock(&DBLock{Holder: "runner-b"}); err != nil {
+		t.Fatalf("Unlock returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(checkout.Dir, dbLockFile)); !os.IsNotExist(err) {
+		t.Fatalf("expected lock.yaml to be removed, got %v", err)
+	}
+}
+
+func TestLockClonedDatabase(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	remote := filepath.Join(t.TempDir(), "db.git")
+	if out, err := exec.Command("git", "init", "-q", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v: %s", err, out)
+	}
+	url := "file://" + remote
+	seed, err := openDB(url, "")
+	if err != nil {
+		t.Fatalf("openDB returned error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(seed.Dir, "repositories.yaml"), []byte("organization: example-org\n"), 0644); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	if err := seed.Publish("Update example-org index"); err != nil {
+		t.Fatalf("Publish returned error: %v", err)
+	}
+	seed.Close()
+
+	// Two runs clone the database before either has locked it; the second push loses the race
+	first, err := openDB(url, "")
+	if err != nil {
+		t.Fatalf("openDB returned error: %v", err)
+	}
+	defer first.Close()
+	second, err := openDB(url, "")
+	if err != nil {
+		t.Fatalf("openDB returned error: %v", err)
+	}
+	defer second.Close()
+
+	now := time.Now()
+	lock, err := first.Lock("runner-a", "example-org", time.Hour, now)
+	if err != nil {
+		t.Fatalf("Lock returned error: %v", err)
+	}
+	if _, err := second.Lock("runner-b", "example-org", time.Hour, now); !errors.Is(err, errDBLocked) {
+		t.Fatalf("expected errDBLocked after losing the race, got %v", err)
+	}
+
+	if err := first.Unlock(lock); err != nil {
+		t.Fatalf("Unlock returned error: %v", err)
+	}
+	third, err := openDB(url, "")
+	if err != nil {
+		t.Fatalf("openDB returned error: %v", err)
+	}
+	defer third.Close()
+	if _, err := third.Lock("runner-b", "example-org", time.Hour, now); err != nil {
+		t.Fatalf("expected the released lock to be taken, got %v", err)
+	}
+}
+
+func TestScheduleJitter(t *testing.T) {
+	t.Parallel()
+
+	if delay := scheduleJitter(0); delay != 0 {
+		t.Fatalf("expected no delay without jitter, got %v", delay)
+	}
+	for range 100 {
+		if delay := scheduleJitter(time.Minute); delay < 0 || delay >= time.Minute {
+			t.Fatalf("expected a delay below a minute, got %v", delay)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ------------------------
+// Section: Logging
+// ------------------------
+
+// logLevel is the least severe level logged: info by default, debug with -v, and warnings with -q.
+var logLevel = new(slog.LevelVar)
+
+// logger receives the log of every command, in the -log-format format.
+var logger = slog.New(newPlainHandler(logLevel))
+
+// stdoutWriter writes to os.Stdout as it is at each write, so that the log follows useJSONOutput and the
+// redaction filter, which replace os.Stdout. Messages are written above the progress line of a scan.
+type stdoutWriter struct{}
+
+func (stdoutWriter) Write(p []byte) (int, error) {
+	if progress := activeProgress.Load(); progress != nil {
+		// Written past the redaction filter, which would forward the message after the line is redrawn
+		if _, err := progress.writeAbove(unredactedFile(os.Stdout), []byte(redactSecrets(string(p)))); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	return os.Stdout.Write(p)
+}
+
+// plainHandler writes each message on a line of its own, followed by its attributes as key=value, which
+// is the text log format.
+type plainHandler struct {
+	level slog.Leveler
+	attrs []slog.Attr
+	mu    *sync.Mutex
+	out   io.Writer
+}
+
+// newPlainHandler creates a text handler writing to standard output.
+func newPlainHandler(level slog.Leveler) *plainHandler {
+	return &plainHandler{level: level, mu: &sync.Mutex{}, out: stdoutWriter{}}
+}
+
+func (h *plainHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *plainHandler) Handle(_ context.Context, record slog.Record) error {
+	var line strings.Builder
+	line.WriteString(record.Message)
+	appendAttr := func(attr slog.Attr) bool {
+		fmt.Fprintf(&line, " %s=%v", attr.Key, attr.Value)
+		return true
+	}
+	for _, attr := range h.attrs {
+		appendAttr(attr)
+	}
+	record.Attrs(appendAttr)
+	line.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.out, line.String())
+	return err
+}
+
+func (h *plainHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(append([]slog.Attr(nil), h.attrs...), attrs...)
+	return &clone
+}
+
+// WithGroup returns the handler unchanged, since the text format does not nest attributes.
+func (h *plainHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// LogOptions holds the logging flags every command accepts.
+type LogOptions struct {
+	verbose *bool
+	quiet   *bool
+	format  *string
+}
+
+// addLogFlags adds -v, -q, and -log-format to the flags of a command.
+func addLogFlags(fs *flag.FlagSet) *LogOptions {
+	return &LogOptions{
+		verbose: fs.Bool("v", false, "Log every step, such as each file fetched, stored, or skipped"),
+		quiet:   fs.Bool("q", false, "Log only warnings and errors"),
+		format:  fs.String("log-format", formatText, "Log format: text, or json for one JSON object per line with its time and level"),
+	}
+}
+
+// apply configures the logger from the logging flags.
+func (o *LogOptions) apply() error {
+	if *o.verbose && *o.quiet {
+		return fmt.Errorf("-v cannot be combined with -q")
+	}
+	if err := checkFormat(*o.format, formatText, formatJSON); err != nil {
+		return fmt.Errorf("unknown log format '%s'", *o.format)
+	}
+	configureLogger(*o.format, *o.verbose, *o.quiet)
+	return nil
+}
+
+// configureLogger sets the format and level of the log.
+func configureLogger(format string, verbose, quiet bool) {
+	switch {
+	case verbose:
+		logLevel.Set(slog.LevelDebug)
+	case quiet:
+		logLevel.Set(slog.LevelWarn)
+	default:
+		logLevel.Set(slog.LevelInfo)
+	}
+	if format == formatJSON {
+		logger = slog.New(slog.NewJSONHandler(stdoutWriter{}, &slog.HandlerOptions{Level: logLevel}))
+		return
+	}
+	logger = slog.New(newPlainHandler(logLevel))
+}
+
+// logf logs a message at a level, formatting it only when the level is logged.
+func logf(level slog.Level, format string, args ...any) {
+	ctx := context.Background()
+	if logger.Enabled(ctx, level) {
+		logger.Log(ctx, level, fmt.Sprintf(format, args...))
+	}
+}
+
+// logDebugf logs a step only shown with -v, such as a file being fetched or stored.
+func logDebugf(format string, args ...any) {
+	logf(slog.LevelDebug, format, args...)
+}
+
+// logInfof logs the progress of a command.
+func logInfof(format string, args ...any) {
+	logf(slog.LevelInfo, format, args...)
+}
+
+// logWarnf logs a problem the command works around, such as a repository queued for retry.
+func logWarnf(format string, args ...any) {
+	logf(slog.LevelWarn, format, args...)
+}
+
+// logErrorf logs an error, including one that fails the command.
+func logErrorf(format string, args ...any) {
+	logf(slog.LevelError, format, args...)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlainHandler(t *testing.T) {
+	t.Parallel()
+
+	var out strings.Builder
+	handler := newPlainHandler(slog.LevelInfo)
+	handler.out = &out
+	log := slog.New(handler).With("organization", "example-org")
+
+	log.Debug("Storing workflow file 'build.yml'")
+	log.Info("Processing repository: repo-a", "attempt", 2)
+	log.Error("Failed to publish database")
+
+	want := "Processing repository: repo-a organization=example-org attempt=2\nFailed to publish database organization=example-org\n"
+	if out.String() != want {
+		t.Fatalf("unexpected log:\n%s", out.String())
+	}
+}
+
+// Not parallel: configures the package logger and swaps os.Stdout.
+func TestLogOptions(t *testing.T) {
+	defer configureLogger(formatText, false, false)
+	stdout := os.Stdout
+	defer func() { os.Stdout = stdout }()
+
+	apply := func(args ...string) error {
+		t.Helper()
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		options := addLogFlags(fs)
+		if err := fs.Parse(args); err != nil {
+			t.Fatalf("Parse returned error: %v", err)
+		}
+		return options.apply()
+	}
+
+	if err := apply("-v", "-q"); err == nil {
+		t.Fatal("expected -v and -q to be rejected together")
+	}
+	if err := apply("-log-format", "xml"); err == nil {
+		t.Fatal("expected an unknown log format to be rejected")
+	}
+
+	logPath := filepath.Join(t.TempDir(), "log")
+	file, err := os.Create(logPath)
+	if err != nil {
+		t.Fatalf("failed to create log file: %v", err)
+	}
+	defer file.Close()
+	os.Stdout = file
+
+	if err := apply("-q", "-log-format", "json"); err != nil {
+		t.Fatalf("apply returned error: %v", err)
+	}
+	logInfof("Processing repository: %s", "repo-a")
+	logWarnf("Retry %d failed for repository %s", 1, "repo-a")
+	if err := apply("-v"); err != nil {
+		t.Fatalf("apply returned error: %v", err)
+	}
+	logDebugf("Storing workflow file '%s'", "build.yml")
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("failed to read log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || lines[1] != "Storing workflow file 'build.yml'" {
+		t.Fatalf("unexpected log:\n%s", data)
+	}
+	var entry struct {
+		Level string `json:"level"`
+		Msg   string `json:"msg"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("expected a JSON log line, got %q: %v", lines[0], err)
+	}
+	if entry.Level != "WARN" || entry.Msg != "Retry 1 failed for repository repo-a" {
+		t.Fatalf("unexpected log entry: %+v", entry)
+	}
+}
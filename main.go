@@ -2,12 +2,16 @@ package main
 
 import (
 	"context"
+	"crypto/sha1"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"runtime"
@@ -15,6 +19,7 @@ import (
 	"slices"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/go-github/v50/github"
@@ -30,11 +35,26 @@ import (
 type RepositoryManifest struct {
 	Organization string   `yaml:"organization"`
 	Repositories []string `yaml:"repositories"`
+	// DefaultBranches records the default branch of each repository, used for status badges
+	DefaultBranches map[string]string `yaml:"default_branches,omitempty"`
+	// Toolchains records the toolchains each repository builds with, inferred from its workflow steps
+	Toolchains map[string][]string `yaml:"toolchains,omitempty"`
 }
 
 // ActionIndex maps repositories to the hash of the workflow file they use.
 type ActionIndex struct {
-	Repositories map[string]string `yaml:"repositories"` // RepoName: Hash
+	Repositories map[string]string `yaml:"repositories"`            // RepoName: Hash
+	Blobs        map[string]string `yaml:"blobs,omitempty"`         // Hash: GitHub blob SHA
+	Filenames    map[string]string `yaml:"filenames,omitempty"`     // RepoName: original file name, when it differs from the logical name
+	Symlinks     map[string]string `yaml:"symlinks,omitempty"`      // RepoName: path the file links to, when it is a symbolic link
+	Reasons      map[string]string `yaml:"reasons,omitempty"`       // RepoName: why the file is recorded under emptyWorkflowHash
+	Encodings    map[string]string `yaml:"encodings,omitempty"`     // RepoName: encoding the file is stored in, when it is not plain UTF-8
+	Directories  map[string]string `yaml:"directories,omitempty"`   // RepoName: directory the file was read from, when it is not .github/workflows
+	EncodedBlobs map[string]string `yaml:"encoded_blobs,omitempty"` // GitHub blob SHA of a file stored in another encoding: hash of its decoded content
+	// Versions records when each workflow version was first and last used; dependabot indexes leave it empty
+	Versions map[string]VersionDates `yaml:"versions,omitempty"` // Hash: dates
+	// Annotations are the comment-based metadata of each repository's workflow, such as its owner
+	Annotations map[string]map[string]string `yaml:"annotations,omitempty"` // RepoName: key: value
 }
 
 // WorkflowFile represents a GitHub Actions workflow file.
@@ -43,13 +63,19 @@ type WorkflowFile struct {
 	FilePath string
 	Content  string
 	Hash     string
+	BlobSHA  string
+	Symlink  string // Path the file links to, when it is a symbolic link
+	Reason   string // Why the file has no content to index, when it is recorded under emptyWorkflowHash
+	Encoding string // Encoding the file is stored in, when it is not UTF-8 without a byte order mark
 }
 
 // DependabotFile represents a dependabot.yml file.
 type DependabotFile struct {
 	RepoName string
+	FilePath string
 	Content  string
 	Hash     string
+	BlobSHA  string
 	Category string
 }
 
@@ -64,12 +90,14 @@ type DotfileFile struct {
 	FilePath string
 	Content  string
 	Hash     string
+	BlobSHA  string
 	Category string
 }
 
 // DotfileIndexEntry maps a repository to a dotfile hash and category.
 type DotfileIndexEntry struct {
 	Hash     string `yaml:"hash"`
+	BlobSHA  string `yaml:"blob_sha,omitempty"`
 	Category string `yaml:"category,omitempty"`
 }
 
@@ -91,10 +119,74 @@ type ActionUsesIndex struct {
 	Actions map[string]map[string][]WorkflowReference // Action -> Version -> []WorkflowReference
 }
 
+// AuditOptions configures a single audit run.
+type AuditOptions struct {
+	Org               string
+	Token             string
+	DBPath            string
+	IncludePublic     bool
+	IncludePrivate    bool
+	Filter            *RepositoryFilter // Repositories selected with -include, -exclude, -skip-archived, and -skip-forks; nil skips archived ones
+	Repositories      []string          // Index only these repositories instead of listing the organization, keeping the stored results of the rest
+	Retries           int
+	Concurrency       int
+	Adaptive          bool
+	Profile           string
+	Analyzers         string          // Comma-separated analyzer selection; empty uses the profile's or runs all
+	AuditLog          bool            // Correlate workflow changes with organization audit-log entries
+	Shard             *ShardSpec      // Scan only this shard of the repositories; nil scans all
+	Installation      bool            // List repositories from the GitHub App installation of the token instead of the organization
+	User              bool            // List the repositories of the user account named by org instead of an organization
+	Incremental       bool            // Skip repositories not pushed to since they were last indexed
+	MaxDuration       time.Duration   // Stop starting repositories after this long, least recently scanned first; zero is unlimited
+	Repair            bool            // Scan only the repositories whose database entries diverged from the state recorded by the last run
+	Resume            bool            // Skip the repositories listed in the checkpoint of a stopped run
+	ExternalConsumers bool            // Search code outside the organization for workflows using its actions and reusable workflows
+	Renovate          bool            // Also index Renovate configuration files
+	DotGitHubAll      bool            // Also snapshot the rest of the .github directory, such as CODEOWNERS
+	Stop              <-chan struct{} // Closed to stop scanning after the repositories in progress; nil never stops
+	OnEvent           func(ScanEvent) // Called for each scan event, never concurrently; nil ignores events
+	Progress          *ScanProgress   // Reports the repositories done, the ETA, and the rate limit; nil reports nothing
+}
+
+// RepositoryFiles holds the files fetched from a single repository before they are indexed.
+type RepositoryFiles struct {
+	Workflows  []WorkflowFile
+	Dependabot *DependabotFile
+	Dotfiles   []DotfileFile
+	// Renovate holds the Renovate config; only fetched with -renovate
+	Renovate *RenovateFile
+	// DotGitHubFiles holds the rest of the .github directory; only fetched with -dotgithub-all
+	DotGitHubFiles []DotGitHubFile
+	// ActionDefinitions holds the action.yml files at the root and under .github/actions
+	ActionDefinitions []ActionDefinitionFile
+	// OrgGitHubFiles holds the workflow templates and profile files; only the .github repository has them
+	OrgGitHubFiles []OrgGitHubFile
+	// Environments holds the deployment environments defined in the repository
+	Environments []EnvironmentSnapshot
+	// DefaultBranch is the repository's default branch
+	DefaultBranch string
+	// TokenDefaults holds the default workflow token settings; nil when they could not be read
+	TokenDefaults *WorkflowTokenDefaults
+}
+
+// ErrorsManifest records repositories that could not be processed during the last run.
+type ErrorsManifest struct {
+	Repositories map[string]RepositoryError `yaml:"repositories"`
+}
+
+// RepositoryError describes why a repository failed to be processed.
+type RepositoryError struct {
+	Error    string   `yaml:"error"`
+	Attempts int      `yaml:"attempts"`
+	Files    []string `yaml:"files,omitempty"` // Files whose content failed verification on every download; their stored versions are kept
+}
+
 // WorkflowReference represents a reference to a workflow file that uses an action.
 type WorkflowReference struct {
 	RepoName string
 	FilePath string
+	Via      []string // Composite actions through which the action is used, outermost first; empty for direct uses
 }
 
 // ------------------------
@@ -102,16 +194,23 @@ type WorkflowReference struct {
 // ------------------------
 
 var (
-	org        string
-	includePub bool
-	includePrv bool
-	token      string
-	dbPath     string
+	org         string
+	includePub  bool
+	includePrv  bool
+	token       string
+	dbPath      string
+	retries     int
+	concurrency int
+	adaptive    bool
+	profile     string
 )
 
 var Version = "dev" // This will be set by the build systems to the release version
 var semverRe = regexp.MustCompile(`^\d+\.\d+\.\d+`)
 
+// retryBackoff is the base delay before retrying failed repositories; it grows with each attempt.
+var retryBackoff = 10 * time.Second
+
 // ------------------------
 // Section: Main Function and CLI Setup
 // ------------------------
@@ -126,40 +225,537 @@ func main() {
 		}
 	}
 
-	// Define CLI flags using the flag package
-	flag.StringVar(&org, "org", "", "GitHub Organization name (required)")
-	flag.BoolVar(&includePub, "public", true, "Include public repositories; boolean")
-	flag.BoolVar(&includePrv, "private", false, "Include private repositories; boolean")
-	flag.StringVar(&token, "token", "", "GitHub API token (required)")
-	flag.StringVar(&dbPath, "db", "./db", "Path to the database repository")
+	code := runCommand(os.Args[1:])
+	flushRedactedOutput()
+	os.Exit(code)
+}
 
-	showVersion := flag.Bool("version", false, "Print version")
+// runCommand dispatches to a subcommand and returns the process exit code. A run without a subcommand
+// indexes the organization as before subcommands existed.
+func runCommand(args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "index":
+			return runIndexCommand(args[1:])
+		case "gc":
+			return runGCCommand(args[1:])
+		case "query":
+			return runQueryCommand(args[1:])
+		case "serve":
+			return runServeCommand(args[1:])
+		case "migrate":
+			return runMigrateCommand(args[1:])
+		case "self-update":
+			return runSelfUpdateCommand(args[1:])
+		case "preview":
+			return runPreviewCommand(args[1:])
+		case "report":
+			return runReportCommand(args[1:])
+		case "modernize":
+			return runModernizeCommand(args[1:])
+		case "freeze":
+			return runFreezeCommand(args[1:])
+		case "analyzers":
+			return runAnalyzersCommand(args[1:])
+		case "approve", "reject":
+			return runReviewDecisionCommand(args[0], args[1:])
+		case "policy":
+			return runPolicyCommand(args[1:])
+		case "merge":
+			return runMergeCommand(args[1:])
+		case "compare-org":
+			return runCompareOrgCommand(args[1:])
+		case "verify-report":
+			return runVerifyReportCommand(args[1:])
+		case "upgrade-db":
+			return runUpgradeDBCommand(args[1:])
+		case "diff-version":
+			return runDiffVersionCommand(args[1:])
+		}
+	}
+	return runIndexCommand(args)
+}
 
-	flag.Parse()
+// runIndexCommand scans the organization, updates the database, and regenerates every report.
+func runIndexCommand(args []string) int {
+	fs := flag.NewFlagSet("index", flag.ContinueOnError)
+	var orgs organizationList
+	fs.Var(&orgs, "org", "GitHub Organization name (required unless -user is given); repeat it, or separate names with commas, to index several organizations into <db>/<organization>")
+	enterprise := fs.String("enterprise", "", "GitHub Enterprise account slug whose organizations are listed and each indexed into <db>/<organization>, with a combined usage report")
+	user := fs.String("user", "", "GitHub user account to scan instead of an organization, listing the repositories it owns; private ones are listed when the token belongs to the account")
+	fs.BoolVar(&includePub, "public", true, "Include public repositories; boolean")
+	fs.BoolVar(&includePrv, "private", false, "Include private repositories; boolean")
+	include := fs.String("include", "", "Comma-separated globs, or /regular expressions/, of the repository names to scan, e.g. 'service-*,/^lib-/'; others are skipped")
+	exclude := fs.String("exclude", "", "Comma-separated globs, or /regular expressions/, of repository names to skip, e.g. 'archived-*,sandbox-*'")
+	skipArchived := fs.Bool("skip-archived", true, "Skip archived repositories, which cannot change; -skip-archived=false indexes them too")
+	skipForks := fs.Bool("skip-forks", false, "Skip forked repositories, whose workflows duplicate their upstream's")
+	var selectedRepos repositoryList
+	fs.Var(&selectedRepos, "repo", "Index only this repository, as owner/name or name, keeping the stored results of the rest; repeat it, or separate names with commas, for several")
+	repoFile := fs.String("repo-file", "", "File of repositories to index, one owner/name or name per line, as with -repo")
+	var tokens tokenList
+	fs.Var(&tokens, "token", "GitHub API token (required); defaults to the DOTGITHUBINDEXER_TOKEN or GITHUB_TOKEN environment variable. Repeat it, or separate tokens with commas, to rotate among several tokens")
+	tokenFile := fs.String("token-file", "", "File of further GitHub API tokens to rotate among, one per line")
+	rateLimit := fs.Int("rate-limit", 0, "Send at most this many GitHub API requests an hour, leaving the rest of a quota shared with other tools; 0 does not limit the rate")
+	rateLimitRedis := fs.String("rate-limit-redis", "", "Redis server, as host:port or redis://[:password@]host:port[/db], through which -rate-limit is shared with the other processes and tools counting requests under -rate-limit-key")
+	rateLimitKey := fs.String("rate-limit-key", "dotgithubindexer", "Key prefix of the -rate-limit-redis request counters; give every consumer of one quota the same key")
+	tokenThreshold := fs.Int("token-threshold", 500, "Switch to the token with the most requests left when the current one has fewer than this many; applies when there are several tokens")
+	baseURL := fs.String("base-url", "", "GitHub Enterprise Server API URL, e.g. https://github.example.com/api/v3; defaults to github.com")
+	uploadURL := fs.String("upload-url", "", "GitHub Enterprise Server upload URL; defaults to -base-url")
+	fs.StringVar(&dbPath, "db", "./db", "Path to the database repository, or a git URL to clone, update, and push")
+	fs.IntVar(&retries, "retries", 2, "Number of times to retry repositories that failed during the run")
+	fs.IntVar(&concurrency, "concurrency", 1, "Maximum number of repositories to scan in parallel")
+	fs.BoolVar(&adaptive, "adaptive", false, "Tune concurrency and request pacing automatically from rate limit headroom and latency")
+	fs.StringVar(&profile, "profile", "", "Scan profile from scope.yaml used to skip inactive or trivial repositories")
+	shard := fs.String("shard", "", "Scan only shard i of n, e.g. 2/4; combine the shard databases with 'merge'")
+	auditLog := fs.Bool("audit-log", false, "Correlate workflow changes with organization audit-log entries; requires GitHub Enterprise Cloud")
+	analyzerSelection := fs.String("analyzers", "", "Comma-separated analyzers to run, or to skip when prefixed with '-'; see 'analyzers list'")
+	review := fs.Bool("review", false, "Push database changes to a new branch and open a pull request instead of pushing them directly; requires a git URL -db")
+	commitNotes := fs.Bool("commit-notes", false, "Attach a JSON summary of each database commit as a git note under refs/notes/dotgithubindexer")
+	gitCommit := fs.Bool("git-commit", false, "Commit the changes of a local -db to the git working tree it is in, with a message summarizing the run")
+	gitPush := fs.Bool("git-push", false, "Push the -git-commit commit to the upstream of the current branch; implies -git-commit")
+	incremental := fs.Bool("incremental", false, "Skip repositories not pushed to since they were last indexed, reusing their stored results")
+	fs.BoolVar(&storeContent, "store-content", true, "Store the content of every indexed file version; with -store-content=false only indexes, hashes, and extracted metadata are kept")
+	maxDuration := fs.Duration("max-duration", 0, "Stop starting repositories after this long, e.g. 15m, scanning the least recently scanned first so that successive runs cover every repository")
+	resume := fs.Bool("resume", false, "Continue a run stopped by SIGINT or SIGTERM, skipping the repositories recorded in its checkpoint.yaml")
+	repair := fs.Bool("repair", false, "Scan only the repositories whose database entries changed outside dotgithubindexer since the last run, such as by a force-push or hand edit, keeping the stored results of the rest")
+	externalConsumers := fs.Bool("external-consumers", false, "Search code outside the organization for workflows using its actions and reusable workflows; slow, as code search allows ten requests a minute")
+	renovate := fs.Bool("renovate", false, "Also index Renovate configuration files such as renovate.json, alongside dependabot.yml")
+	dotgithubAll := fs.Bool("dotgithub-all", false, "Also snapshot the rest of the .github directory, such as CODEOWNERS, SECURITY.md, ISSUE_TEMPLATE, and PULL_REQUEST_TEMPLATE")
+	installation := fs.Bool("installation", false, "List repositories from the GitHub App installation the token belongs to instead of the organization, for installations on selected repositories")
+	fs.StringVar(&dbFormat, "format", formatYAML, "Database format: yaml, or json to also write a JSON copy of every generated YAML file")
+	layout := fs.Int("layout", 0, "Layout of a new database: 1, or 2 to store file versions in blobs folders; an existing database keeps its layout until 'upgrade-db'")
+	eventsPath := fs.String("events", "", "File to write scan events to as lines of JSON, or '-' for standard output with the log on standard error")
+	signKey := fs.String("sign-key", "", "Sign the scan summary and findings with this Ed25519 or ECDSA private key in PEM format; see 'verify-report'")
+	signKeyless := fs.Bool("sign-keyless", false, "Sign the scan summary and findings with cosign keyless signing, using the CI's OIDC identity; requires cosign")
+	paths := fs.String("paths", "", "Comma-separated directories to scan for workflow files, e.g. .github/workflows,ci; defaults to .github/workflows, or workflows when a repository has none")
+	fs.StringVar(&workflowFetchMode, "fetch", fetchModeGraphQL, "How workflow directories are listed: graphql, with their content in one request, or tree, with the Git trees API, downloading only content not already in the database")
+	httpCacheDir := fs.String("http-cache", "", "Directory to cache GitHub API responses in across runs; cached responses are revalidated with ETags, and unchanged ones do not count against the rate limit")
+	lockDB := fs.Bool("lock", false, "Hold a lock in the database's lock.yaml while scanning, skipping the run when another run holds it; for several schedulers sharing one database")
+	lockTTL := fs.Duration("lock-ttl", 2*time.Hour, "How long a -lock is held before another run may take it over; set it above the longest run")
+	jitter := fs.Duration("jitter", 0, "Wait a random time below this, e.g. 5m, before starting, so that scheduled runs do not use the API at the same moment")
+	progressInterval := fs.Duration("progress-interval", time.Minute, "How often to log a progress summary when standard error is not a terminal, where a progress line is drawn instead; 0 logs none")
+	failOn := fs.String("fail-on", "", "Comma-separated conditions that make the run exit with code 2 after publishing the database: unpinned, third-party, violations, or violations:<severity>")
+
+	showVersion := fs.Bool("version", false, "Print version")
+	timezone := fs.String("timezone", "UTC", "IANA timezone used for dates and times in reports, e.g. America/New_York")
+	checkUpdate := fs.Bool("check-update", !isCIEnvironment(), "Check GitHub releases for a newer version at startup; disabled by default in CI")
+
+	logFlags := addLogFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	if err := applyEnvironmentDefaults(fs); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := logFlags.apply(); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
 
 	if *showVersion {
 		fmt.Printf("dotgithubindexer version %s\n", buildVersionOutput(Version))
-		return
+		return 0
+	}
+
+	if *tokenFile != "" {
+		fileTokens, err := readTokenFile(*tokenFile)
+		if err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+		for _, fileToken := range fileTokens {
+			redactSecret(fileToken)
+		}
+		tokens = append(tokens, fileTokens...)
+	}
+	if len(tokens) > 0 {
+		token = tokens[0]
+	}
+
+	if len(orgs) > 0 {
+		org = orgs[0]
+	}
+
+	// A user account takes the place of the organization
+	if *user != "" {
+		if org != "" {
+			logErrorf("-user cannot be combined with -org")
+			return 1
+		}
+		org = *user
+	}
+
+	// An enterprise takes the place of the organizations, which are listed once the server is known
+	if *enterprise != "" && org != "" {
+		logErrorf("-enterprise cannot be combined with -org or -user")
+		return 1
 	}
 
 	// Check required flags
-	if org == "" || token == "" {
-		fmt.Println("Usage: dotgithubindexer -org <organization> -token <token> [options]")
-		flag.PrintDefaults()
-		os.Exit(1)
+	if (org == "" && *enterprise == "") || token == "" {
+		printUsage()
+		fs.PrintDefaults()
+		return 1
+	}
+
+	if err := setReportTimezone(*timezone); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+
+	if err := setGitHubServer(*baseURL, *uploadURL); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+
+	if *enterprise != "" {
+		enterpriseOrgs, err := listEnterpriseOrganizations(getGitHubClient(token), *enterprise)
+		if err != nil {
+			logErrorf("Failed to list the organizations of enterprise '%s': %v", *enterprise, err)
+			return 1
+		}
+		if len(enterpriseOrgs) == 0 {
+			logErrorf("Enterprise '%s' has no organizations the token can see", *enterprise)
+			return 1
+		}
+		logInfof("Enterprise '%s' has %d organizations: %s", *enterprise, len(enterpriseOrgs), strings.Join(enterpriseOrgs, ", "))
+		orgs = enterpriseOrgs
+		org = orgs[0]
+	}
+
+	if err := checkFormat(dbFormat, formatYAML, formatJSON); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+
+	failOnConditions, err := parseFailOn(*failOn)
+	if err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+
+	workflowPaths, err = parseWorkflowPaths(*paths)
+	if err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+
+	if *repoFile != "" {
+		fileRepos, err := readRepositoryFile(*repoFile)
+		if err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+		selectedRepos = append(selectedRepos, fileRepos...)
+	}
+	repoSelection, err := parseRepositorySelection(org, selectedRepos)
+	if err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+
+	// Skipped repositories keep the results of their stored content, which slim databases do not have
+	if !storeContent && (*incremental || *maxDuration > 0 || *repair || *resume || len(repoSelection) > 0) {
+		logErrorf("-incremental, -max-duration, -repair, -resume, -repo, and -repo-file cannot be combined with -store-content=false")
+		return 1
+	}
+	if len(repoSelection) > 0 && (*incremental || *maxDuration > 0 || *repair || *resume || *shard != "") {
+		logErrorf("-repo and -repo-file cannot be combined with -incremental, -max-duration, -repair, -resume, or -shard")
+		return 1
+	}
+	if *repair && (*incremental || *maxDuration > 0) {
+		logErrorf("-repair cannot be combined with -incremental or -max-duration")
+		return 1
+	}
+	if *user != "" && *auditLog {
+		logErrorf("-audit-log cannot be combined with -user, since user accounts have no audit log")
+		return 1
+	}
+	if (len(orgs) > 1 || *enterprise != "") && (len(repoSelection) > 0 || *installation || *resume || *shard != "") {
+		logErrorf("Several -org values and -enterprise cannot be combined with -repo, -repo-file, -installation, -resume, or -shard")
+		return 1
+	}
+
+	if *httpCacheDir != "" {
+		httpResponseCache, err = newHTTPCache(*httpCacheDir)
+		if err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+	}
+
+	// The pool sets the token of each request before the HTTP cache keys the request by it
+	if len(tokens) > 1 {
+		var base http.RoundTripper = http.DefaultTransport
+		if httpResponseCache != nil {
+			base = httpResponseCache
+		}
+		githubTokens = newTokenPool(tokens, *tokenThreshold, base)
+		logInfof("Rotating among %d GitHub tokens", len(tokens))
+	}
+
+	githubRateLimiter, err = newRateLimiter(*rateLimit, *rateLimitRedis, *rateLimitKey)
+	if err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if githubRateLimiter != nil {
+		logInfof("Limiting GitHub API requests to %d an hour", *rateLimit)
+	}
+
+	if workflowFetchMode != fetchModeGraphQL && workflowFetchMode != fetchModeTree {
+		logErrorf("unknown fetch mode '%s'", workflowFetchMode)
+		return 1
+	}
+
+	if err := checkSigningOptions(*signKey, *signKeyless); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+
+	repoFilter, err := newRepositoryFilter(*include, *exclude, *skipArchived, *skipForks)
+	if err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+
+	var shardSpec *ShardSpec
+	if *shard != "" {
+		spec, err := parseShardSpec(*shard)
+		if err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+		// Shards are combined by 'merge', so they must not push to a shared remote database
+		if isGitURL(dbPath) {
+			logErrorf("-shard requires a local -db path; run 'merge' to combine the shards into the remote database")
+			return 1
+		}
+		shardSpec = &spec
+		if *incremental {
+			logErrorf("-incremental cannot be combined with -shard")
+			return 1
+		}
+		if *maxDuration > 0 {
+			logErrorf("-max-duration cannot be combined with -shard")
+			return 1
+		}
+		if *repair {
+			logErrorf("-repair cannot be combined with -shard")
+			return 1
+		}
+		if !storeContent {
+			logErrorf("-store-content=false cannot be combined with -shard, since 'merge' rebuilds the reports from stored content")
+			return 1
+		}
+		if len(failOnConditions) > 0 {
+			logErrorf("-fail-on cannot be combined with -shard; pass it to 'merge' instead")
+			return 1
+		}
+		if *signKey != "" || *signKeyless {
+			logErrorf("-sign-key and -sign-keyless cannot be combined with -shard; pass them to 'merge' instead")
+			return 1
+		}
+	}
+
+	if (*gitCommit || *gitPush) && isGitURL(dbPath) {
+		logErrorf("-git-commit and -git-push apply to a local -db; a git URL -db is always committed and pushed")
+		return 1
+	}
+
+	if err := checkReviewMode(*review, dbPath); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+
+	var onEvent func(ScanEvent)
+	var scanCounter ScanCounter
+	switch *eventsPath {
+	case "":
+	case "-":
+		useJSONOutput()
+		onEvent = newScanEventWriter(resultWriter)
+	default:
+		eventsFile, err := os.Create(*eventsPath)
+		if err != nil {
+			logErrorf("Failed to create events file: %v", err)
+			return 1
+		}
+		defer eventsFile.Close()
+		onEvent = newScanEventWriter(eventsFile)
+	}
+
+	if *checkUpdate {
+		// Releases of this tool are published on github.com, not on an enterprise server
+		updateClient := getGitHubClient(token)
+		if githubBaseURL != "" {
+			updateClient = github.NewClient(nil)
+		}
+		checkForUpdate(updateClient, Version)
+	}
+
+	if *lockTTL <= 0 {
+		logErrorf("-lock-ttl must be positive")
+		return 1
+	}
+	if delay := scheduleJitter(*jitter); delay > 0 {
+		logInfof("Waiting %v before starting (-jitter %v)", delay.Round(time.Second), *jitter)
+		time.Sleep(delay)
+	}
+
+	checkout, err := openDB(dbPath, token)
+	if err != nil {
+		logErrorf("Failed to open database: %v", err)
+		return 1
+	}
+	defer checkout.Close()
+	checkout.Notes = *commitNotes
+	checkout.Scanned = scanCounter.Scanned
+
+	if err := checkWritableDir(checkout.Dir); err != nil {
+		logErrorf("Failed to open database: %v", err)
+		return 1
+	}
+	if *gitCommit || *gitPush {
+		if err := checkout.CommitLocally(*gitPush); err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+	}
+	if *lockDB {
+		lock, err := checkout.Lock(newLockHolder(), org, *lockTTL, time.Now())
+		if errors.Is(err, errDBLocked) {
+			logWarnf("Skipping this run: %v", err)
+			return 0
+		}
+		if err != nil {
+			logErrorf("Failed to lock database: %v", err)
+			return 1
+		}
+		// Deferred after Close, so the lock is released before the clone is removed
+		defer func() {
+			if err := checkout.Unlock(lock); err != nil {
+				logErrorf("Failed to unlock database; it stays locked until %s: %v", formatReportTime(lock.Expires), err)
+			}
+		}()
+	}
+	// A database that already indexes several organizations keeps each in its own folder, even when one is indexed
+	indexedOrgs, err := loadOrganizations(checkout.Dir)
+	if err != nil {
+		logErrorf("Failed to open database: %v", err)
+		return 1
+	}
+	multipleOrgs := len(orgs) > 1 || indexedOrgs != nil || *enterprise != ""
+
+	// With several organizations the layout is selected for each organization's folder
+	if !multipleOrgs {
+		if err := selectLayout(checkout.Dir, *layout); err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
 	}
 
 	// Execute main audit logic
 	startTime := time.Now()
-	fmt.Println("Starting GitHub Actions Audit")
-
-	err := auditGitHubActions(org, token, dbPath, includePub, includePrv)
+	logInfof("Starting GitHub Actions Audit at %s", formatReportTime(startTime))
+
+	opts := AuditOptions{
+		Org:               org,
+		Token:             token,
+		DBPath:            checkout.Dir,
+		IncludePublic:     includePub,
+		IncludePrivate:    includePrv,
+		Filter:            repoFilter,
+		Repositories:      repoSelection,
+		Retries:           retries,
+		Concurrency:       concurrency,
+		Adaptive:          adaptive,
+		Profile:           profile,
+		Analyzers:         *analyzerSelection,
+		AuditLog:          *auditLog,
+		Shard:             shardSpec,
+		Installation:      *installation,
+		User:              *user != "",
+		Incremental:       *incremental,
+		MaxDuration:       *maxDuration,
+		Repair:            *repair,
+		Resume:            *resume,
+		ExternalConsumers: *externalConsumers,
+		Renovate:          *renovate,
+		DotGitHubAll:      *dotgithubAll,
+		Stop:              watchTermination(),
+		OnEvent:           scanCounter.Observe(onEvent),
+		Progress:          newScanProgress(progressTerminal(), *progressInterval),
+	}
+	defer opts.Progress.Stop()
+	if multipleOrgs {
+		names := []string(orgs)
+		if len(names) < 2 {
+			names = []string{org}
+		}
+		return indexOrganizations(checkout, names, opts, MultiOrganizationRun{
+			Enterprise:  *enterprise,
+			Layout:      *layout,
+			SignKey:     *signKey,
+			SignKeyless: *signKeyless,
+			Review:      *review,
+			Token:       token,
+			FailOn:      failOnConditions,
+			StartTime:   startTime,
+			Counter:     &scanCounter,
+		})
+	}
+	// A shard is recorded by the merge of every shard
+	var tracker *RunTracker
+	if shardSpec == nil {
+		if tracker, err = startRunTracker("index", checkout.Dir, &scanCounter); err != nil {
+			logErrorf("Failed to open database: %v", err)
+			return 1
+		}
+	}
+	err = auditGitHubActions(opts)
 	if err != nil {
-		fmt.Printf("Audit failed: %v\n", err)
-		os.Exit(1)
+		logErrorf("Audit failed: %v", err)
+		// Publish the repositories indexed before the run stopped with its checkpoint, so that -resume continues after them
+		if errors.Is(err, errScanStopped) {
+			if tracker != nil {
+				tracker.record(org, RunStopped, -1)
+			}
+			if err := publishDB(checkout, fmt.Sprintf("Checkpoint %s index (%s)", org, formatReportDate(startTime)), *review, token); err != nil {
+				logErrorf("Failed to publish database: %v", err)
+			}
+		}
+		return 1
+	}
+	if tracker != nil {
+		tracker.record(org, RunCompleted, -1)
+	}
+
+	if *signKey != "" || *signKeyless {
+		if err := signReports(checkout.Dir, org, *signKey, *signKeyless); err != nil {
+			logErrorf("Failed to sign reports: %v", err)
+			return 1
+		}
 	}
 
-	fmt.Printf("Audit completed successfully in %v.\n", time.Since(startTime))
+	if err := publishDB(checkout, fmt.Sprintf("Update %s index (%s)", org, formatReportDate(startTime)), *review, token); err != nil {
+		logErrorf("Failed to publish database: %v", err)
+		return 1
+	}
+
+	logInfof("Audit completed successfully at %s in %v.", formatReportTime(time.Now()), time.Since(startTime))
+	return enforcePolicyGate(checkout.Dir, failOnConditions)
+}
+
+// printUsage prints the usage for indexing and lists the other commands.
+func printUsage() {
+	fmt.Println("Usage: dotgithubindexer [index] -org <organization> -token <token> [options]")
+	fmt.Println("       dotgithubindexer <command> [options]")
+	fmt.Println("")
+	fmt.Println("Commands: index, gc, report, query, serve, preview, modernize, freeze, analyzers, policy, approve, reject, merge, compare-org, migrate, upgrade-db, diff-version, verify-report, self-update")
+	fmt.Println("")
 }
 
 func buildVersionOutput(version string) string {
@@ -174,23 +770,53 @@ func buildVersionOutput(version string) string {
 // Section: GitHub Client Setup
 // ------------------------
 
-// getGitHubClient authenticates with GitHub using the provided token.
+// getGitHubClient authenticates with GitHub using the provided token. The client talks to the GitHub
+// Enterprise Server set with -base-url, if any, and to github.com otherwise.
 func getGitHubClient(token string) *github.Client {
 	ctx := context.Background()
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
 	)
-	tc := oauth2.NewClient(ctx, ts)
-	client := github.NewClient(tc)
-	return client
+	if githubTokens != nil {
+		return newGitHubClient(&http.Client{Transport: githubTokens})
+	}
+	if httpResponseCache != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: httpResponseCache})
+	}
+	return newGitHubClient(oauth2.NewClient(ctx, ts))
+}
+
+// newGitHubClient creates a client for the GitHub Enterprise Server set with -base-url, if any, and for
+// github.com otherwise. Its requests are counted for runs.yaml and paced by -rate-limit.
+func newGitHubClient(httpClient *http.Client) *github.Client {
+	if githubRateLimiter != nil {
+		httpClient.Transport = rateLimitedTransport{limiter: githubRateLimiter, base: httpClient.Transport}
+	}
+	httpClient.Transport = countingTransport{base: httpClient.Transport}
+	if githubBaseURL != "" {
+		// setGitHubServer has already validated the URLs
+		client, _ := github.NewEnterpriseClient(githubBaseURL, githubUploadURL, httpClient)
+		return client
+	}
+	return github.NewClient(httpClient)
 }
 
 // ------------------------
 // Section: Fetch Repositories
 // ------------------------
 
-// fetchRepositories retrieves repositories based on visibility options.
-func fetchRepositories(client *github.Client, org string, includePub, includePrv bool) ([]*github.Repository, error) {
+// includeRepository reports whether a listed repository is scanned under the visibility options and the filter.
+func includeRepository(repo *github.Repository, includePub, includePrv bool, filter *RepositoryFilter) bool {
+	if !filter.selects(repo) {
+		return false
+	}
+
+	visibility := repo.GetVisibility()
+	return (includePub && visibility == "public") || (includePrv && visibility == "private")
+}
+
+// fetchRepositories retrieves repositories based on visibility options and the filter.
+func fetchRepositories(client *github.Client, org string, includePub, includePrv bool, filter *RepositoryFilter) ([]*github.Repository, error) {
 	ctx := context.Background()
 	var allRepos []*github.Repository
 	opt := &github.RepositoryListByOrgOptions{
@@ -204,17 +830,7 @@ func fetchRepositories(client *github.Client, org string, includePub, includePrv
 		}
 
 		for _, repo := range repos {
-			// Skip archived repositories
-			if repo.GetArchived() {
-				continue
-			}
-
-			visibility := repo.GetVisibility()
-
-			if includePub && visibility == "public" {
-				allRepos = append(allRepos, repo)
-			}
-			if includePrv && visibility == "private" {
+			if includeRepository(repo, includePub, includePrv, filter) {
 				allRepos = append(allRepos, repo)
 			}
 		}
@@ -225,7 +841,7 @@ func fetchRepositories(client *github.Client, org string, includePub, includePrv
 		opt.Page = resp.NextPage
 
 		// Handle rate limiting
-		if err := checkRateLimit(client); err != nil {
+		if _, err := checkRateLimit(client); err != nil {
 			return nil, err
 		}
 	}
@@ -237,67 +853,142 @@ func fetchRepositories(client *github.Client, org string, includePub, includePrv
 // Section: Fetch Workflow Files
 // ------------------------
 
-// fetchWorkflowFiles retrieves workflow files from a repository.
+// workflowPaths are the directories scanned for workflow files, set with -paths. When empty,
+// .github/workflows is scanned, or workflows in a repository without it.
+var workflowPaths []string
+
+// parseWorkflowPaths parses the comma-separated directories of -paths into repository-relative paths.
+func parseWorkflowPaths(value string) ([]string, error) {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		cleaned := path.Clean(strings.Trim(entry, "/"))
+		if strings.HasPrefix(entry, "/") || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+			return nil, fmt.Errorf("invalid -paths entry '%s': expected a directory inside the repository", entry)
+		}
+		if !seen[cleaned] {
+			seen[cleaned] = true
+			paths = append(paths, cleaned)
+		}
+	}
+	return paths, nil
+}
+
+// fetchWorkflowFiles retrieves workflow files from a repository. The scanned directories are listed in a
+// single request: with GraphQL, which returns the content of their files too, or with -fetch tree through
+// the Git trees API, which leaves only the files whose content is not stored in the database to download.
 func fetchWorkflowFiles(client *github.Client, repo *github.Repository) ([]WorkflowFile, error) {
-	ctx := context.Background()
 	workflows := []WorkflowFile{}
 
 	defaultBranch := getDefaultBranch(repo)
-	fmt.Printf("Default branch for repository '%s' is '%s'\n", repo.GetName(), defaultBranch)
-
-	// Check the .github/workflows directory
-	_, workflowFiles, _, err := client.Repositories.GetContents(ctx, repo.GetOwner().GetLogin(), repo.GetName(), ".github/workflows", &github.RepositoryContentGetOptions{
-		Ref: defaultBranch,
-	})
+	logDebugf("Default branch for repository '%s' is '%s'", repo.GetName(), defaultBranch)
 
+	// Both default directories are listed up front, so the fallback costs no extra request
+	dirs := workflowPaths
+	if len(dirs) == 0 {
+		dirs = []string{".github/workflows", "workflows"}
+	}
+	var listing map[string][]*github.RepositoryContent
+	var contents map[string]string
+	var err error
+	if workflowFetchMode == fetchModeTree {
+		listing, contents, err = listWorkflowTree(client, repo, defaultBranch, dirs)
+	}
+	if listing == nil && err == nil {
+		listing, contents, err = listDirectoryTrees(client, repo, defaultBranch, dirs)
+	}
 	if err != nil {
-		// If '.github/workflows' is not found, try 'workflows' directly under root
-		if isNotFoundError(err) {
-			fmt.Printf("No '.github/workflows' directory found in repository '%s'. Trying 'workflows' directory.\n", repo.GetName())
-			_, workflowFiles, _, err = client.Repositories.GetContents(ctx, repo.GetOwner().GetLogin(), repo.GetName(), "workflows", &github.RepositoryContentGetOptions{
-				Ref: defaultBranch,
-			})
-			if err != nil {
-				// Repository might not have workflows
-				fmt.Printf("No 'workflows' directory found in repository '%s'. Skipping.\n", repo.GetName())
-				return workflows, nil
+		logErrorf("Error accessing workflows directory in repository '%s': %v", repo.GetName(), err)
+		return nil, err
+	}
+
+	var workflowFiles []*github.RepositoryContent
+
+	// Directories given with -paths are all scanned; a missing one is skipped
+	if len(workflowPaths) > 0 {
+		for _, dir := range workflowPaths {
+			files, ok := listing[dir]
+			if !ok {
+				logDebugf("No '%s' directory found in repository '%s'.", dir, repo.GetName())
+				continue
 			}
-		} else {
-			fmt.Printf("Error accessing workflows directory in repository '%s': %v\n", repo.GetName(), err)
-			return nil, err
+			workflowFiles = append(workflowFiles, files...)
+		}
+		return fetchWorkflowContents(client, repo, workflowFiles, contents)
+	}
+
+	// Check the .github/workflows directory, then 'workflows' directly under root
+	if files, ok := listing[".github/workflows"]; ok {
+		workflowFiles = files
+	} else {
+		logDebugf("No '.github/workflows' directory found in repository '%s'. Trying 'workflows' directory.", repo.GetName())
+		files, ok := listing["workflows"]
+		if !ok {
+			// Repository might not have workflows
+			logDebugf("No 'workflows' directory found in repository '%s'. Skipping.", repo.GetName())
+			return workflows, nil
 		}
+		workflowFiles = files
 	}
 
-	if workflowFiles == nil || len(workflowFiles) == 0 {
-		fmt.Printf("No workflow files found in repository '%s'.\n", repo.GetName())
+	return fetchWorkflowContents(client, repo, workflowFiles, contents)
+}
+
+// fetchWorkflowContents retrieves the content of the files among the entries of the scanned directories.
+// Content already fetched and verified, keyed by blob SHA, is used as is; other files are downloaded.
+func fetchWorkflowContents(client *github.Client, repo *github.Repository, workflowFiles []*github.RepositoryContent, fetched map[string]string) ([]WorkflowFile, error) {
+	workflows := []WorkflowFile{}
+	if len(workflowFiles) == 0 {
+		logDebugf("No workflow files found in repository '%s'.", repo.GetName())
 		return workflows, nil
 	}
 
-	// Iterate through the files in the directory
+	// Iterate through the files in the directory. Files that fail verification are collected so that
+	// errors.yaml lists all of them, but any one fails the repository so its stored files are kept.
+	var integrityErrs []error
 	for _, file := range workflowFiles {
-		if file.GetType() == "file" {
-			fmt.Printf("Found workflow file: %s in repository '%s'\n", file.GetPath(), repo.GetName())
-
-			content, err := fetchBlobContent(client, repo.GetOwner().GetLogin(), repo.GetName(), file.GetSHA())
+		if file.GetType() == "symlink" {
+			logDebugf("Found symbolic link: %s in repository '%s'", file.GetPath(), repo.GetName())
+			workflow, err := resolveWorkflowSymlink(client, repo, file)
 			if err != nil {
-				fmt.Printf("Error fetching content for file '%s' in repository '%s': %v\n", file.GetPath(), repo.GetName(), err)
-				continue
+				logErrorf("Error resolving symbolic link '%s' in repository '%s': %v", file.GetPath(), repo.GetName(), err)
+				if len(blobIntegrityFailures(err)) > 0 {
+					integrityErrs = append(integrityErrs, err)
+					continue
+				}
+				return nil, err
 			}
+			workflows = append(workflows, workflow)
+			continue
+		}
+		if file.GetType() == "file" {
+			logDebugf("Found workflow file: %s in repository '%s'", file.GetPath(), repo.GetName())
 
-			if content == "" {
-				fmt.Printf("Empty content for file '%s' in repository '%s'\n", file.GetPath(), repo.GetName())
-				continue
+			content, ok := fetched[file.GetSHA()]
+			if !ok {
+				var err error
+				content, err = fetchBlobContent(client, repo.GetOwner().GetLogin(), repo.GetName(), file.GetSHA())
+				if err != nil {
+					logErrorf("Error fetching content for file '%s' in repository '%s': %v", file.GetPath(), repo.GetName(), err)
+					err = withBlobPath(err, file.GetPath())
+					if len(blobIntegrityFailures(err)) > 0 {
+						integrityErrs = append(integrityErrs, err)
+						continue
+					}
+					return nil, err
+				}
 			}
-			hash := computeHash([]byte(content))
-			fmt.Printf("Hashing file '%s' in repository '%s': %s\n", file.GetPath(), repo.GetName(), hash)
-			workflows = append(workflows, WorkflowFile{
-				RepoName: repo.GetName(),
-				FilePath: file.GetPath(),
-				Content:  content,
-				Hash:     hash,
-			})
+
+			workflows = append(workflows, workflowFileFromContent(repo.GetName(), file.GetPath(), content, file.GetSHA(), ""))
 		}
 	}
+	if len(integrityErrs) > 0 {
+		return nil, errors.Join(integrityErrs...)
+	}
 
 	return workflows, nil
 }
@@ -307,48 +998,57 @@ func fetchDependabotFile(client *github.Client, repo *github.Repository) (*Depen
 	ctx := context.Background()
 	defaultBranch := getDefaultBranch(repo)
 
-	// Try to fetch .github/dependabot.yml
-	fileContent, _, _, err := client.Repositories.GetContents(ctx, repo.GetOwner().GetLogin(), repo.GetName(), ".github/dependabot.yml", &github.RepositoryContentGetOptions{
-		Ref: defaultBranch,
-	})
-
-	if err != nil {
-		// If file is not found, return nil without error
-		if isNotFoundError(err) {
-			fmt.Printf("No '.github/dependabot.yml' file found in repository '%s'.\n", repo.GetName())
-			return nil, nil
+	// Try each accepted dependabot config file name in turn
+	var fileContent *github.RepositoryContent
+	var filePath string
+	for _, candidate := range dependabotConfigPaths {
+		content, _, _, err := client.Repositories.GetContents(ctx, repo.GetOwner().GetLogin(), repo.GetName(), candidate, &github.RepositoryContentGetOptions{
+			Ref: defaultBranch,
+		})
+		if err != nil {
+			if isNotFoundError(err) {
+				continue
+			}
+			logErrorf("Error accessing %s in repository '%s': %v", candidate, repo.GetName(), err)
+			return nil, err
+		}
+		if content != nil {
+			fileContent = content
+			filePath = candidate
+			break
 		}
-		fmt.Printf("Error accessing .github/dependabot.yml in repository '%s': %v\n", repo.GetName(), err)
-		return nil, err
 	}
 
+	// If no config is found, return nil without error
 	if fileContent == nil {
-		fmt.Printf("No dependabot.yml file found in repository '%s'.\n", repo.GetName())
+		logDebugf("No dependabot.yml file found in repository '%s'.", repo.GetName())
 		return nil, nil
 	}
 
-	fmt.Printf("Found dependabot.yml file in repository '%s'\n", repo.GetName())
+	logDebugf("Found %s file in repository '%s'", filePath, repo.GetName())
 
 	content, err := fetchBlobContent(client, repo.GetOwner().GetLogin(), repo.GetName(), fileContent.GetSHA())
 	if err != nil {
-		fmt.Printf("Error fetching content for dependabot.yml in repository '%s': %v\n", repo.GetName(), err)
-		return nil, err
+		logErrorf("Error fetching content for dependabot.yml in repository '%s': %v", repo.GetName(), err)
+		return nil, withBlobPath(err, filePath)
 	}
 
 	if content == "" {
-		fmt.Printf("Empty content for dependabot.yml in repository '%s'\n", repo.GetName())
+		logDebugf("Empty content for dependabot.yml in repository '%s'", repo.GetName())
 		return nil, nil
 	}
 
 	hash := computeHash([]byte(content))
 	category := extractCategory(content)
 
-	fmt.Printf("Hashing dependabot.yml in repository '%s': %s (category: %s)\n", repo.GetName(), hash, category)
+	logDebugf("Hashing dependabot.yml in repository '%s': %s (category: %s)", repo.GetName(), hash, category)
 
 	return &DependabotFile{
 		RepoName: repo.GetName(),
+		FilePath: filePath,
 		Content:  content,
 		Hash:     hash,
+		BlobSHA:  fileContent.GetSHA(),
 		Category: category,
 	}, nil
 }
@@ -365,37 +1065,38 @@ func fetchConfiguredDotfiles(client *github.Client, repo *github.Repository, con
 		})
 		if err != nil {
 			if isNotFoundError(err) {
-				fmt.Printf("No configured dotfile '%s' found in repository '%s'.\n", dotfilePath, repo.GetName())
+				logDebugf("No configured dotfile '%s' found in repository '%s'.", dotfilePath, repo.GetName())
 				continue
 			}
-			fmt.Printf("Error accessing configured dotfile '%s' in repository '%s': %v\n", dotfilePath, repo.GetName(), err)
+			logErrorf("Error accessing configured dotfile '%s' in repository '%s': %v", dotfilePath, repo.GetName(), err)
 			return nil, err
 		}
 
 		if fileContent == nil {
-			fmt.Printf("No configured dotfile '%s' found in repository '%s'.\n", dotfilePath, repo.GetName())
+			logDebugf("No configured dotfile '%s' found in repository '%s'.", dotfilePath, repo.GetName())
 			continue
 		}
 
 		content, err := fetchBlobContent(client, repo.GetOwner().GetLogin(), repo.GetName(), fileContent.GetSHA())
 		if err != nil {
-			fmt.Printf("Error fetching content for configured dotfile '%s' in repository '%s': %v\n", dotfilePath, repo.GetName(), err)
-			return nil, err
+			logErrorf("Error fetching content for configured dotfile '%s' in repository '%s': %v", dotfilePath, repo.GetName(), err)
+			return nil, withBlobPath(err, dotfilePath)
 		}
 		if content == "" {
-			fmt.Printf("Empty content for configured dotfile '%s' in repository '%s'\n", dotfilePath, repo.GetName())
+			logDebugf("Empty content for configured dotfile '%s' in repository '%s'", dotfilePath, repo.GetName())
 			continue
 		}
 
 		hash := computeHash([]byte(content))
 		category := extractCategory(content)
-		fmt.Printf("Hashing configured dotfile '%s' in repository '%s': %s (category: %s)\n", dotfilePath, repo.GetName(), hash, category)
+		logDebugf("Hashing configured dotfile '%s' in repository '%s': %s (category: %s)", dotfilePath, repo.GetName(), hash, category)
 
 		dotfiles = append(dotfiles, DotfileFile{
 			RepoName: repo.GetName(),
 			FilePath: dotfilePath,
 			Content:  content,
 			Hash:     hash,
+			BlobSHA:  fileContent.GetSHA(),
 			Category: category,
 		})
 	}
@@ -418,57 +1119,48 @@ func computeHash(content []byte) string {
 }
 
 // extractActionUses parses a workflow YAML file and extracts all 'uses' statements.
+// Anchors, aliases, and merge keys are resolved first so steps reused through aliases are included.
 func extractActionUses(workflowContent string, repoName string, filePath string) []ActionUse {
 	var uses []ActionUse
 
 	// Parse the YAML content
-	var workflow map[string]any
-	err := yaml.Unmarshal([]byte(workflowContent), &workflow)
+	workflow, err := parseWorkflowDocument(workflowContent)
 	if err != nil {
-		fmt.Printf("Error parsing YAML for %s/%s: %v\n", repoName, filePath, err)
+		logErrorf("Error parsing YAML for %s/%s: %v", repoName, filePath, err)
 		return uses
 	}
 
 	// Navigate through jobs
-	jobs, ok := workflow["jobs"].(map[string]any)
-	if !ok {
+	jobs := mappingValue(workflow, "jobs")
+	if jobs == nil || jobs.Kind != yaml.MappingNode {
 		return uses
 	}
 
 	// Iterate through each job
-	for _, jobData := range jobs {
-		job, ok := jobData.(map[string]any)
-		if !ok {
-			continue
-		}
-
+	for i := 1; i < len(jobs.Content); i += 2 {
 		// Get steps from the job
-		steps, ok := job["steps"].([]any)
-		if !ok {
+		steps := mappingValue(jobs.Content[i], "steps")
+		if steps == nil || steps.Kind != yaml.SequenceNode {
 			continue
 		}
 
 		// Iterate through each step
-		for _, stepData := range steps {
-			step, ok := stepData.(map[string]any)
-			if !ok {
+		for _, step := range steps.Content {
+			// Check if step has a 'uses' field
+			usesNode := mappingValue(step, "uses")
+			if usesNode == nil || usesNode.Kind != yaml.ScalarNode {
 				continue
 			}
 
-			// Check if step has a 'uses' field
-			if usesVal, ok := step["uses"]; ok {
-				if usesStr, ok := usesVal.(string); ok {
-					// Parse the uses string to extract action and version
-					action, version := parseUsesString(usesStr, workflowContent)
-					if action != "" {
-						uses = append(uses, ActionUse{
-							Action:   action,
-							Version:  version,
-							RepoName: repoName,
-							FilePath: filePath,
-						})
-					}
-				}
+			// Parse the uses string to extract action and version
+			action, version := parseUsesString(usesNode.Value, usesNode.LineComment)
+			if action != "" {
+				uses = append(uses, ActionUse{
+					Action:   action,
+					Version:  version,
+					RepoName: repoName,
+					FilePath: filePath,
+				})
 			}
 		}
 	}
@@ -477,8 +1169,8 @@ func extractActionUses(workflowContent string, repoName string, filePath string)
 }
 
 // parseUsesString parses a 'uses' string to extract the action name and version.
-// It also looks for inline comments to include them in the version string.
-func parseUsesString(usesStr string, workflowContent string) (action string, version string) {
+// The inline comment of the uses line, if any, is included in the version string.
+func parseUsesString(usesStr string, lineComment string) (action string, version string) {
 	// Split by '@' to separate action from version
 	parts := strings.SplitN(usesStr, "@", 2)
 	if len(parts) != 2 {
@@ -489,27 +1181,9 @@ func parseUsesString(usesStr string, workflowContent string) (action string, ver
 	action = parts[0]
 	version = parts[1]
 
-	// Look for the uses line in the original content to get any inline comment
-	lines := strings.SplitSeq(workflowContent, "\n")
-	for line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-		// Check if this line contains our uses statement
-		if strings.Contains(trimmedLine, "uses:") && strings.Contains(trimmedLine, usesStr) {
-			// Check if there's a comment after the uses statement
-			// Find the position after the version part to look for a comment
-			usesEndIdx := strings.Index(trimmedLine, usesStr) + len(usesStr)
-			if usesEndIdx < len(trimmedLine) {
-				remainingLine := trimmedLine[usesEndIdx:]
-				if _, after, ok := strings.Cut(remainingLine, "#"); ok {
-					// Extract the comment part (after the #)
-					comment := strings.TrimSpace(after)
-					if comment != "" {
-						version = version + " # " + comment
-					}
-				}
-			}
-			break
-		}
+	comment := strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(lineComment), "#"))
+	if comment != "" {
+		version = version + " # " + comment
 	}
 
 	return action, version
@@ -548,8 +1222,88 @@ func isNotFoundError(err error) bool {
 	return false
 }
 
-// fetchBlobContent retrieves and decodes the blob content for the provided SHA.
+// isForbiddenError reports whether err is a 403 response, as for a fine-grained token or GitHub App that
+// lacks a read permission.
+func isForbiddenError(err error) bool {
+	errResp, ok := err.(*github.ErrorResponse)
+	return ok && errResp.Response != nil && errResp.Response.StatusCode == http.StatusForbidden
+}
+
+// fetchBlobContent returns the decoded content of a blob, reusing a blob with the same SHA fetched earlier in the run.
 func fetchBlobContent(client *github.Client, owner, repoName, sha string) (string, error) {
+	return fetchedBlobs.fetch(client, owner, repoName, sha)
+}
+
+// blobDownloadAttempts bounds how often a blob whose content fails verification is downloaded before
+// the repository is failed.
+const blobDownloadAttempts = 3
+
+// blobRetryDelay is the pause before downloading a blob again, growing with each attempt.
+const blobRetryDelay = time.Second
+
+// BlobIntegrityError reports blob content that could not be decoded or did not match the size and SHA
+// reported by GitHub, which happens when an API response is truncated.
+type BlobIntegrityError struct {
+	Path   string // Path of the file in the repository; empty when the blob was not fetched for a known file
+	SHA    string
+	Reason string
+}
+
+func (e *BlobIntegrityError) Error() string {
+	if e.Path != "" {
+		return fmt.Sprintf("blob '%s' of '%s' %s", e.SHA, e.Path, e.Reason)
+	}
+	return fmt.Sprintf("blob '%s' %s", e.SHA, e.Reason)
+}
+
+// withBlobPath records the file a blob was fetched for in a blob integrity error. Other errors are
+// returned unchanged.
+func withBlobPath(err error, filePath string) error {
+	var integrityErr *BlobIntegrityError
+	if !errors.As(err, &integrityErr) {
+		return err
+	}
+	withPath := *integrityErr
+	withPath.Path = filePath
+	return &withPath
+}
+
+// blobIntegrityFailures returns the paths of the files whose blobs failed verification in err, which
+// may join the errors of several files.
+func blobIntegrityFailures(err error) []string {
+	var paths []string
+	switch e := err.(type) {
+	case nil:
+	case *BlobIntegrityError:
+		if e.Path != "" {
+			paths = append(paths, e.Path)
+		}
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			paths = append(paths, blobIntegrityFailures(inner)...)
+		}
+	case interface{ Unwrap() error }:
+		paths = blobIntegrityFailures(e.Unwrap())
+	}
+	return paths
+}
+
+// downloadBlob fetches a blob from the API, decodes it, and verifies it against its SHA and size.
+// Content that fails verification is downloaded again, since a truncated response is usually transient.
+func downloadBlob(client *github.Client, owner, repoName, sha string) (string, error) {
+	for attempt := 1; ; attempt++ {
+		content, err := downloadBlobOnce(client, owner, repoName, sha)
+		var integrityErr *BlobIntegrityError
+		if err == nil || !errors.As(err, &integrityErr) || attempt == blobDownloadAttempts {
+			return content, err
+		}
+		logWarnf("Downloading blob '%s' in repository '%s' again: %v", sha, repoName, err)
+		time.Sleep(time.Duration(attempt) * blobRetryDelay)
+	}
+}
+
+// downloadBlobOnce fetches, decodes, and verifies a blob once.
+func downloadBlobOnce(client *github.Client, owner, repoName, sha string) (string, error) {
 	ctx := context.Background()
 	blob, _, err := client.Git.GetBlob(ctx, owner, repoName, sha)
 	if err != nil {
@@ -558,12 +1312,44 @@ func fetchBlobContent(client *github.Client, owner, repoName, sha string) (strin
 
 	contentBytes, err := base64.StdEncoding.DecodeString(blob.GetContent())
 	if err != nil {
+		return "", &BlobIntegrityError{SHA: sha, Reason: fmt.Sprintf("could not be decoded: %v", err)}
+	}
+
+	if err := verifyBlobContent(contentBytes, sha, reportedSize(blob.Size)); err != nil {
 		return "", err
 	}
 
 	return string(contentBytes), nil
 }
 
+// reportedSize returns the size GitHub reported for a blob, or -1 when the response did not include one.
+func reportedSize(size *int) int {
+	if size == nil {
+		return -1
+	}
+	return *size
+}
+
+// computeBlobSHA computes the git object ID GitHub reports for a blob with the given content.
+func computeBlobSHA(content []byte) string {
+	hasher := sha1.New()
+	fmt.Fprintf(hasher, "blob %d\x00", len(content))
+	hasher.Write(content)
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// verifyBlobContent checks decoded blob content against the size and SHA reported by GitHub,
+// catching decode or truncation errors before the content is stored. A negative size skips the size check.
+func verifyBlobContent(content []byte, sha string, size int) error {
+	if size >= 0 && len(content) != size {
+		return &BlobIntegrityError{SHA: sha, Reason: fmt.Sprintf("decoded to %d bytes, expected %d", len(content), size)}
+	}
+	if actual := computeBlobSHA(content); !strings.EqualFold(actual, sha) {
+		return &BlobIntegrityError{SHA: sha, Reason: fmt.Sprintf("failed verification: decoded content has SHA '%s'", actual)}
+	}
+	return nil
+}
+
 // normalizeDotfilePath converts a configured path into a clean repository-relative path.
 func normalizeDotfilePath(dotfilePath string) (string, error) {
 	cleaned := strings.TrimSpace(dotfilePath)
@@ -598,7 +1384,7 @@ func loadDotfilesConfig(dbPath string) (*DotfilesConfig, error) {
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			fmt.Printf("No 'dotfiles.yaml' file found at '%s'. Skipping extra dotfile indexing.\n", configPath)
+			logInfof("No 'dotfiles.yaml' file found at '%s'. Skipping extra dotfile indexing.", configPath)
 			return nil, nil
 		}
 		return nil, err
@@ -626,7 +1412,7 @@ func loadDotfilesConfig(dbPath string) (*DotfilesConfig, error) {
 	config.Dotfiles = normalized
 
 	if len(config.Dotfiles) == 0 {
-		fmt.Printf("'dotfiles.yaml' at '%s' does not define any dotfiles. Skipping extra dotfile indexing.\n", configPath)
+		logInfof("'dotfiles.yaml' at '%s' does not define any dotfiles. Skipping extra dotfile indexing.", configPath)
 	}
 
 	return &config, nil
@@ -639,19 +1425,19 @@ func loadDotfilesConfig(dbPath string) (*DotfilesConfig, error) {
 // initializeDB sets up the database directory and initial manifests.
 func initializeDB(dbPath string) error {
 	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
-		fmt.Printf("Creating database directory at '%s'\n", dbPath)
+		logInfof("Creating database directory at '%s'", dbPath)
 		err := os.MkdirAll(dbPath, os.ModePerm)
 		if err != nil {
 			return err
 		}
 	} else {
-		fmt.Printf("Database directory '%s' already exists.\n", dbPath)
+		logInfof("Database directory '%s' already exists.", dbPath)
 	}
 
 	// Initialize repositories.yaml
 	reposManifestPath := filepath.Join(dbPath, "repositories.yaml")
 	if _, err := os.Stat(reposManifestPath); os.IsNotExist(err) {
-		fmt.Printf("Creating 'repositories.yaml' at '%s'\n", reposManifestPath)
+		logInfof("Creating 'repositories.yaml' at '%s'", reposManifestPath)
 		emptyManifest := RepositoryManifest{Organization: org, Repositories: []string{}}
 		data, _ := yaml.Marshal(&emptyManifest)
 		err = os.WriteFile(reposManifestPath, data, 0644)
@@ -659,38 +1445,79 @@ func initializeDB(dbPath string) error {
 			return err
 		}
 	} else {
-		fmt.Printf("'repositories.yaml' already exists at '%s'\n", reposManifestPath)
+		logInfof("'repositories.yaml' already exists at '%s'", reposManifestPath)
+	}
+
+	// Record the layout, which is layout 1 for databases created before it was recorded
+	if _, err := os.Stat(filepath.Join(dbPath, layoutMarker)); os.IsNotExist(err) {
+		if err := writeLayout(dbPath, dbLayout); err != nil {
+			return err
+		}
 	}
 
 	// Initialize actions directory
 	actionsPath := filepath.Join(dbPath, "workflows")
 	if _, err := os.Stat(actionsPath); os.IsNotExist(err) {
-		fmt.Printf("Creating 'actions' directory at '%s'\n", actionsPath)
+		logInfof("Creating 'actions' directory at '%s'", actionsPath)
 		err = os.MkdirAll(actionsPath, os.ModePerm)
 		if err != nil {
 			return err
 		}
 	} else {
-		fmt.Printf("'actions' directory already exists at '%s'\n", actionsPath)
+		logInfof("'actions' directory already exists at '%s'", actionsPath)
 	}
 
 	// Initialize dependabot directory
 	dependabotPath := filepath.Join(dbPath, "dependabot")
 	if _, err := os.Stat(dependabotPath); os.IsNotExist(err) {
-		fmt.Printf("Creating 'dependabot' directory at '%s'\n", dependabotPath)
+		logInfof("Creating 'dependabot' directory at '%s'", dependabotPath)
 		err = os.MkdirAll(dependabotPath, os.ModePerm)
 		if err != nil {
 			return err
 		}
 	} else {
-		fmt.Printf("'dependabot' directory already exists at '%s'\n", dependabotPath)
+		logInfof("'dependabot' directory already exists at '%s'", dependabotPath)
 	}
 
 	return nil
 }
 
-// updateRepositoriesManifest adds a repository to the repositories.yaml manifest.
-func updateRepositoriesManifest(dbPath string, repoName string) error {
+// loadRepositoryManifest reads repositories.yaml from the database.
+func loadRepositoryManifest(dbPath string) (*RepositoryManifest, error) {
+	var manifest RepositoryManifest
+	data, err := os.ReadFile(filepath.Join(dbPath, "repositories.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse repositories.yaml: %v", err)
+	}
+	return &manifest, nil
+}
+
+// loadDefaultBranches returns the default branch recorded for each repository in repositories.yaml, or
+// none when the manifest cannot be read.
+func loadDefaultBranches(dbPath string) map[string]string {
+	manifest, err := loadRepositoryManifest(dbPath)
+	if err != nil {
+		return nil
+	}
+	return manifest.DefaultBranches
+}
+
+// repositoryFileURL returns the link to a file on the default branch of a repository, assuming main for
+// one whose default branch was not recorded.
+func repositoryFileURL(org, repoName string, defaultBranches map[string]string, filePath string) string {
+	branch := defaultBranches[repoName]
+	if branch == "" {
+		branch = "main"
+	}
+	return fmt.Sprintf("%s/%s/%s/blob/%s/%s", githubWebURL, org, repoName, branch, filePath)
+}
+
+// updateRepositoriesManifest adds a repository to the repositories.yaml manifest and records its
+// default branch when one is given.
+func updateRepositoriesManifest(dbPath string, repoName string, defaultBranch string) error {
 	reposManifestPath := filepath.Join(dbPath, "repositories.yaml")
 	var manifest RepositoryManifest
 
@@ -703,14 +1530,25 @@ func updateRepositoriesManifest(dbPath string, repoName string) error {
 		return err
 	}
 
-	// Add repo if not exists
-	if slices.Contains(manifest.Repositories, repoName) {
+	added := !slices.Contains(manifest.Repositories, repoName)
+	branchChanged := defaultBranch != "" && manifest.DefaultBranches[repoName] != defaultBranch
+	if !added && !branchChanged {
 		return nil
 	}
-	manifest.Repositories = append(manifest.Repositories, repoName)
 
-	// Sort repositories alphabetically
-	sort.Strings(manifest.Repositories)
+	// Add repo if not exists
+	if added {
+		manifest.Repositories = append(manifest.Repositories, repoName)
+
+		// Sort repositories alphabetically
+		sort.Strings(manifest.Repositories)
+	}
+	if branchChanged {
+		if manifest.DefaultBranches == nil {
+			manifest.DefaultBranches = make(map[string]string)
+		}
+		manifest.DefaultBranches[repoName] = defaultBranch
+	}
 
 	updatedData, err := yaml.Marshal(&manifest)
 	if err != nil {
@@ -721,12 +1559,43 @@ func updateRepositoriesManifest(dbPath string, repoName string) error {
 		return err
 	}
 
-	fmt.Printf("Added repository '%s' to 'repositories.yaml'\n", repoName)
+	if added {
+		logInfof("Added repository '%s' to 'repositories.yaml'", repoName)
+	}
+	return nil
+}
+
+// writeErrorsManifest records the repositories that failed after all retries in errors.yaml.
+func writeErrorsManifest(dbPath string, failures map[string]error, attempts int) error {
+	manifest := ErrorsManifest{Repositories: make(map[string]RepositoryError)}
+	for repoName, err := range failures {
+		manifest.Repositories[repoName] = RepositoryError{
+			Error:    err.Error(),
+			Attempts: attempts,
+			Files:    blobIntegrityFailures(err),
+		}
+	}
+
+	data, err := yaml.Marshal(&manifest)
+	if err != nil {
+		return err
+	}
+
+	errorsPath := filepath.Join(dbPath, "errors.yaml")
+	if err := os.WriteFile(errorsPath, data, 0644); err != nil {
+		return err
+	}
+
+	if len(failures) > 0 {
+		logInfof("Recorded %d failed repositories in 'errors.yaml'", len(failures))
+	}
 	return nil
 }
 
 // updateActionIndex maps a repository to a workflow file hash in the action's index.
-func updateActionIndex(dbPath, actionName, repoName, hash string) error {
+// The GitHub blob SHA is recorded for each hash when provided, and the repository's
+// file name is recorded when it differs from the logical action name.
+func updateActionIndex(dbPath, actionName, repoName, fileName, hash, blobSHA string) error {
 	actionPath := filepath.Join(dbPath, "workflows", actionName)
 	if err := os.MkdirAll(actionPath, os.ModePerm); err != nil {
 		return err
@@ -749,6 +1618,9 @@ func updateActionIndex(dbPath, actionName, repoName, hash string) error {
 	}
 
 	index.Repositories[repoName] = hash
+	recordBlobSHA(&index, hash, blobSHA)
+	recordFilename(&index, repoName, actionName, fileName)
+	recordVersionDates(&index, dbPath, actionName, hash, time.Now())
 
 	// Sort repositories alphabetically by key
 	sortedKeys := make([]string, 0, len(index.Repositories))
@@ -772,10 +1644,35 @@ func updateActionIndex(dbPath, actionName, repoName, hash string) error {
 		return err
 	}
 
-	fmt.Printf("Updated action index for action '%s' with repository '%s'\n", actionName, repoName)
+	logDebugf("Updated action index for action '%s' with repository '%s'", actionName, repoName)
 	return nil
 }
 
+// recordBlobSHA stores the GitHub blob SHA for a content hash and drops entries for hashes no longer in use.
+func recordBlobSHA(index *ActionIndex, hash, blobSHA string) {
+	if blobSHA != "" {
+		if index.Blobs == nil {
+			index.Blobs = make(map[string]string)
+		}
+		index.Blobs[hash] = blobSHA
+	}
+
+	hashesInUse := make(map[string]bool)
+	for _, h := range index.Repositories {
+		hashesInUse[h] = true
+	}
+	for h := range index.Blobs {
+		if !hashesInUse[h] {
+			delete(index.Blobs, h)
+		}
+	}
+	for blobSHA, h := range index.EncodedBlobs {
+		if !hashesInUse[h] {
+			delete(index.EncodedBlobs, blobSHA)
+		}
+	}
+}
+
 // storeActionVersion saves the workflow file content under its hash.
 func storeActionVersion(dbPath, actionName, hash, content string) error {
 	actionPath := filepath.Join(dbPath, "workflows", actionName)
@@ -783,19 +1680,22 @@ func storeActionVersion(dbPath, actionName, hash, content string) error {
 		return err
 	}
 
-	filePath := filepath.Join(actionPath, fmt.Sprintf("%s", hash))
+	if err := os.MkdirAll(versionsDir(actionPath), os.ModePerm); err != nil {
+		return err
+	}
+	filePath := versionPath(actionPath, hash)
 	// Check if file already exists to avoid unnecessary writes
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		fmt.Printf("Storing workflow file '%s' under hash '%s'\n", actionName, hash)
-		return os.WriteFile(filePath, []byte(content), 0644)
+		logDebugf("Storing workflow file '%s' under hash '%s'", actionName, hash)
+		return writeVersionFile(filePath, []byte(content))
 	}
 
-	fmt.Printf("Workflow file with hash '%s' already exists. Skipping write.\n", hash)
+	logDebugf("Workflow file with hash '%s' already exists. Skipping write.", hash)
 	return nil
 }
 
 // updateDependabotIndex maps a repository to a dependabot file hash and category in the dependabot index.
-func updateDependabotIndex(dbPath, repoName, hash, category string) error {
+func updateDependabotIndex(dbPath, repoName, filePath, hash, blobSHA, category string) error {
 	categoryPath := filepath.Join(dbPath, "dependabot", category)
 	if err := os.MkdirAll(categoryPath, os.ModePerm); err != nil {
 		return err
@@ -818,6 +1718,8 @@ func updateDependabotIndex(dbPath, repoName, hash, category string) error {
 	}
 
 	index.Repositories[repoName] = hash
+	recordBlobSHA(&index, hash, blobSHA)
+	recordFilename(&index, repoName, dependabotLogicalPath, filePath)
 
 	// Sort repositories alphabetically by key
 	sortedKeys := make([]string, 0, len(index.Repositories))
@@ -841,7 +1743,7 @@ func updateDependabotIndex(dbPath, repoName, hash, category string) error {
 		return err
 	}
 
-	fmt.Printf("Updated dependabot index for category '%s' with repository '%s'\n", category, repoName)
+	logDebugf("Updated dependabot index for category '%s' with repository '%s'", category, repoName)
 	return nil
 }
 
@@ -852,14 +1754,17 @@ func storeDependabotVersion(dbPath, category, hash, content string) error {
 		return err
 	}
 
-	filePath := filepath.Join(categoryPath, hash)
+	if err := os.MkdirAll(versionsDir(categoryPath), os.ModePerm); err != nil {
+		return err
+	}
+	filePath := versionPath(categoryPath, hash)
 	// Check if file already exists to avoid unnecessary writes
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		fmt.Printf("Storing dependabot file under category '%s' with hash '%s'\n", category, hash)
-		return os.WriteFile(filePath, []byte(content), 0644)
+		logDebugf("Storing dependabot file under category '%s' with hash '%s'", category, hash)
+		return writeVersionFile(filePath, []byte(content))
 	}
 
-	fmt.Printf("Dependabot file with hash '%s' already exists. Skipping write.\n", hash)
+	logDebugf("Dependabot file with hash '%s' already exists. Skipping write.", hash)
 	return nil
 }
 
@@ -869,7 +1774,7 @@ func dotfileStoragePath(dbPath, dotfilePath string) string {
 }
 
 // updateDotfileIndex maps a repository to a configured dotfile hash and category.
-func updateDotfileIndex(dbPath, dotfilePath, repoName, hash, category string) error {
+func updateDotfileIndex(dbPath, dotfilePath, repoName, hash, blobSHA, category string) error {
 	storagePath := dotfileStoragePath(dbPath, dotfilePath)
 	if err := os.MkdirAll(storagePath, os.ModePerm); err != nil {
 		return err
@@ -896,6 +1801,7 @@ func updateDotfileIndex(dbPath, dotfilePath, repoName, hash, category string) er
 
 	index.Repositories[repoName] = DotfileIndexEntry{
 		Hash:     hash,
+		BlobSHA:  blobSHA,
 		Category: category,
 	}
 
@@ -919,7 +1825,7 @@ func updateDotfileIndex(dbPath, dotfilePath, repoName, hash, category string) er
 		return err
 	}
 
-	fmt.Printf("Updated dotfile index for '%s' with repository '%s'\n", dotfilePath, repoName)
+	logDebugf("Updated dotfile index for '%s' with repository '%s'", dotfilePath, repoName)
 	return nil
 }
 
@@ -930,16 +1836,78 @@ func storeDotfileVersion(dbPath, dotfilePath, hash, content string) error {
 		return err
 	}
 
-	filePath := filepath.Join(storagePath, hash)
+	if err := os.MkdirAll(versionsDir(storagePath), os.ModePerm); err != nil {
+		return err
+	}
+	filePath := versionPath(storagePath, hash)
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		fmt.Printf("Storing configured dotfile '%s' under hash '%s'\n", dotfilePath, hash)
-		return os.WriteFile(filePath, []byte(content), 0644)
+		logDebugf("Storing configured dotfile '%s' under hash '%s'", dotfilePath, hash)
+		return writeVersionFile(filePath, []byte(content))
 	}
 
-	fmt.Printf("Configured dotfile '%s' with hash '%s' already exists. Skipping write.\n", dotfilePath, hash)
+	logDebugf("Configured dotfile '%s' with hash '%s' already exists. Skipping write.", dotfilePath, hash)
+	return nil
+}
+
+// walkIndexedWorkflows calls fn with the stored content of every workflow file currently indexed for a repository.
+// The file name passed to fn is the repository's original name for the workflow, such as build.yaml.
+// Workflow names and repositories are visited in alphabetical order.
+func walkIndexedWorkflows(dbPath string, fn func(fileName, repoName, content string)) error {
+	actionsPath := filepath.Join(dbPath, "workflows")
+	unstored := 0
+	dirs, err := os.ReadDir(actionsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+
+		actionName := dir.Name()
+		data, err := os.ReadFile(filepath.Join(actionsPath, actionName, "index.yaml"))
+		if err != nil {
+			continue
+		}
+		var index ActionIndex
+		if err := yaml.Unmarshal(data, &index); err != nil {
+			logErrorf("Error parsing index.yaml for workflow '%s': %v", actionName, err)
+			continue
+		}
+
+		repoNames := make([]string, 0, len(index.Repositories))
+		for repoName := range index.Repositories {
+			repoNames = append(repoNames, repoName)
+		}
+		sort.Strings(repoNames)
+
+		for _, repoName := range repoNames {
+			content, err := readVersionFile(versionPath(filepath.Join(actionsPath, actionName), index.Repositories[repoName]))
+			if err != nil {
+				if os.IsNotExist(err) {
+					unstored++
+				}
+				continue
+			}
+			fn(index.fileName(repoName, actionName), repoName, string(content))
+		}
+	}
+
+	if unstored > 0 {
+		reportUnstoredWorkflows.Do(func() {
+			logInfof("%d indexed workflows have no stored content, as with -store-content=false; reports built from stored workflows leave them out", unstored)
+		})
+	}
 	return nil
 }
 
+// reportUnstoredWorkflows reports once per run that indexed workflows have no stored content.
+var reportUnstoredWorkflows sync.Once
+
 // walkDotfileIndexes returns all configured dotfile storage directories that contain an index.yaml file.
 func walkDotfileIndexes(dbPath string) ([]string, error) {
 	dotfilesPath := filepath.Join(dbPath, "dotfiles")
@@ -993,14 +1961,14 @@ func loadDotfileIndex(dbPath, dotfilePath string) (DotfileIndex, error) {
 func dotfilesUseCategories(dbPath string) bool {
 	dotfilePaths, err := walkDotfileIndexes(dbPath)
 	if err != nil {
-		fmt.Printf("Error inspecting configured dotfile categories: %v\n", err)
+		logErrorf("Error inspecting configured dotfile categories: %v", err)
 		return false
 	}
 
 	for _, dotfilePath := range dotfilePaths {
 		index, err := loadDotfileIndex(dbPath, dotfilePath)
 		if err != nil {
-			fmt.Printf("Error loading configured dotfile index for '%s': %v\n", dotfilePath, err)
+			logErrorf("Error loading configured dotfile index for '%s': %v", dotfilePath, err)
 			continue
 		}
 		for _, entry := range index.Repositories {
@@ -1020,7 +1988,7 @@ func clearDotfilesOutput(dbPath string) error {
 		return nil
 	}
 
-	fmt.Printf("Removing configured dotfile output at '%s' because dotfile indexing is disabled.\n", dotfilesPath)
+	logInfof("Removing configured dotfile output at '%s' because dotfile indexing is disabled.", dotfilesPath)
 	return os.RemoveAll(dotfilesPath)
 }
 
@@ -1032,7 +2000,7 @@ func clearDotfilesOutput(dbPath string) error {
 func garbageCollect(dbPath string) error {
 	actionsPath := filepath.Join(dbPath, "workflows")
 	if _, err := os.Stat(actionsPath); os.IsNotExist(err) {
-		fmt.Printf("No 'workflows' directory found at '%s'. Skipping garbage collection.\n", actionsPath)
+		logInfof("No 'workflows' directory found at '%s'. Skipping garbage collection.", actionsPath)
 		return nil
 	}
 
@@ -1048,12 +2016,12 @@ func garbageCollect(dbPath string) error {
 			var index ActionIndex
 			data, err := os.ReadFile(indexPath)
 			if err != nil {
-				fmt.Printf("No index found for action '%s'. Skipping.\n", actionName)
+				logDebugf("No index found for action '%s'. Skipping.", actionName)
 				continue
 			}
 			err = yaml.Unmarshal(data, &index)
 			if err != nil {
-				fmt.Printf("Error unmarshaling index for action '%s': %v\n", actionName, err)
+				logErrorf("Error unmarshaling index for action '%s': %v", actionName, err)
 				continue
 			}
 
@@ -1064,27 +2032,31 @@ func garbageCollect(dbPath string) error {
 			}
 
 			// Iterate over all files in action directory
-			actionDirPath := filepath.Join(actionsPath, actionName)
+			actionDirPath := versionsDir(filepath.Join(actionsPath, actionName))
 			files, err := os.ReadDir(actionDirPath)
+			if os.IsNotExist(err) {
+				continue
+			}
 			if err != nil {
-				fmt.Printf("Error reading action directory '%s': %v\n", actionDirPath, err)
+				logErrorf("Error reading action directory '%s': %v", actionDirPath, err)
 				continue
 			}
 
 			for _, file := range files {
-				if file.IsDir() || file.Name() == "index.yaml" {
+				// In layout 1 the versions share the folder with the index, its JSON copies, and the README
+				if file.IsDir() || file.Name() == "index.yaml" || file.Name() == "changelog.yaml" || isJSONCopyName(file.Name()) || strings.HasSuffix(file.Name(), ".md") {
 					continue
 				}
 				hash := strings.TrimSuffix(file.Name(), filepath.Ext(file.Name()))
 				if !hashesInUse[hash] {
-					fmt.Printf("Removing unused workflow file '%s' from action '%s'\n", file.Name(), actionName)
+					logDebugf("Removing unused workflow file '%s' from action '%s'", file.Name(), actionName)
 					os.Remove(filepath.Join(actionDirPath, file.Name()))
 				}
 			}
 		}
 	}
 
-	fmt.Println("Garbage collection completed.")
+	logInfof("Garbage collection completed.")
 	return nil
 }
 
@@ -1092,7 +2064,7 @@ func garbageCollect(dbPath string) error {
 func garbageCollectDependabot(dbPath string) error {
 	dependabotPath := filepath.Join(dbPath, "dependabot")
 	if _, err := os.Stat(dependabotPath); os.IsNotExist(err) {
-		fmt.Printf("No 'dependabot' directory found at '%s'. Skipping garbage collection.\n", dependabotPath)
+		logInfof("No 'dependabot' directory found at '%s'. Skipping garbage collection.", dependabotPath)
 		return nil
 	}
 
@@ -1108,12 +2080,12 @@ func garbageCollectDependabot(dbPath string) error {
 			var index ActionIndex
 			data, err := os.ReadFile(indexPath)
 			if err != nil {
-				fmt.Printf("No index found for dependabot category '%s'. Skipping.\n", categoryName)
+				logDebugf("No index found for dependabot category '%s'. Skipping.", categoryName)
 				continue
 			}
 			err = yaml.Unmarshal(data, &index)
 			if err != nil {
-				fmt.Printf("Error unmarshaling index for dependabot category '%s': %v\n", categoryName, err)
+				logErrorf("Error unmarshaling index for dependabot category '%s': %v", categoryName, err)
 				continue
 			}
 
@@ -1124,267 +2096,862 @@ func garbageCollectDependabot(dbPath string) error {
 			}
 
 			// Iterate over all files in category directory
-			categoryDirPath := filepath.Join(dependabotPath, categoryName)
+			categoryDirPath := versionsDir(filepath.Join(dependabotPath, categoryName))
 			files, err := os.ReadDir(categoryDirPath)
+			if os.IsNotExist(err) {
+				continue
+			}
 			if err != nil {
-				fmt.Printf("Error reading dependabot category directory '%s': %v\n", categoryDirPath, err)
+				logErrorf("Error reading dependabot category directory '%s': %v", categoryDirPath, err)
+				continue
+			}
+
+			for _, file := range files {
+				if file.IsDir() || file.Name() == "index.yaml" || file.Name() == "changelog.yaml" || isJSONCopyName(file.Name()) || strings.HasSuffix(file.Name(), ".md") {
+					continue
+				}
+				hash := file.Name()
+				if !hashesInUse[hash] {
+					logDebugf("Removing unused dependabot file '%s' from category '%s'", file.Name(), categoryName)
+					os.Remove(filepath.Join(categoryDirPath, file.Name()))
+				}
+			}
+		}
+	}
+
+	logInfof("Dependabot garbage collection completed.")
+	return nil
+}
+
+// garbageCollectDotfiles removes unused configured dotfile versions from the database.
+func garbageCollectDotfiles(dbPath string) error {
+	dotfilePaths, err := walkDotfileIndexes(dbPath)
+	if err != nil {
+		return err
+	}
+	if len(dotfilePaths) == 0 {
+		logInfof("No 'dotfiles' directory found at '%s'. Skipping garbage collection.", filepath.Join(dbPath, "dotfiles"))
+		return nil
+	}
+
+	for _, dotfilePath := range dotfilePaths {
+		index, err := loadDotfileIndex(dbPath, dotfilePath)
+		if err != nil {
+			logDebugf("No index found for configured dotfile '%s'. Skipping.", dotfilePath)
+			continue
+		}
+
+		hashesInUse := make(map[string]bool)
+		for _, entry := range index.Repositories {
+			hashesInUse[entry.Hash] = true
+		}
+
+		storagePath := versionsDir(dotfileStoragePath(dbPath, dotfilePath))
+		files, err := os.ReadDir(storagePath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			logErrorf("Error reading configured dotfile directory '%s': %v", storagePath, err)
+			continue
+		}
+
+		for _, file := range files {
+			if file.IsDir() || file.Name() == "index.yaml" || file.Name() == "README.md" || isJSONCopyName(file.Name()) {
+				continue
+			}
+			if !hashesInUse[file.Name()] {
+				logDebugf("Removing unused configured dotfile '%s' from '%s'", file.Name(), dotfilePath)
+				_ = os.Remove(filepath.Join(storagePath, file.Name()))
+			}
+		}
+	}
+
+	logInfof("Configured dotfile garbage collection completed.")
+	return nil
+}
+
+// ------------------------
+// Section: Rate Limiting
+// ------------------------
+
+// checkRateLimit monitors GitHub API rate limits and waits if necessary.
+// It returns the core rate limit observed before any wait.
+func checkRateLimit(client *github.Client) (*github.Rate, error) {
+	ctx := context.Background()
+	rate, _, err := client.RateLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	core := rate.GetCore()
+	if core.Remaining < 100 {
+		waitDuration := time.Until(core.Reset.Time) + time.Minute
+		logWarnf("Rate limit low (%d remaining). Waiting for %v...", core.Remaining, waitDuration)
+		time.Sleep(waitDuration)
+	}
+
+	return core, nil
+}
+
+// ------------------------
+// Section: Audit Function
+// ------------------------
+
+// fetchRepositoryFiles fetches the workflow, action definition, dependabot, Renovate, .github, and configured dotfiles of a repository.
+// All files are fetched before anything is written so that a failed repository can be retried cleanly.
+func fetchRepositoryFiles(client *github.Client, repo *github.Repository, dotfilePaths []string, renovate, dotgithubAll bool) (*RepositoryFiles, error) {
+	files := &RepositoryFiles{DefaultBranch: getDefaultBranch(repo)}
+	var err error
+
+	// Fetch workflow files
+	files.Workflows, err = fetchWorkflowFiles(client, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch workflow files: %w", err)
+	}
+
+	// Fetch in-house action definitions
+	files.ActionDefinitions, err = fetchActionDefinitions(client, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch action definitions: %w", err)
+	}
+
+	// Fetch workflow templates and profile files of the organization's .github repository
+	files.OrgGitHubFiles, err = fetchOrgGitHubFiles(client, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch workflow templates: %w", err)
+	}
+
+	// Fetch dependabot file
+	files.Dependabot, err = fetchDependabotFile(client, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch dependabot file: %w", err)
+	}
+
+	// Fetch Renovate config
+	if renovate {
+		files.Renovate, err = fetchRenovateFile(client, repo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch Renovate config: %w", err)
+		}
+	}
+
+	// Fetch the rest of the .github directory
+	if dotgithubAll {
+		files.DotGitHubFiles, err = fetchDotGitHubFiles(client, repo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch .github files: %w", err)
+		}
+	}
+
+	// Fetch configured dotfiles
+	if len(dotfilePaths) > 0 {
+		files.Dotfiles, err = fetchConfiguredDotfiles(client, repo, dotfilePaths)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch configured dotfiles: %w", err)
+		}
+	}
+
+	// Fetch deployment environments, which only the environments analyzer uses
+	if analyzerEnabled("environments") {
+		files.Environments, err = fetchEnvironments(client, repo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch environments: %w", err)
+		}
+	}
+
+	// Fetch default workflow token permissions
+	files.TokenDefaults, err = fetchWorkflowTokenDefaults(client, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch workflow permissions: %w", err)
+	}
+
+	return files, nil
+}
+
+// indexRepositoryFiles writes the fetched files of a repository into the database and returns
+// its findings and the workflow files whose version changed.
+// It is not safe for concurrent use; callers must serialize access to the database.
+func indexRepositoryFiles(dbPath, repoName string, files *RepositoryFiles, usesIndex *ActionUsesIndex) ([]Finding, []WorkflowChange, error) {
+	var findings []Finding
+	var changes []WorkflowChange
+	workflows := files.Workflows
+	dependabotFile := files.Dependabot
+	dotfiles := files.Dotfiles
+
+	// Update repositories manifest
+	if err := updateRepositoriesManifest(dbPath, repoName, files.DefaultBranch); err != nil {
+		return nil, nil, fmt.Errorf("failed to update repositories manifest: %v", err)
+	}
+	if err := updateRepositoryToolchains(dbPath, repoName, detectToolchains(workflows)); err != nil {
+		logErrorf("Error updating toolchains for %s: %v", repoName, err)
+	}
+
+	if len(workflows) == 0 {
+		logDebugf("No workflow files to process in repository '%s'.", repoName)
+	}
+	seenIdentities := make(map[string]bool)
+	for _, wf := range workflows {
+		fileName := filepath.Base(wf.FilePath)
+		actionName := workflowIdentity(fileName)
+		if seenIdentities[actionName] {
+			if seenIdentities[fileName] {
+				// The same file name in two scanned directories would share one index entry
+				logWarnf("Workflow '%s' in repository '%s' has the same name as one already indexed from another directory; skipping", wf.FilePath, repoName)
 				continue
 			}
+			// Both build.yml and build.yaml exist in this repository, so keep the second under its own name
+			logWarnf("Workflow '%s' in repository '%s' duplicates '%s'; indexing it under its own name", fileName, repoName, actionName)
+			actionName = fileName
+		}
+		seenIdentities[actionName] = true
 
-			for _, file := range files {
-				if file.IsDir() || file.Name() == "index.yaml" {
-					continue
-				}
-				hash := file.Name()
-				if !hashesInUse[hash] {
-					fmt.Printf("Removing unused dependabot file '%s' from category '%s'\n", file.Name(), categoryName)
-					os.Remove(filepath.Join(categoryDirPath, file.Name()))
-				}
+		// Run analyzers before storing the content
+		workflowFindings := analyzeWorkflow(wf.Content, wf.RepoName, wf.FilePath)
+		annotations := extractAnnotations(wf.Content, annotationKeys)
+		annotateFindings(workflowFindings, annotations)
+		for _, finding := range workflowFindings {
+			logInfof("%s: %s in %s/%s line %d", strings.ToUpper(finding.Severity), finding.Message, finding.RepoName, finding.FilePath, finding.Line)
+		}
+		findings = append(findings, workflowFindings...)
+
+		previousHash := currentActionHash(dbPath, actionName, wf.RepoName)
+		newVersion := !actionVersionExists(dbPath, actionName, wf.Hash)
+
+		// Update action index. The blob of a decoded file does not hold the stored content, so it is
+		// recorded among the encoded blobs instead of for the hash.
+		blobSHA := wf.BlobSHA
+		if wf.Encoding != "" {
+			blobSHA = ""
+		}
+		if err := updateActionIndex(dbPath, actionName, wf.RepoName, fileName, wf.Hash, blobSHA); err != nil {
+			logErrorf("Error updating action index for %s in %s: %v", actionName, repoName, err)
+			continue
+		}
+		if wf.Encoding != "" && wf.Reason == "" {
+			if err := updateEncodedBlob(dbPath, actionName, wf.BlobSHA, wf.Hash); err != nil {
+				logErrorf("Error recording the encoded blob of %s in %s: %v", actionName, repoName, err)
 			}
 		}
-	}
+		if err := updateWorkflowAnnotations(dbPath, actionName, wf.RepoName, annotations); err != nil {
+			logErrorf("Error updating annotations for %s in %s: %v", actionName, repoName, err)
+		}
+		if err := updateWorkflowSource(dbPath, actionName, wf.RepoName, wf.source()); err != nil {
+			logErrorf("Error updating the source of %s in %s: %v", actionName, repoName, err)
+		}
 
-	fmt.Println("Dependabot garbage collection completed.")
-	return nil
-}
+		// Store action version
+		if err := storeActionVersion(dbPath, actionName, wf.Hash, wf.Content); err != nil {
+			logErrorf("Error storing action version for %s in %s: %v", actionName, repoName, err)
+			continue
+		}
 
-// garbageCollectDotfiles removes unused configured dotfile versions from the database.
-func garbageCollectDotfiles(dbPath string) error {
-	dotfilePaths, err := walkDotfileIndexes(dbPath)
-	if err != nil {
-		return err
+		// Record the change in the workflow's change log
+		if err := recordActionChange(dbPath, actionName, wf.RepoName, previousHash, wf.Hash, wf.Content, newVersion); err != nil {
+			logErrorf("Error recording change for %s in %s: %v", actionName, repoName, err)
+		}
+		if previousHash != wf.Hash {
+			changes = append(changes, WorkflowChange{RepoName: wf.RepoName, FilePath: wf.FilePath, ActionName: actionName, From: previousHash, To: wf.Hash, Annotations: annotations})
+		}
+
+		// Extract action uses from workflow content
+		addActionUses(usesIndex, extractActionUses(wf.Content, wf.RepoName, wf.FilePath))
 	}
-	if len(dotfilePaths) == 0 {
-		fmt.Printf("No 'dotfiles' directory found at '%s'. Skipping garbage collection.\n", filepath.Join(dbPath, "dotfiles"))
-		return nil
+
+	for _, definition := range files.ActionDefinitions {
+		if err := updateActionDefinitionIndex(dbPath, definition); err != nil {
+			logErrorf("Error updating action definition index for %s in %s: %v", definition.Name, repoName, err)
+			continue
+		}
+		if err := storeActionDefinitionVersion(dbPath, definition); err != nil {
+			logErrorf("Error storing action definition version for %s in %s: %v", definition.Name, repoName, err)
+		}
 	}
 
-	for _, dotfilePath := range dotfilePaths {
-		index, err := loadDotfileIndex(dbPath, dotfilePath)
-		if err != nil {
-			fmt.Printf("No index found for configured dotfile '%s'. Skipping.\n", dotfilePath)
+	for _, file := range files.OrgGitHubFiles {
+		if err := updateOrgGitHubFileIndex(dbPath, file); err != nil {
+			logErrorf("Error updating %s index for %s in %s: %v", file.DBDir, file.Name, repoName, err)
 			continue
 		}
+		if err := storeOrgGitHubFileVersion(dbPath, file); err != nil {
+			logErrorf("Error storing %s version for %s in %s: %v", file.DBDir, file.Name, repoName, err)
+		}
+	}
 
-		hashesInUse := make(map[string]bool)
-		for _, entry := range index.Repositories {
-			hashesInUse[entry.Hash] = true
+	if dependabotFile != nil {
+		// Update dependabot index
+		if err := updateDependabotIndex(dbPath, dependabotFile.RepoName, dependabotFile.FilePath, dependabotFile.Hash, dependabotFile.BlobSHA, dependabotFile.Category); err != nil {
+			logErrorf("Error updating dependabot index for %s: %v", repoName, err)
 		}
 
-		storagePath := dotfileStoragePath(dbPath, dotfilePath)
-		files, err := os.ReadDir(storagePath)
-		if err != nil {
-			fmt.Printf("Error reading configured dotfile directory '%s': %v\n", storagePath, err)
-			continue
+		// Store dependabot version
+		if err := storeDependabotVersion(dbPath, dependabotFile.Category, dependabotFile.Hash, dependabotFile.Content); err != nil {
+			logErrorf("Error storing dependabot version for %s: %v", repoName, err)
 		}
+	}
 
-		for _, file := range files {
-			if file.IsDir() || file.Name() == "index.yaml" || file.Name() == "README.md" {
-				continue
-			}
-			if !hashesInUse[file.Name()] {
-				fmt.Printf("Removing unused configured dotfile '%s' from '%s'\n", file.Name(), dotfilePath)
-				_ = os.Remove(filepath.Join(storagePath, file.Name()))
-			}
+	if files.Renovate != nil {
+		if err := updateRenovateIndex(dbPath, *files.Renovate); err != nil {
+			logErrorf("Error updating Renovate index for %s: %v", repoName, err)
+		} else if err := storeRenovateVersion(dbPath, *files.Renovate); err != nil {
+			logErrorf("Error storing Renovate version for %s: %v", repoName, err)
 		}
 	}
 
-	fmt.Println("Configured dotfile garbage collection completed.")
-	return nil
-}
+	for _, file := range files.DotGitHubFiles {
+		if err := updateDotGitHubIndex(dbPath, file); err != nil {
+			logErrorf("Error updating .github index for %s in %s: %v", file.Name, repoName, err)
+			continue
+		}
+		if err := storeDotGitHubVersion(dbPath, file); err != nil {
+			logErrorf("Error storing .github version for %s in %s: %v", file.Name, repoName, err)
+		}
+	}
 
-// ------------------------
-// Section: Rate Limiting
-// ------------------------
+	for _, dotfile := range dotfiles {
+		if err := updateDotfileIndex(dbPath, dotfile.FilePath, dotfile.RepoName, dotfile.Hash, dotfile.BlobSHA, dotfile.Category); err != nil {
+			logErrorf("Error updating dotfile index for %s in %s: %v", dotfile.FilePath, repoName, err)
+			continue
+		}
+		if err := storeDotfileVersion(dbPath, dotfile.FilePath, dotfile.Hash, dotfile.Content); err != nil {
+			logErrorf("Error storing dotfile version for %s in %s: %v", dotfile.FilePath, repoName, err)
+		}
+	}
 
-// checkRateLimit monitors GitHub API rate limits and waits if necessary.
-func checkRateLimit(client *github.Client) error {
-	ctx := context.Background()
-	rate, _, err := client.RateLimits(ctx)
-	if err != nil {
-		return err
+	// Snapshot deployment environments and check their protection rules; without the environments analyzer
+	// they are not fetched, and the stored snapshot is kept
+	var environmentFindings []Finding
+	if analyzerEnabled("environments") {
+		if err := updateEnvironmentIndex(dbPath, repoName, files.Environments); err != nil {
+			logErrorf("Error updating environment index for %s: %v", repoName, err)
+		}
+		environmentFindings = analyzeEnvironments(org, repoName, files.Environments)
 	}
+	for _, finding := range environmentFindings {
+		logInfof("%s: %s in %s", strings.ToUpper(finding.Severity), finding.Message, finding.RepoName)
+	}
+	findings = append(findings, environmentFindings...)
 
-	core := rate.GetCore()
-	if core.Remaining < 100 {
-		waitDuration := time.Until(core.Reset.Time) + time.Minute
-		fmt.Printf("Rate limit low (%d remaining). Waiting for %v...\n", core.Remaining, waitDuration)
-		time.Sleep(waitDuration)
+	// Record the default workflow token settings and check the jobs that rely on them
+	if err := updateTokenDefaultsIndex(dbPath, repoName, files.TokenDefaults); err != nil {
+		logErrorf("Error updating workflow permissions index for %s: %v", repoName, err)
+	}
+	if analyzerEnabled("permissions") {
+		var tokenFindings []Finding
+		for _, wf := range workflows {
+			workflowFindings := analyzeTokenDefaults(repoName, wf.FilePath, wf.Content, files.TokenDefaults)
+			annotateFindings(workflowFindings, extractAnnotations(wf.Content, annotationKeys))
+			tokenFindings = append(tokenFindings, workflowFindings...)
+		}
+		tokenFindings = append(tokenFindings, analyzePullRequestApproval(org, repoName, files.TokenDefaults)...)
+		for _, finding := range tokenFindings {
+			logInfof("%s: %s in %s", strings.ToUpper(finding.Severity), finding.Message, finding.RepoName)
+		}
+		findings = append(findings, tokenFindings...)
 	}
 
-	return nil
+	return findings, changes, nil
 }
 
-// ------------------------
-// Section: Audit Function
-// ------------------------
+// addActionUses adds the action uses of a workflow file to the uses index.
+func addActionUses(usesIndex *ActionUsesIndex, uses []ActionUse) {
+	for _, use := range uses {
+		if _, ok := usesIndex.Actions[use.Action]; !ok {
+			usesIndex.Actions[use.Action] = make(map[string][]WorkflowReference)
+		}
+		usesIndex.Actions[use.Action][use.Version] = append(
+			usesIndex.Actions[use.Action][use.Version],
+			WorkflowReference{
+				RepoName: use.RepoName,
+				FilePath: use.FilePath,
+			},
+		)
+	}
+}
 
 // auditGitHubActions orchestrates the entire audit process.
-func auditGitHubActions(org, token, dbPath string, includePub, includePrv bool) error {
-	client := getGitHubClient(token)
+func auditGitHubActions(opts AuditOptions) error {
+	runStart := time.Now()
+	client := getGitHubClient(opts.Token)
+	org := opts.Org
+	dbPath := opts.DBPath
 
 	// Initialize DB
 	if err := initializeDB(dbPath); err != nil {
 		return fmt.Errorf("failed to initialize database: %v", err)
 	}
 
+	// Compare the database with the state the last run left it in, before this run changes it
+	divergences, err := checkDBState(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to check the database against its recorded state: %v", err)
+	}
+	if opts.Repair {
+		state, err := loadDBState(dbPath)
+		if err != nil {
+			return fmt.Errorf("failed to load the recorded database state: %v", err)
+		}
+		if state == nil {
+			return fmt.Errorf("the database has no %s to repair against; run a full index first", dbStateFile)
+		}
+	}
+	diverged := divergedRepositories(divergences)
+	if len(divergences) > 0 {
+		logWarnf("The database changed outside dotgithubindexer since the last run for %d repositories:", len(diverged))
+		for _, divergence := range divergences {
+			logWarnf("  %s: %s", divergence.Repository, divergence.Reason)
+		}
+		if err := removeCorruptVersions(divergences); err != nil {
+			return fmt.Errorf("failed to remove corrupt stored versions: %v", err)
+		}
+	}
+
+	// Fold directories indexed under variant file names into their logical workflow
+	if err := mergeWorkflowVariants(dbPath); err != nil {
+		return fmt.Errorf("failed to merge workflow file name variants: %v", err)
+	}
+
+	if err := loadDenylist(dbPath); err != nil {
+		return fmt.Errorf("failed to load denylist: %v", err)
+	}
+
+	if err := loadBudgets(dbPath); err != nil {
+		return fmt.Errorf("failed to load budgets: %v", err)
+	}
+	if err := loadEnvNaming(dbPath); err != nil {
+		return fmt.Errorf("failed to load env naming convention: %v", err)
+	}
+	if err := loadSeverityOverrides(dbPath); err != nil {
+		return fmt.Errorf("failed to load severity overrides: %v", err)
+	}
+	if err := loadFreeze(dbPath); err != nil {
+		return fmt.Errorf("failed to load freeze: %v", err)
+	}
+
+	if err := loadAnnotationKeys(dbPath); err != nil {
+		return fmt.Errorf("failed to load annotation keys: %v", err)
+	}
+
+	if workflowFetchMode == fetchModeTree {
+		blobs, err := loadStoredWorkflowBlobs(dbPath)
+		if err != nil {
+			return fmt.Errorf("failed to load stored workflow blobs: %v", err)
+		}
+		storedWorkflowBlobs = blobs
+	}
+
 	dotfilesConfig, err := loadDotfilesConfig(dbPath)
 	if err != nil {
 		return fmt.Errorf("failed to load dotfiles config: %v", err)
 	}
 	dotfilesEnabled := dotfilesConfig != nil && len(dotfilesConfig.Dotfiles) > 0
-	if !dotfilesEnabled {
+	var dotfilePaths []string
+	if dotfilesEnabled {
+		dotfilePaths = dotfilesConfig.Dotfiles
+	} else {
 		if err := clearDotfilesOutput(dbPath); err != nil {
 			return fmt.Errorf("failed to clear dotfiles output: %v", err)
 		}
 	}
 
+	notificationConfig, err := loadNotificationConfig(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to load notification config: %v", err)
+	}
+
+	var scope *ScanScope
+	if opts.Profile != "" {
+		scope, err = loadScanScope(dbPath, opts.Profile)
+		if err != nil {
+			return fmt.Errorf("failed to load scan scope: %v", err)
+		}
+	}
+
+	// The -analyzers flag takes precedence over the profile's analyzer selection
+	analyzerSelection := opts.Analyzers
+	if analyzerSelection == "" && scope != nil {
+		analyzerSelection = strings.Join(scope.Analyzers, ",")
+	}
+	if err := setAnalyzerSelection(analyzerSelection); err != nil {
+		return fmt.Errorf("invalid analyzer selection: %v", err)
+	}
+
 	// Initialize action uses index
 	usesIndex := &ActionUsesIndex{
 		Actions: make(map[string]map[string][]WorkflowReference),
 	}
 
+	var findings []Finding
+	var workflowChanges []WorkflowChange
+
 	// Fetch Repositories
-	repos, err := fetchRepositories(client, org, includePub, includePrv)
+	var repos []*github.Repository
+	if len(opts.Repositories) > 0 {
+		repos, err = fetchSelectedRepositories(client, org, opts.Repositories, opts.IncludePublic, opts.IncludePrivate)
+	} else if opts.Installation {
+		repos, err = fetchInstallationRepositories(client, org, opts.IncludePublic, opts.IncludePrivate, opts.Filter)
+	} else if opts.User {
+		repos, err = fetchUserRepositories(client, org, opts.IncludePublic, opts.IncludePrivate, opts.Filter)
+	} else {
+		repos, err = fetchRepositories(client, org, opts.IncludePublic, opts.IncludePrivate, opts.Filter)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to fetch repositories: %v", err)
 	}
+	// Repositories named with -repo are indexed even when the profile would skip them
+	if len(opts.Repositories) == 0 {
+		repos = applyScanScope(repos, scope, time.Now())
+	}
+	if opts.Shard != nil {
+		repos = applyShard(repos, *opts.Shard)
+	}
 
-	for _, repo := range repos {
-		repoName := repo.GetName()
-		fmt.Printf("Processing repository: %s\n", repoName)
-
-		// Update repositories manifest
-		if err := updateRepositoriesManifest(dbPath, repoName); err != nil {
-			fmt.Printf("Error updating repositories manifest for %s: %v\n", repoName, err)
-			continue
+	// Repositories not pushed to since they were last indexed keep their stored results, and so do those
+	// a time-boxed run does not reach
+	var scanState *ScanState
+	listedRepos := repos
+	skippedRepos := make(map[string]bool)
+	settings := ScanSettings{
+		Version:      Version,
+		Dotfiles:     dotfilePaths,
+		Paths:        workflowPaths,
+		Renovate:     opts.Renovate,
+		DotGitHubAll: opts.DotGitHubAll,
+	}
+	if opts.Incremental || opts.MaxDuration > 0 {
+		scanState, err = loadScanState(dbPath)
+		if err != nil {
+			return fmt.Errorf("failed to load scan state: %v", err)
 		}
+		scanState.prepare(settings)
+	}
+	if scanState != nil {
+		scanState.forget(diverged)
+	}
+	if len(opts.Repositories) > 0 {
+		skippedRepos, err = unselectedRepositories(dbPath, repos)
+		if err != nil {
+			return err
+		}
+		logInfof("Keeping the stored results of %d repositories that were not selected", len(skippedRepos))
+	}
+	if opts.Repair {
+		repos, skippedRepos = partitionDiverged(repos, diverged)
+		logInfof("Repairing %d repositories that diverged from the recorded state; keeping the stored results of %d", len(repos), len(skippedRepos))
+	}
+	if opts.Incremental {
+		repos, skippedRepos = partitionUnchanged(repos, scanState)
+		logInfof("Skipping %d repositories unchanged since they were last indexed; scanning %d", len(skippedRepos), len(repos))
+	}
 
-		// Fetch workflow files
-		workflows, err := fetchWorkflowFiles(client, repo)
+	// Repositories a stopped run scanned keep their stored results; a stopped run checkpoints those it scanned
+	var mu sync.Mutex
+	checkpoint := &Checkpoint{ScanSettings: settings, Organization: org, StartedAt: runStart.UTC()}
+	if opts.Resume {
+		resumed, err := resumableCheckpoint(dbPath, org, settings)
 		if err != nil {
-			fmt.Printf("Error fetching workflow files for %s: %v\n", repoName, err)
-		} else if len(workflows) == 0 {
-			fmt.Printf("No workflow files to process in repository '%s'.\n", repoName)
+			return fmt.Errorf("failed to load checkpoint: %v", err)
+		}
+		if resumed != nil {
+			var checkpointed map[string]bool
+			repos, checkpointed = partitionCheckpointed(repos, resumed)
+			for repoName := range checkpointed {
+				skippedRepos[repoName] = true
+			}
+			logInfof("Resuming the run started at %s; skipping %d repositories it scanned", formatReportTime(resumed.StartedAt), len(checkpointed))
+			checkpoint = resumed
+		}
+	}
+	stopped := func(reason string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := writeCheckpoint(dbPath, checkpoint); err != nil {
+			logErrorf("Error writing %s: %v", checkpointFile, err)
 		} else {
-			for _, wf := range workflows {
-				actionName := filepath.Base(wf.FilePath)
+			logInfof("Checkpointed %d scanned repositories in %s; run again with -resume to continue after them", len(checkpoint.Repositories), checkpointFile)
+		}
+		if scanState != nil {
+			if err := writeScanState(dbPath, scanState); err != nil {
+				logErrorf("Error writing scan_state.yaml: %v", err)
+			}
+		}
+		return fmt.Errorf("%w %s", errScanStopped, reason)
+	}
 
-				// Update action index
-				if err := updateActionIndex(dbPath, actionName, wf.RepoName, wf.Hash); err != nil {
-					fmt.Printf("Error updating action index for %s in %s: %v\n", actionName, repoName, err)
-					continue
-				}
+	var deadline time.Time
+	if opts.MaxDuration > 0 {
+		deadline = runStart.Add(opts.MaxDuration)
+		sortLeastRecentlyScanned(repos, scanState)
+	}
+	pastDeadline := func() bool {
+		return !deadline.IsZero() && time.Now().After(deadline)
+	}
 
-				// Store action version
-				if err := storeActionVersion(dbPath, actionName, wf.Hash, wf.Content); err != nil {
-					fmt.Printf("Error storing action version for %s in %s: %v\n", actionName, repoName, err)
-					continue
-				}
+	var failedRepos []*github.Repository
+	failureErrors := make(map[string]error)
+	emit := newScanEventEmitter(opts.OnEvent)
 
-				// Extract action uses from workflow content
-				uses := extractActionUses(wf.Content, wf.RepoName, wf.FilePath)
-				for _, use := range uses {
-					// Add to uses index
-					if _, ok := usesIndex.Actions[use.Action]; !ok {
-						usesIndex.Actions[use.Action] = make(map[string][]WorkflowReference)
-					}
-					usesIndex.Actions[use.Action][use.Version] = append(
-						usesIndex.Actions[use.Action][use.Version],
-						WorkflowReference{
-							RepoName: use.RepoName,
-							FilePath: use.FilePath,
-						},
-					)
-				}
-			}
+	// scanRepository fetches a repository concurrently and indexes it while holding the database lock
+	scanRepository := func(repo *github.Repository, attempt int) error {
+		emit(ScanEvent{Type: ScanEventRepoStarted, Repository: repo.GetName(), Attempt: attempt})
+		opts.Progress.Start(repo.GetName())
+		files, err := fetchRepositoryFiles(client, repo, dotfilePaths, opts.Renovate, opts.DotGitHubAll)
+		if err != nil {
+			return err
 		}
 
-		// Fetch dependabot file
-		dependabotFile, err := fetchDependabotFile(client, repo)
+		mu.Lock()
+		defer mu.Unlock()
+		repoFindings, repoChanges, err := indexRepositoryFiles(dbPath, repo.GetName(), files, usesIndex)
 		if err != nil {
-			fmt.Printf("Error fetching dependabot file for %s: %v\n", repoName, err)
-			// Don't continue, this is non-fatal
+			return err
 		}
+		emitRepositoryEvents(emit, repo.GetName(), files, repoFindings, repoChanges)
+		if scanState != nil {
+			scanState.record(repo, time.Now())
+		}
+		checkpoint.Repositories = append(checkpoint.Repositories, repo.GetName())
+		checkpoint.LastRepository = repo.GetName()
+		findings = append(findings, repoFindings...)
+		workflowChanges = append(workflowChanges, repoChanges...)
+		return nil
+	}
 
-		if dependabotFile != nil {
-			// Update dependabot index
-			if err := updateDependabotIndex(dbPath, dependabotFile.RepoName, dependabotFile.Hash, dependabotFile.Category); err != nil {
-				fmt.Printf("Error updating dependabot index for %s: %v\n", repoName, err)
-			}
+	opts.Progress.Begin(len(repos))
+	tuner := newConcurrencyTuner(opts.Concurrency, opts.Adaptive)
+	var wg sync.WaitGroup
+	var rateLimitErr error
 
-			// Store dependabot version
-			if err := storeDependabotVersion(dbPath, dependabotFile.Category, dependabotFile.Hash, dependabotFile.Content); err != nil {
-				fmt.Printf("Error storing dependabot version for %s: %v\n", repoName, err)
+	for i, repo := range repos {
+		tuner.acquire()
+
+		mu.Lock()
+		stop := rateLimitErr != nil || stopRequested(opts.Stop)
+		mu.Unlock()
+		if stop {
+			tuner.release(0, nil, nil)
+			break
+		}
+		if pastDeadline() {
+			tuner.release(0, nil, nil)
+			for _, rest := range repos[i:] {
+				skippedRepos[rest.GetName()] = true
 			}
+			logInfof("Reached the maximum duration of %v; leaving %d repositories to later runs", opts.MaxDuration, len(repos)-i)
+			break
 		}
 
-		if dotfilesEnabled {
-			dotfiles, err := fetchConfiguredDotfiles(client, repo, dotfilesConfig.Dotfiles)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			repoName := repo.GetName()
+			logInfof("Processing repository: %s", repoName)
+
+			start := time.Now()
+			err := scanRepository(repo, 1)
+			latency := time.Since(start)
+
+			// Handle rate limiting after processing each repository
+			rate, rateErr := checkRateLimit(client)
+			opts.Progress.Finished(rate)
+
+			mu.Lock()
 			if err != nil {
-				fmt.Printf("Error fetching configured dotfiles for %s: %v\n", repoName, err)
+				logWarnf("Error processing repository %s: %v. Queued for retry.", repoName, err)
+				failedRepos = append(failedRepos, repo)
+				failureErrors[repoName] = err
+				emit(ScanEvent{Type: ScanEventRepoFailed, Repository: repoName, Attempt: 1, Error: err.Error(), Final: opts.Retries == 0})
+			}
+			if rateErr != nil && rateLimitErr == nil {
+				rateLimitErr = rateErr
+			}
+			mu.Unlock()
+
+			tuner.release(latency, rate, err)
+		}()
+	}
+	wg.Wait()
+
+	if rateLimitErr != nil {
+		return fmt.Errorf("rate limit check failed: %v", rateLimitErr)
+	}
+	// Reports and garbage collection need every repository, so a stopped run ends without them
+	if stopRequested(opts.Stop) {
+		return stopped("before all repositories were scanned")
+	}
+
+	// Retry repositories that failed during the run
+	for attempt := 1; attempt <= opts.Retries && len(failedRepos) > 0 && !pastDeadline(); attempt++ {
+		backoff := time.Duration(attempt) * retryBackoff
+		logInfof("Retrying %d failed repositories (attempt %d of %d) after %v", len(failedRepos), attempt, opts.Retries, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-opts.Stop:
+			return stopped("before failed repositories were retried")
+		}
+
+		var stillFailing []*github.Repository
+		for _, repo := range failedRepos {
+			if stopRequested(opts.Stop) {
+				return stopped("before failed repositories were retried")
+			}
+			repoName := repo.GetName()
+			logInfof("Retrying repository: %s", repoName)
+
+			if err := scanRepository(repo, attempt+1); err != nil {
+				logWarnf("Retry %d failed for repository %s: %v", attempt, repoName, err)
+				stillFailing = append(stillFailing, repo)
+				failureErrors[repoName] = err
+				emit(ScanEvent{Type: ScanEventRepoFailed, Repository: repoName, Attempt: attempt + 1, Error: err.Error(), Final: attempt == opts.Retries})
 			} else {
-				for _, dotfile := range dotfiles {
-					if err := updateDotfileIndex(dbPath, dotfile.FilePath, dotfile.RepoName, dotfile.Hash, dotfile.Category); err != nil {
-						fmt.Printf("Error updating dotfile index for %s in %s: %v\n", dotfile.FilePath, repoName, err)
-						continue
-					}
-					if err := storeDotfileVersion(dbPath, dotfile.FilePath, dotfile.Hash, dotfile.Content); err != nil {
-						fmt.Printf("Error storing dotfile version for %s in %s: %v\n", dotfile.FilePath, repoName, err)
-					}
-				}
+				delete(failureErrors, repoName)
+			}
+
+			rate, err := checkRateLimit(client)
+			if err != nil {
+				return fmt.Errorf("rate limit check failed: %v", err)
 			}
+			opts.Progress.RateLimit(rate)
+		}
+		failedRepos = stillFailing
+	}
+
+	if hits, misses := fetchedBlobs.stats(); hits > 0 {
+		logInfof("Reused %d identical files already fetched this run; fetched %d blobs", hits, misses)
+	}
+	if httpResponseCache != nil {
+		revalidated, stored := httpResponseCache.stats()
+		logInfof("HTTP cache: %d responses unchanged since they were cached, %d responses cached", revalidated, stored)
+	}
+	if githubTokens != nil {
+		logInfof("Switched GitHub tokens %d times", githubTokens.stats())
+	}
+
+	if len(skippedRepos) > 0 {
+		skippedUses, skippedFindings, err := collectIndexedResults(dbPath, org, skippedRepos)
+		if err != nil {
+			return fmt.Errorf("failed to collect results of skipped repositories: %v", err)
 		}
+		mergeActionUses(usesIndex, skippedUses)
+		findings = append(findings, skippedFindings...)
+	}
 
-		// Handle rate limiting after processing each repository
-		if err := checkRateLimit(client); err != nil {
-			return fmt.Errorf("rate limit check failed: %v", err)
+	if scanState != nil {
+		scanState.prune(listedRepos)
+		if err := writeScanState(dbPath, scanState); err != nil {
+			logErrorf("Error writing scan_state.yaml: %v", err)
 		}
 	}
 
-	// Perform garbage collection
-	if err := garbageCollect(dbPath); err != nil {
-		fmt.Printf("Error during garbage collection: %v\n", err)
+	// The scan completed, so the checkpoint of a stopped run is no longer needed
+	if err := removeCheckpoint(dbPath); err != nil {
+		logErrorf("Error removing %s: %v", checkpointFile, err)
 	}
 
-	// Perform dependabot garbage collection
-	if err := garbageCollectDependabot(dbPath); err != nil {
-		fmt.Printf("Error during dependabot garbage collection: %v\n", err)
+	// Record repositories that failed after all retries
+	if err := writeErrorsManifest(dbPath, failureErrors, opts.Retries+1); err != nil {
+		logErrorf("Error writing errors.yaml: %v", err)
 	}
 
-	if dotfilesEnabled {
-		if err := garbageCollectDotfiles(dbPath); err != nil {
-			fmt.Printf("Error during configured dotfile garbage collection: %v\n", err)
+	// Attach audit-log context to this run's workflow changes before the change logs are rendered
+	if opts.AuditLog && len(workflowChanges) > 0 {
+		since := auditLogWindowStart(dbPath, time.Now())
+		correlateAuditLog(dbPath, fetchAuditLogEntries(client, org), workflowChanges, since)
+	}
+
+	// A shard leaves garbage collection and reports to the merge of every shard
+	if opts.Shard != nil {
+		return writeShardManifest(dbPath, org, *opts.Shard, repos)
+	}
+
+	completeRun(client, dbPath, org, dotfilesEnabled, opts.ExternalConsumers, notificationConfig, usesIndex, findings, workflowChanges)
+	return nil
+}
+
+// completeRun garbage collects the database, generates the reports, notifies the configured sinks,
+// and records a metrics snapshot from the results of a scan. Both full runs and merges of shards end here.
+func completeRun(client *github.Client, dbPath, org string, dotfilesEnabled, externalConsumers bool, notificationConfig *NotificationConfig, usesIndex *ActionUsesIndex, findings []Finding, workflowChanges []WorkflowChange) {
+	collectGarbage(dbPath, dotfilesEnabled)
+
+	// Check the workflows required by organization rulesets against the index, now that it is complete
+	var requiredWorkflowEvents []NotificationEvent
+	if analyzerEnabled("rulesets") {
+		requiredFindings, events, err := updateRequiredWorkflows(client, dbPath, org)
+		if err != nil {
+			logErrorf("Error checking required workflows: %v", err)
+		}
+		findings = append(findings, requiredFindings...)
+		requiredWorkflowEvents = events
+	}
+
+	// Search for workflows outside the organization that use its actions, for EXPOSURE.md
+	if externalConsumers {
+		if err := updateExternalConsumers(client, dbPath, org, usesIndex); err != nil {
+			logErrorf("Error searching for external consumers: %v", err)
 		}
 	}
 
-	// Generate README.md files
-	if err := generateReadmeFiles(dbPath, org); err != nil {
-		fmt.Printf("Error generating README.md files: %v\n", err)
+	// Generate the reports that only read the database in parallel
+	runReportGenerators(databaseReportGenerators(dbPath, org, dotfilesEnabled))
+
+	// Follow composite actions so transitive dependencies appear in the uses index
+	resolveCompositeDependencies(org, usesIndex, fetchActionDefinition(client))
+
+	// Fetch marketplace metadata for third-party actions and record it in the database
+	actionMetadata := updateActionMetadataIndex(dbPath, org, usesIndex, fetchActionMetadata(client, org, usesIndex), time.Now())
+
+	// Refresh the cached releases of third-party actions for the updates report
+	releaseCache := updateReleaseCache(dbPath, org, usesIndex, fetchReleases(client), time.Now())
+
+	// Generate the reports built from this run's uses index and findings
+	runReportGenerators(indexReportGenerators(dbPath, org, usesIndex, findings, actionMetadata.Actions, releaseCache))
+
+	// Queue the third-party actions new since the previous run for review, before its snapshot is replaced
+	if _, err := updateReviewQueue(dbPath, org, usesIndex, time.Now()); err != nil {
+		logErrorf("Error updating the action review queue: %v", err)
 	}
 
-	// Generate README.md files for dependabot
-	if err := generateDependabotReadmeFiles(dbPath, org); err != nil {
-		fmt.Printf("Error generating dependabot README.md files: %v\n", err)
+	// Follow up on the remediation pull requests opened by the freeze command
+	if _, err := followUpRemediations(dbPath, org, fetchPullRequestState(client), deleteBranch(client), time.Now()); err != nil {
+		logErrorf("Error following up on remediation pull requests: %v", err)
 	}
 
-	if dotfilesEnabled {
-		if err := generateDotfileReadmeFiles(dbPath, org); err != nil {
-			fmt.Printf("Error generating configured dotfile README.md files: %v\n", err)
+	// Notify configured sinks of changes since the previous run, before its snapshot is replaced
+	if notificationConfig != nil {
+		history, err := loadMetricsHistory(dbPath)
+		if err != nil {
+			logErrorf("Error loading metrics history for notifications: %v", err)
+		} else {
+			var previous *MetricsSnapshot
+			if n := len(history.Snapshots); n > 0 {
+				previous = &history.Snapshots[n-1]
+			}
+			events := buildNotificationEvents(org, previous, findings, usesIndex, workflowChanges)
+			events = append(events, requiredWorkflowEvents...)
+			sendNotifications(notificationConfig, org, events, time.Now())
 		}
 	}
 
-	// Generate summary README.md in db folder
-	if err := generateDBSummary(dbPath); err != nil {
-		fmt.Printf("Error generating DB summary README.md: %v\n", err)
+	// Record metrics for trend reports
+	if err := recordMetricsSnapshot(dbPath, org, usesIndex, findings); err != nil {
+		logErrorf("Error recording metrics snapshot: %v", err)
 	}
 
-	// Generate USES.md file
-	if err := generateUSESMarkdown(dbPath, org, usesIndex); err != nil {
-		fmt.Printf("Error generating USES.md: %v\n", err)
+	// Record the state the run leaves the database in, to detect changes made outside the tool
+	if err := recordDBState(dbPath, !storeContent, time.Now()); err != nil {
+		logErrorf("Error recording the database state: %v", err)
 	}
 
-	return nil
+	// Copy the manifests and indexes to JSON last, so the copies include this run's metrics
+	if dbFormat == formatJSON {
+		written, err := writeJSONCopies(dbPath)
+		if err != nil {
+			logErrorf("Error writing JSON copies: %v", err)
+		} else {
+			logInfof("Wrote JSON copies of %d YAML files", written)
+		}
+	}
 }
 
 // generateReadmeFiles creates README.md files in each action directory with links to workflow files.
@@ -1394,8 +2961,9 @@ func generateReadmeFiles(dbPath, org string) error {
 	if err != nil {
 		return fmt.Errorf("failed to read actions directory: %v", err)
 	}
+	defaultBranches := loadDefaultBranches(dbPath)
 
-	for _, dir := range dirs {
+	forEachParallel(dirs, func(dir os.DirEntry) {
 		if dir.IsDir() {
 			actionName := dir.Name()
 			indexPath := filepath.Join(actionsPath, actionName, "index.yaml")
@@ -1403,14 +2971,14 @@ func generateReadmeFiles(dbPath, org string) error {
 
 			data, err := os.ReadFile(indexPath)
 			if err != nil {
-				fmt.Printf("Skipping action '%s' due to missing index.yaml.\n", actionName)
-				continue
+				logDebugf("Skipping action '%s' due to missing index.yaml.", actionName)
+				return
 			}
 
 			err = yaml.Unmarshal(data, &index)
 			if err != nil {
-				fmt.Printf("Error parsing index.yaml for action '%s': %v\n", actionName, err)
-				continue
+				logErrorf("Error parsing index.yaml for action '%s': %v", actionName, err)
+				return
 			}
 
 			// Reverse mapping from hash to repositories
@@ -1419,38 +2987,43 @@ func generateReadmeFiles(dbPath, org string) error {
 				hashToRepos[hash] = append(hashToRepos[hash], repo)
 			}
 
-			// Sort hash keys alphabetically
+			// Sort hash keys chronologically by first-seen date
 			var hashes []string
 			for hash := range hashToRepos {
 				hashes = append(hashes, hash)
 			}
-			sort.Strings(hashes)
+			sortVersionsChronologically(hashes, index.Versions)
 
 			var markdownBuilder strings.Builder
 			markdownBuilder.WriteString(fmt.Sprintf("# %s\n\n", actionName))
+			if _, err := os.Stat(filepath.Join(actionsPath, actionName, "changelog.yaml")); err == nil {
+				markdownBuilder.WriteString("See [CHANGELOG.md](CHANGELOG.md) for the history of changes to this workflow.\n\n")
+			}
 			for _, hash := range hashes {
 				repos := hashToRepos[hash]
 				// Sort repository names alphabetically
 				sort.Strings(repos)
-				markdownBuilder.WriteString(fmt.Sprintf("## [%s](%s)\n\n", hash, hash))
+				markdownBuilder.WriteString(fmt.Sprintf("## %s\n\n", versionReference(filepath.Join(actionsPath, actionName), hash)))
+				markdownBuilder.WriteString(fmt.Sprintf("**Current** · First seen %s\n\n", dateOrUnknown(index.Versions[hash].FirstSeen)))
 				for _, repo := range repos {
-					filePath := ".github/workflows/" + actionName
-					url := fmt.Sprintf("https://github.com/%s/%s/blob/main/%s", org, repo, filePath)
+					filePath := index.source(repo).repositoryPath(index.fileName(repo, actionName))
+					url := repositoryFileURL(org, repo, defaultBranches, filePath)
 					markdownBuilder.WriteString(fmt.Sprintf("- [%s](%s)\n", repo, url))
 				}
 				markdownBuilder.WriteString("\n")
 			}
+			markdownBuilder.WriteString(formatHistoricalVersions(index))
 
 			readmePath := filepath.Join(actionsPath, actionName, "README.md")
 			err = os.WriteFile(readmePath, []byte(markdownBuilder.String()), 0644)
 			if err != nil {
-				fmt.Printf("Error writing README.md for action '%s': %v\n", actionName, err)
-				continue
+				logErrorf("Error writing README.md for action '%s': %v", actionName, err)
+				return
 			}
 
-			fmt.Printf("Generated README.md for action '%s'\n", actionName)
+			logDebugf("Generated README.md for action '%s'", actionName)
 		}
-	}
+	})
 
 	return nil
 }
@@ -1460,7 +3033,7 @@ func generateReadmeFiles(dbPath, org string) error {
 func generateDependabotReadmeFiles(dbPath, org string) error {
 	dependabotPath := filepath.Join(dbPath, "dependabot")
 	if _, err := os.Stat(dependabotPath); os.IsNotExist(err) {
-		fmt.Printf("No 'dependabot' directory found at '%s'. Skipping README generation.\n", dependabotPath)
+		logInfof("No 'dependabot' directory found at '%s'. Skipping README generation.", dependabotPath)
 		return nil
 	}
 
@@ -1469,7 +3042,7 @@ func generateDependabotReadmeFiles(dbPath, org string) error {
 		return fmt.Errorf("failed to read dependabot directory: %v", err)
 	}
 
-	for _, dir := range dirs {
+	forEachParallel(dirs, func(dir os.DirEntry) {
 		if dir.IsDir() {
 			categoryName := dir.Name()
 			indexPath := filepath.Join(dependabotPath, categoryName, "index.yaml")
@@ -1477,14 +3050,14 @@ func generateDependabotReadmeFiles(dbPath, org string) error {
 
 			data, err := os.ReadFile(indexPath)
 			if err != nil {
-				fmt.Printf("Skipping dependabot category '%s' due to missing index.yaml.\n", categoryName)
-				continue
+				logDebugf("Skipping dependabot category '%s' due to missing index.yaml.", categoryName)
+				return
 			}
 
 			err = yaml.Unmarshal(data, &index)
 			if err != nil {
-				fmt.Printf("Error parsing index.yaml for dependabot category '%s': %v\n", categoryName, err)
-				continue
+				logErrorf("Error parsing index.yaml for dependabot category '%s': %v", categoryName, err)
+				return
 			}
 
 			// Reverse mapping from hash to repositories
@@ -1506,10 +3079,10 @@ func generateDependabotReadmeFiles(dbPath, org string) error {
 				repos := hashToRepos[hash]
 				// Sort repository names alphabetically
 				sort.Strings(repos)
-				markdownBuilder.WriteString(fmt.Sprintf("## [%s](%s)\n\n", hash, hash))
+				markdownBuilder.WriteString(fmt.Sprintf("## %s\n\n", versionReference(filepath.Join(dependabotPath, categoryName), hash)))
 				for _, repo := range repos {
-					filePath := ".github/dependabot.yml"
-					url := fmt.Sprintf("https://github.com/%s/%s/blob/main/%s", org, repo, filePath)
+					filePath := index.fileName(repo, dependabotLogicalPath)
+					url := fmt.Sprintf("%s/%s/%s/blob/main/%s", githubWebURL, org, repo, filePath)
 					markdownBuilder.WriteString(fmt.Sprintf("- [%s](%s)\n", repo, url))
 				}
 				markdownBuilder.WriteString("\n")
@@ -1518,13 +3091,13 @@ func generateDependabotReadmeFiles(dbPath, org string) error {
 			readmePath := filepath.Join(dependabotPath, categoryName, "README.md")
 			err = os.WriteFile(readmePath, []byte(markdownBuilder.String()), 0644)
 			if err != nil {
-				fmt.Printf("Error writing README.md for dependabot category '%s': %v\n", categoryName, err)
-				continue
+				logErrorf("Error writing README.md for dependabot category '%s': %v", categoryName, err)
+				return
 			}
 
-			fmt.Printf("Generated README.md for dependabot category '%s'\n", categoryName)
+			logDebugf("Generated README.md for dependabot category '%s'", categoryName)
 		}
-	}
+	})
 
 	return nil
 }
@@ -1536,17 +3109,17 @@ func generateDotfileReadmeFiles(dbPath, org string) error {
 		return fmt.Errorf("failed to inspect dotfiles directory: %v", err)
 	}
 	if len(dotfilePaths) == 0 {
-		fmt.Printf("No 'dotfiles' directory found at '%s'. Skipping README generation.\n", filepath.Join(dbPath, "dotfiles"))
+		logInfof("No 'dotfiles' directory found at '%s'. Skipping README generation.", filepath.Join(dbPath, "dotfiles"))
 		return nil
 	}
 
 	useCategories := dotfilesUseCategories(dbPath)
 
-	for _, dotfilePath := range dotfilePaths {
+	forEachParallel(dotfilePaths, func(dotfilePath string) {
 		index, err := loadDotfileIndex(dbPath, dotfilePath)
 		if err != nil {
-			fmt.Printf("Skipping configured dotfile '%s' due to missing index.yaml.\n", dotfilePath)
-			continue
+			logDebugf("Skipping configured dotfile '%s' due to missing index.yaml.", dotfilePath)
+			return
 		}
 
 		var markdownBuilder strings.Builder
@@ -1583,9 +3156,9 @@ func generateDotfileReadmeFiles(dbPath, org string) error {
 				for _, hash := range hashes {
 					repos := categoryToHashes[category][hash]
 					sort.Strings(repos)
-					markdownBuilder.WriteString(fmt.Sprintf("### [%s](%s)\n\n", hash, hash))
+					markdownBuilder.WriteString(fmt.Sprintf("### %s\n\n", versionReference(dotfileStoragePath(dbPath, dotfilePath), hash)))
 					for _, repo := range repos {
-						url := fmt.Sprintf("https://github.com/%s/%s/blob/main/%s", org, repo, dotfilePath)
+						url := fmt.Sprintf("%s/%s/%s/blob/main/%s", githubWebURL, org, repo, dotfilePath)
 						markdownBuilder.WriteString(fmt.Sprintf("- [%s](%s)\n", repo, url))
 					}
 					markdownBuilder.WriteString("\n")
@@ -1606,9 +3179,9 @@ func generateDotfileReadmeFiles(dbPath, org string) error {
 			for _, hash := range hashes {
 				repos := hashToRepos[hash]
 				sort.Strings(repos)
-				markdownBuilder.WriteString(fmt.Sprintf("## [%s](%s)\n\n", hash, hash))
+				markdownBuilder.WriteString(fmt.Sprintf("## %s\n\n", versionReference(dotfileStoragePath(dbPath, dotfilePath), hash)))
 				for _, repo := range repos {
-					url := fmt.Sprintf("https://github.com/%s/%s/blob/main/%s", org, repo, dotfilePath)
+					url := fmt.Sprintf("%s/%s/%s/blob/main/%s", githubWebURL, org, repo, dotfilePath)
 					markdownBuilder.WriteString(fmt.Sprintf("- [%s](%s)\n", repo, url))
 				}
 				markdownBuilder.WriteString("\n")
@@ -1617,12 +3190,12 @@ func generateDotfileReadmeFiles(dbPath, org string) error {
 
 		readmePath := filepath.Join(dotfileStoragePath(dbPath, dotfilePath), "README.md")
 		if err := os.WriteFile(readmePath, []byte(markdownBuilder.String()), 0644); err != nil {
-			fmt.Printf("Error writing README.md for configured dotfile '%s': %v\n", dotfilePath, err)
-			continue
+			logErrorf("Error writing README.md for configured dotfile '%s': %v", dotfilePath, err)
+			return
 		}
 
-		fmt.Printf("Generated README.md for configured dotfile '%s'\n", dotfilePath)
-	}
+		logDebugf("Generated README.md for configured dotfile '%s'", dotfilePath)
+	})
 
 	return nil
 }
@@ -1631,7 +3204,7 @@ func generateDotfileReadmeFiles(dbPath, org string) error {
 func generateDBSummary(dbPath string) error {
 	actionsPath := filepath.Join(dbPath, "workflows")
 	if _, err := os.Stat(actionsPath); os.IsNotExist(err) {
-		fmt.Printf("No 'workflows' directory found at '%s'. Skipping summary generation.\n", actionsPath)
+		logInfof("No 'workflows' directory found at '%s'. Skipping summary generation.", actionsPath)
 		return nil
 	}
 
@@ -1656,13 +3229,13 @@ func generateDBSummary(dbPath string) error {
 			var index ActionIndex
 			data, err := os.ReadFile(indexPath)
 			if err != nil {
-				fmt.Printf("Skipping workflow '%s' due to missing index.yaml.\n", workflowName)
+				logDebugf("Skipping workflow '%s' due to missing index.yaml.", workflowName)
 				continue
 			}
 
 			err = yaml.Unmarshal(data, &index)
 			if err != nil {
-				fmt.Printf("Error parsing index.yaml for workflow '%s': %v\n", workflowName, err)
+				logErrorf("Error parsing index.yaml for workflow '%s': %v", workflowName, err)
 				continue
 			}
 
@@ -1707,13 +3280,13 @@ func generateDBSummary(dbPath string) error {
 					var index ActionIndex
 					data, err := os.ReadFile(indexPath)
 					if err != nil {
-						fmt.Printf("Skipping dependabot category '%s' due to missing index.yaml.\n", categoryName)
+						logDebugf("Skipping dependabot category '%s' due to missing index.yaml.", categoryName)
 						continue
 					}
 
 					err = yaml.Unmarshal(data, &index)
 					if err != nil {
-						fmt.Printf("Error parsing index.yaml for dependabot category '%s': %v\n", categoryName, err)
+						logErrorf("Error parsing index.yaml for dependabot category '%s': %v", categoryName, err)
 						continue
 					}
 
@@ -1754,7 +3327,7 @@ func generateDBSummary(dbPath string) error {
 		for _, dotfilePath := range dotfilePaths {
 			index, err := loadDotfileIndex(dbPath, dotfilePath)
 			if err != nil {
-				fmt.Printf("Skipping configured dotfile '%s' due to missing index.yaml.\n", dotfilePath)
+				logDebugf("Skipping configured dotfile '%s' due to missing index.yaml.", dotfilePath)
 				continue
 			}
 
@@ -1855,6 +3428,12 @@ func generateDBSummary(dbPath string) error {
 		}
 	}
 
+	if pinning, err := buildUnpinnedReport(dbPath); err != nil {
+		logErrorf("Error summarizing pinned references: %v", err)
+	} else if pinning.TotalUses > 0 {
+		markdownBuilder.WriteString(formatPinningSummary(pinning))
+	}
+
 	markdownBuilder.WriteString("\n*This file is automatically generated after each data collection run.*\n")
 
 	// Write to README.md in db folder
@@ -1864,14 +3443,14 @@ func generateDBSummary(dbPath string) error {
 		return fmt.Errorf("error writing DB summary README.md: %v", err)
 	}
 
-	fmt.Printf("Generated DB summary README.md with %d workflows, %d dependabot categories, and %d configured dotfiles\n", len(summaries), len(dependabotSummaries), len(dotfileSummaries))
+	logInfof("Generated DB summary README.md with %d workflows, %d dependabot categories, and %d configured dotfiles", len(summaries), len(dependabotSummaries), len(dotfileSummaries))
 	return nil
 }
 
 // generateUSESMarkdown creates a USES.md file in the db folder that indexes all action uses.
-func generateUSESMarkdown(dbPath, org string, usesIndex *ActionUsesIndex) error {
+func generateUSESMarkdown(dbPath, org string, usesIndex *ActionUsesIndex, actionMetadata map[string]ActionMetadata) error {
 	if usesIndex == nil || len(usesIndex.Actions) == 0 {
-		fmt.Printf("No action uses found. Skipping USES.md generation.\n")
+		logInfof("No action uses found. Skipping USES.md generation.")
 		return nil
 	}
 
@@ -1881,7 +3460,9 @@ func generateUSESMarkdown(dbPath, org string, usesIndex *ActionUsesIndex) error
 	markdownBuilder.WriteString("**Legend:**\n")
 	markdownBuilder.WriteString("- **Action**: The GitHub Action being used (e.g., `actions/checkout`)\n")
 	markdownBuilder.WriteString("- **Version**: The specific version of the action, including any inline comments\n")
-	markdownBuilder.WriteString("- **Usage Count**: The number of workflow files using this specific version\n\n")
+	markdownBuilder.WriteString("- **Usage Count**: The number of workflow files using this specific version\n")
+	markdownBuilder.WriteString("- **via**: The action is used transitively through the listed composite action(s) rather than directly by the workflow\n")
+	markdownBuilder.WriteString("- **Marketplace**: For third-party actions, the verified creator status, star count, latest release date, and archived status of the source repository\n\n")
 
 	// Sort actions alphabetically
 	var actionNames []string
@@ -1910,6 +3491,9 @@ func generateUSESMarkdown(dbPath, org string, usesIndex *ActionUsesIndex) error
 		markdownBuilder.WriteString("---\n\n")
 		markdownBuilder.WriteString(fmt.Sprintf("## %s\n\n", actionName))
 		markdownBuilder.WriteString(fmt.Sprintf("**Total Usage**: %d workflow file(s) across %d version(s)\n\n", totalUsage, len(versions)))
+		if meta, ok := actionMetadata[actionName]; ok {
+			markdownBuilder.WriteString(formatActionMetadata(meta))
+		}
 
 		// For each version, create a collapsible section
 		for _, version := range versionKeys {
@@ -1918,6 +3502,9 @@ func generateUSESMarkdown(dbPath, org string, usesIndex *ActionUsesIndex) error
 			// Sort references by repo name and file path
 			sort.Slice(refs, func(i, j int) bool {
 				if refs[i].RepoName == refs[j].RepoName {
+					if refs[i].FilePath == refs[j].FilePath {
+						return strings.Join(refs[i].Via, " ") < strings.Join(refs[j].Via, " ")
+					}
 					return refs[i].FilePath < refs[j].FilePath
 				}
 				return refs[i].RepoName < refs[j].RepoName
@@ -1943,8 +3530,12 @@ func generateUSESMarkdown(dbPath, org string, usesIndex *ActionUsesIndex) error
 
 			// Show all refs in the collapsible section
 			for _, ref := range refs {
-				url := fmt.Sprintf("https://github.com/%s/%s/blob/main/%s", org, ref.RepoName, ref.FilePath)
-				markdownBuilder.WriteString(fmt.Sprintf("- [%s: %s](%s)\n", ref.RepoName, ref.FilePath, url))
+				url := fmt.Sprintf("%s/%s/%s/blob/main/%s", githubWebURL, org, ref.RepoName, ref.FilePath)
+				markdownBuilder.WriteString(fmt.Sprintf("- [%s: %s](%s)", ref.RepoName, ref.FilePath, url))
+				if len(ref.Via) > 0 {
+					markdownBuilder.WriteString(fmt.Sprintf(" via `%s`", strings.Join(ref.Via, "` → `")))
+				}
+				markdownBuilder.WriteString("\n")
 			}
 
 			markdownBuilder.WriteString("\n</details>\n\n")
@@ -1962,6 +3553,6 @@ func generateUSESMarkdown(dbPath, org string, usesIndex *ActionUsesIndex) error
 		return fmt.Errorf("error writing USES.md: %v", err)
 	}
 
-	fmt.Printf("Generated USES.md with %d actions\n", len(actionNames))
+	logInfof("Generated USES.md with %d actions", len(actionNames))
 	return nil
 }
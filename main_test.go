@@ -1,11 +1,17 @@
 package main
 
 import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
+
+	"github.com/google/go-github/v50/github"
 )
 
 func TestNormalizeDotfilePath(t *testing.T) {
@@ -80,12 +86,15 @@ func TestGenerateDotfileReadmeFilesWithoutCategories(t *testing.T) {
 	t.Parallel()
 
 	dbPath := t.TempDir()
-	if err := updateDotfileIndex(dbPath, ".gitignore", "repo-a", "hash-one", "Default"); err != nil {
+	if err := updateDotfileIndex(dbPath, ".gitignore", "repo-a", "hash-one", "", "Default"); err != nil {
 		t.Fatalf("updateDotfileIndex returned error: %v", err)
 	}
-	if err := updateDotfileIndex(dbPath, ".gitignore", "repo-b", "hash-one", "Default"); err != nil {
+	if err := updateDotfileIndex(dbPath, ".gitignore", "repo-b", "hash-one", "", "Default"); err != nil {
 		t.Fatalf("updateDotfileIndex returned error: %v", err)
 	}
+	if err := storeDotfileVersion(dbPath, ".gitignore", "hash-one", "bin/\n"); err != nil {
+		t.Fatalf("storeDotfileVersion returned error: %v", err)
+	}
 
 	if err := generateDotfileReadmeFiles(dbPath, "UnitVectorY-Labs"); err != nil {
 		t.Fatalf("generateDotfileReadmeFiles returned error: %v", err)
@@ -113,10 +122,10 @@ func TestGenerateDBSummaryIncludesDotfileCategories(t *testing.T) {
 	if err := os.MkdirAll(filepath.Join(dbPath, "workflows", "build.yml"), 0755); err != nil {
 		t.Fatalf("failed to create workflows directory: %v", err)
 	}
-	if err := updateActionIndex(dbPath, "build.yml", "repo-a", "workflow-hash"); err != nil {
+	if err := updateActionIndex(dbPath, "build.yml", "repo-a", "build.yml", "workflow-hash", ""); err != nil {
 		t.Fatalf("updateActionIndex returned error: %v", err)
 	}
-	if err := updateDotfileIndex(dbPath, ".gitignore", "repo-a", "dotfile-hash", "Base"); err != nil {
+	if err := updateDotfileIndex(dbPath, ".gitignore", "repo-a", "dotfile-hash", "", "Base"); err != nil {
 		t.Fatalf("updateDotfileIndex returned error: %v", err)
 	}
 
@@ -145,7 +154,7 @@ func TestClearDotfilesOutputRemovesGeneratedDotfiles(t *testing.T) {
 	t.Parallel()
 
 	dbPath := t.TempDir()
-	if err := updateDotfileIndex(dbPath, ".gitignore", "repo-a", "hash-one", "Default"); err != nil {
+	if err := updateDotfileIndex(dbPath, ".gitignore", "repo-a", "hash-one", "", "Default"); err != nil {
 		t.Fatalf("updateDotfileIndex returned error: %v", err)
 	}
 
@@ -158,6 +167,26 @@ func TestClearDotfilesOutputRemovesGeneratedDotfiles(t *testing.T) {
 	}
 }
 
+func TestWriteErrorsManifest(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	failures := map[string]error{"repo-a": errors.New("502 Bad Gateway")}
+	if err := writeErrorsManifest(dbPath, failures, 3); err != nil {
+		t.Fatalf("writeErrorsManifest returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dbPath, "errors.yaml"))
+	if err != nil {
+		t.Fatalf("failed to read errors.yaml: %v", err)
+	}
+
+	content := string(data)
+	if !strings.Contains(content, "repo-a:") || !strings.Contains(content, "error: 502 Bad Gateway") || !strings.Contains(content, "attempts: 3") {
+		t.Fatalf("unexpected errors.yaml content:\n%s", content)
+	}
+}
+
 func TestBuildVersionOutput(t *testing.T) {
 	t.Parallel()
 
@@ -183,3 +212,106 @@ func TestBuildVersionOutput(t *testing.T) {
 		})
 	}
 }
+
+func TestVerifyBlobContent(t *testing.T) {
+	t.Parallel()
+
+	content := []byte("hello world\n")
+	sha := "3b18e512dba79e4c8300dd08aeb37f8e728b8dad"
+
+	if err := verifyBlobContent(content, sha, len(content)); err != nil {
+		t.Fatalf("verifyBlobContent returned error: %v", err)
+	}
+	if err := verifyBlobContent(content[:5], sha, len(content)); err == nil {
+		t.Fatalf("expected truncated content to fail verification")
+	}
+	if err := verifyBlobContent([]byte("hello world!"), sha, 0); err == nil {
+		t.Fatalf("expected modified content to fail verification")
+	}
+}
+
+func TestUpdateActionIndexRecordsBlobSHA(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	if err := updateActionIndex(dbPath, "build.yml", "repo-a", "build.yml", "hash-one", "blob-one"); err != nil {
+		t.Fatalf("updateActionIndex returned error: %v", err)
+	}
+	if err := updateActionIndex(dbPath, "build.yml", "repo-a", "build.yml", "hash-two", "blob-two"); err != nil {
+		t.Fatalf("updateActionIndex returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dbPath, "workflows", "build.yml", "index.yaml"))
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+	content := string(data)
+	// The replaced version stays in the version history but its blob SHA is dropped
+	if !strings.Contains(content, "hash-two: blob-two") || strings.Contains(content, "hash-one: blob-one") {
+		t.Fatalf("unexpected index.yaml content:\n%s", content)
+	}
+}
+
+func TestParseWorkflowPaths(t *testing.T) {
+	t.Parallel()
+
+	paths, err := parseWorkflowPaths(" .github/workflows, ci/ ,, ./ci,deploy/pipelines")
+	if err != nil {
+		t.Fatalf("parseWorkflowPaths returned error: %v", err)
+	}
+	if strings.Join(paths, ",") != ".github/workflows,ci,deploy/pipelines" {
+		t.Fatalf("unexpected paths: %q", paths)
+	}
+	if paths, err := parseWorkflowPaths(""); err != nil || paths != nil {
+		t.Fatalf("expected no paths for an empty value, got %q, %v", paths, err)
+	}
+	for _, value := range []string{"/etc", "..", "ci/../..", "."} {
+		if _, err := parseWorkflowPaths(value); err == nil {
+			t.Fatalf("expected an error for %q", value)
+		}
+	}
+}
+
+func TestFetchWorkflowFilesFromPaths(t *testing.T) {
+	defer func() { workflowPaths = nil }()
+
+	contents := map[string]string{
+		".github/workflows/build.yml": "on: push\n",
+		"ci/test.yml":                 "on: pull_request\n",
+		"workflows/legacy.yml":        "on: workflow_dispatch\n",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/graphql" {
+			serveGraphQLTrees(t, w, r, contents, nil)
+			return
+		}
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	repo := &github.Repository{Name: github.String("repo-a"), Owner: &github.User{Login: github.String("example-org")}, DefaultBranch: github.String("main")}
+
+	fetchPaths := func() string {
+		workflows, err := fetchWorkflowFiles(client, repo)
+		if err != nil {
+			t.Fatalf("fetchWorkflowFiles returned error: %v", err)
+		}
+		var paths []string
+		for _, wf := range workflows {
+			paths = append(paths, wf.FilePath)
+		}
+		return strings.Join(paths, ",")
+	}
+
+	// By default only .github/workflows is scanned while it exists
+	if got := fetchPaths(); got != ".github/workflows/build.yml" {
+		t.Fatalf("unexpected default workflows: %s", got)
+	}
+
+	workflowPaths = []string{".github/workflows", "ci", "missing"}
+	if got := fetchPaths(); got != ".github/workflows/build.yml,ci/test.yml" {
+		t.Fatalf("unexpected workflows with -paths: %s", got)
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Marketplace Metadata
+// ------------------------
+
+// ActionMetadata holds trust signals for the repository backing a third-party action.
+type ActionMetadata struct {
+	VerifiedCreator bool   `yaml:"verified_creator" json:"verified_creator"`
+	Stars           int    `yaml:"stars" json:"stars"`
+	LatestRelease   string `yaml:"latest_release,omitempty" json:"latest_release,omitempty"` // Date of the latest release formatted as YYYY-MM-DD, empty if none
+	Archived        bool   `yaml:"archived" json:"archived"`
+	Fetched         string `yaml:"fetched,omitempty" json:"fetched,omitempty"` // Date the metadata was fetched, formatted as YYYY-MM-DD
+}
+
+// ActionMetadataIndex records the marketplace metadata of every third-party action in use. It is stored
+// as action_metadata.yaml, from which USES.md is rendered and 'query action' reads.
+type ActionMetadataIndex struct {
+	Actions map[string]ActionMetadata `yaml:"actions"`
+}
+
+// actionRepository splits an action reference into the owner and repository that host it.
+// Local actions and docker references are not hosted in a repository and return false.
+func actionRepository(action string) (owner string, repo string, ok bool) {
+	if strings.HasPrefix(action, "./") || strings.HasPrefix(action, "docker://") {
+		return "", "", false
+	}
+
+	parts := strings.Split(action, "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+
+	return parts[0], parts[1], true
+}
+
+// isThirdPartyAction reports whether an action is hosted outside of the audited organization.
+func isThirdPartyAction(action, org string) bool {
+	owner, _, ok := actionRepository(action)
+	if !ok {
+		return false
+	}
+	return !strings.EqualFold(owner, org)
+}
+
+// fetchActionMetadata retrieves marketplace metadata for every third-party action in the uses index.
+func fetchActionMetadata(client *github.Client, org string, usesIndex *ActionUsesIndex) map[string]ActionMetadata {
+	ctx := context.Background()
+	metadata := make(map[string]ActionMetadata)
+	if usesIndex == nil {
+		return metadata
+	}
+
+	var actionNames []string
+	for actionName := range usesIndex.Actions {
+		if isThirdPartyAction(actionName, org) {
+			actionNames = append(actionNames, actionName)
+		}
+	}
+	sort.Strings(actionNames)
+
+	repoCache := make(map[string]*ActionMetadata)
+	verifiedCache := make(map[string]bool)
+
+	for _, actionName := range actionNames {
+		owner, repoName, _ := actionRepository(actionName)
+		repoKey := strings.ToLower(owner + "/" + repoName)
+
+		if cached, ok := repoCache[repoKey]; ok {
+			if cached != nil {
+				metadata[actionName] = *cached
+			}
+			continue
+		}
+
+		repo, _, err := client.Repositories.Get(ctx, owner, repoName)
+		if err != nil {
+			logErrorf("Error fetching marketplace metadata for action '%s': %v", actionName, err)
+			repoCache[repoKey] = nil
+			continue
+		}
+
+		entry := ActionMetadata{
+			Stars:    repo.GetStargazersCount(),
+			Archived: repo.GetArchived(),
+		}
+
+		if repo.GetOwner().GetType() == "Organization" {
+			ownerKey := strings.ToLower(owner)
+			verified, ok := verifiedCache[ownerKey]
+			if !ok {
+				ownerOrg, _, err := client.Organizations.Get(ctx, owner)
+				if err != nil {
+					logErrorf("Error fetching organization '%s' for action '%s': %v", owner, actionName, err)
+				} else {
+					verified = ownerOrg.GetIsVerified()
+				}
+				verifiedCache[ownerKey] = verified
+			}
+			entry.VerifiedCreator = verified
+		}
+
+		release, _, err := client.Repositories.GetLatestRelease(ctx, owner, repoName)
+		if err != nil {
+			if !isNotFoundError(err) {
+				logErrorf("Error fetching latest release for action '%s': %v", actionName, err)
+			}
+		} else if release.PublishedAt != nil {
+			entry.LatestRelease = formatReportDate(release.GetPublishedAt().Time)
+		}
+
+		logDebugf("Fetched marketplace metadata for action '%s' (stars: %d, verified: %t, archived: %t)", actionName, entry.Stars, entry.VerifiedCreator, entry.Archived)
+		repoCache[repoKey] = &entry
+		metadata[actionName] = entry
+	}
+
+	return metadata
+}
+
+// loadActionMetadataIndex reads action_metadata.yaml from the database, returning an empty index when it
+// does not exist yet.
+func loadActionMetadataIndex(dbPath string) (*ActionMetadataIndex, error) {
+	index := &ActionMetadataIndex{Actions: make(map[string]ActionMetadata)}
+	data, err := os.ReadFile(filepath.Join(dbPath, "action_metadata.yaml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return index, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("failed to parse action_metadata.yaml: %v", err)
+	}
+	if index.Actions == nil {
+		index.Actions = make(map[string]ActionMetadata)
+	}
+	return index, nil
+}
+
+// updateActionMetadataIndex records the fetched metadata of each third-party action in the uses index
+// and writes action_metadata.yaml. An action whose metadata could not be fetched keeps its stored entry,
+// and actions no longer in use are dropped. Errors are printed and the index as updated is returned.
+func updateActionMetadataIndex(dbPath, org string, usesIndex *ActionUsesIndex, fetched map[string]ActionMetadata, now time.Time) *ActionMetadataIndex {
+	stored, err := loadActionMetadataIndex(dbPath)
+	if err != nil {
+		logErrorf("Error loading action metadata: %v", err)
+		stored = &ActionMetadataIndex{Actions: make(map[string]ActionMetadata)}
+	}
+
+	index := &ActionMetadataIndex{Actions: make(map[string]ActionMetadata)}
+	if usesIndex != nil {
+		for actionName := range usesIndex.Actions {
+			if !isThirdPartyAction(actionName, org) {
+				continue
+			}
+			if meta, ok := fetched[actionName]; ok {
+				meta.Fetched = formatReportDate(now)
+				index.Actions[actionName] = meta
+			} else if meta, ok := stored.Actions[actionName]; ok {
+				index.Actions[actionName] = meta
+			}
+		}
+	}
+
+	data, err := yaml.Marshal(index)
+	if err != nil {
+		logErrorf("Error encoding action metadata: %v", err)
+		return index
+	}
+	if err := os.WriteFile(filepath.Join(dbPath, "action_metadata.yaml"), data, 0644); err != nil {
+		logErrorf("Error writing action_metadata.yaml: %v", err)
+	}
+	return index
+}
+
+// formatActionMetadata renders action metadata as a single markdown line for the uses report.
+func formatActionMetadata(meta ActionMetadata) string {
+	verified := "no"
+	if meta.VerifiedCreator {
+		verified = "yes"
+	}
+	archived := "no"
+	if meta.Archived {
+		archived = "**yes**"
+	}
+	latestRelease := meta.LatestRelease
+	if latestRelease == "" {
+		latestRelease = "none"
+	}
+
+	return fmt.Sprintf("**Marketplace**: Verified creator: %s | Stars: %d | Latest release: %s | Archived: %s\n\n",
+		verified, meta.Stars, latestRelease, archived)
+}
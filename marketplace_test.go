@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsThirdPartyAction(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		action string
+		want   bool
+	}{
+		{action: "actions/checkout", want: true},
+		{action: "UnitVectorY-Labs/shared-actions/setup", want: false},
+		{action: "unitvectory-labs/shared-actions", want: false},
+		{action: "./.github/actions/local", want: false},
+		{action: "docker://alpine:3.19", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := isThirdPartyAction(tt.action, "UnitVectorY-Labs"); got != tt.want {
+			t.Fatalf("isThirdPartyAction(%q) = %t, want %t", tt.action, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateUSESMarkdownIncludesMarketplaceMetadata(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	usesIndex := &ActionUsesIndex{
+		Actions: map[string]map[string][]WorkflowReference{
+			"actions/checkout": {"v4": {{RepoName: "repo-a", FilePath: ".github/workflows/build.yml"}}},
+		},
+	}
+	metadata := map[string]ActionMetadata{
+		"actions/checkout": {VerifiedCreator: true, Stars: 42, LatestRelease: "2024-01-02"},
+	}
+
+	if err := generateUSESMarkdown(dbPath, "UnitVectorY-Labs", usesIndex, metadata); err != nil {
+		t.Fatalf("generateUSESMarkdown returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dbPath, "USES.md"))
+	if err != nil {
+		t.Fatalf("failed to read USES.md: %v", err)
+	}
+	if !strings.Contains(string(data), "**Marketplace**: Verified creator: yes | Stars: 42 | Latest release: 2024-01-02 | Archived: no") {
+		t.Fatalf("expected marketplace metadata, got:\n%s", data)
+	}
+}
+
+func TestUpdateActionMetadataIndex(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	uses := func(actions ...string) *ActionUsesIndex {
+		index := &ActionUsesIndex{Actions: make(map[string]map[string][]WorkflowReference)}
+		for _, action := range actions {
+			index.Actions[action] = map[string][]WorkflowReference{"v1": {{RepoName: "repo-a", FilePath: ".github/workflows/build.yml"}}}
+		}
+		return index
+	}
+	first := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	updateActionMetadataIndex(dbPath, "example-org", uses("actions/checkout", "codecov/codecov-action", "example-org/shared"), map[string]ActionMetadata{
+		"actions/checkout":       {VerifiedCreator: true, Stars: 42},
+		"codecov/codecov-action": {Stars: 7, LatestRelease: "2026-02-01"},
+	}, first)
+
+	// A failed fetch keeps the stored entry, and an action no longer in use is dropped
+	index := updateActionMetadataIndex(dbPath, "example-org", uses("actions/checkout", "example-org/shared"), map[string]ActionMetadata{}, first.AddDate(0, 0, 1))
+	stored, err := loadActionMetadataIndex(dbPath)
+	if err != nil {
+		t.Fatalf("loadActionMetadataIndex returned error: %v", err)
+	}
+	want := ActionMetadata{VerifiedCreator: true, Stars: 42, Fetched: "2026-03-01"}
+	if len(stored.Actions) != 1 || stored.Actions["actions/checkout"] != want || index.Actions["actions/checkout"] != want {
+		t.Fatalf("unexpected action metadata: %+v", stored.Actions)
+	}
+}
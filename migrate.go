@@ -0,0 +1,158 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Migrate Command
+// ------------------------
+
+// runMigrateCommand dispatches the migrate subcommands and returns the process exit code.
+func runMigrateCommand(args []string) int {
+	if len(args) == 0 {
+		printMigrateUsage()
+		return 1
+	}
+
+	switch args[0] {
+	case "rename-org":
+		fs := flag.NewFlagSet("migrate rename-org", flag.ContinueOnError)
+		migrateDBPath := fs.String("db", "./db", "Path to the database repository, or a git URL to clone, update, and push")
+		migrateToken := fs.String("token", "", "GitHub API token used to push to an HTTPS database URL")
+		baseURL := fs.String("base-url", "", "GitHub Enterprise Server API URL, e.g. https://github.example.com/api/v3; defaults to github.com")
+		uploadURL := fs.String("upload-url", "", "GitHub Enterprise Server upload URL; defaults to -base-url")
+		review := fs.Bool("review", false, "Push database changes to a new branch and open a pull request instead of pushing them directly; requires a git URL -db")
+		logFlags := addLogFlags(fs)
+		if err := fs.Parse(args[1:]); err != nil {
+			return 1
+		}
+		if err := applyEnvironmentDefaults(fs); err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+		if err := logFlags.apply(); err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+		if fs.NArg() != 2 {
+			printMigrateUsage()
+			fs.PrintDefaults()
+			return 1
+		}
+		if err := setGitHubServer(*baseURL, *uploadURL); err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+		if err := checkReviewMode(*review, *migrateDBPath); err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+
+		checkout, err := openDB(*migrateDBPath, *migrateToken)
+		if err != nil {
+			logErrorf("Failed to open database: %v", err)
+			return 1
+		}
+		defer checkout.Close()
+
+		if err := renameOrganization(checkout.Dir, fs.Arg(0), fs.Arg(1)); err != nil {
+			logErrorf("Migration failed: %v", err)
+			return 1
+		}
+		if err := publishDB(checkout, fmt.Sprintf("Rename organization %s to %s", fs.Arg(0), fs.Arg(1)), *review, *migrateToken); err != nil {
+			logErrorf("Failed to publish database: %v", err)
+			return 1
+		}
+		return 0
+	default:
+		logErrorf("Unknown migrate command '%s'", args[0])
+		printMigrateUsage()
+		return 1
+	}
+}
+
+// printMigrateUsage prints the usage for the migrate command.
+func printMigrateUsage() {
+	fmt.Println("Usage: dotgithubindexer migrate rename-org [-db <path or git URL>] [-token <token>] <old-org> <new-org>")
+}
+
+// renameOrganization rewrites the database so that all references to oldOrg point to newOrg.
+func renameOrganization(dbPath, oldOrg, newOrg string) error {
+	if oldOrg == "" || newOrg == "" {
+		return fmt.Errorf("organization names cannot be empty")
+	}
+	if oldOrg == newOrg {
+		return fmt.Errorf("old and new organization names are identical")
+	}
+
+	reposManifestPath := filepath.Join(dbPath, "repositories.yaml")
+	data, err := os.ReadFile(reposManifestPath)
+	if err != nil {
+		return fmt.Errorf("failed to read repositories manifest: %v", err)
+	}
+
+	var manifest RepositoryManifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("failed to parse repositories manifest: %v", err)
+	}
+	if manifest.Organization != oldOrg {
+		return fmt.Errorf("database organization is '%s', not '%s'", manifest.Organization, oldOrg)
+	}
+
+	manifest.Organization = newOrg
+	updatedData, err := yaml.Marshal(&manifest)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(reposManifestPath, updatedData, 0644); err != nil {
+		return err
+	}
+	logInfof("Updated organization in 'repositories.yaml' from '%s' to '%s'", oldOrg, newOrg)
+
+	// Rewrite links in every generated markdown file
+	oldLink := fmt.Sprintf("%s/%s/", githubWebURL, oldOrg)
+	newLink := fmt.Sprintf("%s/%s/", githubWebURL, newOrg)
+	rewritten := 0
+	err = filepath.Walk(dbPath, func(currentPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(info.Name()) != ".md" {
+			return nil
+		}
+
+		content, err := os.ReadFile(currentPath)
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(string(content), oldLink) {
+			return nil
+		}
+
+		updated := strings.ReplaceAll(string(content), oldLink, newLink)
+		if err := os.WriteFile(currentPath, []byte(updated), info.Mode().Perm()); err != nil {
+			return err
+		}
+		rewritten++
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rewrite generated files: %v", err)
+	}
+
+	logInfof("Rewrote links in %d generated files", rewritten)
+	return nil
+}
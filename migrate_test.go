@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenameOrganization(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dbPath, "repositories.yaml"), []byte("organization: old-org\nrepositories:\n    - repo-a\n"), 0644); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+	if err := updateActionIndex(dbPath, "build.yml", "repo-a", "build.yml", "hash-one", ""); err != nil {
+		t.Fatalf("updateActionIndex returned error: %v", err)
+	}
+	if err := generateReadmeFiles(dbPath, "old-org"); err != nil {
+		t.Fatalf("generateReadmeFiles returned error: %v", err)
+	}
+
+	if err := renameOrganization(dbPath, "old-org", "new-org"); err != nil {
+		t.Fatalf("renameOrganization returned error: %v", err)
+	}
+
+	manifest, err := os.ReadFile(filepath.Join(dbPath, "repositories.yaml"))
+	if err != nil {
+		t.Fatalf("failed to read manifest: %v", err)
+	}
+	if !strings.Contains(string(manifest), "organization: new-org") {
+		t.Fatalf("expected organization to be renamed, got:\n%s", manifest)
+	}
+
+	readme, err := os.ReadFile(filepath.Join(dbPath, "workflows", "build.yml", "README.md"))
+	if err != nil {
+		t.Fatalf("failed to read README: %v", err)
+	}
+	if strings.Contains(string(readme), "old-org") || !strings.Contains(string(readme), "https://github.com/new-org/repo-a/") {
+		t.Fatalf("expected README links to be rewritten, got:\n%s", readme)
+	}
+
+	if err := renameOrganization(dbPath, "old-org", "other-org"); err == nil {
+		t.Fatal("expected error when old organization does not match the database")
+	}
+}
@@ -0,0 +1,498 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v50/github"
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Workflow Modernization
+// ------------------------
+
+// Modernization is a suggested update for a legacy pattern in a workflow file.
+// Fix is set when the update can be applied automatically by replacing Old with New on Line.
+type Modernization struct {
+	Rule       string   `json:"rule"`
+	Line       int      `json:"line"`
+	Message    string   `json:"message"`
+	Suggestion string   `json:"suggestion"`
+	Fix        *LineFix `json:"fix,omitempty"`
+}
+
+// LineFix replaces the first occurrence of Old with New on a single line.
+type LineFix struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// actionUpgrade describes the first major version of an action that runs on a supported Node.js runtime.
+type actionUpgrade struct {
+	MinMajor int
+	Note     string
+}
+
+// outdatedActionVersions lists actions whose older major versions run on the retired node12 or node16 runtimes.
+var outdatedActionVersions = map[string]actionUpgrade{
+	"actions/checkout":             {MinMajor: 4},
+	"actions/setup-node":           {MinMajor: 4},
+	"actions/setup-python":         {MinMajor: 5},
+	"actions/setup-java":           {MinMajor: 4},
+	"actions/setup-go":             {MinMajor: 5},
+	"actions/setup-dotnet":         {MinMajor: 4},
+	"actions/cache":                {MinMajor: 4},
+	"actions/github-script":        {MinMajor: 7},
+	"actions/upload-artifact":      {MinMajor: 4, Note: "artifacts are immutable in v4, so jobs uploading to the same artifact name need unique names"},
+	"actions/download-artifact":    {MinMajor: 4, Note: "v4 cannot download artifacts uploaded with v3 or earlier"},
+	"docker/login-action":          {MinMajor: 3},
+	"docker/setup-buildx-action":   {MinMajor: 3},
+	"docker/setup-qemu-action":     {MinMajor: 3},
+	"docker/build-push-action":     {MinMajor: 5},
+	"docker/metadata-action":       {MinMajor: 5},
+	"github/codeql-action/init":    {MinMajor: 3},
+	"github/codeql-action/analyze": {MinMajor: 3},
+}
+
+// deprecatedActions maps unmaintained actions to their suggested replacement.
+var deprecatedActions = map[string]string{
+	"actions/create-release":       "`softprops/action-gh-release@v2` or `gh release create`",
+	"actions/upload-release-asset": "`softprops/action-gh-release@v2` or `gh release upload`",
+	"actions/setup-ruby":           "`ruby/setup-ruby@v1`",
+	"actions-rs/toolchain":         "`dtolnay/rust-toolchain`",
+	"actions-rs/cargo":             "running `cargo` directly in a `run` step",
+	"actions-rs/clippy-check":      "running `cargo clippy` directly in a `run` step",
+}
+
+// renamedInputs maps actions to inputs that were renamed, keyed by the old name.
+var renamedInputs = map[string]map[string]string{
+	"actions/setup-node":   {"version": "node-version"},
+	"actions/setup-python": {"version": "python-version"},
+	"actions/setup-java":   {"version": "java-version"},
+	"actions/setup-go":     {"version": "go-version"},
+}
+
+// workflowCommand describes a deprecated workflow command and the environment file that replaces it.
+type workflowCommand struct {
+	Name    string
+	EnvFile string
+	Pattern *regexp.Regexp // Matches `echo "::command ..."` so the line can be rewritten
+}
+
+var workflowCommands = []workflowCommand{
+	{Name: "set-output", EnvFile: "GITHUB_OUTPUT", Pattern: regexp.MustCompile(`echo\s+(["']?)::set-output name=([A-Za-z0-9_-]+)::(.*?)(["']?)\s*$`)},
+	{Name: "save-state", EnvFile: "GITHUB_STATE", Pattern: regexp.MustCompile(`echo\s+(["']?)::save-state name=([A-Za-z0-9_-]+)::(.*?)(["']?)\s*$`)},
+	{Name: "set-env", EnvFile: "GITHUB_ENV", Pattern: regexp.MustCompile(`echo\s+(["']?)::set-env name=([A-Za-z0-9_-]+)::(.*?)(["']?)\s*$`)},
+	{Name: "add-path", EnvFile: "GITHUB_PATH", Pattern: regexp.MustCompile(`echo\s+(["']?)::add-path::()(.*?)(["']?)\s*$`)},
+}
+
+var majorVersionRe = regexp.MustCompile(`^v?(\d+)(\.\d+)*$`)
+
+// parseMajorVersion returns the major version of a tag such as v2 or v2.3.1.
+func parseMajorVersion(ref string) (int, bool) {
+	m := majorVersionRe.FindStringSubmatch(ref)
+	if m == nil {
+		return 0, false
+	}
+	major, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0, false
+	}
+	return major, true
+}
+
+// detectModernizations finds legacy patterns in a workflow file and suggests replacements.
+func detectModernizations(content string) []Modernization {
+	var modernizations []Modernization
+	lines := strings.Split(content, "\n")
+
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		for _, command := range workflowCommands {
+			if !strings.Contains(line, "::"+command.Name) {
+				continue
+			}
+			modernization := Modernization{
+				Rule:       "deprecated-command",
+				Line:       i + 1,
+				Message:    fmt.Sprintf("Deprecated `::%s` workflow command", command.Name),
+				Suggestion: fmt.Sprintf("Append to the `$%s` file instead", command.EnvFile),
+			}
+			// Single-quoted values are not rewritten because double quotes would change how they expand
+			if m := command.Pattern.FindStringSubmatchIndex(line); m != nil && line[m[2]:m[3]] == line[m[8]:m[9]] && line[m[2]:m[3]] != "'" {
+				old := line[m[0]:m[1]]
+				value := line[m[6]:m[7]]
+				replacement := fmt.Sprintf(`echo "%s" >> "$%s"`, value, command.EnvFile)
+				if name := line[m[4]:m[5]]; name != "" {
+					replacement = fmt.Sprintf(`echo "%s=%s" >> "$%s"`, name, value, command.EnvFile)
+				}
+				modernization.Suggestion = fmt.Sprintf("Replace with `%s`", replacement)
+				modernization.Fix = &LineFix{Old: old, New: replacement}
+			}
+			modernizations = append(modernizations, modernization)
+		}
+	}
+
+	workflow, err := parseWorkflowDocument(content)
+	if err != nil {
+		return modernizations
+	}
+	jobs := mappingValue(workflow, "jobs")
+	if jobs == nil || jobs.Kind != yaml.MappingNode {
+		return modernizations
+	}
+	for i := 1; i < len(jobs.Content); i += 2 {
+		steps := mappingValue(jobs.Content[i], "steps")
+		if steps == nil || steps.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, step := range steps.Content {
+			modernizations = append(modernizations, detectStepModernizations(step)...)
+		}
+	}
+
+	sort.SliceStable(modernizations, func(i, j int) bool {
+		return modernizations[i].Line < modernizations[j].Line
+	})
+	return modernizations
+}
+
+// detectStepModernizations checks the action used by a step for deprecated actions, versions, and inputs.
+func detectStepModernizations(step *yaml.Node) []Modernization {
+	usesNode := mappingValue(step, "uses")
+	if usesNode == nil || usesNode.Kind != yaml.ScalarNode {
+		return nil
+	}
+	action, version := parseUsesString(usesNode.Value, usesNode.LineComment)
+	actionKey := strings.ToLower(action)
+	ref, comment, _ := strings.Cut(version, " # ")
+
+	var modernizations []Modernization
+
+	if replacement, ok := deprecatedActions[actionKey]; ok {
+		modernizations = append(modernizations, Modernization{
+			Rule:       "deprecated-action",
+			Line:       usesNode.Line,
+			Message:    fmt.Sprintf("`%s` is no longer maintained", action),
+			Suggestion: fmt.Sprintf("Replace with %s", replacement),
+		})
+	}
+
+	if upgrade, ok := outdatedActionVersions[actionKey]; ok {
+		pinned := isPinnedVersion(ref)
+		major, ok := parseMajorVersion(ref)
+		if pinned {
+			major, ok = parseMajorVersion(comment)
+		}
+		if ok && major < upgrade.MinMajor {
+			recommended := fmt.Sprintf("v%d", upgrade.MinMajor)
+			modernization := Modernization{
+				Rule:       "outdated-action-runtime",
+				Line:       usesNode.Line,
+				Message:    fmt.Sprintf("`%s@%s` runs on a retired Node.js runtime", action, ref),
+				Suggestion: fmt.Sprintf("Upgrade to `%s@%s`", action, recommended),
+			}
+			if pinned {
+				modernization.Suggestion += " and pin the new commit SHA"
+			} else {
+				modernization.Fix = &LineFix{Old: action + "@" + ref, New: action + "@" + recommended}
+			}
+			if upgrade.Note != "" {
+				modernization.Suggestion += "; note that " + upgrade.Note
+				modernization.Fix = nil // Breaking changes need a manual review
+			}
+			modernizations = append(modernizations, modernization)
+		}
+	}
+
+	if renames, ok := renamedInputs[actionKey]; ok {
+		with := mappingValue(step, "with")
+		if with != nil && with.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(with.Content); i += 2 {
+				key := with.Content[i]
+				newName, ok := renames[key.Value]
+				if !ok {
+					continue
+				}
+				modernizations = append(modernizations, Modernization{
+					Rule:       "deprecated-input",
+					Line:       key.Line,
+					Message:    fmt.Sprintf("Input `%s` of `%s` was renamed", key.Value, action),
+					Suggestion: fmt.Sprintf("Rename it to `%s`", newName),
+					Fix:        &LineFix{Old: key.Value + ":", New: newName + ":"},
+				})
+			}
+		}
+	}
+
+	return modernizations
+}
+
+// scanForObsoleteSyntax reports legacy workflow patterns as findings with a modernization suggestion.
+func scanForObsoleteSyntax(content, repoName, filePath string) []Finding {
+	var findings []Finding
+	for _, modernization := range detectModernizations(content) {
+		finding := newFinding(modernization.Rule, repoName, filePath, modernization.Line, modernization.Message)
+		finding.Suggestion = modernization.Suggestion
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// modernizeWorkflow applies every automatic fix to a workflow file and returns the updated content
+// along with a description of each change.
+func modernizeWorkflow(content string) (string, []string) {
+	lines := strings.Split(content, "\n")
+	var applied []string
+	for _, modernization := range detectModernizations(content) {
+		if modernization.Fix == nil || modernization.Line < 1 || modernization.Line > len(lines) {
+			continue
+		}
+		line := lines[modernization.Line-1]
+		if !strings.Contains(line, modernization.Fix.Old) {
+			continue
+		}
+		lines[modernization.Line-1] = strings.Replace(line, modernization.Fix.Old, modernization.Fix.New, 1)
+		applied = append(applied, fmt.Sprintf("line %d: %s", modernization.Line, modernization.Suggestion))
+	}
+	return strings.Join(lines, "\n"), applied
+}
+
+// ------------------------
+// Section: Modernize Command
+// ------------------------
+
+// modernizeBranch is the branch used for remediation pull requests.
+const modernizeBranch = "dotgithubindexer/modernize-workflows"
+
+// runModernizeCommand prints modernization suggestions for a repository and optionally opens a pull request
+// with the automatic fixes. It returns the process exit code.
+func runModernizeCommand(args []string) int {
+	fs := flag.NewFlagSet("modernize", flag.ContinueOnError)
+	modernizeOrg := fs.String("org", "", "GitHub Organization name (required)")
+	modernizeToken := fs.String("token", "", "GitHub API token (required); defaults to the DOTGITHUBINDEXER_TOKEN or GITHUB_TOKEN environment variable")
+	baseURL := fs.String("base-url", "", "GitHub Enterprise Server API URL, e.g. https://github.example.com/api/v3; defaults to github.com")
+	uploadURL := fs.String("upload-url", "", "GitHub Enterprise Server upload URL; defaults to -base-url")
+	modernizeRepo := fs.String("repo", "", "Repository name (required)")
+	openPR := fs.Bool("open-pr", false, "Open a pull request applying the automatic fixes")
+	format := fs.String("format", formatText, "Output format: text or json")
+	logFlags := addLogFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if err := applyEnvironmentDefaults(fs); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := logFlags.apply(); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+
+	if *modernizeOrg == "" || *modernizeToken == "" || *modernizeRepo == "" {
+		fmt.Println("Usage: dotgithubindexer modernize -org <organization> -token <token> -repo <repository> [-open-pr] [-format text|json]")
+		fs.PrintDefaults()
+		return 1
+	}
+	if err := checkFormat(*format, formatText, formatJSON); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := setGitHubServer(*baseURL, *uploadURL); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if *format == formatJSON {
+		useJSONOutput()
+	}
+
+	client := getGitHubClient(*modernizeToken)
+	result, err := modernizeRepository(client, *modernizeOrg, *modernizeRepo, *openPR)
+	if err != nil {
+		logErrorf("Modernize failed: %v", err)
+		return 1
+	}
+
+	content := formatModernizeText(result, *openPR)
+	if *format == formatJSON {
+		content, err = formatModernizeJSON(result)
+		if err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+	}
+	fmt.Fprint(resultWriter, content)
+	return 0
+}
+
+// ModernizedFile lists the suggested modernizations of one workflow file and the automatic fixes that apply.
+type ModernizedFile struct {
+	FilePath       string          `json:"file_path"`
+	Modernizations []Modernization `json:"modernizations"`
+	AutomaticFixes []string        `json:"automatic_fixes"`
+}
+
+// ModernizeResult is the outcome of the modernize command for a repository.
+type ModernizeResult struct {
+	Repository     string           `json:"repository"`
+	Files          []ModernizedFile `json:"files"`
+	PullRequestURL string           `json:"pull_request_url,omitempty"` // Set when a pull request was opened
+}
+
+// fixableFiles returns the number of files with at least one automatic fix.
+func (r *ModernizeResult) fixableFiles() int {
+	count := 0
+	for _, file := range r.Files {
+		if len(file.AutomaticFixes) > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// formatModernizeText renders the suggestions for each workflow file and what happened to the automatic fixes.
+func formatModernizeText(result *ModernizeResult, openPR bool) string {
+	var builder strings.Builder
+	for _, file := range result.Files {
+		builder.WriteString(fmt.Sprintf("\n%s\n", file.FilePath))
+		for _, modernization := range file.Modernizations {
+			automatic := ""
+			if modernization.Fix != nil {
+				automatic = " (automatic)"
+			}
+			builder.WriteString(fmt.Sprintf("  line %d: %s. %s%s\n", modernization.Line, modernization.Message, modernization.Suggestion, automatic))
+		}
+	}
+
+	switch {
+	case result.fixableFiles() == 0:
+		builder.WriteString(fmt.Sprintf("\nNo automatic fixes available for repository '%s'.\n", result.Repository))
+	case !openPR:
+		builder.WriteString(fmt.Sprintf("\n%d workflow file(s) can be fixed automatically. Run with -open-pr to open a pull request.\n", result.fixableFiles()))
+	default:
+		builder.WriteString(fmt.Sprintf("\nOpened pull request %s\n", result.PullRequestURL))
+	}
+	return builder.String()
+}
+
+// formatModernizeJSON renders the result of the modernize command as JSON. Empty lists are written as [] rather than null.
+func formatModernizeJSON(result *ModernizeResult) (string, error) {
+	document := *result
+	document.Files = make([]ModernizedFile, len(result.Files))
+	for i, file := range result.Files {
+		file.AutomaticFixes = emptyIfNil(file.AutomaticFixes)
+		document.Files[i] = file
+	}
+	return formatJSONDocument(document)
+}
+
+// modernizeRepository fetches the workflows of a repository, collects the suggested modernizations,
+// and opens a pull request with the automatic fixes when openPR is set.
+func modernizeRepository(client *github.Client, owner, repoName string, openPR bool) (*ModernizeResult, error) {
+	ctx := context.Background()
+	repo, _, err := client.Repositories.Get(ctx, owner, repoName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch repository: %v", err)
+	}
+
+	workflows, err := fetchWorkflowFiles(client, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var updates []workflowUpdate
+	result := &ModernizeResult{Repository: repoName, Files: []ModernizedFile{}}
+
+	for _, wf := range workflows {
+		modernizations := detectModernizations(wf.Content)
+		if len(modernizations) == 0 {
+			continue
+		}
+
+		updated, applied := modernizeWorkflow(wf.Content)
+		result.Files = append(result.Files, ModernizedFile{FilePath: wf.FilePath, Modernizations: modernizations, AutomaticFixes: applied})
+		if len(applied) > 0 {
+			updates = append(updates, workflowUpdate{File: wf, Content: updated, Applied: applied})
+		}
+	}
+
+	if len(updates) == 0 || !openPR {
+		return result, nil
+	}
+
+	pr, err := openWorkflowPullRequest(client, repo, modernizeBranch, "Modernize GitHub Actions workflows",
+		"This pull request replaces deprecated workflow syntax and actions running on retired Node.js runtimes.", "Modernize", updates)
+	if err != nil {
+		return nil, err
+	}
+	result.PullRequestURL = pr.GetHTMLURL()
+	return result, nil
+}
+
+// workflowUpdate is a change to one workflow file, with a description of each change applied.
+type workflowUpdate struct {
+	File    WorkflowFile
+	Content string
+	Applied []string
+}
+
+// openWorkflowPullRequest commits updated workflow files to a new branch created from the head of the
+// default branch, one commit per file with a message starting with verb, and opens a pull request listing
+// the changes. It returns the pull request.
+func openWorkflowPullRequest(client *github.Client, repo *github.Repository, branch, title, intro, verb string, updates []workflowUpdate) (*github.PullRequest, error) {
+	ctx := context.Background()
+	owner, repoName := repo.GetOwner().GetLogin(), repo.GetName()
+
+	// Create the branch from the head of the default branch
+	defaultBranch := getDefaultBranch(repo)
+	baseRef, _, err := client.Git.GetRef(ctx, owner, repoName, "refs/heads/"+defaultBranch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read default branch: %v", err)
+	}
+	_, _, err = client.Git.CreateRef(ctx, owner, repoName, &github.Reference{
+		Ref:    github.String("refs/heads/" + branch),
+		Object: &github.GitObject{SHA: baseRef.GetObject().SHA},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create branch '%s': %v", branch, err)
+	}
+
+	var body strings.Builder
+	body.WriteString(intro + "\n\n")
+	for _, update := range updates {
+		_, _, err := client.Repositories.UpdateFile(ctx, owner, repoName, update.File.FilePath, &github.RepositoryContentFileOptions{
+			Message: github.String(verb + " " + update.File.FilePath),
+			Content: []byte(update.Content),
+			SHA:     github.String(update.File.BlobSHA),
+			Branch:  github.String(branch),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to update '%s': %v", update.File.FilePath, err)
+		}
+		body.WriteString(fmt.Sprintf("### `%s`\n\n", update.File.FilePath))
+		for _, change := range update.Applied {
+			body.WriteString(fmt.Sprintf("- %s\n", change))
+		}
+		body.WriteString("\n")
+	}
+	body.WriteString("*Generated by dotgithubindexer.*\n")
+
+	pr, _, err := client.PullRequests.Create(ctx, owner, repoName, &github.NewPullRequest{
+		Title: github.String(title),
+		Head:  github.String(branch),
+		Base:  github.String(defaultBranch),
+		Body:  github.String(body.String()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pull request: %v", err)
+	}
+	return pr, nil
+}
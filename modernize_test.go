@@ -0,0 +1,107 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectModernizations(t *testing.T) {
+	t.Parallel()
+
+	content := `jobs:
+  build:
+    steps:
+      - uses: actions/checkout@v2
+      - uses: actions/setup-node@v1
+        with:
+          version: 12
+      - uses: actions/create-release@v1
+      - uses: actions/cache@de0fac2e4500dabe0009e67214ff5f5447ce83dd # v3.2.1
+      - uses: actions/upload-artifact@v3
+      - run: echo "::set-output name=sha::$(git rev-parse HEAD)"
+      - run: echo '::set-env name=MODE::$MODE'
+`
+
+	modernizations := detectModernizations(content)
+	var rules []string
+	fixes := 0
+	for _, modernization := range modernizations {
+		rules = append(rules, modernization.Rule)
+		if modernization.Fix != nil {
+			fixes++
+		}
+	}
+
+	want := []string{
+		"outdated-action-runtime", // checkout
+		"outdated-action-runtime", // setup-node
+		"deprecated-input",        // version
+		"deprecated-action",       // create-release
+		"outdated-action-runtime", // pinned cache
+		"outdated-action-runtime", // upload-artifact
+		"deprecated-command",      // set-output
+		"deprecated-command",      // set-env
+	}
+	if strings.Join(rules, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected rules:\n got %v\nwant %v", rules, want)
+	}
+	// checkout, setup-node, version input, and set-output can be fixed automatically
+	if fixes != 4 {
+		t.Fatalf("expected 4 automatic fixes, got %d: %+v", fixes, modernizations)
+	}
+}
+
+func TestModernizeWorkflow(t *testing.T) {
+	t.Parallel()
+
+	content := "jobs:\n  build:\n    steps:\n      - uses: actions/setup-node@v2\n        with:\n          version: 14\n      - run: echo \"::set-output name=sha::${GITHUB_SHA}\"\n"
+	want := "jobs:\n  build:\n    steps:\n      - uses: actions/setup-node@v4\n        with:\n          node-version: 14\n      - run: echo \"sha=${GITHUB_SHA}\" >> \"$GITHUB_OUTPUT\"\n"
+
+	updated, applied := modernizeWorkflow(content)
+	if updated != want {
+		t.Fatalf("unexpected modernized workflow:\n%s", updated)
+	}
+	if len(applied) != 3 {
+		t.Fatalf("expected 3 applied changes, got %v", applied)
+	}
+	if remaining := detectModernizations(updated); len(remaining) != 0 {
+		t.Fatalf("expected no remaining modernizations, got %+v", remaining)
+	}
+}
+
+func TestFormatModernizeResult(t *testing.T) {
+	t.Parallel()
+
+	content := "jobs:\n  build:\n    steps:\n      - uses: actions/checkout@v2\n"
+	updated, applied := modernizeWorkflow(content)
+	if updated == content || len(applied) != 1 {
+		t.Fatalf("expected one automatic fix, got %v", applied)
+	}
+	result := &ModernizeResult{
+		Repository: "repo-a",
+		Files: []ModernizedFile{
+			{FilePath: ".github/workflows/build.yml", Modernizations: detectModernizations(content), AutomaticFixes: applied},
+		},
+	}
+
+	text := formatModernizeText(result, false)
+	if !strings.Contains(text, ".github/workflows/build.yml\n  line 4:") || !strings.Contains(text, "1 workflow file(s) can be fixed automatically") {
+		t.Fatalf("unexpected text output:\n%s", text)
+	}
+
+	document, err := formatModernizeJSON(result)
+	if err != nil {
+		t.Fatalf("formatModernizeJSON returned error: %v", err)
+	}
+	if !strings.Contains(document, `"rule": "outdated-action-runtime"`) || !strings.Contains(document, `"new": "actions/checkout@v4"`) || strings.Contains(document, "pull_request_url") {
+		t.Fatalf("unexpected JSON output:\n%s", document)
+	}
+
+	empty, err := formatModernizeJSON(&ModernizeResult{Repository: "repo-b", Files: []ModernizedFile{}})
+	if err != nil {
+		t.Fatalf("formatModernizeJSON returned error: %v", err)
+	}
+	if !strings.Contains(empty, `"files": []`) {
+		t.Fatalf("expected an empty file list, got:\n%s", empty)
+	}
+}
@@ -0,0 +1,544 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Multiple Organizations
+// ------------------------
+
+// organizationsFile lists the organizations of a database that indexes several, each in its own folder.
+const organizationsFile = "organizations.yaml"
+
+// organizationList is the value of -org, which may be given more than once or hold comma-separated names.
+type organizationList []string
+
+func (l *organizationList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *organizationList) Set(value string) error {
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" && !slices.Contains(*l, name) {
+			*l = append(*l, name)
+		}
+	}
+	return nil
+}
+
+// OrganizationsManifest is the content of organizations.yaml.
+type OrganizationsManifest struct {
+	Enterprise    string   `yaml:"enterprise,omitempty"` // Enterprise account the organizations were listed from, with -enterprise
+	Organizations []string `yaml:"organizations"`
+}
+
+// loadOrganizationsManifest reads organizations.yaml, returning nil for a database of a single organization.
+func loadOrganizationsManifest(dbPath string) (*OrganizationsManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dbPath, organizationsFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var manifest OrganizationsManifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", organizationsFile, err)
+	}
+	return &manifest, nil
+}
+
+// loadOrganizations returns the organizations of a database that indexes several, or nil for a database
+// of a single organization.
+func loadOrganizations(dbPath string) ([]string, error) {
+	manifest, err := loadOrganizationsManifest(dbPath)
+	if err != nil || manifest == nil {
+		return nil, err
+	}
+	return manifest.Organizations, nil
+}
+
+// recordOrganizations adds organizations to organizations.yaml, keeping those indexed by earlier runs, and
+// regenerates the README.md linking to the reports of each and the USAGE.md combining them. The enterprise
+// is recorded when the organizations were listed from one.
+func recordOrganizations(dbPath, enterprise string, orgs []string) error {
+	existing, err := loadOrganizationsManifest(dbPath)
+	if err != nil {
+		return err
+	}
+	manifest := OrganizationsManifest{}
+	if existing != nil {
+		manifest = *existing
+	}
+	if enterprise != "" {
+		manifest.Enterprise = enterprise
+	}
+	for _, name := range orgs {
+		if !slices.Contains(manifest.Organizations, name) {
+			manifest.Organizations = append(manifest.Organizations, name)
+		}
+	}
+	sort.Strings(manifest.Organizations)
+	if err := writeYAMLFile(dbPath, organizationsFile, manifest); err != nil {
+		return err
+	}
+	if err := generateOrganizationsMarkdown(dbPath, &manifest); err != nil {
+		return err
+	}
+	return generateCombinedUsageMarkdown(dbPath, manifest.Organizations)
+}
+
+// generateOrganizationsMarkdown writes the README.md of a database that indexes several organizations.
+func generateOrganizationsMarkdown(dbPath string, manifest *OrganizationsManifest) error {
+	orgs := manifest.Organizations
+	var markdownBuilder strings.Builder
+	markdownBuilder.WriteString("# GitHub Actions Index\n\n")
+	if manifest.Enterprise != "" {
+		markdownBuilder.WriteString(fmt.Sprintf("This database indexes the organizations of the **%s** enterprise, each in its own folder with its own reports.\n\n", manifest.Enterprise))
+	} else {
+		markdownBuilder.WriteString("This database indexes several organizations, each in its own folder with its own reports.\n\n")
+	}
+	markdownBuilder.WriteString("Workflow and action use across the organizations is combined in [USAGE.md](USAGE.md).\n\n")
+	markdownBuilder.WriteString("| Organization | Repositories |\n")
+	markdownBuilder.WriteString("|--------------|--------------|\n")
+	for _, name := range orgs {
+		repositories := "-"
+		if manifest, err := loadRepositoryManifest(filepath.Join(dbPath, name)); err == nil {
+			repositories = fmt.Sprintf("%d", len(manifest.Repositories))
+		}
+		markdownBuilder.WriteString(fmt.Sprintf("| [%s](%s/README.md) | %s |\n", name, name, repositories))
+	}
+	markdownBuilder.WriteString("\n*This file is automatically generated after each data collection run.*\n")
+
+	if err := os.WriteFile(filepath.Join(dbPath, "README.md"), []byte(markdownBuilder.String()), 0644); err != nil {
+		return fmt.Errorf("error writing README.md: %v", err)
+	}
+	logInfof("Generated README.md for %d organizations", len(orgs))
+	return nil
+}
+
+// MultiOrganizationRun holds the settings of an index run over several organizations that apply after
+// each organization is scanned.
+type MultiOrganizationRun struct {
+	Enterprise  string
+	Layout      int
+	SignKey     string
+	SignKeyless bool
+	Review      bool
+	Token       string
+	FailOn      []string
+	StartTime   time.Time
+	Counter     *ScanCounter // Counts what the scans index, for each organization's runs.yaml
+}
+
+// indexOrganizations scans each organization into its own folder of the database, then publishes the
+// database once. An organization that fails does not stop the others, but the run fails. It returns the
+// process exit code.
+func indexOrganizations(checkout *DBCheckout, orgs []string, opts AuditOptions, run MultiOrganizationRun) int {
+	if _, err := os.Stat(filepath.Join(checkout.Dir, "repositories.yaml")); err == nil {
+		logErrorf("The database at '%s' indexes a single organization; index several organizations into a new database", checkout.Dir)
+		return 1
+	}
+
+	failed := false
+	var indexed []string
+	for _, name := range orgs {
+		// The organization is read from the global by the rest of the scan
+		org = name
+		orgOpts := opts
+		orgOpts.Org = name
+		orgOpts.DBPath = filepath.Join(checkout.Dir, name)
+		if err := checkWritableDir(orgOpts.DBPath); err != nil {
+			logErrorf("Failed to open database: %v", err)
+			return 1
+		}
+		if err := selectLayout(orgOpts.DBPath, run.Layout); err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+
+		tracker, err := startRunTracker("index", orgOpts.DBPath, run.Counter)
+		if err != nil {
+			logErrorf("Failed to open database: %v", err)
+			return 1
+		}
+
+		logInfof("Indexing organization '%s' into '%s'", name, orgOpts.DBPath)
+		err = auditGitHubActions(orgOpts)
+		switch {
+		case err == nil:
+			tracker.record(name, RunCompleted, -1)
+		case errors.Is(err, errScanStopped):
+			tracker.record(name, RunStopped, -1)
+		}
+		if err == nil && (run.SignKey != "" || run.SignKeyless) {
+			err = signReports(orgOpts.DBPath, name, run.SignKey, run.SignKeyless)
+		}
+		if err != nil {
+			logErrorf("Audit of organization '%s' failed: %v", name, err)
+			failed = true
+			// The repositories indexed before a stop are published with the organization's checkpoint
+			if errors.Is(err, errScanStopped) {
+				indexed = append(indexed, name)
+				break
+			}
+			continue
+		}
+		indexed = append(indexed, name)
+	}
+	if len(indexed) == 0 {
+		return 1
+	}
+
+	if err := recordOrganizations(checkout.Dir, run.Enterprise, indexed); err != nil {
+		logErrorf("Failed to record organizations: %v", err)
+		return 1
+	}
+	message := fmt.Sprintf("Update %s index (%s)", strings.Join(indexed, ", "), formatReportDate(run.StartTime))
+	if err := publishDB(checkout, message, run.Review, run.Token); err != nil {
+		logErrorf("Failed to publish database: %v", err)
+		return 1
+	}
+	if failed {
+		return 1
+	}
+
+	logInfof("Audit of %d organizations completed successfully at %s in %v.", len(indexed), formatReportTime(time.Now()), time.Since(run.StartTime))
+	code := 0
+	for _, name := range indexed {
+		if gateCode := enforcePolicyGate(filepath.Join(checkout.Dir, name), run.FailOn); gateCode != 0 {
+			code = gateCode
+		}
+	}
+	return code
+}
+
+// queryActionAcrossOrganizations answers an action query for every organization of a database that
+// indexes several, naming repositories as organization/repository. A database of a single organization
+// is queried as is.
+func queryActionAcrossOrganizations(dbPath, query string) (*ActionQueryResult, error) {
+	orgs, err := loadOrganizations(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	if orgs == nil {
+		return queryAction(dbPath, query)
+	}
+	actionName, version, _ := strings.Cut(query, "@")
+	combined := &ActionQueryResult{Action: actionName, Version: version, Uses: []ActionReference{}}
+	for _, orgName := range orgs {
+		result, err := queryAction(filepath.Join(dbPath, orgName), query)
+		if err != nil {
+			return nil, err
+		}
+		for _, use := range result.Uses {
+			use.Repository = orgName + "/" + use.Repository
+			combined.Uses = append(combined.Uses, use)
+		}
+		if combined.Marketplace == nil {
+			combined.Marketplace = result.Marketplace
+		}
+	}
+	sort.SliceStable(combined.Uses, func(i, j int) bool { return combined.Uses[i].Repository < combined.Uses[j].Repository })
+	return combined, nil
+}
+
+// queryCheckAcrossOrganizations answers a check query for every organization of a database that indexes
+// several, naming repositories as organization/repository. A database of a single organization is
+// queried as is.
+func queryCheckAcrossOrganizations(dbPath, name string) (*CheckQueryResult, error) {
+	orgs, err := loadOrganizations(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	if orgs == nil {
+		return queryCheck(dbPath, name)
+	}
+	combined := &CheckQueryResult{Check: name, Sources: []MatchedCheckSource{}}
+	for _, orgName := range orgs {
+		result, err := queryCheck(filepath.Join(dbPath, orgName), name)
+		if err != nil {
+			return nil, err
+		}
+		for _, source := range result.Sources {
+			source.Repository = orgName + "/" + source.Repository
+			combined.Sources = append(combined.Sources, source)
+		}
+	}
+	sort.SliceStable(combined.Sources, func(i, j int) bool { return combined.Sources[i].Repository < combined.Sources[j].Repository })
+	return combined, nil
+}
+
+// queryRepositoryAcrossOrganizations answers a repository query in a database that indexes several
+// organizations, where the repository is named as organization/repository. A database of a single
+// organization is queried as is.
+func queryRepositoryAcrossOrganizations(dbPath, repoName string) (*RepositoryQueryResult, error) {
+	orgs, err := loadOrganizations(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	if orgs == nil {
+		return queryRepository(dbPath, repoName)
+	}
+	orgName, name, ok := strings.Cut(repoName, "/")
+	if !ok || !slices.Contains(orgs, orgName) {
+		return nil, fmt.Errorf("%w: %s; name it as organization/repository", errRepositoryNotIndexed, repoName)
+	}
+	result, err := queryRepository(filepath.Join(dbPath, orgName), name)
+	if err != nil {
+		return nil, err
+	}
+	result.Repository = repoName
+	return result, nil
+}
+
+// ------------------------
+// Section: Combined Usage
+// ------------------------
+
+// CombinedWorkflow counts the repositories using a workflow file name in each organization.
+type CombinedWorkflow struct {
+	Workflow      string
+	Repositories  map[string]int // Organization: repositories
+	TotalRepos    int
+	Organizations int
+}
+
+// CombinedAction merges the versions of a third-party action referenced across organizations.
+type CombinedAction struct {
+	Action        string
+	Versions      []ComparedVersion // Most used first
+	Uses          int
+	Organizations []string
+}
+
+// combineOrganizationUsage collects the workflow files and third-party actions of each organization of a
+// database that indexes several. Organizations not indexed yet are left out of the result.
+func combineOrganizationUsage(dbPath string, orgs []string) ([]ComparedOrganization, []CombinedWorkflow, []CombinedAction, error) {
+	var summaries []ComparedOrganization
+	workflows := make(map[string]*CombinedWorkflow)
+	actions := make(map[string]*CombinedAction)
+	versionUses := make(map[string]map[string]int)
+	for _, name := range orgs {
+		orgPath := filepath.Join(dbPath, name)
+		if _, err := os.Stat(filepath.Join(orgPath, "actions.yaml")); os.IsNotExist(err) {
+			continue
+		}
+		summary, orgActions, err := loadComparedOrganization(orgPath)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		summary.Organization = name
+		summaries = append(summaries, *summary)
+
+		for actionName, versions := range orgActions {
+			action, ok := actions[actionName]
+			if !ok {
+				action = &CombinedAction{Action: actionName}
+				actions[actionName] = action
+				versionUses[actionName] = make(map[string]int)
+			}
+			action.Organizations = append(action.Organizations, name)
+			for _, version := range versions {
+				action.Uses += version.Uses
+				versionUses[actionName][version.Version] += version.Uses
+			}
+		}
+
+		workflowNames, err := readSubdirectories(filepath.Join(orgPath, "workflows"))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		for _, workflowName := range workflowNames {
+			index, err := readActionIndex(filepath.Join(orgPath, "workflows", workflowName, "index.yaml"))
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("failed to read index of workflow '%s' in '%s': %v", workflowName, name, err)
+			}
+			if len(index.Repositories) == 0 {
+				continue
+			}
+			workflow, ok := workflows[workflowName]
+			if !ok {
+				workflow = &CombinedWorkflow{Workflow: workflowName, Repositories: make(map[string]int)}
+				workflows[workflowName] = workflow
+			}
+			workflow.Repositories[name] = len(index.Repositories)
+			workflow.TotalRepos += len(index.Repositories)
+			workflow.Organizations++
+		}
+	}
+
+	combinedWorkflows := make([]CombinedWorkflow, 0, len(workflows))
+	for _, workflow := range workflows {
+		combinedWorkflows = append(combinedWorkflows, *workflow)
+	}
+	sort.Slice(combinedWorkflows, func(i, j int) bool {
+		a, b := combinedWorkflows[i], combinedWorkflows[j]
+		if a.Organizations != b.Organizations {
+			return a.Organizations > b.Organizations
+		}
+		if a.TotalRepos != b.TotalRepos {
+			return a.TotalRepos > b.TotalRepos
+		}
+		return a.Workflow < b.Workflow
+	})
+
+	combinedActions := make([]CombinedAction, 0, len(actions))
+	for actionName, action := range actions {
+		for version, uses := range versionUses[actionName] {
+			action.Versions = append(action.Versions, ComparedVersion{Version: version, Uses: uses})
+		}
+		sort.Slice(action.Versions, func(i, j int) bool {
+			if action.Versions[i].Uses != action.Versions[j].Uses {
+				return action.Versions[i].Uses > action.Versions[j].Uses
+			}
+			return action.Versions[i].Version < action.Versions[j].Version
+		})
+		combinedActions = append(combinedActions, *action)
+	}
+	sort.Slice(combinedActions, func(i, j int) bool {
+		a, b := combinedActions[i], combinedActions[j]
+		if len(a.Organizations) != len(b.Organizations) {
+			return len(a.Organizations) > len(b.Organizations)
+		}
+		if a.Uses != b.Uses {
+			return a.Uses > b.Uses
+		}
+		return a.Action < b.Action
+	})
+	return summaries, combinedWorkflows, combinedActions, nil
+}
+
+// generateCombinedUsageMarkdown writes the USAGE.md of a database that indexes several organizations,
+// combining their workflow files and third-party actions.
+func generateCombinedUsageMarkdown(dbPath string, orgs []string) error {
+	summaries, workflows, actions, err := combineOrganizationUsage(dbPath, orgs)
+	if err != nil {
+		return err
+	}
+
+	var markdownBuilder strings.Builder
+	markdownBuilder.WriteString("# Combined Usage\n\n")
+	markdownBuilder.WriteString(fmt.Sprintf("Workflow files and third-party actions across %d organizations.\n\n", len(summaries)))
+
+	markdownBuilder.WriteString("## Organizations\n\n")
+	markdownBuilder.WriteString("| Organization | Repositories | Third-party actions | Action uses | Pinned |\n")
+	markdownBuilder.WriteString("|--------------|--------------|---------------------|-------------|--------|\n")
+	total := ComparedOrganization{Organization: "**Total**"}
+	for _, summary := range summaries {
+		markdownBuilder.WriteString(fmt.Sprintf("| [%s](%s/README.md) | %d | %d | %d | %s |\n", summary.Organization, summary.Organization, summary.Repositories, summary.Actions, summary.Uses, percent(summary.PinnedUses, summary.Uses)))
+		total.Repositories += summary.Repositories
+		total.Uses += summary.Uses
+		total.PinnedUses += summary.PinnedUses
+	}
+	markdownBuilder.WriteString(fmt.Sprintf("| %s | %d | %d | %d | %s |\n\n", total.Organization, total.Repositories, len(actions), total.Uses, percent(total.PinnedUses, total.Uses)))
+
+	markdownBuilder.WriteString("## Workflows\n\n")
+	if len(workflows) == 0 {
+		markdownBuilder.WriteString("No workflow files are indexed.\n\n")
+	} else {
+		markdownBuilder.WriteString("| Workflow | Organizations | Repositories | By organization |\n")
+		markdownBuilder.WriteString("|----------|---------------|--------------|-----------------|\n")
+		for _, workflow := range workflows {
+			var byOrg []string
+			for _, summary := range summaries {
+				if count, ok := workflow.Repositories[summary.Organization]; ok {
+					byOrg = append(byOrg, fmt.Sprintf("%s (%d)", summary.Organization, count))
+				}
+			}
+			markdownBuilder.WriteString(fmt.Sprintf("| `%s` | %d | %d | %s |\n", workflow.Workflow, workflow.Organizations, workflow.TotalRepos, strings.Join(byOrg, ", ")))
+		}
+		markdownBuilder.WriteString("\n")
+	}
+
+	markdownBuilder.WriteString("## Third-Party Actions\n\n")
+	if len(actions) == 0 {
+		markdownBuilder.WriteString("No third-party actions are referenced.\n\n")
+	} else {
+		markdownBuilder.WriteString("| Action | Organizations | Uses | Versions |\n")
+		markdownBuilder.WriteString("|--------|---------------|------|----------|\n")
+		for _, action := range actions {
+			markdownBuilder.WriteString(fmt.Sprintf("| `%s` | %s | %d | %s |\n", action.Action, strings.Join(action.Organizations, ", "), action.Uses, formatComparedVersions(action.Versions)))
+		}
+		markdownBuilder.WriteString("\n")
+	}
+	markdownBuilder.WriteString("*This file is automatically generated after each data collection run.*\n")
+
+	if err := os.WriteFile(filepath.Join(dbPath, "USAGE.md"), []byte(markdownBuilder.String()), 0644); err != nil {
+		return fmt.Errorf("error writing USAGE.md: %v", err)
+	}
+	logInfof("Generated USAGE.md for %d organizations", len(summaries))
+	return nil
+}
+
+// ------------------------
+// Section: Enterprise Organizations
+// ------------------------
+
+// enterpriseOrganizationsQuery lists a page of the organizations of an enterprise account.
+const enterpriseOrganizationsQuery = `query($slug: String!, $cursor: String) { enterprise(slug: $slug) { organizations(first: 100, after: $cursor) { nodes { login } pageInfo { hasNextPage endCursor } } } }`
+
+// listEnterpriseOrganizations returns the logins of the organizations of an enterprise account, sorted.
+// Only the organizations the token can see are listed, so the token needs to belong to an enterprise
+// member, and an owner's token lists every organization.
+func listEnterpriseOrganizations(client *github.Client, slug string) ([]string, error) {
+	var orgs []string
+	var cursor *string
+	for {
+		req, err := client.NewRequest("POST", graphqlPath(client), &graphqlRequest{
+			Query:     enterpriseOrganizationsQuery,
+			Variables: map[string]any{"slug": slug, "cursor": cursor},
+		})
+		if err != nil {
+			return nil, err
+		}
+		var response struct {
+			Data struct {
+				Enterprise *struct {
+					Organizations struct {
+						Nodes []struct {
+							Login string `json:"login"`
+						} `json:"nodes"`
+						PageInfo struct {
+							HasNextPage bool   `json:"hasNextPage"`
+							EndCursor   string `json:"endCursor"`
+						} `json:"pageInfo"`
+					} `json:"organizations"`
+				} `json:"enterprise"`
+			} `json:"data"`
+			Errors []graphqlError `json:"errors"`
+		}
+		if _, err := client.Do(context.Background(), req, &response); err != nil {
+			return nil, err
+		}
+		if len(response.Errors) > 0 {
+			return nil, fmt.Errorf("GraphQL query for enterprise '%s' failed: %s", slug, response.Errors[0].Message)
+		}
+		if response.Data.Enterprise == nil {
+			return nil, fmt.Errorf("enterprise '%s' not found", slug)
+		}
+		page := response.Data.Enterprise.Organizations
+		for _, node := range page.Nodes {
+			orgs = append(orgs, node.Login)
+		}
+		if !page.PageInfo.HasNextPage {
+			break
+		}
+		endCursor := page.PageInfo.EndCursor
+		cursor = &endCursor
+	}
+	sort.Strings(orgs)
+	return orgs, nil
+}
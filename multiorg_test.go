@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v50/github"
+)
+
+// writeMultiOrgTestDB creates a database indexing two organizations, each with the repositories of writeQueryTestDB.
+func writeMultiOrgTestDB(t *testing.T) string {
+	t.Helper()
+
+	dbPath := t.TempDir()
+	for _, name := range []string{"org-b", "org-a"} {
+		if err := os.Rename(writeQueryTestDB(t), filepath.Join(dbPath, name)); err != nil {
+			t.Fatalf("failed to move organization database: %v", err)
+		}
+	}
+	if err := recordOrganizations(dbPath, "", []string{"org-b", "org-a"}); err != nil {
+		t.Fatalf("recordOrganizations returned error: %v", err)
+	}
+	return dbPath
+}
+
+func TestOrganizationList(t *testing.T) {
+	t.Parallel()
+
+	var orgs organizationList
+	for _, value := range []string{"org-a", "org-b, org-c", "org-a"} {
+		if err := orgs.Set(value); err != nil {
+			t.Fatalf("Set returned error: %v", err)
+		}
+	}
+	if orgs.String() != "org-a,org-b,org-c" {
+		t.Fatalf("unexpected organizations: %v", orgs)
+	}
+}
+
+func TestRecordOrganizations(t *testing.T) {
+	t.Parallel()
+
+	dbPath := writeMultiOrgTestDB(t)
+
+	// A later run of one organization keeps the others
+	if err := recordOrganizations(dbPath, "example-enterprise", []string{"org-c"}); err != nil {
+		t.Fatalf("recordOrganizations returned error: %v", err)
+	}
+	orgs, err := loadOrganizations(dbPath)
+	if err != nil {
+		t.Fatalf("loadOrganizations returned error: %v", err)
+	}
+	if strings.Join(orgs, ",") != "org-a,org-b,org-c" {
+		t.Fatalf("unexpected organizations: %v", orgs)
+	}
+
+	readme, err := os.ReadFile(filepath.Join(dbPath, "README.md"))
+	if err != nil {
+		t.Fatalf("failed to read README.md: %v", err)
+	}
+	if !strings.Contains(string(readme), "the **example-enterprise** enterprise") || !strings.Contains(string(readme), "| [org-a](org-a/README.md) | 2 |") || !strings.Contains(string(readme), "| [org-c](org-c/README.md) | - |") {
+		t.Fatalf("unexpected README.md:\n%s", readme)
+	}
+
+	// A database of a single organization has no organizations.yaml
+	if orgs, err := loadOrganizations(writeQueryTestDB(t)); err != nil || orgs != nil {
+		t.Fatalf("expected no organizations, got %v, %v", orgs, err)
+	}
+}
+
+func TestQueryAcrossOrganizations(t *testing.T) {
+	t.Parallel()
+
+	dbPath := writeMultiOrgTestDB(t)
+
+	actions, err := queryActionAcrossOrganizations(dbPath, "actions/checkout@v4")
+	if err != nil {
+		t.Fatalf("queryActionAcrossOrganizations returned error: %v", err)
+	}
+	if len(actions.Uses) != 2 || actions.Uses[0].Repository != "org-a/repo-a" || actions.Uses[1].Repository != "org-b/repo-a" {
+		t.Fatalf("unexpected uses: %+v", actions.Uses)
+	}
+
+	repo, err := queryRepositoryAcrossOrganizations(dbPath, "org-b/repo-a")
+	if err != nil {
+		t.Fatalf("queryRepositoryAcrossOrganizations returned error: %v", err)
+	}
+	if repo.Repository != "org-b/repo-a" || len(repo.Workflows) != 1 {
+		t.Fatalf("unexpected repository: %+v", repo)
+	}
+
+	// Without the organization the repository is ambiguous
+	if _, err := queryRepositoryAcrossOrganizations(dbPath, "repo-a"); !errors.Is(err, errRepositoryNotIndexed) {
+		t.Fatalf("expected errRepositoryNotIndexed, got %v", err)
+	}
+
+	// A database of a single organization is queried as is
+	single, err := queryActionAcrossOrganizations(writeQueryTestDB(t), "actions/checkout")
+	if err != nil {
+		t.Fatalf("queryActionAcrossOrganizations returned error: %v", err)
+	}
+	if len(single.Uses) != 2 || single.Uses[0].Repository != "repo-a" {
+		t.Fatalf("unexpected uses: %+v", single.Uses)
+	}
+}
+
+func TestGenerateCombinedUsageMarkdown(t *testing.T) {
+	t.Parallel()
+
+	dbPath := writeMultiOrgTestDB(t)
+	pinned := "0565863a31f2c772f9f0395002a31e3f06189574 # v5.4.0"
+	for name, refs := range map[string][]ActionReference{
+		"org-a": {{Repository: "repo-a", Workflow: ".github/workflows/build.yml", Version: pinned}},
+		"org-b": {
+			{Repository: "repo-a", Workflow: ".github/workflows/build.yml", Version: pinned},
+			{Repository: "repo-b", Workflow: ".github/workflows/build.yml", Version: "v5"},
+		},
+	} {
+		index := ActionsReverseIndex{Organization: name, Actions: map[string][]ActionReference{"codecov/codecov-action": refs}}
+		if err := writeYAMLFile(filepath.Join(dbPath, name), "actions.yaml", index); err != nil {
+			t.Fatalf("writeYAMLFile returned error: %v", err)
+		}
+	}
+
+	// An organization listed but not indexed yet is left out
+	if err := generateCombinedUsageMarkdown(dbPath, []string{"org-a", "org-b", "org-c"}); err != nil {
+		t.Fatalf("generateCombinedUsageMarkdown returned error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dbPath, "USAGE.md"))
+	if err != nil {
+		t.Fatalf("failed to read USAGE.md: %v", err)
+	}
+	usage := string(data)
+	for _, want := range []string{
+		"across 2 organizations",
+		"| [org-b](org-b/README.md) | 2 | 1 | 2 | 50.0% |",
+		"| **Total** | 4 | 1 | 3 | 66.7% |",
+		"| `build.yml` | 2 | 4 | org-a (2), org-b (2) |",
+		"| `codecov/codecov-action` | org-a, org-b | 3 | `" + pinned + "` (2), `v5` (1) |",
+	} {
+		if !strings.Contains(usage, want) {
+			t.Fatalf("expected USAGE.md to contain %q:\n%s", want, usage)
+		}
+	}
+}
+
+func TestListEnterpriseOrganizations(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request graphqlRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if request.Variables["slug"] != "example-enterprise" {
+			json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"enterprise": nil}})
+			return
+		}
+		page := map[string]any{"nodes": []map[string]string{{"login": "org-c"}, {"login": "org-a"}}, "pageInfo": map[string]any{"hasNextPage": true, "endCursor": "page-2"}}
+		if request.Variables["cursor"] == "page-2" {
+			page = map[string]any{"nodes": []map[string]string{{"login": "org-b"}}, "pageInfo": map[string]any{"hasNextPage": false}}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"enterprise": map[string]any{"organizations": page}}})
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	orgs, err := listEnterpriseOrganizations(client, "example-enterprise")
+	if err != nil {
+		t.Fatalf("listEnterpriseOrganizations returned error: %v", err)
+	}
+	if strings.Join(orgs, ",") != "org-a,org-b,org-c" {
+		t.Fatalf("unexpected organizations: %v", orgs)
+	}
+	if _, err := listEnterpriseOrganizations(client, "missing"); err == nil {
+		t.Fatal("expected an error for an unknown enterprise")
+	}
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Notifications
+// ------------------------
+
+// Event types that can be sent to notification sinks.
+const (
+	EventFinding        = "finding"
+	EventNewAction      = "new-action"
+	EventWorkflowChange = "workflow-change"
+	// EventRequiredWorkflow reports a workflow required by an organization ruleset that changed
+	EventRequiredWorkflow = "required-workflow"
+)
+
+// Kinds of notification sinks.
+const (
+	SinkTypeSlack   = "slack"
+	SinkTypeWebhook = "webhook"
+)
+
+// severityRank orders severities so that thresholds can be compared; higher is more urgent.
+var severityRank = map[string]int{
+	SeverityLow:      1,
+	SeverityMedium:   2,
+	SeverityHigh:     3,
+	SeverityCritical: 4,
+}
+
+// notificationClient sends notification requests; it is replaced in tests.
+var notificationClient = &http.Client{Timeout: 30 * time.Second}
+
+// QuietHours is a daily window during which a sink receives no notifications.
+// A window whose end is before its start wraps past midnight.
+type QuietHours struct {
+	Start    string `yaml:"start" json:"start"` // HH:MM
+	End      string `yaml:"end" json:"end"`     // HH:MM
+	Timezone string `yaml:"timezone,omitempty" json:"timezone,omitempty"`
+}
+
+// NotificationSink is a destination for notifications and the events it wants to receive.
+type NotificationSink struct {
+	Name        string      `yaml:"name"`
+	Type        string      `yaml:"type"`
+	URL         string      `yaml:"url,omitempty"`
+	URLEnv      string      `yaml:"url_env,omitempty"` // Environment variable holding the URL, so it need not be committed
+	Events      []string    `yaml:"events,omitempty"`  // Defaults to every event type
+	MinSeverity string      `yaml:"min_severity,omitempty"`
+	QuietHours  *QuietHours `yaml:"quiet_hours,omitempty"`
+	// Annotations restricts the sink to events from workflows carrying all of these annotation values
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// NotificationConfig is the contents of notifications.yaml.
+type NotificationConfig struct {
+	Sinks []NotificationSink `yaml:"sinks"`
+}
+
+// NotificationEvent is a single change detected during a run.
+type NotificationEvent struct {
+	Type     string `json:"type"`
+	Severity string `json:"severity,omitempty"` // Only set for findings
+	RepoName string `json:"repository,omitempty"`
+	Subject  string `json:"subject"`
+	Message  string `json:"message"`
+	// Annotations are the comment-based metadata of the workflow the event concerns, if any
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// WorkflowChange records a repository moving to a different version of a workflow file during a run.
+type WorkflowChange struct {
+	RepoName   string
+	FilePath   string
+	ActionName string // Folder of the workflow in the database
+	From       string
+	To         string
+	// Annotations are the comment-based metadata of the new version of the workflow
+	Annotations map[string]string
+}
+
+// loadNotificationConfig reads notifications.yaml from the database, returning nil if it does not exist.
+func loadNotificationConfig(dbPath string) (*NotificationConfig, error) {
+	data, err := os.ReadFile(filepath.Join(dbPath, "notifications.yaml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var config NotificationConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse notifications.yaml: %v", err)
+	}
+	for i, sink := range config.Sinks {
+		if err := sink.validate(); err != nil {
+			return nil, fmt.Errorf("invalid notification sink %d ('%s'): %v", i+1, sink.Name, err)
+		}
+	}
+	return &config, nil
+}
+
+// validate checks that a sink's configuration can be used.
+func (s NotificationSink) validate() error {
+	if s.Type != SinkTypeSlack && s.Type != SinkTypeWebhook {
+		return fmt.Errorf("unknown type '%s', expected '%s' or '%s'", s.Type, SinkTypeSlack, SinkTypeWebhook)
+	}
+	if s.URL == "" && s.URLEnv == "" {
+		return fmt.Errorf("one of 'url' or 'url_env' is required")
+	}
+	for _, event := range s.Events {
+		if event != EventFinding && event != EventNewAction && event != EventWorkflowChange && event != EventRequiredWorkflow {
+			return fmt.Errorf("unknown event type '%s'", event)
+		}
+	}
+	if s.MinSeverity != "" {
+		if _, ok := severityRank[s.MinSeverity]; !ok {
+			return fmt.Errorf("unknown severity '%s'", s.MinSeverity)
+		}
+	}
+	if s.QuietHours != nil {
+		if _, _, err := s.QuietHours.window(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// accepts reports whether the sink wants to receive an event.
+func (s NotificationSink) accepts(event NotificationEvent) bool {
+	if len(s.Events) > 0 {
+		found := false
+		for _, eventType := range s.Events {
+			if eventType == event.Type {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for key, value := range s.Annotations {
+		if !strings.EqualFold(event.Annotations[strings.ToLower(key)], value) {
+			return false
+		}
+	}
+	if s.MinSeverity != "" && event.Severity != "" {
+		return severityRank[event.Severity] >= severityRank[s.MinSeverity]
+	}
+	return true
+}
+
+// window parses the quiet hours into minutes after midnight and the timezone they are expressed in.
+func (q QuietHours) window() (start, end int, err error) {
+	parse := func(value string) (int, error) {
+		t, err := time.Parse("15:04", value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid quiet hours time '%s', expected HH:MM", value)
+		}
+		return t.Hour()*60 + t.Minute(), nil
+	}
+	if start, err = parse(q.Start); err != nil {
+		return 0, 0, err
+	}
+	if end, err = parse(q.End); err != nil {
+		return 0, 0, err
+	}
+	if q.Timezone != "" {
+		if _, err := time.LoadLocation(q.Timezone); err != nil {
+			return 0, 0, fmt.Errorf("invalid quiet hours timezone '%s': %v", q.Timezone, err)
+		}
+	}
+	return start, end, nil
+}
+
+// contains reports whether now falls inside the quiet hours. Times are in the report timezone unless one is set.
+func (q QuietHours) contains(now time.Time) bool {
+	start, end, err := q.window()
+	if err != nil || start == end {
+		return false
+	}
+	location := reportLocation
+	if q.Timezone != "" {
+		location, _ = time.LoadLocation(q.Timezone)
+	}
+	local := now.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// buildNotificationEvents compares this run against the previous metrics snapshot and returns the events to send.
+// Without a previous snapshot there is no baseline, so only workflow changes are reported.
+func buildNotificationEvents(org string, previous *MetricsSnapshot, findings []Finding, usesIndex *ActionUsesIndex, changes []WorkflowChange) []NotificationEvent {
+	var events []NotificationEvent
+
+	if previous != nil {
+		known := make(map[string]bool)
+		for _, fingerprint := range previous.Findings {
+			known[fingerprint] = true
+		}
+		for _, finding := range findings {
+			if known[finding.Fingerprint] {
+				continue
+			}
+			known[finding.Fingerprint] = true
+			events = append(events, NotificationEvent{
+				Type:        EventFinding,
+				Severity:    finding.Severity,
+				RepoName:    finding.RepoName,
+				Subject:     fmt.Sprintf("%s in %s/%s", finding.Rule, finding.RepoName, finding.FilePath),
+				Message:     finding.Message,
+				Annotations: finding.Annotations,
+			})
+		}
+
+		for _, actionName := range setDifference(usedThirdPartyActions(org, usesIndex), previous.ThirdPartyActions) {
+			repos := actionRepositories(usesIndex, actionName)
+			events = append(events, NotificationEvent{
+				Type:    EventNewAction,
+				Subject: actionName,
+				Message: fmt.Sprintf("New third-party action used by %s", strings.Join(repos, ", ")),
+			})
+		}
+	}
+
+	for _, change := range changes {
+		message := fmt.Sprintf("Changed from %s to %s", shortHash(change.From), shortHash(change.To))
+		if change.From == "" {
+			message = fmt.Sprintf("Added at %s", shortHash(change.To))
+		}
+		events = append(events, NotificationEvent{
+			Type:        EventWorkflowChange,
+			RepoName:    change.RepoName,
+			Subject:     fmt.Sprintf("%s/%s", change.RepoName, change.FilePath),
+			Message:     message,
+			Annotations: change.Annotations,
+		})
+	}
+
+	return events
+}
+
+// sendNotifications delivers the events each sink accepts, skipping sinks that are in quiet hours.
+func sendNotifications(config *NotificationConfig, org string, events []NotificationEvent, now time.Time) {
+	if config == nil {
+		return
+	}
+
+	for _, sink := range config.Sinks {
+		var accepted []NotificationEvent
+		for _, event := range events {
+			if sink.accepts(event) {
+				accepted = append(accepted, event)
+			}
+		}
+		if len(accepted) == 0 {
+			continue
+		}
+		if sink.QuietHours != nil && sink.QuietHours.contains(now) {
+			logInfof("Skipping %d notifications for sink '%s' during quiet hours", len(accepted), sink.Name)
+			continue
+		}
+
+		if err := deliverNotification(sink, org, accepted); err != nil {
+			logErrorf("Error sending notifications to sink '%s': %v", sink.Name, err)
+			continue
+		}
+		logInfof("Sent %d notifications to sink '%s'", len(accepted), sink.Name)
+	}
+}
+
+// deliverNotification posts a batch of events to a sink.
+func deliverNotification(sink NotificationSink, org string, events []NotificationEvent) error {
+	url := sink.URL
+	if sink.URLEnv != "" {
+		url = os.Getenv(sink.URLEnv)
+		if url == "" {
+			return fmt.Errorf("environment variable %s is not set", sink.URLEnv)
+		}
+	}
+
+	var payload interface{}
+	if sink.Type == SinkTypeSlack {
+		payload = map[string]string{"text": formatSlackNotification(org, events)}
+	} else {
+		payload = map[string]interface{}{"organization": org, "events": events}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := notificationClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// formatSlackNotification renders events as a Slack message.
+func formatSlackNotification(org string, events []NotificationEvent) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("*dotgithubindexer* found %d changes in %s\n", len(events), org))
+	for _, event := range events {
+		label := event.Type
+		if event.Severity != "" {
+			label = strings.ToUpper(event.Severity)
+		}
+		builder.WriteString(fmt.Sprintf("• [%s] `%s`: %s\n", label, event.Subject, event.Message))
+	}
+	return builder.String()
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotificationSinkAccepts(t *testing.T) {
+	t.Parallel()
+
+	sink := NotificationSink{Events: []string{EventFinding, EventNewAction}, MinSeverity: SeverityCritical}
+	cases := []struct {
+		event NotificationEvent
+		want  bool
+	}{
+		{NotificationEvent{Type: EventFinding, Severity: SeverityCritical}, true},
+		{NotificationEvent{Type: EventFinding, Severity: SeverityHigh}, false},
+		{NotificationEvent{Type: EventNewAction}, true},
+		{NotificationEvent{Type: EventWorkflowChange}, false},
+	}
+	for _, c := range cases {
+		if got := sink.accepts(c.event); got != c.want {
+			t.Fatalf("accepts(%+v) = %v, want %v", c.event, got, c.want)
+		}
+	}
+
+	if !(NotificationSink{}).accepts(NotificationEvent{Type: EventWorkflowChange}) {
+		t.Fatalf("expected a sink without filters to accept every event")
+	}
+}
+
+func TestQuietHoursContains(t *testing.T) {
+	t.Parallel()
+
+	overnight := QuietHours{Start: "22:00", End: "07:00", Timezone: "America/New_York"}
+	cases := map[string]bool{
+		"2024-01-15T03:30:00Z": true,  // 22:30 in New York
+		"2024-01-15T11:59:00Z": true,  // 06:59 in New York
+		"2024-01-15T12:00:00Z": false, // 07:00 in New York
+		"2024-01-15T17:00:00Z": false, // 12:00 in New York
+	}
+	for value, want := range cases {
+		now, _ := time.Parse(time.RFC3339, value)
+		if got := overnight.contains(now); got != want {
+			t.Fatalf("contains(%s) = %v, want %v", value, got, want)
+		}
+	}
+
+	daytime := QuietHours{Start: "09:00", End: "17:00"}
+	if !daytime.contains(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)) || daytime.contains(time.Date(2024, 1, 15, 18, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected daytime quiet hours result")
+	}
+}
+
+func TestLoadNotificationConfigValidates(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	if config, err := loadNotificationConfig(dbPath); err != nil || config != nil {
+		t.Fatalf("expected no config without notifications.yaml, got %+v, %v", config, err)
+	}
+
+	invalid := "sinks:\n  - name: security\n    type: slack\n    url: https://example.com\n    min_severity: urgent\n"
+	if err := os.WriteFile(filepath.Join(dbPath, "notifications.yaml"), []byte(invalid), 0644); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	if _, err := loadNotificationConfig(dbPath); err == nil || !strings.Contains(err.Error(), "urgent") {
+		t.Fatalf("expected an unknown severity error, got %v", err)
+	}
+}
+
+func TestBuildNotificationEvents(t *testing.T) {
+	t.Parallel()
+
+	known := newFinding("github-token", "repo-a", "build.yml", 3, "token")
+	fresh := newFinding("compromised-action", "repo-b", "build.yml", 5, "compromised")
+	previous := &MetricsSnapshot{Findings: []string{known.Fingerprint}, ThirdPartyActions: []string{"third-party/old"}}
+	usesIndex := &ActionUsesIndex{Actions: map[string]map[string][]WorkflowReference{
+		"third-party/old": {"v1": {{RepoName: "repo-a", FilePath: "build.yml"}}},
+		"third-party/new": {"v1": {{RepoName: "repo-b", FilePath: "build.yml"}, {RepoName: "repo-b", FilePath: "test.yml"}}},
+	}}
+	changes := []WorkflowChange{{RepoName: "repo-a", FilePath: "build.yml", From: "aaaaaaaaaaaaaaaa", To: "bbbbbbbbbbbbbbbb"}}
+
+	events := buildNotificationEvents("example-org", previous, []Finding{known, fresh}, usesIndex, changes)
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %+v", events)
+	}
+	if events[0].Type != EventFinding || events[0].Severity != SeverityCritical || events[0].RepoName != "repo-b" {
+		t.Fatalf("unexpected finding event: %+v", events[0])
+	}
+	if events[1].Type != EventNewAction || events[1].Subject != "third-party/new" || events[1].Message != "New third-party action used by repo-b" {
+		t.Fatalf("unexpected new action event: %+v", events[1])
+	}
+	if events[2].Type != EventWorkflowChange || events[2].Message != "Changed from aaaaaaaaaaaa to bbbbbbbbbbbb" {
+		t.Fatalf("unexpected workflow change event: %+v", events[2])
+	}
+
+	baseline := buildNotificationEvents("example-org", nil, []Finding{known, fresh}, usesIndex, changes)
+	if len(baseline) != 1 || baseline[0].Type != EventWorkflowChange {
+		t.Fatalf("expected only workflow changes without a previous snapshot, got %+v", baseline)
+	}
+}
+
+func TestSendNotifications(t *testing.T) {
+	var payloads []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]interface{}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+		payloads = append(payloads, payload)
+	}))
+	defer server.Close()
+
+	config := &NotificationConfig{Sinks: []NotificationSink{
+		{Name: "security", Type: SinkTypeSlack, URL: server.URL, Events: []string{EventFinding}, MinSeverity: SeverityCritical},
+		{Name: "audit", Type: SinkTypeWebhook, URL: server.URL},
+		{Name: "night", Type: SinkTypeWebhook, URL: server.URL, QuietHours: &QuietHours{Start: "00:00", End: "23:59", Timezone: "UTC"}},
+	}}
+	events := []NotificationEvent{
+		{Type: EventFinding, Severity: SeverityCritical, Subject: "github-token in repo-a/build.yml", Message: "token"},
+		{Type: EventWorkflowChange, Subject: "repo-a/build.yml", Message: "Changed"},
+	}
+
+	sendNotifications(config, "example-org", events, time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	if len(payloads) != 2 {
+		t.Fatalf("expected 2 deliveries, got %d", len(payloads))
+	}
+	text, _ := payloads[0]["text"].(string)
+	if !strings.Contains(text, "[CRITICAL] `github-token in repo-a/build.yml`: token") || strings.Contains(text, "Changed") {
+		t.Fatalf("unexpected Slack message: %q", text)
+	}
+	if delivered, _ := payloads[1]["events"].([]interface{}); len(delivered) != 2 || payloads[1]["organization"] != "example-org" {
+		t.Fatalf("unexpected webhook payload: %+v", payloads[1])
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ------------------------
+// Section: Command Output
+// ------------------------
+
+// Output formats accepted by the -format flag of commands that print results.
+const (
+	formatText     = "text"
+	formatMarkdown = "markdown"
+	formatHTML     = "html"
+	formatJSON     = "json"
+	formatYAML     = "yaml"
+	formatGraphML  = "graphml"
+	formatDOT      = "dot"
+)
+
+// resultWriter receives the results printed by commands. The log goes to os.Stdout.
+var resultWriter io.Writer = os.Stdout
+
+// useJSONOutput sends the log to standard error so that standard output only carries
+// the JSON document written to resultWriter.
+func useJSONOutput() {
+	resultWriter = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+// checkFormat returns an error unless format is one of the formats a command supports.
+func checkFormat(format string, supported ...string) error {
+	for _, name := range supported {
+		if format == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown format '%s'", format)
+}
+
+// emptyIfNil returns an empty slice for nil so that it is encoded as [] in JSON documents.
+func emptyIfNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
+// formatJSONDocument renders v as an indented JSON document.
+func formatJSONDocument(v any) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JSON: %v", err)
+	}
+	return string(data) + "\n", nil
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Unpinned Actions
+// ------------------------
+
+// Kinds of mutable references, inferred from the reference itself.
+const (
+	RefTypeTag      = "tag"       // A version such as v4 or 1.2.3
+	RefTypeBranch   = "branch"    // Any other name, such as main
+	RefTypeShortSHA = "short-sha" // An abbreviated commit SHA, which GitHub resolves like a branch or tag name
+	RefTypeImageTag = "image-tag" // A Docker image tag instead of an @sha256: digest
+	RefTypeNone     = "none"      // No reference at all
+)
+
+var (
+	versionRefRe  = regexp.MustCompile(`^v?\d+(\.\d+)*([-+].*)?$`)
+	shortSHARefRe = regexp.MustCompile(`^[0-9a-f]{7,39}$`)
+)
+
+// UnpinnedUse is a uses: reference that is not pinned to a full commit SHA or image digest.
+type UnpinnedUse struct {
+	Repository string `yaml:"repository"`
+	Workflow   string `yaml:"workflow"`
+	Line       int    `yaml:"line"`
+	Uses       string `yaml:"uses"` // The action, reusable workflow, or image without its reference
+	Ref        string `yaml:"ref"`
+	RefType    string `yaml:"ref_type"`
+}
+
+// UnpinnedReport is the contents of reports/unpinned.yaml.
+type UnpinnedReport struct {
+	TotalUses  int           `yaml:"total_uses"`
+	PinnedUses int           `yaml:"pinned_uses"`
+	Unpinned   []UnpinnedUse `yaml:"unpinned"`
+}
+
+// workflowUsesLines returns every remote uses: value in a workflow, from both steps and jobs calling a
+// reusable workflow, with its line. Local actions and workflows are pinned by the repository itself and left out.
+func workflowUsesLines(content string) map[int]string {
+	uses := make(map[int]string)
+	doc, err := parseWorkflowDocument(content)
+	if err != nil {
+		return uses
+	}
+	jobs := mappingValue(doc, "jobs")
+	if jobs == nil || jobs.Kind != yaml.MappingNode {
+		return uses
+	}
+
+	add := func(node *yaml.Node) {
+		if node != nil && node.Kind == yaml.ScalarNode && node.Value != "" && !strings.HasPrefix(node.Value, "./") {
+			uses[node.Line] = node.Value
+		}
+	}
+	for i := 1; i < len(jobs.Content); i += 2 {
+		add(mappingValue(jobs.Content[i], "uses"))
+		steps := mappingValue(jobs.Content[i], "steps")
+		if steps == nil || steps.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, step := range steps.Content {
+			add(mappingValue(step, "uses"))
+		}
+	}
+	return uses
+}
+
+// classifyUsesRef splits a uses: value into what it references and its reference, and reports whether the
+// reference is immutable. For a mutable reference it also returns the kind of reference.
+func classifyUsesRef(value string) (target, ref, refType string, pinned bool) {
+	if image, ok := strings.CutPrefix(value, "docker://"); ok {
+		if name, digest, ok := strings.Cut(image, "@"); ok {
+			return name, digest, "", strings.HasPrefix(digest, "sha256:")
+		}
+		// The tag follows the last colon after the last slash, so a registry port is not mistaken for one
+		slash := strings.LastIndex(image, "/")
+		if colon := strings.LastIndex(image, ":"); colon > slash {
+			return image[:colon], image[colon+1:], RefTypeImageTag, false
+		}
+		return image, "latest", RefTypeImageTag, false
+	}
+
+	target, ref, ok := strings.Cut(value, "@")
+	switch {
+	case !ok || ref == "":
+		return target, "", RefTypeNone, false
+	case shaPinRe.MatchString(ref):
+		return target, ref, "", true
+	case shortSHARefRe.MatchString(ref):
+		return target, ref, RefTypeShortSHA, false
+	case versionRefRe.MatchString(ref):
+		return target, ref, RefTypeTag, false
+	default:
+		return target, ref, RefTypeBranch, false
+	}
+}
+
+// buildUnpinnedReport lists every uses: reference in the indexed workflows that is not pinned to a full
+// commit SHA, sorted by repository, workflow, then line.
+func buildUnpinnedReport(dbPath string) (*UnpinnedReport, error) {
+	report := &UnpinnedReport{Unpinned: []UnpinnedUse{}}
+	err := walkIndexedWorkflows(dbPath, func(fileName, repoName, content string) {
+		for line, value := range workflowUsesLines(content) {
+			report.TotalUses++
+			target, ref, refType, pinned := classifyUsesRef(value)
+			if pinned {
+				report.PinnedUses++
+				continue
+			}
+			report.Unpinned = append(report.Unpinned, UnpinnedUse{
+				Repository: repoName,
+				Workflow:   ".github/workflows/" + fileName,
+				Line:       line,
+				Uses:       target,
+				Ref:        ref,
+				RefType:    refType,
+			})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(report.Unpinned, func(i, j int) bool {
+		a, b := report.Unpinned[i], report.Unpinned[j]
+		if a.Repository != b.Repository {
+			return a.Repository < b.Repository
+		}
+		if a.Workflow != b.Workflow {
+			return a.Workflow < b.Workflow
+		}
+		return a.Line < b.Line
+	})
+	return report, nil
+}
+
+// countByRefType counts the unpinned references of each kind.
+func (r *UnpinnedReport) countByRefType() map[string]int {
+	counts := make(map[string]int)
+	for _, use := range r.Unpinned {
+		counts[use.RefType]++
+	}
+	return counts
+}
+
+// generateUnpinnedReport writes reports/unpinned.yaml to the database.
+func generateUnpinnedReport(dbPath string) error {
+	report, err := buildUnpinnedReport(dbPath)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("error marshaling unpinned.yaml: %v", err)
+	}
+	reportsPath := filepath.Join(dbPath, "reports")
+	if err := os.MkdirAll(reportsPath, 0755); err != nil {
+		return fmt.Errorf("failed to create reports directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(reportsPath, "unpinned.yaml"), data, 0644); err != nil {
+		return fmt.Errorf("error writing unpinned.yaml: %v", err)
+	}
+
+	logInfof("Generated reports/unpinned.yaml with %d of %d references not pinned to a commit SHA", len(report.Unpinned), report.TotalUses)
+	return nil
+}
+
+// formatPinningSummary formats the summary of the unpinned references for the database README.
+func formatPinningSummary(report *UnpinnedReport) string {
+	counts := report.countByRefType()
+	var builder strings.Builder
+	builder.WriteString("\n## Pinning Summary\n\n")
+	builder.WriteString("This table counts the `uses:` references of the indexed workflows by what they are pinned to. Every reference that is not pinned to a full commit SHA or image digest is listed in [reports/unpinned.yaml](reports/unpinned.yaml).\n\n")
+	builder.WriteString("| Pinned To | References |\n")
+	builder.WriteString("|-----------|------------|\n")
+	builder.WriteString(fmt.Sprintf("| Full commit SHA or image digest | %d (%s) |\n", report.PinnedUses, percent(report.PinnedUses, report.TotalUses)))
+	for _, row := range []struct{ label, refType string }{
+		{"Tag", RefTypeTag},
+		{"Branch", RefTypeBranch},
+		{"Abbreviated SHA", RefTypeShortSHA},
+		{"Docker image tag", RefTypeImageTag},
+		{"No reference", RefTypeNone},
+	} {
+		if counts[row.refType] > 0 {
+			builder.WriteString(fmt.Sprintf("| %s | %d (%s) |\n", row.label, counts[row.refType], percent(counts[row.refType], report.TotalUses)))
+		}
+	}
+	return builder.String()
+}
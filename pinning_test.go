@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClassifyUsesRef(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value, target, ref, refType string
+		pinned                      bool
+	}{
+		{"actions/checkout@de0fac2e4500dabe0009e67214ff5f5447ce83dd", "actions/checkout", "de0fac2e4500dabe0009e67214ff5f5447ce83dd", "", true},
+		{"actions/checkout@v4", "actions/checkout", "v4", RefTypeTag, false},
+		{"actions/setup-go@1.2.3-beta", "actions/setup-go", "1.2.3-beta", RefTypeTag, false},
+		{"example-org/workflows/.github/workflows/go.yml@main", "example-org/workflows/.github/workflows/go.yml", "main", RefTypeBranch, false},
+		{"actions/cache@de0fac2", "actions/cache", "de0fac2", RefTypeShortSHA, false},
+		{"actions/cache", "actions/cache", "", RefTypeNone, false},
+		{"docker://alpine@sha256:0123", "alpine", "sha256:0123", "", true},
+		{"docker://registry.example.com:5000/tools/lint:1.4", "registry.example.com:5000/tools/lint", "1.4", RefTypeImageTag, false},
+		{"docker://registry.example.com:5000/tools/lint", "registry.example.com:5000/tools/lint", "latest", RefTypeImageTag, false},
+	}
+	for _, tt := range tests {
+		target, ref, refType, pinned := classifyUsesRef(tt.value)
+		if target != tt.target || ref != tt.ref || refType != tt.refType || pinned != tt.pinned {
+			t.Fatalf("classifyUsesRef(%q) = %q, %q, %q, %v", tt.value, target, ref, refType, pinned)
+		}
+	}
+}
+
+func TestGenerateUnpinnedReport(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	content := `jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@de0fac2e4500dabe0009e67214ff5f5447ce83dd # v4
+      - uses: actions/setup-go@v5
+      - uses: ./.github/actions/local
+  release:
+    uses: example-org/workflows/.github/workflows/release.yml@main
+`
+	if err := updateActionIndex(dbPath, "ci.yml", "repo-a", "ci.yml", "hash-one", ""); err != nil {
+		t.Fatalf("updateActionIndex returned error: %v", err)
+	}
+	if err := storeActionVersion(dbPath, "ci.yml", "hash-one", content); err != nil {
+		t.Fatalf("storeActionVersion returned error: %v", err)
+	}
+
+	if err := generateUnpinnedReport(dbPath); err != nil {
+		t.Fatalf("generateUnpinnedReport returned error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dbPath, "reports", "unpinned.yaml"))
+	if err != nil {
+		t.Fatalf("failed to read unpinned.yaml: %v", err)
+	}
+	want := `total_uses: 3
+pinned_uses: 1
+unpinned:
+    - repository: repo-a
+      workflow: .github/workflows/ci.yml
+      line: 6
+      uses: actions/setup-go
+      ref: v5
+      ref_type: tag
+    - repository: repo-a
+      workflow: .github/workflows/ci.yml
+      line: 9
+      uses: example-org/workflows/.github/workflows/release.yml
+      ref: main
+      ref_type: branch
+`
+	if string(data) != want {
+		t.Fatalf("unexpected unpinned.yaml content:\n%s", data)
+	}
+
+	report, err := buildUnpinnedReport(dbPath)
+	if err != nil {
+		t.Fatalf("buildUnpinnedReport returned error: %v", err)
+	}
+	summary := formatPinningSummary(report)
+	if !strings.Contains(summary, "| Full commit SHA or image digest | 1 (33.3%) |") || !strings.Contains(summary, "| Branch | 1 (33.3%) |") || strings.Contains(summary, "| No reference |") {
+		t.Fatalf("unexpected pinning summary:\n%s", summary)
+	}
+}
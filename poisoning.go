@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Workflow Triggers
+// ------------------------
+
+// workflowTriggers returns the events a workflow runs on, sorted. The 'on' key may be a single event,
+// a list of events, or a mapping of events to their filters.
+func workflowTriggers(workflow *yaml.Node) []string {
+	on := mappingValue(workflow, "on")
+	if on == nil {
+		return nil
+	}
+
+	var triggers []string
+	switch on.Kind {
+	case yaml.ScalarNode:
+		triggers = []string{on.Value}
+	case yaml.SequenceNode:
+		for _, event := range on.Content {
+			if event.Kind == yaml.ScalarNode {
+				triggers = append(triggers, event.Value)
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(on.Content); i += 2 {
+			triggers = append(triggers, on.Content[i].Value)
+		}
+	}
+	sort.Strings(triggers)
+	return triggers
+}
+
+// ------------------------
+// Section: Cache and Artifact Poisoning
+// ------------------------
+
+// privilegedFromForkTriggers are the events whose runs have the base repository's secrets and token while
+// handling data a pull request from a fork can control: pull_request_target shares the cache of the base
+// branch, and workflow_run downloads the artifacts of the run that triggered it, which may be a fork's
+// pull_request run.
+var privilegedFromForkTriggers = []string{"pull_request_target", "workflow_run"}
+
+// cacheRestoreActions are the actions that restore a cache.
+var cacheRestoreActions = []string{"actions/cache", "actions/cache/restore"}
+
+// cachingSetupActions are setup actions that restore a dependency cache when their 'cache' input is set.
+var cachingSetupActions = []string{"actions/setup-node", "actions/setup-python", "actions/setup-java", "actions/setup-go", "actions/setup-dotnet"}
+
+// artifactDownloadRunRe matches run scripts and github-script calls that download artifacts through the API or CLI.
+var artifactDownloadRunRe = regexp.MustCompile(`\bgh\s+run\s+download\b|\bdownloadArtifact\b|/actions/artifacts/[^\s]*/zip`)
+
+// poisonedInput is a step that brings a cache or artifact into a job.
+type poisonedInput struct {
+	Rule string // artifact-poisoning or cache-poisoning
+	What string // Description for messages, such as "downloads an artifact"
+	Step int
+	Line int
+}
+
+// scanForPoisoning reports jobs of privileged workflows that restore a cache or download an artifact a fork
+// can control and then execute code.
+func scanForPoisoning(content, repoName, filePath string) []Finding {
+	workflow, err := parseWorkflowDocument(content)
+	if err != nil {
+		return nil
+	}
+	var triggers []string
+	for _, trigger := range workflowTriggers(workflow) {
+		if slices.Contains(privilegedFromForkTriggers, trigger) {
+			triggers = append(triggers, trigger)
+		}
+	}
+	if len(triggers) == 0 {
+		return nil
+	}
+	jobsNode := mappingValue(workflow, "jobs")
+	if jobsNode == nil || jobsNode.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	event := strings.Join(triggers, " and ")
+	var findings []Finding
+	for i := 0; i+1 < len(jobsNode.Content); i += 2 {
+		jobID, jobNode := jobsNode.Content[i].Value, jobsNode.Content[i+1]
+		steps := mappingValue(jobNode, "steps")
+		if steps == nil || steps.Kind != yaml.SequenceNode {
+			continue
+		}
+
+		var inputs []poisonedInput
+		for j, step := range steps.Content {
+			if input, ok := poisonedStepInput(step, triggers); ok {
+				input.Step, input.Line = j+1, step.Line
+				inputs = append(inputs, input)
+				continue
+			}
+			executes := executesCode(step)
+			if executes == "" {
+				continue
+			}
+			// Each input is reported once, at the first step that executes code after it
+			for _, input := range inputs {
+				findings = append(findings, newFinding(input.Rule, repoName, filePath, input.Line,
+					fmt.Sprintf("Job '%s' of a %s workflow %s in step %d and %s in step %d", jobID, event, input.What, input.Step, executes, j+1)))
+			}
+			inputs = nil
+		}
+	}
+	return findings
+}
+
+// poisonedStepInput reports whether a step downloads an artifact or restores a cache that a fork can control
+// under the given triggers.
+func poisonedStepInput(step *yaml.Node, triggers []string) (poisonedInput, bool) {
+	uses := mappingValue(step, "uses")
+	if uses != nil && uses.Kind == yaml.ScalarNode {
+		action, _ := parseUsesString(uses.Value, "")
+		switch {
+		case action == "actions/download-artifact":
+			// Without run-id the action downloads the artifacts of the current run
+			if mappingValue(mappingValue(step, "with"), "run-id") != nil {
+				return poisonedInput{Rule: "artifact-poisoning", What: "downloads an artifact of another run"}, true
+			}
+		case action == "dawidd6/action-download-artifact":
+			return poisonedInput{Rule: "artifact-poisoning", What: "downloads an artifact of another run"}, true
+		case slices.Contains(cacheRestoreActions, action) && slices.Contains(triggers, "pull_request_target"):
+			return poisonedInput{Rule: "cache-poisoning", What: "restores a cache"}, true
+		case slices.Contains(cachingSetupActions, action) && slices.Contains(triggers, "pull_request_target"):
+			cache := mappingValue(mappingValue(step, "with"), "cache")
+			if cache != nil && cache.Kind == yaml.ScalarNode && cache.Value != "" && cache.Value != "false" {
+				return poisonedInput{Rule: "cache-poisoning", What: fmt.Sprintf("restores the %s cache of %s", cache.Value, action)}, true
+			}
+		case action == githubScriptAction:
+			script := mappingValue(mappingValue(step, "with"), "script")
+			if script != nil && script.Kind == yaml.ScalarNode && artifactDownloadRunRe.MatchString(script.Value) {
+				return poisonedInput{Rule: "artifact-poisoning", What: "downloads an artifact of another run"}, true
+			}
+		}
+		return poisonedInput{}, false
+	}
+	if run := mappingValue(step, "run"); run != nil && run.Kind == yaml.ScalarNode && artifactDownloadRunRe.MatchString(run.Value) {
+		return poisonedInput{Rule: "artifact-poisoning", What: "downloads an artifact of another run"}, true
+	}
+	return poisonedInput{}, false
+}
+
+// executesCode describes how a step executes code from the workspace, or returns "" if it does not:
+// run steps execute scripts and the tools a restored cache or downloaded artifact can replace, and local
+// actions run from the workspace.
+func executesCode(step *yaml.Node) string {
+	if run := mappingValue(step, "run"); run != nil {
+		return "runs a script"
+	}
+	if uses := mappingValue(step, "uses"); uses != nil && uses.Kind == yaml.ScalarNode && strings.HasPrefix(uses.Value, "./") {
+		return fmt.Sprintf("runs the local action %s", uses.Value)
+	}
+	return ""
+}
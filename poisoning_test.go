@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWorkflowTriggers(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"on: push\n":                        "push",
+		"on: [push, pull_request_target]\n": "pull_request_target,push",
+		"on:\n  workflow_run:\n    workflows: [CI]\n    types: [completed]\n  workflow_dispatch:\n": "workflow_dispatch,workflow_run",
+		"jobs: {}\n": "",
+	}
+	for content, want := range cases {
+		workflow, err := parseWorkflowDocument(content)
+		if err != nil {
+			t.Fatalf("parseWorkflowDocument returned error: %v", err)
+		}
+		if got := strings.Join(workflowTriggers(workflow), ","); got != want {
+			t.Fatalf("workflowTriggers(%q) = %q, want %q", content, got, want)
+		}
+	}
+}
+
+func TestScanForPoisoning(t *testing.T) {
+	t.Parallel()
+
+	workflow := `on:
+  workflow_run:
+    workflows: [CI]
+    types: [completed]
+  pull_request_target:
+jobs:
+  report:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/download-artifact@v4
+        with:
+          run-id: ${{ github.event.workflow_run.id }}
+          github-token: ${{ github.token }}
+      - uses: actions/setup-node@v4
+        with:
+          cache: npm
+      - uses: actions/upload-artifact@v4
+      - run: ./coverage/report.sh
+  own-artifacts:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/download-artifact@v4
+      - run: ls
+  cli:
+    runs-on: ubuntu-latest
+    steps:
+      - run: gh run download ${{ github.event.workflow_run.id }}
+      - uses: actions/cache/restore@v4
+        with:
+          path: ~/.cache
+          key: deps
+      - uses: ./.github/actions/publish
+      - run: ./bin/tool
+  data-only:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: dawidd6/action-download-artifact@v6
+      - uses: actions/github-script@v7
+        with:
+          script: core.info('done')
+`
+	findings := scanForPoisoning(workflow, "repo-a", ".github/workflows/report.yml")
+	var got []string
+	for _, finding := range findings {
+		got = append(got, fmt.Sprintf("%s %d %s", finding.Rule, finding.Line, finding.Message))
+	}
+	want := []string{
+		"artifact-poisoning 10 Job 'report' of a pull_request_target and workflow_run workflow downloads an artifact of another run in step 1 and runs a script in step 4",
+		"cache-poisoning 14 Job 'report' of a pull_request_target and workflow_run workflow restores the npm cache of actions/setup-node in step 2 and runs a script in step 4",
+		"artifact-poisoning 27 Job 'cli' of a pull_request_target and workflow_run workflow downloads an artifact of another run in step 1 and runs the local action ./.github/actions/publish in step 3",
+		"cache-poisoning 28 Job 'cli' of a pull_request_target and workflow_run workflow restores a cache in step 2 and runs the local action ./.github/actions/publish in step 3",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected findings:\n%s", strings.Join(got, "\n"))
+	}
+
+	// Caches are only reported for pull_request_target
+	workflowRun := strings.Replace(workflow, "  pull_request_target:\n", "", 1)
+	findings = scanForPoisoning(workflowRun, "repo-a", ".github/workflows/report.yml")
+	if len(findings) != 2 || findings[0].Rule != "artifact-poisoning" || findings[1].Rule != "artifact-poisoning" {
+		t.Fatalf("expected only artifact findings for a workflow_run workflow, got %+v", findings)
+	}
+
+	// Workflows without a privileged trigger are not checked
+	if findings := scanForPoisoning(strings.Replace(workflowRun, "workflow_run:", "push:", 1), "repo-a", ".github/workflows/report.yml"); len(findings) != 0 {
+		t.Fatalf("expected no findings for a push workflow, got %+v", findings)
+	}
+}
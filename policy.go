@@ -0,0 +1,365 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Policy Evaluation
+// ------------------------
+
+// Policy is a proposed set of the policy files of a database, evaluated by 'policy eval'. Each section has
+// the format of the file it stands for and, when present, replaces it; absent sections keep the database's.
+type Policy struct {
+	Analyzers  *string            `yaml:"analyzers"`  // Analyzer selection, with the syntax of -analyzers
+	Budgets    *WorkflowBudgets   `yaml:"budgets"`    // budgets.yaml
+	Freeze     *ActionFreeze      `yaml:"freeze"`     // freeze.yaml
+	Denylist   *Denylist          `yaml:"denylist"`   // denylist.yaml, added to the built-in denylist
+	EnvNaming  *EnvNaming         `yaml:"env_naming"` // env-naming.yaml
+	Severities *SeverityOverrides `yaml:"severities"` // severities.yaml
+}
+
+// policyGlobals holds the policy in effect for the analyzers, so that it can be swapped and restored.
+type policyGlobals struct {
+	analyzers  map[string]bool
+	budgets    *WorkflowBudgets
+	freeze     *ActionFreeze
+	denylisted []DenylistEntry
+	envNaming  *EnvNaming
+	severities *SeverityOverrides
+}
+
+// currentPolicyGlobals returns the policy the analyzers currently use.
+func currentPolicyGlobals() policyGlobals {
+	return policyGlobals{analyzers: enabledAnalyzers, budgets: workflowBudgets, freeze: actionFreeze, denylisted: compromisedActions, envNaming: envNaming, severities: severityOverrides}
+}
+
+// restore makes the analyzers use this policy again.
+func (g policyGlobals) restore() {
+	enabledAnalyzers, workflowBudgets, actionFreeze, compromisedActions, envNaming, severityOverrides = g.analyzers, g.budgets, g.freeze, g.denylisted, g.envNaming, g.severities
+}
+
+// PolicyDelta lists the findings a proposed policy would open and resolve compared with the database's policy.
+type PolicyDelta struct {
+	Organization string                `json:"organization"`
+	Current      int                   `json:"current"`  // Findings under the database's policy
+	Proposed     int                   `json:"proposed"` // Findings under the proposed policy
+	Opened       []Finding             `json:"opened"`
+	Resolved     []Finding             `json:"resolved"`
+	Reclassified []ReclassifiedFinding `json:"reclassified"` // Findings open under both policies with a different severity
+}
+
+// ReclassifiedFinding is a finding, as the proposed policy reports it, whose severity the proposed policy changes.
+type ReclassifiedFinding struct {
+	Finding
+	PreviousSeverity string `json:"previous_severity"`
+}
+
+// loadPolicy reads and validates a policy file.
+func loadPolicy(policyPath string) (*Policy, error) {
+	data, err := os.ReadFile(policyPath)
+	if err != nil {
+		return nil, err
+	}
+	var policy Policy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %v", err)
+	}
+	if policy.Analyzers != nil {
+		if _, err := parseAnalyzerSelection(*policy.Analyzers); err != nil {
+			return nil, err
+		}
+	}
+	if policy.Budgets != nil {
+		if err := policy.Budgets.validate(); err != nil {
+			return nil, err
+		}
+	}
+	if policy.Freeze != nil {
+		if err := policy.Freeze.validate(); err != nil {
+			return nil, fmt.Errorf("invalid freeze %v", err)
+		}
+	}
+	if policy.EnvNaming != nil {
+		if err := policy.EnvNaming.validate(); err != nil {
+			return nil, fmt.Errorf("invalid env_naming: %v", err)
+		}
+	}
+	if policy.Severities != nil {
+		if err := policy.Severities.validate(); err != nil {
+			return nil, fmt.Errorf("invalid severities: %v", err)
+		}
+	}
+	return &policy, nil
+}
+
+// apply makes the analyzers use the sections of the policy that are present.
+func (p *Policy) apply() {
+	if p.Analyzers != nil {
+		enabledAnalyzers, _ = parseAnalyzerSelection(*p.Analyzers)
+	}
+	if p.Budgets != nil {
+		workflowBudgets = p.Budgets
+	}
+	if p.Freeze != nil {
+		actionFreeze = p.Freeze
+	}
+	if p.EnvNaming != nil {
+		envNaming = p.EnvNaming
+	}
+	if p.Severities != nil {
+		severityOverrides = p.Severities
+	}
+	if p.Denylist != nil {
+		compromisedActions = append(mustParseDenylist(builtinDenylistData), p.Denylist.Actions...)
+	}
+}
+
+// evaluateStoredFindings runs the enabled analyzers over the stored workflows and repository settings
+// of the listed repositories, as a scan would. Ruleset findings need the API and are not included.
+func evaluateStoredFindings(dbPath, org string) ([]Finding, error) {
+	manifest, err := loadRepositoryManifest(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load repositories manifest: %v", err)
+	}
+	listed := make(map[string]bool, len(manifest.Repositories))
+	for _, repoName := range manifest.Repositories {
+		listed[repoName] = true
+	}
+	tokenDefaults, err := loadTokenDefaultsIndex(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	environments, err := loadEnvironmentIndex(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	err = walkIndexedWorkflows(dbPath, func(fileName, repoName, content string) {
+		if !listed[repoName] {
+			return
+		}
+		filePath := ".github/workflows/" + fileName
+		findings = append(findings, analyzeWorkflow(content, repoName, filePath)...)
+		if analyzerEnabled("permissions") {
+			findings = append(findings, analyzeTokenDefaults(repoName, filePath, content, tokenDefaults.tokenDefaultsFor(repoName))...)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, repoName := range manifest.Repositories {
+		if analyzerEnabled("permissions") {
+			findings = append(findings, analyzePullRequestApproval(org, repoName, tokenDefaults.tokenDefaultsFor(repoName))...)
+		}
+		if analyzerEnabled("environments") {
+			findings = append(findings, analyzeEnvironments(org, repoName, environments.Repositories[repoName])...)
+		}
+	}
+	return findings, nil
+}
+
+// evaluatePolicy compares the findings of the stored content under the database's policy files with
+// those under a proposed policy. The analyzers' policy is restored afterwards.
+func evaluatePolicy(dbPath string, policy *Policy) (*PolicyDelta, error) {
+	manifest, err := loadRepositoryManifest(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load repositories manifest: %v", err)
+	}
+	org := manifest.Organization
+
+	defer currentPolicyGlobals().restore()
+	if err := loadDenylist(dbPath); err != nil {
+		return nil, fmt.Errorf("failed to load denylist: %v", err)
+	}
+	if err := loadBudgets(dbPath); err != nil {
+		return nil, fmt.Errorf("failed to load budgets: %v", err)
+	}
+	if err := loadEnvNaming(dbPath); err != nil {
+		return nil, fmt.Errorf("failed to load env naming convention: %v", err)
+	}
+	if err := loadSeverityOverrides(dbPath); err != nil {
+		return nil, fmt.Errorf("failed to load severity overrides: %v", err)
+	}
+	if err := loadFreeze(dbPath); err != nil {
+		return nil, fmt.Errorf("failed to load freeze: %v", err)
+	}
+	current, err := evaluateStoredFindings(dbPath, org)
+	if err != nil {
+		return nil, err
+	}
+
+	policy.apply()
+	proposed, err := evaluateStoredFindings(dbPath, org)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PolicyDelta{
+		Organization: org,
+		Current:      len(current),
+		Proposed:     len(proposed),
+		Opened:       findingsMissingFrom(proposed, current),
+		Resolved:     findingsMissingFrom(current, proposed),
+		Reclassified: findingsReclassified(proposed, current),
+	}, nil
+}
+
+// findingsMissingFrom returns the findings whose fingerprint is not among the other findings, sorted by
+// repository, file, line, and rule.
+func findingsMissingFrom(findings, other []Finding) []Finding {
+	known := make(map[string]bool, len(other))
+	for _, finding := range other {
+		known[finding.Fingerprint] = true
+	}
+	missing := []Finding{}
+	for _, finding := range findings {
+		if !known[finding.Fingerprint] {
+			missing = append(missing, finding)
+		}
+	}
+	sortFindingsByLocation(missing)
+	return missing
+}
+
+// findingsReclassified returns the findings that are also among the other findings with a different
+// severity, sorted by repository, file, line, and rule.
+func findingsReclassified(findings, other []Finding) []ReclassifiedFinding {
+	previous := make(map[string]string, len(other))
+	for _, finding := range other {
+		previous[finding.Fingerprint] = finding.Severity
+	}
+	var changed []Finding
+	for _, finding := range findings {
+		if severity, ok := previous[finding.Fingerprint]; ok && severity != finding.Severity {
+			changed = append(changed, finding)
+		}
+	}
+	sortFindingsByLocation(changed)
+	reclassified := []ReclassifiedFinding{}
+	for _, finding := range changed {
+		reclassified = append(reclassified, ReclassifiedFinding{Finding: finding, PreviousSeverity: previous[finding.Fingerprint]})
+	}
+	return reclassified
+}
+
+// sortFindingsByLocation sorts findings by repository, file, line, rule, and message.
+func sortFindingsByLocation(findings []Finding) {
+	sort.Slice(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.RepoName != b.RepoName {
+			return a.RepoName < b.RepoName
+		}
+		if a.FilePath != b.FilePath {
+			return a.FilePath < b.FilePath
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Rule != b.Rule {
+			return a.Rule < b.Rule
+		}
+		return a.Message < b.Message
+	})
+}
+
+// formatPolicyDelta describes the findings a proposed policy would open and resolve.
+func formatPolicyDelta(delta *PolicyDelta) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("%d findings with the proposed policy, %d with the database's policy\n", delta.Proposed, delta.Current))
+	for _, section := range []struct {
+		title    string
+		findings []Finding
+	}{{"Would open", delta.Opened}, {"Would resolve", delta.Resolved}} {
+		builder.WriteString(fmt.Sprintf("\n%s %d findings:\n", section.title, len(section.findings)))
+		for _, finding := range section.findings {
+			builder.WriteString(fmt.Sprintf("  [%s] %s %s %s:%d: %s\n", finding.Severity, finding.Rule, finding.RepoName, finding.FilePath, finding.Line, finding.Message))
+		}
+	}
+	if len(delta.Reclassified) > 0 {
+		builder.WriteString(fmt.Sprintf("\nWould change the severity of %d findings:\n", len(delta.Reclassified)))
+		for _, finding := range delta.Reclassified {
+			builder.WriteString(fmt.Sprintf("  [%s, was %s] %s %s %s:%d: %s\n", finding.Severity, finding.PreviousSeverity, finding.Rule, finding.RepoName, finding.FilePath, finding.Line, finding.Message))
+		}
+	}
+	return builder.String()
+}
+
+// runPolicyCommand runs the policy subcommands.
+func runPolicyCommand(args []string) int {
+	if len(args) == 0 || args[0] != "eval" {
+		printPolicyUsage()
+		return 1
+	}
+
+	fs := flag.NewFlagSet("policy eval", flag.ContinueOnError)
+	policyDB := fs.String("db", "./db", "Path to the database repository, or a git URL to clone")
+	policyToken := fs.String("token", "", "GitHub API token used to clone an HTTPS database URL")
+	policyPath := fs.String("policy", "", "Policy file with the proposed analyzers, budgets, freeze, denylist, or severities (required)")
+	format := fs.String("format", formatText, "Output format: text or json")
+	logFlags := addLogFlags(fs)
+	if err := fs.Parse(args[1:]); err != nil {
+		return 1
+	}
+	if err := applyEnvironmentDefaults(fs); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := logFlags.apply(); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if *policyPath == "" {
+		printPolicyUsage()
+		fs.PrintDefaults()
+		return 1
+	}
+	if err := checkFormat(*format, formatText, formatJSON); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if *format == formatJSON {
+		useJSONOutput()
+	}
+
+	policy, err := loadPolicy(*policyPath)
+	if err != nil {
+		logErrorf("Failed to load policy: %v", err)
+		return 1
+	}
+	checkout, err := openDB(*policyDB, *policyToken)
+	if err != nil {
+		logErrorf("Failed to open database: %v", err)
+		return 1
+	}
+	defer checkout.Close()
+
+	delta, err := evaluatePolicy(checkout.Dir, policy)
+	if err != nil {
+		logErrorf("Policy evaluation failed: %v", err)
+		return 1
+	}
+	if *format == formatJSON {
+		content, err := formatJSONDocument(delta)
+		if err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+		fmt.Fprint(resultWriter, content)
+		return 0
+	}
+	fmt.Fprint(resultWriter, formatPolicyDelta(delta))
+	return 0
+}
+
+// printPolicyUsage prints the usage for the policy command.
+func printPolicyUsage() {
+	fmt.Println("Usage: dotgithubindexer policy eval -policy <file> [-db <path or git URL>] [-format text|json]")
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEvaluatePolicy(t *testing.T) {
+	dbPath := t.TempDir()
+	files := map[string]string{
+		"repositories.yaml": "organization: example-org\nrepositories:\n    - repo-a\n",
+		"budgets.yaml":      "max_jobs_per_workflow: 1\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dbPath, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	content := "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - uses: actions/checkout@v3\n  test:\n    runs-on: ubuntu-latest\n    steps:\n      - run: make test\n"
+	hash := computeHash([]byte(content))
+	if err := updateActionIndex(dbPath, "ci.yml", "repo-a", "ci.yml", hash, ""); err != nil {
+		t.Fatalf("updateActionIndex returned error: %v", err)
+	}
+	if err := storeActionVersion(dbPath, "ci.yml", hash, content); err != nil {
+		t.Fatalf("storeActionVersion returned error: %v", err)
+	}
+
+	policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+	policyContent := "budgets:\n  max_jobs_per_workflow: 5\nfreeze:\n  actions:\n    - action: actions/checkout\n      versions: [v4]\nseverities:\n  rules:\n    outdated-action-runtime: critical\n"
+	if err := os.WriteFile(policyPath, []byte(policyContent), 0644); err != nil {
+		t.Fatalf("failed to write policy: %v", err)
+	}
+	policy, err := loadPolicy(policyPath)
+	if err != nil {
+		t.Fatalf("loadPolicy returned error: %v", err)
+	}
+
+	delta, err := evaluatePolicy(dbPath, policy)
+	if err != nil {
+		t.Fatalf("evaluatePolicy returned error: %v", err)
+	}
+	if workflowBudgets != nil || actionFreeze != nil || severityOverrides != nil {
+		t.Fatalf("expected the analyzers' policy to be restored, got budgets %+v, freeze %+v, and severities %+v", workflowBudgets, actionFreeze, severityOverrides)
+	}
+	if len(delta.Opened) != 1 || delta.Opened[0].Rule != "unfrozen-action-version" || delta.Opened[0].FilePath != ".github/workflows/ci.yml" {
+		t.Fatalf("unexpected opened findings: %+v", delta.Opened)
+	}
+	if len(delta.Resolved) != 1 || delta.Resolved[0].Rule != "job-count-budget" {
+		t.Fatalf("unexpected resolved findings: %+v", delta.Resolved)
+	}
+	if len(delta.Reclassified) != 1 || delta.Reclassified[0].Rule != "outdated-action-runtime" || delta.Reclassified[0].Severity != SeverityCritical || delta.Reclassified[0].PreviousSeverity != SeverityMedium {
+		t.Fatalf("unexpected reclassified findings: %+v", delta.Reclassified)
+	}
+	if delta.Current-len(delta.Resolved)+len(delta.Opened) != delta.Proposed {
+		t.Fatalf("inconsistent counts: %+v", delta)
+	}
+
+	text := formatPolicyDelta(delta)
+	if !strings.Contains(text, "Would open 1 findings:\n  [") || !strings.Contains(text, "Would resolve 1 findings:\n  [") || !strings.Contains(text, "Would change the severity of 1 findings:\n  [critical, was medium] outdated-action-runtime") {
+		t.Fatalf("unexpected text output:\n%s", text)
+	}
+}
+
+func TestLoadPolicyRejectsInvalidSections(t *testing.T) {
+	t.Parallel()
+
+	for _, content := range []string{
+		"analyzers: unknown-analyzer\n",
+		"budgets:\n  max_steps_per_job: -1\n",
+		"freeze:\n  actions:\n    - action: actions/checkout\n",
+		"budgets: [\n",
+		"severities:\n  rules:\n    deprecated-action: urgent\n",
+	} {
+		policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+		if err := os.WriteFile(policyPath, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write policy: %v", err)
+		}
+		if _, err := loadPolicy(policyPath); err == nil {
+			t.Fatalf("expected an error for policy %q", content)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v50/github"
+)
+
+// ------------------------
+// Section: Pull Request Preview
+// ------------------------
+
+// PreviewReport describes how merging a change would affect the findings and score of a repository.
+type PreviewReport struct {
+	RepoName         string    `json:"repository"`
+	Ref              string    `json:"ref"`
+	ChangedFiles     []string  `json:"changed_files"`
+	NewFindings      []Finding `json:"new_findings"`
+	ResolvedFindings []Finding `json:"resolved_findings"`
+	ScoreBefore      int       `json:"score_before"`
+	ScoreAfter       int       `json:"score_after"`
+}
+
+// runPreviewCommand runs the preview subcommand and returns the process exit code.
+func runPreviewCommand(args []string) int {
+	fs := flag.NewFlagSet("preview", flag.ContinueOnError)
+	previewOrg := fs.String("org", "", "GitHub Organization name (required)")
+	previewToken := fs.String("token", "", "GitHub API token (required); defaults to the DOTGITHUBINDEXER_TOKEN or GITHUB_TOKEN environment variable")
+	baseURL := fs.String("base-url", "", "GitHub Enterprise Server API URL, e.g. https://github.example.com/api/v3; defaults to github.com")
+	uploadURL := fs.String("upload-url", "", "GitHub Enterprise Server upload URL; defaults to -base-url")
+	previewRepo := fs.String("repo", "", "Repository name (required)")
+	previewPR := fs.Int("pr", 0, "Pull request number to preview")
+	previewRef := fs.String("ref", "", "Branch or commit to preview when no pull request is given")
+	previewDB := fs.String("db", "./db", "Path to the database repository, or a git URL to clone")
+	previewAnalyzers := fs.String("analyzers", "", "Comma-separated analyzers to run, or to skip when prefixed with '-'; see 'analyzers list'")
+	format := fs.String("format", formatMarkdown, "Output format: markdown or json")
+	logFlags := addLogFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if err := applyEnvironmentDefaults(fs); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := logFlags.apply(); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+
+	if *previewOrg == "" || *previewToken == "" || *previewRepo == "" || (*previewPR == 0 && *previewRef == "") {
+		fmt.Println("Usage: dotgithubindexer preview -org <organization> -token <token> -repo <repository> (-pr <number> | -ref <branch>) [options]")
+		fs.PrintDefaults()
+		return 1
+	}
+
+	if err := checkFormat(*format, formatMarkdown, formatJSON); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := setGitHubServer(*baseURL, *uploadURL); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if *format == formatJSON {
+		useJSONOutput()
+	}
+
+	if err := setAnalyzerSelection(*previewAnalyzers); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+
+	checkout, err := openDB(*previewDB, *previewToken)
+	if err != nil {
+		logErrorf("Failed to open database: %v", err)
+		return 1
+	}
+	defer checkout.Close()
+
+	client := getGitHubClient(*previewToken)
+	report, err := previewWorkflowChanges(client, checkout.Dir, *previewOrg, *previewRepo, *previewPR, *previewRef)
+	if err != nil {
+		logErrorf("Preview failed: %v", err)
+		return 1
+	}
+
+	content := formatPreviewMarkdown(report)
+	if *format == formatJSON {
+		content, err = formatPreviewJSON(report)
+		if err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+	}
+	fmt.Fprint(resultWriter, content)
+	return 0
+}
+
+// isWorkflowPath reports whether a repository path is a GitHub Actions workflow file.
+func isWorkflowPath(filePath string) bool {
+	ext := path.Ext(filePath)
+	return path.Dir(filePath) == ".github/workflows" && (ext == ".yml" || ext == ".yaml")
+}
+
+// previewWorkflowChanges fetches the workflow files changed by a pull request or ref and
+// compares their findings and compliance score against the state indexed in the database.
+func previewWorkflowChanges(client *github.Client, dbPath, owner, repoName string, prNumber int, ref string) (*PreviewReport, error) {
+	ctx := context.Background()
+	var changedFiles []*github.CommitFile
+
+	if prNumber != 0 {
+		pr, _, err := client.PullRequests.Get(ctx, owner, repoName, prNumber)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch pull request #%d: %v", prNumber, err)
+		}
+		ref = pr.GetHead().GetSHA()
+
+		opt := &github.ListOptions{PerPage: 100}
+		for {
+			files, resp, err := client.PullRequests.ListFiles(ctx, owner, repoName, prNumber, opt)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list pull request files: %v", err)
+			}
+			changedFiles = append(changedFiles, files...)
+			if resp.NextPage == 0 {
+				break
+			}
+			opt.Page = resp.NextPage
+		}
+	} else {
+		repo, _, err := client.Repositories.Get(ctx, owner, repoName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch repository: %v", err)
+		}
+		comparison, _, err := client.Repositories.CompareCommits(ctx, owner, repoName, getDefaultBranch(repo), ref, &github.ListOptions{PerPage: 100})
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare '%s' with the default branch: %v", ref, err)
+		}
+		changedFiles = comparison.Files
+	}
+
+	base, err := loadRepoWorkflows(dbPath, repoName)
+	if err != nil {
+		return nil, err
+	}
+	head := make(map[string]string, len(base))
+	for name, content := range base {
+		head[name] = content
+	}
+
+	report := &PreviewReport{RepoName: repoName, Ref: ref}
+	for _, file := range changedFiles {
+		filePath := file.GetFilename()
+		if !isWorkflowPath(filePath) {
+			continue
+		}
+		report.ChangedFiles = append(report.ChangedFiles, filePath)
+
+		name := path.Base(filePath)
+		if file.GetStatus() == "removed" {
+			delete(head, name)
+			continue
+		}
+		if file.GetStatus() == "renamed" {
+			delete(head, path.Base(file.GetPreviousFilename()))
+		}
+
+		content, err := fetchBlobContent(client, owner, repoName, file.GetSHA())
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch '%s' at '%s': %v", filePath, ref, err)
+		}
+		head[name] = content
+	}
+	sort.Strings(report.ChangedFiles)
+
+	if err := loadDenylist(dbPath); err != nil {
+		return nil, err
+	}
+	if err := loadBudgets(dbPath); err != nil {
+		return nil, err
+	}
+	if err := loadEnvNaming(dbPath); err != nil {
+		return nil, err
+	}
+	if err := loadSeverityOverrides(dbPath); err != nil {
+		return nil, err
+	}
+	if err := loadFreeze(dbPath); err != nil {
+		return nil, err
+	}
+
+	config, err := loadScorecardConfig(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	baseFindings, baseScore := evaluateWorkflowSet(base, repoName, config)
+	headFindings, headScore := evaluateWorkflowSet(head, repoName, config)
+	report.ScoreBefore = baseScore
+	report.ScoreAfter = headScore
+	report.NewFindings = diffFindings(headFindings, baseFindings)
+	report.ResolvedFindings = diffFindings(baseFindings, headFindings)
+
+	return report, nil
+}
+
+// loadRepoWorkflows returns the indexed workflow contents of a repository keyed by workflow file name.
+func loadRepoWorkflows(dbPath, repoName string) (map[string]string, error) {
+	workflows := make(map[string]string)
+	err := walkIndexedWorkflows(dbPath, func(fileName, indexedRepo, content string) {
+		if indexedRepo == repoName {
+			workflows[fileName] = content
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return workflows, nil
+}
+
+// evaluateWorkflowSet runs the analyzers and compliance scoring over a set of workflow files.
+func evaluateWorkflowSet(workflows map[string]string, repoName string, config *ScorecardConfig) ([]Finding, int) {
+	var findings []Finding
+	signals := RepoSignals{RequiredWorkflows: len(config.RequiredWorkflows)}
+
+	names := make([]string, 0, len(workflows))
+	for name := range workflows {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		filePath := ".github/workflows/" + name
+		findings = append(findings, analyzeWorkflow(workflows[name], repoName, filePath)...)
+		collectWorkflowSignals(&signals, workflows[name], repoName, filePath)
+		if slices.ContainsFunc(config.RequiredWorkflows, func(required string) bool {
+			return strings.EqualFold(workflowIdentity(required), workflowIdentity(name))
+		}) {
+			signals.PresentRequired++
+		}
+	}
+
+	return findings, computeScore(signals)
+}
+
+// diffFindings returns the findings in a that have no equivalent in b, ignoring line numbers.
+func diffFindings(a, b []Finding) []Finding {
+	counts := make(map[string]int)
+	for _, f := range b {
+		counts[f.Fingerprint]++
+	}
+
+	var diff []Finding
+	for _, f := range a {
+		if counts[f.Fingerprint] > 0 {
+			counts[f.Fingerprint]--
+			continue
+		}
+		diff = append(diff, f)
+	}
+	return diff
+}
+
+// formatPreviewJSON renders a preview report as JSON. Empty lists are written as [] rather than null.
+func formatPreviewJSON(report *PreviewReport) (string, error) {
+	document := *report
+	document.ChangedFiles = emptyIfNil(document.ChangedFiles)
+	document.NewFindings = emptyIfNil(document.NewFindings)
+	document.ResolvedFindings = emptyIfNil(document.ResolvedFindings)
+	return formatJSONDocument(document)
+}
+
+// formatPreviewMarkdown renders a preview report as markdown suitable for a pull request comment.
+func formatPreviewMarkdown(report *PreviewReport) string {
+	var markdownBuilder strings.Builder
+	markdownBuilder.WriteString(fmt.Sprintf("# Workflow Preview for %s @ %s\n\n", report.RepoName, shortHash(report.Ref)))
+
+	if len(report.ChangedFiles) == 0 {
+		markdownBuilder.WriteString("No workflow files are changed.\n")
+		return markdownBuilder.String()
+	}
+
+	markdownBuilder.WriteString("**Changed workflow files:**\n")
+	for _, filePath := range report.ChangedFiles {
+		markdownBuilder.WriteString(fmt.Sprintf("- %s\n", filePath))
+	}
+	markdownBuilder.WriteString(fmt.Sprintf("\n**Compliance score:** %d → %d (%+d)\n\n", report.ScoreBefore, report.ScoreAfter, report.ScoreAfter-report.ScoreBefore))
+
+	writeFindings := func(title string, findings []Finding) {
+		markdownBuilder.WriteString(fmt.Sprintf("## %s\n\n", title))
+		if len(findings) == 0 {
+			markdownBuilder.WriteString("*None*\n\n")
+			return
+		}
+		markdownBuilder.WriteString("| Severity | Rule | File | Message |\n")
+		markdownBuilder.WriteString("|----------|------|------|---------|\n")
+		for _, finding := range findings {
+			markdownBuilder.WriteString(fmt.Sprintf("| %s | %s | %s:%d | %s |\n", finding.Severity, finding.Rule, finding.FilePath, finding.Line, finding.Message))
+		}
+		markdownBuilder.WriteString("\n")
+	}
+	writeFindings("New Findings", report.NewFindings)
+	writeFindings("Resolved Findings", report.ResolvedFindings)
+
+	return markdownBuilder.String()
+}
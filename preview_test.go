@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestIsWorkflowPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: ".github/workflows/build.yml", want: true},
+		{path: ".github/workflows/release.yaml", want: true},
+		{path: ".github/workflows/nested/build.yml", want: false},
+		{path: ".github/dependabot.yml", want: false},
+		{path: "README.md", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := isWorkflowPath(tt.path); got != tt.want {
+			t.Fatalf("isWorkflowPath(%q) = %t, want %t", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluateWorkflowSetPreviewDiff(t *testing.T) {
+	t.Parallel()
+
+	config := &ScorecardConfig{}
+	base := map[string]string{
+		"build.yml": "permissions: {}\njobs:\n  build:\n    timeout-minutes: 5\n    steps:\n      - run: echo hi\n",
+	}
+	head := map[string]string{
+		"build.yml": "jobs:\n  build:\n    steps:\n      - run: echo hi\n    env:\n      API_KEY: s3cr3tvalue123\n",
+	}
+
+	baseFindings, baseScore := evaluateWorkflowSet(base, "repo-a", config)
+	headFindings, headScore := evaluateWorkflowSet(head, "repo-a", config)
+	if baseScore != 100 || headScore != 65 {
+		t.Fatalf("unexpected scores: base=%d head=%d", baseScore, headScore)
+	}
+
+	report := &PreviewReport{
+		RepoName:         "repo-a",
+		Ref:              "feature",
+		ChangedFiles:     []string{".github/workflows/build.yml"},
+		NewFindings:      diffFindings(headFindings, baseFindings),
+		ResolvedFindings: diffFindings(baseFindings, headFindings),
+		ScoreBefore:      baseScore,
+		ScoreAfter:       headScore,
+	}
+	if len(report.NewFindings) != 1 || len(report.ResolvedFindings) != 0 {
+		t.Fatalf("unexpected finding diff: new=%+v resolved=%+v", report.NewFindings, report.ResolvedFindings)
+	}
+
+	content := formatPreviewMarkdown(report)
+	if !strings.Contains(content, "**Compliance score:** 100 → 65 (-35)") || !strings.Contains(content, "| critical | hardcoded-secret |") {
+		t.Fatalf("unexpected preview markdown:\n%s", content)
+	}
+
+	document, err := formatPreviewJSON(report)
+	if err != nil {
+		t.Fatalf("formatPreviewJSON returned error: %v", err)
+	}
+	var decoded struct {
+		Repository       string           `json:"repository"`
+		ScoreAfter       int              `json:"score_after"`
+		NewFindings      []map[string]any `json:"new_findings"`
+		ResolvedFindings []map[string]any `json:"resolved_findings"`
+	}
+	if err := json.Unmarshal([]byte(document), &decoded); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	if decoded.Repository != "repo-a" || decoded.ScoreAfter != 65 || len(decoded.NewFindings) != 1 || decoded.NewFindings[0]["rule"] != "hardcoded-secret" {
+		t.Fatalf("unexpected preview JSON:\n%s", document)
+	}
+	if !strings.Contains(document, `"resolved_findings": []`) {
+		t.Fatalf("expected empty lists to be encoded as []:\n%s", document)
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+)
+
+// ------------------------
+// Section: Scan Progress
+// ------------------------
+
+// progressRedrawInterval is the least time between redraws of the progress line, so that fast repositories
+// do not flood the terminal.
+const progressRedrawInterval = 200 * time.Millisecond
+
+// progressBarWidth is the number of characters of the progress bar, between its brackets.
+const progressBarWidth = 20
+
+// activeProgress is the progress line drawn on the terminal, above which log messages are written; nil when
+// no line is drawn.
+var activeProgress atomic.Pointer[ScanProgress]
+
+// ScanProgress reports how far a scan is: the repositories done out of those listed, the repository being
+// scanned, the estimated time left, and the remaining rate limit. On a terminal it redraws a progress line;
+// otherwise it logs a summary every interval. Its methods do nothing on a nil ScanProgress.
+type ScanProgress struct {
+	mu         sync.Mutex
+	terminal   io.Writer // Terminal the progress line is drawn on; nil logs summaries instead
+	interval   time.Duration
+	now        func() time.Time
+	log        func(format string, args ...any)
+	started    time.Time
+	total      int
+	done       int
+	current    string
+	remaining  int // Remaining core rate limit; -1 until it is known
+	lastReport time.Time
+	drawn      bool
+}
+
+// newScanProgress creates the progress of a scan drawn on terminal, or logged every interval when terminal
+// is nil. A zero interval without a terminal reports nothing and returns nil.
+func newScanProgress(terminal io.Writer, interval time.Duration) *ScanProgress {
+	if terminal == nil && interval <= 0 {
+		return nil
+	}
+	progress := &ScanProgress{terminal: terminal, interval: interval, now: time.Now, log: logInfof, remaining: -1}
+	if terminal != nil {
+		activeProgress.Store(progress)
+	}
+	return progress
+}
+
+// progressTerminal returns standard error when it is a terminal and info messages are logged, and nil
+// otherwise, in which case progress is logged as periodic summaries. The line is drawn on standard error
+// as it was before the redaction filter replaced it, since the filter only forwards whole lines.
+func progressTerminal() io.Writer {
+	if logLevel.Level() > slog.LevelInfo || os.Getenv("TERM") == "dumb" {
+		return nil
+	}
+	stderr := unredactedFile(os.Stderr)
+	info, err := stderr.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return stderr
+}
+
+// Begin adds the repositories listed for an organization to the total, starting the clock at the first.
+func (p *ScanProgress) Begin(repositories int) {
+	p.update(func() {
+		if p.started.IsZero() {
+			p.started = p.now()
+			p.lastReport = p.started
+		}
+		p.total += repositories
+	})
+}
+
+// Start records the repository being scanned, including one being retried.
+func (p *ScanProgress) Start(repo string) {
+	p.update(func() {
+		p.current = repo
+	})
+}
+
+// Finished counts a repository as done after its first attempt, recording the rate limit checked after it.
+func (p *ScanProgress) Finished(rate *github.Rate) {
+	p.update(func() {
+		p.done++
+		p.setRate(rate)
+	})
+}
+
+// RateLimit records the rate limit checked after a retry.
+func (p *ScanProgress) RateLimit(rate *github.Rate) {
+	p.update(func() {
+		p.setRate(rate)
+	})
+}
+
+// Stop clears the progress line, so that the rest of the log is written below the last message.
+func (p *ScanProgress) Stop() {
+	if p == nil {
+		return
+	}
+	activeProgress.CompareAndSwap(p, nil)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+}
+
+func (p *ScanProgress) setRate(rate *github.Rate) {
+	if rate != nil {
+		p.remaining = rate.Remaining
+	}
+}
+
+// update applies a change, then redraws the progress line or logs a summary when one is due.
+func (p *ScanProgress) update(change func()) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	change()
+	now := p.now()
+	if p.terminal != nil {
+		if !p.drawn || now.Sub(p.lastReport) >= progressRedrawInterval || p.done == p.total {
+			p.lastReport = now
+			p.draw()
+		}
+		p.mu.Unlock()
+		return
+	}
+	var summary string
+	if p.started.IsZero() || now.Sub(p.lastReport) < p.interval {
+		p.mu.Unlock()
+		return
+	}
+	p.lastReport = now
+	summary = p.summary(now)
+	p.mu.Unlock()
+	// Logged after unlocking, as the log may write above a progress line
+	p.log("%s", summary)
+}
+
+// summary describes the progress as a log message.
+func (p *ScanProgress) summary(now time.Time) string {
+	parts := []string{fmt.Sprintf("Progress: %d/%d repositories (%d%%)", p.done, p.total, p.percent())}
+	if p.current != "" {
+		parts = append(parts, "current "+p.current)
+	}
+	parts = append(parts, "ETA "+p.eta(now))
+	if p.remaining >= 0 {
+		parts = append(parts, fmt.Sprintf("rate limit %d remaining", p.remaining))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// line renders the progress line drawn on a terminal.
+func (p *ScanProgress) line(now time.Time) string {
+	filled := 0
+	if p.total > 0 {
+		filled = p.done * progressBarWidth / p.total
+	}
+	line := fmt.Sprintf("[%s%s] %d/%d %3d%% ETA %s", strings.Repeat("#", filled), strings.Repeat(".", progressBarWidth-filled), p.done, p.total, p.percent(), p.eta(now))
+	if p.remaining >= 0 {
+		line += fmt.Sprintf(" | rate limit %d", p.remaining)
+	}
+	if p.current != "" {
+		line += " | " + strings.TrimSpace(fitColumn(p.current, 30))
+	}
+	return line
+}
+
+func (p *ScanProgress) percent() int {
+	if p.total == 0 {
+		return 0
+	}
+	return p.done * 100 / p.total
+}
+
+// eta estimates the time left from the average time taken by the repositories done so far.
+func (p *ScanProgress) eta(now time.Time) string {
+	if p.done == 0 || p.started.IsZero() {
+		return "unknown"
+	}
+	if p.done >= p.total {
+		return "0s"
+	}
+	perRepo := now.Sub(p.started) / time.Duration(p.done)
+	return (perRepo * time.Duration(p.total-p.done)).Round(time.Second).String()
+}
+
+// draw redraws the progress line in place; the caller holds p.mu.
+func (p *ScanProgress) draw() {
+	fmt.Fprintf(p.terminal, "\r\033[K%s", p.line(p.now()))
+	p.drawn = true
+}
+
+// clear erases the progress line; the caller holds p.mu.
+func (p *ScanProgress) clear() {
+	if p.drawn {
+		fmt.Fprint(p.terminal, "\r\033[K")
+		p.drawn = false
+	}
+}
+
+// writeAbove writes a log message above the progress line, redrawing the line below it.
+func (p *ScanProgress) writeAbove(w io.Writer, message []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	redraw := p.drawn
+	p.clear()
+	n, err := w.Write(message)
+	if redraw {
+		p.draw()
+	}
+	return n, err
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+)
+
+// newTestProgress creates a progress on a clock the test advances.
+func newTestProgress(terminal *strings.Builder, interval time.Duration) (*ScanProgress, *time.Time, *[]string) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	var logged []string
+	progress := &ScanProgress{interval: interval, now: func() time.Time { return now }, remaining: -1}
+	if terminal != nil {
+		progress.terminal = terminal
+	}
+	progress.log = func(format string, args ...any) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	}
+	return progress, &now, &logged
+}
+
+func TestScanProgressSummaries(t *testing.T) {
+	t.Parallel()
+
+	progress, now, logged := newTestProgress(nil, time.Minute)
+	progress.Begin(4)
+	progress.Start("repo-a")
+	*now = now.Add(30 * time.Second)
+	progress.Finished(&github.Rate{Remaining: 4200})
+	if len(*logged) != 0 {
+		t.Fatalf("expected no summary before the interval, got %q", *logged)
+	}
+
+	progress.Start("repo-b")
+	*now = now.Add(30 * time.Second)
+	progress.Finished(nil)
+	want := "Progress: 2/4 repositories (50%), current repo-b, ETA 1m0s, rate limit 4200 remaining"
+	if len(*logged) != 1 || (*logged)[0] != want {
+		t.Fatalf("expected %q, got %q", want, *logged)
+	}
+
+	// A second organization adds its repositories to the total
+	progress.Begin(4)
+	*now = now.Add(time.Minute)
+	progress.Start("repo-c")
+	if len(*logged) != 2 || !strings.HasPrefix((*logged)[1], "Progress: 2/8 repositories (25%), current repo-c, ETA 6m0s") {
+		t.Fatalf("unexpected summaries: %q", *logged)
+	}
+
+	if newScanProgress(nil, 0) != nil {
+		t.Fatal("expected no progress without a terminal or an interval")
+	}
+}
+
+func TestScanProgressLine(t *testing.T) {
+	t.Parallel()
+
+	var terminal strings.Builder
+	progress, now, logged := newTestProgress(&terminal, time.Minute)
+	progress.Begin(4)
+	progress.Start("repo-a")
+	*now = now.Add(time.Minute)
+	progress.Finished(&github.Rate{Remaining: 4200})
+
+	want := "\r\033[K[#####...............] 1/4  25% ETA 3m0s | rate limit 4200 | repo-a"
+	if !strings.HasSuffix(terminal.String(), want) {
+		t.Fatalf("expected the line to end with %q, got %q", want, terminal.String())
+	}
+	if len(*logged) != 0 {
+		t.Fatalf("expected no summaries on a terminal, got %q", *logged)
+	}
+
+	// Log messages are written above the line, which is redrawn below them
+	terminal.Reset()
+	var log strings.Builder
+	progress.writeAbove(&log, []byte("Processing repository: repo-b\n"))
+	if log.String() != "Processing repository: repo-b\n" || !strings.HasPrefix(terminal.String(), "\r\033[K\r\033[K[#####") {
+		t.Fatalf("unexpected output: log %q, terminal %q", log.String(), terminal.String())
+	}
+
+	// Redraws within the redraw interval are skipped, except for the last repository
+	terminal.Reset()
+	progress.Start("repo-b")
+	if terminal.Len() != 0 {
+		t.Fatalf("expected no redraw within %v, got %q", progressRedrawInterval, terminal.String())
+	}
+	for range 3 {
+		progress.Finished(nil)
+	}
+	if !strings.HasSuffix(terminal.String(), "[####################] 4/4 100% ETA 0s | rate limit 4200 | repo-b") {
+		t.Fatalf("expected the finished line, got %q", terminal.String())
+	}
+
+	terminal.Reset()
+	progress.Stop()
+	if terminal.String() != "\r\033[K" {
+		t.Fatalf("expected Stop to clear the line, got %q", terminal.String())
+	}
+
+	var missing *ScanProgress
+	missing.Begin(1)
+	missing.Start("repo-a")
+	missing.Finished(nil)
+	missing.Stop()
+}
+
+// Not parallel: starts the redaction filter and swaps os.Stdout and os.Stderr.
+func TestProgressTerminalAfterRedaction(t *testing.T) {
+	stdout, stderr, results := os.Stdout, os.Stderr, resultWriter
+	defer func() {
+		flushRedactedOutput()
+		os.Stdout, os.Stderr, resultWriter = stdout, stderr, results
+		secretRedactor.secrets, secretRedactor.pipes, secretRedactor.done, secretRedactor.targets = nil, nil, nil, nil
+	}()
+	t.Setenv("TERM", "xterm")
+
+	// /dev/null is a character device, standing in for a terminal
+	terminal, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", os.DevNull, err)
+	}
+	defer terminal.Close()
+	logPath := filepath.Join(t.TempDir(), "log")
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		t.Fatalf("failed to create log file: %v", err)
+	}
+	defer logFile.Close()
+	os.Stdout, os.Stderr = logFile, terminal
+
+	secret := "ghp_0123456789abcdef"
+	redactSecret(secret)
+	if os.Stderr == terminal {
+		t.Fatal("expected the redaction filter to replace os.Stderr")
+	}
+	if got := progressTerminal(); got != io.Writer(terminal) {
+		t.Fatalf("expected the progress line on the terminal behind the redaction filter, got %v", got)
+	}
+
+	// Log messages written above the line skip the filter, and are redacted on the way
+	progress := newScanProgress(progressTerminal(), time.Minute)
+	progress.Begin(1)
+	logInfof("Cloning with token %s", secret)
+	progress.Stop()
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("failed to read log: %v", err)
+	}
+	if string(data) != "Cloning with token [REDACTED]\n" {
+		t.Fatalf("unexpected log: %q", data)
+	}
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Query Command
+// ------------------------
+
+// ActionQueryResult lists the workflow files that use an action.
+type ActionQueryResult struct {
+	Action      string            `json:"action"`
+	Version     string            `json:"version,omitempty"`     // Only set when the query named a version
+	Marketplace *ActionMetadata   `json:"marketplace,omitempty"` // Recorded marketplace metadata of a third-party action
+	Uses        []ActionReference `json:"uses"`
+}
+
+// QueriedWorkflow is a workflow file indexed for a repository.
+type QueriedWorkflow struct {
+	Workflow    string            `json:"workflow"`
+	Hash        string            `json:"hash"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// RepositoryQueryResult lists what the database records for a repository.
+type RepositoryQueryResult struct {
+	Repository    string            `json:"repository"`
+	DefaultBranch string            `json:"default_branch,omitempty"`
+	Toolchains    []string          `json:"toolchains"`
+	Workflows     []QueriedWorkflow `json:"workflows"`
+}
+
+// errRepositoryNotIndexed is returned when a queried repository is not in the database.
+var errRepositoryNotIndexed = errors.New("repository is not indexed")
+
+// runQueryCommand answers questions about an existing database without scanning the organization.
+func runQueryCommand(args []string) int {
+	if len(args) == 0 {
+		printQueryUsage()
+		return 1
+	}
+
+	fs := flag.NewFlagSet("query "+args[0], flag.ContinueOnError)
+	queryDB := fs.String("db", "./db", "Path to the database repository, or a git URL to clone")
+	queryToken := fs.String("token", "", "GitHub API token used to clone an HTTPS database URL")
+	format := fs.String("format", formatText, "Output format: text or json")
+	logFlags := addLogFlags(fs)
+	if err := fs.Parse(args[1:]); err != nil {
+		return 1
+	}
+	if err := applyEnvironmentDefaults(fs); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := logFlags.apply(); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := checkFormat(*format, formatText, formatJSON); err != nil {
+		logErrorf("%v", err)
+		printQueryUsage()
+		fs.PrintDefaults()
+		return 1
+	}
+	if fs.NArg() != 1 {
+		printQueryUsage()
+		fs.PrintDefaults()
+		return 1
+	}
+	if *format == formatJSON {
+		useJSONOutput()
+	}
+
+	checkout, err := openDB(*queryDB, *queryToken)
+	if err != nil {
+		logErrorf("Failed to open database: %v", err)
+		return 1
+	}
+	defer checkout.Close()
+
+	var output string
+	switch args[0] {
+	case "action":
+		result, err := queryActionAcrossOrganizations(checkout.Dir, fs.Arg(0))
+		if err != nil {
+			logErrorf("Query failed: %v", err)
+			return 1
+		}
+		if *format == formatJSON {
+			output, err = formatJSONDocument(result)
+		} else {
+			output = formatActionQueryText(result)
+		}
+		if err != nil {
+			logErrorf("Query failed: %v", err)
+			return 1
+		}
+	case "repository":
+		result, err := queryRepositoryAcrossOrganizations(checkout.Dir, fs.Arg(0))
+		if err != nil {
+			logErrorf("Query failed: %v", err)
+			return 1
+		}
+		if *format == formatJSON {
+			output, err = formatJSONDocument(result)
+		} else {
+			output = formatRepositoryQueryText(result)
+		}
+		if err != nil {
+			logErrorf("Query failed: %v", err)
+			return 1
+		}
+	case "check":
+		result, err := queryCheckAcrossOrganizations(checkout.Dir, fs.Arg(0))
+		if err != nil {
+			logErrorf("Query failed: %v", err)
+			return 1
+		}
+		if *format == formatJSON {
+			output, err = formatJSONDocument(result)
+		} else {
+			output = formatCheckQueryText(result)
+		}
+		if err != nil {
+			logErrorf("Query failed: %v", err)
+			return 1
+		}
+	default:
+		logErrorf("Unknown query '%s'", args[0])
+		printQueryUsage()
+		return 1
+	}
+
+	fmt.Fprint(resultWriter, output)
+	return 0
+}
+
+// printQueryUsage prints the usage for the query command.
+func printQueryUsage() {
+	fmt.Println("Usage: dotgithubindexer query action [-db <path or git URL>] [-format text|json] <owner/repo>[@version]")
+	fmt.Println("       dotgithubindexer query repository [-db <path or git URL>] [-format text|json] <repository>")
+	fmt.Println("       dotgithubindexer query check [-db <path or git URL>] [-format text|json] <check name>")
+}
+
+// queryAction returns the indexed workflow files that use an action, optionally at one version. A version
+// matches either the ref itself or the tag in a comment after a pinned SHA.
+func queryAction(dbPath, query string) (*ActionQueryResult, error) {
+	actionName, version, _ := strings.Cut(query, "@")
+	if actionName == "" {
+		return nil, fmt.Errorf("no action given")
+	}
+	result := &ActionQueryResult{Action: actionName, Version: version, Uses: []ActionReference{}}
+	metadata, err := loadActionMetadataIndex(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	for name, meta := range metadata.Actions {
+		if strings.EqualFold(name, actionName) {
+			result.Marketplace = &meta
+			break
+		}
+	}
+
+	err = walkIndexedWorkflows(dbPath, func(fileName, repoName, content string) {
+		for _, use := range extractActionUses(content, repoName, ".github/workflows/"+fileName) {
+			if !strings.EqualFold(use.Action, actionName) {
+				continue
+			}
+			if version != "" && use.Version != version && !strings.HasSuffix(use.Version, "# "+version) {
+				continue
+			}
+			result.Uses = append(result.Uses, ActionReference{Repository: use.RepoName, Workflow: use.FilePath, Version: use.Version})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(result.Uses, func(i, j int) bool {
+		a, b := result.Uses[i], result.Uses[j]
+		if a.Repository != b.Repository {
+			return a.Repository < b.Repository
+		}
+		if a.Workflow != b.Workflow {
+			return a.Workflow < b.Workflow
+		}
+		return a.Version < b.Version
+	})
+	return result, nil
+}
+
+// queryRepository returns the workflow files indexed for a repository.
+func queryRepository(dbPath, repoName string) (*RepositoryQueryResult, error) {
+	manifest, err := loadRepositoryManifest(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load repositories manifest: %v", err)
+	}
+	found := false
+	for _, name := range manifest.Repositories {
+		if name == repoName {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %s", errRepositoryNotIndexed, repoName)
+	}
+	result := &RepositoryQueryResult{Repository: repoName, DefaultBranch: manifest.DefaultBranches[repoName], Toolchains: emptyIfNil(manifest.Toolchains[repoName]), Workflows: []QueriedWorkflow{}}
+
+	actionsPath := filepath.Join(dbPath, "workflows")
+	dirs, err := os.ReadDir(actionsPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		actionName := dir.Name()
+		data, err := os.ReadFile(filepath.Join(actionsPath, actionName, "index.yaml"))
+		if err != nil {
+			continue
+		}
+		var index ActionIndex
+		if err := yaml.Unmarshal(data, &index); err != nil {
+			logErrorf("Error parsing index.yaml for workflow '%s': %v", actionName, err)
+			continue
+		}
+		hash, ok := index.Repositories[repoName]
+		if !ok {
+			continue
+		}
+		result.Workflows = append(result.Workflows, QueriedWorkflow{
+			Workflow:    index.fileName(repoName, actionName),
+			Hash:        hash,
+			Annotations: index.Annotations[repoName],
+		})
+	}
+	sort.Slice(result.Workflows, func(i, j int) bool { return result.Workflows[i].Workflow < result.Workflows[j].Workflow })
+	return result, nil
+}
+
+// formatActionQueryText formats an action query result as one line per workflow file.
+func formatActionQueryText(result *ActionQueryResult) string {
+	if len(result.Uses) == 0 {
+		return fmt.Sprintf("No indexed workflows use %s.\n", strings.TrimSuffix(result.Action+"@"+result.Version, "@"))
+	}
+	var builder strings.Builder
+	if meta := result.Marketplace; meta != nil {
+		latestRelease := meta.LatestRelease
+		if latestRelease == "" {
+			latestRelease = "none"
+		}
+		builder.WriteString(fmt.Sprintf("Marketplace: verified creator %t, %d stars, latest release %s, archived %t\n", meta.VerifiedCreator, meta.Stars, latestRelease, meta.Archived))
+	}
+	for _, use := range result.Uses {
+		builder.WriteString(fmt.Sprintf("%s\t%s\t%s\n", use.Repository, use.Workflow, use.Version))
+	}
+	return builder.String()
+}
+
+// formatRepositoryQueryText formats a repository query result as one line per workflow file.
+func formatRepositoryQueryText(result *RepositoryQueryResult) string {
+	var builder strings.Builder
+	builder.WriteString(result.Repository)
+	if result.DefaultBranch != "" {
+		builder.WriteString(fmt.Sprintf(" (default branch %s)", result.DefaultBranch))
+	}
+	builder.WriteString("\n")
+	if len(result.Toolchains) > 0 {
+		builder.WriteString(fmt.Sprintf("Toolchains: %s\n", strings.Join(result.Toolchains, ", ")))
+	}
+	if len(result.Workflows) == 0 {
+		builder.WriteString("No indexed workflows.\n")
+	}
+	for _, workflow := range result.Workflows {
+		builder.WriteString(fmt.Sprintf("%s\t%s", workflow.Workflow, shortHash(workflow.Hash)))
+		keys := make([]string, 0, len(workflow.Annotations))
+		for key := range workflow.Annotations {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			builder.WriteString(fmt.Sprintf("\t%s=%s", key, workflow.Annotations[key]))
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
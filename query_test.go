@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeQueryTestDB creates a database with two repositories sharing a build workflow.
+func writeQueryTestDB(t *testing.T) string {
+	t.Helper()
+
+	dbPath := t.TempDir()
+	manifest := "organization: example-org\nrepositories:\n    - repo-a\n    - repo-b\ndefault_branches:\n    repo-a: main\n"
+	if err := os.WriteFile(filepath.Join(dbPath, "repositories.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatalf("failed to write repositories.yaml: %v", err)
+	}
+
+	versions := map[string]string{
+		"hash-one": "# owner: team-x\njobs:\n  build:\n    steps:\n      - uses: actions/checkout@v4\n      - uses: codecov/codecov-action@0565863a31f2c772f9f0395002a31e3f06189574 # v5.4.0\n",
+		"hash-two": "jobs:\n  build:\n    steps:\n      - uses: actions/checkout@v3\n",
+	}
+	for repo, hash := range map[string]string{"repo-a": "hash-one", "repo-b": "hash-two"} {
+		if err := updateActionIndex(dbPath, "build.yml", repo, "build.yml", hash, ""); err != nil {
+			t.Fatalf("updateActionIndex returned error: %v", err)
+		}
+		if err := storeActionVersion(dbPath, "build.yml", hash, versions[hash]); err != nil {
+			t.Fatalf("storeActionVersion returned error: %v", err)
+		}
+	}
+	if err := updateWorkflowAnnotations(dbPath, "build.yml", "repo-a", map[string]string{"owner": "team-x"}); err != nil {
+		t.Fatalf("updateWorkflowAnnotations returned error: %v", err)
+	}
+	return dbPath
+}
+
+func TestQueryAction(t *testing.T) {
+	t.Parallel()
+
+	dbPath := writeQueryTestDB(t)
+
+	result, err := queryAction(dbPath, "actions/checkout")
+	if err != nil {
+		t.Fatalf("queryAction returned error: %v", err)
+	}
+	if len(result.Uses) != 2 || result.Uses[0].Repository != "repo-a" || result.Uses[1].Version != "v3" {
+		t.Fatalf("unexpected uses: %+v", result.Uses)
+	}
+
+	// A version matches the tag comment of a pinned SHA
+	result, err = queryAction(dbPath, "codecov/codecov-action@v5.4.0")
+	if err != nil {
+		t.Fatalf("queryAction returned error: %v", err)
+	}
+	if len(result.Uses) != 1 || result.Uses[0].Workflow != ".github/workflows/build.yml" {
+		t.Fatalf("unexpected uses: %+v", result.Uses)
+	}
+
+	result, err = queryAction(dbPath, "actions/checkout@v2")
+	if err != nil {
+		t.Fatalf("queryAction returned error: %v", err)
+	}
+	if text := formatActionQueryText(result); text != "No indexed workflows use actions/checkout@v2.\n" {
+		t.Fatalf("unexpected text: %q", text)
+	}
+
+	// The recorded marketplace metadata of the action is included
+	updateActionMetadataIndex(dbPath, "example-org", &ActionUsesIndex{Actions: map[string]map[string][]WorkflowReference{"codecov/codecov-action": {}}},
+		map[string]ActionMetadata{"codecov/codecov-action": {Stars: 7, LatestRelease: "2026-02-01"}}, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	result, err = queryAction(dbPath, "codecov/codecov-action")
+	if err != nil {
+		t.Fatalf("queryAction returned error: %v", err)
+	}
+	if result.Marketplace == nil || result.Marketplace.Stars != 7 {
+		t.Fatalf("expected the marketplace metadata, got %+v", result.Marketplace)
+	}
+	if text := formatActionQueryText(result); !strings.HasPrefix(text, "Marketplace: verified creator false, 7 stars, latest release 2026-02-01, archived false\n") {
+		t.Fatalf("unexpected text: %q", text)
+	}
+}
+
+func TestQueryRepository(t *testing.T) {
+	t.Parallel()
+
+	dbPath := writeQueryTestDB(t)
+
+	result, err := queryRepository(dbPath, "repo-a")
+	if err != nil {
+		t.Fatalf("queryRepository returned error: %v", err)
+	}
+	if result.DefaultBranch != "main" || len(result.Workflows) != 1 || result.Workflows[0].Hash != "hash-one" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if text := formatRepositoryQueryText(result); !strings.Contains(text, "build.yml\thash-one\towner=team-x") {
+		t.Fatalf("unexpected text: %q", text)
+	}
+
+	if _, err := queryRepository(dbPath, "repo-z"); !errors.Is(err, errRepositoryNotIndexed) {
+		t.Fatalf("expected errRepositoryNotIndexed, got %v", err)
+	}
+}
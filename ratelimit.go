@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ------------------------
+// Section: Rate Limiting
+// ------------------------
+
+// rateLimitWindow is the period the request budget of -rate-limit is divided into: the burst of the local
+// token bucket and the window of the shared Redis counter.
+const rateLimitWindow = time.Minute
+
+// RateLimiter paces the requests sent to the GitHub API, so that a run leaves part of a quota it shares
+// with other tools, such as other consumers of the same GitHub App installation.
+type RateLimiter interface {
+	// Wait blocks until another request may be sent, or until the context is done.
+	Wait(ctx context.Context) error
+}
+
+// githubRateLimiter paces the requests of API clients, set with -rate-limit; nil sends them unpaced.
+var githubRateLimiter RateLimiter
+
+// newRateLimiter creates the limiter of -rate-limit: a local token bucket, or a counter shared through the
+// Redis server of -rate-limit-redis when one is given.
+func newRateLimiter(perHour int, redisAddr, key string) (RateLimiter, error) {
+	if perHour <= 0 {
+		if redisAddr != "" {
+			return nil, errors.New("-rate-limit-redis requires -rate-limit")
+		}
+		return nil, nil
+	}
+	local := newTokenBucket(perHour)
+	if redisAddr == "" {
+		return local, nil
+	}
+	return newRedisLimiter(redisAddr, key, perHour, local)
+}
+
+// windowLimit returns how many of perHour requests may be sent in one rateLimitWindow, at least one.
+func windowLimit(perHour int) int {
+	return max(1, int(math.Ceil(float64(perHour)*rateLimitWindow.Hours())))
+}
+
+// sleepContext sleeps for d, returning early with the error of the context when it is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// tokenBucket is a RateLimiter local to this process. The bucket holds up to a window's worth of
+// requests and refills at the hourly rate, so a run may burst briefly but not exceed the rate over time.
+type tokenBucket struct {
+	rate     float64 // Requests added per second
+	capacity float64
+	mu       sync.Mutex
+	tokens   float64
+	last     time.Time
+}
+
+// newTokenBucket creates a full bucket for perHour requests an hour.
+func newTokenBucket(perHour int) *tokenBucket {
+	capacity := float64(windowLimit(perHour))
+	return &tokenBucket{rate: float64(perHour) / 3600, capacity: capacity, tokens: capacity}
+}
+
+// reserve takes a request from the bucket at now, returning how long to wait before sending it. A request
+// the bucket cannot cover yet is taken on credit, so that waiting requests are sent in order.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.last.IsZero() {
+		b.tokens = min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// Wait takes a request from the bucket, sleeping until it has refilled enough.
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	return sleepContext(ctx, b.reserve(time.Now()))
+}
+
+// redisLimiter is a RateLimiter shared by every process counting requests under the same key in a Redis
+// server. Each request increments the counter of the current window, <key>:<minutes since the epoch>,
+// and a request over the limit waits for the next window. When the server cannot be reached the local
+// token bucket paces the rest of the run.
+type redisLimiter struct {
+	addr     string
+	password string
+	db       int
+	key      string
+	limit    int
+	local    *tokenBucket
+	mu       sync.Mutex
+	conn     net.Conn
+	reader   *bufio.Reader
+	failed   bool
+}
+
+// newRedisLimiter creates a limiter for the Redis server at addr, given as host:port or as a
+// redis://[:password@]host:port[/db] URL.
+func newRedisLimiter(addr, key string, perHour int, local *tokenBucket) (*redisLimiter, error) {
+	limiter := &redisLimiter{addr: addr, key: key, limit: windowLimit(perHour), local: local}
+	if strings.Contains(addr, "://") {
+		parsed, err := url.Parse(addr)
+		if err != nil || parsed.Scheme != "redis" || parsed.Host == "" {
+			return nil, errors.New("invalid -rate-limit-redis URL; expected redis://[:password@]host:port[/db]")
+		}
+		limiter.addr = parsed.Host
+		if password, ok := parsed.User.Password(); ok {
+			limiter.password = password
+			redactSecret(password)
+		}
+		if db := strings.Trim(parsed.Path, "/"); db != "" {
+			limiter.db, err = strconv.Atoi(db)
+			if err != nil {
+				return nil, fmt.Errorf("invalid database '%s' in -rate-limit-redis", db)
+			}
+		}
+	}
+	if limiter.key == "" {
+		return nil, errors.New("-rate-limit-key must not be empty")
+	}
+	return limiter, nil
+}
+
+// Wait counts the request in the current window, waiting for the next window while it is over the limit.
+func (l *redisLimiter) Wait(ctx context.Context) error {
+	for {
+		now := time.Now()
+		window := now.Truncate(rateLimitWindow)
+		count, err := l.increment(fmt.Sprintf("%s:%d", l.key, window.Unix()/int64(rateLimitWindow/time.Second)))
+		if err != nil {
+			return l.local.Wait(ctx)
+		}
+		if count <= int64(l.limit) {
+			return nil
+		}
+		if err := sleepContext(ctx, window.Add(rateLimitWindow).Sub(now)); err != nil {
+			return err
+		}
+	}
+}
+
+// increment increments a window counter, which expires after the window has passed, and returns its
+// value. After the first error the limiter stops using the server for the rest of the run.
+func (l *redisLimiter) increment(key string) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.failed {
+		return 0, errors.New("redis limiter disabled")
+	}
+	count, err := l.incrementLocked(key)
+	if err != nil {
+		logWarnf("Shared rate limit unavailable (%v); pacing requests locally", err)
+		l.failed = true
+		if l.conn != nil {
+			l.conn.Close()
+		}
+		return 0, err
+	}
+	return count, nil
+}
+
+// incrementLocked sends the commands of increment, connecting first when needed; l.mu is held.
+func (l *redisLimiter) incrementLocked(key string) (int64, error) {
+	if l.conn == nil {
+		if err := l.connect(); err != nil {
+			return 0, err
+		}
+	}
+	count, err := l.command("INCR", key)
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		if _, err := l.command("EXPIRE", key, strconv.Itoa(int(2*rateLimitWindow/time.Second))); err != nil {
+			return 0, err
+		}
+	}
+	return count, nil
+}
+
+// connect opens the connection to the server, authenticating and selecting the database when configured.
+func (l *redisLimiter) connect() error {
+	conn, err := net.DialTimeout("tcp", l.addr, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	l.conn, l.reader = conn, bufio.NewReader(conn)
+	if l.password != "" {
+		if _, err := l.command("AUTH", l.password); err != nil {
+			return err
+		}
+	}
+	if l.db != 0 {
+		if _, err := l.command("SELECT", strconv.Itoa(l.db)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// command sends a command in the Redis serialization protocol and reads its reply, which is returned
+// when it is an integer.
+func (l *redisLimiter) command(args ...string) (int64, error) {
+	var request strings.Builder
+	fmt.Fprintf(&request, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&request, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	l.conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := l.conn.Write([]byte(request.String())); err != nil {
+		return 0, err
+	}
+
+	line, err := l.reader.ReadString('\n')
+	if err != nil {
+		return 0, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return 0, errors.New("empty reply from redis")
+	}
+	switch line[0] {
+	case '+':
+		return 0, nil
+	case '-':
+		return 0, fmt.Errorf("redis %s failed: %s", args[0], line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	}
+	return 0, fmt.Errorf("unexpected reply to redis %s: %q", args[0], line)
+}
+
+// rateLimitedTransport waits for the limiter before sending each request.
+type rateLimitedTransport struct {
+	limiter RateLimiter
+	base    http.RoundTripper
+}
+
+// RoundTrip waits for the limiter and sends the request.
+func (t rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketReserve(t *testing.T) {
+	t.Parallel()
+
+	// 120 requests an hour is a burst of 2, refilled every 30 seconds
+	bucket := newTokenBucket(120)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, want := range []time.Duration{0, 0, 30 * time.Second, time.Minute} {
+		if delay := bucket.reserve(now); delay != want {
+			t.Fatalf("request %d: expected a delay of %v, got %v", i+1, want, delay)
+		}
+	}
+
+	// Once the credit is paid back and the bucket refills, it holds no more than its capacity
+	now = now.Add(time.Hour)
+	for i, want := range []time.Duration{0, 0, 30 * time.Second} {
+		if delay := bucket.reserve(now); delay != want {
+			t.Fatalf("request %d after refilling: expected a delay of %v, got %v", i+1, want, delay)
+		}
+	}
+}
+
+// startFakeRedis serves INCR, EXPIRE, AUTH, and SELECT, counting every INCR in one counter, and records the
+// commands it receives.
+func startFakeRedis(t *testing.T) (string, func() []string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	var mu sync.Mutex
+	var commands []string
+	count := 0
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				for {
+					header, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					n, _ := strconv.Atoi(strings.TrimSpace(header[1:]))
+					args := make([]string, n)
+					for i := range args {
+						reader.ReadString('\n')
+						arg, _ := reader.ReadString('\n')
+						args[i] = strings.TrimSuffix(arg, "\r\n")
+					}
+
+					mu.Lock()
+					commands = append(commands, strings.Join(args, " "))
+					reply := "+OK\r\n"
+					switch args[0] {
+					case "INCR":
+						count++
+						reply = fmt.Sprintf(":%d\r\n", count)
+					case "EXPIRE":
+						reply = ":1\r\n"
+					case "AUTH":
+						if args[1] != "secret" {
+							reply = "-WRONGPASS invalid password\r\n"
+						}
+					}
+					mu.Unlock()
+					conn.Write([]byte(reply))
+				}
+			}()
+		}
+	}()
+
+	return listener.Addr().String(), func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), commands...)
+	}
+}
+
+func TestRedisLimiter(t *testing.T) {
+	t.Parallel()
+
+	addr, commands := startFakeRedis(t)
+	limiter, err := newRateLimiter(120, "redis://:secret@"+addr+"/2", "example-quota")
+	if err != nil {
+		t.Fatalf("newRateLimiter returned error: %v", err)
+	}
+
+	// The window allows 2 requests; the third waits for the next window
+	for range 2 {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait returned error: %v", err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the third request to wait for the next window, got %v", err)
+	}
+
+	got := commands()
+	if len(got) != 6 || got[0] != "AUTH secret" || got[1] != "SELECT 2" || !strings.HasPrefix(got[2], "INCR example-quota:") || !strings.HasPrefix(got[3], "EXPIRE example-quota:") || !strings.HasSuffix(got[3], " 120") {
+		t.Fatalf("unexpected commands: %q", got)
+	}
+}
+
+func TestRedisLimiterFallsBackLocally(t *testing.T) {
+	t.Parallel()
+
+	addr, _ := startFakeRedis(t)
+	limiter, err := newRateLimiter(120, "redis://:wrong@"+addr, "example-quota")
+	if err != nil {
+		t.Fatalf("newRateLimiter returned error: %v", err)
+	}
+
+	// The failed login leaves the local bucket pacing requests
+	for range 2 {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait returned error: %v", err)
+		}
+	}
+	if !limiter.(*redisLimiter).failed {
+		t.Fatal("expected the limiter to stop using the server")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the local bucket to make the third request wait, got %v", err)
+	}
+}
+
+func TestNewRateLimiter(t *testing.T) {
+	t.Parallel()
+
+	if limiter, err := newRateLimiter(0, "", "dotgithubindexer"); limiter != nil || err != nil {
+		t.Fatalf("expected no limiter without -rate-limit, got %v, %v", limiter, err)
+	}
+	if limiter, err := newRateLimiter(5000, "", "dotgithubindexer"); err != nil {
+		t.Fatalf("newRateLimiter returned error: %v", err)
+	} else if _, ok := limiter.(*tokenBucket); !ok {
+		t.Fatalf("expected a local token bucket without -rate-limit-redis, got %T", limiter)
+	}
+	for _, tc := range []struct {
+		perHour int
+		redis   string
+		key     string
+	}{
+		{0, "localhost:6379", "dotgithubindexer"},
+		{5000, "http://localhost:6379", "dotgithubindexer"},
+		{5000, "redis://localhost:6379/db", "dotgithubindexer"},
+		{5000, "localhost:6379", ""},
+	} {
+		if _, err := newRateLimiter(tc.perHour, tc.redis, tc.key); err == nil {
+			t.Fatalf("expected an error for %+v", tc)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ------------------------
+// Section: Secret Redaction
+// ------------------------
+
+// minSecretLength keeps short placeholder values from being redacted out of unrelated output.
+const minSecretLength = 8
+
+// redactedSecret replaces secrets in printed output.
+const redactedSecret = "[REDACTED]"
+
+// secretRedactor filters standard output and standard error once a secret is registered, so that a
+// token cannot reach logs through an error message or an echoed URL.
+var secretRedactor struct {
+	mu      sync.Mutex
+	secrets []string
+	done    []chan struct{}
+	pipes   []*os.File
+	targets map[*os.File]*os.File // File each pipe forwards to
+}
+
+// redactSecret registers a value that must not be printed. The first call starts filtering standard
+// output and standard error; flushRedactedOutput must be called before the process exits.
+func redactSecret(secret string) {
+	if len(secret) < minSecretLength {
+		return
+	}
+	secretRedactor.mu.Lock()
+	defer secretRedactor.mu.Unlock()
+	for _, known := range secretRedactor.secrets {
+		if known == secret {
+			return
+		}
+	}
+	secretRedactor.secrets = append(secretRedactor.secrets, secret)
+	if secretRedactor.pipes != nil {
+		return
+	}
+
+	for _, target := range []**os.File{&os.Stdout, &os.Stderr} {
+		r, w, err := os.Pipe()
+		if err != nil {
+			continue
+		}
+		done := make(chan struct{})
+		go func(dst *os.File) {
+			defer close(done)
+			copyRedacted(dst, r, redactSecrets)
+		}(*target)
+		// Results printed to standard output go through the filter too, keeping them in order with the log
+		if resultWriter == io.Writer(*target) {
+			resultWriter = w
+		}
+		if secretRedactor.targets == nil {
+			secretRedactor.targets = make(map[*os.File]*os.File)
+		}
+		secretRedactor.targets[w] = *target
+		*target = w
+		secretRedactor.pipes = append(secretRedactor.pipes, w)
+		secretRedactor.done = append(secretRedactor.done, done)
+	}
+}
+
+// flushRedactedOutput writes any output still held by the redaction filter. Output printed afterwards
+// is lost, so it is only called right before the process exits.
+func flushRedactedOutput() {
+	secretRedactor.mu.Lock()
+	pipes, done := secretRedactor.pipes, secretRedactor.done
+	secretRedactor.mu.Unlock()
+	for _, pipe := range pipes {
+		pipe.Close()
+	}
+	for _, ch := range done {
+		<-ch
+	}
+}
+
+// unredactedFile returns the file a redaction pipe forwards to, or f when it is not one, such as to check
+// whether standard error is a terminal. Whatever is written to it must be passed through redactSecrets.
+func unredactedFile(f *os.File) *os.File {
+	secretRedactor.mu.Lock()
+	defer secretRedactor.mu.Unlock()
+	if target, ok := secretRedactor.targets[f]; ok {
+		return target
+	}
+	return f
+}
+
+// redactSecrets replaces every registered secret in s.
+func redactSecrets(s string) string {
+	secretRedactor.mu.Lock()
+	defer secretRedactor.mu.Unlock()
+	for _, secret := range secretRedactor.secrets {
+		s = strings.ReplaceAll(s, secret, redactedSecret)
+	}
+	return s
+}
+
+// copyRedacted copies src to dst a line at a time, passing each line through redact. Lines are copied
+// whole so that a secret split across writes is still found.
+func copyRedacted(dst io.Writer, src io.Reader, redact func(string) string) {
+	reader := bufio.NewReader(src)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			io.WriteString(dst, redact(line))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Release Notes
+// ------------------------
+
+// releaseCacheTTL is how long the cached releases of an action repository are used before they are fetched again.
+const releaseCacheTTL = 7 * 24 * time.Hour
+
+// Limits on the release notes kept for each release.
+const (
+	releaseSummaryLines      = 5
+	releaseSummaryLineLength = 200
+)
+
+// ReleaseNote is a published release of an action repository with a short summary of its notes.
+type ReleaseNote struct {
+	Tag       string   `yaml:"tag"`
+	Published string   `yaml:"published,omitempty"`
+	URL       string   `yaml:"url,omitempty"`
+	Summary   []string `yaml:"summary,omitempty"`
+}
+
+// RepositoryReleases are the cached releases of one action repository, newest first.
+type RepositoryReleases struct {
+	FetchedAt string        `yaml:"fetched_at"` // RFC 3339
+	Releases  []ReleaseNote `yaml:"releases,omitempty"`
+}
+
+// ReleaseCache is stored as releases.yaml, keyed by owner/repository in lower case.
+type ReleaseCache struct {
+	Repositories map[string]RepositoryReleases `yaml:"repositories"`
+}
+
+// ActionUpdate is a version of a third-party action used at an older major version than its latest release.
+type ActionUpdate struct {
+	Action       string
+	Version      string
+	Latest       ReleaseNote
+	Repositories []string
+	Notes        []ReleaseNote // The first release of each newer major version, oldest first
+}
+
+// releaseFetcher lists the recent releases of a repository.
+type releaseFetcher func(owner, repoName string) ([]*github.RepositoryRelease, error)
+
+// fetchReleases lists the most recent releases of a repository from the Releases API.
+func fetchReleases(client *github.Client) releaseFetcher {
+	return func(owner, repoName string) ([]*github.RepositoryRelease, error) {
+		releases, _, err := client.Repositories.ListReleases(context.Background(), owner, repoName, &github.ListOptions{PerPage: 100})
+		return releases, err
+	}
+}
+
+// summarizeReleaseNotes keeps the first lines of a release body that describe changes, skipping
+// headings, blank lines, and the generated full changelog link.
+func summarizeReleaseNotes(body string) []string {
+	var summary []string
+	for _, line := range strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "<!--") || strings.HasPrefix(line, "**Full Changelog**") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimLeft(line, "-*+ "))
+		if line == "" {
+			continue
+		}
+		if len(line) > releaseSummaryLineLength {
+			line = strings.TrimSpace(line[:releaseSummaryLineLength]) + "…"
+		}
+		summary = append(summary, line)
+		if len(summary) == releaseSummaryLines {
+			break
+		}
+	}
+	return summary
+}
+
+// loadReleaseCache reads releases.yaml, returning an empty cache when it does not exist.
+func loadReleaseCache(dbPath string) (*ReleaseCache, error) {
+	cache := &ReleaseCache{Repositories: make(map[string]RepositoryReleases)}
+	data, err := os.ReadFile(filepath.Join(dbPath, "releases.yaml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cache, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, cache); err != nil {
+		return nil, fmt.Errorf("failed to parse releases.yaml: %v", err)
+	}
+	if cache.Repositories == nil {
+		cache.Repositories = make(map[string]RepositoryReleases)
+	}
+	return cache, nil
+}
+
+// refreshReleaseCache fetches the releases of each repository whose cached entry is missing or older
+// than releaseCacheTTL, and drops the entries of repositories no longer used. Drafts and prereleases
+// are skipped. When a fetch fails the stale entry is kept.
+func refreshReleaseCache(cache *ReleaseCache, repositories []string, fetch releaseFetcher, now time.Time) {
+	wanted := make(map[string]bool)
+	for _, key := range repositories {
+		wanted[key] = true
+
+		if entry, ok := cache.Repositories[key]; ok {
+			if fetchedAt, err := time.Parse(time.RFC3339, entry.FetchedAt); err == nil && now.Sub(fetchedAt) < releaseCacheTTL {
+				continue
+			}
+		}
+
+		owner, repoName, _ := strings.Cut(key, "/")
+		releases, err := fetch(owner, repoName)
+		if err != nil {
+			if !isNotFoundError(err) {
+				logErrorf("Error fetching releases for '%s': %v", key, err)
+				continue
+			}
+			releases = nil
+		}
+
+		entry := RepositoryReleases{FetchedAt: now.UTC().Format(time.RFC3339)}
+		for _, release := range releases {
+			if release.GetDraft() || release.GetPrerelease() {
+				continue
+			}
+			note := ReleaseNote{
+				Tag:     release.GetTagName(),
+				URL:     release.GetHTMLURL(),
+				Summary: summarizeReleaseNotes(release.GetBody()),
+			}
+			if release.PublishedAt != nil {
+				note.Published = formatReportDate(release.GetPublishedAt().Time)
+			}
+			entry.Releases = append(entry.Releases, note)
+		}
+		cache.Repositories[key] = entry
+	}
+
+	for key := range cache.Repositories {
+		if !wanted[key] {
+			delete(cache.Repositories, key)
+		}
+	}
+}
+
+// updateReleaseCache refreshes the cached releases of every third-party action in the uses index and
+// writes releases.yaml. Errors are printed and the cache as loaded is returned.
+func updateReleaseCache(dbPath, org string, usesIndex *ActionUsesIndex, fetch releaseFetcher, now time.Time) *ReleaseCache {
+	cache, err := loadReleaseCache(dbPath)
+	if err != nil {
+		logErrorf("Error loading release cache: %v", err)
+		cache = &ReleaseCache{Repositories: make(map[string]RepositoryReleases)}
+	}
+
+	seen := make(map[string]bool)
+	var repositories []string
+	for actionName := range usesIndex.Actions {
+		owner, repoName, ok := actionRepository(actionName)
+		if !ok || !isThirdPartyAction(actionName, org) {
+			continue
+		}
+		key := strings.ToLower(owner + "/" + repoName)
+		if !seen[key] {
+			seen[key] = true
+			repositories = append(repositories, key)
+		}
+	}
+	sort.Strings(repositories)
+	refreshReleaseCache(cache, repositories, fetch, now)
+
+	data, err := yaml.Marshal(cache)
+	if err != nil {
+		logErrorf("Error encoding release cache: %v", err)
+		return cache
+	}
+	if err := os.WriteFile(filepath.Join(dbPath, "releases.yaml"), data, 0644); err != nil {
+		logErrorf("Error writing releases.yaml: %v", err)
+	}
+	return cache
+}
+
+// buildActionUpdates lists the tagged versions of third-party actions used directly by workflows whose
+// major version is older than the latest release. Results are sorted by action, then version.
+func buildActionUpdates(org string, usesIndex *ActionUsesIndex, cache *ReleaseCache) []ActionUpdate {
+	var updates []ActionUpdate
+	for actionName, versions := range usesIndex.Actions {
+		owner, repoName, ok := actionRepository(actionName)
+		if !ok || !isThirdPartyAction(actionName, org) {
+			continue
+		}
+		releases := cache.Repositories[strings.ToLower(owner+"/"+repoName)].Releases
+
+		// The Releases API lists the newest first, so the first tagged release is the latest
+		var latest ReleaseNote
+		var latestParts [3]int
+		for _, release := range releases {
+			if parts, ok := versionParts(release.Tag); ok {
+				latest, latestParts = release, parts
+				break
+			}
+		}
+		if latest.Tag == "" {
+			continue
+		}
+
+		for version, refs := range versions {
+			parts, ok := versionParts(version)
+			if !ok || parts[0] >= latestParts[0] {
+				continue
+			}
+
+			repoSet := make(map[string]bool)
+			for _, ref := range refs {
+				if len(ref.Via) == 0 {
+					repoSet[ref.RepoName] = true
+				}
+			}
+			if len(repoSet) == 0 {
+				continue
+			}
+
+			update := ActionUpdate{Action: actionName, Version: version, Latest: latest, Repositories: sortedKeys(repoSet)}
+			firstOfMajor := make(map[int]ReleaseNote)
+			for _, release := range releases {
+				if releaseParts, ok := versionParts(release.Tag); ok && releaseParts[0] > parts[0] {
+					// Releases are newest first, so the last one seen is the first of its major version
+					firstOfMajor[releaseParts[0]] = release
+				}
+			}
+			majors := make([]int, 0, len(firstOfMajor))
+			for major := range firstOfMajor {
+				majors = append(majors, major)
+			}
+			sort.Ints(majors)
+			for _, major := range majors {
+				update.Notes = append(update.Notes, firstOfMajor[major])
+			}
+			updates = append(updates, update)
+		}
+	}
+
+	sort.Slice(updates, func(i, j int) bool {
+		if updates[i].Action != updates[j].Action {
+			return updates[i].Action < updates[j].Action
+		}
+		return newerVersion(updates[j].Version, updates[i].Version)
+	})
+	return updates
+}
+
+// formatReleaseNote renders a release heading with its summary.
+func formatReleaseNote(note ReleaseNote) string {
+	var builder strings.Builder
+	heading := fmt.Sprintf("`%s`", note.Tag)
+	if note.URL != "" {
+		heading = fmt.Sprintf("[%s](%s)", note.Tag, note.URL)
+	}
+	if note.Published != "" {
+		heading += " · " + note.Published
+	}
+	builder.WriteString(fmt.Sprintf("#### %s\n\n", heading))
+	if len(note.Summary) == 0 {
+		builder.WriteString("*No release notes.*\n\n")
+		return builder.String()
+	}
+	for _, line := range note.Summary {
+		builder.WriteString(fmt.Sprintf("- %s\n", line))
+	}
+	builder.WriteString("\n")
+	return builder.String()
+}
+
+// generateUpdatesMarkdown creates an UPDATES.md file in the db folder listing the actions used at an
+// older major version than their latest release, with the release notes of each newer major version.
+func generateUpdatesMarkdown(dbPath string, updates []ActionUpdate) error {
+	var markdownBuilder strings.Builder
+	markdownBuilder.WriteString("# Action Updates\n\n")
+	markdownBuilder.WriteString("Third-party actions used at an older major version than their latest release. The notes of the first release of each newer major version are summarized to help judge the upgrade effort. Releases are fetched from GitHub Releases and cached in `releases.yaml` for up to a week.\n\n")
+	markdownBuilder.WriteString("| Action | In Use | Latest | Repositories |\n")
+	markdownBuilder.WriteString("|--------|--------|--------|--------------|\n")
+	if len(updates) == 0 {
+		markdownBuilder.WriteString("| *Every action is on its latest major version* | - | - | - |\n")
+	}
+	for _, update := range updates {
+		markdownBuilder.WriteString(fmt.Sprintf("| %s | `%s` | `%s` | %d |\n", update.Action, update.Version, update.Latest.Tag, len(update.Repositories)))
+	}
+	markdownBuilder.WriteString("\n")
+
+	for _, update := range updates {
+		markdownBuilder.WriteString(fmt.Sprintf("## %s `%s` → `%s`\n\n", update.Action, update.Version, update.Latest.Tag))
+		markdownBuilder.WriteString(fmt.Sprintf("**Used by**: %s\n\n", strings.Join(update.Repositories, ", ")))
+		for _, note := range update.Notes {
+			markdownBuilder.WriteString(formatReleaseNote(note))
+		}
+	}
+
+	markdownBuilder.WriteString("*This file is automatically generated after each data collection run.*\n")
+
+	if err := os.WriteFile(filepath.Join(dbPath, "UPDATES.md"), []byte(markdownBuilder.String()), 0644); err != nil {
+		return fmt.Errorf("error writing UPDATES.md: %v", err)
+	}
+
+	logInfof("Generated UPDATES.md with %d outdated action versions", len(updates))
+	return nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+)
+
+func TestSummarizeReleaseNotes(t *testing.T) {
+	t.Parallel()
+
+	body := "## What's Changed\r\n\r\n<!-- Release notes generated -->\r\n* Upgrade to node24 by @octocat\r\n- Remove the `cache` input\r\n\r\n**Full Changelog**: https://github.com/actions/setup-go/compare/v5...v6\r\n"
+	summary := summarizeReleaseNotes(body)
+	if len(summary) != 2 || summary[0] != "Upgrade to node24 by @octocat" || summary[1] != "Remove the `cache` input" {
+		t.Fatalf("unexpected summary: %q", summary)
+	}
+
+	long := strings.Repeat("x", releaseSummaryLineLength+10)
+	var lines []string
+	for i := 0; i < releaseSummaryLines+2; i++ {
+		lines = append(lines, long)
+	}
+	summary = summarizeReleaseNotes(strings.Join(lines, "\n"))
+	if len(summary) != releaseSummaryLines || !strings.HasSuffix(summary[0], "…") {
+		t.Fatalf("expected %d truncated lines, got %q", releaseSummaryLines, summary)
+	}
+}
+
+func TestRefreshReleaseCache(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	cache := &ReleaseCache{Repositories: map[string]RepositoryReleases{
+		"actions/cache":    {FetchedAt: now.Add(-time.Hour).Format(time.RFC3339), Releases: []ReleaseNote{{Tag: "v4.0.0"}}},
+		"actions/checkout": {FetchedAt: now.Add(-8 * 24 * time.Hour).Format(time.RFC3339), Releases: []ReleaseNote{{Tag: "v3.0.0"}}},
+		"retired/action":   {FetchedAt: now.Format(time.RFC3339)},
+	}}
+
+	var fetched []string
+	fetch := func(owner, repoName string) ([]*github.RepositoryRelease, error) {
+		fetched = append(fetched, owner+"/"+repoName)
+		if repoName == "broken" {
+			return nil, fmt.Errorf("boom")
+		}
+		return []*github.RepositoryRelease{
+			{TagName: github.String("v5.0.0-beta"), Prerelease: github.Bool(true)},
+			{TagName: github.String("v4.1.0"), HTMLURL: github.String("https://example.com/v4.1.0"), Body: github.String("- Faster clones")},
+		}, nil
+	}
+	refreshReleaseCache(cache, []string{"actions/cache", "actions/checkout", "acme/broken"}, fetch, now)
+
+	if strings.Join(fetched, ",") != "actions/checkout,acme/broken" {
+		t.Fatalf("expected only stale and missing entries to be fetched, got %v", fetched)
+	}
+	checkout := cache.Repositories["actions/checkout"]
+	if len(checkout.Releases) != 1 || checkout.Releases[0].Tag != "v4.1.0" || checkout.Releases[0].Summary[0] != "Faster clones" {
+		t.Fatalf("unexpected refreshed releases: %+v", checkout)
+	}
+	if _, ok := cache.Repositories["retired/action"]; ok {
+		t.Fatalf("expected unused repositories to be dropped")
+	}
+	if _, ok := cache.Repositories["acme/broken"]; ok {
+		t.Fatalf("expected a failed fetch not to create an entry")
+	}
+}
+
+func TestBuildActionUpdates(t *testing.T) {
+	t.Parallel()
+
+	usesIndex := &ActionUsesIndex{Actions: map[string]map[string][]WorkflowReference{
+		"actions/setup-go": {
+			"v4": {{RepoName: "repo-b"}, {RepoName: "repo-a"}},
+			"v6": {{RepoName: "repo-c"}},
+			"0123456789abcdef0123456789abcdef01234567 # v5.1.0": {{RepoName: "repo-d"}},
+		},
+		"actions/cache": {
+			"v3": {{RepoName: "repo-e", Via: []string{"example-org/setup@v1"}}},
+		},
+		"example-org/shared": {
+			"v1": {{RepoName: "repo-a"}},
+		},
+	}}
+	cache := &ReleaseCache{Repositories: map[string]RepositoryReleases{
+		"actions/setup-go": {Releases: []ReleaseNote{
+			{Tag: "v6.1.0"},
+			{Tag: "v6.0.0", Summary: []string{"Node 24"}},
+			{Tag: "v5.2.0"},
+			{Tag: "v5.0.0", Summary: []string{"Caching on by default"}},
+		}},
+		"actions/cache":      {Releases: []ReleaseNote{{Tag: "v4.0.0"}}},
+		"example-org/shared": {Releases: []ReleaseNote{{Tag: "v2.0.0"}}},
+	}}
+
+	updates := buildActionUpdates("example-org", usesIndex, cache)
+	if len(updates) != 2 {
+		t.Fatalf("expected 2 updates, got %+v", updates)
+	}
+	first := updates[0]
+	if first.Version != "v4" || first.Latest.Tag != "v6.1.0" || strings.Join(first.Repositories, ",") != "repo-a,repo-b" {
+		t.Fatalf("unexpected first update: %+v", first)
+	}
+	if len(first.Notes) != 2 || first.Notes[0].Tag != "v5.0.0" || first.Notes[1].Tag != "v6.0.0" {
+		t.Fatalf("expected the first release of each newer major, got %+v", first.Notes)
+	}
+	if second := updates[1]; !strings.HasSuffix(second.Version, "# v5.1.0") || len(second.Notes) != 1 {
+		t.Fatalf("unexpected second update: %+v", second)
+	}
+
+	dbPath := t.TempDir()
+	if err := generateUpdatesMarkdown(dbPath, updates); err != nil {
+		t.Fatalf("generateUpdatesMarkdown returned error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dbPath, "UPDATES.md"))
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+	content := string(data)
+	for _, want := range []string{"| actions/setup-go | `v4` | `v6.1.0` | 2 |", "## actions/setup-go `v4` → `v6.1.0`", "#### `v5.0.0`\n\n- Caching on by default", "*This file is automatically generated"} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("expected UPDATES.md to contain %q, got:\n%s", want, content)
+		}
+	}
+}
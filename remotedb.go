@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// ------------------------
+// Section: Remote Database
+// ------------------------
+
+// scpLikeURLRe matches git URLs in the scp-like form user@host:path.
+var scpLikeURLRe = regexp.MustCompile(`^[A-Za-z0-9._-]+@[A-Za-z0-9.-]+:`)
+
+// Identity used for database commits when git has no user configured, as on fresh CI runners.
+const (
+	dbCommitName  = "dotgithubindexer"
+	dbCommitEmail = "dotgithubindexer@users.noreply.github.com"
+)
+
+// DBCheckout is the local directory a command reads and writes the database in.
+// When the database is a git URL, the directory is a temporary shallow clone.
+type DBCheckout struct {
+	Dir     string
+	URL     string     // Empty when the database is a local path
+	Notes   bool       // Attach a JSON summary of each commit as a git note under dbNotesRef
+	Scanned func() int // Counts the repositories the run scanned, for the commit trailers; nil when it does not scan
+	token   string
+
+	localCommit bool // Commit the changes of a local database in the git working tree it is in
+	localPush   bool // Push those commits to the upstream of the current branch
+}
+
+// isGitURL reports whether a -db value refers to a remote git repository rather than a local path.
+func isGitURL(location string) bool {
+	for _, prefix := range []string{"https://", "http://", "ssh://", "git://", "file://"} {
+		if strings.HasPrefix(location, prefix) {
+			return true
+		}
+	}
+	return scpLikeURLRe.MatchString(location)
+}
+
+// openDB prepares the database named by a -db value. Local paths are used as is; git URLs are
+// cloned into a temporary directory. The token, when set, authenticates HTTPS clones and pushes.
+func openDB(location, token string) (*DBCheckout, error) {
+	if !isGitURL(location) {
+		if err := detectLayout(location); err != nil {
+			return nil, err
+		}
+		return &DBCheckout{Dir: location}, nil
+	}
+
+	dir, err := os.MkdirTemp("", "dotgithubindexer-db-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create checkout directory; on a read-only filesystem set TMPDIR to a writable volume: %v", err)
+	}
+	checkout := &DBCheckout{Dir: dir, URL: location, token: token}
+
+	logInfof("Cloning database repository into '%s'", dir)
+	if err := checkout.git("clone", "--depth", "1", "--single-branch", location, dir); err != nil {
+		checkout.Close()
+		return nil, err
+	}
+	if err := detectLayout(dir); err != nil {
+		checkout.Close()
+		return nil, err
+	}
+	return checkout, nil
+}
+
+// CommitLocally makes Publish commit the changes of a local database, which must be inside a git working
+// tree, and push the commit to the upstream of the current branch when push is set. Only the database
+// directory is committed, so it may be a folder of a larger repository.
+func (c *DBCheckout) CommitLocally(push bool) error {
+	if c.URL != "" {
+		return fmt.Errorf("-git-commit and -git-push apply to a local -db; a git URL -db is always committed and pushed")
+	}
+	inside, err := c.gitOutput("-C", c.Dir, "rev-parse", "--is-inside-work-tree")
+	if err != nil || strings.TrimSpace(inside) != "true" {
+		return fmt.Errorf("-git-commit requires '%s' to be inside a git working tree", c.Dir)
+	}
+	if push {
+		if _, err := c.gitOutput("-C", c.Dir, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}"); err != nil {
+			return fmt.Errorf("-git-push requires the current branch of '%s' to have an upstream: %v", c.Dir, err)
+		}
+	}
+	c.localCommit = true
+	c.localPush = push
+	return nil
+}
+
+// Publish commits every change in a cloned database and pushes it. A local database is only committed,
+// and pushed, after CommitLocally. Nothing is committed when nothing changed.
+func (c *DBCheckout) Publish(message string) error {
+	if c.URL == "" {
+		if !c.localCommit {
+			return nil
+		}
+		return c.publishLocal(message)
+	}
+
+	if err := c.git("-C", c.Dir, "add", "-A"); err != nil {
+		return err
+	}
+	status, err := c.gitOutput("-C", c.Dir, "status", "--porcelain")
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(status) == "" {
+		logInfof("No database changes to push.")
+		return nil
+	}
+
+	if err := c.commit(message); err != nil {
+		return err
+	}
+	if err := c.git("-C", c.Dir, "push", "-q", "origin", "HEAD"); err != nil {
+		return err
+	}
+	if err := c.pushCommitNotes(); err != nil {
+		return err
+	}
+
+	logInfof("Pushed database changes to '%s'", c.URL)
+	return nil
+}
+
+// publishLocal commits the changes in the directory of a local database and, with -git-push, pushes them.
+// Changes elsewhere in the working tree and the lock of a running scan are left out of the commit.
+func (c *DBCheckout) publishLocal(message string) error {
+	if err := c.git("-C", c.Dir, "add", "-A", "--", ".", ":(exclude)"+dbLockFile); err != nil {
+		return err
+	}
+	status, err := c.gitOutput("-C", c.Dir, "status", "--porcelain", "--untracked-files=no", "--", ".")
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(status) == "" {
+		logInfof("No database changes to commit.")
+		return nil
+	}
+
+	if err := c.commit(message, "."); err != nil {
+		return err
+	}
+	if !c.localPush {
+		logInfof("Committed database changes in '%s'", c.Dir)
+		return nil
+	}
+	if err := c.git("-C", c.Dir, "push", "-q"); err != nil {
+		return err
+	}
+	if err := c.pushCommitNotes(); err != nil {
+		return err
+	}
+	logInfof("Committed and pushed database changes in '%s'", c.Dir)
+	return nil
+}
+
+// commit commits the staged changes with trailers summarizing them, falling back to the
+// dotgithubindexer identity when git has no user configured. Paths, when given, limit the commit to them.
+func (c *DBCheckout) commit(message string, paths ...string) error {
+	message, summary := c.commitMessage(message)
+	identity := c.commitIdentity()
+	args := append(identity, "commit", "-q", "-m", message)
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+	if err := c.git(args...); err != nil {
+		return err
+	}
+	if !c.Notes {
+		return nil
+	}
+	return c.addCommitNote(summary, identity)
+}
+
+// commitIdentity returns the git options that commit in the checkout, with the dotgithubindexer identity
+// when git has no user configured.
+func (c *DBCheckout) commitIdentity() []string {
+	identity := []string{"-C", c.Dir}
+	if email, _ := c.gitOutput("-C", c.Dir, "config", "user.email"); strings.TrimSpace(email) == "" {
+		identity = append(identity, "-c", "user.name="+dbCommitName, "-c", "user.email="+dbCommitEmail)
+	}
+	return identity
+}
+
+// Close removes the temporary clone of a remote database.
+func (c *DBCheckout) Close() {
+	if c.URL == "" {
+		return
+	}
+	if err := os.RemoveAll(c.Dir); err != nil {
+		logErrorf("Error removing database checkout '%s': %v", c.Dir, err)
+	}
+}
+
+// git runs a git command, including its output in the returned error.
+func (c *DBCheckout) git(args ...string) error {
+	_, err := c.gitOutput(args...)
+	return err
+}
+
+// gitOutput runs a git command and returns its standard output.
+func (c *DBCheckout) gitOutput(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Env = c.gitEnv()
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %v: %s", gitSubcommand(args), err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// gitEnv returns the environment git runs in. The token of an HTTPS database is passed as an extra header
+// through GIT_CONFIG_PARAMETERS rather than a -c argument, so that it does not show in the process list.
+func (c *DBCheckout) gitEnv() []string {
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if c.token == "" || !strings.HasPrefix(c.URL, "https://") {
+		return env
+	}
+	credentials := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + c.token))
+	parameters := "'http.extraheader=AUTHORIZATION: basic " + credentials + "'"
+	if existing := os.Getenv("GIT_CONFIG_PARAMETERS"); existing != "" {
+		parameters = existing + " " + parameters
+	}
+	// The last value of a repeated variable is the one used
+	return append(env, "GIT_CONFIG_PARAMETERS="+parameters)
+}
+
+// gitSubcommand returns the git subcommand in an argument list, skipping global options, for error messages.
+func gitSubcommand(args []string) string {
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-C", "-c":
+			i++
+		default:
+			return args[i]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v50/github"
+)
+
+// ------------------------
+// Section: Dependency Update Configuration
+// ------------------------
+
+// renovateDir is the database directory Renovate configs are stored in.
+const renovateDir = "renovate"
+
+// renovateLogicalPath is the name Renovate configs are indexed under, whatever their location or extension.
+const renovateLogicalPath = "renovate.json"
+
+// renovateConfigPaths lists the Renovate config file names in the order Renovate itself looks for them.
+var renovateConfigPaths = []string{
+	"renovate.json",
+	"renovate.json5",
+	".github/renovate.json",
+	".github/renovate.json5",
+	".gitlab/renovate.json",
+	".gitlab/renovate.json5",
+	".renovaterc",
+	".renovaterc.json",
+	".renovaterc.json5",
+}
+
+// RenovateFile represents a Renovate configuration file.
+type RenovateFile struct {
+	RepoName string
+	FilePath string
+	Content  string
+	Hash     string
+	BlobSHA  string
+}
+
+// fetchRenovateFile retrieves the Renovate config of a repository if it has one.
+func fetchRenovateFile(client *github.Client, repo *github.Repository) (*RenovateFile, error) {
+	ctx := context.Background()
+	opts := &github.RepositoryContentGetOptions{Ref: getDefaultBranch(repo)}
+
+	for _, candidate := range renovateConfigPaths {
+		fileContent, _, _, err := client.Repositories.GetContents(ctx, repo.GetOwner().GetLogin(), repo.GetName(), candidate, opts)
+		if err != nil {
+			if isNotFoundError(err) {
+				continue
+			}
+			logErrorf("Error accessing %s in repository '%s': %v", candidate, repo.GetName(), err)
+			return nil, err
+		}
+		if fileContent == nil {
+			continue
+		}
+
+		logDebugf("Found %s file in repository '%s'", candidate, repo.GetName())
+		content, err := fetchBlobContent(client, repo.GetOwner().GetLogin(), repo.GetName(), fileContent.GetSHA())
+		if err != nil {
+			logErrorf("Error fetching content for %s in repository '%s': %v", candidate, repo.GetName(), err)
+			return nil, withBlobPath(err, candidate)
+		}
+		if content == "" {
+			logDebugf("Empty content for %s in repository '%s'", candidate, repo.GetName())
+			return nil, nil
+		}
+		return &RenovateFile{
+			RepoName: repo.GetName(),
+			FilePath: candidate,
+			Content:  content,
+			Hash:     computeHash([]byte(content)),
+			BlobSHA:  fileContent.GetSHA(),
+		}, nil
+	}
+
+	logDebugf("No Renovate config found in repository '%s'.", repo.GetName())
+	return nil, nil
+}
+
+// updateRenovateIndex maps a repository to its Renovate config hash in db/renovate/renovate.json/index.yaml.
+func updateRenovateIndex(dbPath string, file RenovateFile) error {
+	filePath := filepath.Join(dbPath, renovateDir, renovateLogicalPath)
+	if err := os.MkdirAll(filePath, os.ModePerm); err != nil {
+		return err
+	}
+
+	indexPath := filepath.Join(filePath, "index.yaml")
+	index, err := readActionIndex(indexPath)
+	if err != nil {
+		return err
+	}
+	index.Repositories[file.RepoName] = file.Hash
+	recordBlobSHA(index, file.Hash, file.BlobSHA)
+	recordFilename(index, file.RepoName, renovateLogicalPath, file.FilePath)
+	if err := writeActionIndex(indexPath, index); err != nil {
+		return err
+	}
+
+	logDebugf("Updated Renovate index with repository '%s'", file.RepoName)
+	return nil
+}
+
+// storeRenovateVersion saves the content of a Renovate config under its hash.
+func storeRenovateVersion(dbPath string, file RenovateFile) error {
+	filePath := filepath.Join(dbPath, renovateDir, renovateLogicalPath)
+	if err := os.MkdirAll(versionsDir(filePath), os.ModePerm); err != nil {
+		return err
+	}
+	storedPath := versionPath(filePath, file.Hash)
+	if _, err := os.Stat(storedPath); os.IsNotExist(err) {
+		logDebugf("Storing Renovate config under hash '%s'", file.Hash)
+		return writeVersionFile(storedPath, []byte(file.Content))
+	}
+	return nil
+}
+
+// garbageCollectRenovate removes unused versions of the Renovate configs.
+func garbageCollectRenovate(dbPath string) error {
+	return garbageCollectIndexedFolders(filepath.Join(dbPath, renovateDir), "Renovate config")
+}
+
+// readIndexedRepositories returns the repositories recorded in the index.yaml of every folder of a
+// database directory such as db/dependabot.
+func readIndexedRepositories(kindPath string) (map[string]bool, error) {
+	repos := make(map[string]bool)
+	names, err := readSubdirectories(kindPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		index, err := readActionIndex(filepath.Join(kindPath, name, "index.yaml"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read index of '%s': %v", name, err)
+		}
+		for repoName := range index.Repositories {
+			repos[repoName] = true
+		}
+	}
+	return repos, nil
+}
+
+// generateDependencyUpdatesMarkdown writes DEPENDENCY_UPDATES.md, listing the repositories configured
+// for Dependabot or Renovate and those with no dependency update configuration at all.
+func generateDependencyUpdatesMarkdown(dbPath, org string) error {
+	manifest, err := loadRepositoryManifest(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to load repositories manifest: %v", err)
+	}
+	dependabotRepos, err := readIndexedRepositories(filepath.Join(dbPath, "dependabot"))
+	if err != nil {
+		return err
+	}
+	renovateRepos, err := readIndexedRepositories(filepath.Join(dbPath, renovateDir))
+	if err != nil {
+		return err
+	}
+	_, statErr := os.Stat(filepath.Join(dbPath, renovateDir))
+	renovateIndexed := statErr == nil
+
+	var missing []string
+	for _, repoName := range manifest.Repositories {
+		if !dependabotRepos[repoName] && !renovateRepos[repoName] {
+			missing = append(missing, repoName)
+		}
+	}
+	sort.Strings(missing)
+
+	var markdownBuilder strings.Builder
+	markdownBuilder.WriteString("# Dependency Updates\n\n")
+	markdownBuilder.WriteString(fmt.Sprintf("This document lists the repositories of %s without a Dependabot or Renovate configuration. %d of %d repositories have none.\n\n", org, len(missing), len(manifest.Repositories)))
+	if !renovateIndexed {
+		markdownBuilder.WriteString("Renovate configs are not indexed; run `index` with `-renovate` to include them.\n\n")
+	}
+	markdownBuilder.WriteString("| Configuration | Repositories |\n")
+	markdownBuilder.WriteString("|---------------|--------------|\n")
+	markdownBuilder.WriteString(fmt.Sprintf("| Dependabot | %d |\n", len(dependabotRepos)))
+	markdownBuilder.WriteString(fmt.Sprintf("| Renovate | %d |\n", len(renovateRepos)))
+	markdownBuilder.WriteString(fmt.Sprintf("| None | %d |\n", len(missing)))
+
+	markdownBuilder.WriteString("\n## Repositories Without Dependency Updates\n\n")
+	for _, repoName := range missing {
+		markdownBuilder.WriteString(fmt.Sprintf("- [%s](%s/%s/%s)\n", repoName, githubWebURL, org, repoName))
+	}
+	if len(missing) == 0 {
+		markdownBuilder.WriteString("*Every repository has a dependency update configuration.*\n")
+	}
+
+	markdownBuilder.WriteString("\n*This file is automatically generated after each data collection run.*\n")
+
+	if err := os.WriteFile(filepath.Join(dbPath, "DEPENDENCY_UPDATES.md"), []byte(markdownBuilder.String()), 0644); err != nil {
+		return fmt.Errorf("error writing DEPENDENCY_UPDATES.md: %v", err)
+	}
+
+	logInfof("Generated DEPENDENCY_UPDATES.md with %d repositories lacking dependency update configuration", len(missing))
+	return nil
+}
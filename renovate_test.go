@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v50/github"
+)
+
+func TestFetchRenovateFile(t *testing.T) {
+	t.Parallel()
+
+	content := `{"extends": ["config:recommended"]}`
+	sha := computeBlobSHA([]byte(content))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/example-org/repo-a/contents/.github/renovate.json5":
+			fmt.Fprintf(w, `{"type":"file","name":"renovate.json5","path":".github/renovate.json5","sha":%q}`, sha)
+		case "/repos/example-org/repo-a/git/blobs/" + sha:
+			fmt.Fprintf(w, `{"sha":%q,"size":%d,"encoding":"base64","content":%q}`, sha, len(content), base64.StdEncoding.EncodeToString([]byte(content)))
+		default:
+			http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	owner := &github.User{Login: github.String("example-org")}
+
+	file, err := fetchRenovateFile(client, &github.Repository{Name: github.String("repo-a"), Owner: owner, DefaultBranch: github.String("main")})
+	if err != nil {
+		t.Fatalf("fetchRenovateFile returned error: %v", err)
+	}
+	if file == nil || file.FilePath != ".github/renovate.json5" || file.Content != content {
+		t.Fatalf("unexpected Renovate file: %+v", file)
+	}
+	if missing, err := fetchRenovateFile(client, &github.Repository{Name: github.String("repo-b"), Owner: owner}); err != nil || missing != nil {
+		t.Fatalf("expected no Renovate file, got %+v, %v", missing, err)
+	}
+
+	dbPath := t.TempDir()
+	if err := updateRenovateIndex(dbPath, *file); err != nil {
+		t.Fatalf("updateRenovateIndex returned error: %v", err)
+	}
+	if err := storeRenovateVersion(dbPath, *file); err != nil {
+		t.Fatalf("storeRenovateVersion returned error: %v", err)
+	}
+	folder := filepath.Join(dbPath, "renovate", "renovate.json")
+	index, err := readActionIndex(filepath.Join(folder, "index.yaml"))
+	if err != nil {
+		t.Fatalf("readActionIndex returned error: %v", err)
+	}
+	if index.Repositories["repo-a"] != file.Hash || index.fileName("repo-a", renovateLogicalPath) != ".github/renovate.json5" {
+		t.Fatalf("unexpected index: %+v", index)
+	}
+
+	stale := versionPath(folder, strings.Repeat("0", 64))
+	if err := os.WriteFile(stale, []byte("{}"), 0644); err != nil {
+		t.Fatalf("failed to write stale version: %v", err)
+	}
+	if err := garbageCollectRenovate(dbPath); err != nil {
+		t.Fatalf("garbageCollectRenovate returned error: %v", err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("expected the unused version to be removed, got err=%v", err)
+	}
+	if _, err := os.Stat(versionPath(folder, file.Hash)); err != nil {
+		t.Fatalf("expected the current version to be kept: %v", err)
+	}
+}
+
+func TestGenerateDependencyUpdatesMarkdown(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dbPath, "repositories.yaml"), []byte("organization: example-org\nrepositories:\n    - repo-a\n    - repo-b\n    - repo-c\n"), 0644); err != nil {
+		t.Fatalf("failed to write repositories.yaml: %v", err)
+	}
+	if err := updateDependabotIndex(dbPath, "repo-a", ".github/dependabot.yml", computeHash([]byte("version: 2\n")), "", "gomod"); err != nil {
+		t.Fatalf("updateDependabotIndex returned error: %v", err)
+	}
+
+	// Without indexed Renovate configs the report says so
+	if err := generateDependencyUpdatesMarkdown(dbPath, "example-org"); err != nil {
+		t.Fatalf("generateDependencyUpdatesMarkdown returned error: %v", err)
+	}
+	markdown, err := os.ReadFile(filepath.Join(dbPath, "DEPENDENCY_UPDATES.md"))
+	if err != nil {
+		t.Fatalf("failed to read DEPENDENCY_UPDATES.md: %v", err)
+	}
+	if !strings.Contains(string(markdown), "run `index` with `-renovate`") || !strings.Contains(string(markdown), "2 of 3 repositories have none.") {
+		t.Fatalf("unexpected DEPENDENCY_UPDATES.md:\n%s", markdown)
+	}
+
+	content := `{"extends": ["config:recommended"]}`
+	if err := updateRenovateIndex(dbPath, RenovateFile{RepoName: "repo-b", FilePath: "renovate.json", Content: content, Hash: computeHash([]byte(content))}); err != nil {
+		t.Fatalf("updateRenovateIndex returned error: %v", err)
+	}
+	if err := generateDependencyUpdatesMarkdown(dbPath, "example-org"); err != nil {
+		t.Fatalf("generateDependencyUpdatesMarkdown returned error: %v", err)
+	}
+	markdown, err = os.ReadFile(filepath.Join(dbPath, "DEPENDENCY_UPDATES.md"))
+	if err != nil {
+		t.Fatalf("failed to read DEPENDENCY_UPDATES.md: %v", err)
+	}
+	for _, want := range []string{
+		"1 of 3 repositories have none.",
+		"| Dependabot | 1 |",
+		"| Renovate | 1 |",
+		"- [repo-c](https://github.com/example-org/repo-c)\n",
+	} {
+		if !strings.Contains(string(markdown), want) {
+			t.Fatalf("DEPENDENCY_UPDATES.md does not contain %q:\n%s", want, markdown)
+		}
+	}
+	if strings.Contains(string(markdown), "-renovate") || strings.Contains(string(markdown), "[repo-a]") {
+		t.Fatalf("unexpected DEPENDENCY_UPDATES.md:\n%s", markdown)
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Database Repair
+// ------------------------
+
+// dbStateFile records the state each run leaves the database in, to detect changes made outside the tool.
+const dbStateFile = "db_state.yaml"
+
+// DBState is the contents of db_state.yaml. A force-push or a hand edit of the database changes the index
+// entries of some repositories without changing their digests here.
+type DBState struct {
+	RecordedAt   time.Time         `yaml:"recorded_at"`
+	Slim         bool              `yaml:"slim,omitempty"` // Recorded by a -store-content=false run, so versions may have no stored content
+	Repositories map[string]string `yaml:"repositories"`   // RepoName: digest of the repository's index entries
+}
+
+// DBDivergence is a difference between the database and the state recorded by the last run.
+type DBDivergence struct {
+	Repository string
+	Reason     string
+	Path       string // Stored version whose content does not match its hash; empty for other divergences
+}
+
+// loadDBState reads db_state.yaml, returning nil if no run has recorded it yet.
+func loadDBState(dbPath string) (*DBState, error) {
+	data, err := os.ReadFile(filepath.Join(dbPath, dbStateFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	state := &DBState{}
+	if err := yaml.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", dbStateFile, err)
+	}
+	return state, nil
+}
+
+// recordDBState writes the current index entries of every repository to db_state.yaml. slim records that
+// versions may have no stored content.
+func recordDBState(dbPath string, slim bool, now time.Time) error {
+	entries, err := repositoryIndexEntries(dbPath)
+	if err != nil {
+		return err
+	}
+	state := &DBState{RecordedAt: now.UTC(), Slim: slim, Repositories: make(map[string]string, len(entries))}
+	for repoName, repoEntries := range entries {
+		state.Repositories[repoName] = digestIndexEntries(repoEntries)
+	}
+	return writeYAMLFile(dbPath, dbStateFile, state)
+}
+
+// indexEntry is a file version a repository is indexed with.
+type indexEntry struct {
+	Folder string // Folder of the index, relative to the database, e.g. workflows/build.yml
+	Hash   string
+}
+
+// repositoryIndexEntries reads the entries of every index in the database, grouped by repository.
+func repositoryIndexEntries(dbPath string) (map[string][]indexEntry, error) {
+	folders, err := versionFolders(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	entries := make(map[string][]indexEntry)
+	for _, folder := range folders {
+		rel, err := filepath.Rel(dbPath, folder)
+		if err != nil {
+			return nil, err
+		}
+		read := func(string) ([]byte, error) { return os.ReadFile(filepath.Join(folder, "index.yaml")) }
+		for repoName, value := range indexedRepositories(read, "index.yaml") {
+			entries[repoName] = append(entries[repoName], indexEntry{Folder: filepath.ToSlash(rel), Hash: indexedHash(value)})
+		}
+	}
+	return entries, nil
+}
+
+// indexedHash returns the hash of an index entry, which is either the hash itself or, in dotfile indexes,
+// a mapping holding it.
+func indexedHash(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case map[string]any:
+		hash, _ := v["hash"].(string)
+		return hash
+	}
+	return ""
+}
+
+// digestIndexEntries returns a digest of a repository's index entries that does not depend on their order.
+func digestIndexEntries(entries []indexEntry) string {
+	lines := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		lines = append(lines, entry.Folder+" "+entry.Hash)
+	}
+	sort.Strings(lines)
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// checkDBState compares the database with the state recorded by the last run. Index entries changed since
+// then, and stored versions that are missing or whose content does not match their hash, are divergences
+// of the repositories indexed with them. A database without a recorded state has none.
+func checkDBState(dbPath string) ([]DBDivergence, error) {
+	state, err := loadDBState(dbPath)
+	if err != nil || state == nil {
+		return nil, err
+	}
+	entries, err := repositoryIndexEntries(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var divergences []DBDivergence
+	for repoName, repoEntries := range entries {
+		recorded, ok := state.Repositories[repoName]
+		switch {
+		case !ok:
+			divergences = append(divergences, DBDivergence{Repository: repoName, Reason: "index entries added since the last run"})
+		case recorded != digestIndexEntries(repoEntries):
+			divergences = append(divergences, DBDivergence{Repository: repoName, Reason: "index entries changed since the last run"})
+		}
+
+		for _, entry := range repoEntries {
+			storedPath := versionPath(filepath.Join(dbPath, filepath.FromSlash(entry.Folder)), entry.Hash)
+			content, err := os.ReadFile(storedPath)
+			switch {
+			case os.IsNotExist(err):
+				if !state.Slim {
+					divergences = append(divergences, DBDivergence{Repository: repoName, Reason: fmt.Sprintf("stored version %s of %s is missing", entry.Hash, entry.Folder)})
+				}
+			case err != nil:
+				return nil, err
+			case computeHash(content) != entry.Hash:
+				divergences = append(divergences, DBDivergence{Repository: repoName, Reason: fmt.Sprintf("stored version %s of %s does not match its hash", entry.Hash, entry.Folder), Path: storedPath})
+			}
+		}
+	}
+	for repoName := range state.Repositories {
+		if _, ok := entries[repoName]; !ok {
+			divergences = append(divergences, DBDivergence{Repository: repoName, Reason: "index entries removed since the last run"})
+		}
+	}
+
+	sort.SliceStable(divergences, func(i, j int) bool {
+		if divergences[i].Repository != divergences[j].Repository {
+			return divergences[i].Repository < divergences[j].Repository
+		}
+		return divergences[i].Reason < divergences[j].Reason
+	})
+	return divergences, nil
+}
+
+// divergedRepositories returns the names of the repositories with divergences.
+func divergedRepositories(divergences []DBDivergence) map[string]bool {
+	repos := make(map[string]bool)
+	for _, divergence := range divergences {
+		repos[divergence.Repository] = true
+	}
+	return repos
+}
+
+// removeCorruptVersions deletes the stored versions whose content does not match their hash, so that
+// scanning their repositories again stores them anew.
+func removeCorruptVersions(divergences []DBDivergence) error {
+	for _, divergence := range divergences {
+		if divergence.Path == "" {
+			continue
+		}
+		if err := os.Remove(divergence.Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// partitionDiverged splits repositories into those that diverged, which -repair scans again, and the names
+// of the rest, which keep their stored results.
+func partitionDiverged(repos []*github.Repository, diverged map[string]bool) ([]*github.Repository, map[string]bool) {
+	var scan []*github.Repository
+	skipped := make(map[string]bool)
+	for _, repo := range repos {
+		if diverged[repo.GetName()] {
+			scan = append(scan, repo)
+			continue
+		}
+		skipped[repo.GetName()] = true
+	}
+	return scan, skipped
+}
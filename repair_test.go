@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+)
+
+func TestCheckDBState(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	index := func(actionName, repoName, content string) string {
+		t.Helper()
+		hash := computeHash([]byte(content))
+		if err := storeActionVersion(dbPath, actionName, hash, content); err != nil {
+			t.Fatalf("storeActionVersion returned error: %v", err)
+		}
+		if err := updateActionIndex(dbPath, actionName, repoName, actionName, hash, computeBlobSHA([]byte(content))); err != nil {
+			t.Fatalf("updateActionIndex returned error: %v", err)
+		}
+		return hash
+	}
+	buildHash := index("build.yml", "repo-a", "on: push\njobs: {repair-a: {}}\n")
+	index("lint.yml", "repo-b", "on: pull_request\njobs: {repair-b: {}}\n")
+
+	// Without a recorded state nothing has diverged
+	divergences, err := checkDBState(dbPath)
+	if err != nil {
+		t.Fatalf("checkDBState returned error: %v", err)
+	}
+	if len(divergences) != 0 {
+		t.Fatalf("expected no divergences without a recorded state, got %+v", divergences)
+	}
+
+	if err := recordDBState(dbPath, false, time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("recordDBState returned error: %v", err)
+	}
+	divergences, err = checkDBState(dbPath)
+	if err != nil {
+		t.Fatalf("checkDBState returned error: %v", err)
+	}
+	if len(divergences) != 0 {
+		t.Fatalf("expected no divergences right after recording the state, got %+v", divergences)
+	}
+
+	// A hand edit of a stored version, an index entry changed by a force-push, and a repository whose
+	// entries were removed
+	corruptPath := versionPath(filepath.Join(dbPath, "workflows", "build.yml"), buildHash)
+	if err := os.WriteFile(corruptPath, []byte("on: push\njobs: {edited: {}}\n"), 0644); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	index("lint.yml", "repo-b", "on: pull_request\njobs: {repair-b-rewritten: {}}\n")
+	state, err := loadDBState(dbPath)
+	if err != nil {
+		t.Fatalf("loadDBState returned error: %v", err)
+	}
+	state.Repositories["repo-c"] = digestIndexEntries([]indexEntry{{Folder: "workflows/build.yml", Hash: buildHash}})
+	if err := writeYAMLFile(dbPath, dbStateFile, state); err != nil {
+		t.Fatalf("writeYAMLFile returned error: %v", err)
+	}
+
+	divergences, err = checkDBState(dbPath)
+	if err != nil {
+		t.Fatalf("checkDBState returned error: %v", err)
+	}
+	var got []string
+	for _, divergence := range divergences {
+		got = append(got, divergence.Repository+": "+divergence.Reason)
+	}
+	want := []string{
+		fmt.Sprintf("repo-a: stored version %s of workflows/build.yml does not match its hash", buildHash),
+		"repo-b: index entries changed since the last run",
+		"repo-c: index entries removed since the last run",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected divergences:\n%s", strings.Join(got, "\n"))
+	}
+
+	// Repair removes the corrupt version so that scanning repo-a again stores it anew
+	if err := removeCorruptVersions(divergences); err != nil {
+		t.Fatalf("removeCorruptVersions returned error: %v", err)
+	}
+	if _, err := os.Stat(corruptPath); !os.IsNotExist(err) {
+		t.Fatalf("expected the corrupt version to be removed, got %v", err)
+	}
+
+	repos := []*github.Repository{{Name: github.String("repo-a")}, {Name: github.String("repo-b")}, {Name: github.String("repo-d")}}
+	scan, skipped := partitionDiverged(repos, divergedRepositories(divergences))
+	if len(scan) != 2 || scan[0].GetName() != "repo-a" || scan[1].GetName() != "repo-b" || len(skipped) != 1 || !skipped["repo-d"] {
+		t.Fatalf("expected only the diverged repositories to be scanned, got %v and %v", scan, skipped)
+	}
+}
+
+func TestCheckDBStateSlim(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	content := "on: push\njobs: {repair-slim: {}}\n"
+	if err := updateActionIndex(dbPath, "build.yml", "repo-a", "build.yml", computeHash([]byte(content)), computeBlobSHA([]byte(content))); err != nil {
+		t.Fatalf("updateActionIndex returned error: %v", err)
+	}
+
+	// A slim database has no stored versions, which is only a divergence when the state was not slim
+	for _, slim := range []bool{true, false} {
+		if err := recordDBState(dbPath, slim, time.Now()); err != nil {
+			t.Fatalf("recordDBState returned error: %v", err)
+		}
+		divergences, err := checkDBState(dbPath)
+		if err != nil {
+			t.Fatalf("checkDBState returned error: %v", err)
+		}
+		if slim != (len(divergences) == 0) {
+			t.Fatalf("unexpected divergences with slim %v: %+v", slim, divergences)
+		}
+	}
+}
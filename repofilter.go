@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v50/github"
+)
+
+// ------------------------
+// Section: Repository Filters
+// ------------------------
+
+// repositoryPattern is a -include or -exclude entry: a glob such as sandbox-*, or a regular expression
+// between slashes such as /^tmp-[0-9]+$/.
+type repositoryPattern struct {
+	glob string // Lower-cased, as repository names are case-insensitive
+	re   *regexp.Regexp
+}
+
+// matches reports whether a repository name matches the pattern.
+func (p repositoryPattern) matches(name string) bool {
+	if p.re != nil {
+		return p.re.MatchString(name)
+	}
+	matched, _ := path.Match(p.glob, strings.ToLower(name))
+	return matched
+}
+
+// RepositoryFilter selects repositories by name with -include and -exclude, and skips archived repositories
+// and forks with -skip-archived and -skip-forks. A nil filter is the default: every repository except archived ones.
+type RepositoryFilter struct {
+	include      []repositoryPattern
+	exclude      []repositoryPattern
+	skipArchived bool
+	skipForks    bool
+}
+
+// newRepositoryFilter parses the comma-separated patterns of -include and -exclude, returning nil for the
+// default selection.
+func newRepositoryFilter(include, exclude string, skipArchived, skipForks bool) (*RepositoryFilter, error) {
+	includePatterns, err := parseRepositoryPatterns(include)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -include: %v", err)
+	}
+	excludePatterns, err := parseRepositoryPatterns(exclude)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -exclude: %v", err)
+	}
+	if len(includePatterns) == 0 && len(excludePatterns) == 0 && skipArchived && !skipForks {
+		return nil, nil
+	}
+	return &RepositoryFilter{include: includePatterns, exclude: excludePatterns, skipArchived: skipArchived, skipForks: skipForks}, nil
+}
+
+// parseRepositoryPatterns parses comma-separated globs and /regular expressions/.
+func parseRepositoryPatterns(value string) ([]repositoryPattern, error) {
+	var patterns []repositoryPattern
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if len(entry) > 2 && strings.HasPrefix(entry, "/") && strings.HasSuffix(entry, "/") {
+			re, err := regexp.Compile(entry[1 : len(entry)-1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression '%s': %v", entry, err)
+			}
+			patterns = append(patterns, repositoryPattern{re: re})
+			continue
+		}
+		glob := strings.ToLower(entry)
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %v", entry, err)
+		}
+		patterns = append(patterns, repositoryPattern{glob: glob})
+	}
+	return patterns, nil
+}
+
+// selects reports whether a repository is scanned: it must not be skipped as archived or as a fork, and must
+// match an -include pattern, when there are any, and no -exclude pattern.
+func (f *RepositoryFilter) selects(repo *github.Repository) bool {
+	if f == nil {
+		return !repo.GetArchived()
+	}
+	if (f.skipArchived && repo.GetArchived()) || (f.skipForks && repo.GetFork()) {
+		return false
+	}
+	name := repo.GetName()
+	if len(f.include) > 0 && !matchesAny(f.include, name) {
+		return false
+	}
+	return !matchesAny(f.exclude, name)
+}
+
+// matchesAny reports whether a repository name matches any of the patterns.
+func matchesAny(patterns []repositoryPattern, name string) bool {
+	for _, pattern := range patterns {
+		if pattern.matches(name) {
+			return true
+		}
+	}
+	return false
+}
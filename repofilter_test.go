@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v50/github"
+)
+
+func TestRepositoryFilter(t *testing.T) {
+	t.Parallel()
+
+	filter, err := newRepositoryFilter("", "archived-*, sandbox-*,/^tmp-[0-9]+$/", true, false)
+	if err != nil {
+		t.Fatalf("newRepositoryFilter returned error: %v", err)
+	}
+	cases := map[string]bool{
+		"service-api":  true,
+		"Sandbox-Test": false,
+		"archived-old": false,
+		"tmp-42":       false,
+		"tmp-backup":   true,
+	}
+	for name, want := range cases {
+		if got := filter.selects(&github.Repository{Name: github.String(name)}); got != want {
+			t.Fatalf("selects(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	filter, err = newRepositoryFilter("service-*,/^lib-/", "service-legacy", true, false)
+	if err != nil {
+		t.Fatalf("newRepositoryFilter returned error: %v", err)
+	}
+	for name, want := range map[string]bool{"service-api": true, "lib-core": true, "service-legacy": false, "docs": false} {
+		if got := filter.selects(&github.Repository{Name: github.String(name)}); got != want {
+			t.Fatalf("selects(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	if filter, err := newRepositoryFilter(" , ", "", true, false); err != nil || filter != nil {
+		t.Fatalf("expected no filter for empty patterns, got %v, %v", filter, err)
+	}
+	archived := &github.Repository{Name: github.String("old"), Archived: github.Bool(true)}
+	fork := &github.Repository{Name: github.String("upstream-copy"), Fork: github.Bool(true)}
+	if (*RepositoryFilter)(nil).selects(archived) || !(*RepositoryFilter)(nil).selects(fork) {
+		t.Fatalf("expected a nil filter to skip archived repositories only")
+	}
+	filter, err = newRepositoryFilter("", "", false, true)
+	if err != nil {
+		t.Fatalf("newRepositoryFilter returned error: %v", err)
+	}
+	if !filter.selects(archived) || filter.selects(fork) {
+		t.Fatalf("expected archived repositories to be kept and forks skipped")
+	}
+
+	for _, tc := range []struct{ include, exclude, want string }{
+		{"[a-", "", "-include"},
+		{"", "/(unclosed/", "-exclude"},
+	} {
+		if _, err := newRepositoryFilter(tc.include, tc.exclude, true, false); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("expected an %s error, got %v", tc.want, err)
+		}
+	}
+}
+
+func TestFetchRepositoriesFiltered(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"name":"service-api","visibility":"public"},
+			{"name":"sandbox-demo","visibility":"public"},
+			{"name":"service-old","visibility":"public","archived":true},
+			{"name":"service-internal","visibility":"private"}]`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	filter, err := newRepositoryFilter("", "sandbox-*", true, false)
+	if err != nil {
+		t.Fatalf("newRepositoryFilter returned error: %v", err)
+	}
+	repos, err := fetchRepositories(client, "example-org", true, false, filter)
+	if err != nil {
+		t.Fatalf("fetchRepositories returned error: %v", err)
+	}
+	if len(repos) != 1 || repos[0].GetName() != "service-api" {
+		t.Fatalf("expected only service-api, got %v", repos)
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+)
+
+// ------------------------
+// Section: Report Generation
+// ------------------------
+
+// reportWorkers bounds how many report files are generated at the same time.
+var reportWorkers = runtime.NumCPU()
+
+// reportGenerator writes one group of report files to the database.
+type reportGenerator struct {
+	Name     string
+	Generate func() error
+}
+
+// runReportGenerators runs independent report generators in parallel and waits for all of them.
+// Generators must write to distinct files so that the output does not depend on scheduling.
+func runReportGenerators(generators []reportGenerator) {
+	forEachParallel(generators, func(generator reportGenerator) {
+		if err := generator.Generate(); err != nil {
+			logErrorf("Error generating %s: %v", generator.Name, err)
+		}
+	})
+}
+
+// forEachParallel calls fn for every item using up to reportWorkers goroutines and waits for them to finish.
+func forEachParallel[T any](items []T, fn func(T)) {
+	workers := min(max(reportWorkers, 1), len(items))
+	work := make(chan T)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range work {
+				fn(item)
+			}
+		}()
+	}
+	for _, item := range items {
+		work <- item
+	}
+	close(work)
+	wg.Wait()
+}
+
+// collectGarbage removes stored file versions that no repository uses anymore.
+func collectGarbage(dbPath string, dotfilesEnabled bool) {
+	// Perform garbage collection
+	if err := garbageCollect(dbPath); err != nil {
+		logErrorf("Error during garbage collection: %v", err)
+	}
+
+	if err := garbageCollectActionDefinitions(dbPath); err != nil {
+		logErrorf("Error during action definition garbage collection: %v", err)
+	}
+
+	if err := garbageCollectOrgGitHubFiles(dbPath); err != nil {
+		logErrorf("Error during workflow template garbage collection: %v", err)
+	}
+
+	// Perform dependabot garbage collection
+	if err := garbageCollectDependabot(dbPath); err != nil {
+		logErrorf("Error during dependabot garbage collection: %v", err)
+	}
+
+	if err := garbageCollectRenovate(dbPath); err != nil {
+		logErrorf("Error during Renovate garbage collection: %v", err)
+	}
+
+	if err := garbageCollectDotGitHub(dbPath); err != nil {
+		logErrorf("Error during .github file garbage collection: %v", err)
+	}
+
+	if dotfilesEnabled {
+		if err := garbageCollectDotfiles(dbPath); err != nil {
+			logErrorf("Error during configured dotfile garbage collection: %v", err)
+		}
+	}
+}
+
+// databaseReportGenerators returns the generators of the reports that are built only from the database.
+func databaseReportGenerators(dbPath, org string, dotfilesEnabled bool) []reportGenerator {
+	generators := []reportGenerator{
+		{Name: "README.md files", Generate: func() error { return generateReadmeFiles(dbPath, org) }},
+		{Name: "CHANGELOG.md files", Generate: func() error { return generateActionChangelogs(dbPath) }},
+		{Name: "action definition README.md files", Generate: func() error { return generateActionDefinitionReadmeFiles(dbPath, org) }},
+		{Name: ".github file README.md files", Generate: func() error { return generateDotGitHubReadmeFiles(dbPath, org) }},
+		{Name: "dependabot README.md files", Generate: func() error { return generateDependabotReadmeFiles(dbPath, org) }},
+		{Name: "DB summary README.md", Generate: func() error { return generateDBSummary(dbPath) }},
+		{Name: "compliance scorecard", Generate: func() error { return generateScorecard(dbPath) }},
+		{Name: "INCIDENTS.md", Generate: func() error { return generateIncidentsMarkdown(dbPath, org) }},
+		{Name: "IDENTITIES.md", Generate: func() error { return generateIdentitiesMarkdown(dbPath, org) }},
+		{Name: "SUPPRESSIONS.md", Generate: func() error { return generateSuppressionsMarkdown(dbPath, org) }},
+		{Name: "ENVIRONMENTS.md", Generate: func() error { return generateEnvironmentsMarkdown(dbPath, org) }},
+		{Name: "PERMISSIONS.md", Generate: func() error { return generateTokenPowerMarkdown(dbPath, org) }},
+		{Name: "ANNOTATIONS.md", Generate: func() error { return generateAnnotationsMarkdown(dbPath, org) }},
+		{Name: "CHECKS.md", Generate: func() error { return generateChecksIndex(dbPath, org) }},
+		{Name: "EXPOSURE.md", Generate: func() error { return generateExposureMarkdown(dbPath, org) }},
+		{Name: "DEPENDENCY_UPDATES.md", Generate: func() error { return generateDependencyUpdatesMarkdown(dbPath, org) }},
+		{Name: "TOOLCHAINS.md", Generate: func() error { return generateToolchainsMarkdown(dbPath, org) }},
+		{Name: "GITHUB_SCRIPT.md", Generate: func() error { return generateGitHubScriptInventory(dbPath, org) }},
+		{Name: "TEMPLATES.md", Generate: func() error { return generateTemplateAdoption(dbPath, org) }},
+		{Name: "reusable workflow callers", Generate: func() error { return generateReusableWorkflowCallers(dbPath, org) }},
+		{Name: "reports/unpinned.yaml", Generate: func() error { return generateUnpinnedReport(dbPath) }},
+		{Name: "REQUIRED_WORKFLOWS.md", Generate: func() error { return generateRequiredWorkflowsMarkdown(dbPath, org) }},
+		{Name: "repository pages", Generate: func() error { return generateRepositoryPages(dbPath, org) }},
+	}
+	if dotfilesEnabled {
+		generators = append(generators, reportGenerator{Name: "configured dotfile README.md files", Generate: func() error { return generateDotfileReadmeFiles(dbPath, org) }})
+	}
+	return generators
+}
+
+// indexReportGenerators returns the generators of the reports built from a uses index and findings.
+// actionMetadata is the marketplace metadata recorded in action_metadata.yaml.
+func indexReportGenerators(dbPath, org string, usesIndex *ActionUsesIndex, findings []Finding, actionMetadata map[string]ActionMetadata, releaseCache *ReleaseCache) []reportGenerator {
+	return []reportGenerator{
+		{Name: "USES.md", Generate: func() error { return generateUSESMarkdown(dbPath, org, usesIndex, actionMetadata) }},
+		{Name: "FINDINGS.md", Generate: func() error { return generateFindingsMarkdown(dbPath, org, findings) }},
+		{Name: "RULES.md", Generate: func() error { return generateRulesMarkdown(dbPath) }},
+		{Name: "CONSOLIDATION.md", Generate: func() error { return generateConsolidationMarkdown(dbPath, org, usesIndex, time.Now()) }},
+		{Name: "actions.yaml", Generate: func() error { return writeActionsReverseIndex(dbPath, org, usesIndex) }},
+		{Name: "FREEZE.md", Generate: func() error { return generateFreezeMarkdown(dbPath, org, usesIndex) }},
+		{Name: "UPDATES.md", Generate: func() error { return generateUpdatesMarkdown(dbPath, buildActionUpdates(org, usesIndex, releaseCache)) }},
+	}
+}
+
+// regenerateReports rebuilds the reports of an existing database without scanning the organization.
+// Without a client only the reports built from the database are regenerated; with one, the reports built
+// from the uses index are too, fetching composite actions, marketplace metadata, and releases as a run does.
+func regenerateReports(client *github.Client, dbPath string) error {
+	manifest, err := loadRepositoryManifest(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to load repositories manifest: %v", err)
+	}
+	org := manifest.Organization
+	if org == "" {
+		return fmt.Errorf("repositories.yaml does not name an organization; run 'index' first")
+	}
+	dotfilesConfig, err := loadDotfilesConfig(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to load dotfiles config: %v", err)
+	}
+	dotfilesEnabled := dotfilesConfig != nil && len(dotfilesConfig.Dotfiles) > 0
+	// RULES.md shows the severity overrides
+	if err := loadSeverityOverrides(dbPath); err != nil {
+		return fmt.Errorf("failed to load severity overrides: %v", err)
+	}
+
+	runReportGenerators(databaseReportGenerators(dbPath, org, dotfilesEnabled))
+	if client == nil {
+		logInfof("Skipping the reports built from the uses index; pass -token to regenerate them.")
+		return nil
+	}
+
+	if err := loadDenylist(dbPath); err != nil {
+		return fmt.Errorf("failed to load denylist: %v", err)
+	}
+	if err := loadBudgets(dbPath); err != nil {
+		return fmt.Errorf("failed to load budgets: %v", err)
+	}
+	if err := loadEnvNaming(dbPath); err != nil {
+		return fmt.Errorf("failed to load env naming convention: %v", err)
+	}
+	if err := loadFreeze(dbPath); err != nil {
+		return fmt.Errorf("failed to load freeze: %v", err)
+	}
+	if err := loadAnnotationKeys(dbPath); err != nil {
+		return fmt.Errorf("failed to load annotation keys: %v", err)
+	}
+	repos := make(map[string]bool, len(manifest.Repositories))
+	for _, repoName := range manifest.Repositories {
+		repos[repoName] = true
+	}
+	usesIndex, findings, err := collectIndexedResults(dbPath, org, repos)
+	if err != nil {
+		return fmt.Errorf("failed to collect results: %v", err)
+	}
+
+	resolveCompositeDependencies(org, usesIndex, fetchActionDefinition(client))
+	actionMetadata := updateActionMetadataIndex(dbPath, org, usesIndex, fetchActionMetadata(client, org, usesIndex), time.Now())
+	releaseCache := updateReleaseCache(dbPath, org, usesIndex, fetchReleases(client), time.Now())
+	runReportGenerators(indexReportGenerators(dbPath, org, usesIndex, findings, actionMetadata.Actions, releaseCache))
+	return nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestForEachParallelVisitsEveryItem(t *testing.T) {
+	t.Parallel()
+
+	items := make([]int, 100)
+	for i := range items {
+		items[i] = i + 1
+	}
+
+	var sum atomic.Int64
+	forEachParallel(items, func(item int) {
+		sum.Add(int64(item))
+	})
+	if sum.Load() != 5050 {
+		t.Fatalf("expected every item to be visited once, got sum %d", sum.Load())
+	}
+
+	forEachParallel([]int{}, func(int) {
+		t.Fatalf("expected no calls for an empty slice")
+	})
+}
+
+func TestReadmeGenerationIsDeterministic(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	for i := 0; i < 20; i++ {
+		actionName := fmt.Sprintf("workflow-%02d.yml", i)
+		for r := 0; r < 10; r++ {
+			hash := fmt.Sprintf("hash-%d", r%3)
+			if err := updateActionIndex(dbPath, actionName, fmt.Sprintf("repo-%02d", r), actionName, hash, ""); err != nil {
+				t.Fatalf("updateActionIndex returned error: %v", err)
+			}
+		}
+	}
+
+	readAll := func() map[string]string {
+		contents := make(map[string]string)
+		matches, err := filepath.Glob(filepath.Join(dbPath, "workflows", "*", "README.md"))
+		if err != nil {
+			t.Fatalf("Glob returned error: %v", err)
+		}
+		for _, match := range matches {
+			data, err := os.ReadFile(match)
+			if err != nil {
+				t.Fatalf("ReadFile returned error: %v", err)
+			}
+			contents[match] = string(data)
+		}
+		return contents
+	}
+
+	if err := generateReadmeFiles(dbPath, "example-org"); err != nil {
+		t.Fatalf("generateReadmeFiles returned error: %v", err)
+	}
+	first := readAll()
+	if len(first) != 20 {
+		t.Fatalf("expected 20 README.md files, got %d", len(first))
+	}
+
+	if err := generateReadmeFiles(dbPath, "example-org"); err != nil {
+		t.Fatalf("generateReadmeFiles returned error: %v", err)
+	}
+	for path, content := range readAll() {
+		if first[path] != content {
+			t.Fatalf("README.md content changed between runs for %s", path)
+		}
+	}
+}
+
+func TestReadmeLinksFollowWorkflowSource(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dbPath, "repositories.yaml"), []byte("organization: example-org\nrepositories:\n  - repo-a\n  - repo-b\n"), 0644); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	if err := updateRepositoriesManifest(dbPath, "repo-a", "master"); err != nil {
+		t.Fatalf("updateRepositoriesManifest returned error: %v", err)
+	}
+	for _, repo := range []string{"repo-a", "repo-b"} {
+		if err := updateActionIndex(dbPath, "deploy.yml", repo, "deploy.yml", "hash-one", ""); err != nil {
+			t.Fatalf("updateActionIndex returned error: %v", err)
+		}
+	}
+	// repo-a keeps the workflow in a directory given with -paths
+	workflow := WorkflowFile{RepoName: "repo-a", FilePath: "ci/deploy.yml"}
+	if err := updateWorkflowSource(dbPath, "deploy.yml", "repo-a", workflow.source()); err != nil {
+		t.Fatalf("updateWorkflowSource returned error: %v", err)
+	}
+
+	if err := generateReadmeFiles(dbPath, "example-org"); err != nil {
+		t.Fatalf("generateReadmeFiles returned error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dbPath, "workflows", "deploy.yml", "README.md"))
+	if err != nil {
+		t.Fatalf("failed to read README.md: %v", err)
+	}
+	for _, want := range []string{
+		"- [repo-a](https://github.com/example-org/repo-a/blob/master/ci/deploy.yml)",
+		"- [repo-b](https://github.com/example-org/repo-b/blob/main/.github/workflows/deploy.yml)",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected README.md to contain %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestRegenerateReportsWithoutClient(t *testing.T) {
+	t.Parallel()
+
+	dbPath := writeQueryTestDB(t)
+	if err := regenerateReports(nil, dbPath); err != nil {
+		t.Fatalf("regenerateReports returned error: %v", err)
+	}
+	for _, name := range []string{"README.md", "ANNOTATIONS.md", filepath.Join("workflows", "build.yml", "README.md")} {
+		if _, err := os.Stat(filepath.Join(dbPath, name)); err != nil {
+			t.Fatalf("expected %s to be generated: %v", name, err)
+		}
+	}
+	// The reports built from the uses index need a client
+	if _, err := os.Stat(filepath.Join(dbPath, "USES.md")); !os.IsNotExist(err) {
+		t.Fatalf("expected USES.md not to be generated without a client, got %v", err)
+	}
+
+	if err := regenerateReports(nil, t.TempDir()); err == nil {
+		t.Fatalf("expected an error for a database without repositories.yaml")
+	}
+}
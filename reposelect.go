@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v50/github"
+)
+
+// ------------------------
+// Section: Repository Selection
+// ------------------------
+
+// repositoryList is the value of -repo, which may be given more than once or hold comma-separated names.
+type repositoryList []string
+
+func (l *repositoryList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *repositoryList) Set(value string) error {
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			*l = append(*l, name)
+		}
+	}
+	return nil
+}
+
+// readRepositoryFile reads repository names from a file, one per line, skipping blank lines and lines
+// starting with #.
+func readRepositoryFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read repository file: %v", err)
+	}
+	var names []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		names = append(names, line)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("repository file '%s' holds no repositories", path)
+	}
+	return names, nil
+}
+
+// parseRepositorySelection returns the sorted, distinct repository names of -repo and -repo-file entries,
+// given as owner/name or as a bare name. The owner must be the organization the database indexes.
+func parseRepositorySelection(org string, entries []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var names []string
+	for _, entry := range entries {
+		name := entry
+		if owner, repoName, ok := strings.Cut(entry, "/"); ok {
+			if !strings.EqualFold(owner, org) {
+				return nil, fmt.Errorf("repository '%s' is not in organization '%s'", entry, org)
+			}
+			name = repoName
+		}
+		if name == "" || strings.Contains(name, "/") {
+			return nil, fmt.Errorf("invalid repository '%s', expected owner/name or name", entry)
+		}
+		if !seen[strings.ToLower(name)] {
+			seen[strings.ToLower(name)] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// fetchSelectedRepositories fetches the named repositories of an organization instead of listing all of
+// them. Naming a repository overrides -include, -exclude, -skip-archived, and -skip-forks, but
+// repositories of an excluded visibility are still skipped.
+func fetchSelectedRepositories(client *github.Client, org string, names []string, includePub, includePrv bool) ([]*github.Repository, error) {
+	var repos []*github.Repository
+	for _, name := range names {
+		repo, _, err := client.Repositories.Get(context.Background(), org, name)
+		if err != nil {
+			if isNotFoundError(err) {
+				return nil, fmt.Errorf("repository '%s/%s' not found", org, name)
+			}
+			return nil, fmt.Errorf("failed to fetch repository '%s/%s': %v", org, name, err)
+		}
+		if !includeRepository(repo, includePub, includePrv, &RepositoryFilter{}) {
+			logDebugf("Skipping repository '%s' because its visibility is %s", repo.GetName(), repo.GetVisibility())
+			continue
+		}
+		repos = append(repos, repo)
+	}
+	logInfof("Indexing %d selected repositories", len(repos))
+	return repos, nil
+}
+
+// unselectedRepositories returns the repositories in the manifest other than the selected ones, which keep
+// their stored results.
+func unselectedRepositories(dbPath string, repos []*github.Repository) (map[string]bool, error) {
+	manifest, err := loadRepositoryManifest(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load repositories manifest: %v", err)
+	}
+	selected := make(map[string]bool)
+	for _, repo := range repos {
+		selected[repo.GetName()] = true
+	}
+	skipped := make(map[string]bool)
+	for _, repoName := range manifest.Repositories {
+		if !selected[repoName] {
+			skipped[repoName] = true
+		}
+	}
+	return skipped, nil
+}
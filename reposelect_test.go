@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v50/github"
+)
+
+func TestParseRepositorySelection(t *testing.T) {
+	t.Parallel()
+
+	var list repositoryList
+	list.Set("example-org/service-api, docs")
+	list.Set("Example-Org/cli")
+
+	path := filepath.Join(t.TempDir(), "repos.txt")
+	if err := os.WriteFile(path, []byte("# spot checks\nexample-org/docs\n\nbilling\n"), 0644); err != nil {
+		t.Fatalf("failed to write repository file: %v", err)
+	}
+	fileRepos, err := readRepositoryFile(path)
+	if err != nil {
+		t.Fatalf("readRepositoryFile returned error: %v", err)
+	}
+
+	names, err := parseRepositorySelection("example-org", append(list, fileRepos...))
+	if err != nil {
+		t.Fatalf("parseRepositorySelection returned error: %v", err)
+	}
+	if got := strings.Join(names, ","); got != "billing,cli,docs,service-api" {
+		t.Fatalf("unexpected selection: %s", got)
+	}
+
+	for _, entry := range []string{"other-org/service-api", "example-org/", "example-org/a/b"} {
+		if _, err := parseRepositorySelection("example-org", []string{entry}); err == nil {
+			t.Fatalf("expected an error for %q", entry)
+		}
+	}
+	if err := os.WriteFile(path, []byte("# nothing to scan\n"), 0644); err != nil {
+		t.Fatalf("failed to write repository file: %v", err)
+	}
+	if _, err := readRepositoryFile(path); err == nil {
+		t.Fatalf("expected an error for a repository file without repositories")
+	}
+}
+
+func TestFetchSelectedRepositories(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/example-org/service-api":
+			w.Write([]byte(`{"name":"service-api","visibility":"public","archived":true}`))
+		case "/repos/example-org/internal":
+			w.Write([]byte(`{"name":"internal","visibility":"private"}`))
+		default:
+			http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	// Archived repositories are indexed when named, but private ones need -private
+	repos, err := fetchSelectedRepositories(client, "example-org", []string{"internal", "service-api"}, true, false)
+	if err != nil {
+		t.Fatalf("fetchSelectedRepositories returned error: %v", err)
+	}
+	if len(repos) != 1 || repos[0].GetName() != "service-api" {
+		t.Fatalf("expected only service-api, got %v", repos)
+	}
+
+	if _, err := fetchSelectedRepositories(client, "example-org", []string{"missing"}, true, false); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+
+	dbPath := t.TempDir()
+	manifest := "organization: example-org\nrepositories:\n    - docs\n    - service-api\n"
+	if err := os.WriteFile(filepath.Join(dbPath, "repositories.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatalf("failed to write repositories.yaml: %v", err)
+	}
+	skipped, err := unselectedRepositories(dbPath, repos)
+	if err != nil {
+		t.Fatalf("unselectedRepositories returned error: %v", err)
+	}
+	if len(skipped) != 1 || !skipped["docs"] {
+		t.Fatalf("expected docs to keep its stored results, got %v", skipped)
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Reusable Workflow Callers
+// ------------------------
+
+// reusableDir is the folder of the database holding the callers of each reusable workflow.
+const reusableDir = "reusable"
+
+// ReusableWorkflowCaller is a workflow job that calls a reusable workflow.
+type ReusableWorkflowCaller struct {
+	Repository string `yaml:"repository" json:"repository"`
+	Workflow   string `yaml:"workflow" json:"workflow"`
+	Job        string `yaml:"job" json:"job"`
+	Ref        string `yaml:"ref,omitempty" json:"ref,omitempty"` // Empty for a local call, which runs the caller's own commit
+	Line       int    `yaml:"line" json:"line"`
+}
+
+// ReusableWorkflowCallers lists the jobs calling one reusable workflow of the organization. It is stored
+// as reusable/<repository>/<workflow file>/callers.yaml so that the repositories affected by a change to a
+// shared workflow are known before it is made.
+type ReusableWorkflowCallers struct {
+	Repository string                   `yaml:"repository"`
+	Workflow   string                   `yaml:"workflow"`
+	Callers    []ReusableWorkflowCaller `yaml:"callers"`
+}
+
+// parseReusableWorkflowCall splits the `uses` value of a job into the repository and workflow file it
+// calls and the reference. A local call such as ./.github/workflows/build.yml resolves to the calling
+// repository. ok is false for workflows outside the organization and values that do not name a workflow file.
+func parseReusableWorkflowCall(uses, org, callerRepo string) (repo, workflow, ref string, ok bool) {
+	if local, found := strings.CutPrefix(uses, "./.github/workflows/"); found {
+		if local == "" || strings.Contains(local, "/") {
+			return "", "", "", false
+		}
+		return callerRepo, local, "", true
+	}
+	target, ref, _ := strings.Cut(uses, "@")
+	owner, repo, ok := actionRepository(target)
+	if !ok || !strings.EqualFold(owner, org) {
+		return "", "", "", false
+	}
+	dir, workflow := path.Split(strings.TrimPrefix(target, owner+"/"+repo+"/"))
+	if dir != ".github/workflows/" || workflow == "" {
+		return "", "", "", false
+	}
+	return repo, workflow, ref, true
+}
+
+// buildReusableWorkflowCallers collects the callers of every reusable workflow of the organization from
+// the jobs of the indexed workflows, keyed by repository and then workflow file. Callers are sorted by
+// repository, workflow, then job.
+func buildReusableWorkflowCallers(dbPath, org string) (map[string]map[string][]ReusableWorkflowCaller, error) {
+	callers := make(map[string]map[string][]ReusableWorkflowCaller)
+	err := walkIndexedWorkflows(dbPath, func(fileName, repoName, content string) {
+		jobs, err := resolveWorkflowJobs(content)
+		if err != nil {
+			return
+		}
+		for _, job := range jobs {
+			if job.ReusableWorkflow == "" {
+				continue
+			}
+			repo, workflow, ref, ok := parseReusableWorkflowCall(job.ReusableWorkflow, org, repoName)
+			if !ok {
+				continue
+			}
+			if callers[repo] == nil {
+				callers[repo] = make(map[string][]ReusableWorkflowCaller)
+			}
+			callers[repo][workflow] = append(callers[repo][workflow], ReusableWorkflowCaller{
+				Repository: repoName,
+				Workflow:   ".github/workflows/" + fileName,
+				Job:        job.ID,
+				Ref:        ref,
+				Line:       job.Line,
+			})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, workflows := range callers {
+		for _, list := range workflows {
+			sort.Slice(list, func(i, j int) bool {
+				if list[i].Repository != list[j].Repository {
+					return list[i].Repository < list[j].Repository
+				}
+				if list[i].Workflow != list[j].Workflow {
+					return list[i].Workflow < list[j].Workflow
+				}
+				return list[i].Job < list[j].Job
+			})
+		}
+	}
+	return callers, nil
+}
+
+// generateReusableWorkflowCallers rewrites the reusable folder of the database with a callers.yaml for
+// each reusable workflow called from an indexed workflow, and a README.md listing them.
+func generateReusableWorkflowCallers(dbPath, org string) error {
+	callers, err := buildReusableWorkflowCallers(dbPath, org)
+	if err != nil {
+		return err
+	}
+
+	// Rewrite the folder so workflows that are no longer called do not linger
+	reusablePath := filepath.Join(dbPath, reusableDir)
+	if err := os.RemoveAll(reusablePath); err != nil {
+		return fmt.Errorf("failed to clear reusable directory: %v", err)
+	}
+	if err := os.MkdirAll(reusablePath, 0755); err != nil {
+		return fmt.Errorf("failed to create reusable directory: %v", err)
+	}
+
+	repos := make([]string, 0, len(callers))
+	for repo := range callers {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+
+	var markdownBuilder strings.Builder
+	markdownBuilder.WriteString("# Reusable Workflows\n\n")
+	markdownBuilder.WriteString(fmt.Sprintf("This document lists the reusable workflows of %s called from indexed workflows and the repositories calling them. Each workflow's callers.yaml lists the calling jobs with the reference they call.\n\n", org))
+	markdownBuilder.WriteString("| Reusable Workflow | Callers | Calling Repositories |\n")
+	markdownBuilder.WriteString("|-------------------|---------|----------------------|\n")
+
+	count := 0
+	for _, repo := range repos {
+		workflows := make([]string, 0, len(callers[repo]))
+		for workflow := range callers[repo] {
+			workflows = append(workflows, workflow)
+		}
+		sort.Strings(workflows)
+
+		for _, workflow := range workflows {
+			count++
+			list := callers[repo][workflow]
+			data, err := yaml.Marshal(ReusableWorkflowCallers{Repository: repo, Workflow: ".github/workflows/" + workflow, Callers: list})
+			if err != nil {
+				return fmt.Errorf("error marshaling callers of '%s/%s': %v", repo, workflow, err)
+			}
+			workflowPath := filepath.Join(reusablePath, repo, workflow)
+			if err := os.MkdirAll(workflowPath, 0755); err != nil {
+				return fmt.Errorf("failed to create directory for '%s/%s': %v", repo, workflow, err)
+			}
+			if err := os.WriteFile(filepath.Join(workflowPath, "callers.yaml"), data, 0644); err != nil {
+				return fmt.Errorf("error writing callers of '%s/%s': %v", repo, workflow, err)
+			}
+
+			var callingRepos []string
+			seen := make(map[string]bool)
+			for _, caller := range list {
+				if !seen[caller.Repository] {
+					seen[caller.Repository] = true
+					callingRepos = append(callingRepos, fmt.Sprintf("[%s](%s/%s/%s)", caller.Repository, githubWebURL, org, caller.Repository))
+				}
+			}
+			markdownBuilder.WriteString(fmt.Sprintf("| [%s/.github/workflows/%s](%s/callers.yaml) | %d | %s |\n",
+				repo, workflow, path.Join(repo, workflow), len(list), strings.Join(callingRepos, ", ")))
+		}
+	}
+	if count == 0 {
+		markdownBuilder.WriteString("| *No reusable workflows called* | - | - |\n")
+	}
+
+	markdownBuilder.WriteString("\n*This file is automatically generated after each data collection run.*\n")
+
+	if err := os.WriteFile(filepath.Join(reusablePath, "README.md"), []byte(markdownBuilder.String()), 0644); err != nil {
+		return fmt.Errorf("error writing reusable/README.md: %v", err)
+	}
+
+	logInfof("Generated callers of %d reusable workflows", count)
+	return nil
+}
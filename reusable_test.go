@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseReusableWorkflowCall(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		uses                string
+		repo, workflow, ref string
+		ok                  bool
+	}{
+		{"example-org/workflows/.github/workflows/release.yml@v1", "workflows", "release.yml", "v1", true},
+		{"Example-Org/workflows/.github/workflows/release.yml@main", "workflows", "release.yml", "main", true},
+		{"./.github/workflows/build.yml", "repo-a", "build.yml", "", true},
+		{"other-org/workflows/.github/workflows/release.yml@v1", "", "", "", false},
+		{"example-org/workflows/ci/release.yml@v1", "", "", "", false},
+		{"example-org/workflows/.github/workflows/nested/release.yml@v1", "", "", "", false},
+	}
+	for _, c := range cases {
+		repo, workflow, ref, ok := parseReusableWorkflowCall(c.uses, "example-org", "repo-a")
+		if repo != c.repo || workflow != c.workflow || ref != c.ref || ok != c.ok {
+			t.Fatalf("parseReusableWorkflowCall(%q) = %q, %q, %q, %v", c.uses, repo, workflow, ref, ok)
+		}
+	}
+}
+
+func TestGenerateReusableWorkflowCallers(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	workflows := map[string]map[string]string{
+		"deploy.yml": {
+			"repo-a": "on: push\njobs:\n  release:\n    uses: example-org/workflows/.github/workflows/release.yml@v1\n  lint:\n    uses: ./.github/workflows/lint.yml\n",
+			"repo-b": "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n  release:\n    uses: example-org/workflows/.github/workflows/release.yml@main\n  external:\n    uses: other-org/shared/.github/workflows/ci.yml@v2\n",
+		},
+	}
+	for fileName, repos := range workflows {
+		for repoName, content := range repos {
+			hash := computeHash([]byte(content))
+			if err := updateActionIndex(dbPath, fileName, repoName, fileName, hash, ""); err != nil {
+				t.Fatalf("updateActionIndex returned error: %v", err)
+			}
+			if err := storeActionVersion(dbPath, fileName, hash, content); err != nil {
+				t.Fatalf("storeActionVersion returned error: %v", err)
+			}
+		}
+	}
+
+	// A workflow no longer called is removed
+	stale := filepath.Join(dbPath, reusableDir, "workflows", "old.yml")
+	if err := os.MkdirAll(stale, 0755); err != nil {
+		t.Fatalf("failed to create stale folder: %v", err)
+	}
+
+	if err := generateReusableWorkflowCallers(dbPath, "example-org"); err != nil {
+		t.Fatalf("generateReusableWorkflowCallers returned error: %v", err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("expected the stale folder to be removed, got err=%v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dbPath, reusableDir, "workflows", "release.yml", "callers.yaml"))
+	if err != nil {
+		t.Fatalf("failed to read callers.yaml: %v", err)
+	}
+	want := "repository: workflows\nworkflow: .github/workflows/release.yml\ncallers:\n" +
+		"    - repository: repo-a\n      workflow: .github/workflows/deploy.yml\n      job: release\n      ref: v1\n      line: 3\n" +
+		"    - repository: repo-b\n      workflow: .github/workflows/deploy.yml\n      job: release\n      ref: main\n      line: 5\n"
+	if string(data) != want {
+		t.Fatalf("unexpected callers.yaml:\n%s", data)
+	}
+
+	if _, err := os.Stat(filepath.Join(dbPath, reusableDir, "repo-a", "lint.yml", "callers.yaml")); err != nil {
+		t.Fatalf("expected callers of the local workflow: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dbPath, reusableDir, "shared")); !os.IsNotExist(err) {
+		t.Fatalf("expected workflows outside the organization to be left out, got err=%v", err)
+	}
+
+	readme, err := os.ReadFile(filepath.Join(dbPath, reusableDir, "README.md"))
+	if err != nil {
+		t.Fatalf("failed to read README.md: %v", err)
+	}
+	if !strings.Contains(string(readme), "| [workflows/.github/workflows/release.yml](workflows/release.yml/callers.yaml) | 2 | [repo-a](https://github.com/example-org/repo-a), [repo-b](https://github.com/example-org/repo-b) |") {
+		t.Fatalf("unexpected README.md content:\n%s", readme)
+	}
+}
@@ -0,0 +1,408 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Action Review Queue
+// ------------------------
+
+// reviewQueueFile lists the third-party actions awaiting review, in the database directory.
+const reviewQueueFile = "new-actions.yaml"
+
+// allowlistFile lists the third-party actions approved with the approve command, in the database directory.
+const allowlistFile = "allowlist.yaml"
+
+// rejectedAdvisory is the advisory of the denylist entries added by the reject command.
+const rejectedAdvisory = "rejected in review"
+
+// QueuedAction is a third-party action the organization started using that has not been reviewed yet.
+type QueuedAction struct {
+	Action       string   `yaml:"action"`
+	FirstSeen    string   `yaml:"first_seen"`
+	Repositories []string `yaml:"repositories"`
+	Versions     []string `yaml:"versions"`
+}
+
+// ReviewQueue is the content of new-actions.yaml.
+type ReviewQueue struct {
+	Actions []QueuedAction `yaml:"actions"`
+}
+
+// ApprovedAction is a third-party action approved for use.
+type ApprovedAction struct {
+	Action   string `yaml:"action"`
+	Approved string `yaml:"approved"` // Date of the approval
+	Reviewer string `yaml:"reviewer,omitempty"`
+	Note     string `yaml:"note,omitempty"`
+}
+
+// Allowlist is the content of allowlist.yaml.
+type Allowlist struct {
+	Actions []ApprovedAction `yaml:"actions"`
+}
+
+// loadReviewQueue reads new-actions.yaml, returning an empty queue if it does not exist.
+func loadReviewQueue(dbPath string) (*ReviewQueue, error) {
+	queue := &ReviewQueue{}
+	data, err := os.ReadFile(filepath.Join(dbPath, reviewQueueFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return queue, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, queue); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", reviewQueueFile, err)
+	}
+	return queue, nil
+}
+
+// loadAllowlist reads allowlist.yaml, returning an empty allowlist if it does not exist.
+func loadAllowlist(dbPath string) (*Allowlist, error) {
+	allowlist := &Allowlist{}
+	data, err := os.ReadFile(filepath.Join(dbPath, allowlistFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return allowlist, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, allowlist); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", allowlistFile, err)
+	}
+	return allowlist, nil
+}
+
+// writeYAMLFile writes a value as YAML to a file of the database directory.
+func writeYAMLFile(dbPath, name string, value any) error {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dbPath, name), data, 0644)
+}
+
+// contains reports whether an action is on the allowlist.
+func (a *Allowlist) contains(action string) bool {
+	for _, approved := range a.Actions {
+		if strings.EqualFold(approved.Action, action) {
+			return true
+		}
+	}
+	return false
+}
+
+// index returns the position of an action in the queue, or -1 if it is not queued.
+func (q *ReviewQueue) index(action string) int {
+	for i, queued := range q.Actions {
+		if strings.EqualFold(queued.Action, action) {
+			return i
+		}
+	}
+	return -1
+}
+
+// remove takes an action off the queue, reporting whether it was queued.
+func (q *ReviewQueue) remove(action string) bool {
+	i := q.index(action)
+	if i < 0 {
+		return false
+	}
+	q.Actions = slices.Delete(q.Actions, i, i+1)
+	return true
+}
+
+// usedThirdPartyActions returns the sorted third-party actions of a uses index.
+func usedThirdPartyActions(org string, usesIndex *ActionUsesIndex) []string {
+	var actions []string
+	for actionName := range usesIndex.Actions {
+		if isThirdPartyAction(actionName, org) {
+			actions = append(actions, actionName)
+		}
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+// actionRepositories returns the sorted repositories whose workflows use an action.
+func actionRepositories(usesIndex *ActionUsesIndex, actionName string) []string {
+	var repos []string
+	for _, refs := range usesIndex.Actions[actionName] {
+		for _, ref := range refs {
+			repos = append(repos, ref.RepoName)
+		}
+	}
+	sort.Strings(repos)
+	return slices.Compact(repos)
+}
+
+// actionVersions returns the sorted versions of an action in use.
+func actionVersions(usesIndex *ActionUsesIndex, actionName string) []string {
+	var versions []string
+	for version := range usesIndex.Actions[actionName] {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// isRejectedAction reports whether every version of an action is on the denylist, as after 'reject'.
+func isRejectedAction(action string) bool {
+	for _, entry := range compromisedActions {
+		if strings.EqualFold(entry.Action, action) && slices.Contains(entry.Refs, "*") {
+			return true
+		}
+	}
+	return false
+}
+
+// updateReviewQueue adds the third-party actions that are new since the previous run's metrics snapshot
+// to new-actions.yaml, unless they are already approved or rejected, and refreshes where queued actions
+// are used. Without a previous snapshot there is no baseline, so nothing is queued. It returns the
+// actions it queued.
+func updateReviewQueue(dbPath, org string, usesIndex *ActionUsesIndex, now time.Time) ([]string, error) {
+	history, err := loadMetricsHistory(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	queue, err := loadReviewQueue(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	allowlist, err := loadAllowlist(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	used := usedThirdPartyActions(org, usesIndex)
+	for i := range queue.Actions {
+		queued := &queue.Actions[i]
+		if _, ok := usesIndex.Actions[queued.Action]; ok {
+			queued.Repositories = actionRepositories(usesIndex, queued.Action)
+			queued.Versions = actionVersions(usesIndex, queued.Action)
+		}
+	}
+
+	var added []string
+	if n := len(history.Snapshots); n > 0 {
+		for _, actionName := range setDifference(used, history.Snapshots[n-1].ThirdPartyActions) {
+			if queue.index(actionName) >= 0 || allowlist.contains(actionName) || isRejectedAction(actionName) {
+				continue
+			}
+			queue.Actions = append(queue.Actions, QueuedAction{
+				Action:       actionName,
+				FirstSeen:    formatReportDate(now),
+				Repositories: actionRepositories(usesIndex, actionName),
+				Versions:     actionVersions(usesIndex, actionName),
+			})
+			added = append(added, actionName)
+			logInfof("Queued new third-party action '%s' for review", actionName)
+		}
+	}
+
+	if err := writeReviewQueue(dbPath, queue); err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+// addDenylistEntry appends an entry to the database's denylist.yaml, keeping the rest of the file,
+// comments included, as it is.
+func addDenylistEntry(dbPath string, entry DenylistEntry) error {
+	denylistPath := filepath.Join(dbPath, "denylist.yaml")
+	data, err := os.ReadFile(denylistPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse denylist: %v", err)
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("failed to parse denylist: expected a mapping")
+	}
+
+	var actions *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "actions" {
+			actions = root.Content[i+1]
+		}
+	}
+	if actions == nil {
+		actions = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "actions"}, actions)
+	}
+	if actions.Kind != yaml.SequenceNode {
+		// An empty 'actions:' is a null scalar
+		*actions = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	}
+
+	var item yaml.Node
+	if err := item.Encode(entry); err != nil {
+		return err
+	}
+	actions.Content = append(actions.Content, &item)
+
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(denylistPath, out, 0644)
+}
+
+// approveActions moves actions from the review queue onto the allowlist. Actions that are not queued
+// are approved ahead of their first use.
+func approveActions(dbPath string, actions []string, reviewer, note string, now time.Time) error {
+	queue, err := loadReviewQueue(dbPath)
+	if err != nil {
+		return err
+	}
+	allowlist, err := loadAllowlist(dbPath)
+	if err != nil {
+		return err
+	}
+	for _, action := range actions {
+		if !queue.remove(action) {
+			logInfof("Action '%s' is not in the review queue; approving it ahead of use", action)
+		}
+		if allowlist.contains(action) {
+			logInfof("Action '%s' is already approved", action)
+			continue
+		}
+		allowlist.Actions = append(allowlist.Actions, ApprovedAction{Action: action, Approved: formatReportDate(now), Reviewer: reviewer, Note: note})
+		logInfof("Approved action '%s'", action)
+	}
+	sort.Slice(allowlist.Actions, func(i, j int) bool { return allowlist.Actions[i].Action < allowlist.Actions[j].Action })
+	if err := writeYAMLFile(dbPath, allowlistFile, allowlist); err != nil {
+		return err
+	}
+	return writeReviewQueue(dbPath, queue)
+}
+
+// rejectActions moves actions from the review queue onto the database's denylist, so that any use of
+// them is raised as a critical finding.
+func rejectActions(dbPath string, actions []string, note string) error {
+	queue, err := loadReviewQueue(dbPath)
+	if err != nil {
+		return err
+	}
+	if err := loadDenylist(dbPath); err != nil {
+		return fmt.Errorf("failed to load denylist: %v", err)
+	}
+	description := "Rejected in the review of new third-party actions."
+	if note != "" {
+		description = note
+	}
+	for _, action := range actions {
+		if !queue.remove(action) {
+			logInfof("Action '%s' is not in the review queue; rejecting it ahead of use", action)
+		}
+		if isRejectedAction(action) {
+			logInfof("Action '%s' is already denylisted", action)
+			continue
+		}
+		entry := DenylistEntry{Action: action, Advisory: rejectedAdvisory, Description: description, Refs: []string{"*"}}
+		if err := addDenylistEntry(dbPath, entry); err != nil {
+			return err
+		}
+		compromisedActions = append(compromisedActions, entry)
+		logInfof("Rejected action '%s'", action)
+	}
+	return writeReviewQueue(dbPath, queue)
+}
+
+// writeReviewQueue writes new-actions.yaml, removing it once the queue is empty.
+func writeReviewQueue(dbPath string, queue *ReviewQueue) error {
+	if len(queue.Actions) == 0 {
+		if err := os.Remove(filepath.Join(dbPath, reviewQueueFile)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return writeYAMLFile(dbPath, reviewQueueFile, queue)
+}
+
+// runReviewDecisionCommand runs the approve and reject commands, which record the review of queued actions.
+func runReviewDecisionCommand(command string, args []string) int {
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	decisionDB := fs.String("db", "./db", "Path to the database repository, or a git URL to clone, update, and push")
+	decisionToken := fs.String("token", "", "GitHub API token used to clone and push an HTTPS database URL")
+	baseURL := fs.String("base-url", "", "GitHub Enterprise Server API URL, e.g. https://github.example.com/api/v3; defaults to github.com")
+	uploadURL := fs.String("upload-url", "", "GitHub Enterprise Server upload URL; defaults to -base-url")
+	review := fs.Bool("review", false, "Push database changes to a new branch and open a pull request instead of pushing them directly; requires a git URL -db")
+	reviewer := fs.String("reviewer", "", "Name of the reviewer, recorded in allowlist.yaml")
+	note := fs.String("note", "", "Reason for the decision, recorded in allowlist.yaml or as the description of the denylist entry")
+	logFlags := addLogFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if err := applyEnvironmentDefaults(fs); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := logFlags.apply(); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	actions := fs.Args()
+	if len(actions) == 0 {
+		fmt.Printf("Usage: dotgithubindexer %s [-db <path or git URL>] [-token <token>] [-review] [-reviewer <name>] [-note <text>] <owner/action>...\n", command)
+		fs.PrintDefaults()
+		return 1
+	}
+	for _, action := range actions {
+		if _, _, ok := actionRepository(action); !ok {
+			logErrorf("Invalid action '%s', expected owner/repository", action)
+			return 1
+		}
+	}
+	if err := setGitHubServer(*baseURL, *uploadURL); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := checkReviewMode(*review, *decisionDB); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+
+	checkout, err := openDB(*decisionDB, *decisionToken)
+	if err != nil {
+		logErrorf("Failed to open database: %v", err)
+		return 1
+	}
+	defer checkout.Close()
+
+	now := time.Now()
+	verb := "Approve"
+	if command == "reject" {
+		verb = "Reject"
+		err = rejectActions(checkout.Dir, actions, *note)
+	} else {
+		err = approveActions(checkout.Dir, actions, *reviewer, *note, now)
+	}
+	if err != nil {
+		logErrorf("Failed to record review: %v", err)
+		return 1
+	}
+
+	if err := publishDB(checkout, fmt.Sprintf("%s %s (%s)", verb, strings.Join(actions, ", "), formatReportDate(now)), *review, *decisionToken); err != nil {
+		logErrorf("Failed to publish database: %v", err)
+		return 1
+	}
+	return 0
+}
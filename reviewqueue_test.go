@@ -0,0 +1,147 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUpdateReviewQueue(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	usesIndex := &ActionUsesIndex{Actions: map[string]map[string][]WorkflowReference{
+		"actions/checkout":        {"v4": {{RepoName: "repo-a", FilePath: ".github/workflows/build.yml"}}},
+		"example/new-action":      {"v1": {{RepoName: "repo-b", FilePath: ".github/workflows/build.yml"}}, "v2": {{RepoName: "repo-a", FilePath: ".github/workflows/lint.yml"}}},
+		"example/approved-action": {"v1": {{RepoName: "repo-a", FilePath: ".github/workflows/build.yml"}}},
+		"example-org/internal":    {"v1": {{RepoName: "repo-a", FilePath: ".github/workflows/build.yml"}}},
+	}}
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+
+	// Without a previous snapshot nothing is queued
+	added, err := updateReviewQueue(dbPath, "example-org", usesIndex, now)
+	if err != nil {
+		t.Fatalf("updateReviewQueue returned error: %v", err)
+	}
+	if len(added) != 0 {
+		t.Fatalf("expected nothing to be queued without a baseline, got %v", added)
+	}
+	if _, err := os.Stat(filepath.Join(dbPath, reviewQueueFile)); !os.IsNotExist(err) {
+		t.Fatalf("expected no review queue to be written, got %v", err)
+	}
+
+	history := &MetricsHistory{Snapshots: []MetricsSnapshot{{Date: "2026-03-01", ThirdPartyActions: []string{"actions/checkout"}}}}
+	if err := writeYAMLFile(dbPath, "metrics.yaml", history); err != nil {
+		t.Fatalf("writeYAMLFile returned error: %v", err)
+	}
+	if err := writeYAMLFile(dbPath, allowlistFile, &Allowlist{Actions: []ApprovedAction{{Action: "example/approved-action", Approved: "2026-02-01"}}}); err != nil {
+		t.Fatalf("writeYAMLFile returned error: %v", err)
+	}
+
+	added, err = updateReviewQueue(dbPath, "example-org", usesIndex, now)
+	if err != nil {
+		t.Fatalf("updateReviewQueue returned error: %v", err)
+	}
+	if len(added) != 1 || added[0] != "example/new-action" {
+		t.Fatalf("expected only the new unapproved action to be queued, got %v", added)
+	}
+	queue, err := loadReviewQueue(dbPath)
+	if err != nil {
+		t.Fatalf("loadReviewQueue returned error: %v", err)
+	}
+	queued := queue.Actions[0]
+	if len(queue.Actions) != 1 || queued.FirstSeen != "2026-03-02" || strings.Join(queued.Repositories, ",") != "repo-a,repo-b" || strings.Join(queued.Versions, ",") != "v1,v2" {
+		t.Fatalf("unexpected review queue: %+v", queue.Actions)
+	}
+
+	// A queued action is not queued again on the next run
+	added, err = updateReviewQueue(dbPath, "example-org", usesIndex, now.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("updateReviewQueue returned error: %v", err)
+	}
+	queue, _ = loadReviewQueue(dbPath)
+	if len(added) != 0 || len(queue.Actions) != 1 || queue.Actions[0].FirstSeen != "2026-03-02" {
+		t.Fatalf("expected the queue to be unchanged, got %v and %+v", added, queue.Actions)
+	}
+}
+
+func TestApproveActions(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	queue := &ReviewQueue{Actions: []QueuedAction{{Action: "example/new-action", FirstSeen: "2026-03-02", Repositories: []string{"repo-a"}, Versions: []string{"v1"}}}}
+	if err := writeReviewQueue(dbPath, queue); err != nil {
+		t.Fatalf("writeReviewQueue returned error: %v", err)
+	}
+
+	now := time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC)
+	if err := approveActions(dbPath, []string{"example/new-action", "example/future-action"}, "alex", "Vetted by security", now); err != nil {
+		t.Fatalf("approveActions returned error: %v", err)
+	}
+
+	allowlist, err := loadAllowlist(dbPath)
+	if err != nil {
+		t.Fatalf("loadAllowlist returned error: %v", err)
+	}
+	if len(allowlist.Actions) != 2 || allowlist.Actions[1].Action != "example/new-action" || allowlist.Actions[1].Approved != "2026-03-03" || allowlist.Actions[1].Reviewer != "alex" {
+		t.Fatalf("unexpected allowlist: %+v", allowlist.Actions)
+	}
+	if _, err := os.Stat(filepath.Join(dbPath, reviewQueueFile)); !os.IsNotExist(err) {
+		t.Fatalf("expected the emptied review queue to be removed, got %v", err)
+	}
+}
+
+// TestRejectActions mutates the denylist, so it does not run in parallel.
+func TestRejectActions(t *testing.T) {
+	previous := compromisedActions
+	defer func() { compromisedActions = previous }()
+
+	dbPath := t.TempDir()
+	denylist := "# Actions pulled after the March incident\nactions:\n  - action: example/compromised-action\n    advisory: GHSA-xxxx-xxxx-xxxx\n    refs:\n      - v1\n"
+	if err := os.WriteFile(filepath.Join(dbPath, "denylist.yaml"), []byte(denylist), 0644); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	queue := &ReviewQueue{Actions: []QueuedAction{
+		{Action: "example/new-action", FirstSeen: "2026-03-02"},
+		{Action: "example/other-action", FirstSeen: "2026-03-02"},
+	}}
+	if err := writeReviewQueue(dbPath, queue); err != nil {
+		t.Fatalf("writeReviewQueue returned error: %v", err)
+	}
+
+	if err := rejectActions(dbPath, []string{"example/new-action"}, ""); err != nil {
+		t.Fatalf("rejectActions returned error: %v", err)
+	}
+	// Rejecting again does not add a second entry
+	if err := rejectActions(dbPath, []string{"example/new-action"}, ""); err != nil {
+		t.Fatalf("rejectActions returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dbPath, "denylist.yaml"))
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+	if !strings.Contains(string(data), "# Actions pulled after the March incident") {
+		t.Fatalf("expected the denylist's comments to be kept, got:\n%s", data)
+	}
+	if err := loadDenylist(dbPath); err != nil {
+		t.Fatalf("loadDenylist returned error: %v", err)
+	}
+	entry := matchDenylist("example/new-action", "v3")
+	if entry == nil || entry.Advisory != rejectedAdvisory || strings.Count(string(data), "example/new-action") != 1 {
+		t.Fatalf("expected one denylist entry matching every version, got %+v in:\n%s", entry, data)
+	}
+	if matchDenylist("example/compromised-action", "v1") == nil {
+		t.Fatalf("expected the existing denylist entry to be kept")
+	}
+
+	remaining, err := loadReviewQueue(dbPath)
+	if err != nil {
+		t.Fatalf("loadReviewQueue returned error: %v", err)
+	}
+	if len(remaining.Actions) != 1 || remaining.Actions[0].Action != "example/other-action" {
+		t.Fatalf("unexpected review queue: %+v", remaining.Actions)
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Remediation Rollout
+// ------------------------
+
+// rolloutFile configures the waves in which remediation pull requests are opened, in the database directory.
+const rolloutFile = "rollout.yaml"
+
+// remediationFile tracks the remediation pull requests opened in each repository, in the database directory.
+const remediationFile = "remediation.yaml"
+
+// Remediation pull request states recorded in remediation.yaml.
+const (
+	remediationOpen   = "open"
+	remediationMerged = "merged"
+	remediationClosed = "closed" // Closed without merging; the repository is left out of later waves
+)
+
+// RolloutTier is a group of repositories that receive remediation pull requests before the tiers after it.
+type RolloutTier struct {
+	Name         string   `yaml:"name"`
+	Repositories []string `yaml:"repositories"` // Globs or /regular expressions/, as with -include
+	patterns     []repositoryPattern
+}
+
+// RolloutConfig is the content of rollout.yaml.
+type RolloutConfig struct {
+	WaveSize int           `yaml:"wave_size"` // Repositories per run; zero opens pull requests in all of them
+	Tiers    []RolloutTier `yaml:"tiers"`     // Repositories matching no tier come last
+}
+
+// readRolloutConfig parses rollout.yaml, returning an empty configuration when it does not exist.
+func readRolloutConfig(dbPath string) (*RolloutConfig, error) {
+	config := &RolloutConfig{}
+	data, err := os.ReadFile(filepath.Join(dbPath, rolloutFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", rolloutFile, err)
+	}
+	if config.WaveSize < 0 {
+		return nil, fmt.Errorf("invalid %s: wave_size must not be negative", rolloutFile)
+	}
+	for i := range config.Tiers {
+		tier := &config.Tiers[i]
+		for _, entry := range tier.Repositories {
+			patterns, err := parseRepositoryPatterns(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: tier '%s': %v", rolloutFile, tier.Name, err)
+			}
+			tier.patterns = append(tier.patterns, patterns...)
+		}
+	}
+	return config, nil
+}
+
+// tier returns the position of the first tier a repository matches, or the number of tiers when it matches none.
+func (c *RolloutConfig) tier(repoName string) int {
+	for i, tier := range c.Tiers {
+		if matchesAny(tier.patterns, repoName) {
+			return i
+		}
+	}
+	return len(c.Tiers)
+}
+
+// RemediationPullRequest is a remediation pull request opened by a rollout wave.
+type RemediationPullRequest struct {
+	Repository string `yaml:"repository"`
+	Branch     string `yaml:"branch"`
+	Number     int    `yaml:"number"`
+	URL        string `yaml:"url"`
+	Wave       int    `yaml:"wave"`
+	State      string `yaml:"state"`
+	Opened     string `yaml:"opened"`
+	Updated    string `yaml:"updated,omitempty"` // Date the state last changed
+}
+
+// RemediationTracking is the content of remediation.yaml.
+type RemediationTracking struct {
+	PullRequests []RemediationPullRequest `yaml:"pull_requests"`
+}
+
+// loadRemediationTracking reads remediation.yaml, returning an empty tracking file if it does not exist.
+func loadRemediationTracking(dbPath string) (*RemediationTracking, error) {
+	tracking := &RemediationTracking{}
+	data, err := os.ReadFile(filepath.Join(dbPath, remediationFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return tracking, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, tracking); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", remediationFile, err)
+	}
+	return tracking, nil
+}
+
+// nextWave returns the number of the next rollout wave.
+func (t *RemediationTracking) nextWave() int {
+	wave := 0
+	for _, pr := range t.PullRequests {
+		wave = max(wave, pr.Wave)
+	}
+	return wave + 1
+}
+
+// pending reports whether a repository has a remediation pull request on a branch that is still open, or
+// that was closed without merging, so that a wave should not open another one.
+func (t *RemediationTracking) pending(repoName, branch string) bool {
+	for _, pr := range t.PullRequests {
+		if pr.Repository == repoName && pr.Branch == branch && pr.State != remediationMerged {
+			return true
+		}
+	}
+	return false
+}
+
+// planRolloutWave returns the repositories of the next wave: those without a pending pull request on the
+// branch, ordered by tier and then by name, up to waveSize of them when it is positive.
+func planRolloutWave(repoNames []string, branch string, config *RolloutConfig, tracking *RemediationTracking, waveSize int) []string {
+	var candidates []string
+	for _, repoName := range repoNames {
+		if !tracking.pending(repoName, branch) {
+			candidates = append(candidates, repoName)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ti, tj := config.tier(candidates[i]), config.tier(candidates[j])
+		if ti != tj {
+			return ti < tj
+		}
+		return candidates[i] < candidates[j]
+	})
+	if waveSize > 0 && len(candidates) > waveSize {
+		candidates = candidates[:waveSize]
+	}
+	return candidates
+}
+
+// pullRequestStateFetcher returns whether a pull request is still open and whether it was merged.
+type pullRequestStateFetcher func(owner, repoName string, number int) (open, merged bool, err error)
+
+// branchDeleter deletes a branch of a repository.
+type branchDeleter func(owner, repoName, branch string) error
+
+// fetchPullRequestState reads the state of a pull request from the Pull Requests API.
+func fetchPullRequestState(client *github.Client) pullRequestStateFetcher {
+	return func(owner, repoName string, number int) (bool, bool, error) {
+		pr, _, err := client.PullRequests.Get(context.Background(), owner, repoName, number)
+		if err != nil {
+			return false, false, err
+		}
+		return pr.GetState() == "open", pr.GetMerged(), nil
+	}
+}
+
+// deleteBranch deletes a branch through the Git references API. A branch that is already gone, as when
+// the repository deletes head branches on merge, is not an error.
+func deleteBranch(client *github.Client) branchDeleter {
+	return func(owner, repoName, branch string) error {
+		resp, err := client.Git.DeleteRef(context.Background(), owner, repoName, "heads/"+branch)
+		if err != nil && resp != nil && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusUnprocessableEntity) {
+			return nil
+		}
+		return err
+	}
+}
+
+// followUpRemediations updates the state of the open remediation pull requests in remediation.yaml. The
+// branch of a merged pull request is deleted, so a later wave can open a new one in the repository when it
+// falls out of conformance again. It returns the number of pull requests whose state changed.
+func followUpRemediations(dbPath, org string, fetchState pullRequestStateFetcher, deleteBranch branchDeleter, now time.Time) (int, error) {
+	tracking, err := loadRemediationTracking(dbPath)
+	if err != nil {
+		return 0, err
+	}
+	changed := 0
+	for i := range tracking.PullRequests {
+		pr := &tracking.PullRequests[i]
+		if pr.State != remediationOpen {
+			continue
+		}
+		open, merged, err := fetchState(org, pr.Repository, pr.Number)
+		if err != nil {
+			logErrorf("Error fetching remediation pull request #%d in repository '%s': %v", pr.Number, pr.Repository, err)
+			continue
+		}
+		if open {
+			continue
+		}
+		pr.State = remediationClosed
+		if merged {
+			pr.State = remediationMerged
+			if err := deleteBranch(org, pr.Repository, pr.Branch); err != nil {
+				logErrorf("Error deleting branch '%s' in repository '%s': %v", pr.Branch, pr.Repository, err)
+			}
+		}
+		pr.Updated = formatReportDate(now)
+		logInfof("Remediation pull request #%d in repository '%s' was %s", pr.Number, pr.Repository, pr.State)
+		changed++
+	}
+	if changed == 0 {
+		return 0, nil
+	}
+	return changed, writeYAMLFile(dbPath, remediationFile, tracking)
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+)
+
+func TestReadRolloutConfig(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	config, err := readRolloutConfig(dbPath)
+	if err != nil || config.WaveSize != 0 || len(config.Tiers) != 0 {
+		t.Fatalf("expected an empty configuration without rollout.yaml, got %+v, %v", config, err)
+	}
+
+	content := "wave_size: 2\ntiers:\n  - name: canary\n    repositories: [sandbox-*, docs-site]\n  - name: services\n    repositories: [\"/-service$/\"]\n"
+	if err := os.WriteFile(filepath.Join(dbPath, rolloutFile), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write rollout.yaml: %v", err)
+	}
+	config, err = readRolloutConfig(dbPath)
+	if err != nil {
+		t.Fatalf("readRolloutConfig returned error: %v", err)
+	}
+	for repoName, want := range map[string]int{"sandbox-demo": 0, "docs-site": 0, "api-service": 1, "web-app": 2} {
+		if got := config.tier(repoName); got != want {
+			t.Fatalf("tier(%q) = %d, want %d", repoName, got, want)
+		}
+	}
+
+	for _, invalid := range []string{"wave_size: -1\n", "tiers:\n  - name: broken\n    repositories: [\"[a-\"]\n"} {
+		if err := os.WriteFile(filepath.Join(dbPath, rolloutFile), []byte(invalid), 0644); err != nil {
+			t.Fatalf("failed to write rollout.yaml: %v", err)
+		}
+		if _, err := readRolloutConfig(dbPath); err == nil {
+			t.Fatalf("expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestPlanRolloutWave(t *testing.T) {
+	t.Parallel()
+
+	config := &RolloutConfig{Tiers: []RolloutTier{
+		{Name: "canary", patterns: []repositoryPattern{{glob: "sandbox-*"}}},
+		{Name: "services", patterns: []repositoryPattern{{glob: "*-service"}}},
+	}}
+	tracking := &RemediationTracking{PullRequests: []RemediationPullRequest{
+		{Repository: "sandbox-a", Branch: freezeBranch, Wave: 1, State: remediationMerged},
+		{Repository: "sandbox-b", Branch: freezeBranch, Wave: 1, State: remediationOpen},
+		{Repository: "api-service", Branch: freezeBranch, Wave: 2, State: remediationClosed},
+		{Repository: "web-app", Branch: modernizeBranch, Wave: 2, State: remediationOpen},
+	}}
+	repoNames := []string{"web-app", "billing-service", "sandbox-a", "sandbox-b", "api-service", "cli-tool", "auth-service"}
+
+	got := planRolloutWave(repoNames, freezeBranch, config, tracking, 0)
+	want := []string{"sandbox-a", "auth-service", "billing-service", "cli-tool", "web-app"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("planRolloutWave() = %v, want %v", got, want)
+	}
+	if got := planRolloutWave(repoNames, freezeBranch, config, tracking, 2); strings.Join(got, ",") != "sandbox-a,auth-service" {
+		t.Fatalf("expected the first two repositories in a wave of 2, got %v", got)
+	}
+	if wave := tracking.nextWave(); wave != 3 {
+		t.Fatalf("nextWave() = %d, want 3", wave)
+	}
+}
+
+func TestFollowUpRemediations(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	tracking := &RemediationTracking{PullRequests: []RemediationPullRequest{
+		{Repository: "repo-a", Branch: freezeBranch, Number: 1, Wave: 1, State: remediationOpen, Opened: "2026-03-01"},
+		{Repository: "repo-b", Branch: freezeBranch, Number: 2, Wave: 1, State: remediationOpen, Opened: "2026-03-01"},
+		{Repository: "repo-c", Branch: freezeBranch, Number: 3, Wave: 1, State: remediationOpen, Opened: "2026-03-01"},
+		{Repository: "repo-d", Branch: freezeBranch, Number: 4, Wave: 1, State: remediationOpen, Opened: "2026-03-01"},
+		{Repository: "repo-e", Branch: freezeBranch, Number: 5, Wave: 1, State: remediationMerged, Opened: "2026-02-01", Updated: "2026-02-03"},
+	}}
+	if err := writeYAMLFile(dbPath, remediationFile, tracking); err != nil {
+		t.Fatalf("writeYAMLFile returned error: %v", err)
+	}
+
+	var fetched, deleted []string
+	fetchState := func(owner, repoName string, number int) (bool, bool, error) {
+		fetched = append(fetched, fmt.Sprintf("%s/%s#%d", owner, repoName, number))
+		switch repoName {
+		case "repo-a":
+			return false, true, nil
+		case "repo-b":
+			return false, false, nil
+		case "repo-c":
+			return true, false, nil
+		}
+		return false, false, fmt.Errorf("server error")
+	}
+	deleteBranch := func(owner, repoName, branch string) error {
+		deleted = append(deleted, repoName+":"+branch)
+		return nil
+	}
+
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)
+	changed, err := followUpRemediations(dbPath, "example-org", fetchState, deleteBranch, now)
+	if err != nil {
+		t.Fatalf("followUpRemediations returned error: %v", err)
+	}
+	if changed != 2 || len(fetched) != 4 || strings.Join(deleted, ",") != "repo-a:"+freezeBranch {
+		t.Fatalf("unexpected follow-up: changed %d, fetched %v, deleted %v", changed, fetched, deleted)
+	}
+
+	tracking, err = loadRemediationTracking(dbPath)
+	if err != nil {
+		t.Fatalf("loadRemediationTracking returned error: %v", err)
+	}
+	var states []string
+	for _, pr := range tracking.PullRequests {
+		states = append(states, pr.Repository+"="+pr.State+"@"+pr.Updated)
+	}
+	want := "repo-a=merged@2026-03-08,repo-b=closed@2026-03-08,repo-c=open@,repo-d=open@,repo-e=merged@2026-02-03"
+	if strings.Join(states, ",") != want {
+		t.Fatalf("unexpected states: %s", strings.Join(states, ","))
+	}
+
+	// Without a tracking file there is nothing to follow up
+	if changed, err := followUpRemediations(t.TempDir(), "example-org", fetchState, deleteBranch, now); err != nil || changed != 0 {
+		t.Fatalf("expected no follow-up without remediation.yaml, got %d, %v", changed, err)
+	}
+}
+
+func TestDeleteBranchAlreadyDeleted(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete || r.URL.Path != "/repos/example-org/repo-a/git/refs/heads/"+freezeBranch {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		http.Error(w, `{"message":"Reference does not exist"}`, http.StatusUnprocessableEntity)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	if err := deleteBranch(client)("example-org", "repo-a", freezeBranch); err != nil {
+		t.Fatalf("expected a branch that is already gone not to be an error, got %v", err)
+	}
+}
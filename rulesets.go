@@ -0,0 +1,354 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v50/github"
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Required Workflows
+// ------------------------
+
+// RulesetEnforcementActive is the enforcement level of rulesets that block merges, as opposed to
+// "evaluate" and "disabled".
+const RulesetEnforcementActive = "active"
+
+// Ruleset is an organization ruleset as returned by the API, keeping only the fields needed to find required workflows.
+type Ruleset struct {
+	ID          int64         `json:"id"`
+	Name        string        `json:"name"`
+	Enforcement string        `json:"enforcement"`
+	Rules       []RulesetRule `json:"rules"`
+}
+
+// RulesetRule is a single rule of a ruleset. Rules of type "workflows" list the workflows that must pass.
+type RulesetRule struct {
+	Type       string `json:"type"`
+	Parameters struct {
+		Workflows []RulesetWorkflowTarget `json:"workflows"`
+	} `json:"parameters"`
+}
+
+// RulesetWorkflowTarget is a workflow file that a "workflows" rule requires, identified by repository ID.
+type RulesetWorkflowTarget struct {
+	Path         string `json:"path"`
+	RepositoryID int64  `json:"repository_id"`
+	Ref          string `json:"ref"`
+	SHA          string `json:"sha"`
+}
+
+// RequiredWorkflow is a workflow file that an organization ruleset requires to pass, resolved against the index.
+type RequiredWorkflow struct {
+	Ruleset      string `yaml:"ruleset"`
+	RulesetID    int64  `yaml:"ruleset_id"`
+	Enforcement  string `yaml:"enforcement"`
+	RepositoryID int64  `yaml:"repository_id"`
+	Repository   string `yaml:"repository"` // Empty when the repository does not exist or is not visible to the token
+	Path         string `yaml:"path"`
+	Ref          string `yaml:"ref,omitempty"`
+	SHA          string `yaml:"sha,omitempty"`  // Commit the ruleset pins the workflow to, if any
+	Hash         string `yaml:"hash,omitempty"` // Stored version of the workflow in the index; empty when it is not indexed
+}
+
+// RequiredWorkflowsIndex is the contents of required_workflows.yaml, the snapshot of the workflows required
+// by organization rulesets that the next run is compared against.
+type RequiredWorkflowsIndex struct {
+	Organization string             `yaml:"organization"`
+	Workflows    []RequiredWorkflow `yaml:"workflows"`
+}
+
+// key identifies a required workflow across runs.
+func (w RequiredWorkflow) key() string {
+	return fmt.Sprintf("%d/%d/%s", w.RulesetID, w.RepositoryID, strings.ToLower(w.Path))
+}
+
+// fetchOrganizationRulesets retrieves the rulesets of an organization with their rules. It returns nil
+// when the rulesets cannot be read, as when the token lacks administration access to the organization.
+func fetchOrganizationRulesets(client *github.Client, org string) ([]Ruleset, error) {
+	ctx := context.Background()
+	var summaries []Ruleset
+	// The rulesets endpoints are not covered by the client library, so the requests are built directly
+	for page := 1; page != 0; {
+		req, err := client.NewRequest("GET", fmt.Sprintf("orgs/%s/rulesets?per_page=100&page=%d", org, page), nil)
+		if err != nil {
+			return nil, err
+		}
+		var batch []Ruleset
+		resp, err := client.Do(ctx, req, &batch)
+		if err != nil {
+			if errResp, ok := err.(*github.ErrorResponse); ok && errResp.Response != nil &&
+				(errResp.Response.StatusCode == http.StatusNotFound || errResp.Response.StatusCode == http.StatusForbidden) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		summaries = append(summaries, batch...)
+		page = resp.NextPage
+	}
+
+	// The list omits the rules, so each ruleset is fetched on its own
+	rulesets := make([]Ruleset, 0, len(summaries))
+	for _, summary := range summaries {
+		req, err := client.NewRequest("GET", fmt.Sprintf("orgs/%s/rulesets/%d", org, summary.ID), nil)
+		if err != nil {
+			return nil, err
+		}
+		var ruleset Ruleset
+		if _, err := client.Do(ctx, req, &ruleset); err != nil {
+			return nil, fmt.Errorf("failed to fetch ruleset '%s': %v", summary.Name, err)
+		}
+		rulesets = append(rulesets, ruleset)
+	}
+	return rulesets, nil
+}
+
+// resolveRequiredWorkflows lists the workflows required by the rulesets. repositoryName resolves a repository
+// ID to its name, returning an empty name for a repository that cannot be found, and indexedHashes maps
+// lowercase repository/path pairs to the stored version of each indexed workflow.
+func resolveRequiredWorkflows(rulesets []Ruleset, repositoryName func(int64) string, indexedHashes map[string]string) []RequiredWorkflow {
+	var workflows []RequiredWorkflow
+	for _, ruleset := range rulesets {
+		for _, rule := range ruleset.Rules {
+			if rule.Type != "workflows" {
+				continue
+			}
+			for _, target := range rule.Parameters.Workflows {
+				workflow := RequiredWorkflow{
+					Ruleset:      ruleset.Name,
+					RulesetID:    ruleset.ID,
+					Enforcement:  ruleset.Enforcement,
+					RepositoryID: target.RepositoryID,
+					Repository:   repositoryName(target.RepositoryID),
+					Path:         target.Path,
+					Ref:          target.Ref,
+					SHA:          target.SHA,
+				}
+				if workflow.Repository != "" {
+					workflow.Hash = indexedHashes[strings.ToLower(workflow.Repository+"/"+workflow.Path)]
+				}
+				workflows = append(workflows, workflow)
+			}
+		}
+	}
+
+	sort.Slice(workflows, func(i, j int) bool {
+		a, b := workflows[i], workflows[j]
+		if a.Ruleset != b.Ruleset {
+			return a.Ruleset < b.Ruleset
+		}
+		if a.Repository != b.Repository {
+			return a.Repository < b.Repository
+		}
+		return a.Path < b.Path
+	})
+	return workflows
+}
+
+// indexedWorkflowHashes maps the lowercase repository/path of every indexed workflow to its stored version.
+func indexedWorkflowHashes(dbPath string) (map[string]string, error) {
+	hashes := make(map[string]string)
+	err := walkIndexedWorkflows(dbPath, func(fileName, repoName, content string) {
+		hashes[strings.ToLower(repoName+"/.github/workflows/"+fileName)] = computeHash([]byte(content))
+	})
+	return hashes, err
+}
+
+// requiredWorkflowLabel names a required workflow target in messages.
+func requiredWorkflowLabel(workflow RequiredWorkflow) string {
+	repository := workflow.Repository
+	if repository == "" {
+		repository = fmt.Sprintf("repository %d", workflow.RepositoryID)
+	}
+	return repository + "/" + workflow.Path
+}
+
+// requiredWorkflowFindings reports the workflows required by active rulesets that are not in the index.
+// Every pull request covered by the ruleset fails while the target is missing.
+func requiredWorkflowFindings(org string, workflows []RequiredWorkflow) []Finding {
+	var findings []Finding
+	for _, workflow := range workflows {
+		if workflow.Enforcement != RulesetEnforcementActive || workflow.Hash != "" {
+			continue
+		}
+		reason := "is not in the index"
+		if workflow.Repository == "" {
+			reason = "is in a repository that no longer exists or is not visible to the token"
+		}
+		finding := newFinding("required-workflow-missing", workflow.Repository, workflow.Path, 0,
+			fmt.Sprintf("Ruleset '%s' requires %s, which %s", workflow.Ruleset, requiredWorkflowLabel(workflow), reason))
+		finding.URL = fmt.Sprintf("%s/organizations/%s/settings/rules/%d", githubWebURL, org, workflow.RulesetID)
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// diffRequiredWorkflows compares the required workflows against the previous snapshot and returns an event
+// for each workflow that changed, started being required, or stopped being required. Targets going missing
+// are reported as findings instead.
+func diffRequiredWorkflows(previous, current []RequiredWorkflow) []NotificationEvent {
+	before := make(map[string]RequiredWorkflow)
+	for _, workflow := range previous {
+		before[workflow.key()] = workflow
+	}
+
+	var events []NotificationEvent
+	for _, workflow := range current {
+		event := NotificationEvent{
+			Type:     EventRequiredWorkflow,
+			RepoName: workflow.Repository,
+			Subject:  requiredWorkflowLabel(workflow),
+		}
+		old, ok := before[workflow.key()]
+		delete(before, workflow.key())
+		switch {
+		case !ok:
+			event.Message = fmt.Sprintf("Now required by ruleset '%s' (%s)", workflow.Ruleset, workflow.Enforcement)
+		case old.Hash != "" && workflow.Hash != "" && old.Hash != workflow.Hash:
+			event.Message = fmt.Sprintf("Required by ruleset '%s'; changed from %s to %s", workflow.Ruleset, shortHash(old.Hash), shortHash(workflow.Hash))
+		default:
+			continue
+		}
+		events = append(events, event)
+	}
+
+	var removed []RequiredWorkflow
+	for _, workflow := range before {
+		removed = append(removed, workflow)
+	}
+	sort.Slice(removed, func(i, j int) bool { return removed[i].key() < removed[j].key() })
+	for _, workflow := range removed {
+		events = append(events, NotificationEvent{
+			Type:     EventRequiredWorkflow,
+			RepoName: workflow.Repository,
+			Subject:  requiredWorkflowLabel(workflow),
+			Message:  fmt.Sprintf("No longer required by ruleset '%s'", workflow.Ruleset),
+		})
+	}
+	return events
+}
+
+// loadRequiredWorkflows reads required_workflows.yaml, returning nil if rulesets have never been read.
+func loadRequiredWorkflows(dbPath string) (*RequiredWorkflowsIndex, error) {
+	data, err := os.ReadFile(filepath.Join(dbPath, "required_workflows.yaml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var index RequiredWorkflowsIndex
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse required_workflows.yaml: %v", err)
+	}
+	return &index, nil
+}
+
+// updateRequiredWorkflows snapshots the workflows required by the organization's rulesets to
+// required_workflows.yaml and returns the findings for missing targets and the events for changes since
+// the previous snapshot. The previous snapshot is kept when the rulesets cannot be read.
+func updateRequiredWorkflows(client *github.Client, dbPath, org string) ([]Finding, []NotificationEvent, error) {
+	rulesets, err := fetchOrganizationRulesets(client, org)
+	if err != nil {
+		return nil, nil, err
+	}
+	if rulesets == nil {
+		logWarnf("Organization rulesets are not readable with this token; skipping required workflows")
+		return nil, nil, nil
+	}
+
+	indexedHashes, err := indexedWorkflowHashes(dbPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	names := make(map[int64]string)
+	repositoryName := func(id int64) string {
+		if name, ok := names[id]; ok {
+			return name
+		}
+		repo, _, err := client.Repositories.GetByID(context.Background(), id)
+		if err != nil {
+			logErrorf("Error resolving repository %d required by a ruleset: %v", id, err)
+		}
+		names[id] = repo.GetName()
+		return names[id]
+	}
+	workflows := resolveRequiredWorkflows(rulesets, repositoryName, indexedHashes)
+
+	previous, err := loadRequiredWorkflows(dbPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	var events []NotificationEvent
+	if previous != nil {
+		events = diffRequiredWorkflows(previous.Workflows, workflows)
+	}
+
+	data, err := yaml.Marshal(RequiredWorkflowsIndex{Organization: org, Workflows: workflows})
+	if err != nil {
+		return nil, nil, fmt.Errorf("error marshaling required_workflows.yaml: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dbPath, "required_workflows.yaml"), data, 0644); err != nil {
+		return nil, nil, fmt.Errorf("error writing required_workflows.yaml: %v", err)
+	}
+
+	logInfof("Found %d workflows required by %d organization rulesets", len(workflows), len(rulesets))
+	return requiredWorkflowFindings(org, workflows), events, nil
+}
+
+// generateRequiredWorkflowsMarkdown writes REQUIRED_WORKFLOWS.md from required_workflows.yaml. Nothing is
+// written until the organization's rulesets have been read once.
+func generateRequiredWorkflowsMarkdown(dbPath, org string) error {
+	index, err := loadRequiredWorkflows(dbPath)
+	if err != nil || index == nil {
+		return err
+	}
+
+	var markdownBuilder strings.Builder
+	markdownBuilder.WriteString("# Required Workflows\n\n")
+	markdownBuilder.WriteString(fmt.Sprintf("This document lists the workflows that the rulesets of %s require to pass before a pull request can merge. A workflow that is not in the index blocks every pull request the ruleset covers while its ruleset is active. The stored version is the one on the default branch, which may differ from the ref the ruleset uses.\n\n", org))
+	markdownBuilder.WriteString("| Ruleset | Enforcement | Workflow | Ref | Stored Version |\n")
+	markdownBuilder.WriteString("|---------|-------------|----------|-----|----------------|\n")
+
+	missing := 0
+	for _, workflow := range index.Workflows {
+		ruleset := fmt.Sprintf("[%s](%s/organizations/%s/settings/rules/%d)", workflow.Ruleset, githubWebURL, org, workflow.RulesetID)
+		target := requiredWorkflowLabel(workflow)
+		if workflow.Repository != "" {
+			target = fmt.Sprintf("[%s](%s/%s/%s/blob/HEAD/%s)", target, githubWebURL, org, workflow.Repository, workflow.Path)
+		}
+		ref := "-"
+		if workflow.Ref != "" {
+			ref = "`" + workflow.Ref + "`"
+		}
+		if workflow.SHA != "" {
+			ref += " @ `" + shortHash(workflow.SHA) + "`"
+		}
+		version := "**Missing**"
+		if workflow.Hash != "" {
+			version = "`" + shortHash(workflow.Hash) + "`"
+		} else {
+			missing++
+		}
+		markdownBuilder.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n", ruleset, workflow.Enforcement, target, ref, version))
+	}
+	if len(index.Workflows) == 0 {
+		markdownBuilder.WriteString("| *No rulesets require workflows* | - | - | - | - |\n")
+	}
+
+	markdownBuilder.WriteString("\n*This file is automatically generated after each data collection run.*\n")
+
+	if err := os.WriteFile(filepath.Join(dbPath, "REQUIRED_WORKFLOWS.md"), []byte(markdownBuilder.String()), 0644); err != nil {
+		return fmt.Errorf("error writing REQUIRED_WORKFLOWS.md: %v", err)
+	}
+
+	logInfof("Generated REQUIRED_WORKFLOWS.md with %d required workflows, %d missing", len(index.Workflows), missing)
+	return nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v50/github"
+)
+
+func TestFetchOrganizationRulesets(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/orgs/example-org/rulesets":
+			fmt.Fprint(w, `[{"id":7,"name":"ci","enforcement":"active"}]`)
+		case "/orgs/example-org/rulesets/7":
+			fmt.Fprint(w, `{"id":7,"name":"ci","enforcement":"active","rules":[{"type":"deletion"},{"type":"workflows","parameters":{"workflows":[{"path":".github/workflows/required.yml","repository_id":42,"ref":"refs/heads/main"}]}}]}`)
+		default:
+			http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	rulesets, err := fetchOrganizationRulesets(client, "example-org")
+	if err != nil {
+		t.Fatalf("fetchOrganizationRulesets returned error: %v", err)
+	}
+	if len(rulesets) != 1 || len(rulesets[0].Rules) != 2 || rulesets[0].Rules[1].Parameters.Workflows[0].RepositoryID != 42 {
+		t.Fatalf("unexpected rulesets: %+v", rulesets)
+	}
+
+	unreadable, err := fetchOrganizationRulesets(client, "other-org")
+	if err != nil || unreadable != nil {
+		t.Fatalf("expected unreadable rulesets to be skipped, got %+v, %v", unreadable, err)
+	}
+}
+
+func TestResolveRequiredWorkflows(t *testing.T) {
+	t.Parallel()
+
+	var ruleset Ruleset
+	ruleset.ID, ruleset.Name, ruleset.Enforcement = 7, "ci", RulesetEnforcementActive
+	rule := RulesetRule{Type: "workflows"}
+	rule.Parameters.Workflows = []RulesetWorkflowTarget{
+		{Path: ".github/workflows/required.yml", RepositoryID: 42},
+		{Path: ".github/workflows/gone.yml", RepositoryID: 99},
+	}
+	ruleset.Rules = []RulesetRule{rule}
+
+	names := map[int64]string{42: "policies"}
+	hashes := map[string]string{"policies/.github/workflows/required.yml": "hash-one"}
+	workflows := resolveRequiredWorkflows([]Ruleset{ruleset}, func(id int64) string { return names[id] }, hashes)
+	if len(workflows) != 2 {
+		t.Fatalf("expected 2 required workflows, got %+v", workflows)
+	}
+	if workflows[0].Repository != "" || workflows[0].Hash != "" || workflows[1].Repository != "policies" || workflows[1].Hash != "hash-one" {
+		t.Fatalf("unexpected required workflows: %+v", workflows)
+	}
+
+	findings := requiredWorkflowFindings("example-org", workflows)
+	if len(findings) != 1 || findings[0].Rule != "required-workflow-missing" || !strings.Contains(findings[0].Message, "repository 99/.github/workflows/gone.yml") {
+		t.Fatalf("unexpected findings: %+v", findings)
+	}
+	if !strings.HasSuffix(findings[0].URL, "/organizations/example-org/settings/rules/7") {
+		t.Fatalf("unexpected finding URL: %s", findings[0].URL)
+	}
+
+	workflows[0].Enforcement = "evaluate"
+	if findings := requiredWorkflowFindings("example-org", workflows); len(findings) != 0 {
+		t.Fatalf("expected no findings for rulesets that are not enforced, got %+v", findings)
+	}
+}
+
+func TestDiffRequiredWorkflows(t *testing.T) {
+	t.Parallel()
+
+	previous := []RequiredWorkflow{
+		{Ruleset: "ci", RulesetID: 7, RepositoryID: 42, Repository: "policies", Path: ".github/workflows/required.yml", Hash: strings.Repeat("a", 64)},
+		{Ruleset: "ci", RulesetID: 7, RepositoryID: 42, Repository: "policies", Path: ".github/workflows/old.yml", Hash: strings.Repeat("c", 64)},
+	}
+	current := []RequiredWorkflow{
+		{Ruleset: "ci", RulesetID: 7, RepositoryID: 42, Repository: "policies", Path: ".github/workflows/required.yml", Hash: strings.Repeat("b", 64)},
+		{Ruleset: "ci", RulesetID: 7, RepositoryID: 42, Repository: "policies", Path: ".github/workflows/new.yml", Enforcement: "evaluate"},
+	}
+
+	events := diffRequiredWorkflows(previous, current)
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %+v", events)
+	}
+	if events[0].Type != EventRequiredWorkflow || events[0].Message != "Required by ruleset 'ci'; changed from aaaaaaaaaaaa to bbbbbbbbbbbb" {
+		t.Fatalf("unexpected change event: %+v", events[0])
+	}
+	if events[1].Subject != "policies/.github/workflows/new.yml" || events[1].Message != "Now required by ruleset 'ci' (evaluate)" {
+		t.Fatalf("unexpected added event: %+v", events[1])
+	}
+	if events[2].Subject != "policies/.github/workflows/old.yml" || events[2].Message != "No longer required by ruleset 'ci'" {
+		t.Fatalf("unexpected removed event: %+v", events[2])
+	}
+
+	// A target that goes missing is reported as a finding, not a change
+	current[0].Hash = ""
+	if events := diffRequiredWorkflows(previous[:1], current[:1]); len(events) != 0 {
+		t.Fatalf("expected no events for a missing target, got %+v", events)
+	}
+}
+
+func TestGenerateRequiredWorkflowsMarkdown(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	if err := generateRequiredWorkflowsMarkdown(dbPath, "example-org"); err != nil {
+		t.Fatalf("generateRequiredWorkflowsMarkdown returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dbPath, "REQUIRED_WORKFLOWS.md")); !os.IsNotExist(err) {
+		t.Fatalf("expected no report before rulesets are read, got err=%v", err)
+	}
+
+	snapshot := "organization: example-org\nworkflows:\n" +
+		"  - ruleset: ci\n    ruleset_id: 7\n    enforcement: active\n    repository_id: 42\n    repository: policies\n    path: .github/workflows/required.yml\n    ref: refs/heads/main\n"
+	if err := os.WriteFile(filepath.Join(dbPath, "required_workflows.yaml"), []byte(snapshot), 0644); err != nil {
+		t.Fatalf("failed to write required_workflows.yaml: %v", err)
+	}
+	if err := generateRequiredWorkflowsMarkdown(dbPath, "example-org"); err != nil {
+		t.Fatalf("generateRequiredWorkflowsMarkdown returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dbPath, "REQUIRED_WORKFLOWS.md"))
+	if err != nil {
+		t.Fatalf("failed to read REQUIRED_WORKFLOWS.md: %v", err)
+	}
+	content := string(data)
+	if !strings.Contains(content, "| [ci](https://github.com/organizations/example-org/settings/rules/7) | active | [policies/.github/workflows/required.yml](https://github.com/example-org/policies/blob/HEAD/.github/workflows/required.yml) | `refs/heads/main` | **Missing** |") {
+		t.Fatalf("unexpected REQUIRED_WORKFLOWS.md content:\n%s", content)
+	}
+}
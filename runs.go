@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Run History
+// ------------------------
+
+// runsFile is the append-only history of the runs that updated the database, in its root.
+const runsFile = "runs.yaml"
+
+// Outcomes of a recorded run.
+const (
+	RunCompleted = "completed"
+	RunStopped   = "stopped" // Stopped by a signal or -max-duration, with a checkpoint to resume from
+)
+
+// RunRecord is an entry of runs.yaml.
+type RunRecord struct {
+	Started             time.Time `yaml:"started" json:"started"`
+	Finished            time.Time `yaml:"finished" json:"finished"`
+	Command             string    `yaml:"command" json:"command"` // index or merge
+	Organization        string    `yaml:"organization" json:"organization"`
+	Version             string    `yaml:"version" json:"version"`
+	Outcome             string    `yaml:"outcome" json:"outcome"`
+	RepositoriesScanned int       `yaml:"repositories_scanned" json:"repositories_scanned"`
+	WorkflowsIndexed    int       `yaml:"workflows_indexed" json:"workflows_indexed"`
+	NewVersions         int       `yaml:"new_versions" json:"new_versions"`         // File versions stored for the first time
+	RemovedVersions     int       `yaml:"removed_versions" json:"removed_versions"` // Stored file versions removed by garbage collection
+	APIRequests         int64     `yaml:"api_requests" json:"api_requests"`         // Requests sent to the GitHub API, less those answered from -http-cache
+}
+
+// apiRequests counts the requests the GitHub API clients send.
+var apiRequests atomic.Int64
+
+// countingTransport counts the requests sent through it in apiRequests.
+type countingTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip counts the request and sends it.
+func (t countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	apiRequests.Add(1)
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
+
+// countedAPIRequests returns the API requests sent so far, leaving out the requests -http-cache answered
+// with a cached response after a 304, which do not count against the rate limit.
+func countedAPIRequests() int64 {
+	count := apiRequests.Load()
+	if httpResponseCache != nil {
+		revalidated, _ := httpResponseCache.stats()
+		count -= int64(revalidated)
+	}
+	return count
+}
+
+// RunTracker measures a run by what changed in the database and the counters since it started.
+type RunTracker struct {
+	command  string
+	dbPath   string
+	started  time.Time
+	versions map[string]bool
+	counter  *ScanCounter
+	scanned  int
+	indexed  int
+	requests int64
+}
+
+// startRunTracker records the state of a database at the start of a run. The counter, which may be nil,
+// counts the repositories and workflows the run scans.
+func startRunTracker(command, dbPath string, counter *ScanCounter) (*RunTracker, error) {
+	versions, err := listStoredVersions(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	tracker := &RunTracker{command: command, dbPath: dbPath, started: time.Now(), versions: versions, counter: counter, requests: countedAPIRequests()}
+	if counter != nil {
+		tracker.scanned, tracker.indexed = counter.Scanned(), counter.Workflows()
+	}
+	return tracker, nil
+}
+
+// finish builds the record of the run of an organization from the changes since it started. scanned, when
+// not negative, replaces the count of scanned repositories, for runs such as merges that do not scan.
+func (t *RunTracker) finish(org, outcome string, scanned int) (RunRecord, error) {
+	record := RunRecord{
+		Started:      t.started,
+		Finished:     time.Now(),
+		Command:      t.command,
+		Organization: org,
+		Version:      Version,
+		Outcome:      outcome,
+		APIRequests:  countedAPIRequests() - t.requests,
+	}
+	if t.counter != nil {
+		record.RepositoriesScanned = t.counter.Scanned() - t.scanned
+		record.WorkflowsIndexed = t.counter.Workflows() - t.indexed
+	}
+	if scanned >= 0 {
+		record.RepositoriesScanned = scanned
+	}
+
+	versions, err := listStoredVersions(t.dbPath)
+	if err != nil {
+		return record, err
+	}
+	for path := range versions {
+		if !t.versions[path] {
+			record.NewVersions++
+		}
+	}
+	for path := range t.versions {
+		if !versions[path] {
+			record.RemovedVersions++
+		}
+	}
+	return record, nil
+}
+
+// record appends the record of the run to runs.yaml. A failure to record is printed rather than
+// failing the run, whose results are already in the database.
+func (t *RunTracker) record(org, outcome string, scanned int) {
+	record, err := t.finish(org, outcome, scanned)
+	if err == nil {
+		err = appendRunRecord(t.dbPath, record)
+	}
+	if err != nil {
+		logErrorf("Error recording the run in %s: %v", runsFile, err)
+		return
+	}
+	logInfof("Recorded the run in '%s': %d repositories scanned, %d workflows indexed, %d new versions, %d versions removed, %d API requests", runsFile, record.RepositoriesScanned, record.WorkflowsIndexed, record.NewVersions, record.RemovedVersions, record.APIRequests)
+}
+
+// listStoredVersions returns the paths, relative to the database, of the stored file versions, which are
+// named by the SHA-256 hash of their content.
+func listStoredVersions(dbPath string) (map[string]bool, error) {
+	versions := make(map[string]bool)
+	err := filepath.WalkDir(dbPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dbPath {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if entry.IsDir() {
+			if entry.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if storedVersionRe.MatchString(entry.Name()) {
+			rel, err := filepath.Rel(dbPath, path)
+			if err != nil {
+				return err
+			}
+			versions[filepath.ToSlash(rel)] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stored versions: %v", err)
+	}
+	return versions, nil
+}
+
+// appendRunRecord appends a record to runs.yaml, which is a YAML sequence so that each run adds lines to
+// the end of the file without rewriting the entries before it.
+func appendRunRecord(dbPath string, record RunRecord) error {
+	data, err := yaml.Marshal([]RunRecord{record})
+	if err != nil {
+		return err
+	}
+	runsPath := filepath.Join(dbPath, runsFile)
+	file, err := os.OpenFile(runsPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	// The JSON copies were written with the reports, before the run was recorded
+	if dbFormat == formatJSON {
+		return writeJSONCopy(runsPath)
+	}
+	return nil
+}
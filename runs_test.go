@@ -0,0 +1,104 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestAppendRunRecord(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	started := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, org := range []string{"org-a", "org-b"} {
+		record := RunRecord{Started: started.Add(time.Duration(i) * time.Hour), Command: "index", Organization: org, Outcome: RunCompleted, RepositoriesScanned: 3}
+		if err := appendRunRecord(dbPath, record); err != nil {
+			t.Fatalf("appendRunRecord returned error: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dbPath, runsFile))
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", runsFile, err)
+	}
+	// Each run adds an item to the sequence
+	if strings.Count(string(data), "- started:") != 2 {
+		t.Fatalf("unexpected %s:\n%s", runsFile, data)
+	}
+	var runs []RunRecord
+	if err := yaml.Unmarshal(data, &runs); err != nil {
+		t.Fatalf("failed to parse %s: %v", runsFile, err)
+	}
+	if len(runs) != 2 || runs[0].Organization != "org-a" || runs[1].Organization != "org-b" || !runs[1].Started.Equal(started.Add(time.Hour)) {
+		t.Fatalf("unexpected runs: %+v", runs)
+	}
+}
+
+func TestRunTracker(t *testing.T) {
+	dbPath := t.TempDir()
+	oldHash, newHash := strings.Repeat("a", 64), strings.Repeat("b", 64)
+	dir := filepath.Join(dbPath, "workflows", "build.yml")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("MkdirAll returned error: %v", err)
+	}
+	for _, name := range []string{oldHash, "index.yaml"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("jobs: {}\n"), 0644); err != nil {
+			t.Fatalf("WriteFile returned error: %v", err)
+		}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	client := &http.Client{Transport: countingTransport{}}
+
+	var counter ScanCounter
+	emit := counter.Observe(nil)
+	// Events before the run started are not counted
+	emit(ScanEvent{Type: ScanEventRepoStarted, Repository: "repo-z", Attempt: 1})
+
+	tracker, err := startRunTracker("index", dbPath, &counter)
+	if err != nil {
+		t.Fatalf("startRunTracker returned error: %v", err)
+	}
+	emit(ScanEvent{Type: ScanEventRepoStarted, Repository: "repo-a", Attempt: 1})
+	emit(ScanEvent{Type: ScanEventWorkflowIndexed, Repository: "repo-a", Workflow: ".github/workflows/build.yml", Hash: newHash})
+	for range 2 {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+	if err := os.WriteFile(filepath.Join(dir, newHash), []byte("jobs: {}\n"), 0644); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	if err := os.Remove(filepath.Join(dir, oldHash)); err != nil {
+		t.Fatalf("Remove returned error: %v", err)
+	}
+
+	record, err := tracker.finish("example-org", RunCompleted, -1)
+	if err != nil {
+		t.Fatalf("finish returned error: %v", err)
+	}
+	if record.Organization != "example-org" || record.Command != "index" || record.RepositoriesScanned != 1 || record.WorkflowsIndexed != 1 ||
+		record.NewVersions != 1 || record.RemovedVersions != 1 || record.APIRequests != 2 {
+		t.Fatalf("unexpected record: %+v", record)
+	}
+
+	// A merge counts the repositories of its shards instead
+	if record, err := tracker.finish("example-org", RunCompleted, 5); err != nil || record.RepositoriesScanned != 5 {
+		t.Fatalf("unexpected record: %+v, %v", record, err)
+	}
+
+	// A database that does not exist yet has no stored versions
+	if versions, err := listStoredVersions(filepath.Join(dbPath, "missing")); err != nil || len(versions) != 0 {
+		t.Fatalf("expected no stored versions, got %v, %v", versions, err)
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Sanitized Public Report
+// ------------------------
+
+// pseudonymizer replaces names with stable pseudonyms derived from a secret salt.
+type pseudonymizer struct {
+	key []byte
+}
+
+// newPseudonymizer creates a pseudonymizer. Without a salt a random one is used, so pseudonyms
+// cannot be linked across reports.
+func newPseudonymizer(salt string) (*pseudonymizer, error) {
+	key := []byte(salt)
+	if salt == "" {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate salt: %v", err)
+		}
+	}
+	return &pseudonymizer{key: key}, nil
+}
+
+// name returns the pseudonym for a value, such as repo-1a2b3c4d5e.
+func (p *pseudonymizer) name(prefix, value string) string {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(value))
+	return prefix + "-" + hex.EncodeToString(mac.Sum(nil))[:10]
+}
+
+// SanitizedActionStats counts the use of one third-party action across the organization.
+type SanitizedActionStats struct {
+	Action       string `json:"action"`
+	Repositories int    `json:"repositories"`
+	Uses         int    `json:"uses"`
+	PinnedUses   int    `json:"pinned_uses"`
+}
+
+// SanitizedRuleStats counts the findings of one rule across the organization.
+type SanitizedRuleStats struct {
+	Rule         string `json:"rule"`
+	Severity     string `json:"severity"`
+	Findings     int    `json:"findings"`
+	Repositories int    `json:"repositories"`
+}
+
+// SanitizedRepoStats summarizes a repository under its pseudonym.
+type SanitizedRepoStats struct {
+	Pseudonym  string `json:"pseudonym"`
+	Workflows  int    `json:"workflows"`
+	Uses       int    `json:"uses"`
+	PinnedUses int    `json:"pinned_uses"`
+	Findings   int    `json:"findings"`
+}
+
+// SanitizedReport holds aggregate statistics that are safe to publish.
+type SanitizedReport struct {
+	Repositories      int                    `json:"repositories"`
+	Workflows         int                    `json:"workflows"`
+	TotalUses         int                    `json:"total_uses"`
+	PinnedUses        int                    `json:"pinned_uses"`
+	InternalUses      int                    `json:"internal_uses"` // Uses of local, docker, and organization-owned actions, which are not named
+	Actions           []SanitizedActionStats `json:"actions"`
+	Rules             []SanitizedRuleStats   `json:"rules"`
+	RepositoryDetails []SanitizedRepoStats   `json:"repository_details"`
+}
+
+// buildSanitizedReport computes aggregate statistics from the indexed workflows. Repository names are
+// replaced with pseudonyms and only third-party action names and rule IDs are kept.
+func buildSanitizedReport(dbPath string, names *pseudonymizer) (*SanitizedReport, error) {
+	var manifest RepositoryManifest
+	if data, err := os.ReadFile(filepath.Join(dbPath, "repositories.yaml")); err == nil {
+		if err := yaml.Unmarshal(data, &manifest); err != nil {
+			return nil, fmt.Errorf("failed to parse repositories.yaml: %v", err)
+		}
+	}
+	org := manifest.Organization
+
+	report := &SanitizedReport{Repositories: len(manifest.Repositories)}
+	repoStats := make(map[string]*SanitizedRepoStats)
+	for _, repoName := range manifest.Repositories {
+		repoStats[repoName] = &SanitizedRepoStats{Pseudonym: names.name("repo", repoName)}
+	}
+	actions := make(map[string]*SanitizedActionStats)
+	actionRepos := make(map[string]map[string]bool)
+	rules := make(map[string]*SanitizedRuleStats)
+	ruleRepos := make(map[string]map[string]bool)
+
+	err := walkIndexedWorkflows(dbPath, func(fileName, repoName, content string) {
+		stats, ok := repoStats[repoName]
+		if !ok {
+			stats = &SanitizedRepoStats{Pseudonym: names.name("repo", repoName)}
+			repoStats[repoName] = stats
+		}
+		stats.Workflows++
+		report.Workflows++
+
+		filePath := ".github/workflows/" + fileName
+		for _, use := range extractActionUses(content, repoName, filePath) {
+			pinned := isPinnedVersion(use.Version)
+			report.TotalUses++
+			stats.Uses++
+			if pinned {
+				report.PinnedUses++
+				stats.PinnedUses++
+			}
+
+			if !isThirdPartyAction(use.Action, org) {
+				report.InternalUses++
+				continue
+			}
+			action, ok := actions[use.Action]
+			if !ok {
+				action = &SanitizedActionStats{Action: use.Action}
+				actions[use.Action] = action
+				actionRepos[use.Action] = make(map[string]bool)
+			}
+			action.Uses++
+			if pinned {
+				action.PinnedUses++
+			}
+			actionRepos[use.Action][repoName] = true
+		}
+
+		for _, finding := range analyzeWorkflow(content, repoName, filePath) {
+			stats.Findings++
+			rule, ok := rules[finding.Rule]
+			if !ok {
+				rule = &SanitizedRuleStats{Rule: finding.Rule, Severity: finding.Severity}
+				rules[finding.Rule] = rule
+				ruleRepos[finding.Rule] = make(map[string]bool)
+			}
+			rule.Findings++
+			ruleRepos[finding.Rule][repoName] = true
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for name, action := range actions {
+		action.Repositories = len(actionRepos[name])
+		report.Actions = append(report.Actions, *action)
+	}
+	sort.Slice(report.Actions, func(i, j int) bool {
+		if report.Actions[i].Repositories != report.Actions[j].Repositories {
+			return report.Actions[i].Repositories > report.Actions[j].Repositories
+		}
+		return report.Actions[i].Action < report.Actions[j].Action
+	})
+
+	for id, rule := range rules {
+		rule.Repositories = len(ruleRepos[id])
+		report.Rules = append(report.Rules, *rule)
+	}
+	sort.Slice(report.Rules, func(i, j int) bool {
+		if report.Rules[i].Severity != report.Rules[j].Severity {
+			return severityRank[report.Rules[i].Severity] > severityRank[report.Rules[j].Severity]
+		}
+		return report.Rules[i].Rule < report.Rules[j].Rule
+	})
+
+	// Sorting by pseudonym rather than name keeps the order from revealing the names
+	for _, stats := range repoStats {
+		report.RepositoryDetails = append(report.RepositoryDetails, *stats)
+	}
+	sort.Slice(report.RepositoryDetails, func(i, j int) bool {
+		return report.RepositoryDetails[i].Pseudonym < report.RepositoryDetails[j].Pseudonym
+	})
+
+	return report, nil
+}
+
+// percent formats part as a percentage of total, treating an empty total as fully compliant.
+func percent(part, total int) string {
+	if total == 0 {
+		return "100.0%"
+	}
+	return fmt.Sprintf("%.1f%%", float64(part)*100/float64(total))
+}
+
+// formatSanitizedMarkdown renders a sanitized report as Markdown without links or repository names.
+func formatSanitizedMarkdown(report *SanitizedReport) string {
+	var markdownBuilder strings.Builder
+	markdownBuilder.WriteString("# GitHub Actions Usage Summary\n\n")
+	markdownBuilder.WriteString("Repository names are replaced with pseudonyms. Only third-party action names are shown.\n\n")
+
+	markdownBuilder.WriteString("## Overview\n\n")
+	markdownBuilder.WriteString("| Metric | Value |\n")
+	markdownBuilder.WriteString("|--------|-------|\n")
+	markdownBuilder.WriteString(fmt.Sprintf("| Repositories | %d |\n", report.Repositories))
+	markdownBuilder.WriteString(fmt.Sprintf("| Workflow files | %d |\n", report.Workflows))
+	markdownBuilder.WriteString(fmt.Sprintf("| Action uses | %d |\n", report.TotalUses))
+	markdownBuilder.WriteString(fmt.Sprintf("| Pinned to a commit SHA | %s |\n", percent(report.PinnedUses, report.TotalUses)))
+	markdownBuilder.WriteString(fmt.Sprintf("| Third-party actions | %d |\n", len(report.Actions)))
+	markdownBuilder.WriteString(fmt.Sprintf("| Internal action uses | %d |\n\n", report.InternalUses))
+
+	markdownBuilder.WriteString("## Third-Party Actions\n\n")
+	markdownBuilder.WriteString("| Action | Repositories | Uses | Pinned |\n")
+	markdownBuilder.WriteString("|--------|--------------|------|--------|\n")
+	if len(report.Actions) == 0 {
+		markdownBuilder.WriteString("| *None* | - | - | - |\n")
+	}
+	for _, action := range report.Actions {
+		markdownBuilder.WriteString(fmt.Sprintf("| `%s` | %d | %d | %s |\n", action.Action, action.Repositories, action.Uses, percent(action.PinnedUses, action.Uses)))
+	}
+	markdownBuilder.WriteString("\n")
+
+	markdownBuilder.WriteString("## Findings by Rule\n\n")
+	markdownBuilder.WriteString("| Rule | Severity | Findings | Repositories |\n")
+	markdownBuilder.WriteString("|------|----------|----------|--------------|\n")
+	if len(report.Rules) == 0 {
+		markdownBuilder.WriteString("| *No findings* | - | - | - |\n")
+	}
+	for _, rule := range report.Rules {
+		markdownBuilder.WriteString(fmt.Sprintf("| %s | %s | %d | %d |\n", rule.Rule, rule.Severity, rule.Findings, rule.Repositories))
+	}
+	markdownBuilder.WriteString("\n")
+
+	markdownBuilder.WriteString("## Repositories\n\n")
+	markdownBuilder.WriteString("| Repository | Workflows | Uses | Pinned | Findings |\n")
+	markdownBuilder.WriteString("|------------|-----------|------|--------|----------|\n")
+	for _, stats := range report.RepositoryDetails {
+		markdownBuilder.WriteString(fmt.Sprintf("| %s | %d | %d | %s | %d |\n", stats.Pseudonym, stats.Workflows, stats.Uses, percent(stats.PinnedUses, stats.Uses), stats.Findings))
+	}
+
+	return markdownBuilder.String()
+}
+
+// formatSanitizedJSON renders the sanitized report as JSON. Empty lists are written as [] rather than null.
+func formatSanitizedJSON(report *SanitizedReport) (string, error) {
+	document := *report
+	document.Actions = emptyIfNil(document.Actions)
+	document.Rules = emptyIfNil(document.Rules)
+	document.RepositoryDetails = emptyIfNil(document.RepositoryDetails)
+	return formatJSONDocument(document)
+}
+
+// writeSanitizedReport builds the sanitized report and writes it to output or standard output.
+func writeSanitizedReport(dbPath, salt, format, output string) error {
+	names, err := newPseudonymizer(salt)
+	if err != nil {
+		return err
+	}
+	report, err := buildSanitizedReport(dbPath, names)
+	if err != nil {
+		return err
+	}
+
+	content := formatSanitizedMarkdown(report)
+	if format == formatJSON {
+		content, err = formatSanitizedJSON(report)
+		if err != nil {
+			return err
+		}
+	}
+	if output == "" {
+		fmt.Fprint(resultWriter, content)
+		return nil
+	}
+	if err := os.WriteFile(output, []byte(content), 0644); err != nil {
+		return fmt.Errorf("error writing %s: %v", output, err)
+	}
+	logInfof("Wrote sanitized report to %s", output)
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPseudonymizer(t *testing.T) {
+	t.Parallel()
+
+	salted, err := newPseudonymizer("secret")
+	if err != nil {
+		t.Fatalf("newPseudonymizer returned error: %v", err)
+	}
+	again, _ := newPseudonymizer("secret")
+	if salted.name("repo", "billing-api") != again.name("repo", "billing-api") {
+		t.Fatalf("expected the same salt to give the same pseudonym")
+	}
+	if name := salted.name("repo", "billing-api"); !strings.HasPrefix(name, "repo-") || len(name) != 15 || strings.Contains(name, "billing") {
+		t.Fatalf("unexpected pseudonym %q", name)
+	}
+
+	random, _ := newPseudonymizer("")
+	if random.name("repo", "billing-api") == salted.name("repo", "billing-api") {
+		t.Fatalf("expected a random salt to give a different pseudonym")
+	}
+}
+
+func TestBuildSanitizedReport(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dbPath, "repositories.yaml"), []byte("organization: example-org\nrepositories:\n    - billing-api\n    - secret-project\n"), 0644); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+	content := `on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@de0fac2e4500dabe0009e67214ff5f5447ce83dd
+      - uses: example-org/internal-deploy@v1
+      - uses: ./.github/actions/local
+      - run: echo "::set-output name=a::b"
+`
+	for _, repo := range []string{"billing-api", "secret-project"} {
+		if err := updateActionIndex(dbPath, "build.yml", repo, "build.yml", "hash-one", ""); err != nil {
+			t.Fatalf("updateActionIndex returned error: %v", err)
+		}
+	}
+	if err := storeActionVersion(dbPath, "build.yml", "hash-one", content); err != nil {
+		t.Fatalf("storeActionVersion returned error: %v", err)
+	}
+
+	names, _ := newPseudonymizer("secret")
+	report, err := buildSanitizedReport(dbPath, names)
+	if err != nil {
+		t.Fatalf("buildSanitizedReport returned error: %v", err)
+	}
+	if report.Repositories != 2 || report.Workflows != 2 || report.TotalUses != 6 || report.PinnedUses != 2 || report.InternalUses != 4 {
+		t.Fatalf("unexpected totals: %+v", report)
+	}
+	if len(report.Actions) != 1 || report.Actions[0] != (SanitizedActionStats{Action: "actions/checkout", Repositories: 2, Uses: 2, PinnedUses: 2}) {
+		t.Fatalf("unexpected actions: %+v", report.Actions)
+	}
+	if len(report.Rules) != 1 || report.Rules[0].Rule != "deprecated-command" || report.Rules[0].Repositories != 2 {
+		t.Fatalf("unexpected rules: %+v", report.Rules)
+	}
+
+	markdown := formatSanitizedMarkdown(report)
+	for _, hidden := range []string{"billing-api", "secret-project", "internal-deploy", "example-org", "https://"} {
+		if strings.Contains(markdown, hidden) {
+			t.Fatalf("expected %q to be removed from the report:\n%s", hidden, markdown)
+		}
+	}
+	for _, want := range []string{"| `actions/checkout` | 2 | 2 | 100.0% |", "| Pinned to a commit SHA | 33.3% |", names.name("repo", "billing-api")} {
+		if !strings.Contains(markdown, want) {
+			t.Fatalf("expected markdown to contain %q, got:\n%s", want, markdown)
+		}
+	}
+}
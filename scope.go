@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Scan Scoping
+// ------------------------
+
+// ScanScope filters repositories out of a scan based on size and activity heuristics.
+// Zero values disable the corresponding filter.
+type ScanScope struct {
+	MinStars           int      `yaml:"min_stars"`
+	MinSizeKB          int      `yaml:"min_size_kb"`
+	PushedWithinMonths int      `yaml:"pushed_within_months"`
+	SkipEmpty          bool     `yaml:"skip_empty"`
+	Analyzers          []string `yaml:"analyzers,omitempty"` // Analyzer selection used when -analyzers is not given
+}
+
+// ScopeConfig holds the named scan profiles defined in scope.yaml.
+type ScopeConfig struct {
+	Profiles map[string]ScanScope `yaml:"profiles"`
+}
+
+// loadScanScope reads scope.yaml from the database and returns the named profile.
+func loadScanScope(dbPath, profile string) (*ScanScope, error) {
+	configPath := filepath.Join(dbPath, "scope.yaml")
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("profile '%s' requested but no 'scope.yaml' file found at '%s'", profile, configPath)
+		}
+		return nil, err
+	}
+
+	var config ScopeConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse scope config: %v", err)
+	}
+
+	scope, ok := config.Profiles[profile]
+	if !ok {
+		names := make([]string, 0, len(config.Profiles))
+		for name := range config.Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("profile '%s' not found in 'scope.yaml' (available: %s)", profile, strings.Join(names, ", "))
+	}
+	return &scope, nil
+}
+
+// exclusionReason returns why a repository falls outside the scope, or an empty string if it should be scanned.
+func (s ScanScope) exclusionReason(repo *github.Repository, now time.Time) string {
+	if s.SkipEmpty && repo.GetSize() == 0 {
+		return "repository is empty"
+	}
+	if s.MinStars > 0 && repo.GetStargazersCount() < s.MinStars {
+		return fmt.Sprintf("%d stars is below the minimum of %d", repo.GetStargazersCount(), s.MinStars)
+	}
+	if s.MinSizeKB > 0 && repo.GetSize() < s.MinSizeKB {
+		return fmt.Sprintf("size of %d KB is below the minimum of %d KB", repo.GetSize(), s.MinSizeKB)
+	}
+	if s.PushedWithinMonths > 0 {
+		cutoff := now.AddDate(0, -s.PushedWithinMonths, 0)
+		if repo.PushedAt == nil || repo.GetPushedAt().Time.Before(cutoff) {
+			return fmt.Sprintf("no pushes in the last %d months", s.PushedWithinMonths)
+		}
+	}
+	return ""
+}
+
+// applyScanScope returns the repositories that fall within the scope, logging each one that is skipped.
+func applyScanScope(repos []*github.Repository, scope *ScanScope, now time.Time) []*github.Repository {
+	if scope == nil {
+		return repos
+	}
+
+	scoped := make([]*github.Repository, 0, len(repos))
+	for _, repo := range repos {
+		if reason := scope.exclusionReason(repo, now); reason != "" {
+			logDebugf("Skipping repository '%s' outside of scan scope: %s", repo.GetName(), reason)
+			continue
+		}
+		scoped = append(scoped, repo)
+	}
+
+	logInfof("Scan scope selected %d of %d repositories", len(scoped), len(repos))
+	return scoped
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+)
+
+func TestScanScopeExclusionReason(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	scope := ScanScope{MinStars: 1, MinSizeKB: 10, PushedWithinMonths: 6, SkipEmpty: true}
+	recent := &github.Timestamp{Time: now.AddDate(0, -1, 0)}
+	stale := &github.Timestamp{Time: now.AddDate(-1, 0, 0)}
+
+	tests := []struct {
+		name     string
+		repo     *github.Repository
+		excluded bool
+	}{
+		{name: "active", repo: &github.Repository{StargazersCount: github.Int(3), Size: github.Int(200), PushedAt: recent}},
+		{name: "empty", repo: &github.Repository{StargazersCount: github.Int(3), Size: github.Int(0), PushedAt: recent}, excluded: true},
+		{name: "no stars", repo: &github.Repository{StargazersCount: github.Int(0), Size: github.Int(200), PushedAt: recent}, excluded: true},
+		{name: "tiny", repo: &github.Repository{StargazersCount: github.Int(3), Size: github.Int(4), PushedAt: recent}, excluded: true},
+		{name: "stale", repo: &github.Repository{StargazersCount: github.Int(3), Size: github.Int(200), PushedAt: stale}, excluded: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			reason := scope.exclusionReason(tt.repo, now)
+			if (reason != "") != tt.excluded {
+				t.Fatalf("exclusionReason = %q, want excluded %t", reason, tt.excluded)
+			}
+		})
+	}
+}
+
+func TestLoadScanScope(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	config := "profiles:\n  routine:\n    min_size_kb: 10\n    pushed_within_months: 12\n"
+	if err := os.WriteFile(filepath.Join(dbPath, "scope.yaml"), []byte(config), 0644); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+
+	scope, err := loadScanScope(dbPath, "routine")
+	if err != nil {
+		t.Fatalf("loadScanScope returned error: %v", err)
+	}
+	if scope.MinSizeKB != 10 || scope.PushedWithinMonths != 12 || scope.SkipEmpty {
+		t.Fatalf("unexpected scope: %+v", scope)
+	}
+
+	if _, err := loadScanScope(dbPath, "missing"); err == nil {
+		t.Fatalf("expected an error for an unknown profile")
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Compliance Scorecard
+// ------------------------
+
+// Weights of each signal in the compliance score; they add up to 100.
+const (
+	scoreWeightPinning     = 30
+	scoreWeightPermissions = 20
+	scoreWeightRequired    = 20
+	scoreWeightDeprecated  = 15
+	scoreWeightTimeouts    = 15
+)
+
+// ScorecardConfig represents the optional scorecard configuration stored in the database directory.
+type ScorecardConfig struct {
+	RequiredWorkflows []string `yaml:"required_workflows"`
+}
+
+// RepoSignals holds the raw compliance signals collected from a repository's workflows.
+type RepoSignals struct {
+	TotalUses          int
+	PinnedUses         int
+	Workflows          int
+	WorkflowsWithPerms int
+	RequiredWorkflows  int
+	PresentRequired    int
+	DeprecatedPatterns int
+	Jobs               int
+	JobsWithTimeout    int
+}
+
+// ScoreEntry records the compliance score of a repository on a given date.
+type ScoreEntry struct {
+	Date  string `yaml:"date"`
+	Score int    `yaml:"score"`
+}
+
+// ScoreHistory maps repositories to their score history, oldest first.
+type ScoreHistory struct {
+	Repositories map[string][]ScoreEntry `yaml:"repositories"`
+}
+
+var shaPinRe = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// deprecatedWorkflowPatterns are workflow commands that GitHub has deprecated.
+var deprecatedWorkflowPatterns = []string{"::set-output", "::save-state", "::set-env", "::add-path"}
+
+// loadScorecardConfig loads the optional scorecard configuration from the database directory.
+func loadScorecardConfig(dbPath string) (*ScorecardConfig, error) {
+	data, err := os.ReadFile(filepath.Join(dbPath, "scorecard.yaml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &ScorecardConfig{}, nil
+		}
+		return nil, err
+	}
+
+	var config ScorecardConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse scorecard config: %v", err)
+	}
+	return &config, nil
+}
+
+// isPinnedVersion reports whether an action version is pinned to a full commit SHA.
+func isPinnedVersion(version string) bool {
+	ref, _, _ := strings.Cut(version, " ")
+	return shaPinRe.MatchString(ref)
+}
+
+// collectWorkflowSignals adds the compliance signals of a single workflow file to signals.
+func collectWorkflowSignals(signals *RepoSignals, content, repoName, filePath string) {
+	signals.Workflows++
+
+	for _, use := range extractActionUses(content, repoName, filePath) {
+		if _, _, ok := actionRepository(use.Action); !ok {
+			continue
+		}
+		signals.TotalUses++
+		if isPinnedVersion(use.Version) {
+			signals.PinnedUses++
+		}
+	}
+
+	for _, pattern := range deprecatedWorkflowPatterns {
+		signals.DeprecatedPatterns += strings.Count(content, pattern)
+	}
+
+	jobs, err := resolveWorkflowJobs(content)
+	if err != nil {
+		return
+	}
+
+	// Permissions and timeouts are measured on each job's effective settings, so a
+	// workflow-level declaration counts for every job that does not override it
+	allJobsHavePerms := len(jobs) > 0
+	for _, job := range jobs {
+		if job.Permissions == nil {
+			allJobsHavePerms = false
+		}
+		// Reusable workflow calls cannot declare a timeout
+		if job.ReusableWorkflow != "" {
+			continue
+		}
+		signals.Jobs++
+		if job.HasTimeout() {
+			signals.JobsWithTimeout++
+		}
+	}
+
+	if allJobsHavePerms {
+		signals.WorkflowsWithPerms++
+	}
+}
+
+// ratioScore scales a weight by numerator/denominator, awarding the full weight when there is nothing to measure.
+func ratioScore(weight, numerator, denominator int) float64 {
+	if denominator == 0 {
+		return float64(weight)
+	}
+	return float64(weight) * float64(numerator) / float64(denominator)
+}
+
+// computeScore converts repository signals into a score from 0 to 100.
+func computeScore(signals RepoSignals) int {
+	score := ratioScore(scoreWeightPinning, signals.PinnedUses, signals.TotalUses)
+	score += ratioScore(scoreWeightPermissions, signals.WorkflowsWithPerms, signals.Workflows)
+	score += ratioScore(scoreWeightRequired, signals.PresentRequired, signals.RequiredWorkflows)
+	score += ratioScore(scoreWeightTimeouts, signals.JobsWithTimeout, signals.Jobs)
+
+	deprecated := scoreWeightDeprecated - 5*signals.DeprecatedPatterns
+	if deprecated > 0 {
+		score += float64(deprecated)
+	}
+
+	return int(score + 0.5)
+}
+
+// collectRepoSignals gathers compliance signals for every repository in the database.
+func collectRepoSignals(dbPath string, repoNames []string, config *ScorecardConfig) (map[string]*RepoSignals, error) {
+	signals := make(map[string]*RepoSignals)
+	for _, repoName := range repoNames {
+		signals[repoName] = &RepoSignals{RequiredWorkflows: len(config.RequiredWorkflows)}
+	}
+
+	err := walkIndexedWorkflows(dbPath, func(fileName, repoName, content string) {
+		repoSignals, ok := signals[repoName]
+		// An empty workflow file defines no jobs, so it neither scores nor provides a required workflow
+		if !ok || content == "" {
+			return
+		}
+		collectWorkflowSignals(repoSignals, content, repoName, ".github/workflows/"+fileName)
+		if slices.ContainsFunc(config.RequiredWorkflows, func(name string) bool { return strings.EqualFold(workflowIdentity(name), workflowIdentity(fileName)) }) {
+			repoSignals.PresentRequired++
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return signals, nil
+}
+
+// generateScorecard computes compliance scores, appends them to scores.yaml, and writes SCORECARD.md.
+func generateScorecard(dbPath string) error {
+	config, err := loadScorecardConfig(dbPath)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dbPath, "repositories.yaml"))
+	if err != nil {
+		return fmt.Errorf("failed to read repositories manifest: %v", err)
+	}
+	var manifest RepositoryManifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("failed to parse repositories manifest: %v", err)
+	}
+
+	signals, err := collectRepoSignals(dbPath, manifest.Repositories, config)
+	if err != nil {
+		return err
+	}
+
+	// Load and update score history
+	historyPath := filepath.Join(dbPath, "scores.yaml")
+	history := ScoreHistory{Repositories: make(map[string][]ScoreEntry)}
+	if data, err := os.ReadFile(historyPath); err == nil {
+		if err := yaml.Unmarshal(data, &history); err != nil {
+			return fmt.Errorf("failed to parse scores.yaml: %v", err)
+		}
+		if history.Repositories == nil {
+			history.Repositories = make(map[string][]ScoreEntry)
+		}
+	}
+
+	today := formatReportDate(time.Now())
+	repoNames := make([]string, 0, len(signals))
+	for repoName := range signals {
+		repoNames = append(repoNames, repoName)
+	}
+	sort.Strings(repoNames)
+
+	for _, repoName := range repoNames {
+		entry := ScoreEntry{Date: today, Score: computeScore(*signals[repoName])}
+		entries := history.Repositories[repoName]
+		if len(entries) > 0 && entries[len(entries)-1].Date == today {
+			entries[len(entries)-1] = entry
+		} else {
+			entries = append(entries, entry)
+		}
+		history.Repositories[repoName] = entries
+	}
+
+	historyData, err := yaml.Marshal(&history)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(historyPath, historyData, 0644); err != nil {
+		return err
+	}
+
+	var markdownBuilder strings.Builder
+	markdownBuilder.WriteString("# Compliance Scorecard\n\n")
+	markdownBuilder.WriteString("This table scores each repository from 0 to 100 based on weighted signals from its workflow files.\n\n")
+	markdownBuilder.WriteString("**Legend:**\n")
+	markdownBuilder.WriteString(fmt.Sprintf("- **Pinning** (%d): Share of action uses pinned to a full commit SHA\n", scoreWeightPinning))
+	markdownBuilder.WriteString(fmt.Sprintf("- **Permissions** (%d): Share of workflows declaring `permissions` at the workflow level or on every job\n", scoreWeightPermissions))
+	markdownBuilder.WriteString(fmt.Sprintf("- **Required** (%d): Share of the workflows listed in `scorecard.yaml` that are present\n", scoreWeightRequired))
+	markdownBuilder.WriteString(fmt.Sprintf("- **Deprecated** (%d): Deprecated workflow commands found; each one costs 5 points\n", scoreWeightDeprecated))
+	markdownBuilder.WriteString(fmt.Sprintf("- **Timeouts** (%d): Share of jobs declaring `timeout-minutes`\n", scoreWeightTimeouts))
+	markdownBuilder.WriteString("- **Previous**: The score from the previous recorded run\n\n")
+	markdownBuilder.WriteString("| Repository | Score | Previous | Pinning | Permissions | Required | Deprecated | Timeouts |\n")
+	markdownBuilder.WriteString("|------------|-------|----------|---------|-------------|----------|------------|----------|\n")
+
+	for _, repoName := range repoNames {
+		s := signals[repoName]
+		entries := history.Repositories[repoName]
+		previous := "-"
+		if len(entries) > 1 {
+			previous = fmt.Sprintf("%d", entries[len(entries)-2].Score)
+		}
+		markdownBuilder.WriteString(fmt.Sprintf("| %s | %d | %s | %d/%d | %d/%d | %d/%d | %d | %d/%d |\n",
+			repoName, entries[len(entries)-1].Score, previous,
+			s.PinnedUses, s.TotalUses, s.WorkflowsWithPerms, s.Workflows,
+			s.PresentRequired, s.RequiredWorkflows, s.DeprecatedPatterns, s.JobsWithTimeout, s.Jobs))
+	}
+
+	markdownBuilder.WriteString("\n*This file is automatically generated after each data collection run.*\n")
+
+	if err := os.WriteFile(filepath.Join(dbPath, "SCORECARD.md"), []byte(markdownBuilder.String()), 0644); err != nil {
+		return fmt.Errorf("error writing SCORECARD.md: %v", err)
+	}
+
+	logInfof("Generated SCORECARD.md for %d repositories", len(repoNames))
+	return nil
+}
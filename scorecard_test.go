@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestComputeScore(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    int
+	}{
+		{
+			name:    "fully compliant",
+			content: "permissions:\n  contents: read\njobs:\n  build:\n    timeout-minutes: 10\n    steps:\n      - uses: actions/checkout@de0fac2e4500dabe0009e67214ff5f5447ce83dd # v6.0.2\n",
+			want:    100,
+		},
+		{
+			name:    "unpinned without permissions or timeouts",
+			content: "jobs:\n  build:\n    steps:\n      - uses: actions/checkout@v4\n      - run: echo \"::set-output name=a::b\"\n",
+			want:    30,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var signals RepoSignals
+			collectWorkflowSignals(&signals, tt.content, "repo-a", ".github/workflows/build.yml")
+			if got := computeScore(signals); got != tt.want {
+				t.Fatalf("computeScore() = %d, want %d (signals %+v)", got, tt.want, signals)
+			}
+		})
+	}
+}
+
+func TestGenerateScorecard(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dbPath, "repositories.yaml"), []byte("organization: org\nrepositories:\n    - repo-a\n"), 0644); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dbPath, "scorecard.yaml"), []byte("required_workflows:\n  - build.yml\n  - release.yml\n"), 0644); err != nil {
+		t.Fatalf("failed to write scorecard config: %v", err)
+	}
+	content := "permissions: {}\njobs:\n  build:\n    timeout-minutes: 5\n    steps:\n      - run: echo hi\n"
+	if err := updateActionIndex(dbPath, "build.yml", "repo-a", "build.yml", "hash-one", ""); err != nil {
+		t.Fatalf("updateActionIndex returned error: %v", err)
+	}
+	if err := storeActionVersion(dbPath, "build.yml", "hash-one", content); err != nil {
+		t.Fatalf("storeActionVersion returned error: %v", err)
+	}
+
+	if err := generateScorecard(dbPath); err != nil {
+		t.Fatalf("generateScorecard returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dbPath, "SCORECARD.md"))
+	if err != nil {
+		t.Fatalf("failed to read SCORECARD.md: %v", err)
+	}
+	if !strings.Contains(string(data), "| repo-a | 90 | - | 0/0 | 1/1 | 1/2 | 0 | 1/1 |") {
+		t.Fatalf("unexpected scorecard, got:\n%s", data)
+	}
+
+	history, err := os.ReadFile(filepath.Join(dbPath, "scores.yaml"))
+	if err != nil {
+		t.Fatalf("failed to read scores.yaml: %v", err)
+	}
+	if !strings.Contains(string(history), "score: 90") {
+		t.Fatalf("expected score history, got:\n%s", history)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ------------------------
+// Section: Secret Scanning
+// ------------------------
+
+// secretPattern describes a regular expression that identifies a likely secret value.
+type secretPattern struct {
+	Rule        string
+	Description string
+	Pattern     *regexp.Regexp
+}
+
+var secretPatterns = []secretPattern{
+	{Rule: "github-token", Description: "GitHub token", Pattern: regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{Rule: "aws-access-key", Description: "AWS access key ID", Pattern: regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{Rule: "slack-token", Description: "Slack token", Pattern: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{Rule: "private-key", Description: "private key header", Pattern: regexp.MustCompile(`-----BEGIN ([A-Z0-9]+ )*PRIVATE KEY( BLOCK)?-----`)},
+}
+
+// hardcodedSecretRe matches "key: value" lines where the key name suggests a credential.
+var hardcodedSecretRe = regexp.MustCompile(`(?i)^\s*-?\s*([A-Za-z0-9_-]*(token|secret|password|passwd|api[_-]?key|access[_-]?key|private[_-]?key)[A-Za-z0-9_-]*)\s*:\s*(.+)$`)
+
+// scanForSecrets checks workflow content for committed credentials and hardcoded secret values.
+func scanForSecrets(content, repoName, filePath string) []Finding {
+	var findings []Finding
+
+	for i, line := range strings.Split(content, "\n") {
+		lineNumber := i + 1
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		matched := false
+		for _, sp := range secretPatterns {
+			if match := sp.Pattern.FindString(line); match != "" {
+				findings = append(findings, newFinding(sp.Rule, repoName, filePath, lineNumber,
+					fmt.Sprintf("Possible %s found: %s", sp.Description, maskSecret(match))))
+				matched = true
+			}
+		}
+		if matched {
+			continue
+		}
+
+		if m := hardcodedSecretRe.FindStringSubmatch(line); m != nil {
+			value := strings.TrimSpace(m[3])
+			if idx := strings.Index(value, " #"); idx >= 0 {
+				value = strings.TrimSpace(value[:idx])
+			}
+			value = strings.Trim(value, `"'`)
+			if !isHardcodedSecretValue(value) {
+				continue
+			}
+			findings = append(findings, newFinding("hardcoded-secret", repoName, filePath, lineNumber,
+				fmt.Sprintf("Hardcoded value assigned to '%s': %s", m[1], maskSecret(value))))
+		}
+	}
+
+	return findings
+}
+
+// isHardcodedSecretValue reports whether a value looks like a literal credential rather than a reference.
+func isHardcodedSecretValue(value string) bool {
+	if len(value) < 8 {
+		return false
+	}
+	if strings.Contains(value, "${{") || strings.HasPrefix(value, "$") {
+		return false
+	}
+	if strings.ContainsAny(value, " |>") {
+		return false
+	}
+	return true
+}
+
+// maskSecret hides all but the first few characters of a detected secret.
+func maskSecret(secret string) string {
+	const visible = 4
+	if len(secret) <= visible {
+		return strings.Repeat("*", len(secret))
+	}
+	return secret[:visible] + strings.Repeat("*", 8)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ------------------------
+// Section: Serve Command
+// ------------------------
+
+// serveShutdownTimeout bounds how long requests in progress may take to finish after a stop is requested.
+const serveShutdownTimeout = 10 * time.Second
+
+// runServeCommand serves an existing database read-only over HTTP.
+func runServeCommand(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	serveDB := fs.String("db", "./db", "Path to the database repository, or a git URL to clone once at startup")
+	serveToken := fs.String("token", "", "GitHub API token used to clone an HTTPS database URL")
+	addr := fs.String("addr", "127.0.0.1:8080", "Address to listen on")
+	logFlags := addLogFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if err := applyEnvironmentDefaults(fs); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := logFlags.apply(); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if fs.NArg() != 0 {
+		fmt.Println("Usage: dotgithubindexer serve [-db <path or git URL>] [-addr host:port]")
+		fs.PrintDefaults()
+		return 1
+	}
+
+	checkout, err := openDB(*serveDB, *serveToken)
+	if err != nil {
+		logErrorf("Failed to open database: %v", err)
+		return 1
+	}
+	defer checkout.Close()
+
+	server := &http.Server{Addr: *addr, Handler: newServeHandler(checkout.Dir), ReadHeaderTimeout: 10 * time.Second}
+	stop := watchTermination()
+	go func() {
+		<-stop
+		ctx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			logErrorf("Error shutting down server: %v", err)
+		}
+	}()
+
+	logInfof("Serving database '%s' on http://%s", *serveDB, *addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logErrorf("Server failed: %v", err)
+		return 1
+	}
+	return 0
+}
+
+// newServeHandler returns the handler serving a database: the query API under /api/, the statistics and
+// change feed for dashboards at /stats and /changes, and the database files, such as the generated reports,
+// everywhere else. The .git directory of a clone is not served.
+func newServeHandler(dbPath string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/actions", func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			http.Error(w, "missing name parameter", http.StatusBadRequest)
+			return
+		}
+		result, err := queryActionAcrossOrganizations(dbPath, name)
+		writeServeResult(w, result, err)
+	})
+	mux.HandleFunc("GET /api/checks", func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			http.Error(w, "missing name parameter", http.StatusBadRequest)
+			return
+		}
+		result, err := queryCheckAcrossOrganizations(dbPath, name)
+		writeServeResult(w, result, err)
+	})
+	mux.HandleFunc("GET /api/repositories/{name...}", func(w http.ResponseWriter, r *http.Request) {
+		result, err := queryRepositoryAcrossOrganizations(dbPath, r.PathValue("name"))
+		if errors.Is(err, errRepositoryNotIndexed) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		writeServeResult(w, result, err)
+	})
+	mux.HandleFunc("GET /stats", func(w http.ResponseWriter, r *http.Request) {
+		result, err := buildScanStats(dbPath)
+		writeServeResult(w, result, err)
+	})
+	mux.HandleFunc("GET /changes", func(w http.ResponseWriter, r *http.Request) {
+		since := r.URL.Query().Get("since")
+		if since != "" {
+			if _, err := time.Parse("2006-01-02", since); err != nil {
+				http.Error(w, "invalid since parameter: expected YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+		}
+		result, err := buildChangeFeed(dbPath, since)
+		writeServeResult(w, result, err)
+	})
+	files := http.FileServer(http.Dir(dbPath))
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		for _, segment := range strings.Split(r.URL.Path, "/") {
+			if segment == ".git" {
+				http.NotFound(w, r)
+				return
+			}
+		}
+		files.ServeHTTP(w, r)
+	})
+	return mux
+}
+
+// writeServeResult writes a query result as JSON, or the error as a server error.
+func writeServeResult(w http.ResponseWriter, result any, err error) {
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	document, err := formatJSONDocument(result)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, document)
+}
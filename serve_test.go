@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestServeHandler(t *testing.T) {
+	t.Parallel()
+
+	dbPath := writeQueryTestDB(t)
+	if err := os.MkdirAll(filepath.Join(dbPath, ".git"), 0755); err != nil {
+		t.Fatalf("MkdirAll returned error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dbPath, ".git", "config"), []byte("[core]\n"), 0644); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	server := httptest.NewServer(newServeHandler(dbPath))
+	defer server.Close()
+
+	get := func(path string) (int, string) {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s returned error: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response: %v", err)
+		}
+		return resp.StatusCode, string(body)
+	}
+
+	status, body := get("/api/actions?name=actions/checkout@v4")
+	var actionResult ActionQueryResult
+	if err := json.Unmarshal([]byte(body), &actionResult); err != nil || status != http.StatusOK {
+		t.Fatalf("unexpected response %d %q: %v", status, body, err)
+	}
+	if len(actionResult.Uses) != 1 || actionResult.Uses[0].Repository != "repo-a" {
+		t.Fatalf("unexpected uses: %+v", actionResult.Uses)
+	}
+
+	if status, _ := get("/api/repositories/repo-b"); status != http.StatusOK {
+		t.Fatalf("expected repository to be found, got %d", status)
+	}
+	if status, _ := get("/api/repositories/repo-z"); status != http.StatusNotFound {
+		t.Fatalf("expected unknown repository to be not found, got %d", status)
+	}
+	if status, _ := get("/api/actions"); status != http.StatusBadRequest {
+		t.Fatalf("expected a missing name to be rejected, got %d", status)
+	}
+	if status, body := get("/repositories.yaml"); status != http.StatusOK || body == "" {
+		t.Fatalf("expected database files to be served, got %d", status)
+	}
+	if status, _ := get("/.git/config"); status != http.StatusNotFound {
+		t.Fatalf("expected the .git directory to be hidden, got %d", status)
+	}
+}
+
+func TestServeStatsAndChanges(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(newServeHandler(writeStatsTestDB(t)))
+	defer server.Close()
+
+	get := func(path string) (int, []byte) {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s returned error: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response: %v", err)
+		}
+		return resp.StatusCode, body
+	}
+
+	status, body := get("/stats")
+	var stats ScanStats
+	if err := json.Unmarshal(body, &stats); err != nil || status != http.StatusOK {
+		t.Fatalf("unexpected response %d %q: %v", status, body, err)
+	}
+	if stats.Organization != "example-org" || stats.OpenFindings != 2 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	status, body = get("/changes?since=2026-03-08")
+	var entries []ChangeFeedEntry
+	if err := json.Unmarshal(body, &entries); err != nil || status != http.StatusOK {
+		t.Fatalf("unexpected response %d %q: %v", status, body, err)
+	}
+	if len(entries) != 2 || entries[0].Repository != "repo-b" {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+
+	if status, _ := get("/changes?since=last-week"); status != http.StatusBadRequest {
+		t.Fatalf("expected an invalid since to be rejected, got %d", status)
+	}
+}
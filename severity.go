@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Severity Overrides
+// ------------------------
+
+// SeverityOverrides is the contents of severities.yaml, which replaces the catalog severity of rules for
+// this database.
+type SeverityOverrides struct {
+	Rules map[string]string `yaml:"rules"` // Severity of each overridden rule, by rule ID
+}
+
+// severityOverrides holds the overrides in effect for this run; nil when severities.yaml does not exist.
+var severityOverrides *SeverityOverrides
+
+// loadSeverityOverrides reads the optional severities.yaml in the database directory.
+func loadSeverityOverrides(dbPath string) error {
+	data, err := os.ReadFile(filepath.Join(dbPath, "severities.yaml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			severityOverrides = nil
+			return nil
+		}
+		return err
+	}
+
+	var overrides SeverityOverrides
+	if err := yaml.Unmarshal(data, &overrides); err != nil {
+		return fmt.Errorf("failed to parse severities.yaml: %v", err)
+	}
+	if err := overrides.validate(); err != nil {
+		return fmt.Errorf("invalid severities.yaml: %v", err)
+	}
+	severityOverrides = &overrides
+	logInfof("Loaded severity overrides for %d rules from 'severities.yaml'", len(overrides.Rules))
+	return nil
+}
+
+// validate rejects overrides of rules that are not in the catalog and unknown severities.
+func (o *SeverityOverrides) validate() error {
+	ids := make([]string, 0, len(o.Rules))
+	for id := range o.Rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if _, ok := lookupRule(id); !ok {
+			return fmt.Errorf("unknown rule '%s'", id)
+		}
+		if _, ok := severityRank[o.Rules[id]]; !ok {
+			return fmt.Errorf("unknown severity '%s' for rule '%s'", o.Rules[id], id)
+		}
+	}
+	return nil
+}
+
+// ruleSeverity returns the severity of a rule in this run, which is its override when one is configured.
+func ruleSeverity(rule Rule) string {
+	if severityOverrides != nil {
+		if severity, ok := severityOverrides.Rules[rule.ID]; ok {
+			return severity
+		}
+	}
+	return rule.Severity
+}
+
+// describeRuleSeverity formats the severity of a rule for RULES.md, noting the default of an overridden rule.
+func describeRuleSeverity(rule Rule) string {
+	if severity := ruleSeverity(rule); severity != rule.Severity {
+		return fmt.Sprintf("%s (default %s)", severity, rule.Severity)
+	}
+	return rule.Severity
+}
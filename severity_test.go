@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadSeverityOverrides(t *testing.T) {
+	dbPath := t.TempDir()
+	if err := loadSeverityOverrides(dbPath); err != nil || severityOverrides != nil {
+		t.Fatalf("expected no overrides without severities.yaml, got %+v, %v", severityOverrides, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dbPath, "severities.yaml"), []byte("rules:\n  deprecated-action: high\n"), 0644); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	if err := loadSeverityOverrides(dbPath); err != nil {
+		t.Fatalf("loadSeverityOverrides returned error: %v", err)
+	}
+	defer func() { severityOverrides = nil }()
+
+	if finding := newFinding("deprecated-action", "repo-a", ".github/workflows/ci.yml", 3, "message"); finding.Severity != SeverityHigh {
+		t.Fatalf("expected the overridden severity, got %s", finding.Severity)
+	}
+	if finding := newFinding("outdated-action-runtime", "repo-a", ".github/workflows/ci.yml", 3, "message"); finding.Severity != SeverityMedium {
+		t.Fatalf("expected the catalog severity, got %s", finding.Severity)
+	}
+
+	if err := generateRulesMarkdown(dbPath); err != nil {
+		t.Fatalf("generateRulesMarkdown returned error: %v", err)
+	}
+	rules, err := os.ReadFile(filepath.Join(dbPath, "RULES.md"))
+	if err != nil {
+		t.Fatalf("failed to read RULES.md: %v", err)
+	}
+	if !strings.Contains(string(rules), "| [deprecated-action](#deprecated-action) | high (default medium) |") || !strings.Contains(string(rules), "| [outdated-action-runtime](#outdated-action-runtime) | medium |") {
+		t.Fatalf("unexpected RULES.md:\n%s", rules)
+	}
+}
+
+func TestSeverityOverridesValidate(t *testing.T) {
+	t.Parallel()
+
+	for _, rules := range []map[string]string{
+		{"unknown-rule": SeverityHigh},
+		{"deprecated-action": "urgent"},
+	} {
+		if err := (&SeverityOverrides{Rules: rules}).validate(); err == nil {
+			t.Fatalf("expected an error for %v", rules)
+		}
+	}
+	if err := (&SeverityOverrides{Rules: map[string]string{"deprecated-action": SeverityLow}}).validate(); err != nil {
+		t.Fatalf("validate returned error: %v", err)
+	}
+}
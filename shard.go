@@ -0,0 +1,769 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Scan Sharding
+// ------------------------
+
+// ShardSpec selects one of Count shards, numbered from 1.
+type ShardSpec struct {
+	Index int
+	Count int
+}
+
+// String formats the shard as i/n.
+func (s ShardSpec) String() string {
+	return fmt.Sprintf("%d/%d", s.Index, s.Count)
+}
+
+// contains reports whether a repository belongs to the shard. Repositories are assigned by a hash
+// of their name, so every runner computes the same partition without coordinating.
+func (s ShardSpec) contains(repoName string) bool {
+	h := fnv.New32a()
+	h.Write([]byte(repoName))
+	return int(h.Sum32()%uint32(s.Count)) == s.Index-1
+}
+
+// parseShardSpec parses a -shard value such as 2/4.
+func parseShardSpec(value string) (ShardSpec, error) {
+	indexPart, countPart, ok := strings.Cut(value, "/")
+	index, indexErr := strconv.Atoi(strings.TrimSpace(indexPart))
+	count, countErr := strconv.Atoi(strings.TrimSpace(countPart))
+	if !ok || indexErr != nil || countErr != nil || count < 1 || index < 1 || index > count {
+		return ShardSpec{}, fmt.Errorf("invalid shard '%s': expected i/n with 1 <= i <= n", value)
+	}
+	return ShardSpec{Index: index, Count: count}, nil
+}
+
+// applyShard keeps the repositories that belong to the shard.
+func applyShard(repos []*github.Repository, shard ShardSpec) []*github.Repository {
+	sharded := make([]*github.Repository, 0, len(repos)/shard.Count+1)
+	for _, repo := range repos {
+		if shard.contains(repo.GetName()) {
+			sharded = append(sharded, repo)
+		}
+	}
+	logInfof("Shard %s selected %d of %d repositories", shard, len(sharded), len(repos))
+	return sharded
+}
+
+// ShardManifest is written to shard.yaml in a shard's database and records which repositories it scanned.
+type ShardManifest struct {
+	Organization string   `yaml:"organization"`
+	Shard        string   `yaml:"shard"`
+	Repositories []string `yaml:"repositories"`
+}
+
+// writeShardManifest records the repositories scanned by a shard.
+func writeShardManifest(dbPath, org string, shard ShardSpec, repos []*github.Repository) error {
+	manifest := ShardManifest{Organization: org, Shard: shard.String(), Repositories: []string{}}
+	for _, repo := range repos {
+		manifest.Repositories = append(manifest.Repositories, repo.GetName())
+	}
+	sort.Strings(manifest.Repositories)
+
+	data, err := yaml.Marshal(&manifest)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dbPath, "shard.yaml"), data, 0644); err != nil {
+		return fmt.Errorf("error writing shard.yaml: %v", err)
+	}
+	logInfof("Wrote shard.yaml for shard %s; run 'merge' to combine the shards and generate reports", shard)
+	return nil
+}
+
+// loadShardManifest reads shard.yaml from a shard's database.
+func loadShardManifest(shardPath string) (*ShardManifest, error) {
+	data, err := os.ReadFile(filepath.Join(shardPath, "shard.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("'%s' is not a shard database: %v", shardPath, err)
+	}
+	var manifest ShardManifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse shard.yaml in '%s': %v", shardPath, err)
+	}
+	return &manifest, nil
+}
+
+// validateShards checks that the shards belong to one organization and together cover every shard exactly once.
+func validateShards(manifests []*ShardManifest) (string, error) {
+	if len(manifests) == 0 {
+		return "", fmt.Errorf("no shards given")
+	}
+	org := manifests[0].Organization
+	seen := make(map[int]bool)
+	count := 0
+	for _, manifest := range manifests {
+		spec, err := parseShardSpec(manifest.Shard)
+		if err != nil {
+			return "", err
+		}
+		if manifest.Organization != org {
+			return "", fmt.Errorf("shard %s belongs to organization '%s', expected '%s'", manifest.Shard, manifest.Organization, org)
+		}
+		if count != 0 && spec.Count != count {
+			return "", fmt.Errorf("shard %s does not match the shard count %d", manifest.Shard, count)
+		}
+		count = spec.Count
+		if seen[spec.Index] {
+			return "", fmt.Errorf("shard %s was given more than once", manifest.Shard)
+		}
+		seen[spec.Index] = true
+	}
+	for i := 1; i <= count; i++ {
+		if !seen[i] {
+			return "", fmt.Errorf("missing shard %d/%d", i, count)
+		}
+	}
+	return org, nil
+}
+
+// runMergeCommand combines shard databases into one database and generates the reports.
+func runMergeCommand(args []string) int {
+	fs := flag.NewFlagSet("merge", flag.ContinueOnError)
+	mergeDBPath := fs.String("db", "./db", "Path to the database repository, or a git URL to clone, update, and push")
+	mergeToken := fs.String("token", "", "GitHub API token (required); defaults to the DOTGITHUBINDEXER_TOKEN or GITHUB_TOKEN environment variable")
+	baseURL := fs.String("base-url", "", "GitHub Enterprise Server API URL, e.g. https://github.example.com/api/v3; defaults to github.com")
+	uploadURL := fs.String("upload-url", "", "GitHub Enterprise Server upload URL; defaults to -base-url")
+	timezone := fs.String("timezone", "UTC", "IANA timezone used for dates and times in reports, e.g. America/New_York")
+	analyzerSelection := fs.String("analyzers", "", "Comma-separated analyzers to run, or to skip when prefixed with '-'; see 'analyzers list'")
+	review := fs.Bool("review", false, "Push database changes to a new branch and open a pull request instead of pushing them directly; requires a git URL -db")
+	commitNotes := fs.Bool("commit-notes", false, "Attach a JSON summary of each database commit as a git note under refs/notes/dotgithubindexer")
+	fs.StringVar(&dbFormat, "format", formatYAML, "Database format: yaml, or json to also write a JSON copy of every generated YAML file")
+	signKey := fs.String("sign-key", "", "Sign the scan summary and findings with this Ed25519 or ECDSA private key in PEM format; see 'verify-report'")
+	signKeyless := fs.Bool("sign-keyless", false, "Sign the scan summary and findings with cosign keyless signing, using the CI's OIDC identity; requires cosign")
+	failOn := fs.String("fail-on", "", "Comma-separated conditions that make the merge exit with code 2 after publishing the database: unpinned, third-party, violations, or violations:<severity>")
+	logFlags := addLogFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if err := applyEnvironmentDefaults(fs); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := logFlags.apply(); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if *mergeToken == "" || fs.NArg() == 0 {
+		printMergeUsage()
+		fs.PrintDefaults()
+		return 1
+	}
+	if err := setReportTimezone(*timezone); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := setGitHubServer(*baseURL, *uploadURL); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := setAnalyzerSelection(*analyzerSelection); err != nil {
+		logErrorf("Invalid analyzer selection: %v", err)
+		return 1
+	}
+	if err := checkFormat(dbFormat, formatYAML, formatJSON); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := checkReviewMode(*review, *mergeDBPath); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	failOnConditions, err := parseFailOn(*failOn)
+	if err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := checkSigningOptions(*signKey, *signKeyless); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+
+	checkout, err := openDB(*mergeDBPath, *mergeToken)
+	if err != nil {
+		logErrorf("Failed to open database: %v", err)
+		return 1
+	}
+	defer checkout.Close()
+	checkout.Notes = *commitNotes
+
+	startTime := time.Now()
+	tracker, err := startRunTracker("merge", checkout.Dir, nil)
+	if err != nil {
+		logErrorf("Failed to open database: %v", err)
+		return 1
+	}
+	merged, err := mergeShards(getGitHubClient(*mergeToken), checkout.Dir, fs.Args())
+	if err != nil {
+		logErrorf("Merge failed: %v", err)
+		return 1
+	}
+	tracker.record(org, RunCompleted, merged)
+	if *signKey != "" || *signKeyless {
+		if err := signReports(checkout.Dir, org, *signKey, *signKeyless); err != nil {
+			logErrorf("Failed to sign reports: %v", err)
+			return 1
+		}
+	}
+	if err := publishDB(checkout, fmt.Sprintf("Update %s index from %d shards (%s)", org, fs.NArg(), formatReportDate(startTime)), *review, *mergeToken); err != nil {
+		logErrorf("Failed to publish database: %v", err)
+		return 1
+	}
+	return enforcePolicyGate(checkout.Dir, failOnConditions)
+}
+
+// printMergeUsage prints the usage for the merge command.
+func printMergeUsage() {
+	fmt.Println("Usage: dotgithubindexer merge -token <token> [-db <path or git URL>] <shard-db> [<shard-db>...]")
+}
+
+// mergeShards copies what each shard indexed for its repositories into the database, then completes
+// the run as a single unsharded scan would. It returns the number of repositories the shards scanned.
+func mergeShards(client *github.Client, dbPath string, shardPaths []string) (int, error) {
+	manifests := make([]*ShardManifest, 0, len(shardPaths))
+	for _, shardPath := range shardPaths {
+		manifest, err := loadShardManifest(shardPath)
+		if err != nil {
+			return 0, err
+		}
+		manifests = append(manifests, manifest)
+	}
+	shardOrg, err := validateShards(manifests)
+	if err != nil {
+		return 0, err
+	}
+	org = shardOrg
+	if err := selectShardLayout(dbPath, shardPaths); err != nil {
+		return 0, err
+	}
+
+	if err := initializeDB(dbPath); err != nil {
+		return 0, fmt.Errorf("failed to initialize database: %v", err)
+	}
+	if err := loadDenylist(dbPath); err != nil {
+		return 0, fmt.Errorf("failed to load denylist: %v", err)
+	}
+	if err := loadBudgets(dbPath); err != nil {
+		return 0, fmt.Errorf("failed to load budgets: %v", err)
+	}
+	if err := loadEnvNaming(dbPath); err != nil {
+		return 0, fmt.Errorf("failed to load env naming convention: %v", err)
+	}
+	if err := loadSeverityOverrides(dbPath); err != nil {
+		return 0, fmt.Errorf("failed to load severity overrides: %v", err)
+	}
+	if err := loadFreeze(dbPath); err != nil {
+		return 0, fmt.Errorf("failed to load freeze: %v", err)
+	}
+	if err := loadAnnotationKeys(dbPath); err != nil {
+		return 0, fmt.Errorf("failed to load annotation keys: %v", err)
+	}
+	dotfilesConfig, err := loadDotfilesConfig(dbPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load dotfiles config: %v", err)
+	}
+	notificationConfig, err := loadNotificationConfig(dbPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load notification config: %v", err)
+	}
+
+	scanned := make(map[string]bool)
+	failures := make(map[string]RepositoryError)
+	var workflowChanges []WorkflowChange
+	for i, shardPath := range shardPaths {
+		logInfof("Merging shard %s from '%s'", manifests[i].Shard, shardPath)
+		repos := make(map[string]bool)
+		for _, repoName := range manifests[i].Repositories {
+			repos[repoName] = true
+			scanned[repoName] = true
+		}
+		changes, err := mergeShard(dbPath, shardPath, repos)
+		if err != nil {
+			return 0, fmt.Errorf("failed to merge shard %s: %v", manifests[i].Shard, err)
+		}
+		workflowChanges = append(workflowChanges, changes...)
+
+		var shardErrors ErrorsManifest
+		if data, err := os.ReadFile(filepath.Join(shardPath, "errors.yaml")); err == nil {
+			if err := yaml.Unmarshal(data, &shardErrors); err != nil {
+				return 0, fmt.Errorf("failed to parse errors.yaml of shard %s: %v", manifests[i].Shard, err)
+			}
+		}
+		for repoName, repoErr := range shardErrors.Repositories {
+			failures[repoName] = repoErr
+			// Repositories that failed were not indexed in this run, as in an unsharded scan
+			delete(scanned, repoName)
+		}
+	}
+
+	data, err := yaml.Marshal(&ErrorsManifest{Repositories: failures})
+	if err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(filepath.Join(dbPath, "errors.yaml"), data, 0644); err != nil {
+		return 0, fmt.Errorf("error writing errors.yaml: %v", err)
+	}
+
+	usesIndex, findings, err := collectIndexedResults(dbPath, org, scanned)
+	if err != nil {
+		return 0, fmt.Errorf("failed to collect results: %v", err)
+	}
+
+	dotfilesEnabled := dotfilesConfig != nil && len(dotfilesConfig.Dotfiles) > 0
+	completeRun(client, dbPath, org, dotfilesEnabled, false, notificationConfig, usesIndex, findings, workflowChanges)
+	return len(scanned), nil
+}
+
+// mergeShard copies the index entries, file versions, change log entries, environments, and default
+// branches of the given repositories from a shard's database. It returns the workflow changes the shard
+// recorded that are not yet in the database.
+func mergeShard(dbPath, shardPath string, repos map[string]bool) ([]WorkflowChange, error) {
+	var shardManifest RepositoryManifest
+	if data, err := os.ReadFile(filepath.Join(shardPath, "repositories.yaml")); err == nil {
+		if err := yaml.Unmarshal(data, &shardManifest); err != nil {
+			return nil, fmt.Errorf("failed to parse repositories.yaml: %v", err)
+		}
+	}
+	for _, repoName := range shardManifest.Repositories {
+		if repos[repoName] {
+			if err := updateRepositoriesManifest(dbPath, repoName, shardManifest.DefaultBranches[repoName]); err != nil {
+				return nil, fmt.Errorf("failed to update repositories manifest: %v", err)
+			}
+			if err := updateRepositoryToolchains(dbPath, repoName, shardManifest.Toolchains[repoName]); err != nil {
+				return nil, fmt.Errorf("failed to update toolchains: %v", err)
+			}
+		}
+	}
+
+	var changes []WorkflowChange
+	workflowDirs, err := readSubdirectories(filepath.Join(shardPath, "workflows"))
+	if err != nil {
+		return nil, err
+	}
+	for _, actionName := range workflowDirs {
+		from := filepath.Join(shardPath, "workflows", actionName)
+		to := filepath.Join(dbPath, "workflows", actionName)
+		index, err := mergeActionIndex(from, to, repos)
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge workflow '%s': %v", actionName, err)
+		}
+		newChanges, err := replaceChangeLogEntries(filepath.Join(from, "changelog.yaml"), filepath.Join(to, "changelog.yaml"), repos)
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge changelog of workflow '%s': %v", actionName, err)
+		}
+		for _, change := range newChanges {
+			changes = append(changes, WorkflowChange{
+				RepoName:   change.Repository,
+				FilePath:   ".github/workflows/" + index.fileName(change.Repository, actionName),
+				ActionName: actionName,
+				From:       change.From,
+				To:         change.To,
+			})
+		}
+	}
+
+	definitionNames, err := readSubdirectories(filepath.Join(shardPath, "actions"))
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range definitionNames {
+		if _, err := mergeActionIndex(filepath.Join(shardPath, "actions", name), filepath.Join(dbPath, "actions", name), repos); err != nil {
+			return nil, fmt.Errorf("failed to merge action definition '%s': %v", name, err)
+		}
+	}
+
+	for _, folder := range orgGitHubFolders {
+		names, err := readSubdirectories(filepath.Join(shardPath, folder.DBDir))
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			if _, err := mergeActionIndex(filepath.Join(shardPath, folder.DBDir, name), filepath.Join(dbPath, folder.DBDir, name), repos); err != nil {
+				return nil, fmt.Errorf("failed to merge %s file '%s': %v", folder.RepoDir, name, err)
+			}
+		}
+	}
+
+	categories, err := readSubdirectories(filepath.Join(shardPath, "dependabot"))
+	if err != nil {
+		return nil, err
+	}
+	for _, category := range categories {
+		if _, err := mergeActionIndex(filepath.Join(shardPath, "dependabot", category), filepath.Join(dbPath, "dependabot", category), repos); err != nil {
+			return nil, fmt.Errorf("failed to merge dependabot category '%s': %v", category, err)
+		}
+	}
+
+	renovateNames, err := readSubdirectories(filepath.Join(shardPath, renovateDir))
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range renovateNames {
+		if _, err := mergeActionIndex(filepath.Join(shardPath, renovateDir, name), filepath.Join(dbPath, renovateDir, name), repos); err != nil {
+			return nil, fmt.Errorf("failed to merge Renovate config '%s': %v", name, err)
+		}
+	}
+
+	dotGitHubNames, err := walkIndexedFolders(filepath.Join(shardPath, dotGitHubDir))
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range dotGitHubNames {
+		if _, err := mergeActionIndex(dotGitHubStoragePath(shardPath, name), dotGitHubStoragePath(dbPath, name), repos); err != nil {
+			return nil, fmt.Errorf("failed to merge .github file '%s': %v", name, err)
+		}
+	}
+
+	if err := mergeDotfileIndexes(dbPath, shardPath, repos); err != nil {
+		return nil, err
+	}
+
+	environments, err := loadEnvironmentIndex(shardPath)
+	if err != nil {
+		return nil, err
+	}
+	repoNames := make([]string, 0, len(repos))
+	for repoName := range repos {
+		repoNames = append(repoNames, repoName)
+	}
+	sort.Strings(repoNames)
+	tokenDefaults, err := loadTokenDefaultsIndex(shardPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, repoName := range repoNames {
+		if err := updateEnvironmentIndex(dbPath, repoName, environments.Repositories[repoName]); err != nil {
+			return nil, err
+		}
+		if err := updateTokenDefaultsIndex(dbPath, repoName, tokenDefaults.tokenDefaultsFor(repoName)); err != nil {
+			return nil, err
+		}
+	}
+
+	return changes, nil
+}
+
+// walkIndexedFolders returns the slash-separated paths, relative to a database directory such as
+// dotgithub, of the folders within it that hold an index.yaml, or nothing if it does not exist.
+func walkIndexedFolders(kindPath string) ([]string, error) {
+	var names []string
+	err := filepath.WalkDir(kindPath, func(currentPath string, entry os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && currentPath == kindPath {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		if entry.IsDir() || entry.Name() != "index.yaml" {
+			return nil
+		}
+		relativePath, err := filepath.Rel(kindPath, filepath.Dir(currentPath))
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.ToSlash(relativePath))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// readSubdirectories returns the names of the directories in a folder, or nothing if it does not exist.
+func readSubdirectories(path string) ([]string, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// mergeActionIndex copies the entries of the given repositories from one index.yaml to another,
+// together with the file versions they reference, and returns the merged index.
+func mergeActionIndex(fromDir, toDir string, repos map[string]bool) (*ActionIndex, error) {
+	from, err := readActionIndex(filepath.Join(fromDir, "index.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	to, err := readActionIndex(filepath.Join(toDir, "index.yaml"))
+	if err != nil {
+		return nil, err
+	}
+
+	changed := false
+	for repoName, hash := range from.Repositories {
+		if !repos[repoName] {
+			continue
+		}
+		changed = true
+		to.Repositories[repoName] = hash
+		if blobSHA, ok := from.Blobs[hash]; ok {
+			if to.Blobs == nil {
+				to.Blobs = make(map[string]string)
+			}
+			to.Blobs[hash] = blobSHA
+		}
+		copyEncodedBlobs(to, from, hash)
+		if fileName, ok := from.Filenames[repoName]; ok {
+			if to.Filenames == nil {
+				to.Filenames = make(map[string]string)
+			}
+			to.Filenames[repoName] = fileName
+		} else {
+			delete(to.Filenames, repoName)
+		}
+		recordAnnotations(to, repoName, from.Annotations[repoName])
+		recordWorkflowSource(to, repoName, from.source(repoName))
+		if err := copyFileIfMissing(versionPath(fromDir, hash), versionPath(toDir, hash)); err != nil {
+			return nil, err
+		}
+	}
+	if !changed {
+		return to, nil
+	}
+	if from.Versions != nil {
+		if to.Versions == nil {
+			to.Versions = make(map[string]VersionDates)
+		}
+		for hash, dates := range from.Versions {
+			if _, ok := to.Versions[hash]; !ok {
+				to.Versions[hash] = dates
+			}
+		}
+		refreshVersionDates(to, formatReportDate(time.Now()), func(hash string) string {
+			return from.Versions[hash].FirstSeen
+		})
+	}
+
+	if err := os.MkdirAll(toDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	if err := writeActionIndex(filepath.Join(toDir, "index.yaml"), to); err != nil {
+		return nil, err
+	}
+	return to, nil
+}
+
+// mergeDotfileIndexes copies the dotfile index entries and versions of the given repositories from a shard.
+func mergeDotfileIndexes(dbPath, shardPath string, repos map[string]bool) error {
+	root := filepath.Join(shardPath, "dotfiles")
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || entry.Name() != "index.yaml" {
+			return nil
+		}
+		rel, err := filepath.Rel(shardPath, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		fromDir, toDir := filepath.Dir(path), filepath.Join(dbPath, rel)
+
+		var from, to DotfileIndex
+		if err := readYAMLFile(path, &from); err != nil {
+			return err
+		}
+		if err := readYAMLFile(filepath.Join(toDir, "index.yaml"), &to); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if to.Repositories == nil {
+			to.Repositories = make(map[string]DotfileIndexEntry)
+		}
+
+		changed := false
+		for repoName, indexEntry := range from.Repositories {
+			if !repos[repoName] {
+				continue
+			}
+			changed = true
+			to.Repositories[repoName] = indexEntry
+			if err := copyFileIfMissing(versionPath(fromDir, indexEntry.Hash), versionPath(toDir, indexEntry.Hash)); err != nil {
+				return err
+			}
+		}
+		if !changed {
+			return nil
+		}
+
+		data, err := yaml.Marshal(&to)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(toDir, "index.yaml"), data, 0644)
+	})
+}
+
+// readYAMLFile decodes a YAML file into out.
+func readYAMLFile(path string, out any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	return nil
+}
+
+// copyFileIfMissing copies a file unless the destination already exists. Versions are named by their
+// content hash, so an existing file already has the same content.
+func copyFileIfMissing(from, to string) error {
+	if _, err := os.Stat(to); err == nil {
+		return nil
+	}
+	data, err := os.ReadFile(from)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(to), os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(to, data, 0644)
+}
+
+// replaceChangeLogEntries replaces the change log entries of the given repositories with those in a
+// shard's changelog.yaml, which started from the same history, and returns the entries that are new.
+func replaceChangeLogEntries(fromPath, toPath string, repos map[string]bool) ([]ActionChange, error) {
+	var from, to ActionChangeLog
+	if err := readYAMLFile(fromPath, &from); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := readYAMLFile(toPath, &to); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	known := make(map[string]bool)
+	var merged []ActionChange
+	for _, change := range to.Changes {
+		if repos[change.Repository] {
+			known[changeKey(change)] = true
+		} else {
+			merged = append(merged, change)
+		}
+	}
+
+	var added []ActionChange
+	for _, change := range from.Changes {
+		if !repos[change.Repository] {
+			continue
+		}
+		merged = append(merged, change)
+		if !known[changeKey(change)] {
+			added = append(added, change)
+		}
+	}
+	if len(added) == 0 && len(merged) == len(to.Changes) {
+		return nil, nil
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Date < merged[j].Date
+	})
+	to.Changes = merged
+	data, err := yaml.Marshal(&to)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(toPath, data, 0644); err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+// changeKey identifies a change log entry.
+func changeKey(change ActionChange) string {
+	return strings.Join([]string{change.Date, change.Repository, change.From, change.To}, "|")
+}
+
+// collectIndexedResults rebuilds the uses index and findings of the given repositories from the database,
+// as a scan of them would have produced.
+func collectIndexedResults(dbPath, org string, repos map[string]bool) (*ActionUsesIndex, []Finding, error) {
+	usesIndex := &ActionUsesIndex{Actions: make(map[string]map[string][]WorkflowReference)}
+	var findings []Finding
+	tokenDefaults, err := loadTokenDefaultsIndex(dbPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = walkIndexedWorkflows(dbPath, func(fileName, repoName, content string) {
+		if !repos[repoName] {
+			return
+		}
+		filePath := ".github/workflows/" + fileName
+		workflowFindings := analyzeWorkflow(content, repoName, filePath)
+		if analyzerEnabled("permissions") {
+			workflowFindings = append(workflowFindings, analyzeTokenDefaults(repoName, filePath, content, tokenDefaults.tokenDefaultsFor(repoName))...)
+		}
+		annotateFindings(workflowFindings, extractAnnotations(content, annotationKeys))
+		findings = append(findings, workflowFindings...)
+		addActionUses(usesIndex, extractActionUses(content, repoName, filePath))
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if analyzerEnabled("permissions") {
+		repoNames := make([]string, 0, len(tokenDefaults.Repositories))
+		for repoName := range tokenDefaults.Repositories {
+			if repos[repoName] {
+				repoNames = append(repoNames, repoName)
+			}
+		}
+		sort.Strings(repoNames)
+		for _, repoName := range repoNames {
+			findings = append(findings, analyzePullRequestApproval(org, repoName, tokenDefaults.tokenDefaultsFor(repoName))...)
+		}
+	}
+
+	if analyzerEnabled("environments") {
+		environments, err := loadEnvironmentIndex(dbPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		repoNames := make([]string, 0, len(environments.Repositories))
+		for repoName := range environments.Repositories {
+			if repos[repoName] {
+				repoNames = append(repoNames, repoName)
+			}
+		}
+		sort.Strings(repoNames)
+		for _, repoName := range repoNames {
+			findings = append(findings, analyzeEnvironments(org, repoName, environments.Repositories[repoName])...)
+		}
+	}
+	return usesIndex, findings, nil
+}
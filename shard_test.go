@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseShardSpec(t *testing.T) {
+	t.Parallel()
+
+	spec, err := parseShardSpec("2/4")
+	if err != nil {
+		t.Fatalf("parseShardSpec returned error: %v", err)
+	}
+	if spec.Index != 2 || spec.Count != 4 || spec.String() != "2/4" {
+		t.Fatalf("unexpected shard: %+v", spec)
+	}
+
+	for _, value := range []string{"", "2", "0/4", "5/4", "a/b", "1/0"} {
+		if _, err := parseShardSpec(value); err == nil {
+			t.Fatalf("expected error for %q", value)
+		}
+	}
+}
+
+func TestShardPartition(t *testing.T) {
+	t.Parallel()
+
+	counts := make(map[int]int)
+	for i := 0; i < 200; i++ {
+		repoName := fmt.Sprintf("repo-%d", i)
+		matches := 0
+		for index := 1; index <= 3; index++ {
+			if (ShardSpec{Index: index, Count: 3}).contains(repoName) {
+				matches++
+				counts[index]++
+			}
+		}
+		if matches != 1 {
+			t.Fatalf("repository %s belongs to %d shards", repoName, matches)
+		}
+	}
+	for index := 1; index <= 3; index++ {
+		if counts[index] == 0 {
+			t.Fatalf("shard %d/3 received no repositories", index)
+		}
+	}
+}
+
+func TestValidateShards(t *testing.T) {
+	t.Parallel()
+
+	complete := []*ShardManifest{
+		{Organization: "acme", Shard: "2/2"},
+		{Organization: "acme", Shard: "1/2"},
+	}
+	org, err := validateShards(complete)
+	if err != nil || org != "acme" {
+		t.Fatalf("validateShards = %q, %v", org, err)
+	}
+
+	cases := map[string][]*ShardManifest{
+		"missing shard 2/2":       {{Organization: "acme", Shard: "1/2"}},
+		"more than once":          {{Organization: "acme", Shard: "1/1"}, {Organization: "acme", Shard: "1/1"}},
+		"does not match":          {{Organization: "acme", Shard: "1/2"}, {Organization: "acme", Shard: "2/3"}},
+		"belongs to organization": {{Organization: "acme", Shard: "1/2"}, {Organization: "other", Shard: "2/2"}},
+	}
+	for want, manifests := range cases {
+		if _, err := validateShards(manifests); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected error containing %q, got %v", want, err)
+		}
+	}
+}
+
+// indexWorkflowForTest indexes a workflow version for a repository as a scan would.
+func indexWorkflowForTest(t *testing.T, dbPath, repoName, hash, content string) {
+	t.Helper()
+	previous := currentActionHash(dbPath, "build.yml", repoName)
+	if err := updateRepositoriesManifest(dbPath, repoName, "main"); err != nil {
+		t.Fatalf("updateRepositoriesManifest returned error: %v", err)
+	}
+	if err := updateActionIndex(dbPath, "build.yml", repoName, "build.yml", hash, ""); err != nil {
+		t.Fatalf("updateActionIndex returned error: %v", err)
+	}
+	if err := storeActionVersion(dbPath, "build.yml", hash, content); err != nil {
+		t.Fatalf("storeActionVersion returned error: %v", err)
+	}
+	if err := recordActionChange(dbPath, "build.yml", repoName, previous, hash, content, true); err != nil {
+		t.Fatalf("recordActionChange returned error: %v", err)
+	}
+}
+
+func TestMergeShard(t *testing.T) {
+	t.Parallel()
+
+	manifest := []byte("organization: acme\nrepositories: []\n")
+	dbPath, shardPath := t.TempDir(), t.TempDir()
+	for _, path := range []string{dbPath, shardPath} {
+		if err := os.WriteFile(filepath.Join(path, "repositories.yaml"), manifest, 0644); err != nil {
+			t.Fatalf("failed to write manifest: %v", err)
+		}
+		indexWorkflowForTest(t, path, "repo-a", "hash-a1", "on: push\n")
+		indexWorkflowForTest(t, path, "repo-b", "hash-b1", "on: push\n# b\n")
+	}
+
+	// The shard scans repo-a, which moved to a new version; its copy of repo-b is stale
+	indexWorkflowForTest(t, shardPath, "repo-a", "hash-a2", "on: pull_request\n")
+	// Another shard already moved repo-b in the database
+	indexWorkflowForTest(t, dbPath, "repo-b", "hash-b2", "on: pull_request\n# b\n")
+
+	changes, err := mergeShard(dbPath, shardPath, map[string]bool{"repo-a": true})
+	if err != nil {
+		t.Fatalf("mergeShard returned error: %v", err)
+	}
+	if len(changes) != 1 || changes[0].RepoName != "repo-a" || changes[0].From != "hash-a1" || changes[0].To != "hash-a2" {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+	if changes[0].FilePath != ".github/workflows/build.yml" || changes[0].ActionName != "build.yml" {
+		t.Fatalf("unexpected change location: %+v", changes[0])
+	}
+
+	index, err := readActionIndex(filepath.Join(dbPath, "workflows", "build.yml", "index.yaml"))
+	if err != nil {
+		t.Fatalf("readActionIndex returned error: %v", err)
+	}
+	if index.Repositories["repo-a"] != "hash-a2" || index.Repositories["repo-b"] != "hash-b2" {
+		t.Fatalf("unexpected index after merge: %+v", index.Repositories)
+	}
+	if _, err := os.Stat(filepath.Join(dbPath, "workflows", "build.yml", "hash-a2")); err != nil {
+		t.Fatalf("expected merged version to be copied: %v", err)
+	}
+
+	var changeLog ActionChangeLog
+	if err := readYAMLFile(filepath.Join(dbPath, "workflows", "build.yml", "changelog.yaml"), &changeLog); err != nil {
+		t.Fatalf("failed to read changelog: %v", err)
+	}
+	var repoA, repoB int
+	for _, change := range changeLog.Changes {
+		switch change.Repository {
+		case "repo-a":
+			repoA++
+		case "repo-b":
+			repoB++
+		}
+	}
+	if repoA != 2 || repoB != 2 {
+		t.Fatalf("expected two changes per repository, got repo-a=%d repo-b=%d", repoA, repoB)
+	}
+
+	// Merging the same shard again adds nothing
+	changes, err = mergeShard(dbPath, shardPath, map[string]bool{"repo-a": true})
+	if err != nil {
+		t.Fatalf("mergeShard returned error: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("expected no new changes, got %+v", changes)
+	}
+}
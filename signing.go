@@ -0,0 +1,376 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Report Signing
+// ------------------------
+
+// signaturesDir holds the signed manifest of a run's reports and its signature.
+const signaturesDir = "signatures"
+
+// signedReportFiles are the database files covered by the signature: the scan summary, the findings,
+// the metrics they are counted in, and the repositories that failed. Files a run did not write are left out.
+var signedReportFiles = []string{"README.md", "FINDINGS.md", "metrics.yaml", "errors.yaml"}
+
+// Signers recorded in the manifest.
+const (
+	signerKey     = "key"     // A provided Ed25519 or ECDSA private key; the signature is in manifest.sig
+	signerKeyless = "keyless" // cosign keyless signing with the CI's OIDC identity; the bundle is in manifest.bundle
+)
+
+// ReportManifest is the contents of signatures/manifest.yaml, the file that is signed.
+type ReportManifest struct {
+	Organization string            `yaml:"organization"`
+	SignedAt     string            `yaml:"signed_at"`
+	Version      string            `yaml:"version"`
+	Signer       string            `yaml:"signer"`
+	KeyID        string            `yaml:"key_id,omitempty"` // SHA-256 of the public key, for key signing
+	Files        map[string]string `yaml:"files"`            // Path: SHA-256 of its content
+}
+
+// hashFile returns the hex SHA-256 of a file's content.
+func hashFile(filePath string) (string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// publicKeyID returns the hex SHA-256 of a public key's PKIX encoding.
+func publicKeyID(publicKey crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// readPrivateKey reads an unencrypted PKCS #8 Ed25519 or ECDSA private key in PEM format, such as one
+// created with 'openssl genpkey -algorithm ed25519'.
+func readPrivateKey(keyPath string) (crypto.Signer, error) {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("signing key '%s' is not in PEM format", keyPath)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %v", err)
+	}
+	switch key := key.(type) {
+	case ed25519.PrivateKey:
+		return key, nil
+	case *ecdsa.PrivateKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("signing key '%s' must be an Ed25519 or ECDSA key", keyPath)
+	}
+}
+
+// readPublicKey reads a PKIX Ed25519 or ECDSA public key in PEM format.
+func readPublicKey(keyPath string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("public key '%s' is not in PEM format", keyPath)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %v", err)
+	}
+	switch key.(type) {
+	case ed25519.PublicKey, *ecdsa.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("public key '%s' must be an Ed25519 or ECDSA key", keyPath)
+	}
+}
+
+// signPayload signs data with an Ed25519 key, or an ECDSA key over its SHA-256 digest.
+func signPayload(signer crypto.Signer, data []byte) ([]byte, error) {
+	if _, ok := signer.(ed25519.PrivateKey); ok {
+		return signer.Sign(rand.Reader, data, crypto.Hash(0))
+	}
+	digest := sha256.Sum256(data)
+	return signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
+
+// verifyPayload checks a signature created by signPayload.
+func verifyPayload(publicKey crypto.PublicKey, data, signature []byte) bool {
+	switch key := publicKey.(type) {
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, data, signature)
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(data)
+		return ecdsa.VerifyASN1(key, digest[:], signature)
+	}
+	return false
+}
+
+// buildReportManifest hashes the signed report files present in the database.
+func buildReportManifest(dbPath, org, signer string, now time.Time) (*ReportManifest, error) {
+	manifest := &ReportManifest{
+		Organization: org,
+		SignedAt:     now.UTC().Format(time.RFC3339),
+		Version:      Version,
+		Signer:       signer,
+		Files:        make(map[string]string),
+	}
+	for _, name := range signedReportFiles {
+		hash, err := hashFile(filepath.Join(dbPath, name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		manifest.Files[name] = hash
+	}
+	return manifest, nil
+}
+
+// checkSigningOptions validates -sign-key and -sign-keyless before a run, so that a bad key does not
+// surface only after the scan.
+func checkSigningOptions(keyPath string, keyless bool) error {
+	if keyPath != "" && keyless {
+		return fmt.Errorf("-sign-key cannot be combined with -sign-keyless")
+	}
+	if keyPath != "" {
+		if _, err := readPrivateKey(keyPath); err != nil {
+			return err
+		}
+	}
+	if keyless {
+		if _, err := exec.LookPath("cosign"); err != nil {
+			return fmt.Errorf("-sign-keyless requires cosign on the PATH: %v", err)
+		}
+	}
+	return nil
+}
+
+// signReports writes signatures/manifest.yaml with the hashes of the signed report files and signs it,
+// with the private key at keyPath or, when keyless is set, with cosign keyless signing.
+// Signatures left by a previous run are replaced.
+func signReports(dbPath, org, keyPath string, keyless bool) error {
+	signer := signerKey
+	if keyless {
+		signer = signerKeyless
+	}
+	manifest, err := buildReportManifest(dbPath, org, signer, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to hash reports: %v", err)
+	}
+
+	var key crypto.Signer
+	if !keyless {
+		key, err = readPrivateKey(keyPath)
+		if err != nil {
+			return err
+		}
+		manifest.KeyID, err = publicKeyID(key.Public())
+		if err != nil {
+			return err
+		}
+	}
+
+	signaturesPath := filepath.Join(dbPath, signaturesDir)
+	if err := os.RemoveAll(signaturesPath); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(signaturesPath, 0755); err != nil {
+		return err
+	}
+	payload, err := yaml.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("error marshaling manifest.yaml: %v", err)
+	}
+	manifestPath := filepath.Join(signaturesPath, "manifest.yaml")
+	if err := os.WriteFile(manifestPath, payload, 0644); err != nil {
+		return fmt.Errorf("error writing manifest.yaml: %v", err)
+	}
+
+	if keyless {
+		// cosign picks up the CI's OIDC token, such as the GitHub Actions id-token, on its own
+		if err := runCosign("sign-blob", "--yes", "--bundle", filepath.Join(signaturesPath, "manifest.bundle"), manifestPath); err != nil {
+			return err
+		}
+	} else {
+		signature, err := signPayload(key, payload)
+		if err != nil {
+			return fmt.Errorf("failed to sign manifest.yaml: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(signaturesPath, "manifest.sig"), []byte(base64.StdEncoding.EncodeToString(signature)+"\n"), 0644); err != nil {
+			return fmt.Errorf("error writing manifest.sig: %v", err)
+		}
+	}
+
+	logInfof("Signed %d report files in '%s/manifest.yaml' (%s)", len(manifest.Files), signaturesDir, signer)
+	return nil
+}
+
+// runCosign runs the cosign CLI, including its output in the returned error.
+func runCosign(args ...string) error {
+	cmd := exec.Command("cosign", args...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("cosign %s failed: %v: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// VerifyOptions selects how verify-report checks the signature of a manifest.
+type VerifyOptions struct {
+	KeyPath             string // Public key for manifests signed with a key
+	CertificateIdentity string // Expected signer identity for keyless manifests, such as the scanner's workflow
+	CertificateIssuer   string // Expected OIDC issuer for keyless manifests
+}
+
+// verifyReports checks the signature of signatures/manifest.yaml and that every file it lists is unchanged.
+// It returns the verified manifest and a description of each problem found.
+func verifyReports(dbPath string, opts VerifyOptions) (*ReportManifest, []string, error) {
+	signaturesPath := filepath.Join(dbPath, signaturesDir)
+	manifestPath := filepath.Join(signaturesPath, "manifest.yaml")
+	payload, err := os.ReadFile(manifestPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, fmt.Errorf("no %s/manifest.yaml found; the reports are not signed", signaturesDir)
+		}
+		return nil, nil, err
+	}
+	var manifest ReportManifest
+	if err := yaml.Unmarshal(payload, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse manifest.yaml: %v", err)
+	}
+
+	switch manifest.Signer {
+	case signerKey:
+		if opts.KeyPath == "" {
+			return nil, nil, fmt.Errorf("the reports are signed with a key; pass its public key with -key")
+		}
+		publicKey, err := readPublicKey(opts.KeyPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		encoded, err := os.ReadFile(filepath.Join(signaturesPath, "manifest.sig"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read manifest.sig: %v", err)
+		}
+		signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decode manifest.sig: %v", err)
+		}
+		if !verifyPayload(publicKey, payload, signature) {
+			return &manifest, []string{"manifest.yaml: signature does not match the public key"}, nil
+		}
+	case signerKeyless:
+		if opts.CertificateIdentity == "" || opts.CertificateIssuer == "" {
+			return nil, nil, fmt.Errorf("the reports are signed keyless; pass the expected signer with -certificate-identity and -certificate-oidc-issuer")
+		}
+		if err := runCosign("verify-blob", "--bundle", filepath.Join(signaturesPath, "manifest.bundle"),
+			"--certificate-identity", opts.CertificateIdentity, "--certificate-oidc-issuer", opts.CertificateIssuer, manifestPath); err != nil {
+			return &manifest, []string{fmt.Sprintf("manifest.yaml: %v", err)}, nil
+		}
+	default:
+		return nil, nil, fmt.Errorf("manifest.yaml has unknown signer '%s'", manifest.Signer)
+	}
+
+	names := make([]string, 0, len(manifest.Files))
+	for name := range manifest.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	for _, name := range names {
+		hash, err := hashFile(filepath.Join(dbPath, filepath.FromSlash(name)))
+		if err != nil {
+			if os.IsNotExist(err) {
+				problems = append(problems, fmt.Sprintf("%s: missing", name))
+				continue
+			}
+			return nil, nil, err
+		}
+		if hash != manifest.Files[name] {
+			problems = append(problems, fmt.Sprintf("%s: changed since it was signed", name))
+		}
+	}
+	return &manifest, problems, nil
+}
+
+// runVerifyReportCommand verifies the signed reports of a database and returns the process exit code.
+func runVerifyReportCommand(args []string) int {
+	fs := flag.NewFlagSet("verify-report", flag.ContinueOnError)
+	verifyDB := fs.String("db", "./db", "Path to the database repository, or a git URL to clone")
+	verifyToken := fs.String("token", "", "GitHub API token used to clone an HTTPS database URL")
+	keyPath := fs.String("key", "", "Public key in PEM format, for reports signed with -sign-key")
+	identity := fs.String("certificate-identity", "", "Expected signer identity, for keyless signatures, e.g. https://github.com/my-org/actions-db/.github/workflows/index.yml@refs/heads/main")
+	issuer := fs.String("certificate-oidc-issuer", "", "Expected OIDC issuer, for keyless signatures, e.g. https://token.actions.githubusercontent.com")
+	logFlags := addLogFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if err := applyEnvironmentDefaults(fs); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if err := logFlags.apply(); err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if fs.NArg() != 0 {
+		fmt.Println("Usage: dotgithubindexer verify-report [-db <path or git URL>] [-key <public key>] [-certificate-identity <identity> -certificate-oidc-issuer <issuer>]")
+		fs.PrintDefaults()
+		return 1
+	}
+
+	checkout, err := openDB(*verifyDB, *verifyToken)
+	if err != nil {
+		logErrorf("Failed to open database: %v", err)
+		return 1
+	}
+	defer checkout.Close()
+
+	manifest, problems, err := verifyReports(checkout.Dir, VerifyOptions{KeyPath: *keyPath, CertificateIdentity: *identity, CertificateIssuer: *issuer})
+	if err != nil {
+		logErrorf("%v", err)
+		return 1
+	}
+	if len(problems) > 0 {
+		for _, problem := range problems {
+			logErrorf("Verification failed: %s", problem)
+		}
+		return 1
+	}
+	logInfof("Verified %d report files of '%s' signed at %s (%s)", len(manifest.Files), manifest.Organization, manifest.SignedAt, manifest.Signer)
+	return 0
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestKeys writes a private and public key pair in PEM format and returns their paths.
+func writeTestKeys(t *testing.T, private, public any) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatalf("failed to marshal private key: %v", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+	privatePath := filepath.Join(dir, "signing.key")
+	publicPath := filepath.Join(dir, "signing.pub")
+	if err := os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0600); err != nil {
+		t.Fatalf("failed to write private key: %v", err)
+	}
+	if err := os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0644); err != nil {
+		t.Fatalf("failed to write public key: %v", err)
+	}
+	return privatePath, publicPath
+}
+
+func TestSignAndVerifyReports(t *testing.T) {
+	t.Parallel()
+
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key: %v", err)
+	}
+	ecPrivate, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate ECDSA key: %v", err)
+	}
+
+	for name, keys := range map[string][2]any{"ed25519": {edPrivate, edPublic}, "ecdsa": {ecPrivate, &ecPrivate.PublicKey}} {
+		privatePath, publicPath := writeTestKeys(t, keys[0], keys[1])
+		if err := checkSigningOptions(privatePath, false); err != nil {
+			t.Fatalf("%s: checkSigningOptions returned error: %v", name, err)
+		}
+
+		dbPath := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dbPath, "README.md"), []byte("# Summary\n"), 0644); err != nil {
+			t.Fatalf("failed to write README.md: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dbPath, "FINDINGS.md"), []byte("# Findings\n"), 0644); err != nil {
+			t.Fatalf("failed to write FINDINGS.md: %v", err)
+		}
+		if err := signReports(dbPath, "example-org", privatePath, false); err != nil {
+			t.Fatalf("%s: signReports returned error: %v", name, err)
+		}
+
+		manifest, problems, err := verifyReports(dbPath, VerifyOptions{KeyPath: publicPath})
+		if err != nil {
+			t.Fatalf("%s: verifyReports returned error: %v", name, err)
+		}
+		if len(problems) != 0 || len(manifest.Files) != 2 || manifest.Signer != signerKey || manifest.KeyID == "" {
+			t.Fatalf("%s: unexpected verification result: %+v, %v", name, manifest, problems)
+		}
+
+		// An altered report fails verification
+		if err := os.WriteFile(filepath.Join(dbPath, "FINDINGS.md"), []byte("# Findings\n\nNone\n"), 0644); err != nil {
+			t.Fatalf("failed to write FINDINGS.md: %v", err)
+		}
+		if _, problems, _ := verifyReports(dbPath, VerifyOptions{KeyPath: publicPath}); len(problems) != 1 || problems[0] != "FINDINGS.md: changed since it was signed" {
+			t.Fatalf("%s: expected the altered report to fail, got %v", name, problems)
+		}
+
+		// So does an altered manifest
+		manifestPath := filepath.Join(dbPath, signaturesDir, "manifest.yaml")
+		data, err := os.ReadFile(manifestPath)
+		if err != nil {
+			t.Fatalf("failed to read manifest.yaml: %v", err)
+		}
+		if err := os.WriteFile(manifestPath, []byte(strings.Replace(string(data), "example-org", "other-org", 1)), 0644); err != nil {
+			t.Fatalf("failed to write manifest.yaml: %v", err)
+		}
+		if _, problems, _ := verifyReports(dbPath, VerifyOptions{KeyPath: publicPath}); len(problems) != 1 || !strings.Contains(problems[0], "signature does not match") {
+			t.Fatalf("%s: expected the altered manifest to fail, got %v", name, problems)
+		}
+	}
+}
+
+func TestVerifyReportsRequiresSignature(t *testing.T) {
+	t.Parallel()
+
+	if _, _, err := verifyReports(t.TempDir(), VerifyOptions{}); err == nil {
+		t.Fatalf("expected an error for a database without signatures")
+	}
+	if err := checkSigningOptions("signing.key", true); err == nil {
+		t.Fatalf("expected an error for -sign-key with -sign-keyless")
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Statistics API
+// ------------------------
+
+// ScanStats are the aggregate metrics of a database, served at /stats for dashboards.
+type ScanStats struct {
+	Organization      string  `json:"organization"`
+	Date              string  `json:"date,omitempty"` // Date of the latest metrics snapshot; empty before the first run
+	Repositories      int     `json:"repositories"`
+	Workflows         int     `json:"workflows"` // Workflow files indexed across all repositories
+	TotalUses         int     `json:"total_uses"`
+	PinnedUses        int     `json:"pinned_uses"`
+	PinnedPercent     float64 `json:"pinned_percent"`
+	ThirdPartyActions int     `json:"third_party_actions"`
+	OpenFindings      int     `json:"open_findings"`
+	Snapshots         int     `json:"snapshots"`
+}
+
+// buildScanStats reads the current metrics of a database from repositories.yaml, the workflow indexes,
+// and the latest snapshot in metrics.yaml.
+func buildScanStats(dbPath string) (*ScanStats, error) {
+	manifest, err := loadRepositoryManifest(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load repositories manifest: %v", err)
+	}
+	history, err := loadMetricsHistory(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &ScanStats{Organization: manifest.Organization, Repositories: len(manifest.Repositories), Snapshots: len(history.Snapshots)}
+	if n := len(history.Snapshots); n > 0 {
+		latest := history.Snapshots[n-1]
+		stats.Date = latest.Date
+		stats.TotalUses = latest.TotalUses
+		stats.PinnedUses = latest.PinnedUses
+		stats.PinnedPercent = latest.pinnedPercent()
+		stats.ThirdPartyActions = len(latest.ThirdPartyActions)
+		stats.OpenFindings = len(latest.Findings)
+	}
+
+	actionsPath := filepath.Join(dbPath, "workflows")
+	dirs, err := os.ReadDir(actionsPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		index, err := readActionIndex(filepath.Join(actionsPath, dir.Name(), "index.yaml"))
+		if err != nil {
+			continue
+		}
+		stats.Workflows += len(index.Repositories)
+	}
+	return stats, nil
+}
+
+// ChangeFeedEntry is a workflow change-log entry, served at /changes.
+type ChangeFeedEntry struct {
+	Date       string `json:"date"`
+	Workflow   string `json:"workflow"`
+	Repository string `json:"repository"`
+	From       string `json:"from,omitempty"`
+	To         string `json:"to"`
+	NewVersion bool   `json:"new_version"`
+	Added      int    `json:"added"`
+	Removed    int    `json:"removed"`
+}
+
+// buildChangeFeed returns the entries of every workflow's changelog.yaml dated on or after since (YYYY-MM-DD),
+// or all of them when since is empty, newest first.
+func buildChangeFeed(dbPath, since string) ([]ChangeFeedEntry, error) {
+	entries := []ChangeFeedEntry{}
+	actionsPath := filepath.Join(dbPath, "workflows")
+	dirs, err := os.ReadDir(actionsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return entries, nil
+		}
+		return nil, err
+	}
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		workflowName := dir.Name()
+		data, err := os.ReadFile(filepath.Join(actionsPath, workflowName, "changelog.yaml"))
+		if err != nil {
+			continue
+		}
+		var changeLog ActionChangeLog
+		if err := yaml.Unmarshal(data, &changeLog); err != nil {
+			logErrorf("Error parsing changelog.yaml for workflow '%s': %v", workflowName, err)
+			continue
+		}
+		for _, change := range changeLog.Changes {
+			if change.Date < since {
+				continue
+			}
+			entries = append(entries, ChangeFeedEntry{
+				Date:       change.Date,
+				Workflow:   workflowName,
+				Repository: change.Repository,
+				From:       change.From,
+				To:         change.To,
+				NewVersion: change.NewVersion,
+				Added:      change.Added,
+				Removed:    change.Removed,
+			})
+		}
+	}
+
+	// Entries of a workflow are recorded in date order, so a stable sort keeps same-day changes in the order they happened
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Date != entries[j].Date {
+			return entries[i].Date > entries[j].Date
+		}
+		if entries[i].Workflow != entries[j].Workflow {
+			return entries[i].Workflow < entries[j].Workflow
+		}
+		return entries[i].Repository < entries[j].Repository
+	})
+	return entries, nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeStatsTestDB extends the query test database with a metrics history and workflow change logs.
+func writeStatsTestDB(t *testing.T) string {
+	t.Helper()
+
+	dbPath := writeQueryTestDB(t)
+	metrics := "snapshots:\n" +
+		"    - date: \"2026-03-01\"\n      repositories: 2\n      total_uses: 2\n      pinned_uses: 0\n" +
+		"    - date: \"2026-03-08\"\n      repositories: 2\n      total_uses: 4\n      pinned_uses: 1\n      third_party_actions:\n        - codecov/codecov-action\n      findings:\n        - abc\n        - def\n"
+	if err := os.WriteFile(filepath.Join(dbPath, "metrics.yaml"), []byte(metrics), 0644); err != nil {
+		t.Fatalf("failed to write metrics.yaml: %v", err)
+	}
+	changeLogs := map[string]string{
+		"build.yml":   "changes:\n    - date: \"2026-03-01\"\n      repository: repo-a\n      to: hash-one\n      new_version: true\n    - date: \"2026-03-08\"\n      repository: repo-b\n      from: hash-one\n      to: hash-two\n      added: 1\n      removed: 2\n",
+		"release.yml": "changes:\n    - date: \"2026-03-08\"\n      repository: repo-a\n      to: hash-three\n",
+	}
+	for workflow, changeLog := range changeLogs {
+		if err := os.MkdirAll(filepath.Join(dbPath, "workflows", workflow), 0755); err != nil {
+			t.Fatalf("failed to create workflow folder: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dbPath, "workflows", workflow, "changelog.yaml"), []byte(changeLog), 0644); err != nil {
+			t.Fatalf("failed to write changelog.yaml: %v", err)
+		}
+	}
+	return dbPath
+}
+
+func TestBuildScanStats(t *testing.T) {
+	t.Parallel()
+
+	stats, err := buildScanStats(writeStatsTestDB(t))
+	if err != nil {
+		t.Fatalf("buildScanStats returned error: %v", err)
+	}
+	want := ScanStats{Organization: "example-org", Date: "2026-03-08", Repositories: 2, Workflows: 2, TotalUses: 4, PinnedUses: 1, PinnedPercent: 25, ThirdPartyActions: 1, OpenFindings: 2, Snapshots: 2}
+	if *stats != want {
+		t.Fatalf("unexpected stats: %+v", *stats)
+	}
+
+	// A database without metrics reports the indexed repositories and workflows only
+	stats, err = buildScanStats(writeQueryTestDB(t))
+	if err != nil {
+		t.Fatalf("buildScanStats returned error: %v", err)
+	}
+	if stats.Date != "" || stats.Snapshots != 0 || stats.Repositories != 2 || stats.Workflows != 2 {
+		t.Fatalf("unexpected stats without metrics: %+v", *stats)
+	}
+}
+
+func TestBuildChangeFeed(t *testing.T) {
+	t.Parallel()
+
+	dbPath := writeStatsTestDB(t)
+	entries, err := buildChangeFeed(dbPath, "")
+	if err != nil {
+		t.Fatalf("buildChangeFeed returned error: %v", err)
+	}
+	var got []string
+	for _, entry := range entries {
+		got = append(got, fmt.Sprintf("%s %s %s %s->%s", entry.Date, entry.Workflow, entry.Repository, entry.From, entry.To))
+	}
+	want := []string{
+		"2026-03-08 build.yml repo-b hash-one->hash-two",
+		"2026-03-08 release.yml repo-a ->hash-three",
+		"2026-03-01 build.yml repo-a ->hash-one",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected entries:\n%s", strings.Join(got, "\n"))
+	}
+
+	entries, err = buildChangeFeed(dbPath, "2026-03-02")
+	if err != nil {
+		t.Fatalf("buildChangeFeed returned error: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Added != 1 || entries[0].Removed != 2 {
+		t.Fatalf("unexpected entries since 2026-03-02: %+v", entries)
+	}
+
+	if entries, err := buildChangeFeed(t.TempDir(), ""); err != nil || entries == nil || len(entries) != 0 {
+		t.Fatalf("expected an empty feed for an empty database, got %v, %v", entries, err)
+	}
+}
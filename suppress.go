@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ------------------------
+// Section: Rule Suppressions
+// ------------------------
+
+// suppressionRe matches an inline suppression comment such as
+// `# dotgithubindexer:ignore hardcoded-secret,github-token reason=test fixture`.
+var suppressionRe = regexp.MustCompile(`#\s*dotgithubindexer:ignore\s+([A-Za-z0-9_,-]+)(?:\s+reason=(.*))?$`)
+
+// Suppression is an inline comment that acknowledges the findings of some rules on one line.
+type Suppression struct {
+	Line        int // Line the suppression applies to
+	CommentLine int // Line of the comment itself
+	Rules       []string
+	Reason      string
+}
+
+// SuppressedFinding is a finding that was acknowledged by a suppression comment.
+type SuppressedFinding struct {
+	Finding Finding
+	Reason  string
+}
+
+// parseSuppressions finds the suppression comments in a workflow file. A comment at the end of a line
+// applies to that line; a comment on its own line applies to the next line that is not blank or a comment.
+func parseSuppressions(content string) []Suppression {
+	lines := strings.Split(content, "\n")
+	var suppressions []Suppression
+	for i, line := range lines {
+		m := suppressionRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		suppression := Suppression{Line: i + 1, CommentLine: i + 1}
+		for _, rule := range strings.Split(m[1], ",") {
+			if rule != "" {
+				suppression.Rules = append(suppression.Rules, rule)
+			}
+		}
+		suppression.Reason = strings.Trim(strings.TrimSpace(m[2]), `"'`)
+
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			suppression.Line = 0
+			for j := i + 1; j < len(lines); j++ {
+				next := strings.TrimSpace(lines[j])
+				if next != "" && !strings.HasPrefix(next, "#") {
+					suppression.Line = j + 1
+					break
+				}
+			}
+		}
+		suppressions = append(suppressions, suppression)
+	}
+	return suppressions
+}
+
+// applySuppressions splits findings into those still open and those acknowledged by a suppression.
+// Suppressions without a reason are honored but reported with the suppression-missing-reason rule.
+func applySuppressions(findings []Finding, suppressions []Suppression, repoName, filePath string) ([]Finding, []SuppressedFinding) {
+	var open []Finding
+	var suppressed []SuppressedFinding
+	for _, finding := range findings {
+		reason, ok := matchSuppression(finding, suppressions)
+		if !ok {
+			open = append(open, finding)
+			continue
+		}
+		suppressed = append(suppressed, SuppressedFinding{Finding: finding, Reason: reason})
+	}
+
+	for _, suppression := range suppressions {
+		if suppression.Reason == "" {
+			open = append(open, newFinding("suppression-missing-reason", repoName, filePath, suppression.CommentLine,
+				fmt.Sprintf("Suppression of %s has no reason", strings.Join(suppression.Rules, ", "))))
+		}
+	}
+	return open, suppressed
+}
+
+// matchSuppression returns the reason of the suppression covering a finding, if any.
+func matchSuppression(finding Finding, suppressions []Suppression) (string, bool) {
+	for _, suppression := range suppressions {
+		if suppression.Line != finding.Line {
+			continue
+		}
+		for _, rule := range suppression.Rules {
+			if rule == finding.Rule {
+				return suppression.Reason, true
+			}
+		}
+	}
+	return "", false
+}
+
+// generateSuppressionsMarkdown creates a SUPPRESSIONS.md file in the db folder listing every acknowledged finding.
+func generateSuppressionsMarkdown(dbPath, org string) error {
+	var suppressed []SuppressedFinding
+	err := walkIndexedWorkflows(dbPath, func(fileName, repoName, content string) {
+		_, fileSuppressed := analyzeWorkflowWithSuppressions(content, repoName, ".github/workflows/"+fileName)
+		suppressed = append(suppressed, fileSuppressed...)
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(suppressed, func(i, j int) bool {
+		a, b := suppressed[i].Finding, suppressed[j].Finding
+		if a.RepoName != b.RepoName {
+			return a.RepoName < b.RepoName
+		}
+		if a.FilePath != b.FilePath {
+			return a.FilePath < b.FilePath
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Rule < b.Rule
+	})
+
+	var markdownBuilder strings.Builder
+	markdownBuilder.WriteString("# Suppressions\n\n")
+	markdownBuilder.WriteString("This document lists findings acknowledged with a `# dotgithubindexer:ignore` comment. They are not included in [FINDINGS.md](FINDINGS.md).\n\n")
+	markdownBuilder.WriteString("| Rule | Repository | File | Reason |\n")
+	markdownBuilder.WriteString("|------|------------|------|--------|\n")
+
+	if len(suppressed) == 0 {
+		markdownBuilder.WriteString("| *No suppressions* | - | - | - |\n")
+	}
+	defaultBranches := loadDefaultBranches(dbPath)
+	for _, entry := range suppressed {
+		finding := entry.Finding
+		url := fmt.Sprintf("%s#L%d", repositoryFileURL(org, finding.RepoName, defaultBranches, finding.FilePath), finding.Line)
+		reason := strings.ReplaceAll(entry.Reason, "|", "\\|")
+		if reason == "" {
+			reason = "*No reason given*"
+		}
+		markdownBuilder.WriteString(fmt.Sprintf("| %s | %s | [%s:%d](%s) | %s |\n",
+			finding.Rule, finding.RepoName, finding.FilePath, finding.Line, url, reason))
+	}
+
+	markdownBuilder.WriteString("\n*This file is automatically generated after each data collection run.*\n")
+
+	if err := os.WriteFile(filepath.Join(dbPath, "SUPPRESSIONS.md"), []byte(markdownBuilder.String()), 0644); err != nil {
+		return fmt.Errorf("error writing SUPPRESSIONS.md: %v", err)
+	}
+
+	logInfof("Generated SUPPRESSIONS.md with %d suppressions", len(suppressed))
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const suppressedWorkflow = `on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo "::set-output name=a::b" # dotgithubindexer:ignore deprecated-command reason="legacy runner"
+      # dotgithubindexer:ignore deprecated-command,hardcoded-secret
+
+      - run: echo "::set-output name=c::d"
+      - run: echo "::set-output name=e::f"
+`
+
+func TestParseSuppressions(t *testing.T) {
+	t.Parallel()
+
+	suppressions := parseSuppressions(suppressedWorkflow)
+	if len(suppressions) != 2 {
+		t.Fatalf("expected 2 suppressions, got %+v", suppressions)
+	}
+	if suppressions[0].Line != 6 || suppressions[0].Reason != "legacy runner" || strings.Join(suppressions[0].Rules, ",") != "deprecated-command" {
+		t.Fatalf("unexpected trailing suppression: %+v", suppressions[0])
+	}
+	if suppressions[1].Line != 9 || suppressions[1].CommentLine != 7 || suppressions[1].Reason != "" || len(suppressions[1].Rules) != 2 {
+		t.Fatalf("unexpected standalone suppression: %+v", suppressions[1])
+	}
+}
+
+func TestAnalyzeWorkflowWithSuppressions(t *testing.T) {
+	t.Parallel()
+
+	findings, suppressed := analyzeWorkflowWithSuppressions(suppressedWorkflow, "repo-a", ".github/workflows/build.yml")
+	if len(suppressed) != 2 || suppressed[0].Finding.Line != 6 || suppressed[0].Reason != "legacy runner" || suppressed[1].Finding.Line != 9 {
+		t.Fatalf("unexpected suppressed findings: %+v", suppressed)
+	}
+
+	var rules []string
+	for _, finding := range findings {
+		rules = append(rules, finding.Rule)
+	}
+	if strings.Join(rules, ",") != "deprecated-command,suppression-missing-reason" {
+		t.Fatalf("unexpected open findings: %+v", findings)
+	}
+	if findings[0].Line != 10 || findings[1].Line != 7 {
+		t.Fatalf("unexpected finding lines: %+v", findings)
+	}
+
+	if len(analyzeWorkflow(suppressedWorkflow, "repo-a", ".github/workflows/build.yml")) != 2 {
+		t.Fatalf("expected analyzeWorkflow to exclude suppressed findings")
+	}
+}
+
+func TestGenerateSuppressionsMarkdown(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	if err := updateActionIndex(dbPath, "build.yml", "repo-a", "build.yml", "hash-one", ""); err != nil {
+		t.Fatalf("updateActionIndex returned error: %v", err)
+	}
+	if err := storeActionVersion(dbPath, "build.yml", "hash-one", suppressedWorkflow); err != nil {
+		t.Fatalf("storeActionVersion returned error: %v", err)
+	}
+
+	if err := generateSuppressionsMarkdown(dbPath, "example-org"); err != nil {
+		t.Fatalf("generateSuppressionsMarkdown returned error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dbPath, "SUPPRESSIONS.md"))
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+	for _, want := range []string{
+		"| deprecated-command | repo-a | [.github/workflows/build.yml:6](https://github.com/example-org/repo-a/blob/main/.github/workflows/build.yml#L6) | legacy runner |",
+		"| deprecated-command | repo-a | [.github/workflows/build.yml:9](https://github.com/example-org/repo-a/blob/main/.github/workflows/build.yml#L9) | *No reason given* |",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected SUPPRESSIONS.md to contain %q, got:\n%s", want, data)
+		}
+	}
+}
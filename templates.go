@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v50/github"
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Workflow Templates
+// ------------------------
+
+// orgGitHubRepo is the repository holding the organization's workflow templates and profile.
+const orgGitHubRepo = ".github"
+
+// defaultBranchPlaceholder is replaced with the repository's default branch when a workflow is created from a template.
+const defaultBranchPlaceholder = "$default-branch"
+
+// orgGitHubFolder is a directory of the .github repository whose files are indexed, with the database
+// directory they are stored in.
+type orgGitHubFolder struct {
+	RepoDir string
+	DBDir   string
+}
+
+// orgGitHubFolders are the directories of the .github repository that are indexed.
+var orgGitHubFolders = []orgGitHubFolder{
+	{RepoDir: "workflow-templates", DBDir: "templates"},
+	{RepoDir: "profile", DBDir: "profile"},
+}
+
+// OrgGitHubFile is a file of the .github repository's workflow-templates or profile directory.
+type OrgGitHubFile struct {
+	RepoName string
+	DBDir    string // Database directory, such as templates
+	Name     string // File name within the directory
+	FilePath string
+	Content  string
+	Hash     string
+	BlobSHA  string
+}
+
+// TemplateUse is a workflow file created from a workflow template.
+type TemplateUse struct {
+	Repository string `yaml:"repository" json:"repository"`
+	Workflow   string `yaml:"workflow" json:"workflow"`
+}
+
+// TemplateAdoption lists the workflows created from a workflow template. Adopted workflows match the
+// template once its placeholders are filled in; modified ones share its file name but not its content.
+type TemplateAdoption struct {
+	Template string        `yaml:"template" json:"template"`
+	Name     string        `yaml:"name,omitempty" json:"name,omitempty"` // From the template's .properties.json
+	Adopted  []TemplateUse `yaml:"adopted" json:"adopted"`
+	Modified []TemplateUse `yaml:"modified" json:"modified"`
+}
+
+// TemplateAdoptionIndex is stored as templates.yaml to measure how widely each workflow template is used.
+type TemplateAdoptionIndex struct {
+	Organization string             `yaml:"organization"`
+	Templates    []TemplateAdoption `yaml:"templates"`
+}
+
+// isOrgGitHubRepo reports whether a repository is the organization's .github repository.
+func isOrgGitHubRepo(repoName string) bool {
+	return strings.EqualFold(repoName, orgGitHubRepo)
+}
+
+// isWorkflowTemplate reports whether a file of the workflow-templates directory is a template rather
+// than its properties or icon.
+func isWorkflowTemplate(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".yml" || ext == ".yaml"
+}
+
+// fetchOrgGitHubFiles retrieves the workflow templates and profile files of the organization's .github
+// repository. Other repositories have none.
+func fetchOrgGitHubFiles(client *github.Client, repo *github.Repository) ([]OrgGitHubFile, error) {
+	if !isOrgGitHubRepo(repo.GetName()) {
+		return nil, nil
+	}
+	ctx := context.Background()
+	owner := repo.GetOwner().GetLogin()
+	opts := &github.RepositoryContentGetOptions{Ref: getDefaultBranch(repo)}
+
+	var files []OrgGitHubFile
+	var integrityErrs []error
+	for _, folder := range orgGitHubFolders {
+		_, entries, _, err := client.Repositories.GetContents(ctx, owner, repo.GetName(), folder.RepoDir, opts)
+		if err != nil {
+			if isNotFoundError(err) {
+				continue
+			}
+			logErrorf("Error accessing %s in repository '%s': %v", folder.RepoDir, repo.GetName(), err)
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.GetType() != "file" {
+				continue
+			}
+			logDebugf("Found %s file: %s in repository '%s'", folder.RepoDir, entry.GetPath(), repo.GetName())
+			content, err := fetchBlobContent(client, owner, repo.GetName(), entry.GetSHA())
+			if err != nil {
+				logErrorf("Error fetching content for file '%s' in repository '%s': %v", entry.GetPath(), repo.GetName(), err)
+				err = withBlobPath(err, entry.GetPath())
+				if len(blobIntegrityFailures(err)) > 0 {
+					integrityErrs = append(integrityErrs, err)
+					continue
+				}
+				return nil, err
+			}
+			files = append(files, OrgGitHubFile{
+				RepoName: repo.GetName(),
+				DBDir:    folder.DBDir,
+				Name:     entry.GetName(),
+				FilePath: entry.GetPath(),
+				Content:  content,
+				Hash:     computeHash([]byte(content)),
+				BlobSHA:  entry.GetSHA(),
+			})
+		}
+	}
+	if len(integrityErrs) > 0 {
+		return nil, errors.Join(integrityErrs...)
+	}
+	return files, nil
+}
+
+// updateOrgGitHubFileIndex maps the .github repository to a file hash in db/<directory>/<name>/index.yaml.
+func updateOrgGitHubFileIndex(dbPath string, file OrgGitHubFile) error {
+	filePath := filepath.Join(dbPath, file.DBDir, file.Name)
+	if err := os.MkdirAll(filePath, os.ModePerm); err != nil {
+		return err
+	}
+
+	indexPath := filepath.Join(filePath, "index.yaml")
+	index, err := readActionIndex(indexPath)
+	if err != nil {
+		return err
+	}
+	index.Repositories[file.RepoName] = file.Hash
+	recordBlobSHA(index, file.Hash, file.BlobSHA)
+	if err := writeActionIndex(indexPath, index); err != nil {
+		return err
+	}
+
+	logDebugf("Updated %s index for '%s' with repository '%s'", file.DBDir, file.Name, file.RepoName)
+	return nil
+}
+
+// storeOrgGitHubFileVersion saves the content of a .github repository file under its hash.
+func storeOrgGitHubFileVersion(dbPath string, file OrgGitHubFile) error {
+	filePath := filepath.Join(dbPath, file.DBDir, file.Name)
+	if err := os.MkdirAll(versionsDir(filePath), os.ModePerm); err != nil {
+		return err
+	}
+	storedPath := versionPath(filePath, file.Hash)
+	if _, err := os.Stat(storedPath); os.IsNotExist(err) {
+		logDebugf("Storing %s file '%s' under hash '%s'", file.DBDir, file.Name, file.Hash)
+		return writeVersionFile(storedPath, []byte(file.Content))
+	}
+	return nil
+}
+
+// garbageCollectOrgGitHubFiles removes unused versions of the workflow templates and profile files.
+func garbageCollectOrgGitHubFiles(dbPath string) error {
+	for _, folder := range orgGitHubFolders {
+		if err := garbageCollectIndexedFolders(filepath.Join(dbPath, folder.DBDir), folder.RepoDir+" file"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readStoredOrgGitHubFile returns the current content of a file indexed in a directory such as
+// db/templates, or an empty string when it is not indexed.
+func readStoredOrgGitHubFile(dirPath, name string) (string, error) {
+	index, err := readActionIndex(filepath.Join(dirPath, name, "index.yaml"))
+	if err != nil {
+		return "", err
+	}
+	for repoName, hash := range index.Repositories {
+		if !isOrgGitHubRepo(repoName) {
+			continue
+		}
+		content, err := readVersionFile(versionPath(filepath.Join(dirPath, name), hash))
+		if err != nil {
+			return "", err
+		}
+		return string(content), nil
+	}
+	return "", nil
+}
+
+// buildTemplateAdoption compares every indexed workflow with the workflow templates. A workflow is
+// adopted when it equals a template with $default-branch replaced by its repository's default branch.
+func buildTemplateAdoption(dbPath, org string) (*TemplateAdoptionIndex, error) {
+	index := &TemplateAdoptionIndex{Organization: org, Templates: []TemplateAdoption{}}
+	templatesPath := filepath.Join(dbPath, "templates")
+	names, err := readSubdirectories(templatesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := make(map[string]string)
+	for _, name := range names {
+		if !isWorkflowTemplate(name) {
+			continue
+		}
+		content, err := readStoredOrgGitHubFile(templatesPath, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template '%s': %v", name, err)
+		}
+		if content == "" {
+			continue
+		}
+		adoption := TemplateAdoption{Template: name, Adopted: []TemplateUse{}, Modified: []TemplateUse{}}
+		properties, err := readStoredOrgGitHubFile(templatesPath, strings.TrimSuffix(name, filepath.Ext(name))+".properties.json")
+		if err == nil && properties != "" {
+			var metadata struct {
+				Name string `json:"name"`
+			}
+			if json.Unmarshal([]byte(properties), &metadata) == nil {
+				adoption.Name = metadata.Name
+			}
+		}
+		contents[name] = content
+		index.Templates = append(index.Templates, adoption)
+	}
+	if len(index.Templates) == 0 {
+		return index, nil
+	}
+
+	defaultBranches := make(map[string]string)
+	if manifest, err := loadRepositoryManifest(dbPath); err == nil {
+		defaultBranches = manifest.DefaultBranches
+	}
+	err = walkIndexedWorkflows(dbPath, func(fileName, repoName, content string) {
+		if isOrgGitHubRepo(repoName) {
+			return
+		}
+		branch := defaultBranches[repoName]
+		if branch == "" {
+			branch = "main"
+		}
+		use := TemplateUse{Repository: repoName, Workflow: ".github/workflows/" + fileName}
+		for i := range index.Templates {
+			template := &index.Templates[i]
+			if strings.ReplaceAll(contents[template.Template], defaultBranchPlaceholder, branch) == content {
+				template.Adopted = append(template.Adopted, use)
+			} else if workflowIdentity(fileName) == workflowIdentity(template.Template) {
+				template.Modified = append(template.Modified, use)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// generateTemplateAdoption writes templates.yaml and TEMPLATES.md to the database.
+func generateTemplateAdoption(dbPath, org string) error {
+	index, err := buildTemplateAdoption(dbPath, org)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("error marshaling templates.yaml: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dbPath, "templates.yaml"), data, 0644); err != nil {
+		return fmt.Errorf("error writing templates.yaml: %v", err)
+	}
+
+	var markdownBuilder strings.Builder
+	markdownBuilder.WriteString("# Workflow Templates\n\n")
+	markdownBuilder.WriteString(fmt.Sprintf("This document lists the workflow templates of the %s/%s repository and the workflows created from them. Adopted workflows match the template with `%s` filled in; modified ones share its file name but have since been changed.\n\n", org, orgGitHubRepo, defaultBranchPlaceholder))
+	markdownBuilder.WriteString("| Template | Name | Adopted | Modified | Repositories |\n")
+	markdownBuilder.WriteString("|----------|------|---------|----------|--------------|\n")
+	for _, template := range index.Templates {
+		repos := make(map[string]bool)
+		for _, use := range append(append([]TemplateUse{}, template.Adopted...), template.Modified...) {
+			repos[use.Repository] = true
+		}
+		repoLinks := make([]string, 0, len(repos))
+		for repoName := range repos {
+			repoLinks = append(repoLinks, fmt.Sprintf("[%s](%s/%s/%s)", repoName, githubWebURL, org, repoName))
+		}
+		sort.Strings(repoLinks)
+		name := template.Name
+		if name == "" {
+			name = "-"
+		}
+		markdownBuilder.WriteString(fmt.Sprintf("| [%s](%s/%s/%s/blob/main/workflow-templates/%s) | %s | %d | %d | %s |\n",
+			template.Template, githubWebURL, org, orgGitHubRepo, template.Template, name, len(template.Adopted), len(template.Modified), strings.Join(repoLinks, ", ")))
+	}
+	if len(index.Templates) == 0 {
+		markdownBuilder.WriteString("| *No workflow templates* | - | - | - | - |\n")
+	}
+
+	markdownBuilder.WriteString("\n*This file is automatically generated after each data collection run.*\n")
+
+	if err := os.WriteFile(filepath.Join(dbPath, "TEMPLATES.md"), []byte(markdownBuilder.String()), 0644); err != nil {
+		return fmt.Errorf("error writing TEMPLATES.md: %v", err)
+	}
+
+	logInfof("Generated templates.yaml and TEMPLATES.md for %d workflow templates", len(index.Templates))
+	return nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v50/github"
+)
+
+func TestFetchOrgGitHubFiles(t *testing.T) {
+	t.Parallel()
+
+	templateContent := "on:\n  push:\n    branches: [$default-branch]\njobs:\n  build:\n    runs-on: ubuntu-latest\n"
+	propertiesContent := `{"name": "Go CI", "description": "Build and test"}`
+	profileContent := "# Example Org\n"
+	files := map[string]string{
+		"workflow-templates/ci.yml":             templateContent,
+		"workflow-templates/ci.properties.json": propertiesContent,
+		"profile/README.md":                     profileContent,
+	}
+	blobs := make(map[string]string)
+	listings := make(map[string][]string)
+	for filePath, content := range files {
+		sha := computeBlobSHA([]byte(content))
+		blobs[sha] = content
+		dir, name := filepath.Split(filePath)
+		listings[strings.TrimSuffix(dir, "/")] = append(listings[strings.TrimSuffix(dir, "/")], fmt.Sprintf(`{"type":"file","name":%q,"path":%q,"sha":%q}`, name, filePath, sha))
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if dir, ok := strings.CutPrefix(r.URL.Path, "/repos/example-org/.github/contents/"); ok && listings[dir] != nil {
+			fmt.Fprintf(w, "[%s]", strings.Join(listings[dir], ","))
+			return
+		}
+		if sha, ok := strings.CutPrefix(r.URL.Path, "/repos/example-org/.github/git/blobs/"); ok && blobs[sha] != "" {
+			fmt.Fprintf(w, `{"sha":%q,"size":%d,"encoding":"base64","content":%q}`, sha, len(blobs[sha]), base64.StdEncoding.EncodeToString([]byte(blobs[sha])))
+			return
+		}
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	owner := &github.User{Login: github.String("example-org")}
+
+	other, err := fetchOrgGitHubFiles(client, &github.Repository{Name: github.String("repo-a"), Owner: owner})
+	if err != nil || other != nil {
+		t.Fatalf("expected no files for another repository, got %+v, %v", other, err)
+	}
+	fetched, err := fetchOrgGitHubFiles(client, &github.Repository{Name: github.String(".github"), Owner: owner, DefaultBranch: github.String("main")})
+	if err != nil {
+		t.Fatalf("fetchOrgGitHubFiles returned error: %v", err)
+	}
+	if len(fetched) != 3 || fetched[len(fetched)-1].DBDir != "profile" || fetched[len(fetched)-1].Content != profileContent {
+		t.Fatalf("unexpected files: %+v", fetched)
+	}
+
+	dbPath := t.TempDir()
+	for _, file := range fetched {
+		if err := updateOrgGitHubFileIndex(dbPath, file); err != nil {
+			t.Fatalf("updateOrgGitHubFileIndex returned error: %v", err)
+		}
+		if err := storeOrgGitHubFileVersion(dbPath, file); err != nil {
+			t.Fatalf("storeOrgGitHubFileVersion returned error: %v", err)
+		}
+	}
+	if content, err := readStoredOrgGitHubFile(filepath.Join(dbPath, "profile"), "README.md"); err != nil || content != profileContent {
+		t.Fatalf("unexpected stored profile README.md: %q, %v", content, err)
+	}
+
+	stale := filepath.Join(dbPath, "templates", "ci.yml", strings.Repeat("0", 64))
+	if err := os.WriteFile(stale, []byte("on: push\n"), 0644); err != nil {
+		t.Fatalf("failed to write stale version: %v", err)
+	}
+	if err := garbageCollectOrgGitHubFiles(dbPath); err != nil {
+		t.Fatalf("garbageCollectOrgGitHubFiles returned error: %v", err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("expected the unused version to be removed, got err=%v", err)
+	}
+}
+
+func TestGenerateTemplateAdoption(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dbPath, "repositories.yaml"), []byte("organization: example-org\nrepositories:\n    - repo-a\n    - repo-b\ndefault_branches:\n    repo-a: develop\n"), 0644); err != nil {
+		t.Fatalf("failed to write repositories.yaml: %v", err)
+	}
+
+	template := "on:\n  push:\n    branches: [$default-branch]\n"
+	for _, file := range []OrgGitHubFile{
+		{RepoName: ".github", DBDir: "templates", Name: "ci.yml", Content: template, Hash: computeHash([]byte(template))},
+		{RepoName: ".github", DBDir: "templates", Name: "ci.properties.json", Content: `{"name": "Go CI"}`, Hash: computeHash([]byte(`{"name": "Go CI"}`))},
+	} {
+		if err := updateOrgGitHubFileIndex(dbPath, file); err != nil {
+			t.Fatalf("updateOrgGitHubFileIndex returned error: %v", err)
+		}
+		if err := storeOrgGitHubFileVersion(dbPath, file); err != nil {
+			t.Fatalf("storeOrgGitHubFileVersion returned error: %v", err)
+		}
+	}
+
+	workflows := map[string]map[string]string{
+		"ci.yml":  {"repo-a": "on:\n  push:\n    branches: [develop]\n"},
+		"ci.yaml": {"repo-b": "on:\n  push:\n    branches: [main, release]\n"},
+	}
+	for fileName, repos := range workflows {
+		for repoName, content := range repos {
+			hash := computeHash([]byte(content))
+			if err := updateActionIndex(dbPath, workflowIdentity(fileName), repoName, fileName, hash, ""); err != nil {
+				t.Fatalf("updateActionIndex returned error: %v", err)
+			}
+			if err := storeActionVersion(dbPath, workflowIdentity(fileName), hash, content); err != nil {
+				t.Fatalf("storeActionVersion returned error: %v", err)
+			}
+		}
+	}
+
+	if err := generateTemplateAdoption(dbPath, "example-org"); err != nil {
+		t.Fatalf("generateTemplateAdoption returned error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dbPath, "templates.yaml"))
+	if err != nil {
+		t.Fatalf("failed to read templates.yaml: %v", err)
+	}
+	want := "organization: example-org\ntemplates:\n    - template: ci.yml\n      name: Go CI\n" +
+		"      adopted:\n        - repository: repo-a\n          workflow: .github/workflows/ci.yml\n" +
+		"      modified:\n        - repository: repo-b\n          workflow: .github/workflows/ci.yaml\n"
+	if string(data) != want {
+		t.Fatalf("unexpected templates.yaml:\n%s", data)
+	}
+
+	markdown, err := os.ReadFile(filepath.Join(dbPath, "TEMPLATES.md"))
+	if err != nil {
+		t.Fatalf("failed to read TEMPLATES.md: %v", err)
+	}
+	if !strings.Contains(string(markdown), "| [ci.yml](https://github.com/example-org/.github/blob/main/workflow-templates/ci.yml) | Go CI | 1 | 1 | [repo-a](https://github.com/example-org/repo-a), [repo-b](https://github.com/example-org/repo-b) |") {
+		t.Fatalf("unexpected TEMPLATES.md:\n%s", markdown)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"time"
+	_ "time/tzdata" // Embed the timezone database so -timezone works on minimal systems
+)
+
+// ------------------------
+// Section: Report Timezone
+// ------------------------
+
+// reportLocation is the timezone used to render every date and time written to the database.
+var reportLocation = time.UTC
+
+// setReportTimezone configures the timezone used in reports from an IANA name such as "America/New_York".
+func setReportTimezone(name string) error {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Errorf("invalid timezone '%s': %v", name, err)
+	}
+	reportLocation = location
+	return nil
+}
+
+// formatReportDate renders a date in the report timezone as YYYY-MM-DD.
+func formatReportDate(t time.Time) string {
+	return t.In(reportLocation).Format("2006-01-02")
+}
+
+// formatReportTime renders a timestamp in the report timezone including the zone abbreviation.
+func formatReportTime(t time.Time) string {
+	return t.In(reportLocation).Format("2006-01-02 15:04:05 MST")
+}
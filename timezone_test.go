@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatReportTimesInConfiguredTimezone(t *testing.T) {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+
+	// Not parallel: reportLocation is package state
+	previous := reportLocation
+	t.Cleanup(func() { reportLocation = previous })
+	reportLocation = location
+
+	instant := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if got := formatReportDate(instant); got != "2024-01-01" {
+		t.Fatalf("formatReportDate = %q, want %q", got, "2024-01-01")
+	}
+	if got := formatReportTime(instant); got != "2024-01-01 22:04:05 EST" {
+		t.Fatalf("formatReportTime = %q, want %q", got, "2024-01-01 22:04:05 EST")
+	}
+}
+
+func TestSetReportTimezoneRejectsUnknownZone(t *testing.T) {
+	t.Parallel()
+
+	if err := setReportTimezone("Not/AZone"); err == nil {
+		t.Fatal("expected error for unknown timezone")
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v50/github"
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Workflow Token Power
+// ------------------------
+
+// Default GITHUB_TOKEN permissions a repository can grant to workflows.
+const (
+	DefaultPermissionsRead  = "read"
+	DefaultPermissionsWrite = "write"
+)
+
+// Effective GITHUB_TOKEN power of a job or workflow, from least to most privileged.
+const (
+	TokenPowerUnknown  = "unknown"
+	TokenPowerNone     = "none"
+	TokenPowerRead     = "read"
+	TokenPowerWrite    = "write"
+	TokenPowerWriteAll = "write-all"
+)
+
+// tokenPowerRank orders token power levels; unknown ranks lowest since nothing can be said about it.
+var tokenPowerRank = map[string]int{
+	TokenPowerUnknown:  0,
+	TokenPowerNone:     1,
+	TokenPowerRead:     2,
+	TokenPowerWrite:    3,
+	TokenPowerWriteAll: 4,
+}
+
+// WorkflowTokenDefaults are the repository settings that apply to the GITHUB_TOKEN of every workflow.
+type WorkflowTokenDefaults struct {
+	DefaultPermissions     string `yaml:"default_permissions" json:"default_workflow_permissions"`
+	CanApprovePullRequests bool   `yaml:"can_approve_pull_requests" json:"can_approve_pull_request_reviews"`
+}
+
+// TokenDefaultsIndex maps repository names to their workflow token settings. It is stored as workflow_permissions.yaml.
+type TokenDefaultsIndex struct {
+	Repositories map[string]WorkflowTokenDefaults `yaml:"repositories"`
+}
+
+// WorkflowTokenPower is the most privileged token any job of a workflow runs with.
+type WorkflowTokenPower struct {
+	RepoName               string
+	FilePath               string
+	Power                  string
+	WriteScopes            []string // Scopes granted write access; every scope for write-all
+	CanApprovePullRequests bool     // The token can create and approve pull requests
+}
+
+// fetchWorkflowTokenDefaults retrieves the default workflow token permissions of a repository. It returns
+// nil when the settings cannot be read, as when the token lacks administration access to the repository.
+func fetchWorkflowTokenDefaults(client *github.Client, repo *github.Repository) (*WorkflowTokenDefaults, error) {
+	ctx := context.Background()
+	// The endpoint is not covered by the client library, so the request is built directly
+	u := fmt.Sprintf("repos/%s/%s/actions/permissions/workflow", repo.GetOwner().GetLogin(), repo.GetName())
+	req, err := client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	defaults := new(WorkflowTokenDefaults)
+	if _, err := client.Do(ctx, req, defaults); err != nil {
+		if errResp, ok := err.(*github.ErrorResponse); ok && errResp.Response != nil &&
+			(errResp.Response.StatusCode == http.StatusNotFound || errResp.Response.StatusCode == http.StatusForbidden) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return defaults, nil
+}
+
+// jobTokenPower classifies the token a job runs with and lists the scopes it can write. Jobs without a
+// permissions block get the repository default, which is unknown when defaults is nil.
+func jobTokenPower(job EffectiveJob, defaults *WorkflowTokenDefaults) (string, []string) {
+	perms := job.Permissions
+	if perms == nil {
+		switch {
+		case defaults == nil:
+			return TokenPowerUnknown, nil
+		case defaults.DefaultPermissions == DefaultPermissionsWrite:
+			return TokenPowerWriteAll, nil
+		default:
+			return TokenPowerRead, nil
+		}
+	}
+
+	switch perms.All {
+	case "write-all":
+		return TokenPowerWriteAll, nil
+	case "read-all":
+		return TokenPowerRead, nil
+	case "":
+	default:
+		// An expression or an unrecognized value
+		return TokenPowerUnknown, nil
+	}
+
+	if len(perms.Scopes) == 0 {
+		return TokenPowerNone, nil
+	}
+	var writeScopes []string
+	for scope, level := range perms.Scopes {
+		if level == "write" {
+			writeScopes = append(writeScopes, scope)
+		}
+	}
+	if len(writeScopes) > 0 {
+		sort.Strings(writeScopes)
+		return TokenPowerWrite, writeScopes
+	}
+	return TokenPowerRead, nil
+}
+
+// computeWorkflowTokenPower combines the permissions of each job in a workflow with the repository
+// defaults to find the most privileged token the workflow runs with.
+func computeWorkflowTokenPower(repoName, filePath, content string, defaults *WorkflowTokenDefaults) (WorkflowTokenPower, bool) {
+	jobs, err := resolveWorkflowJobs(content)
+	if err != nil || len(jobs) == 0 {
+		return WorkflowTokenPower{}, false
+	}
+
+	result := WorkflowTokenPower{RepoName: repoName, FilePath: filePath, Power: TokenPowerUnknown}
+	scopes := make(map[string]bool)
+	for i, job := range jobs {
+		power, writeScopes := jobTokenPower(job, defaults)
+		if i == 0 || tokenPowerRank[power] > tokenPowerRank[result.Power] {
+			result.Power = power
+		}
+		for _, scope := range writeScopes {
+			scopes[scope] = true
+		}
+	}
+	result.WriteScopes = sortedKeys(scopes)
+	if result.Power == TokenPowerWriteAll {
+		result.WriteScopes = []string{"all"}
+	}
+	result.CanApprovePullRequests = defaults != nil && defaults.CanApprovePullRequests &&
+		(result.Power == TokenPowerWriteAll || scopes["pull-requests"])
+	return result, true
+}
+
+// analyzeTokenDefaults reports jobs that have no permissions block in a repository whose default token
+// can write. Inline suppressions in the workflow apply.
+func analyzeTokenDefaults(repoName, filePath, content string, defaults *WorkflowTokenDefaults) []Finding {
+	if defaults == nil || defaults.DefaultPermissions != DefaultPermissionsWrite {
+		return nil
+	}
+	jobs, err := resolveWorkflowJobs(content)
+	if err != nil {
+		return nil
+	}
+
+	var findings []Finding
+	for _, job := range jobs {
+		if job.Permissions != nil {
+			continue
+		}
+		findings = append(findings, newFinding("default-write-token", repoName, filePath, job.Line,
+			fmt.Sprintf("Job '%s' sets no permissions and gets the repository's read and write default token", job.ID)))
+	}
+	open, _ := applySuppressions(findings, parseSuppressions(content), repoName, filePath)
+	return open
+}
+
+// analyzePullRequestApproval reports repositories that let GitHub Actions create and approve pull requests.
+func analyzePullRequestApproval(org, repoName string, defaults *WorkflowTokenDefaults) []Finding {
+	if defaults == nil || !defaults.CanApprovePullRequests {
+		return nil
+	}
+	finding := newFinding("actions-can-approve-pull-requests", repoName, "settings/actions", 0,
+		"GitHub Actions is allowed to create and approve pull requests")
+	finding.URL = fmt.Sprintf("%s/%s/%s/settings/actions", githubWebURL, org, repoName)
+	return []Finding{finding}
+}
+
+// loadTokenDefaultsIndex reads workflow_permissions.yaml from the database, returning an empty index if it does not exist.
+func loadTokenDefaultsIndex(dbPath string) (*TokenDefaultsIndex, error) {
+	index := &TokenDefaultsIndex{Repositories: make(map[string]WorkflowTokenDefaults)}
+	data, err := os.ReadFile(filepath.Join(dbPath, "workflow_permissions.yaml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return index, nil
+		}
+		return nil, fmt.Errorf("error reading workflow_permissions.yaml: %v", err)
+	}
+	if err := yaml.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("error parsing workflow_permissions.yaml: %v", err)
+	}
+	if index.Repositories == nil {
+		index.Repositories = make(map[string]WorkflowTokenDefaults)
+	}
+	return index, nil
+}
+
+// updateTokenDefaultsIndex replaces the workflow token settings recorded for a repository. A nil value
+// removes the repository, since its settings could not be read.
+func updateTokenDefaultsIndex(dbPath, repoName string, defaults *WorkflowTokenDefaults) error {
+	index, err := loadTokenDefaultsIndex(dbPath)
+	if err != nil {
+		return err
+	}
+	if defaults == nil {
+		delete(index.Repositories, repoName)
+	} else {
+		index.Repositories[repoName] = *defaults
+	}
+
+	data, err := yaml.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("error marshaling workflow_permissions.yaml: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dbPath, "workflow_permissions.yaml"), data, 0644); err != nil {
+		return fmt.Errorf("error writing workflow_permissions.yaml: %v", err)
+	}
+	return nil
+}
+
+// tokenDefaultsFor returns the recorded settings of a repository, or nil when they are unknown.
+func (index *TokenDefaultsIndex) tokenDefaultsFor(repoName string) *WorkflowTokenDefaults {
+	defaults, ok := index.Repositories[repoName]
+	if !ok {
+		return nil
+	}
+	return &defaults
+}
+
+// generateTokenPowerMarkdown creates a PERMISSIONS.md file listing the effective token power of every
+// indexed workflow, most privileged first.
+func generateTokenPowerMarkdown(dbPath, org string) error {
+	index, err := loadTokenDefaultsIndex(dbPath)
+	if err != nil {
+		return err
+	}
+
+	var workflows []WorkflowTokenPower
+	err = walkIndexedWorkflows(dbPath, func(fileName, repoName, content string) {
+		if power, ok := computeWorkflowTokenPower(repoName, ".github/workflows/"+fileName, content, index.tokenDefaultsFor(repoName)); ok {
+			workflows = append(workflows, power)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(workflows, func(i, j int) bool {
+		if tokenPowerRank[workflows[i].Power] != tokenPowerRank[workflows[j].Power] {
+			return tokenPowerRank[workflows[i].Power] > tokenPowerRank[workflows[j].Power]
+		}
+		if workflows[i].RepoName != workflows[j].RepoName {
+			return workflows[i].RepoName < workflows[j].RepoName
+		}
+		return workflows[i].FilePath < workflows[j].FilePath
+	})
+
+	var markdownBuilder strings.Builder
+	markdownBuilder.WriteString("# Workflow Token Permissions\n\n")
+	markdownBuilder.WriteString("This document lists the most privileged `GITHUB_TOKEN` each workflow runs with, combining the `permissions` declared in the workflow with the repository's default workflow permissions. Jobs without a `permissions` block get the repository default; it is shown as unknown when the repository settings could not be read.\n\n")
+	markdownBuilder.WriteString("| Repository | Workflow | Repository Default | Effective Token | Write Scopes | Can Approve PRs |\n")
+	markdownBuilder.WriteString("|------------|----------|--------------------|-----------------|--------------|-----------------|\n")
+	if len(workflows) == 0 {
+		markdownBuilder.WriteString("| *No workflows* | - | - | - | - | - |\n")
+	}
+	for _, workflow := range workflows {
+		repoDefault := TokenPowerUnknown
+		if defaults := index.tokenDefaultsFor(workflow.RepoName); defaults != nil {
+			repoDefault = defaults.DefaultPermissions
+		}
+		writeScopes := "-"
+		if len(workflow.WriteScopes) > 0 {
+			writeScopes = "`" + strings.Join(workflow.WriteScopes, "`, `") + "`"
+		}
+		approve := "no"
+		if workflow.CanApprovePullRequests {
+			approve = "**yes**"
+		}
+		link := fmt.Sprintf("%s/%s/%s/blob/main/%s", githubWebURL, org, workflow.RepoName, workflow.FilePath)
+		markdownBuilder.WriteString(fmt.Sprintf("| %s | [%s](%s) | %s | %s | %s | %s |\n",
+			workflow.RepoName, filepath.Base(workflow.FilePath), link, repoDefault, workflow.Power, writeScopes, approve))
+	}
+
+	markdownBuilder.WriteString("\n*This file is automatically generated after each data collection run.*\n")
+
+	if err := os.WriteFile(filepath.Join(dbPath, "PERMISSIONS.md"), []byte(markdownBuilder.String()), 0644); err != nil {
+		return fmt.Errorf("error writing PERMISSIONS.md: %v", err)
+	}
+
+	logInfof("Generated PERMISSIONS.md with %d workflows", len(workflows))
+	return nil
+}
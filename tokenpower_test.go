@@ -0,0 +1,166 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v50/github"
+)
+
+func TestFetchWorkflowTokenDefaults(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/example-org/repo-a/actions/permissions/workflow":
+			w.Write([]byte(`{"default_workflow_permissions":"write","can_approve_pull_request_reviews":true}`))
+		default:
+			http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	repo := func(name string) *github.Repository {
+		return &github.Repository{Name: github.String(name), Owner: &github.User{Login: github.String("example-org")}}
+	}
+
+	defaults, err := fetchWorkflowTokenDefaults(client, repo("repo-a"))
+	if err != nil {
+		t.Fatalf("fetchWorkflowTokenDefaults returned error: %v", err)
+	}
+	if defaults == nil || defaults.DefaultPermissions != DefaultPermissionsWrite || !defaults.CanApprovePullRequests {
+		t.Fatalf("unexpected defaults: %+v", defaults)
+	}
+
+	defaults, err = fetchWorkflowTokenDefaults(client, repo("repo-b"))
+	if err != nil || defaults != nil {
+		t.Fatalf("expected unknown defaults for an unreadable repository, got %+v, %v", defaults, err)
+	}
+}
+
+func TestComputeWorkflowTokenPower(t *testing.T) {
+	t.Parallel()
+
+	content := `permissions:
+  contents: read
+jobs:
+  build:
+    steps:
+      - run: make
+  release:
+    permissions:
+      contents: write
+      pull-requests: write
+    steps:
+      - run: make release
+`
+	write := &WorkflowTokenDefaults{DefaultPermissions: DefaultPermissionsWrite, CanApprovePullRequests: true}
+	power, ok := computeWorkflowTokenPower("repo-a", ".github/workflows/build.yml", content, write)
+	if !ok || power.Power != TokenPowerWrite || strings.Join(power.WriteScopes, ",") != "contents,pull-requests" || !power.CanApprovePullRequests {
+		t.Fatalf("unexpected token power: %+v", power)
+	}
+
+	inherited := "jobs:\n  build:\n    steps:\n      - run: make\n"
+	cases := []struct {
+		defaults *WorkflowTokenDefaults
+		want     string
+	}{
+		{nil, TokenPowerUnknown},
+		{&WorkflowTokenDefaults{DefaultPermissions: DefaultPermissionsRead}, TokenPowerRead},
+		{write, TokenPowerWriteAll},
+	}
+	for _, tc := range cases {
+		power, _ := computeWorkflowTokenPower("repo-a", ".github/workflows/build.yml", inherited, tc.defaults)
+		if power.Power != tc.want {
+			t.Fatalf("expected %s with defaults %+v, got %+v", tc.want, tc.defaults, power)
+		}
+	}
+
+	none, _ := computeWorkflowTokenPower("repo-a", ".github/workflows/build.yml", "permissions: {}\n"+inherited, write)
+	if none.Power != TokenPowerNone || none.CanApprovePullRequests {
+		t.Fatalf("unexpected token power for empty permissions: %+v", none)
+	}
+}
+
+func TestAnalyzeTokenDefaults(t *testing.T) {
+	t.Parallel()
+
+	content := `jobs:
+  build:
+    steps:
+      - run: make
+  # dotgithubindexer:ignore default-write-token reason="pushes tags"
+  tag:
+    steps:
+      - run: git push --tags
+  lint:
+    permissions:
+      contents: read
+    steps:
+      - run: make lint
+`
+	write := &WorkflowTokenDefaults{DefaultPermissions: DefaultPermissionsWrite, CanApprovePullRequests: true}
+	findings := analyzeTokenDefaults("repo-a", ".github/workflows/ci.yml", content, write)
+	if len(findings) != 1 || findings[0].Rule != "default-write-token" || findings[0].Line != 2 {
+		t.Fatalf("expected one finding for the build job, got %+v", findings)
+	}
+	if findings := analyzeTokenDefaults("repo-a", ".github/workflows/ci.yml", content, &WorkflowTokenDefaults{DefaultPermissions: DefaultPermissionsRead}); len(findings) != 0 {
+		t.Fatalf("expected no findings with a read default, got %+v", findings)
+	}
+
+	approval := analyzePullRequestApproval("example-org", "repo-a", write)
+	if len(approval) != 1 || approval[0].URL != "https://github.com/example-org/repo-a/settings/actions" {
+		t.Fatalf("unexpected approval findings: %+v", approval)
+	}
+}
+
+func TestGenerateTokenPowerMarkdown(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	actionPath := filepath.Join(dbPath, "workflows", "build.yml")
+	if err := os.MkdirAll(actionPath, 0755); err != nil {
+		t.Fatalf("MkdirAll returned error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(actionPath, "hash-one"), []byte("jobs:\n  build:\n    steps:\n      - run: make\n"), 0644); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(actionPath, "index.yaml"), []byte("repositories:\n    repo-a: hash-one\n    repo-b: hash-one\n"), 0644); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	if err := updateTokenDefaultsIndex(dbPath, "repo-a", &WorkflowTokenDefaults{DefaultPermissions: DefaultPermissionsWrite, CanApprovePullRequests: true}); err != nil {
+		t.Fatalf("updateTokenDefaultsIndex returned error: %v", err)
+	}
+
+	if err := generateTokenPowerMarkdown(dbPath, "example-org"); err != nil {
+		t.Fatalf("generateTokenPowerMarkdown returned error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dbPath, "PERMISSIONS.md"))
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+	content := string(data)
+	writeAll := strings.Index(content, "| repo-a | [build.yml](https://github.com/example-org/repo-a/blob/main/.github/workflows/build.yml) | write | write-all | `all` | **yes** |")
+	unknown := strings.Index(content, "| repo-b | [build.yml](https://github.com/example-org/repo-b/blob/main/.github/workflows/build.yml) | unknown | unknown | - | no |")
+	if writeAll < 0 || unknown < 0 || writeAll > unknown {
+		t.Fatalf("unexpected PERMISSIONS.md:\n%s", content)
+	}
+
+	if err := updateTokenDefaultsIndex(dbPath, "repo-a", nil); err != nil {
+		t.Fatalf("updateTokenDefaultsIndex returned error: %v", err)
+	}
+	index, err := loadTokenDefaultsIndex(dbPath)
+	if err != nil {
+		t.Fatalf("loadTokenDefaultsIndex returned error: %v", err)
+	}
+	if index.tokenDefaultsFor("repo-a") != nil {
+		t.Fatalf("expected unreadable settings to remove the repository, got %+v", index.Repositories)
+	}
+}
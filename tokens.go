@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ------------------------
+// Section: Token Rotation
+// ------------------------
+
+// tokenList is the value of -token, which may be given more than once or hold comma-separated tokens.
+type tokenList []string
+
+func (l *tokenList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *tokenList) Set(value string) error {
+	for _, t := range strings.Split(value, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			*l = append(*l, t)
+		}
+	}
+	return nil
+}
+
+// readTokenFile reads tokens from a file, one per line, skipping blank lines and lines starting with #.
+func readTokenFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token file: %v", err)
+	}
+	var tokens []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		tokens = append(tokens, line)
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("token file '%s' holds no tokens", path)
+	}
+	return tokens, nil
+}
+
+// tokenRate is the rate limit last reported for a token and resource.
+type tokenRate struct {
+	remaining int
+	reset     time.Time
+}
+
+// tokenPool authenticates API requests with one of several tokens, switching to the token with the most
+// requests left when the current one drops below a threshold, so that a scan can use the combined quota of
+// every token. The quota of each token is read from the rate limit headers of its responses, per resource,
+// since GraphQL and REST requests count against separate limits.
+type tokenPool struct {
+	tokens    []string
+	threshold int
+	base      http.RoundTripper
+	mu        sync.Mutex
+	current   int
+	rates     []map[string]tokenRate // Per token, resource: last reported rate limit
+	rotations int
+}
+
+// githubTokens rotates the tokens API clients use when -token is given more than once; nil uses -token alone.
+var githubTokens *tokenPool
+
+// newTokenPool creates a pool of tokens that sends requests through base.
+func newTokenPool(tokens []string, threshold int, base http.RoundTripper) *tokenPool {
+	rates := make([]map[string]tokenRate, len(tokens))
+	for i := range rates {
+		rates[i] = make(map[string]tokenRate)
+	}
+	return &tokenPool{tokens: tokens, threshold: threshold, base: base, rates: rates}
+}
+
+// RoundTrip sends a request with the current token and records the rate limit of its response.
+func (p *tokenPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	p.mu.Lock()
+	i := p.current
+	p.mu.Unlock()
+
+	authorized := req.Clone(req.Context())
+	authorized.Header.Set("Authorization", "Bearer "+p.tokens[i])
+	resp, err := p.base.RoundTrip(authorized)
+	if err != nil {
+		return nil, err
+	}
+	p.observe(i, resp.Header, time.Now())
+	return resp, nil
+}
+
+// observe records the rate limit a response of token i reports, rotating when the current token is low.
+func (p *tokenPool) observe(i int, header http.Header, now time.Time) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+	resource := header.Get("X-RateLimit-Resource")
+	if resource == "" {
+		resource = "core"
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rates[i][resource] = tokenRate{remaining: remaining, reset: time.Unix(reset, 0)}
+	if i == p.current && remaining < p.threshold {
+		p.rotate(now)
+	}
+}
+
+// headroom returns the fewest requests token i has left of any resource. A token not used yet, and a limit
+// that has since reset, count as unlimited.
+func (p *tokenPool) headroom(i int, now time.Time) int {
+	fewest := math.MaxInt
+	for _, rate := range p.rates[i] {
+		if rate.reset.After(now) && rate.remaining < fewest {
+			fewest = rate.remaining
+		}
+	}
+	return fewest
+}
+
+// rotate switches to the token with the most requests left, if it has more than the current one. When
+// every token is low the current one is kept, and checkRateLimit waits for its limit to reset.
+func (p *tokenPool) rotate(now time.Time) {
+	best, bestHeadroom := p.current, p.headroom(p.current, now)
+	for i := range p.tokens {
+		if headroom := p.headroom(i, now); headroom > bestHeadroom {
+			best, bestHeadroom = i, headroom
+		}
+	}
+	if best == p.current {
+		return
+	}
+	logInfof("Token %d of %d is below %d remaining requests; switching to token %d", p.current+1, len(p.tokens), p.threshold, best+1)
+	p.current = best
+	p.rotations++
+}
+
+// stats returns how many times the pool switched tokens.
+func (p *tokenPool) stats() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.rotations
+}
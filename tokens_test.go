@@ -0,0 +1,121 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTokenPoolRotates(t *testing.T) {
+	t.Parallel()
+
+	reset := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	var mu sync.Mutex
+	remaining := map[string]int{"Bearer token-a": 400, "Bearer token-b": 4000}
+	var used []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		auth := r.Header.Get("Authorization")
+		used = append(used, strings.TrimPrefix(auth, "Bearer "))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining[auth]))
+		w.Header().Set("X-RateLimit-Reset", reset)
+		if r.URL.Path == "/graphql" {
+			w.Header().Set("X-RateLimit-Resource", "graphql")
+		}
+	}))
+	defer server.Close()
+
+	pool := newTokenPool([]string{"token-a", "token-b"}, 500, http.DefaultTransport)
+	client := &http.Client{Transport: pool}
+	get := func(path string) {
+		t.Helper()
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("request returned error: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	// token-a is below the threshold, so the next request uses token-b
+	get("/repos/example-org/repo-a")
+	get("/repos/example-org/repo-b")
+
+	// token-b runs low on GraphQL requests, and token-a has more left
+	mu.Lock()
+	remaining["Bearer token-b"] = 100
+	mu.Unlock()
+	get("/graphql")
+	get("/repos/example-org/repo-c")
+
+	if strings.Join(used, ",") != "token-a,token-b,token-b,token-a" {
+		t.Fatalf("unexpected tokens used: %v", used)
+	}
+	if pool.stats() != 2 {
+		t.Fatalf("expected two switches, got %d", pool.stats())
+	}
+}
+
+func TestTokenPoolKeepsTokenWhenAllAreLow(t *testing.T) {
+	t.Parallel()
+
+	pool := newTokenPool([]string{"token-a", "token-b"}, 500, http.DefaultTransport)
+	now := time.Now()
+	header := func(remaining int, reset time.Time) http.Header {
+		h := http.Header{}
+		h.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		h.Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		return h
+	}
+	pool.observe(1, header(50, now.Add(time.Hour)), now)
+	pool.observe(0, header(200, now.Add(time.Hour)), now)
+	if pool.current != 0 || pool.stats() != 0 {
+		t.Fatalf("expected token-a to be kept, got token %d after %d switches", pool.current+1, pool.stats())
+	}
+
+	// Once token-b's limit has reset it counts as unlimited
+	pool.observe(0, header(100, now.Add(3*time.Hour)), now.Add(2*time.Hour))
+	if pool.current != 1 {
+		t.Fatalf("expected a switch to token-b once its limit reset, got token %d", pool.current+1)
+	}
+}
+
+func TestTokenListAndFile(t *testing.T) {
+	t.Parallel()
+
+	var tokens tokenList
+	if err := tokens.Set("token-a, token-b"); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	if err := tokens.Set("token-c"); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	if tokens.String() != "token-a,token-b,token-c" {
+		t.Fatalf("unexpected tokens: %q", tokens.String())
+	}
+
+	tokenPath := filepath.Join(t.TempDir(), "tokens")
+	if err := os.WriteFile(tokenPath, []byte("# Scan tokens\ntoken-d\n\n  token-e  \n"), 0600); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	fileTokens, err := readTokenFile(tokenPath)
+	if err != nil {
+		t.Fatalf("readTokenFile returned error: %v", err)
+	}
+	if strings.Join(fileTokens, ",") != "token-d,token-e" {
+		t.Fatalf("unexpected tokens from file: %v", fileTokens)
+	}
+
+	if err := os.WriteFile(tokenPath, []byte("# No tokens yet\n"), 0600); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	if _, err := readTokenFile(tokenPath); err == nil {
+		t.Fatalf("expected an error for a file without tokens")
+	}
+}
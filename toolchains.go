@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Toolchain Detection
+// ------------------------
+
+// toolchainActions maps the actions that set up or build with a toolchain to the toolchain, keyed by
+// the lowercase action name without its reference.
+var toolchainActions = map[string]string{
+	"actions/setup-go":              "Go",
+	"actions/setup-node":            "Node",
+	"pnpm/action-setup":             "Node",
+	"actions/setup-java":            "Java",
+	"gradle/actions/setup-gradle":   "Java",
+	"gradle/gradle-build-action":    "Java",
+	"actions/setup-python":          "Python",
+	"astral-sh/setup-uv":            "Python",
+	"actions/setup-dotnet":          ".NET",
+	"ruby/setup-ruby":               "Ruby",
+	"dtolnay/rust-toolchain":        "Rust",
+	"actions-rs/toolchain":          "Rust",
+	"docker/build-push-action":      "Docker",
+	"docker/setup-buildx-action":    "Docker",
+	"hashicorp/setup-terraform":     "Terraform",
+	"opentofu/setup-opentofu":       "Terraform",
+	"subosito/flutter-action":       "Flutter",
+	"swift-actions/setup-swift":     "Swift",
+	"erlef/setup-beam":              "Erlang",
+	"shivammathur/setup-php":        "PHP",
+	"azure/setup-helm":              "Helm",
+	"actions/setup-haskell":         "Haskell",
+	"haskell-actions/setup":         "Haskell",
+	"coursier/setup-action":         "Scala",
+	"goreleaser/goreleaser-action":  "Go",
+	"golangci/golangci-lint-action": "Go",
+}
+
+// detectToolchains returns the toolchains a repository builds with, inferred from the actions its
+// workflow steps use, sorted by name.
+func detectToolchains(workflows []WorkflowFile) []string {
+	seen := make(map[string]bool)
+	var toolchains []string
+	for _, wf := range workflows {
+		for _, use := range extractActionUses(wf.Content, wf.RepoName, wf.FilePath) {
+			toolchain, ok := toolchainActions[strings.ToLower(use.Action)]
+			if ok && !seen[toolchain] {
+				seen[toolchain] = true
+				toolchains = append(toolchains, toolchain)
+			}
+		}
+	}
+	sort.Strings(toolchains)
+	return toolchains
+}
+
+// updateRepositoryToolchains records the toolchains of a repository in the repositories.yaml manifest.
+// A repository without any is removed from the toolchains section.
+func updateRepositoryToolchains(dbPath, repoName string, toolchains []string) error {
+	reposManifestPath := filepath.Join(dbPath, "repositories.yaml")
+	manifest, err := loadRepositoryManifest(dbPath)
+	if err != nil {
+		return err
+	}
+	if slices.Equal(manifest.Toolchains[repoName], toolchains) {
+		return nil
+	}
+
+	if len(toolchains) == 0 {
+		delete(manifest.Toolchains, repoName)
+	} else {
+		if manifest.Toolchains == nil {
+			manifest.Toolchains = make(map[string][]string)
+		}
+		manifest.Toolchains[repoName] = toolchains
+	}
+
+	data, err := yaml.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(reposManifestPath, data, 0644)
+}
+
+// generateToolchainsMarkdown writes TOOLCHAINS.md, listing the repositories building with each toolchain.
+func generateToolchainsMarkdown(dbPath, org string) error {
+	manifest, err := loadRepositoryManifest(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to load repositories manifest: %v", err)
+	}
+
+	byToolchain := make(map[string][]string)
+	for repoName, toolchains := range manifest.Toolchains {
+		for _, toolchain := range toolchains {
+			byToolchain[toolchain] = append(byToolchain[toolchain], repoName)
+		}
+	}
+	toolchains := make([]string, 0, len(byToolchain))
+	for toolchain := range byToolchain {
+		toolchains = append(toolchains, toolchain)
+	}
+	sort.Strings(toolchains)
+
+	var markdownBuilder strings.Builder
+	markdownBuilder.WriteString("# Toolchains\n\n")
+	markdownBuilder.WriteString(fmt.Sprintf("This document lists the toolchains the repositories of %s build with, inferred from the setup and build actions their workflows use. %d of %d repositories use at least one.\n\n", org, len(manifest.Toolchains), len(manifest.Repositories)))
+	markdownBuilder.WriteString("| Toolchain | Repositories | Repository Names |\n")
+	markdownBuilder.WriteString("|-----------|--------------|------------------|\n")
+	for _, toolchain := range toolchains {
+		repos := byToolchain[toolchain]
+		sort.Strings(repos)
+		links := make([]string, len(repos))
+		for i, repoName := range repos {
+			links[i] = fmt.Sprintf("[%s](%s/%s/%s)", repoName, githubWebURL, org, repoName)
+		}
+		markdownBuilder.WriteString(fmt.Sprintf("| %s | %d | %s |\n", toolchain, len(repos), strings.Join(links, ", ")))
+	}
+	if len(toolchains) == 0 {
+		markdownBuilder.WriteString("| *No toolchains detected* | - | - |\n")
+	}
+
+	markdownBuilder.WriteString("\n*This file is automatically generated after each data collection run.*\n")
+
+	if err := os.WriteFile(filepath.Join(dbPath, "TOOLCHAINS.md"), []byte(markdownBuilder.String()), 0644); err != nil {
+		return fmt.Errorf("error writing TOOLCHAINS.md: %v", err)
+	}
+
+	logInfof("Generated TOOLCHAINS.md with %d toolchains", len(toolchains))
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectToolchains(t *testing.T) {
+	t.Parallel()
+
+	workflows := []WorkflowFile{
+		{RepoName: "repo-a", FilePath: ".github/workflows/ci.yml", Content: "jobs:\n  build:\n    steps:\n      - uses: actions/checkout@v4\n      - uses: actions/setup-go@v5\n      - uses: Docker/Build-Push-Action@v6\n"},
+		{RepoName: "repo-a", FilePath: ".github/workflows/infra.yml", Content: "jobs:\n  plan:\n    steps:\n      - uses: hashicorp/setup-terraform@v3\n      - uses: actions/setup-go@v5\n"},
+	}
+	if got := strings.Join(detectToolchains(workflows), ","); got != "Docker,Go,Terraform" {
+		t.Fatalf("unexpected toolchains: %s", got)
+	}
+	if got := detectToolchains([]WorkflowFile{{Content: "jobs:\n  lint:\n    steps:\n      - run: make lint\n"}}); got != nil {
+		t.Fatalf("expected no toolchains, got %q", got)
+	}
+}
+
+func TestGenerateToolchainsMarkdown(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dbPath, "repositories.yaml"), []byte("organization: example-org\nrepositories:\n    - repo-a\n    - repo-b\n    - repo-c\n"), 0644); err != nil {
+		t.Fatalf("failed to write repositories.yaml: %v", err)
+	}
+	for repoName, toolchains := range map[string][]string{"repo-a": {"Go", "Node"}, "repo-b": {"Node"}, "repo-c": nil} {
+		if err := updateRepositoryToolchains(dbPath, repoName, toolchains); err != nil {
+			t.Fatalf("updateRepositoryToolchains returned error: %v", err)
+		}
+	}
+	// A repository that no longer uses any toolchain is dropped
+	if err := updateRepositoryToolchains(dbPath, "repo-a", nil); err != nil {
+		t.Fatalf("updateRepositoryToolchains returned error: %v", err)
+	}
+	if err := updateRepositoryToolchains(dbPath, "repo-a", []string{"Go"}); err != nil {
+		t.Fatalf("updateRepositoryToolchains returned error: %v", err)
+	}
+
+	manifest, err := loadRepositoryManifest(dbPath)
+	if err != nil {
+		t.Fatalf("loadRepositoryManifest returned error: %v", err)
+	}
+	if len(manifest.Toolchains) != 2 || strings.Join(manifest.Toolchains["repo-a"], ",") != "Go" || len(manifest.Repositories) != 3 {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+
+	if err := generateToolchainsMarkdown(dbPath, "example-org"); err != nil {
+		t.Fatalf("generateToolchainsMarkdown returned error: %v", err)
+	}
+	markdown, err := os.ReadFile(filepath.Join(dbPath, "TOOLCHAINS.md"))
+	if err != nil {
+		t.Fatalf("failed to read TOOLCHAINS.md: %v", err)
+	}
+	for _, want := range []string{
+		"2 of 3 repositories use at least one.",
+		"| Go | 1 | [repo-a](https://github.com/example-org/repo-a) |",
+		"| Node | 1 | [repo-b](https://github.com/example-org/repo-b) |",
+	} {
+		if !strings.Contains(string(markdown), want) {
+			t.Fatalf("TOOLCHAINS.md does not contain %q:\n%s", want, markdown)
+		}
+	}
+}
@@ -0,0 +1,561 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Metrics History and Trend Report
+// ------------------------
+
+// MetricsSnapshot records organization-wide metrics for a single audit run.
+type MetricsSnapshot struct {
+	Date              string   `yaml:"date"`
+	Repositories      int      `yaml:"repositories"`
+	TotalUses         int      `yaml:"total_uses"`
+	PinnedUses        int      `yaml:"pinned_uses"`
+	ThirdPartyActions []string `yaml:"third_party_actions,omitempty"`
+	Findings          []string `yaml:"findings,omitempty"` // Fingerprints of the findings open at the time of the run
+	// Severities counts the open findings by severity, after the overrides in severities.yaml
+	Severities map[string]int `yaml:"severities,omitempty"`
+}
+
+// MetricsHistory is the list of recorded snapshots, oldest first.
+type MetricsHistory struct {
+	Snapshots []MetricsSnapshot `yaml:"snapshots"`
+}
+
+// TrendPoint summarizes a snapshot and the changes since the snapshot before it.
+type TrendPoint struct {
+	Date              string   `json:"date"`
+	Repositories      int      `json:"repositories"`
+	PinnedPercent     float64  `json:"pinned_percent"`
+	ThirdPartyActions int      `json:"third_party_actions"`
+	OpenFindings      int      `json:"open_findings"`
+	Opened            int      `json:"opened"`
+	Resolved          int      `json:"resolved"`
+	NewActions        []string `json:"new_actions"`
+}
+
+// TrendReport is the data rendered by the trend report.
+type TrendReport struct {
+	Organization string       `json:"organization"`
+	Since        string       `json:"since,omitempty"`
+	Points       []TrendPoint `json:"points"`
+	Opened       int          `json:"opened"`
+	Resolved     int          `json:"resolved"`
+}
+
+// pinnedPercent returns the share of pinned uses in a snapshot as a percentage.
+func (s MetricsSnapshot) pinnedPercent() float64 {
+	if s.TotalUses == 0 {
+		return 100
+	}
+	return float64(s.PinnedUses) * 100 / float64(s.TotalUses)
+}
+
+// loadMetricsHistory reads metrics.yaml from the database, returning an empty history if it does not exist.
+func loadMetricsHistory(dbPath string) (*MetricsHistory, error) {
+	history := &MetricsHistory{}
+	data, err := os.ReadFile(filepath.Join(dbPath, "metrics.yaml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return history, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, history); err != nil {
+		return nil, fmt.Errorf("failed to parse metrics.yaml: %v", err)
+	}
+	return history, nil
+}
+
+// recordMetricsSnapshot appends the metrics for the current run to metrics.yaml.
+// A run on the same date as the latest snapshot replaces it.
+func recordMetricsSnapshot(dbPath, org string, usesIndex *ActionUsesIndex, findings []Finding) error {
+	history, err := loadMetricsHistory(dbPath)
+	if err != nil {
+		return err
+	}
+
+	snapshot := MetricsSnapshot{Date: formatReportDate(time.Now())}
+
+	if data, err := os.ReadFile(filepath.Join(dbPath, "repositories.yaml")); err == nil {
+		var manifest RepositoryManifest
+		if err := yaml.Unmarshal(data, &manifest); err == nil {
+			snapshot.Repositories = len(manifest.Repositories)
+		}
+	}
+
+	if usesIndex != nil {
+		for actionName, versions := range usesIndex.Actions {
+			if isThirdPartyAction(actionName, org) {
+				snapshot.ThirdPartyActions = append(snapshot.ThirdPartyActions, actionName)
+			}
+			if strings.HasPrefix(actionName, "./") || strings.HasPrefix(actionName, "docker://") {
+				continue
+			}
+			for version, refs := range versions {
+				ref, _, _ := strings.Cut(version, " ")
+				for _, workflowRef := range refs {
+					if len(workflowRef.Via) > 0 {
+						continue // Pinning is measured on direct uses only
+					}
+					snapshot.TotalUses++
+					if isPinnedVersion(ref) {
+						snapshot.PinnedUses++
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(snapshot.ThirdPartyActions)
+
+	seen := make(map[string]bool)
+	for _, finding := range findings {
+		if !seen[finding.Fingerprint] {
+			seen[finding.Fingerprint] = true
+			snapshot.Findings = append(snapshot.Findings, finding.Fingerprint)
+			if snapshot.Severities == nil {
+				snapshot.Severities = make(map[string]int)
+			}
+			snapshot.Severities[finding.Severity]++
+		}
+	}
+	sort.Strings(snapshot.Findings)
+
+	if n := len(history.Snapshots); n > 0 && history.Snapshots[n-1].Date == snapshot.Date {
+		history.Snapshots[n-1] = snapshot
+	} else {
+		history.Snapshots = append(history.Snapshots, snapshot)
+	}
+
+	data, err := yaml.Marshal(history)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dbPath, "metrics.yaml"), data, 0644); err != nil {
+		return err
+	}
+
+	logInfof("Recorded metrics snapshot for %s", snapshot.Date)
+	return nil
+}
+
+// buildTrendReport computes trend points for every snapshot on or after since (YYYY-MM-DD).
+// The last snapshot before since, if any, is used as the baseline for the first point.
+func buildTrendReport(history *MetricsHistory, org, since string) TrendReport {
+	report := TrendReport{Organization: org, Since: since}
+
+	var previous *MetricsSnapshot
+	for i := range history.Snapshots {
+		snapshot := &history.Snapshots[i]
+		if snapshot.Date < since {
+			previous = snapshot
+			continue
+		}
+
+		point := TrendPoint{
+			Date:              snapshot.Date,
+			Repositories:      snapshot.Repositories,
+			PinnedPercent:     snapshot.pinnedPercent(),
+			ThirdPartyActions: len(snapshot.ThirdPartyActions),
+			OpenFindings:      len(snapshot.Findings),
+		}
+		if previous != nil {
+			point.NewActions = setDifference(snapshot.ThirdPartyActions, previous.ThirdPartyActions)
+			point.Opened = len(setDifference(snapshot.Findings, previous.Findings))
+			point.Resolved = len(setDifference(previous.Findings, snapshot.Findings))
+		}
+		report.Opened += point.Opened
+		report.Resolved += point.Resolved
+		report.Points = append(report.Points, point)
+		previous = snapshot
+	}
+
+	return report
+}
+
+// setDifference returns the values in a that are not in b.
+func setDifference(a, b []string) []string {
+	inB := make(map[string]bool, len(b))
+	for _, value := range b {
+		inB[value] = true
+	}
+	var diff []string
+	for _, value := range a {
+		if !inB[value] {
+			diff = append(diff, value)
+		}
+	}
+	return diff
+}
+
+// formatTrendMarkdown renders a trend report as Markdown.
+func formatTrendMarkdown(report TrendReport) string {
+	var markdownBuilder strings.Builder
+	markdownBuilder.WriteString(fmt.Sprintf("# Workflow Trends for %s\n\n", report.Organization))
+	markdownBuilder.WriteString(fmt.Sprintf("Covers audit runs since %s.\n\n", report.Since))
+
+	if len(report.Points) == 0 {
+		markdownBuilder.WriteString("*No metrics were recorded in this period.*\n")
+		return markdownBuilder.String()
+	}
+
+	first := report.Points[0]
+	last := report.Points[len(report.Points)-1]
+	markdownBuilder.WriteString("## Summary\n\n")
+	markdownBuilder.WriteString(fmt.Sprintf("- **Pinned action uses**: %.1f%% → %.1f%%\n", first.PinnedPercent, last.PinnedPercent))
+	markdownBuilder.WriteString(fmt.Sprintf("- **Third-party actions**: %d → %d\n", first.ThirdPartyActions, last.ThirdPartyActions))
+	markdownBuilder.WriteString(fmt.Sprintf("- **Violations opened**: %d\n", report.Opened))
+	markdownBuilder.WriteString(fmt.Sprintf("- **Violations resolved**: %d\n", report.Resolved))
+	markdownBuilder.WriteString(fmt.Sprintf("- **Open violations**: %d\n\n", last.OpenFindings))
+
+	markdownBuilder.WriteString("## Over Time\n\n")
+	markdownBuilder.WriteString("| Date | Repositories | Pinned | Third-Party Actions | Open Violations | Opened | Resolved |\n")
+	markdownBuilder.WriteString("|------|--------------|--------|---------------------|-----------------|--------|----------|\n")
+	for _, point := range report.Points {
+		markdownBuilder.WriteString(fmt.Sprintf("| %s | %d | %.1f%% | %d | %d | %d | %d |\n",
+			point.Date, point.Repositories, point.PinnedPercent, point.ThirdPartyActions, point.OpenFindings, point.Opened, point.Resolved))
+	}
+
+	markdownBuilder.WriteString("\n## New Third-Party Actions\n\n")
+	introduced := false
+	for _, point := range report.Points {
+		for _, action := range point.NewActions {
+			markdownBuilder.WriteString(fmt.Sprintf("- `%s` (first seen %s)\n", action, point.Date))
+			introduced = true
+		}
+	}
+	if !introduced {
+		markdownBuilder.WriteString("*No new third-party actions were introduced.*\n")
+	}
+
+	return markdownBuilder.String()
+}
+
+var trendHTMLTemplate = template.Must(template.New("trend").Funcs(template.FuncMap{
+	"percent": func(value float64) string { return fmt.Sprintf("%.1f%%", value) },
+	"last":    func(points []TrendPoint) TrendPoint { return points[len(points)-1] },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Workflow Trends for {{.Organization}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+</style>
+</head>
+<body>
+<h1>Workflow Trends for {{.Organization}}</h1>
+<p>Covers audit runs since {{.Since}}.</p>
+{{- if not .Points}}
+<p><em>No metrics were recorded in this period.</em></p>
+{{- else}}
+{{- $first := index .Points 0}}{{$last := last .Points}}
+<h2>Summary</h2>
+<ul>
+<li><strong>Pinned action uses</strong>: {{percent $first.PinnedPercent}} → {{percent $last.PinnedPercent}}</li>
+<li><strong>Third-party actions</strong>: {{$first.ThirdPartyActions}} → {{$last.ThirdPartyActions}}</li>
+<li><strong>Violations opened</strong>: {{.Opened}}</li>
+<li><strong>Violations resolved</strong>: {{.Resolved}}</li>
+<li><strong>Open violations</strong>: {{$last.OpenFindings}}</li>
+</ul>
+<h2>Over Time</h2>
+<table>
+<tr><th>Date</th><th>Repositories</th><th>Pinned</th><th>Third-Party Actions</th><th>Open Violations</th><th>Opened</th><th>Resolved</th></tr>
+{{- range .Points}}
+<tr><td>{{.Date}}</td><td>{{.Repositories}}</td><td>{{percent .PinnedPercent}}</td><td>{{.ThirdPartyActions}}</td><td>{{.OpenFindings}}</td><td>{{.Opened}}</td><td>{{.Resolved}}</td></tr>
+{{- end}}
+</table>
+<h2>New Third-Party Actions</h2>
+<ul>
+{{- range .Points}}{{$date := .Date}}{{range .NewActions}}
+<li><code>{{.}}</code> (first seen {{$date}})</li>
+{{- end}}{{end}}
+</ul>
+{{- end}}
+</body>
+</html>
+`))
+
+// formatTrendJSON renders the trend report as JSON. Empty lists are written as [] rather than null.
+func formatTrendJSON(report TrendReport) (string, error) {
+	points := make([]TrendPoint, len(report.Points))
+	for i, point := range report.Points {
+		point.NewActions = emptyIfNil(point.NewActions)
+		points[i] = point
+	}
+	report.Points = points
+	return formatJSONDocument(report)
+}
+
+// formatTrendHTML renders a trend report as a standalone HTML page.
+func formatTrendHTML(report TrendReport) (string, error) {
+	var htmlBuilder strings.Builder
+	if err := trendHTMLTemplate.Execute(&htmlBuilder, report); err != nil {
+		return "", err
+	}
+	return htmlBuilder.String(), nil
+}
+
+// runReportCommand dispatches the report subcommands and returns the process exit code.
+func runReportCommand(args []string) int {
+	if len(args) == 0 {
+		printReportUsage()
+		return 1
+	}
+
+	switch args[0] {
+	case "trend":
+		fs := flag.NewFlagSet("report trend", flag.ContinueOnError)
+		reportDB := fs.String("db", "./db", "Path to the database repository, or a git URL to clone")
+		reportToken := fs.String("token", "", "GitHub API token used to clone an HTTPS database URL")
+		since := fs.String("since", "", "Only include audit runs on or after this date (YYYY-MM-DD)")
+		format := fs.String("format", formatMarkdown, "Output format: markdown, html, or json")
+		output := fs.String("output", "", "File to write the report to; defaults to standard output")
+		logFlags := addLogFlags(fs)
+		if err := fs.Parse(args[1:]); err != nil {
+			return 1
+		}
+		if err := applyEnvironmentDefaults(fs); err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+		if err := logFlags.apply(); err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+		if *since != "" {
+			if _, err := time.Parse("2006-01-02", *since); err != nil {
+				logErrorf("Invalid -since date '%s': expected YYYY-MM-DD", *since)
+				return 1
+			}
+		}
+		if err := checkFormat(*format, formatMarkdown, formatHTML, formatJSON); err != nil {
+			logErrorf("%v", err)
+			printReportUsage()
+			fs.PrintDefaults()
+			return 1
+		}
+		if *format == formatJSON && *output == "" {
+			useJSONOutput()
+		}
+
+		checkout, err := openDB(*reportDB, *reportToken)
+		if err != nil {
+			logErrorf("Failed to open database: %v", err)
+			return 1
+		}
+		defer checkout.Close()
+
+		if err := writeTrendReport(checkout.Dir, *since, *format, *output); err != nil {
+			logErrorf("Report failed: %v", err)
+			return 1
+		}
+		return 0
+	case "public":
+		fs := flag.NewFlagSet("report public", flag.ContinueOnError)
+		reportDB := fs.String("db", "./db", "Path to the database repository, or a git URL to clone")
+		reportToken := fs.String("token", "", "GitHub API token used to clone an HTTPS database URL")
+		salt := fs.String("salt", os.Getenv("DOTGITHUBINDEXER_SALT"), "Secret used to derive repository pseudonyms; random when empty, so pseudonyms differ between reports")
+		format := fs.String("format", formatMarkdown, "Output format: markdown or json")
+		output := fs.String("output", "", "File to write the report to; defaults to standard output")
+		logFlags := addLogFlags(fs)
+		if err := fs.Parse(args[1:]); err != nil {
+			return 1
+		}
+		if err := applyEnvironmentDefaults(fs); err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+		if err := logFlags.apply(); err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+		if err := checkFormat(*format, formatMarkdown, formatJSON); err != nil {
+			logErrorf("%v", err)
+			printReportUsage()
+			fs.PrintDefaults()
+			return 1
+		}
+		if *format == formatJSON && *output == "" {
+			useJSONOutput()
+		}
+
+		checkout, err := openDB(*reportDB, *reportToken)
+		if err != nil {
+			logErrorf("Failed to open database: %v", err)
+			return 1
+		}
+		defer checkout.Close()
+
+		if err := writeSanitizedReport(checkout.Dir, *salt, *format, *output); err != nil {
+			logErrorf("Report failed: %v", err)
+			return 1
+		}
+		return 0
+	case "generate":
+		fs := flag.NewFlagSet("report generate", flag.ContinueOnError)
+		reportDB := fs.String("db", "./db", "Path to the database repository, or a git URL to clone, update, and push")
+		reportToken := fs.String("token", "", "GitHub API token; without one only the reports built from the database are regenerated")
+		baseURL := fs.String("base-url", "", "GitHub Enterprise Server API URL, e.g. https://github.example.com/api/v3; defaults to github.com")
+		uploadURL := fs.String("upload-url", "", "GitHub Enterprise Server upload URL; defaults to -base-url")
+		timezone := fs.String("timezone", "UTC", "IANA timezone used for dates and times in reports, e.g. America/New_York")
+		analyzerSelection := fs.String("analyzers", "", "Comma-separated analyzers to run, or to skip when prefixed with '-'; see 'analyzers list'")
+		review := fs.Bool("review", false, "Push database changes to a new branch and open a pull request instead of pushing them directly; requires a git URL -db")
+		logFlags := addLogFlags(fs)
+		if err := fs.Parse(args[1:]); err != nil {
+			return 1
+		}
+		if err := applyEnvironmentDefaults(fs); err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+		if err := logFlags.apply(); err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+		if err := setReportTimezone(*timezone); err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+		if err := setGitHubServer(*baseURL, *uploadURL); err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+		if err := setAnalyzerSelection(*analyzerSelection); err != nil {
+			logErrorf("Invalid analyzer selection: %v", err)
+			return 1
+		}
+		if err := checkReviewMode(*review, *reportDB); err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+
+		checkout, err := openDB(*reportDB, *reportToken)
+		if err != nil {
+			logErrorf("Failed to open database: %v", err)
+			return 1
+		}
+		defer checkout.Close()
+
+		var client *github.Client
+		if *reportToken != "" {
+			client = getGitHubClient(*reportToken)
+		}
+		startTime := time.Now()
+		if err := regenerateReports(client, checkout.Dir); err != nil {
+			logErrorf("Report failed: %v", err)
+			return 1
+		}
+		if err := publishDB(checkout, fmt.Sprintf("Regenerate reports (%s)", formatReportDate(startTime)), *review, *reportToken); err != nil {
+			logErrorf("Failed to publish database: %v", err)
+			return 1
+		}
+		return 0
+	case "graph":
+		fs := flag.NewFlagSet("report graph", flag.ContinueOnError)
+		reportDB := fs.String("db", "./db", "Path to the database repository, or a git URL to clone")
+		reportToken := fs.String("token", "", "GitHub API token used to clone an HTTPS database URL")
+		format := fs.String("format", formatJSON, "Output format: json, graphml, or dot")
+		output := fs.String("output", "", "File to write the graph to; defaults to standard output")
+		logFlags := addLogFlags(fs)
+		if err := fs.Parse(args[1:]); err != nil {
+			return 1
+		}
+		if err := applyEnvironmentDefaults(fs); err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+		if err := logFlags.apply(); err != nil {
+			logErrorf("%v", err)
+			return 1
+		}
+		if err := checkFormat(*format, formatJSON, formatGraphML, formatDOT); err != nil {
+			logErrorf("%v", err)
+			printReportUsage()
+			fs.PrintDefaults()
+			return 1
+		}
+		if *output == "" {
+			useJSONOutput()
+		}
+
+		checkout, err := openDB(*reportDB, *reportToken)
+		if err != nil {
+			logErrorf("Failed to open database: %v", err)
+			return 1
+		}
+		defer checkout.Close()
+
+		if err := writeUsageGraph(checkout.Dir, *format, *output); err != nil {
+			logErrorf("Report failed: %v", err)
+			return 1
+		}
+		return 0
+	default:
+		logErrorf("Unknown report command '%s'", args[0])
+		printReportUsage()
+		return 1
+	}
+}
+
+// printReportUsage prints the usage for the report command.
+func printReportUsage() {
+	fmt.Println("Usage: dotgithubindexer report trend [-db <path or git URL>] [-since YYYY-MM-DD] [-format markdown|html|json] [-output <file>]")
+	fmt.Println("       dotgithubindexer report public [-db <path or git URL>] [-salt <secret>] [-format markdown|json] [-output <file>]")
+	fmt.Println("       dotgithubindexer report graph [-db <path or git URL>] [-format json|graphml|dot] [-output <file>]")
+	fmt.Println("       dotgithubindexer report generate [-db <path or git URL>] [-token <token>] [-review]")
+}
+
+// writeTrendReport builds the trend report from metrics.yaml and writes it to output or standard output.
+func writeTrendReport(dbPath, since, format, output string) error {
+	history, err := loadMetricsHistory(dbPath)
+	if err != nil {
+		return err
+	}
+
+	org := ""
+	if data, err := os.ReadFile(filepath.Join(dbPath, "repositories.yaml")); err == nil {
+		var manifest RepositoryManifest
+		if err := yaml.Unmarshal(data, &manifest); err == nil {
+			org = manifest.Organization
+		}
+	}
+
+	report := buildTrendReport(history, org, since)
+	content := formatTrendMarkdown(report)
+	switch format {
+	case formatHTML:
+		content, err = formatTrendHTML(report)
+	case formatJSON:
+		content, err = formatTrendJSON(report)
+	}
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		fmt.Fprint(resultWriter, content)
+		return nil
+	}
+	if err := os.WriteFile(output, []byte(content), 0644); err != nil {
+		return fmt.Errorf("error writing %s: %v", output, err)
+	}
+	logInfof("Wrote trend report to %s", output)
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildTrendReport(t *testing.T) {
+	t.Parallel()
+
+	history := &MetricsHistory{Snapshots: []MetricsSnapshot{
+		{Date: "2023-12-01", TotalUses: 10, PinnedUses: 2, ThirdPartyActions: []string{"a/one"}, Findings: []string{"f1", "f2"}},
+		{Date: "2024-01-15", TotalUses: 10, PinnedUses: 5, ThirdPartyActions: []string{"a/one", "b/two"}, Findings: []string{"f2", "f3"}},
+		{Date: "2024-02-15", TotalUses: 10, PinnedUses: 8, ThirdPartyActions: []string{"a/one", "b/two"}, Findings: []string{"f3"}},
+	}}
+
+	report := buildTrendReport(history, "example-org", "2024-01-01")
+	if len(report.Points) != 2 {
+		t.Fatalf("expected 2 trend points, got %+v", report.Points)
+	}
+	first := report.Points[0]
+	if first.PinnedPercent != 50 || first.Opened != 1 || first.Resolved != 1 {
+		t.Fatalf("unexpected first point: %+v", first)
+	}
+	if len(first.NewActions) != 1 || first.NewActions[0] != "b/two" {
+		t.Fatalf("expected b/two to be introduced, got %+v", first.NewActions)
+	}
+	if report.Opened != 1 || report.Resolved != 2 {
+		t.Fatalf("unexpected totals: opened %d, resolved %d", report.Opened, report.Resolved)
+	}
+
+	markdown := formatTrendMarkdown(report)
+	for _, want := range []string{"50.0% → 80.0%", "| 2024-02-15 | 0 | 80.0% | 2 | 1 | 0 | 1 |", "`b/two` (first seen 2024-01-15)"} {
+		if !strings.Contains(markdown, want) {
+			t.Fatalf("expected markdown to contain %q, got:\n%s", want, markdown)
+		}
+	}
+
+	html, err := formatTrendHTML(report)
+	if err != nil {
+		t.Fatalf("formatTrendHTML returned error: %v", err)
+	}
+	if !strings.Contains(html, "<code>b/two</code> (first seen 2024-01-15)") {
+		t.Fatalf("unexpected HTML output:\n%s", html)
+	}
+
+	document, err := formatTrendJSON(report)
+	if err != nil {
+		t.Fatalf("formatTrendJSON returned error: %v", err)
+	}
+	for _, want := range []string{`"organization": "example-org"`, `"pinned_percent": 80`, `"new_actions": []`, `"new_actions": [` + "\n" + `        "b/two"`} {
+		if !strings.Contains(document, want) {
+			t.Fatalf("expected JSON to contain %q, got:\n%s", want, document)
+		}
+	}
+}
+
+func TestRecordMetricsSnapshot(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	usesIndex := &ActionUsesIndex{Actions: map[string]map[string][]WorkflowReference{
+		"actions/checkout": {
+			"v4": {{RepoName: "repo-a", FilePath: "build.yml"}},
+			"de0fac2e4500dabe0009e67214ff5f5447ce83dd # v6": {{RepoName: "repo-b", FilePath: "build.yml"}},
+		},
+		"example-org/shared": {"v1": {{RepoName: "repo-a", FilePath: "build.yml"}}},
+		"third-party/tool":   {"v2": {{RepoName: "repo-a", FilePath: "build.yml", Via: []string{"example-org/shared@v1"}}}},
+	}}
+	findings := []Finding{newFinding("github-token", "repo-a", "build.yml", 3, "token")}
+
+	for i := 0; i < 2; i++ {
+		if err := recordMetricsSnapshot(dbPath, "example-org", usesIndex, findings); err != nil {
+			t.Fatalf("recordMetricsSnapshot returned error: %v", err)
+		}
+	}
+
+	history, err := loadMetricsHistory(dbPath)
+	if err != nil {
+		t.Fatalf("loadMetricsHistory returned error: %v", err)
+	}
+	if len(history.Snapshots) != 1 {
+		t.Fatalf("expected runs on the same date to share a snapshot, got %d", len(history.Snapshots))
+	}
+	snapshot := history.Snapshots[0]
+	if snapshot.TotalUses != 3 || snapshot.PinnedUses != 1 {
+		t.Fatalf("unexpected pinning counts: %+v", snapshot)
+	}
+	if len(snapshot.ThirdPartyActions) != 2 || len(snapshot.Findings) != 1 {
+		t.Fatalf("unexpected snapshot: %+v", snapshot)
+	}
+}
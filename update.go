@@ -0,0 +1,298 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v50/github"
+)
+
+// ------------------------
+// Section: Update Check
+// ------------------------
+
+const (
+	releaseOwner = "UnitVectorY-Labs"
+	releaseRepo  = "dotgithubindexer"
+	binaryName   = "dotgithubindexer"
+)
+
+// isCIEnvironment reports whether the process appears to be running in a CI system.
+func isCIEnvironment() bool {
+	for _, name := range []string{"CI", "GITHUB_ACTIONS", "BUILD_NUMBER", "RUN_ID"} {
+		if value := os.Getenv(name); value != "" && value != "false" && value != "0" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSemver parses a version like "v1.2.3" into its numeric components.
+func parseSemver(version string) ([3]int, bool) {
+	var parts [3]int
+	match := semverRe.FindString(strings.TrimPrefix(version, "v"))
+	if match == "" {
+		return parts, false
+	}
+
+	for i, field := range strings.SplitN(match, ".", 3) {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
+
+// isNewerVersion reports whether latest is a newer semantic version than current.
+// Development builds are never considered outdated.
+func isNewerVersion(current, latest string) bool {
+	currentParts, ok := parseSemver(current)
+	if !ok {
+		return false
+	}
+	latestParts, ok := parseSemver(latest)
+	if !ok {
+		return false
+	}
+
+	for i := range currentParts {
+		if latestParts[i] != currentParts[i] {
+			return latestParts[i] > currentParts[i]
+		}
+	}
+	return false
+}
+
+// fetchLatestRelease retrieves the latest published release of this tool.
+func fetchLatestRelease(client *github.Client) (*github.RepositoryRelease, error) {
+	ctx := context.Background()
+	release, _, err := client.Repositories.GetLatestRelease(ctx, releaseOwner, releaseRepo)
+	if err != nil {
+		return nil, err
+	}
+	return release, nil
+}
+
+// checkForUpdate prints a notice when a newer release than the running version is available.
+// Failures are reported but never stop the audit.
+func checkForUpdate(client *github.Client, currentVersion string) {
+	release, err := fetchLatestRelease(client)
+	if err != nil {
+		logWarnf("Unable to check for updates: %v", err)
+		return
+	}
+
+	latest := release.GetTagName()
+	if isNewerVersion(currentVersion, latest) {
+		logWarnf("A new version of dotgithubindexer is available: %s (current %s). Run 'dotgithubindexer self-update' or see %s", latest, currentVersion, release.GetHTMLURL())
+		return
+	}
+
+	logInfof("dotgithubindexer %s is up to date (latest release %s).", currentVersion, latest)
+}
+
+// ------------------------
+// Section: Self Update
+// ------------------------
+
+// runSelfUpdateCommand replaces the running binary with the latest release and returns the process exit code.
+func runSelfUpdateCommand(args []string) int {
+	if len(args) != 0 {
+		fmt.Println("Usage: dotgithubindexer self-update")
+		return 1
+	}
+
+	if err := selfUpdate(github.NewClient(nil), Version); err != nil {
+		logErrorf("Self-update failed: %v", err)
+		return 1
+	}
+	return 0
+}
+
+// selfUpdate downloads the release asset for the current platform and replaces the running executable.
+func selfUpdate(client *github.Client, currentVersion string) error {
+	release, err := fetchLatestRelease(client)
+	if err != nil {
+		return fmt.Errorf("failed to fetch latest release: %v", err)
+	}
+
+	latest := release.GetTagName()
+	if !isNewerVersion(currentVersion, latest) {
+		logInfof("dotgithubindexer %s is already up to date (latest release %s).", currentVersion, latest)
+		return nil
+	}
+
+	asset := selectReleaseAsset(release.Assets, runtime.GOOS, runtime.GOARCH)
+	if asset == nil {
+		return fmt.Errorf("no release asset found for %s/%s in %s", runtime.GOOS, runtime.GOARCH, latest)
+	}
+
+	// The release publishes the SHA-256 of each asset, which must match before the binary is replaced
+	checksumAsset := selectChecksumAsset(release.Assets, asset.GetName())
+	if checksumAsset == nil {
+		return fmt.Errorf("no checksum found for %s in %s", asset.GetName(), latest)
+	}
+
+	logInfof("Downloading %s from release %s", asset.GetName(), latest)
+	data, err := downloadReleaseAsset(asset)
+	if err != nil {
+		return err
+	}
+	checksum, err := downloadReleaseAsset(checksumAsset)
+	if err != nil {
+		return err
+	}
+	if err := verifyReleaseChecksum(asset.GetName(), data, checksum); err != nil {
+		return err
+	}
+
+	binary, err := extractReleaseBinary(asset.GetName(), data)
+	if err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	executable, err = filepath.EvalSymlinks(executable)
+	if err != nil {
+		return err
+	}
+
+	// Write next to the executable and rename so the swap is atomic
+	tmpPath := executable + ".new"
+	if err := os.WriteFile(tmpPath, binary, 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, executable); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	logInfof("Updated dotgithubindexer from %s to %s", currentVersion, latest)
+	return nil
+}
+
+// releaseArchiveSuffixes are the archive names a release publishes binaries in; checksums, signatures,
+// and other files published alongside them are not selected.
+var releaseArchiveSuffixes = []string{".tar.gz", ".tgz", ".zip"}
+
+// selectReleaseAsset finds the release archive built for the given platform.
+func selectReleaseAsset(assets []*github.ReleaseAsset, goos, goarch string) *github.ReleaseAsset {
+	for _, asset := range assets {
+		name := strings.ToLower(asset.GetName())
+		if !strings.Contains(name, binaryName) || !strings.Contains(name, goos) || !strings.Contains(name, goarch) {
+			continue
+		}
+		for _, suffix := range releaseArchiveSuffixes {
+			if strings.HasSuffix(name, suffix) {
+				return asset
+			}
+		}
+	}
+	return nil
+}
+
+// selectChecksumAsset finds the .sha256 asset published alongside the named release asset.
+func selectChecksumAsset(assets []*github.ReleaseAsset, assetName string) *github.ReleaseAsset {
+	for _, asset := range assets {
+		if strings.EqualFold(asset.GetName(), assetName+".sha256") {
+			return asset
+		}
+	}
+	return nil
+}
+
+// downloadReleaseAsset returns the content of a release asset.
+func downloadReleaseAsset(asset *github.ReleaseAsset) ([]byte, error) {
+	resp, err := http.Get(asset.GetBrowserDownloadURL())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status downloading %s: %s", asset.GetName(), resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// verifyReleaseChecksum checks a downloaded asset against its .sha256 file, which holds the hex digest
+// optionally followed by the file name, as written by sha256sum.
+func verifyReleaseChecksum(assetName string, data, checksumFile []byte) error {
+	fields := strings.Fields(string(checksumFile))
+	if len(fields) == 0 {
+		return fmt.Errorf("checksum for %s is empty", assetName)
+	}
+	expected, err := hex.DecodeString(fields[0])
+	if err != nil || len(expected) != sha256.Size {
+		return fmt.Errorf("checksum for %s is not a SHA-256 digest", assetName)
+	}
+	actual := sha256.Sum256(data)
+	if !bytes.Equal(actual[:], expected) {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", assetName, fields[0], hex.EncodeToString(actual[:]))
+	}
+	return nil
+}
+
+// extractReleaseBinary returns the executable from a downloaded release asset,
+// unpacking it when the asset is a .tar.gz or .zip archive.
+func extractReleaseBinary(assetName string, data []byte) ([]byte, error) {
+	switch {
+	case strings.HasSuffix(assetName, ".tar.gz") || strings.HasSuffix(assetName, ".tgz"):
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+
+		tr := tar.NewReader(gz)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if header.Typeflag == tar.TypeReg && strings.HasPrefix(filepath.Base(header.Name), binaryName) {
+				return io.ReadAll(tr)
+			}
+		}
+		return nil, fmt.Errorf("binary not found in %s", assetName)
+	case strings.HasSuffix(assetName, ".zip"):
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range zr.File {
+			if file.FileInfo().IsDir() || !strings.HasPrefix(filepath.Base(file.Name), binaryName) {
+				continue
+			}
+			rc, err := file.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+			return io.ReadAll(rc)
+		}
+		return nil, fmt.Errorf("binary not found in %s", assetName)
+	default:
+		return data, nil
+	}
+}
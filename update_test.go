@@ -0,0 +1,88 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v50/github"
+)
+
+func TestIsNewerVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		current string
+		latest  string
+		want    bool
+	}{
+		{current: "v1.2.3", latest: "v1.2.4", want: true},
+		{current: "1.2.3", latest: "v1.10.0", want: true},
+		{current: "v2.0.0", latest: "v1.9.9", want: false},
+		{current: "v1.2.3", latest: "v1.2.3", want: false},
+		{current: "dev", latest: "v1.2.3", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := isNewerVersion(tt.current, tt.latest); got != tt.want {
+			t.Fatalf("isNewerVersion(%q, %q) = %t, want %t", tt.current, tt.latest, got, tt.want)
+		}
+	}
+}
+
+func TestSelectReleaseAsset(t *testing.T) {
+	t.Parallel()
+
+	assets := []*github.ReleaseAsset{
+		{Name: github.String("dotgithubindexer_linux_amd64.tar.gz.sha256")},
+		{Name: github.String("dotgithubindexer_linux_amd64.tar.gz.md5")},
+		{Name: github.String("dotgithubindexer_darwin_arm64.tar.gz")},
+		{Name: github.String("dotgithubindexer_linux_amd64.tar.gz")},
+	}
+
+	asset := selectReleaseAsset(assets, "linux", "amd64")
+	if asset == nil || asset.GetName() != "dotgithubindexer_linux_amd64.tar.gz" {
+		t.Fatalf("unexpected asset selected: %v", asset)
+	}
+	if selectReleaseAsset(assets, "windows", "amd64") != nil {
+		t.Fatal("expected no asset for unsupported platform")
+	}
+}
+
+func TestSelectChecksumAsset(t *testing.T) {
+	t.Parallel()
+
+	assets := []*github.ReleaseAsset{
+		{Name: github.String("dotgithubindexer_linux_amd64.tar.gz")},
+		{Name: github.String("dotgithubindexer_linux_amd64.tar.gz.sha256")},
+	}
+
+	asset := selectChecksumAsset(assets, "dotgithubindexer_linux_amd64.tar.gz")
+	if asset == nil || asset.GetName() != "dotgithubindexer_linux_amd64.tar.gz.sha256" {
+		t.Fatalf("unexpected checksum asset selected: %v", asset)
+	}
+	if selectChecksumAsset(assets, "dotgithubindexer_darwin_arm64.tar.gz") != nil {
+		t.Fatal("expected no checksum asset for another asset")
+	}
+}
+
+func TestVerifyReleaseChecksum(t *testing.T) {
+	t.Parallel()
+
+	data := []byte("release binary")
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+
+	for _, checksumFile := range []string{digest, digest + "  dotgithubindexer_linux_amd64.tar.gz\n"} {
+		if err := verifyReleaseChecksum("asset", data, []byte(checksumFile)); err != nil {
+			t.Fatalf("verifyReleaseChecksum(%q) returned error: %v", checksumFile, err)
+		}
+	}
+
+	for _, checksumFile := range []string{"", "not-a-digest", strings.Repeat("0", 64)} {
+		if err := verifyReleaseChecksum("asset", data, []byte(checksumFile)); err == nil {
+			t.Fatalf("expected verifyReleaseChecksum(%q) to fail", checksumFile)
+		}
+	}
+}
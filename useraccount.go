@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v50/github"
+)
+
+// ------------------------
+// Section: User Accounts
+// ------------------------
+
+// fetchUserRepositories lists the repositories owned by a personal account, for -user, since the
+// organization listing fails for user namespaces. Only the account itself can list its private
+// repositories, so they are listed when the token belongs to the account; otherwise only public
+// repositories are returned. Repositories are filtered by the same visibility options and filter as
+// fetchRepositories.
+func fetchUserRepositories(client *github.Client, user string, includePub, includePrv bool, filter *RepositoryFilter) ([]*github.Repository, error) {
+	ctx := context.Background()
+	authenticated, _, err := client.Users.Get(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the user the token belongs to: %v", err)
+	}
+
+	// The two listings take different options; GitHub rejects affiliation combined with type
+	owner := user
+	opt := &github.RepositoryListOptions{Type: "owner", ListOptions: github.ListOptions{PerPage: 100}}
+	if strings.EqualFold(authenticated.GetLogin(), user) {
+		owner = ""
+		opt = &github.RepositoryListOptions{Affiliation: "owner", ListOptions: github.ListOptions{PerPage: 100}}
+	} else if includePrv {
+		logWarnf("Only public repositories of '%s' can be listed, since the token belongs to '%s'", user, authenticated.GetLogin())
+	}
+
+	var allRepos []*github.Repository
+	for {
+		repos, resp, err := client.Repositories.List(ctx, owner, opt)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, repo := range repos {
+			if includeRepository(repo, includePub, includePrv, filter) {
+				allRepos = append(allRepos, repo)
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+
+		// Handle rate limiting
+		if _, err := checkRateLimit(client); err != nil {
+			return nil, err
+		}
+	}
+
+	logInfof("Found %d repositories owned by user '%s'", len(allRepos), user)
+	return allRepos, nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v50/github"
+)
+
+func TestFetchUserRepositories(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user":
+			w.Write([]byte(`{"login":"Example-User"}`))
+		case "/user/repos":
+			if r.URL.Query().Get("affiliation") != "owner" || r.URL.Query().Get("type") != "" {
+				t.Errorf("unexpected query %s", r.URL.RawQuery)
+			}
+			if r.URL.Query().Get("page") == "2" {
+				w.Write([]byte(`[{"name":"dotfiles","visibility":"private"},{"name":"old-blog","visibility":"public","archived":true}]`))
+				return
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<%s/user/repos?affiliation=owner&page=2>; rel="next"`, "http://"+r.Host))
+			w.Write([]byte(`[{"name":"site","visibility":"public"}]`))
+		case "/users/other-user/repos":
+			if r.URL.Query().Get("type") != "owner" {
+				t.Errorf("unexpected query %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`[{"name":"tool","visibility":"public"}]`))
+		case "/rate_limit":
+			w.Write([]byte(`{"resources":{"core":{"limit":5000,"remaining":5000,"reset":0}}}`))
+		default:
+			http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	// The token's own account lists its private repositories too
+	repos, err := fetchUserRepositories(client, "example-user", true, true, nil)
+	if err != nil {
+		t.Fatalf("fetchUserRepositories returned error: %v", err)
+	}
+	var names []string
+	for _, repo := range repos {
+		names = append(names, repo.GetName())
+	}
+	if strings.Join(names, ",") != "site,dotfiles" {
+		t.Fatalf("expected site and dotfiles, got %v", names)
+	}
+
+	repos, err = fetchUserRepositories(client, "other-user", true, true, nil)
+	if err != nil {
+		t.Fatalf("fetchUserRepositories returned error: %v", err)
+	}
+	if len(repos) != 1 || repos[0].GetName() != "tool" {
+		t.Fatalf("expected only tool, got %v", repos)
+	}
+}
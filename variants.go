@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: File Name Variants
+// ------------------------
+
+// dependabotLogicalPath is the path dependabot configs are indexed under, whatever their extension.
+const dependabotLogicalPath = ".github/dependabot.yml"
+
+// dependabotConfigPaths lists the accepted dependabot config file names in the order they are tried.
+// JSON is a subset of YAML, so JSON configs are parsed the same way.
+var dependabotConfigPaths = []string{".github/dependabot.yml", ".github/dependabot.yaml", ".github/dependabot.json"}
+
+// workflowIdentity returns the logical name a workflow file is indexed under, so that
+// equivalent names such as build.yml and build.yaml share a single directory.
+func workflowIdentity(fileName string) string {
+	ext := filepath.Ext(fileName)
+	if strings.EqualFold(ext, ".yaml") || strings.EqualFold(ext, ".yml") {
+		return strings.TrimSuffix(fileName, ext) + ".yml"
+	}
+	return fileName
+}
+
+// recordFilename stores the original file name of a repository's file when it differs from the logical name.
+func recordFilename(index *ActionIndex, repoName, logicalName, fileName string) {
+	if fileName == "" || fileName == logicalName {
+		delete(index.Filenames, repoName)
+		return
+	}
+	if index.Filenames == nil {
+		index.Filenames = make(map[string]string)
+	}
+	index.Filenames[repoName] = fileName
+}
+
+// fileName returns the original file name of a repository's file, defaulting to the logical name.
+func (index ActionIndex) fileName(repoName, logicalName string) string {
+	if name, ok := index.Filenames[repoName]; ok {
+		return name
+	}
+	return logicalName
+}
+
+// mergeWorkflowVariants folds workflow directories created for a variant file name, such as build.yaml,
+// into the directory of their logical name. Repositories already present under the logical name are
+// left in place, and the variant directory is removed once it is empty.
+func mergeWorkflowVariants(dbPath string) error {
+	actionsPath := filepath.Join(dbPath, "workflows")
+	dirs, err := os.ReadDir(actionsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, dir := range dirs {
+		variant := dir.Name()
+		logical := workflowIdentity(variant)
+		if !dir.IsDir() || logical == variant {
+			continue
+		}
+
+		variantIndex, err := readActionIndex(filepath.Join(actionsPath, variant, "index.yaml"))
+		if err != nil {
+			logErrorf("Error reading index for workflow '%s': %v", variant, err)
+			continue
+		}
+		logicalIndex, err := readActionIndex(filepath.Join(actionsPath, logical, "index.yaml"))
+		if err != nil {
+			logErrorf("Error reading index for workflow '%s': %v", logical, err)
+			continue
+		}
+		if err := os.MkdirAll(versionsDir(filepath.Join(actionsPath, logical)), os.ModePerm); err != nil {
+			return err
+		}
+
+		var moved []string
+		for repoName, hash := range variantIndex.Repositories {
+			if _, exists := logicalIndex.Repositories[repoName]; exists {
+				continue
+			}
+			target := versionPath(filepath.Join(actionsPath, logical), hash)
+			if _, err := os.Stat(target); os.IsNotExist(err) {
+				content, err := os.ReadFile(versionPath(filepath.Join(actionsPath, variant), hash))
+				if err != nil {
+					logErrorf("Error reading version '%s' of workflow '%s': %v", hash, variant, err)
+					continue
+				}
+				if err := os.WriteFile(target, content, 0644); err != nil {
+					return err
+				}
+			}
+			logicalIndex.Repositories[repoName] = hash
+			recordFilename(logicalIndex, repoName, logical, variantIndex.fileName(repoName, variant))
+			recordBlobSHA(logicalIndex, hash, variantIndex.Blobs[hash])
+			copyEncodedBlobs(logicalIndex, variantIndex, hash)
+			recordAnnotations(logicalIndex, repoName, variantIndex.Annotations[repoName])
+			recordWorkflowSource(logicalIndex, repoName, variantIndex.source(repoName))
+			moved = append(moved, repoName)
+		}
+		if len(moved) == 0 {
+			continue
+		}
+
+		if err := writeActionIndex(filepath.Join(actionsPath, logical, "index.yaml"), logicalIndex); err != nil {
+			return err
+		}
+		if err := mergeChangeLogs(filepath.Join(actionsPath, variant, "changelog.yaml"), filepath.Join(actionsPath, logical, "changelog.yaml"), moved); err != nil {
+			return err
+		}
+
+		for _, repoName := range moved {
+			delete(variantIndex.Repositories, repoName)
+			delete(variantIndex.Filenames, repoName)
+			delete(variantIndex.Annotations, repoName)
+			delete(variantIndex.Symlinks, repoName)
+			delete(variantIndex.Reasons, repoName)
+			delete(variantIndex.Encodings, repoName)
+			delete(variantIndex.Directories, repoName)
+		}
+		if len(variantIndex.Repositories) == 0 {
+			if err := os.RemoveAll(filepath.Join(actionsPath, variant)); err != nil {
+				return err
+			}
+		} else {
+			recordBlobSHA(variantIndex, "", "")
+			if err := writeActionIndex(filepath.Join(actionsPath, variant, "index.yaml"), variantIndex); err != nil {
+				return err
+			}
+		}
+		logInfof("Merged %d repositories from workflow '%s' into '%s'", len(moved), variant, logical)
+	}
+
+	return nil
+}
+
+// readActionIndex reads an index.yaml file, returning an empty index if it does not exist.
+func readActionIndex(indexPath string) (*ActionIndex, error) {
+	index := &ActionIndex{}
+	data, err := os.ReadFile(indexPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err := yaml.Unmarshal(data, index); err != nil {
+			return nil, err
+		}
+	}
+	if index.Repositories == nil {
+		index.Repositories = make(map[string]string)
+	}
+	return index, nil
+}
+
+// writeActionIndex writes an index.yaml file.
+func writeActionIndex(indexPath string, index *ActionIndex) error {
+	data, err := yaml.Marshal(index)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(indexPath, data, 0644)
+}
+
+// mergeChangeLogs appends the changes of the given repositories from one changelog.yaml to another, keeping them in date order.
+func mergeChangeLogs(fromPath, toPath string, repoNames []string) error {
+	data, err := os.ReadFile(fromPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var from ActionChangeLog
+	if err := yaml.Unmarshal(data, &from); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", fromPath, err)
+	}
+
+	var to ActionChangeLog
+	if data, err := os.ReadFile(toPath); err == nil {
+		if err := yaml.Unmarshal(data, &to); err != nil {
+			return fmt.Errorf("failed to parse %s: %v", toPath, err)
+		}
+	}
+
+	moved := make(map[string]bool)
+	for _, repoName := range repoNames {
+		moved[repoName] = true
+	}
+	for _, change := range from.Changes {
+		if moved[change.Repository] {
+			to.Changes = append(to.Changes, change)
+		}
+	}
+	sort.SliceStable(to.Changes, func(i, j int) bool {
+		return to.Changes[i].Date < to.Changes[j].Date
+	})
+
+	out, err := yaml.Marshal(&to)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(toPath, out, 0644)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWorkflowIdentity(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"build.yml":   "build.yml",
+		"build.yaml":  "build.yml",
+		"Build.YAML":  "Build.yml",
+		"release.yml": "release.yml",
+		"README.md":   "README.md",
+	}
+	for fileName, want := range cases {
+		if got := workflowIdentity(fileName); got != want {
+			t.Fatalf("workflowIdentity(%q) = %q, want %q", fileName, got, want)
+		}
+	}
+}
+
+func TestUpdateActionIndexRecordsFilename(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	if err := updateActionIndex(dbPath, "build.yml", "repo-a", "build.yaml", "hash-one", ""); err != nil {
+		t.Fatalf("updateActionIndex returned error: %v", err)
+	}
+	if err := updateActionIndex(dbPath, "build.yml", "repo-b", "build.yml", "hash-one", ""); err != nil {
+		t.Fatalf("updateActionIndex returned error: %v", err)
+	}
+
+	index, err := readActionIndex(filepath.Join(dbPath, "workflows", "build.yml", "index.yaml"))
+	if err != nil {
+		t.Fatalf("readActionIndex returned error: %v", err)
+	}
+	if index.fileName("repo-a", "build.yml") != "build.yaml" || index.fileName("repo-b", "build.yml") != "build.yml" {
+		t.Fatalf("unexpected file names: %+v", index.Filenames)
+	}
+
+	// Renaming the file back to the logical name drops the override
+	if err := updateActionIndex(dbPath, "build.yml", "repo-a", "build.yml", "hash-one", ""); err != nil {
+		t.Fatalf("updateActionIndex returned error: %v", err)
+	}
+	index, err = readActionIndex(filepath.Join(dbPath, "workflows", "build.yml", "index.yaml"))
+	if err != nil {
+		t.Fatalf("readActionIndex returned error: %v", err)
+	}
+	if len(index.Filenames) != 0 {
+		t.Fatalf("expected no file name overrides, got %+v", index.Filenames)
+	}
+}
+
+func TestMergeWorkflowVariants(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	if err := updateActionIndex(dbPath, "build.yml", "repo-a", "build.yml", "hash-one", ""); err != nil {
+		t.Fatalf("updateActionIndex returned error: %v", err)
+	}
+	if err := storeActionVersion(dbPath, "build.yml", "hash-one", "one"); err != nil {
+		t.Fatalf("storeActionVersion returned error: %v", err)
+	}
+	// A database created before variants were normalized has a separate build.yaml directory
+	if err := updateActionIndex(dbPath, "build.yaml", "repo-b", "build.yaml", "hash-two", "blob-two"); err != nil {
+		t.Fatalf("updateActionIndex returned error: %v", err)
+	}
+	if err := storeActionVersion(dbPath, "build.yaml", "hash-two", "two"); err != nil {
+		t.Fatalf("storeActionVersion returned error: %v", err)
+	}
+	if err := recordActionChange(dbPath, "build.yaml", "repo-b", "", "hash-two", "two", true); err != nil {
+		t.Fatalf("recordActionChange returned error: %v", err)
+	}
+
+	if err := mergeWorkflowVariants(dbPath); err != nil {
+		t.Fatalf("mergeWorkflowVariants returned error: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dbPath, "workflows", "build.yaml")); !os.IsNotExist(err) {
+		t.Fatalf("expected the build.yaml directory to be removed, got %v", err)
+	}
+	index, err := readActionIndex(filepath.Join(dbPath, "workflows", "build.yml", "index.yaml"))
+	if err != nil {
+		t.Fatalf("readActionIndex returned error: %v", err)
+	}
+	if index.Repositories["repo-b"] != "hash-two" || index.fileName("repo-b", "build.yml") != "build.yaml" || index.Blobs["hash-two"] != "blob-two" {
+		t.Fatalf("unexpected merged index: %+v", index)
+	}
+	if content, err := os.ReadFile(filepath.Join(dbPath, "workflows", "build.yml", "hash-two")); err != nil || string(content) != "two" {
+		t.Fatalf("expected merged version content, got %q, %v", content, err)
+	}
+	changelog, err := os.ReadFile(filepath.Join(dbPath, "workflows", "build.yml", "changelog.yaml"))
+	if err != nil || !strings.Contains(string(changelog), "repo-b") {
+		t.Fatalf("expected merged changelog, got %q, %v", changelog, err)
+	}
+
+	var visited []string
+	if err := walkIndexedWorkflows(dbPath, func(fileName, repoName, content string) {
+		visited = append(visited, repoName+"/"+fileName)
+	}); err != nil {
+		t.Fatalf("walkIndexedWorkflows returned error: %v", err)
+	}
+	if strings.Join(visited, ",") != "repo-a/build.yml,repo-b/build.yaml" {
+		t.Fatalf("unexpected walked workflows: %v", visited)
+	}
+}
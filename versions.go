@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Version History
+// ------------------------
+
+// VersionDates records when a workflow version was first and last used by any repository.
+type VersionDates struct {
+	FirstSeen string `yaml:"first_seen,omitempty"` // Empty when the version predates tracking and has no change log entry
+	LastSeen  string `yaml:"last_seen,omitempty"`  // Empty while any repository uses the version
+}
+
+// refreshVersionDates brings the version dates of an index up to date after its repositories changed.
+// Versions in use get an entry and no last-seen date; versions that just went out of use are marked
+// as last seen today. firstSeen supplies the first-seen date of versions without an entry.
+func refreshVersionDates(index *ActionIndex, today string, firstSeen func(hash string) string) {
+	if index.Versions == nil {
+		index.Versions = make(map[string]VersionDates)
+	}
+
+	inUse := make(map[string]bool)
+	for _, hash := range index.Repositories {
+		inUse[hash] = true
+	}
+
+	for hash := range inUse {
+		dates, ok := index.Versions[hash]
+		if !ok {
+			dates.FirstSeen = firstSeen(hash)
+		}
+		dates.LastSeen = ""
+		index.Versions[hash] = dates
+	}
+	for hash, dates := range index.Versions {
+		if !inUse[hash] && dates.LastSeen == "" {
+			dates.LastSeen = today
+			index.Versions[hash] = dates
+		}
+	}
+}
+
+// changeLogFirstSeen returns a function giving the earliest change log date on which a repository
+// moved to each version, or an empty string when the change log has none.
+func changeLogFirstSeen(actionPath string) func(hash string) string {
+	var changeLog ActionChangeLog
+	if data, err := os.ReadFile(filepath.Join(actionPath, "changelog.yaml")); err == nil {
+		if err := yaml.Unmarshal(data, &changeLog); err != nil {
+			logErrorf("Error parsing changelog.yaml in '%s': %v", actionPath, err)
+		}
+	}
+	return func(hash string) string {
+		earliest := ""
+		for _, change := range changeLog.Changes {
+			if change.To == hash && (earliest == "" || change.Date < earliest) {
+				earliest = change.Date
+			}
+		}
+		return earliest
+	}
+}
+
+// recordVersionDates updates the version dates after a repository was mapped to a hash. A version
+// whose file is not stored yet is new and first seen today.
+func recordVersionDates(index *ActionIndex, dbPath, actionName, hash string, now time.Time) {
+	today := formatReportDate(now)
+	if _, ok := index.Versions[hash]; !ok && !actionVersionExists(dbPath, actionName, hash) {
+		if index.Versions == nil {
+			index.Versions = make(map[string]VersionDates)
+		}
+		index.Versions[hash] = VersionDates{FirstSeen: today}
+	}
+	refreshVersionDates(index, today, changeLogFirstSeen(filepath.Join(dbPath, "workflows", actionName)))
+}
+
+// sortVersionsChronologically orders hashes by first-seen date, oldest first. Versions without
+// a first-seen date predate tracking and come first; ties are ordered by hash.
+func sortVersionsChronologically(hashes []string, versions map[string]VersionDates) {
+	sort.Slice(hashes, func(i, j int) bool {
+		a, b := versions[hashes[i]].FirstSeen, versions[hashes[j]].FirstSeen
+		if a != b {
+			return a < b
+		}
+		return hashes[i] < hashes[j]
+	})
+}
+
+// formatHistoricalVersions renders the versions no repository uses anymore as a table, oldest first.
+func formatHistoricalVersions(index ActionIndex) string {
+	inUse := make(map[string]bool)
+	for _, hash := range index.Repositories {
+		inUse[hash] = true
+	}
+	var hashes []string
+	for hash := range index.Versions {
+		if !inUse[hash] {
+			hashes = append(hashes, hash)
+		}
+	}
+	if len(hashes) == 0 {
+		return ""
+	}
+	sortVersionsChronologically(hashes, index.Versions)
+
+	var builder strings.Builder
+	builder.WriteString("## Historical Versions\n\n")
+	builder.WriteString("These versions are no longer used by any repository.\n\n")
+	builder.WriteString("| Version | First Seen | Last Seen |\n")
+	builder.WriteString("|---------|------------|-----------|\n")
+	for _, hash := range hashes {
+		dates := index.Versions[hash]
+		builder.WriteString(fmt.Sprintf("| `%s` | %s | %s |\n", shortHash(hash), dateOrUnknown(dates.FirstSeen), dateOrUnknown(dates.LastSeen)))
+	}
+	builder.WriteString("\n")
+	return builder.String()
+}
+
+// dateOrUnknown returns a date for display, or "unknown" when it was not recorded.
+func dateOrUnknown(date string) string {
+	if date == "" {
+		return "unknown"
+	}
+	return date
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRefreshVersionDates(t *testing.T) {
+	t.Parallel()
+
+	index := &ActionIndex{
+		Repositories: map[string]string{"repo-a": "hash-two", "repo-b": "hash-three", "repo-c": "hash-four"},
+		Versions: map[string]VersionDates{
+			"hash-one":   {FirstSeen: "2026-01-01"},
+			"hash-two":   {FirstSeen: "2026-02-01"},
+			"hash-three": {FirstSeen: "2026-01-15", LastSeen: "2026-02-10"},
+			"hash-old":   {FirstSeen: "2025-06-01", LastSeen: "2025-12-01"},
+		},
+	}
+
+	refreshVersionDates(index, "2026-03-01", func(hash string) string {
+		if hash == "hash-four" {
+			return "2025-11-20"
+		}
+		return ""
+	})
+
+	want := map[string]VersionDates{
+		"hash-one":   {FirstSeen: "2026-01-01", LastSeen: "2026-03-01"},
+		"hash-two":   {FirstSeen: "2026-02-01"},
+		"hash-three": {FirstSeen: "2026-01-15"},
+		"hash-four":  {FirstSeen: "2025-11-20"},
+		"hash-old":   {FirstSeen: "2025-06-01", LastSeen: "2025-12-01"},
+	}
+	for hash, dates := range want {
+		if index.Versions[hash] != dates {
+			t.Fatalf("version %s = %+v, want %+v", hash, index.Versions[hash], dates)
+		}
+	}
+}
+
+func TestGenerateReadmeFilesShowsVersionHistory(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	actionPath := filepath.Join(dbPath, "workflows", "build.yml")
+	if err := os.MkdirAll(actionPath, 0755); err != nil {
+		t.Fatalf("failed to create workflow folder: %v", err)
+	}
+	index := &ActionIndex{
+		Repositories: map[string]string{"repo-a": "hash-new", "repo-b": "hash-mid", "repo-c": "hash-mid"},
+		Versions: map[string]VersionDates{
+			"hash-new": {FirstSeen: "2026-03-01"},
+			"hash-mid": {FirstSeen: "2026-01-01"},
+			"hash-old": {FirstSeen: "2025-06-01", LastSeen: "2026-01-05"},
+		},
+	}
+	if err := writeActionIndex(filepath.Join(actionPath, "index.yaml"), index); err != nil {
+		t.Fatalf("writeActionIndex returned error: %v", err)
+	}
+	for _, hash := range []string{"hash-new", "hash-mid"} {
+		if err := storeActionVersion(dbPath, "build.yml", hash, "on: push\n"); err != nil {
+			t.Fatalf("storeActionVersion returned error: %v", err)
+		}
+	}
+
+	if err := generateReadmeFiles(dbPath, "acme"); err != nil {
+		t.Fatalf("generateReadmeFiles returned error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(actionPath, "README.md"))
+	if err != nil {
+		t.Fatalf("failed to read README.md: %v", err)
+	}
+	content := string(data)
+
+	mid := strings.Index(content, "## [hash-mid](hash-mid)\n\n**Current** · First seen 2026-01-01")
+	latest := strings.Index(content, "## [hash-new](hash-new)\n\n**Current** · First seen 2026-03-01")
+	if mid < 0 || latest < 0 || mid > latest {
+		t.Fatalf("expected current versions in chronological order:\n%s", content)
+	}
+	if !strings.Contains(content, "## Historical Versions") || !strings.Contains(content, "| `hash-old` | 2025-06-01 | 2026-01-05 |") {
+		t.Fatalf("expected historical version table:\n%s", content)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-github/v50/github"
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: Empty and Symlinked Workflows
+// ------------------------
+
+// defaultWorkflowDir is the directory workflow files are read from unless -paths or the repository says otherwise.
+const defaultWorkflowDir = ".github/workflows"
+
+// emptyWorkflowHash is recorded for a workflow file whose content could not be indexed, such as an empty
+// file; its stored version is empty, and the index's reasons section says why.
+var emptyWorkflowHash = strings.Repeat("0", 64)
+
+const (
+	// emptyWorkflowReason is the reason recorded for an empty workflow file.
+	emptyWorkflowReason = "empty file"
+	// symlinkFileMode is the Git file mode of a symbolic link.
+	symlinkFileMode = "120000"
+)
+
+// emptyWorkflowFile returns a workflow file recorded under emptyWorkflowHash for the given reason.
+func emptyWorkflowFile(repoName, filePath, symlink, reason string) WorkflowFile {
+	return WorkflowFile{RepoName: repoName, FilePath: filePath, Hash: emptyWorkflowHash, Symlink: symlink, Reason: reason}
+}
+
+// resolveWorkflowSymlink fetches the file a symbolic link in a workflow directory points to. The contents
+// API follows a link to a file in the same repository; a link to a directory, a missing file, or a path
+// outside the repository is recorded under emptyWorkflowHash with the reason it could not be resolved.
+func resolveWorkflowSymlink(client *github.Client, repo *github.Repository, link *github.RepositoryContent) (WorkflowFile, error) {
+	opts := &github.RepositoryContentGetOptions{Ref: getDefaultBranch(repo)}
+	target, _, _, err := client.Repositories.GetContents(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), link.GetPath(), opts)
+	if err != nil {
+		return WorkflowFile{}, err
+	}
+	if target == nil || target.GetType() != "file" {
+		targetPath := link.GetPath()
+		if target != nil && target.GetTarget() != "" {
+			targetPath = target.GetTarget()
+		}
+		logWarnf("Symbolic link '%s' in repository '%s' does not point to a file in the repository", link.GetPath(), repo.GetName())
+		return emptyWorkflowFile(repo.GetName(), link.GetPath(), targetPath, "symbolic link could not be resolved"), nil
+	}
+
+	content, err := fetchBlobContent(client, repo.GetOwner().GetLogin(), repo.GetName(), target.GetSHA())
+	if err != nil {
+		return WorkflowFile{}, withBlobPath(err, target.GetPath())
+	}
+	logDebugf("Resolved symbolic link '%s' in repository '%s' to '%s'", link.GetPath(), repo.GetName(), target.GetPath())
+	return workflowFileFromContent(repo.GetName(), link.GetPath(), content, target.GetSHA(), target.GetPath()), nil
+}
+
+// recordWorkflowSource records the link target, the reason for a missing content, the encoding, and the
+// directory of a repository's workflow in its index, removing them when the file is a regular UTF-8 one
+// with content in .github/workflows.
+func recordWorkflowSource(index *ActionIndex, repoName string, source WorkflowSource) {
+	recordRepositoryValue(&index.Symlinks, repoName, source.Symlink)
+	recordRepositoryValue(&index.Reasons, repoName, source.Reason)
+	recordRepositoryValue(&index.Encodings, repoName, source.Encoding)
+	recordRepositoryValue(&index.Directories, repoName, source.Directory)
+}
+
+// recordRepositoryValue sets a repository's entry of an index section, deleting it for an empty value.
+func recordRepositoryValue(section *map[string]string, repoName, value string) {
+	if value == "" {
+		delete(*section, repoName)
+		return
+	}
+	if *section == nil {
+		*section = make(map[string]string)
+	}
+	(*section)[repoName] = value
+}
+
+// updateWorkflowSource records how a repository's workflow was indexed in its index.yaml.
+func updateWorkflowSource(dbPath, actionName, repoName string, source WorkflowSource) error {
+	indexPath := filepath.Join(dbPath, "workflows", actionName, "index.yaml")
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		return err
+	}
+	var index ActionIndex
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		return err
+	}
+
+	if index.source(repoName) == source {
+		return nil
+	}
+	recordWorkflowSource(&index, repoName, source)
+	return writeActionIndex(indexPath, &index)
+}
+
+// WorkflowSource describes how a repository's workflow file was indexed when it is not a regular file
+// with content in .github/workflows.
+type WorkflowSource struct {
+	Symlink   string // Path the file links to
+	Reason    string // Why the file has no indexed content
+	Encoding  string // Encoding the file is stored in
+	Directory string // Directory the file was read from, such as one given with -paths
+}
+
+// source returns how a workflow file was indexed.
+func (wf WorkflowFile) source() WorkflowSource {
+	return WorkflowSource{Symlink: wf.Symlink, Reason: wf.Reason, Encoding: wf.Encoding, Directory: workflowDirectory(wf.FilePath)}
+}
+
+// source returns how a repository's workflow was indexed according to the index.
+func (index *ActionIndex) source(repoName string) WorkflowSource {
+	return WorkflowSource{Symlink: index.Symlinks[repoName], Reason: index.Reasons[repoName], Encoding: index.Encodings[repoName], Directory: index.Directories[repoName]}
+}
+
+// workflowDirectory returns the directory of a workflow file's repository path, or an empty string for
+// .github/workflows, which the index does not record.
+func workflowDirectory(filePath string) string {
+	dir := path.Dir(filePath)
+	if dir == "." || dir == defaultWorkflowDir {
+		return ""
+	}
+	return dir
+}
+
+// repositoryPath returns the path of a workflow file in its repository.
+func (source WorkflowSource) repositoryPath(fileName string) string {
+	dir := source.Directory
+	if dir == "" {
+		dir = defaultWorkflowDir
+	}
+	return path.Join(dir, fileName)
+}
+
+// loadWorkflowSources returns the symbolic links, the workflows without content, and the encoded workflows
+// of each repository, keyed by repository and file name.
+func loadWorkflowSources(dbPath string) (map[string]map[string]WorkflowSource, error) {
+	actionsPath := filepath.Join(dbPath, "workflows")
+	dirs, err := os.ReadDir(actionsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	sources := make(map[string]map[string]WorkflowSource)
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		actionName := dir.Name()
+		index, err := readActionIndex(filepath.Join(actionsPath, actionName, "index.yaml"))
+		if err != nil {
+			continue
+		}
+		for repoName := range index.Repositories {
+			source := index.source(repoName)
+			if source == (WorkflowSource{}) {
+				continue
+			}
+			if sources[repoName] == nil {
+				sources[repoName] = make(map[string]WorkflowSource)
+			}
+			sources[repoName][index.fileName(repoName, actionName)] = source
+		}
+	}
+	return sources, nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v50/github"
+)
+
+func TestFetchEmptyAndSymlinkedWorkflows(t *testing.T) {
+	t.Parallel()
+
+	build := "name: Build from sources\non: push\njobs: {}\n"
+	shared := "name: Shared CI from a symlink\non: pull_request\njobs: {}\n"
+	sharedSHA := computeBlobSHA([]byte(shared))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/graphql":
+			entry := func(name, text string, mode int) map[string]any {
+				return map[string]any{"name": name, "type": "blob", "mode": mode, "oid": computeBlobSHA([]byte(text)), "object": map[string]any{"text": text, "isTruncated": false}}
+			}
+			entries := []map[string]any{
+				entry("build.yml", build, 33188),
+				entry("empty.yml", "", 33188),
+				entry("ci.yml", "../../ci/shared.yml", 40960),
+				entry("docs.yml", "../../docs", 40960),
+			}
+			json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"repository": map[string]any{"d0": map[string]any{"entries": entries}}}})
+		case "/repos/example-org/repo-a/contents/.github/workflows/ci.yml":
+			// The contents API follows a link to a file and describes the target
+			fmt.Fprintf(w, `{"type":"file","name":"shared.yml","path":"ci/shared.yml","sha":%q}`, sharedSHA)
+		case "/repos/example-org/repo-a/contents/.github/workflows/docs.yml":
+			fmt.Fprint(w, `{"type":"symlink","name":"docs.yml","path":".github/workflows/docs.yml","target":"../../docs"}`)
+		case "/repos/example-org/repo-a/git/blobs/" + sharedSHA:
+			fmt.Fprintf(w, `{"sha":%q,"size":%d,"encoding":"base64","content":%q}`, sharedSHA, len(shared), base64.StdEncoding.EncodeToString([]byte(shared)))
+		default:
+			http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	repo := &github.Repository{Name: github.String("repo-a"), Owner: &github.User{Login: github.String("example-org")}, DefaultBranch: github.String("main")}
+
+	listing, contents, err := listDirectoryTrees(client, repo, "main", []string{".github/workflows"})
+	if err != nil {
+		t.Fatalf("listDirectoryTrees returned error: %v", err)
+	}
+	workflows, err := fetchWorkflowContents(client, repo, listing[".github/workflows"], contents)
+	if err != nil {
+		t.Fatalf("fetchWorkflowContents returned error: %v", err)
+	}
+	var got []string
+	for _, wf := range workflows {
+		got = append(got, fmt.Sprintf("%s hash=%s symlink=%s reason=%s", wf.FilePath, wf.Hash[:8], wf.Symlink, wf.Reason))
+	}
+	want := []string{
+		".github/workflows/build.yml hash=" + computeHash([]byte(build))[:8] + " symlink= reason=",
+		".github/workflows/empty.yml hash=00000000 symlink= reason=empty file",
+		".github/workflows/ci.yml hash=" + computeHash([]byte(shared))[:8] + " symlink=ci/shared.yml reason=",
+		".github/workflows/docs.yml hash=00000000 symlink=../../docs reason=symbolic link could not be resolved",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected workflows:\n%s", strings.Join(got, "\n"))
+	}
+	if workflows[2].Content != shared || workflows[2].BlobSHA != sharedSHA {
+		t.Fatalf("expected the symbolic link to carry the content of its target, got %+v", workflows[2])
+	}
+}
+
+func TestIndexEmptyAndSymlinkedWorkflows(t *testing.T) {
+	t.Parallel()
+
+	dbPath := t.TempDir()
+	if err := initializeDB(dbPath); err != nil {
+		t.Fatalf("initializeDB returned error: %v", err)
+	}
+	content := "name: Linked release\non: push\njobs: {}\n"
+	files := &RepositoryFiles{DefaultBranch: "main", Workflows: []WorkflowFile{
+		emptyWorkflowFile("repo-a", ".github/workflows/empty.yml", "", emptyWorkflowReason),
+		{RepoName: "repo-a", FilePath: ".github/workflows/release.yml", Content: content, Hash: computeHash([]byte(content)), Symlink: "ci/release.yml"},
+	}}
+	usesIndex := &ActionUsesIndex{Actions: make(map[string]map[string][]WorkflowReference)}
+	if _, _, err := indexRepositoryFiles(dbPath, "repo-a", files, usesIndex); err != nil {
+		t.Fatalf("indexRepositoryFiles returned error: %v", err)
+	}
+
+	index, err := readActionIndex(filepath.Join(dbPath, "workflows", "empty.yml", "index.yaml"))
+	if err != nil {
+		t.Fatalf("readActionIndex returned error: %v", err)
+	}
+	if index.Repositories["repo-a"] != emptyWorkflowHash || index.Reasons["repo-a"] != emptyWorkflowReason {
+		t.Fatalf("expected the empty workflow to be recorded with its reason, got %+v", index)
+	}
+
+	// The file is later filled in, so its reason is dropped
+	files.Workflows[0] = WorkflowFile{RepoName: "repo-a", FilePath: ".github/workflows/empty.yml", Content: "on: push\n", Hash: computeHash([]byte("on: push\n"))}
+	if _, _, err := indexRepositoryFiles(dbPath, "repo-a", files, usesIndex); err != nil {
+		t.Fatalf("indexRepositoryFiles returned error: %v", err)
+	}
+	if index, err = readActionIndex(filepath.Join(dbPath, "workflows", "empty.yml", "index.yaml")); err != nil || index.Reasons != nil {
+		t.Fatalf("expected no reasons once the workflow has content, got %+v, %v", index, err)
+	}
+
+	files.Workflows[0] = emptyWorkflowFile("repo-a", ".github/workflows/empty.yml", "", emptyWorkflowReason)
+	if _, _, err := indexRepositoryFiles(dbPath, "repo-a", files, usesIndex); err != nil {
+		t.Fatalf("indexRepositoryFiles returned error: %v", err)
+	}
+	if err := generateRepositoryPages(dbPath, "example-org"); err != nil {
+		t.Fatalf("generateRepositoryPages returned error: %v", err)
+	}
+	page, err := os.ReadFile(filepath.Join(dbPath, "repositories", "repo-a.md"))
+	if err != nil {
+		t.Fatalf("failed to read repository page: %v", err)
+	}
+	for _, want := range []string{"Not indexed: empty file", "Symbolic link to `ci/release.yml`", "badge.svg"} {
+		if !strings.Contains(string(page), want) {
+			t.Fatalf("expected the repository page to contain %q, got:\n%s", want, page)
+		}
+	}
+	if strings.Count(string(page), "badge.svg") != 2 {
+		t.Fatalf("expected a badge only for the linked workflow, got:\n%s", page)
+	}
+}
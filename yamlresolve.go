@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ------------------------
+// Section: YAML Resolution
+// ------------------------
+
+// maxAliasDepth bounds alias expansion so that recursive anchors cannot expand forever.
+const maxAliasDepth = 64
+
+// parseWorkflowDocument parses YAML content and returns the root node with all anchors,
+// aliases, and merge keys expanded so that analyzers see the fully resolved document.
+func parseWorkflowDocument(content string) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}, nil
+	}
+
+	return resolveNode(doc.Content[0], 0)
+}
+
+// decodeWorkflow parses YAML content into a generic map after resolving anchors, aliases, and merge keys.
+func decodeWorkflow(content string) (map[string]any, error) {
+	root, err := parseWorkflowDocument(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var workflow map[string]any
+	if err := root.Decode(&workflow); err != nil {
+		return nil, err
+	}
+	return workflow, nil
+}
+
+// resolveNode returns a copy of node with aliases replaced by their anchored content and merge keys applied.
+func resolveNode(node *yaml.Node, depth int) (*yaml.Node, error) {
+	if depth > maxAliasDepth {
+		return nil, fmt.Errorf("yaml alias expansion exceeded depth %d at line %d", maxAliasDepth, node.Line)
+	}
+
+	switch node.Kind {
+	case yaml.AliasNode:
+		if node.Alias == nil {
+			return nil, fmt.Errorf("unresolved yaml alias '%s' at line %d", node.Value, node.Line)
+		}
+		resolved, err := resolveNode(node.Alias, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		// Keep a comment written next to the alias itself
+		if node.LineComment != "" {
+			resolved.LineComment = node.LineComment
+		}
+		return resolved, nil
+	case yaml.MappingNode:
+		return resolveMapping(node, depth)
+	default:
+		copied := *node
+		copied.Anchor = ""
+		copied.Content = nil
+		for _, child := range node.Content {
+			resolvedChild, err := resolveNode(child, depth)
+			if err != nil {
+				return nil, err
+			}
+			copied.Content = append(copied.Content, resolvedChild)
+		}
+		return &copied, nil
+	}
+}
+
+// resolveMapping expands a mapping node, applying "<<" merge keys. Keys defined
+// explicitly in the mapping take precedence over merged keys, and earlier merge
+// sources take precedence over later ones.
+func resolveMapping(node *yaml.Node, depth int) (*yaml.Node, error) {
+	copied := *node
+	copied.Anchor = ""
+	copied.Content = nil
+
+	var merged []*yaml.Node
+	explicit := make(map[string]bool)
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		if key.Kind == yaml.ScalarNode && (key.Tag == "!!merge" || key.Value == "<<") {
+			resolvedValue, err := resolveNode(value, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			sources := []*yaml.Node{resolvedValue}
+			if resolvedValue.Kind == yaml.SequenceNode {
+				sources = resolvedValue.Content
+			}
+			for _, source := range sources {
+				if source.Kind != yaml.MappingNode {
+					return nil, fmt.Errorf("yaml merge key at line %d must reference a mapping", key.Line)
+				}
+				merged = append(merged, source)
+			}
+			continue
+		}
+
+		resolvedKey, err := resolveNode(key, depth)
+		if err != nil {
+			return nil, err
+		}
+		resolvedValue, err := resolveNode(value, depth)
+		if err != nil {
+			return nil, err
+		}
+		explicit[resolvedKey.Value] = true
+		copied.Content = append(copied.Content, resolvedKey, resolvedValue)
+	}
+
+	for _, source := range merged {
+		for i := 0; i+1 < len(source.Content); i += 2 {
+			key := source.Content[i]
+			if explicit[key.Value] {
+				continue
+			}
+			explicit[key.Value] = true
+			copied.Content = append(copied.Content, source.Content[i], source.Content[i+1])
+		}
+	}
+
+	return &copied, nil
+}
+
+// mappingValue returns the value node for key in a mapping node, or nil if absent.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestExtractActionUsesResolvesAnchors(t *testing.T) {
+	t.Parallel()
+
+	content := `x-defaults: &defaults
+  runs-on: ubuntu-latest
+  steps:
+    - &checkout
+      uses: actions/checkout@de0fac2e4500dabe0009e67214ff5f5447ce83dd # v6.0.2
+    - uses: actions/setup-go@v5
+jobs:
+  build:
+    <<: *defaults
+  test:
+    <<: *defaults
+    steps:
+      - *checkout
+      - uses: actions/cache@v4 # cache deps
+`
+
+	uses := extractActionUses(content, "repo-a", ".github/workflows/build.yml")
+
+	counts := make(map[string]int)
+	for _, use := range uses {
+		counts[use.Action+"@"+use.Version]++
+	}
+
+	want := map[string]int{
+		"actions/checkout@de0fac2e4500dabe0009e67214ff5f5447ce83dd # v6.0.2": 2,
+		"actions/setup-go@v5":           1,
+		"actions/cache@v4 # cache deps": 1,
+	}
+	if len(counts) != len(want) {
+		t.Fatalf("unexpected uses: %v", counts)
+	}
+	for key, n := range want {
+		if counts[key] != n {
+			t.Fatalf("expected %d uses of %q, got %v", n, key, counts)
+		}
+	}
+}
+
+func TestDecodeWorkflowMergeKeyPrecedence(t *testing.T) {
+	t.Parallel()
+
+	content := "base: &base\n  timeout-minutes: 10\n  runs-on: ubuntu-latest\njob:\n  <<: *base\n  timeout-minutes: 5\n"
+
+	workflow, err := decodeWorkflow(content)
+	if err != nil {
+		t.Fatalf("decodeWorkflow returned error: %v", err)
+	}
+
+	job, ok := workflow["job"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected job mapping, got %#v", workflow["job"])
+	}
+	if job["timeout-minutes"] != 5 || job["runs-on"] != "ubuntu-latest" {
+		t.Fatalf("unexpected merged job: %#v", job)
+	}
+}